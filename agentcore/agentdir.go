@@ -0,0 +1,100 @@
+package agentcore
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+)
+
+// agentManifestNames are the file names of agent manifests.
+var agentManifestNames = []string{"agent.yaml", "agent.yml", "agent.json"}
+
+// LoadAgentsFromDir loads the agents of a monorepo with one folder per
+// agent from the agent manifests, agent.yaml, agent.yml or agent.json, in
+// the directory tree under dir. Hidden directories such as .git are
+// skipped.
+//
+// A manifest holds one agent in the format of a config file's agents
+// entries, e.g.
+//
+//	containerImage: ghcr.io/example/research:${env:IMAGE_TAG}
+//	memoryMB: 1024
+//	environment:
+//	  LOG_LEVEL: info
+//	secretsARNs:
+//	  - arn:aws:secretsmanager:us-east-1:123456789012:secret:research
+//
+// The name defaults to the manifest's directory name. Unknown fields are
+// rejected, and ${env:VAR} placeholders are resolved as LoadStackConfig
+// does. Agents are returned in path order.
+func LoadAgentsFromDir(dir string) ([]iac.AgentConfig, error) {
+	var agents []iac.AgentConfig
+	paths := make(map[string]string)
+
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != dir && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !slices.Contains(agentManifestNames, entry.Name()) {
+			return nil
+		}
+
+		agent, err := loadAgentManifest(path)
+		if err != nil {
+			return err
+		}
+		if other, ok := paths[agent.Name]; ok {
+			return fmt.Errorf("agent %s is defined by both %s and %s", agent.Name, other, path)
+		}
+		paths[agent.Name] = path
+		agents = append(agents, agent)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(agents) == 0 {
+		return nil, fmt.Errorf("no agent manifests found in %s", dir)
+	}
+	return agents, nil
+}
+
+// loadAgentManifest loads an agent manifest.
+func loadAgentManifest(path string) (iac.AgentConfig, error) {
+	var agent iac.AgentConfig
+
+	doc, err := readConfigDocument(path)
+	if err != nil {
+		return agent, err
+	}
+	resolved, err := resolvePlaceholders(nil, doc)
+	if err != nil {
+		return agent, fmt.Errorf("%s: %w", path, err)
+	}
+	data, err := json.Marshal(resolved)
+	if err != nil {
+		return agent, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&agent); err != nil {
+		return agent, fmt.Errorf("%s: %w", path, err)
+	}
+	if agent.Name == "" {
+		agent.Name = filepath.Base(filepath.Dir(path))
+	}
+	return agent, nil
+}
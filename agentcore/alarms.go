@@ -0,0 +1,186 @@
+package agentcore
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/cloudwatch"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// runtimeMetricNamespace is the namespace of the metrics AgentCore
+// publishes for runtime invocations.
+const runtimeMetricNamespace = "Bedrock-AgentCore"
+
+// AlarmsConfig creates CloudWatch alarms on the invocation metrics AgentCore
+// publishes for each agent runtime: errors, p95 latency and throttles.
+// Agents with replicas get alarms per replica.
+type AlarmsConfig struct {
+	// Agents are the agents to alarm on. If empty, all agents are covered.
+	Agents []string `json:"agents,omitempty" yaml:"agents,omitempty"`
+
+	// ErrorThreshold is the number of system errors per period that
+	// triggers the error alarm.
+	// Default: 5
+	ErrorThreshold int `json:"errorThreshold,omitempty" yaml:"errorThreshold,omitempty"`
+
+	// LatencyP95Ms is the p95 invocation latency in milliseconds that
+	// triggers the latency alarm.
+	// Default: 30000
+	LatencyP95Ms int `json:"latencyP95Ms,omitempty" yaml:"latencyP95Ms,omitempty"`
+
+	// ThrottleThreshold is the number of throttled invocations per period
+	// that triggers the throttle alarm.
+	// Default: 1
+	ThrottleThreshold int `json:"throttleThreshold,omitempty" yaml:"throttleThreshold,omitempty"`
+
+	// PeriodSeconds is the alarm evaluation period. Must be a multiple of 60.
+	// Default: 300
+	PeriodSeconds int `json:"periodSeconds,omitempty" yaml:"periodSeconds,omitempty"`
+
+	// EvaluationPeriods is the number of consecutive breaching periods
+	// before an alarm fires.
+	// Default: 1
+	EvaluationPeriods int `json:"evaluationPeriods,omitempty" yaml:"evaluationPeriods,omitempty"`
+
+	// TopicARN is an existing SNS topic for notifications. If empty, one is created.
+	TopicARN string `json:"topicARN,omitempty" yaml:"topicARN,omitempty"`
+
+	// Recipients are email addresses subscribed to a created topic.
+	Recipients []string `json:"recipients,omitempty" yaml:"recipients,omitempty"`
+}
+
+// DefaultAlarmsConfig returns an AlarmsConfig with sensible defaults.
+func DefaultAlarmsConfig() *AlarmsConfig {
+	c := &AlarmsConfig{}
+	c.ApplyDefaults()
+	return c
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *AlarmsConfig) ApplyDefaults() {
+	if c.ErrorThreshold == 0 {
+		c.ErrorThreshold = 5
+	}
+	if c.LatencyP95Ms == 0 {
+		c.LatencyP95Ms = 30000
+	}
+	if c.ThrottleThreshold == 0 {
+		c.ThrottleThreshold = 1
+	}
+	if c.PeriodSeconds == 0 {
+		c.PeriodSeconds = 300
+	}
+	if c.EvaluationPeriods == 0 {
+		c.EvaluationPeriods = 1
+	}
+}
+
+// Validate validates the AlarmsConfig.
+func (c *AlarmsConfig) Validate(config iac.StackConfig) error {
+	if err := validateAgentNames("alarms.agents", c.Agents, config); err != nil {
+		return err
+	}
+	if c.ErrorThreshold < 1 {
+		return fmt.Errorf("alarms.errorThreshold must be at least 1")
+	}
+	if c.LatencyP95Ms < 1 {
+		return fmt.Errorf("alarms.latencyP95Ms must be at least 1")
+	}
+	if c.ThrottleThreshold < 1 {
+		return fmt.Errorf("alarms.throttleThreshold must be at least 1")
+	}
+	if c.PeriodSeconds < 60 || c.PeriodSeconds%60 != 0 {
+		return fmt.Errorf("alarms.periodSeconds must be a multiple of 60")
+	}
+	if c.EvaluationPeriods < 1 {
+		return fmt.Errorf("alarms.evaluationPeriods must be at least 1")
+	}
+	if c.TopicARN != "" && !strings.HasPrefix(c.TopicARN, "arn:") {
+		return fmt.Errorf("alarms.topicARN: '%s' is not an ARN", c.TopicARN)
+	}
+	if c.TopicARN != "" && len(c.Recipients) > 0 {
+		return fmt.Errorf("alarms.recipients can only be set when the topic is created")
+	}
+	for _, email := range c.Recipients {
+		if !strings.Contains(email, "@") {
+			return fmt.Errorf("alarms.recipients: '%s' is not an email address", email)
+		}
+	}
+	return nil
+}
+
+// runtimeAlarm describes an alarm on an AgentCore runtime metric.
+type runtimeAlarm struct {
+	name        string
+	description string
+	metric      string
+	statistic   string
+	threshold   int
+}
+
+// createAlarms creates the runtime alarms of the selected agents. It runs
+// after createAgentRuntimes.
+func (s *AgentCoreStack) createAlarms(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	stackName := s.Config.StackName
+	cfg := s.Options.Alarms
+
+	topic := pulumi.String(cfg.TopicARN).ToStringOutput()
+	if cfg.TopicARN == "" {
+		created, err := s.newEventTopic(ctx, "alarms", fmt.Sprintf("%s-alarms", stackName), tags)
+		if err != nil {
+			return err
+		}
+		if err := s.subscribeEmails(ctx, "alarms-recipient", created.Arn, cfg.Recipients); err != nil {
+			return err
+		}
+		topic = created.Arn
+	}
+	s.AlarmTopic = topic
+
+	alarms := []runtimeAlarm{
+		{"errors", "system errors", "SystemErrors", "Sum", cfg.ErrorThreshold},
+		{"latency", "p95 latency", "Latency", "p95", cfg.LatencyP95Ms},
+		{"throttles", "throttled invocations", "Throttles", "Sum", cfg.ThrottleThreshold},
+	}
+	for _, agentName := range s.selectedAgents(cfg.Agents) {
+		for _, name := range s.agentRuntimeNames(agentName) {
+			runtime := s.AgentRuntimes[name]
+			dimensions := pulumi.StringMap{
+				"Operation": pulumi.String("InvokeAgentRuntime"),
+				"Resource":  runtime.RuntimeArn,
+				"Name":      pulumi.Sprintf("%s::%s", runtime.Name, runtimeEndpointName),
+			}
+			for _, a := range alarms {
+				alarmName := fmt.Sprintf("%s-%s-%s", stackName, name, a.name)
+				args := &cloudwatch.MetricAlarmArgs{
+					Name:               pulumi.String(alarmName),
+					AlarmDescription:   pulumi.Sprintf("Agent %s %s are above threshold", name, a.description),
+					Namespace:          pulumi.String(runtimeMetricNamespace),
+					MetricName:         pulumi.String(a.metric),
+					Dimensions:         dimensions,
+					Period:             pulumi.Int(cfg.PeriodSeconds),
+					EvaluationPeriods:  pulumi.Int(cfg.EvaluationPeriods),
+					Threshold:          pulumi.Float64(float64(a.threshold)),
+					ComparisonOperator: pulumi.String("GreaterThanOrEqualToThreshold"),
+					TreatMissingData:   pulumi.String("notBreaching"),
+					AlarmActions:       pulumi.Array{topic},
+					OkActions:          pulumi.Array{topic},
+					Tags:               mergeTags(s.agentTags(agentName, tags), pulumi.String(alarmName)),
+				}
+				if strings.HasPrefix(a.statistic, "p") {
+					args.ExtendedStatistic = pulumi.String(a.statistic)
+				} else {
+					args.Statistic = pulumi.String(a.statistic)
+				}
+				_, err := cloudwatch.NewMetricAlarm(ctx, s.ResourceName(fmt.Sprintf("%s-%s-alarm", name, a.name)), args, s.child())
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
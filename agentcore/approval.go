@@ -0,0 +1,319 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/apigatewayv2"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/sfn"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/sns"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/sqs"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// ApprovalConfig configures a human-in-the-loop approval step for sensitive
+// agent actions.
+//
+// Agents start an execution of the approval state machine with the action
+// to approve. Approvers are notified by SNS, pending requests are queued in
+// SQS with their task token, and decisions are posted to the approval API
+// (POST /approve or /reject with {"taskToken": ..., "output": ...}).
+type ApprovalConfig struct {
+	// Approvers are email addresses notified of new approval requests.
+	Approvers []string `json:"approvers,omitempty" yaml:"approvers,omitempty"`
+
+	// TimeoutSeconds is how long a request waits for a decision before escalating.
+	// Default: 3600
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty" yaml:"timeoutSeconds,omitempty"`
+
+	// EscalationEmails are notified when a request times out.
+	// If empty, approvers are re-notified.
+	EscalationEmails []string `json:"escalationEmails,omitempty" yaml:"escalationEmails,omitempty"`
+
+	// EscalationTimeoutSeconds is how long an escalated request waits
+	// before it is rejected. If 0, requests are rejected on escalation.
+	EscalationTimeoutSeconds int `json:"escalationTimeoutSeconds,omitempty" yaml:"escalationTimeoutSeconds,omitempty"`
+
+	// Agents is the list of agent names that request approvals.
+	// If empty, all agents in the stack are included.
+	Agents []string `json:"agents,omitempty" yaml:"agents,omitempty"`
+}
+
+// DefaultApprovalConfig returns an ApprovalConfig with sensible defaults.
+func DefaultApprovalConfig() *ApprovalConfig {
+	return &ApprovalConfig{
+		TimeoutSeconds: 3600,
+	}
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *ApprovalConfig) ApplyDefaults() {
+	if c.TimeoutSeconds == 0 {
+		c.TimeoutSeconds = 3600
+	}
+}
+
+// Validate validates the ApprovalConfig against the stack configuration.
+func (c *ApprovalConfig) Validate(config iac.StackConfig) error {
+	// Task tokens expire after a year
+	const maxWait = 365 * 24 * 60 * 60
+	if c.TimeoutSeconds < 60 || c.TimeoutSeconds > maxWait {
+		return fmt.Errorf("approval.timeoutSeconds must be between 60 and %d", maxWait)
+	}
+	if c.EscalationTimeoutSeconds < 0 || c.EscalationTimeoutSeconds > maxWait {
+		return fmt.Errorf("approval.escalationTimeoutSeconds must be between 0 and %d", maxWait)
+	}
+	for _, email := range append(append([]string{}, c.Approvers...), c.EscalationEmails...) {
+		if !strings.Contains(email, "@") {
+			return fmt.Errorf("approval: '%s' is not an email address", email)
+		}
+	}
+	return validateAgentNames("approval.agents", c.Agents, config)
+}
+
+// approvalDefinition returns the Amazon States Language definition of the approval workflow.
+func approvalDefinition(cfg *ApprovalConfig, topicArn, escalationArn, queueURL string) (string, error) {
+	await := func(escalated bool, timeout int, next string) map[string]interface{} {
+		return map[string]interface{}{
+			"Type":           "Task",
+			"Resource":       "arn:aws:states:::sqs:sendMessage.waitForTaskToken",
+			"TimeoutSeconds": timeout,
+			"Parameters": map[string]interface{}{
+				"QueueUrl": queueURL,
+				"MessageBody": map[string]interface{}{
+					"taskToken.$":   "$$.Task.Token",
+					"executionId.$": "$$.Execution.Id",
+					"request.$":     "$",
+					"escalated":     escalated,
+				},
+			},
+			"ResultPath": "$.decision",
+			"Next":       "Approved",
+			"Catch": []map[string]interface{}{
+				{"ErrorEquals": []string{"States.Timeout"}, "ResultPath": "$.error", "Next": next},
+				{"ErrorEquals": []string{"States.ALL"}, "ResultPath": "$.error", "Next": "Rejected"},
+			},
+		}
+	}
+	notify := func(subject, topic, next string) map[string]interface{} {
+		return map[string]interface{}{
+			"Type":     "Task",
+			"Resource": "arn:aws:states:::sns:publish",
+			"Parameters": map[string]interface{}{
+				"TopicArn":  topic,
+				"Subject":   subject,
+				"Message.$": "States.JsonToString($)",
+				"MessageAttributes": map[string]interface{}{
+					"executionId": map[string]interface{}{"DataType": "String", "StringValue.$": "$$.Execution.Id"},
+				},
+			},
+			"ResultPath": nil,
+			"Next":       next,
+		}
+	}
+
+	escalationNext := "TimedOut"
+	states := map[string]interface{}{
+		"NotifyApprovers": notify("Agent action awaiting approval", topicArn, "AwaitApproval"),
+		"Approved":        map[string]interface{}{"Type": "Succeed"},
+		"Rejected":        map[string]interface{}{"Type": "Fail", "Error": "ApprovalRejected", "Cause": "The action was rejected"},
+		"TimedOut":        map[string]interface{}{"Type": "Fail", "Error": "ApprovalTimedOut", "Cause": "No decision before the timeout"},
+	}
+	if cfg.EscalationTimeoutSeconds > 0 {
+		escalationNext = "AwaitEscalatedApproval"
+		states["AwaitEscalatedApproval"] = await(true, cfg.EscalationTimeoutSeconds, "TimedOut")
+	}
+	states["AwaitApproval"] = await(false, cfg.TimeoutSeconds, "Escalate")
+	states["Escalate"] = notify("Agent action approval escalated", escalationArn, escalationNext)
+
+	data, err := json.Marshal(map[string]interface{}{
+		"Comment": "Human approval for sensitive agent actions",
+		"StartAt": "NotifyApprovers",
+		"States":  states,
+	})
+	return string(data), err
+}
+
+// createApproval creates the approval workflow, notifications, queue and API.
+func (s *AgentCoreStack) createApproval(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	stackName := s.Config.StackName
+	cfg := s.Options.Approval
+
+	topic, err := sns.NewTopic(ctx, s.ResourceName("approval-topic"), &sns.TopicArgs{
+		Name: pulumi.Sprintf("%s-approvals", stackName),
+		Tags: mergeTags(tags, pulumi.Sprintf("%s-approvals", stackName)),
+	}, s.child())
+	if err != nil {
+		return err
+	}
+	if err := s.subscribeEmails(ctx, "approval-approver", topic.Arn, cfg.Approvers); err != nil {
+		return err
+	}
+
+	escalation := topic
+	if len(cfg.EscalationEmails) > 0 {
+		escalation, err = sns.NewTopic(ctx, s.ResourceName("approval-escalation-topic"), &sns.TopicArgs{
+			Name: pulumi.Sprintf("%s-approval-escalations", stackName),
+			Tags: mergeTags(tags, pulumi.Sprintf("%s-approval-escalations", stackName)),
+		}, s.child())
+		if err != nil {
+			return err
+		}
+		if err := s.subscribeEmails(ctx, "approval-escalation", escalation.Arn, cfg.EscalationEmails); err != nil {
+			return err
+		}
+	}
+
+	// Pending requests outlive the longest wait so approval UIs can list them
+	retention := cfg.TimeoutSeconds + cfg.EscalationTimeoutSeconds
+	if retention > 14*24*60*60 {
+		retention = 14 * 24 * 60 * 60
+	}
+	queue, err := sqs.NewQueue(ctx, s.ResourceName("approval-queue"), &sqs.QueueArgs{
+		Name:                    pulumi.Sprintf("%s-pending-approvals", stackName),
+		MessageRetentionSeconds: pulumi.Int(max(retention, 60)),
+		SqsManagedSseEnabled:    pulumi.Bool(true),
+		Tags:                    mergeTags(tags, pulumi.Sprintf("%s-pending-approvals", stackName)),
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	// Role assumed by Step Functions to notify and queue requests
+	workflowRole, err := s.newServiceRole(ctx, "approval-workflow-role", "states.amazonaws.com", tags)
+	if err != nil {
+		return err
+	}
+	err = s.newRolePolicy(ctx, "approval-workflow-policy", workflowRole.Name,
+		policyStatement{
+			Actions:   []string{"sns:Publish"},
+			Resources: pulumi.StringArray{topic.Arn, escalation.Arn},
+		},
+		policyStatement{
+			Actions:   []string{"sqs:SendMessage"},
+			Resources: pulumi.StringArray{queue.Arn},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	definition := pulumi.All(topic.Arn, escalation.Arn, queue.Url).ApplyT(func(args []interface{}) (string, error) {
+		return approvalDefinition(cfg, args[0].(string), args[1].(string), args[2].(string))
+	}).(pulumi.StringOutput)
+
+	s.ApprovalWorkflow, err = sfn.NewStateMachine(ctx, s.ResourceName("approval-workflow"), &sfn.StateMachineArgs{
+		Name:       pulumi.Sprintf("%s-approvals", stackName),
+		Type:       pulumi.String("STANDARD"),
+		RoleArn:    workflowRole.Arn,
+		Definition: definition,
+		Tags:       mergeTags(tags, pulumi.Sprintf("%s-approvals", stackName)),
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	if err := s.createApprovalAPI(ctx, tags); err != nil {
+		return err
+	}
+
+	err = s.attachRolePolicy(ctx, "approval-request-policy",
+		policyStatement{
+			Actions:   []string{"states:StartExecution"},
+			Resources: pulumi.StringArray{s.ApprovalWorkflow.Arn},
+		},
+		policyStatement{
+			Actions: []string{"states:DescribeExecution", "states:StopExecution"},
+			Resources: pulumi.StringArray{
+				pulumi.Sprintf("arn:aws:states:*:*:execution:%s-approvals:*", stackName),
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range s.selectedAgents(cfg.Agents) {
+		s.injectEnv(name, "APPROVAL_STATE_MACHINE_ARN", s.ApprovalWorkflow.Arn)
+		s.injectEnv(name, "APPROVAL_QUEUE_URL", queue.Url)
+		s.injectEnv(name, "APPROVAL_API_URL", s.ApprovalAPI.ApiEndpoint)
+	}
+
+	return nil
+}
+
+// createApprovalAPI creates an IAM-authorized HTTP API that sends approval
+// decisions to Step Functions.
+func (s *AgentCoreStack) createApprovalAPI(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	var err error
+	stackName := s.Config.StackName
+
+	s.ApprovalAPI, err = apigatewayv2.NewApi(ctx, s.ResourceName("approval-api"), &apigatewayv2.ApiArgs{
+		Name:         pulumi.Sprintf("%s-approvals", stackName),
+		Description:  pulumi.Sprintf("Approval decisions for %s agents", stackName),
+		ProtocolType: pulumi.String("HTTP"),
+		Tags:         mergeTags(tags, pulumi.Sprintf("%s-approvals", stackName)),
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	role, err := s.newServiceRole(ctx, "approval-api-role", "apigateway.amazonaws.com", tags)
+	if err != nil {
+		return err
+	}
+	err = s.newRolePolicy(ctx, "approval-api-policy", role.Name, policyStatement{
+		Actions:   []string{"states:SendTaskSuccess", "states:SendTaskFailure"},
+		Resources: pulumi.StringArray{s.ApprovalWorkflow.Arn},
+	})
+	if err != nil {
+		return err
+	}
+
+	routes := []struct {
+		path, subtype string
+		params        pulumi.StringMap
+	}{
+		{"approve", "StepFunctions-SendTaskSuccess", pulumi.StringMap{
+			"TaskToken": pulumi.String("$request.body.taskToken"),
+			"Output":    pulumi.String("$request.body.output"),
+		}},
+		{"reject", "StepFunctions-SendTaskFailure", pulumi.StringMap{
+			"TaskToken": pulumi.String("$request.body.taskToken"),
+			"Error":     pulumi.String("ApprovalRejected"),
+			"Cause":     pulumi.String("$request.body.reason"),
+		}},
+	}
+	for _, route := range routes {
+		integration, err := apigatewayv2.NewIntegration(ctx, s.ResourceName(fmt.Sprintf("approval-%s-integration", route.path)), &apigatewayv2.IntegrationArgs{
+			ApiId:                s.ApprovalAPI.ID(),
+			IntegrationType:      pulumi.String("AWS_PROXY"),
+			IntegrationSubtype:   pulumi.String(route.subtype),
+			CredentialsArn:       role.Arn,
+			PayloadFormatVersion: pulumi.String("1.0"),
+			RequestParameters:    route.params,
+		}, s.child())
+		if err != nil {
+			return err
+		}
+		_, err = apigatewayv2.NewRoute(ctx, s.ResourceName(fmt.Sprintf("approval-%s-route", route.path)), &apigatewayv2.RouteArgs{
+			ApiId:             s.ApprovalAPI.ID(),
+			RouteKey:          pulumi.String("POST /" + route.path),
+			AuthorizationType: pulumi.String("AWS_IAM"),
+			Target:            pulumi.Sprintf("integrations/%s", integration.ID()),
+		}, s.child())
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = apigatewayv2.NewStage(ctx, s.ResourceName("approval-api-stage"), &apigatewayv2.StageArgs{
+		ApiId:      s.ApprovalAPI.ID(),
+		Name:       pulumi.String("$default"),
+		AutoDeploy: pulumi.Bool(true),
+		Tags:       mergeTags(tags, pulumi.Sprintf("%s-approvals", stackName)),
+	}, s.child())
+	return err
+}
@@ -0,0 +1,351 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/appsync"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// AppSyncConfig configures an AppSync GraphQL API for invoking agents, with
+// subscriptions that stream tokens published by agents back to clients.
+//
+// Clients call the invokeAgent mutation and subscribe to onChunk for the
+// session. Agents publish tokens with the publishChunk mutation using the
+// execution role.
+type AppSyncConfig struct {
+	// AuthenticationType is the primary authorization mode for clients.
+	// IAM is always enabled so agents can publish chunks.
+	// Supported: "AWS_IAM", "AMAZON_COGNITO_USER_POOLS"
+	// Default: "AWS_IAM"
+	AuthenticationType string `json:"authenticationType,omitempty" yaml:"authenticationType,omitempty"`
+
+	// UserPoolID is the Cognito user pool for AMAZON_COGNITO_USER_POOLS.
+	UserPoolID string `json:"userPoolId,omitempty" yaml:"userPoolId,omitempty"`
+
+	// InvokeAgents are the agents invokeAgent can call.
+	// If empty, all agents in the stack are included, except Lambda agents.
+	InvokeAgents []string `json:"invokeAgents,omitempty" yaml:"invokeAgents,omitempty"`
+
+	// RuntimeARNs overrides the runtime ARNs invokeAgent calls, keyed by
+	// agent name, e.g. for agents whose runtimes another stack manages.
+	// Optional.
+	RuntimeARNs map[string]string `json:"runtimeARNs,omitempty" yaml:"runtimeARNs,omitempty"`
+
+	// Qualifier is the runtime endpoint invokeAgent calls.
+	// Default: "live" for the stack's runtimes, "DEFAULT" for RuntimeARNs
+	Qualifier string `json:"qualifier,omitempty" yaml:"qualifier,omitempty"`
+
+	// Agents is the list of agent names that publish chunks.
+	// If empty, all agents in the stack are included.
+	Agents []string `json:"agents,omitempty" yaml:"agents,omitempty"`
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *AppSyncConfig) ApplyDefaults() {
+	if c.AuthenticationType == "" {
+		c.AuthenticationType = "AWS_IAM"
+	}
+}
+
+// Validate validates the AppSyncConfig against the stack configuration.
+func (c *AppSyncConfig) Validate(config iac.StackConfig) error {
+	switch c.AuthenticationType {
+	case "AWS_IAM":
+		if c.UserPoolID != "" {
+			return fmt.Errorf("appSync.userPoolId requires authenticationType AMAZON_COGNITO_USER_POOLS")
+		}
+	case "AMAZON_COGNITO_USER_POOLS":
+		if c.UserPoolID == "" {
+			return fmt.Errorf("appSync.userPoolId is required for AMAZON_COGNITO_USER_POOLS")
+		}
+	default:
+		return fmt.Errorf("appSync.authenticationType must be one of [AWS_IAM AMAZON_COGNITO_USER_POOLS]")
+	}
+	if err := validateAgentNames("appSync.invokeAgents", c.InvokeAgents, config); err != nil {
+		return err
+	}
+	for name, arn := range c.RuntimeARNs {
+		if !hasAgent(config, name) {
+			return fmt.Errorf("appSync.runtimeARNs: '%s' does not match any agent name", name)
+		}
+		if !strings.HasPrefix(arn, "arn:") {
+			return fmt.Errorf("appSync.runtimeARNs[%s]: '%s' is not an ARN", name, arn)
+		}
+	}
+	return validateAgentNames("appSync.agents", c.Agents, config)
+}
+
+// appSyncSchema returns the GraphQL schema. When Cognito is the primary
+// mode, types shared with agents also allow IAM.
+func appSyncSchema(cognito bool) string {
+	shared, iamOnly := "", ""
+	if cognito {
+		shared = " @aws_iam @aws_cognito_user_pools"
+		iamOnly = " @aws_iam"
+	}
+	return fmt.Sprintf(`schema {
+  query: Query
+  mutation: Mutation
+  subscription: Subscription
+}
+
+type Invocation%[1]s {
+  agent: String!
+  sessionId: ID!
+  output: AWSJSON
+}
+
+type Chunk%[1]s {
+  sessionId: ID!
+  sequence: Int!
+  text: String!
+  done: Boolean!
+}
+
+type Query {
+  agents: [String!]!
+}
+
+type Mutation {
+  invokeAgent(agent: String!, sessionId: ID!, input: AWSJSON!): Invocation
+  publishChunk(sessionId: ID!, sequence: Int!, text: String!, done: Boolean!): Chunk%[2]s
+}
+
+type Subscription {
+  onChunk(sessionId: ID!): Chunk
+    @aws_subscribe(mutations: ["publishChunk"])
+}
+`, shared, iamOnly)
+}
+
+// appSyncInvokeCode is the APPSYNC_JS resolver that calls the agent runtime.
+const appSyncInvokeCode = `import { util } from '@aws-appsync/utils';
+
+const runtimes = %s;
+
+export function request(ctx) {
+  const runtime = runtimes[ctx.args.agent];
+  if (!runtime) {
+    util.error('Unknown agent ' + ctx.args.agent, 'BadRequest');
+  }
+  return {
+    method: 'POST',
+    resourcePath: '/runtimes/' + util.urlEncode(runtime.arn) + '/invocations',
+    params: {
+      query: { qualifier: runtime.qualifier },
+      headers: {
+        'Content-Type': 'application/json',
+        'X-Amzn-Bedrock-AgentCore-Runtime-Session-Id': ctx.args.sessionId,
+      },
+      body: ctx.args.input,
+    },
+  };
+}
+
+export function response(ctx) {
+  if (ctx.error) {
+    util.error(ctx.error.message, ctx.error.type);
+  }
+  if (ctx.result.statusCode !== 200) {
+    util.error(ctx.result.body, 'AgentInvocationError');
+  }
+  return { agent: ctx.args.agent, sessionId: ctx.args.sessionId, output: ctx.result.body };
+}
+`
+
+// appSyncPublishCode is the APPSYNC_JS resolver that fans chunks out to subscribers.
+const appSyncPublishCode = `export function request(ctx) {
+  return { payload: ctx.args };
+}
+
+export function response(ctx) {
+  return ctx.result;
+}
+`
+
+// appSyncAgentsCode is the APPSYNC_JS resolver that lists invokable agents.
+const appSyncAgentsCode = `export function request(ctx) {
+  return { payload: %s };
+}
+
+export function response(ctx) {
+  return ctx.result;
+}
+`
+
+// createAppSyncAPI creates the GraphQL API and wires its URL into the
+// agents. Its resolvers are created by createAppSyncResolvers once the
+// runtimes they invoke exist.
+func (s *AgentCoreStack) createAppSyncAPI(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	var err error
+	stackName := s.Config.StackName
+	cfg := s.Options.AppSync
+	cognito := cfg.AuthenticationType == "AMAZON_COGNITO_USER_POOLS"
+
+	args := &appsync.GraphQLApiArgs{
+		Name:               pulumi.Sprintf("%s-api", stackName),
+		AuthenticationType: pulumi.String(cfg.AuthenticationType),
+		Schema:             pulumi.String(appSyncSchema(cognito)),
+		Tags:               mergeTags(tags, pulumi.Sprintf("%s-api", stackName)),
+	}
+	if cognito {
+		args.UserPoolConfig = &appsync.GraphQLApiUserPoolConfigArgs{
+			UserPoolId:    pulumi.String(cfg.UserPoolID),
+			DefaultAction: pulumi.String("ALLOW"),
+		}
+		args.AdditionalAuthenticationProviders = appsync.GraphQLApiAdditionalAuthenticationProviderArray{
+			&appsync.GraphQLApiAdditionalAuthenticationProviderArgs{
+				AuthenticationType: pulumi.String("AWS_IAM"),
+			},
+		}
+	}
+
+	s.AppSyncAPI, err = appsync.NewGraphQLApi(ctx, s.ResourceName("appsync-api"), args, s.child())
+	if err != nil {
+		return err
+	}
+
+	// Agents publish stream chunks with their execution role
+	err = s.attachRolePolicy(ctx, "appsync-publish-policy", policyStatement{
+		Actions:   []string{"appsync:GraphQL"},
+		Resources: pulumi.StringArray{pulumi.Sprintf("%s/types/Mutation/fields/publishChunk", s.AppSyncAPI.Arn)},
+	})
+	if err != nil {
+		return err
+	}
+
+	graphqlURL := s.AppSyncAPI.Uris.MapIndex(pulumi.String("GRAPHQL"))
+	for _, name := range s.selectedAgents(cfg.Agents) {
+		s.injectEnv(name, "APPSYNC_GRAPHQL_URL", graphqlURL)
+		s.injectEnv(name, "STREAMING_TRANSPORT", pulumi.String("appsync"))
+	}
+
+	return nil
+}
+
+// appSyncInvokeAgents returns the agents invokeAgent can call, in name order.
+func (s *AgentCoreStack) appSyncInvokeAgents() []string {
+	cfg := s.Options.AppSync
+	var names []string
+	if len(cfg.InvokeAgents) > 0 {
+		names = slices.Clone(cfg.InvokeAgents)
+	} else {
+		names = s.runtimeAgents(nil)
+		for name := range cfg.RuntimeARNs {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// createAppSyncResolvers creates the data sources and resolvers of the
+// GraphQL API. It runs after createAgentRuntimes, so invokeAgent calls the
+// stack's runtimes unless AppSyncConfig.RuntimeARNs overrides them.
+func (s *AgentCoreStack) createAppSyncResolvers(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	cfg := s.Options.AppSync
+
+	region, err := aws.GetRegion(ctx, nil, s.invoke())
+	if err != nil {
+		return err
+	}
+
+	// Role assumed by AppSync to invoke agent runtimes
+	role, err := s.newServiceRole(ctx, "appsync-role", "appsync.amazonaws.com", tags)
+	if err != nil {
+		return err
+	}
+
+	names := s.appSyncInvokeAgents()
+	runtimes := pulumi.Map{}
+	var runtimeArns pulumi.StringArray
+	for _, name := range names {
+		arn, qualifier := s.agentInvokeTarget(name, cfg.RuntimeARNs[name])
+		if cfg.Qualifier != "" {
+			qualifier = cfg.Qualifier
+		}
+		runtimes[name] = pulumi.Map{"arn": arn, "qualifier": pulumi.String(qualifier)}
+		runtimeArns = append(runtimeArns, arn, pulumi.Sprintf("%s/*", arn))
+	}
+	err = s.newRolePolicy(ctx, "appsync-policy", role.Name, policyStatement{
+		Actions:   []string{"bedrock-agentcore:InvokeAgentRuntime"},
+		Resources: runtimeArns,
+	})
+	if err != nil {
+		return err
+	}
+
+	agentCore, err := appsync.NewDataSource(ctx, s.ResourceName("appsync-agentcore-source"), &appsync.DataSourceArgs{
+		ApiId:          s.AppSyncAPI.ID(),
+		Name:           pulumi.String("AgentCore"),
+		Type:           pulumi.String("HTTP"),
+		ServiceRoleArn: role.Arn,
+		HttpConfig: &appsync.DataSourceHttpConfigArgs{
+			Endpoint: pulumi.Sprintf("https://bedrock-agentcore.%s.amazonaws.com", region.Name),
+			AuthorizationConfig: &appsync.DataSourceHttpConfigAuthorizationConfigArgs{
+				AuthorizationType: pulumi.String("AWS_IAM"),
+				AwsIamConfig: &appsync.DataSourceHttpConfigAuthorizationConfigAwsIamConfigArgs{
+					SigningRegion:      pulumi.String(region.Name),
+					SigningServiceName: pulumi.String("bedrock-agentcore"),
+				},
+			},
+		},
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	local, err := appsync.NewDataSource(ctx, s.ResourceName("appsync-local-source"), &appsync.DataSourceArgs{
+		ApiId: s.AppSyncAPI.ID(),
+		Name:  pulumi.String("Local"),
+		Type:  pulumi.String("NONE"),
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	invokeCode := runtimes.ToMapOutput().ApplyT(func(value map[string]interface{}) (string, error) {
+		data, err := json.Marshal(value)
+		return fmt.Sprintf(appSyncInvokeCode, data), err
+	}).(pulumi.StringOutput)
+	agentNames, err := json.Marshal(names)
+	if err != nil {
+		return err
+	}
+
+	resolvers := []struct {
+		typeName, field string
+		code            pulumi.StringInput
+		source          pulumi.StringInput
+	}{
+		{"Mutation", "invokeAgent", invokeCode, agentCore.Name},
+		{"Mutation", "publishChunk", pulumi.String(appSyncPublishCode), local.Name},
+		{"Query", "agents", pulumi.String(fmt.Sprintf(appSyncAgentsCode, agentNames)), local.Name},
+	}
+	for _, r := range resolvers {
+		_, err = appsync.NewResolver(ctx, s.ResourceName(fmt.Sprintf("appsync-%s-resolver", r.field)), &appsync.ResolverArgs{
+			ApiId:      s.AppSyncAPI.ID(),
+			Type:       pulumi.String(r.typeName),
+			Field:      pulumi.String(r.field),
+			DataSource: r.source,
+			Code:       r.code,
+			Runtime: &appsync.ResolverRuntimeArgs{
+				Name:           pulumi.String("APPSYNC_JS"),
+				RuntimeVersion: pulumi.String("1.0.0"),
+			},
+		}, s.child())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
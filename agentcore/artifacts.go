@@ -0,0 +1,146 @@
+package agentcore
+
+import (
+	"fmt"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/s3"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// ArtifactBucketConfig configures a versioned S3 bucket for the files agents
+// read and produce, such as uploaded documents and generated reports.
+//
+// Each agent works under its own prefix, "{agent}/", and may only read and
+// write objects under it. Agents receive the bucket name as ARTIFACT_BUCKET
+// and their prefix as ARTIFACT_PREFIX.
+type ArtifactBucketConfig struct {
+	// TransitionDays is how many days after upload artifacts move to
+	// S3 Intelligent-Tiering. Zero keeps them in S3 Standard.
+	// Default: 30
+	TransitionDays int `json:"transitionDays,omitempty" yaml:"transitionDays,omitempty"`
+
+	// ExpirationDays deletes artifacts after this many days.
+	// Must be greater than TransitionDays. Zero keeps them indefinitely.
+	// Default: 0
+	ExpirationDays int `json:"expirationDays,omitempty" yaml:"expirationDays,omitempty"`
+
+	// NoncurrentVersionDays deletes overwritten and deleted versions after
+	// this many days.
+	// Default: 30
+	NoncurrentVersionDays int `json:"noncurrentVersionDays,omitempty" yaml:"noncurrentVersionDays,omitempty"`
+}
+
+// DefaultArtifactBucketConfig returns an ArtifactBucketConfig with sensible defaults.
+func DefaultArtifactBucketConfig() *ArtifactBucketConfig {
+	return &ArtifactBucketConfig{
+		TransitionDays:        30,
+		NoncurrentVersionDays: 30,
+	}
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *ArtifactBucketConfig) ApplyDefaults() {
+	if c.TransitionDays == 0 {
+		c.TransitionDays = 30
+	}
+	if c.NoncurrentVersionDays == 0 {
+		c.NoncurrentVersionDays = 30
+	}
+}
+
+// Validate validates the ArtifactBucketConfig.
+func (c *ArtifactBucketConfig) Validate() error {
+	if c.TransitionDays < 0 {
+		return fmt.Errorf("artifactBucket.transitionDays must not be negative")
+	}
+	if c.ExpirationDays < 0 || (c.ExpirationDays != 0 && c.ExpirationDays <= c.TransitionDays) {
+		return fmt.Errorf("artifactBucket.expirationDays must be greater than transitionDays")
+	}
+	if c.NoncurrentVersionDays < 1 {
+		return fmt.Errorf("artifactBucket.noncurrentVersionDays must be at least 1")
+	}
+	return nil
+}
+
+// createArtifactBucket creates the artifact bucket and grants each agent
+// access to its prefix.
+func (s *AgentCoreStack) createArtifactBucket(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	var err error
+	cfg := s.Options.ArtifactBucket
+
+	s.ArtifactBucket, err = s.newPrivateBucket(ctx, "artifact-bucket", "artifacts", tags)
+	if err != nil {
+		return err
+	}
+
+	versioning, err := s3.NewBucketVersioningV2(ctx, s.ResourceName("artifact-bucket-versioning"), &s3.BucketVersioningV2Args{
+		Bucket: s.ArtifactBucket.ID(),
+		VersioningConfiguration: &s3.BucketVersioningV2VersioningConfigurationArgs{
+			Status: pulumi.String("Enabled"),
+		},
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	rule := &s3.BucketLifecycleConfigurationV2RuleArgs{
+		Id:     pulumi.String("artifacts"),
+		Status: pulumi.String("Enabled"),
+		Filter: &s3.BucketLifecycleConfigurationV2RuleFilterArgs{
+			Prefix: pulumi.String(""),
+		},
+		NoncurrentVersionExpiration: &s3.BucketLifecycleConfigurationV2RuleNoncurrentVersionExpirationArgs{
+			NoncurrentDays: pulumi.Int(cfg.NoncurrentVersionDays),
+		},
+		AbortIncompleteMultipartUpload: &s3.BucketLifecycleConfigurationV2RuleAbortIncompleteMultipartUploadArgs{
+			DaysAfterInitiation: pulumi.Int(7),
+		},
+	}
+	if cfg.TransitionDays != 0 {
+		rule.Transitions = s3.BucketLifecycleConfigurationV2RuleTransitionArray{
+			&s3.BucketLifecycleConfigurationV2RuleTransitionArgs{
+				Days:         pulumi.Int(cfg.TransitionDays),
+				StorageClass: pulumi.String("INTELLIGENT_TIERING"),
+			},
+		}
+	}
+	if cfg.ExpirationDays != 0 {
+		rule.Expiration = &s3.BucketLifecycleConfigurationV2RuleExpirationArgs{
+			Days: pulumi.Int(cfg.ExpirationDays),
+		}
+	}
+	// Lifecycle rules on noncurrent versions require versioning first
+	_, err = s3.NewBucketLifecycleConfigurationV2(ctx, s.ResourceName("artifact-bucket-lifecycle"), &s3.BucketLifecycleConfigurationV2Args{
+		Bucket: s.ArtifactBucket.ID(),
+		Rules:  s3.BucketLifecycleConfigurationV2RuleArray{rule},
+	}, s.child(), pulumi.DependsOn([]pulumi.Resource{versioning}))
+	if err != nil {
+		return err
+	}
+
+	for _, agent := range s.Config.Agents {
+		err = s.attachAgentPolicy(ctx, agent.Name, "artifact-bucket-policy",
+			policyStatement{
+				Actions:   []string{"s3:GetObject", "s3:GetObjectVersion", "s3:PutObject"},
+				Resources: pulumi.StringArray{pulumi.Sprintf("%s/%s/*", s.ArtifactBucket.Arn, agent.Name)},
+			},
+			policyStatement{
+				Actions:   []string{"s3:ListBucket"},
+				Resources: pulumi.StringArray{s.ArtifactBucket.Arn},
+				Conditions: map[string]map[string]interface{}{
+					"StringLike": {"s3:prefix": agent.Name + "/*"},
+				},
+			},
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, agent := range s.Config.Agents {
+		s.injectEnv(agent.Name, "ARTIFACT_BUCKET", s.ArtifactBucket.Bucket)
+		s.injectEnv(agent.Name, "ARTIFACT_PREFIX", pulumi.String(agent.Name+"/"))
+	}
+
+	return nil
+}
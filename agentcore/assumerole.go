@@ -0,0 +1,94 @@
+package agentcore
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws"
+	awsconfig "github.com/pulumi/pulumi-aws/sdk/v6/go/aws/config"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+var (
+	// roleARNPattern matches IAM role ARNs.
+	roleARNPattern = regexp.MustCompile(`^arn:aws[a-z-]*:iam::[0-9]{12}:role/[\w+=,.@/-]+$`)
+
+	// sessionNamePattern matches STS role session names.
+	sessionNamePattern = regexp.MustCompile(`^[\w+=,.@-]{2,64}$`)
+)
+
+// AssumeRoleConfig deploys the stack into another account by assuming a
+// role there, e.g. a spoke account of a platform team deploying from a
+// hub account.
+//
+// The stack creates an AWS provider with the role's credentials, which
+// its resources and lookups use, including the us-east-1 provider of
+// CloudFront web ACLs. The deployment role is managed in the target
+// account; DeploymentRoleTrustPolicy builds its trust policy.
+type AssumeRoleConfig struct {
+	// RoleARN is the role assumed in the target account.
+	RoleARN string `json:"roleARN" yaml:"roleARN"`
+
+	// ExternalID is required by the role's trust policy, if set.
+	ExternalID string `json:"externalID,omitempty" yaml:"externalID,omitempty"`
+
+	// SessionName identifies the deployment in the target account's
+	// CloudTrail.
+	// Default: the stack name
+	SessionName string `json:"sessionName,omitempty" yaml:"sessionName,omitempty"`
+}
+
+// Validate validates the AssumeRoleConfig.
+func (c *AssumeRoleConfig) Validate() error {
+	if !roleARNPattern.MatchString(c.RoleARN) {
+		return fmt.Errorf("assumeRole.roleARN: '%s' is not an IAM role ARN", c.RoleARN)
+	}
+	if c.SessionName != "" && !sessionNamePattern.MatchString(c.SessionName) {
+		return fmt.Errorf("assumeRole.sessionName: '%s' must be 2-64 characters of letters, digits and +=,.@_-", c.SessionName)
+	}
+	return nil
+}
+
+// DeploymentRoleTrustPolicy returns the trust policy of a deployment role
+// in a target account, assumed by the hub account's deployment principals.
+func DeploymentRoleTrustPolicy(principalARNs []string, externalID string) PolicyDocument {
+	statement := Statement{
+		Effect:    "Allow",
+		Principal: map[string][]string{"AWS": principalARNs},
+		Action:    []string{"sts:AssumeRole", "sts:TagSession"},
+	}
+	if externalID != "" {
+		statement.Condition = map[string]map[string]interface{}{
+			"StringEquals": {"sts:ExternalId": externalID},
+		}
+	}
+	return NewPolicyDocument(statement)
+}
+
+// providerArgs returns the arguments of an AWS provider of the stack in a
+// region, with the credentials of Options.AssumeRole. An empty region is
+// the region of the program's default provider.
+func (s *AgentCoreStack) providerArgs(ctx *pulumi.Context, region string) *aws.ProviderArgs {
+	if region == "" {
+		region = awsconfig.GetRegion(ctx)
+	}
+	args := &aws.ProviderArgs{}
+	if region != "" {
+		args.Region = pulumi.String(region)
+	}
+	if cfg := s.Options.AssumeRole; cfg != nil {
+		sessionName := cfg.SessionName
+		if sessionName == "" {
+			sessionName = s.Config.StackName[:min(len(s.Config.StackName), 64)]
+		}
+		assumeRole := &aws.ProviderAssumeRoleArgs{
+			RoleArn:     pulumi.String(cfg.RoleARN),
+			SessionName: pulumi.String(sessionName),
+		}
+		if cfg.ExternalID != "" {
+			assumeRole.ExternalId = pulumi.String(cfg.ExternalID)
+		}
+		args.AssumeRole = assumeRole
+	}
+	return args
+}
@@ -0,0 +1,241 @@
+package agentcore
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/athena"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/glue"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/kinesis"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// auditPrefix is the S3 prefix audit events are delivered under, partitioned by day.
+const auditPrefix = "events/dt=!{timestamp:yyyy-MM-dd}/"
+
+// auditColumns are the fields of a tool invocation audit event. Agents
+// write one JSON object per invocation to the audit stream.
+var auditColumns = []struct{ name, typ, comment string }{
+	{"event_id", "string", "Unique event ID"},
+	{"timestamp", "string", "RFC 3339 time of the invocation"},
+	{"stack", "string", "Stack name"},
+	{"agent", "string", "Agent that invoked the tool"},
+	{"session_id", "string", "Runtime session ID"},
+	{"tenant", "string", "Tenant, if multi-tenant"},
+	{"principal", "string", "Caller identity the agent acted for"},
+	{"tool", "string", "Tool name"},
+	{"input", "string", "Tool input as JSON"},
+	{"output", "string", "Tool output as JSON (may be truncated)"},
+	{"status", "string", "success or error"},
+	{"error", "string", "Error message on failure"},
+	{"duration_ms", "bigint", "Invocation duration in milliseconds"},
+}
+
+// AuditConfig configures an immutable audit log of agent tool invocations.
+//
+// Agents write structured events to a Firehose stream, which delivers them
+// to an S3 bucket with Object Lock in compliance mode. An Athena table over
+// the bucket answers "what did the agent actually do".
+type AuditConfig struct {
+	// RetentionDays is the Object Lock retention period. Objects cannot be
+	// deleted or overwritten until it expires, and it cannot be shortened.
+	// Default: 365
+	RetentionDays int `json:"retentionDays,omitempty" yaml:"retentionDays,omitempty"`
+
+	// BufferSeconds is how long Firehose buffers events before writing to S3.
+	// Range: 0-900
+	// Default: 60
+	BufferSeconds int `json:"bufferSeconds,omitempty" yaml:"bufferSeconds,omitempty"`
+
+	// Agents is the list of agent names that write audit events.
+	// If empty, all agents in the stack are included.
+	Agents []string `json:"agents,omitempty" yaml:"agents,omitempty"`
+}
+
+// DefaultAuditConfig returns an AuditConfig with sensible defaults.
+func DefaultAuditConfig() *AuditConfig {
+	return &AuditConfig{
+		RetentionDays: 365,
+		BufferSeconds: 60,
+	}
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *AuditConfig) ApplyDefaults() {
+	if c.RetentionDays == 0 {
+		c.RetentionDays = 365
+	}
+	if c.BufferSeconds == 0 {
+		c.BufferSeconds = 60
+	}
+}
+
+// Validate validates the AuditConfig against the stack configuration.
+func (c *AuditConfig) Validate(config iac.StackConfig) error {
+	if c.RetentionDays < 1 || c.RetentionDays > 36500 {
+		return fmt.Errorf("audit.retentionDays must be between 1 and 36500")
+	}
+	if c.BufferSeconds < 0 || c.BufferSeconds > 900 {
+		return fmt.Errorf("audit.bufferSeconds must be between 0 and 900")
+	}
+	return validateAgentNames("audit.agents", c.Agents, config)
+}
+
+// auditDatabaseName returns the Glue database name for the stack's audit table.
+func auditDatabaseName(stackName string) string {
+	return strings.ToLower(strings.ReplaceAll(stackName, "-", "_")) + "_audit"
+}
+
+// createAuditLog creates the locked audit bucket, delivery stream and Athena table.
+func (s *AgentCoreStack) createAuditLog(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	var err error
+	stackName := s.Config.StackName
+	cfg := s.Options.Audit
+
+	s.AuditBucket, err = s.newLockedBucket(ctx, "audit-bucket", "audit", cfg.RetentionDays, tags)
+	if err != nil {
+		return err
+	}
+
+	// Role assumed by Firehose to write to the audit bucket
+	firehoseRole, err := s.newServiceRole(ctx, "audit-firehose-role", "firehose.amazonaws.com", tags)
+	if err != nil {
+		return err
+	}
+	err = s.newRolePolicy(ctx, "audit-firehose-policy", firehoseRole.Name,
+		policyStatement{
+			Actions:   []string{"s3:GetBucketLocation", "s3:ListBucket", "s3:ListBucketMultipartUploads"},
+			Resources: pulumi.StringArray{s.AuditBucket.Arn},
+		},
+		policyStatement{
+			Actions:   []string{"s3:PutObject", "s3:AbortMultipartUpload"},
+			Resources: pulumi.StringArray{pulumi.Sprintf("%s/*", s.AuditBucket.Arn)},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	s.AuditStream, err = kinesis.NewFirehoseDeliveryStream(ctx, s.ResourceName("audit-stream"), &kinesis.FirehoseDeliveryStreamArgs{
+		Name:        pulumi.Sprintf("%s-audit", stackName),
+		Destination: pulumi.String("extended_s3"),
+		ExtendedS3Configuration: &kinesis.FirehoseDeliveryStreamExtendedS3ConfigurationArgs{
+			RoleArn:           firehoseRole.Arn,
+			BucketArn:         s.AuditBucket.Arn,
+			Prefix:            pulumi.String(auditPrefix),
+			ErrorOutputPrefix: pulumi.String("errors/!{firehose:error-output-type}/dt=!{timestamp:yyyy-MM-dd}/"),
+			BufferingInterval: pulumi.Int(cfg.BufferSeconds),
+			BufferingSize:     pulumi.Int(5),
+			CompressionFormat: pulumi.String("GZIP"),
+		},
+		Tags: mergeTags(tags, pulumi.Sprintf("%s-audit", stackName)),
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	if err := s.createAuditTable(ctx, tags); err != nil {
+		return err
+	}
+
+	err = s.attachRolePolicy(ctx, "audit-write-policy", policyStatement{
+		Actions:   []string{"firehose:PutRecord", "firehose:PutRecordBatch"},
+		Resources: pulumi.StringArray{s.AuditStream.Arn},
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, name := range s.selectedAgents(cfg.Agents) {
+		s.injectEnv(name, "AUDIT_STREAM_NAME", s.AuditStream.Name)
+	}
+
+	return nil
+}
+
+// createAuditTable creates the Glue table and Athena workgroup for querying audit events.
+func (s *AgentCoreStack) createAuditTable(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	stackName := s.Config.StackName
+	databaseName := auditDatabaseName(stackName)
+
+	database, err := glue.NewCatalogDatabase(ctx, s.ResourceName("audit-database"), &glue.CatalogDatabaseArgs{
+		Name:        pulumi.String(databaseName),
+		Description: pulumi.Sprintf("Audit events for %s agents", stackName),
+		Tags:        mergeTags(tags, pulumi.String(databaseName)),
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	var columns glue.CatalogTableStorageDescriptorColumnArray
+	for _, col := range auditColumns {
+		columns = append(columns, &glue.CatalogTableStorageDescriptorColumnArgs{
+			Name:    pulumi.String(col.name),
+			Type:    pulumi.String(col.typ),
+			Comment: pulumi.String(col.comment),
+		})
+	}
+
+	location := pulumi.Sprintf("s3://%s/events/", s.AuditBucket.Bucket)
+
+	// Partition projection avoids crawlers or MSCK REPAIR for new days
+	_, err = glue.NewCatalogTable(ctx, s.ResourceName("audit-table"), &glue.CatalogTableArgs{
+		DatabaseName: database.Name,
+		Name:         pulumi.String("tool_invocations"),
+		Description:  pulumi.String("Agent tool invocation audit events"),
+		TableType:    pulumi.String("EXTERNAL_TABLE"),
+		Parameters: pulumi.StringMap{
+			"classification":              pulumi.String("json"),
+			"projection.enabled":          pulumi.String("true"),
+			"projection.dt.type":          pulumi.String("date"),
+			"projection.dt.format":        pulumi.String("yyyy-MM-dd"),
+			"projection.dt.range":         pulumi.String("2024-01-01,NOW"),
+			"projection.dt.interval":      pulumi.String("1"),
+			"projection.dt.interval.unit": pulumi.String("DAYS"),
+			"storage.location.template":   pulumi.Sprintf("%s${dt}/", location),
+		},
+		PartitionKeys: glue.CatalogTablePartitionKeyArray{
+			&glue.CatalogTablePartitionKeyArgs{
+				Name: pulumi.String("dt"),
+				Type: pulumi.String("string"),
+			},
+		},
+		StorageDescriptor: &glue.CatalogTableStorageDescriptorArgs{
+			Location:     location,
+			InputFormat:  pulumi.String("org.apache.hadoop.mapred.TextInputFormat"),
+			OutputFormat: pulumi.String("org.apache.hadoop.hive.ql.io.HiveIgnoreKeyTextOutputFormat"),
+			Compressed:   pulumi.Bool(true),
+			SerDeInfo: &glue.CatalogTableStorageDescriptorSerDeInfoArgs{
+				SerializationLibrary: pulumi.String("org.openx.data.jsonserde.JsonSerDe"),
+				Parameters: pulumi.StringMap{
+					"ignore.malformed.json": pulumi.String("true"),
+				},
+			},
+			Columns: columns,
+		},
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	// Query results go to a separate bucket; the audit bucket is write-once
+	results, err := s.newPrivateBucket(ctx, "audit-results-bucket", "audit-results", tags)
+	if err != nil {
+		return err
+	}
+
+	_, err = athena.NewWorkgroup(ctx, s.ResourceName("audit-workgroup"), &athena.WorkgroupArgs{
+		Name:         pulumi.Sprintf("%s-audit", stackName),
+		Description:  pulumi.Sprintf("Audit queries for %s agents", stackName),
+		ForceDestroy: pulumi.Bool(s.Config.RemovalPolicy != "retain"),
+		Configuration: &athena.WorkgroupConfigurationArgs{
+			EnforceWorkgroupConfiguration: pulumi.Bool(true),
+			ResultConfiguration: &athena.WorkgroupConfigurationResultConfigurationArgs{
+				OutputLocation: pulumi.Sprintf("s3://%s/", results.Bucket),
+			},
+		},
+		Tags: mergeTags(tags, pulumi.Sprintf("%s-audit", stackName)),
+	}, s.child())
+	return err
+}
@@ -0,0 +1,214 @@
+// Package auto deploys AgentCore stacks with the Pulumi Automation API.
+// It runs an inline program built from a StackConfig, so platform services
+// can deploy agent teams without a checked-out Pulumi project.
+package auto
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/plexusone/agentkit-aws-pulumi/agentcore"
+	pulumiutil "github.com/plexusone/agentkit-aws-pulumi/deploy/pulumi"
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optdestroy"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optpreview"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optrefresh"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optup"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// DefaultProjectName is the Pulumi project of stacks deployed without
+// Options.ProjectName.
+const DefaultProjectName = "agentcore"
+
+// Options configures an Automation API operation.
+type Options struct {
+	// ProjectName is the Pulumi project name.
+	// Default: DefaultProjectName
+	ProjectName string
+
+	// StackName is the Pulumi stack name.
+	// Default: the StackConfig's StackName
+	StackName string
+
+	// Region is the AWS region, set as the aws:region config.
+	Region string
+
+	// BackendURL is the Pulumi state backend URL.
+	BackendURL string
+
+	// SecretsProvider configures how secrets are encrypted.
+	SecretsProvider string
+
+	// Config contains additional stack configuration values.
+	Config map[string]string
+
+	// EnvVars are additional environment variables for the Pulumi process.
+	EnvVars map[string]string
+
+	// StackOptions are the Pulumi-specific options of the AgentCore stack.
+	StackOptions agentcore.Options
+
+	// Lock, if set, prevents overlapping operations against the stack.
+	Lock *pulumiutil.LockOptions
+
+	// Approval, if set, requires approval before deploy and destroy.
+	Approval *pulumiutil.ApprovalOptions
+
+	// Progress, if set, receives the operation's progress output.
+	Progress io.Writer
+}
+
+// Program returns the inline Pulumi program that deploys the stack.
+func Program(config iac.StackConfig, options agentcore.Options) pulumi.RunFunc {
+	return func(ctx *pulumi.Context) error {
+		_, err := agentcore.NewAgentCoreStackWithOptions(ctx, config, options)
+		return err
+	}
+}
+
+// Deploy creates or updates the stack.
+func Deploy(ctx context.Context, config iac.StackConfig, opts *Options) (*pulumiutil.UpResult, error) {
+	stack, opts, err := openStack(ctx, config, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = stack.Close() }()
+
+	var upOpts []optup.Option
+	if opts.Progress != nil {
+		upOpts = append(upOpts, optup.ProgressStreams(opts.Progress))
+	}
+	return stack.Up(ctx, upOpts...)
+}
+
+// Preview previews the changes Deploy would make.
+func Preview(ctx context.Context, config iac.StackConfig, opts *Options) (*pulumiutil.PreviewResult, error) {
+	stack, opts, err := openStack(ctx, config, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = stack.Close() }()
+
+	var previewOpts []optpreview.Option
+	if opts.Progress != nil {
+		previewOpts = append(previewOpts, optpreview.ProgressStreams(opts.Progress))
+	}
+	return stack.Preview(ctx, previewOpts...)
+}
+
+// Destroy deletes the stack's resources.
+func Destroy(ctx context.Context, config iac.StackConfig, opts *Options) error {
+	stack, opts, err := openStack(ctx, config, opts)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = stack.Close() }()
+
+	var destroyOpts []optdestroy.Option
+	if opts.Progress != nil {
+		destroyOpts = append(destroyOpts, optdestroy.ProgressStreams(opts.Progress))
+	}
+	return stack.Destroy(ctx, destroyOpts...)
+}
+
+// Refresh updates the stack's state from the deployed resources.
+func Refresh(ctx context.Context, config iac.StackConfig, opts *Options) error {
+	stack, opts, err := openStack(ctx, config, opts)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = stack.Close() }()
+
+	var refreshOpts []optrefresh.Option
+	if opts.Progress != nil {
+		refreshOpts = append(refreshOpts, optrefresh.ProgressStreams(opts.Progress))
+	}
+	return stack.Refresh(ctx, refreshOpts...)
+}
+
+// openStack creates or selects the stack with the inline program.
+func openStack(ctx context.Context, config iac.StackConfig, opts *Options) (*pulumiutil.Stack, *Options, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	if config.StackName == "" {
+		return nil, nil, fmt.Errorf("auto: stack name is required")
+	}
+
+	projectName := opts.ProjectName
+	if projectName == "" {
+		projectName = DefaultProjectName
+	}
+	stackName := opts.StackName
+	if stackName == "" {
+		stackName = config.StackName
+	}
+
+	stackConfig := make(map[string]string, len(opts.Config)+1)
+	for k, v := range opts.Config {
+		stackConfig[k] = v
+	}
+	if opts.Region != "" {
+		stackConfig["aws:region"] = opts.Region
+	}
+
+	stack, err := pulumiutil.NewStack(ctx, pulumiutil.StackOptions{
+		ProjectName:     projectName,
+		StackName:       stackName,
+		BackendURL:      opts.BackendURL,
+		SecretsProvider: opts.SecretsProvider,
+		Config:          stackConfig,
+		EnvVars:         opts.EnvVars,
+		Lock:            opts.Lock,
+		Approval:        opts.Approval,
+	}, Program(config, opts.StackOptions))
+	if err != nil {
+		return nil, nil, fmt.Errorf("auto: %w", err)
+	}
+	return stack, opts, nil
+}
+
+// SetMaintenanceMode turns maintenance mode of the stack's agent APIs on or
+// off by deploying it with opts.StackOptions.Maintenance enabled or
+// disabled; opts.StackOptions.Maintenance is defaulted if unset. Other
+// pending changes are deployed too, so pass the configuration and options
+// the stack was last deployed with, and keep Maintenance enabled in later
+// deploys until maintenance is over.
+func SetMaintenanceMode(ctx context.Context, config iac.StackConfig, opts *Options, enabled bool) (*pulumiutil.UpResult, error) {
+	withMaintenance := Options{}
+	if opts != nil {
+		withMaintenance = *opts
+	}
+	var maintenance agentcore.MaintenanceConfig
+	if withMaintenance.StackOptions.Maintenance != nil {
+		maintenance = *withMaintenance.StackOptions.Maintenance
+	}
+	maintenance.Enabled = enabled
+	withMaintenance.StackOptions.Maintenance = &maintenance
+	return Deploy(ctx, config, &withMaintenance)
+}
+
+// SetRegionWeight sets the weight of the stack's region in the weighted
+// routing of its agent subdomains and deploys it, to shift traffic to or
+// away from the region. The weight is set as the agentcore:regionWeight
+// config value; like SetMaintenanceMode, other pending changes are deployed
+// too, and later deploys must pass the weight in opts.Config to keep it.
+func SetRegionWeight(ctx context.Context, config iac.StackConfig, opts *Options, weight int) (*pulumiutil.UpResult, error) {
+	if weight < 0 || weight > 255 {
+		return nil, fmt.Errorf("auto: region weight must be between 0 and 255")
+	}
+	withWeight := Options{}
+	if opts != nil {
+		withWeight = *opts
+	}
+	stackConfig := make(map[string]string, len(withWeight.Config)+1)
+	for k, v := range withWeight.Config {
+		stackConfig[k] = v
+	}
+	stackConfig[agentcore.RegionWeightConfigKey] = strconv.Itoa(weight)
+	withWeight.Config = stackConfig
+	return Deploy(ctx, config, &withWeight)
+}
@@ -0,0 +1,277 @@
+package auto
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sfn"
+	sfntypes "github.com/aws/aws-sdk-go-v2/service/sfn/types"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optdestroy"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+)
+
+// Checks run before a guarded teardown.
+const (
+	// CheckNonEmptyBucket finds S3 buckets that still hold objects.
+	CheckNonEmptyBucket = "nonEmptyBucket"
+
+	// CheckRetainedSnapshot finds databases that take a final snapshot when
+	// deleted, which outlives the stack.
+	CheckRetainedSnapshot = "retainedSnapshot"
+
+	// CheckActiveSchedule finds enabled schedules, which may start runs
+	// while the stack is being deleted.
+	CheckActiveSchedule = "activeSchedule"
+
+	// CheckInFlightExecution finds running workflow executions, such as
+	// pending approvals, and queues holding work that has not been
+	// processed yet.
+	CheckInFlightExecution = "inFlightExecution"
+)
+
+// TeardownOptions configures GuardedDestroy.
+type TeardownOptions struct {
+	// AWSConfig is the configuration of the S3, SQS and Step Functions
+	// clients that inspect the stack's buckets, queues and workflows.
+	AWSConfig aws.Config
+
+	// Force destroys the stack despite findings.
+	Force bool
+}
+
+// TeardownFinding is a stack resource whose deletion may lose data.
+type TeardownFinding struct {
+	// Check is the check that found the resource, e.g. CheckNonEmptyBucket.
+	Check string `json:"check"`
+
+	// Resource is the resource's physical ID, such as a bucket name or
+	// queue URL.
+	Resource string `json:"resource"`
+
+	// Detail explains the finding.
+	Detail string `json:"detail"`
+}
+
+// TeardownReport is the result of a guarded teardown.
+type TeardownReport struct {
+	// StackName is the Pulumi stack.
+	StackName string `json:"stackName"`
+
+	// Findings are the resources whose deletion may lose data.
+	Findings []TeardownFinding `json:"findings"`
+
+	// Retained are the physical IDs of resources that are kept when the
+	// stack is destroyed, such as buckets created with RetainOnDelete.
+	Retained []string `json:"retained,omitempty"`
+
+	// Forced is set when the stack was destroyed despite findings.
+	Forced bool `json:"forced"`
+
+	// Destroyed is set when the stack was destroyed.
+	Destroyed bool `json:"destroyed"`
+}
+
+// Text returns the report as human-readable text.
+func (r *TeardownReport) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Teardown of stack %s\n", r.StackName)
+	if len(r.Findings) == 0 {
+		b.WriteString("No findings.\n")
+	}
+	for _, f := range r.Findings {
+		fmt.Fprintf(&b, "  [%s] %s: %s\n", f.Check, f.Resource, f.Detail)
+	}
+	for _, id := range r.Retained {
+		fmt.Fprintf(&b, "  [retained] %s\n", id)
+	}
+	switch {
+	case r.Destroyed && r.Forced:
+		b.WriteString("Destroyed despite findings (forced).\n")
+	case r.Destroyed:
+		b.WriteString("Destroyed.\n")
+	default:
+		b.WriteString("Not destroyed.\n")
+	}
+	return b.String()
+}
+
+// TeardownBlockedError is returned when GuardedDestroy finds resources whose
+// deletion may lose data and TeardownOptions.Force is not set.
+type TeardownBlockedError struct {
+	// Report lists the findings.
+	Report *TeardownReport
+}
+
+func (e *TeardownBlockedError) Error() string {
+	return fmt.Sprintf("auto: teardown of stack %s blocked by %d findings; set Force to destroy anyway",
+		e.Report.StackName, len(e.Report.Findings))
+}
+
+// GuardedDestroy deletes the stack's resources like Destroy, after checking
+// its state for buckets that still hold objects, databases that retain
+// final snapshots, enabled schedules, running workflow executions and
+// queues with unprocessed messages. If anything is found, the stack is only
+// destroyed with TeardownOptions.Force. Resources that cannot be inspected
+// are reported as findings.
+//
+// The report is returned whether or not the stack was destroyed.
+func GuardedDestroy(ctx context.Context, config iac.StackConfig, opts *Options, teardown TeardownOptions) (*TeardownReport, error) {
+	stack, opts, err := openStack(ctx, config, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = stack.Close() }()
+
+	state, err := stack.Export(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("auto: %w", err)
+	}
+	var deployment apitype.DeploymentV3
+	if err := json.Unmarshal(state.Deployment, &deployment); err != nil {
+		return nil, fmt.Errorf("auto: failed to read stack state: %w", err)
+	}
+
+	report := &TeardownReport{StackName: stack.Name()}
+	checkTeardown(ctx, teardown.AWSConfig, deployment.Resources, report)
+	sort.Slice(report.Findings, func(i, j int) bool {
+		if report.Findings[i].Check != report.Findings[j].Check {
+			return report.Findings[i].Check < report.Findings[j].Check
+		}
+		return report.Findings[i].Resource < report.Findings[j].Resource
+	})
+	sort.Strings(report.Retained)
+
+	if len(report.Findings) > 0 {
+		if !teardown.Force {
+			return report, &TeardownBlockedError{Report: report}
+		}
+		report.Forced = true
+	}
+
+	var destroyOpts []optdestroy.Option
+	if opts.Progress != nil {
+		destroyOpts = append(destroyOpts, optdestroy.ProgressStreams(opts.Progress))
+	}
+	if err := stack.Destroy(ctx, destroyOpts...); err != nil {
+		return report, err
+	}
+	report.Destroyed = true
+	return report, nil
+}
+
+// checkTeardown adds the findings of the stack's resources to the report.
+func checkTeardown(ctx context.Context, cfg aws.Config, resources []apitype.ResourceV3, report *TeardownReport) {
+	var s3Client *s3.Client
+	var sqsClient *sqs.Client
+	var sfnClient *sfn.Client
+
+	for _, res := range resources {
+		if res.Delete || !res.Custom || res.ID == "" {
+			continue
+		}
+		id := string(res.ID)
+		if res.RetainOnDelete {
+			report.Retained = append(report.Retained, id)
+			continue
+		}
+
+		switch string(res.Type) {
+		case "aws:s3/bucketV2:BucketV2", "aws:s3/bucket:Bucket":
+			if s3Client == nil {
+				s3Client = s3.NewFromConfig(cfg)
+			}
+			out, err := s3Client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+				Bucket:  aws.String(id),
+				MaxKeys: aws.Int32(1),
+			})
+			switch {
+			case err != nil:
+				report.Findings = append(report.Findings, TeardownFinding{CheckNonEmptyBucket, id,
+					fmt.Sprintf("could not be checked: %v", err)})
+			case aws.ToInt32(out.KeyCount) > 0:
+				report.Findings = append(report.Findings, TeardownFinding{CheckNonEmptyBucket, id,
+					"bucket holds objects that are deleted with it"})
+			}
+
+		case "aws:sqs/queue:Queue":
+			if sqsClient == nil {
+				sqsClient = sqs.NewFromConfig(cfg)
+			}
+			out, err := sqsClient.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+				QueueUrl: aws.String(id),
+				AttributeNames: []sqstypes.QueueAttributeName{
+					sqstypes.QueueAttributeNameApproximateNumberOfMessages,
+					sqstypes.QueueAttributeNameApproximateNumberOfMessagesNotVisible,
+					sqstypes.QueueAttributeNameApproximateNumberOfMessagesDelayed,
+				},
+			})
+			if err != nil {
+				report.Findings = append(report.Findings, TeardownFinding{CheckInFlightExecution, id,
+					fmt.Sprintf("could not be checked: %v", err)})
+				continue
+			}
+			waiting := queueCount(out.Attributes, sqstypes.QueueAttributeNameApproximateNumberOfMessages) +
+				queueCount(out.Attributes, sqstypes.QueueAttributeNameApproximateNumberOfMessagesDelayed)
+			processing := queueCount(out.Attributes, sqstypes.QueueAttributeNameApproximateNumberOfMessagesNotVisible)
+			if waiting+processing > 0 {
+				report.Findings = append(report.Findings, TeardownFinding{CheckInFlightExecution, id,
+					fmt.Sprintf("%d messages waiting and %d being processed", waiting, processing)})
+			}
+
+		case "aws:sfn/stateMachine:StateMachine":
+			if sfnClient == nil {
+				sfnClient = sfn.NewFromConfig(cfg)
+			}
+			out, err := sfnClient.ListExecutions(ctx, &sfn.ListExecutionsInput{
+				StateMachineArn: aws.String(id),
+				StatusFilter:    sfntypes.ExecutionStatusRunning,
+				MaxResults:      100,
+			})
+			switch {
+			case err != nil:
+				report.Findings = append(report.Findings, TeardownFinding{CheckInFlightExecution, id,
+					fmt.Sprintf("could not be checked: %v", err)})
+			case len(out.Executions) == 100:
+				report.Findings = append(report.Findings, TeardownFinding{CheckInFlightExecution, id,
+					"at least 100 executions are running"})
+			case len(out.Executions) > 0:
+				report.Findings = append(report.Findings, TeardownFinding{CheckInFlightExecution, id,
+					fmt.Sprintf("%d executions are running", len(out.Executions))})
+			}
+
+		case "aws:scheduler/schedule:Schedule":
+			if state, _ := res.Outputs["state"].(string); state != "DISABLED" {
+				report.Findings = append(report.Findings, TeardownFinding{CheckActiveSchedule, id,
+					fmt.Sprintf("schedule %v is enabled", res.Outputs["scheduleExpression"])})
+			}
+
+		case "aws:cloudwatch/eventRule:EventRule":
+			expression, _ := res.Outputs["scheduleExpression"].(string)
+			if state, _ := res.Outputs["state"].(string); expression != "" && state != "DISABLED" {
+				report.Findings = append(report.Findings, TeardownFinding{CheckActiveSchedule, id,
+					fmt.Sprintf("schedule %s is enabled", expression)})
+			}
+
+		default:
+			if skip, ok := res.Outputs["skipFinalSnapshot"].(bool); ok && !skip {
+				report.Findings = append(report.Findings, TeardownFinding{CheckRetainedSnapshot, id,
+					fmt.Sprintf("final snapshot %v is retained after deletion", res.Outputs["finalSnapshotIdentifier"])})
+			}
+		}
+	}
+}
+
+// queueCount returns a numeric queue attribute, or 0 if it is missing.
+func queueCount(attributes map[string]string, name sqstypes.QueueAttributeName) int {
+	n, _ := strconv.Atoi(attributes[string(name)])
+	return n
+}
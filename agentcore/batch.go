@@ -0,0 +1,252 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/cloudwatch"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/scheduler"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// batchJobNamePattern restricts batch job names to characters valid in Bedrock job names.
+var batchJobNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9-]{0,30}$`)
+
+// BatchJobConfig configures a scheduled Bedrock batch inference job that
+// reads JSONL records from an S3 input prefix and writes results to an
+// output prefix, for agents that do nightly bulk processing.
+type BatchJobConfig struct {
+	// Name is the unique job name within the stack.
+	Name string `json:"name" yaml:"name"`
+
+	// ModelID is the Bedrock model or inference profile used for the job.
+	ModelID string `json:"modelId" yaml:"modelId"`
+
+	// Schedule is an EventBridge Scheduler expression, e.g. "cron(0 2 * * ? *)".
+	Schedule string `json:"schedule" yaml:"schedule"`
+
+	// Timezone is the IANA timezone for cron schedules.
+	// Default: "UTC"
+	Timezone string `json:"timezone,omitempty" yaml:"timezone,omitempty"`
+
+	// Bucket is an existing S3 bucket for input and output.
+	// If empty, a stack bucket is created.
+	Bucket string `json:"bucket,omitempty" yaml:"bucket,omitempty"`
+
+	// InputPrefix is the S3 prefix holding JSONL input records.
+	// Default: "batch/{name}/input/"
+	InputPrefix string `json:"inputPrefix,omitempty" yaml:"inputPrefix,omitempty"`
+
+	// OutputPrefix is the S3 prefix job results are written to.
+	// Default: "batch/{name}/output/"
+	OutputPrefix string `json:"outputPrefix,omitempty" yaml:"outputPrefix,omitempty"`
+
+	// TimeoutHours is the maximum job duration.
+	// Range: 24-168
+	// Default: 24
+	TimeoutHours int `json:"timeoutHours,omitempty" yaml:"timeoutHours,omitempty"`
+
+	// NotificationTopicARN is an existing SNS topic for completion notifications.
+	// If empty, a stack topic is created.
+	NotificationTopicARN string `json:"notificationTopicARN,omitempty" yaml:"notificationTopicARN,omitempty"`
+
+	// NotifyEmails are subscribed to the created notification topic.
+	NotifyEmails []string `json:"notifyEmails,omitempty" yaml:"notifyEmails,omitempty"`
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *BatchJobConfig) ApplyDefaults() {
+	if c.Timezone == "" {
+		c.Timezone = "UTC"
+	}
+	if c.InputPrefix == "" {
+		c.InputPrefix = fmt.Sprintf("batch/%s/input/", c.Name)
+	}
+	if c.OutputPrefix == "" {
+		c.OutputPrefix = fmt.Sprintf("batch/%s/output/", c.Name)
+	}
+	if c.TimeoutHours == 0 {
+		c.TimeoutHours = 24
+	}
+}
+
+// Validate validates the BatchJobConfig.
+func (c *BatchJobConfig) Validate() error {
+	if !batchJobNamePattern.MatchString(c.Name) {
+		return fmt.Errorf("batchJobs: name %q must match %s", c.Name, batchJobNamePattern)
+	}
+	if c.ModelID == "" {
+		return fmt.Errorf("batchJobs[%s]: modelId is required", c.Name)
+	}
+	if c.Schedule == "" {
+		return fmt.Errorf("batchJobs[%s]: schedule is required", c.Name)
+	}
+	if c.TimeoutHours < 24 || c.TimeoutHours > 168 {
+		return fmt.Errorf("batchJobs[%s]: timeoutHours must be between 24 and 168", c.Name)
+	}
+	return nil
+}
+
+// createBatchJobs creates the schedules, roles, storage and notifications for batch inference jobs.
+func (s *AgentCoreStack) createBatchJobs(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	stackName := s.Config.StackName
+	jobs := s.Options.BatchJobs
+
+	// Shared storage and notifications for jobs that don't bring their own
+	var bucketName pulumi.StringOutput
+	var topicArn pulumi.StringOutput
+	for _, job := range jobs {
+		if job.Bucket == "" && s.BatchBucket == nil {
+			bucket, err := s.newPrivateBucket(ctx, "batch-bucket", "batch", tags)
+			if err != nil {
+				return err
+			}
+			s.BatchBucket = bucket
+			bucketName = bucket.Bucket
+		}
+		if job.NotificationTopicARN == "" && s.BatchNotifications == nil {
+			topic, err := s.newEventTopic(ctx, "batch-notifications", fmt.Sprintf("%s-batch-notifications", stackName), tags)
+			if err != nil {
+				return err
+			}
+			s.BatchNotifications = topic
+			topicArn = topic.Arn
+		}
+	}
+
+	// Role assumed by Bedrock to read input and write output
+	bedrockRole, err := s.newServiceRole(ctx, "batch-bedrock-role", "bedrock.amazonaws.com", tags)
+	if err != nil {
+		return err
+	}
+
+	// Role assumed by EventBridge Scheduler to start jobs
+	schedulerRole, err := s.newServiceRole(ctx, "batch-scheduler-role", "scheduler.amazonaws.com", tags)
+	if err != nil {
+		return err
+	}
+
+	var objectArns, bucketArns, modelArns pulumi.StringArray
+	for _, job := range jobs {
+		bucket := pulumi.String(job.Bucket).ToStringOutput()
+		if job.Bucket == "" {
+			bucket = bucketName
+		}
+		topic := pulumi.String(job.NotificationTopicARN).ToStringOutput()
+		if job.NotificationTopicARN == "" {
+			topic = topicArn
+		}
+
+		bucketArns = append(bucketArns, pulumi.Sprintf("arn:aws:s3:::%s", bucket))
+		objectArns = append(objectArns,
+			pulumi.Sprintf("arn:aws:s3:::%s/%s*", bucket, job.InputPrefix),
+			pulumi.Sprintf("arn:aws:s3:::%s/%s*", bucket, job.OutputPrefix))
+		modelArns = append(modelArns,
+			pulumi.Sprintf("arn:aws:bedrock:*::foundation-model/%s", job.ModelID),
+			pulumi.Sprintf("arn:aws:bedrock:*:*:inference-profile/%s", job.ModelID))
+
+		// Scheduler execution IDs keep job names unique across runs
+		input := pulumi.All(bucket, bedrockRole.Arn).ApplyT(func(args []interface{}) (string, error) {
+			data, err := json.Marshal(map[string]interface{}{
+				"JobName":                fmt.Sprintf("%s-%s-<aws.scheduler.execution-id>", stackName, job.Name),
+				"ModelId":                job.ModelID,
+				"RoleArn":                args[1].(string),
+				"TimeoutDurationInHours": job.TimeoutHours,
+				"InputDataConfig": map[string]interface{}{
+					"S3InputDataConfig": map[string]interface{}{
+						"S3Uri": fmt.Sprintf("s3://%s/%s", args[0].(string), job.InputPrefix),
+					},
+				},
+				"OutputDataConfig": map[string]interface{}{
+					"S3OutputDataConfig": map[string]interface{}{
+						"S3Uri": fmt.Sprintf("s3://%s/%s", args[0].(string), job.OutputPrefix),
+					},
+				},
+			})
+			return string(data), err
+		}).(pulumi.StringOutput)
+
+		_, err = scheduler.NewSchedule(ctx, s.ResourceName(fmt.Sprintf("batch-%s-schedule", job.Name)), &scheduler.ScheduleArgs{
+			Name:                       pulumi.Sprintf("%s-batch-%s", stackName, job.Name),
+			Description:                pulumi.Sprintf("Bedrock batch inference job %s", job.Name),
+			ScheduleExpression:         pulumi.String(job.Schedule),
+			ScheduleExpressionTimezone: pulumi.String(job.Timezone),
+			FlexibleTimeWindow: &scheduler.ScheduleFlexibleTimeWindowArgs{
+				Mode: pulumi.String("OFF"),
+			},
+			Target: &scheduler.ScheduleTargetArgs{
+				Arn:     pulumi.String("arn:aws:scheduler:::aws-sdk:bedrock:createModelInvocationJob"),
+				RoleArn: schedulerRole.Arn,
+				Input:   input,
+			},
+		}, s.child())
+		if err != nil {
+			return err
+		}
+
+		// Route job completion events to the notification topic
+		pattern, err := json.Marshal(map[string]interface{}{
+			"source":      []string{"aws.bedrock"},
+			"detail-type": []string{"Batch Inference Job State Change"},
+			"detail": map[string]interface{}{
+				"batchJobName": []map[string]string{{"prefix": fmt.Sprintf("%s-%s-", stackName, job.Name)}},
+				"status":       []string{"Completed", "PartiallyCompleted", "Failed", "Stopped", "Expired"},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		rule, err := cloudwatch.NewEventRule(ctx, s.ResourceName(fmt.Sprintf("batch-%s-completion", job.Name)), &cloudwatch.EventRuleArgs{
+			Name:         pulumi.Sprintf("%s-batch-%s-completion", stackName, job.Name),
+			Description:  pulumi.Sprintf("Completion of batch inference job %s", job.Name),
+			EventPattern: pulumi.String(string(pattern)),
+			Tags:         mergeTags(tags, pulumi.Sprintf("%s-batch-%s-completion", stackName, job.Name)),
+		}, s.child())
+		if err != nil {
+			return err
+		}
+		_, err = cloudwatch.NewEventTarget(ctx, s.ResourceName(fmt.Sprintf("batch-%s-completion-target", job.Name)), &cloudwatch.EventTargetArgs{
+			Rule: rule.Name,
+			Arn:  topic,
+		}, s.child())
+		if err != nil {
+			return err
+		}
+
+		if job.NotificationTopicARN == "" {
+			if err := s.subscribeEmails(ctx, fmt.Sprintf("batch-%s-email", job.Name), topic, job.NotifyEmails); err != nil {
+				return err
+			}
+		}
+	}
+
+	err = s.newRolePolicy(ctx, "batch-bedrock-policy", bedrockRole.Name,
+		policyStatement{
+			Actions:   []string{"s3:ListBucket"},
+			Resources: bucketArns,
+		},
+		policyStatement{
+			Actions:   []string{"s3:GetObject", "s3:PutObject"},
+			Resources: objectArns,
+		},
+		policyStatement{
+			Actions:   []string{"bedrock:InvokeModel"},
+			Resources: modelArns,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	return s.newRolePolicy(ctx, "batch-scheduler-policy", schedulerRole.Name,
+		policyStatement{
+			Actions:   []string{"bedrock:CreateModelInvocationJob", "bedrock:TagResource"},
+			Resources: pulumi.StringArray{pulumi.String("*")},
+		},
+		policyStatement{
+			Actions:   []string{"iam:PassRole"},
+			Resources: pulumi.StringArray{bedrockRole.Arn},
+		},
+	)
+}
@@ -0,0 +1,162 @@
+package agentcore
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+)
+
+// regionPattern matches AWS region names such as "us-east-1".
+var regionPattern = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-[0-9]$`)
+
+// bedrockInvokeActions are the actions that invoke Bedrock models.
+var bedrockInvokeActions = []string{
+	"bedrock:InvokeModel",
+	"bedrock:InvokeModelWithResponseStream",
+}
+
+// bedrockModelResourceTypes are the Bedrock resource types that
+// IAM.BedrockModelIDs may name by ARN.
+var bedrockModelResourceTypes = []string{
+	"foundation-model",
+	"inference-profile",
+	"application-inference-profile",
+}
+
+// validateBedrockModelIDs validates the ARNs in IAM.BedrockModelIDs and
+// Options.BedrockRegions.
+func validateBedrockModelIDs(config iac.StackConfig, regions []string) error {
+	for _, region := range regions {
+		if !regionPattern.MatchString(region) {
+			return fmt.Errorf("bedrockRegions: '%s' is not a region", region)
+		}
+	}
+	if config.IAM == nil {
+		return nil
+	}
+	for _, modelID := range config.IAM.BedrockModelIDs {
+		if !strings.HasPrefix(modelID, "arn:") {
+			continue
+		}
+		if _, _, ok := parseBedrockModelARN(modelID); !ok {
+			return fmt.Errorf("iam.bedrockModelIds: '%s' is not a foundation model or inference profile ARN", modelID)
+		}
+	}
+	return nil
+}
+
+// parseBedrockModelARN returns the resource type and ID of a foundation
+// model or inference profile ARN.
+func parseBedrockModelARN(arn string) (resourceType, id string, ok bool) {
+	// arn:{partition}:bedrock:{region}:{account}:{type}/{id}
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" || parts[2] != "bedrock" {
+		return "", "", false
+	}
+	resourceType, id, found := strings.Cut(parts[5], "/")
+	if !found || id == "" {
+		return "", "", false
+	}
+	for _, t := range bedrockModelResourceTypes {
+		if t == resourceType {
+			return resourceType, id, true
+		}
+	}
+	return "", "", false
+}
+
+// bedrockModelStatements returns the statements that let agents invoke the
+// models, in the regions, or every region when there are none.
+//
+// Model IDs may be foundation model IDs, cross-region inference profile IDs
+// such as "us.anthropic.claude-sonnet-4-20250514-v1:0", or foundation model,
+// inference profile and application inference profile ARNs. Without model
+// IDs, agents may invoke any model or profile. Inference profiles route
+// requests to foundation models in other regions, so the models behind
+// them are allowed in every region, but only through the profiles.
+func bedrockModelStatements(modelIDs, regions []string) []Statement {
+	if len(regions) == 0 {
+		regions = []string{"*"}
+	}
+
+	var resources, profiles, profileModels []string
+	if len(modelIDs) == 0 {
+		for _, region := range regions {
+			resources = append(resources,
+				fmt.Sprintf("arn:aws:bedrock:%s::foundation-model/*", region),
+				fmt.Sprintf("arn:aws:bedrock:%s:*:inference-profile/*", region),
+				fmt.Sprintf("arn:aws:bedrock:%s:*:application-inference-profile/*", region),
+			)
+			profiles = append(profiles, fmt.Sprintf("arn:aws:bedrock:%s:*:*inference-profile/*", region))
+		}
+		profileModels = []string{"arn:aws:bedrock:*::foundation-model/*"}
+	}
+	for _, modelID := range modelIDs {
+		switch {
+		case strings.HasPrefix(modelID, "arn:"):
+			resources = append(resources, modelID)
+			resourceType, id, _ := parseBedrockModelARN(modelID)
+			switch resourceType {
+			case "inference-profile":
+				profiles = append(profiles, modelID)
+				profileModels = append(profileModels, bedrockProfileModelARN(id))
+			case "application-inference-profile":
+				// The models behind an application inference profile are
+				// not known until it is read, so any model is allowed
+				// through it
+				profiles = append(profiles, modelID)
+				profileModels = append(profileModels, "arn:aws:bedrock:*::foundation-model/*")
+			}
+		case inferenceProfilePattern.MatchString(modelID):
+			for _, region := range regions {
+				arn := fmt.Sprintf("arn:aws:bedrock:%s:*:inference-profile/%s", region, modelID)
+				resources = append(resources, arn)
+				profiles = append(profiles, arn)
+			}
+			profileModels = append(profileModels, bedrockProfileModelARN(modelID))
+		default:
+			for _, region := range regions {
+				resources = append(resources, fmt.Sprintf("arn:aws:bedrock:%s::foundation-model/%s", region, modelID))
+			}
+		}
+	}
+
+	statements := []Statement{{
+		Effect:   "Allow",
+		Action:   bedrockInvokeActions,
+		Resource: uniqueStrings(resources),
+	}}
+	if len(profiles) > 0 {
+		statements = append(statements, Statement{
+			Effect:   "Allow",
+			Action:   bedrockInvokeActions,
+			Resource: uniqueStrings(profileModels),
+			Condition: map[string]map[string]interface{}{
+				"StringLike": {"bedrock:InferenceProfileArn": uniqueStrings(profiles)},
+			},
+		})
+	}
+	return statements
+}
+
+// bedrockProfileModelARN returns the ARN, in any region, of the foundation
+// model behind a cross-region inference profile.
+func bedrockProfileModelARN(profileID string) string {
+	modelID := inferenceProfilePattern.ReplaceAllString(profileID, "")
+	return fmt.Sprintf("arn:aws:bedrock:*::foundation-model/%s", modelID)
+}
+
+// uniqueStrings returns values without duplicates, in order.
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	var unique []string
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
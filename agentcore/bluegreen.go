@@ -0,0 +1,296 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/ssm"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// Blue/green runtime endpoint names.
+const (
+	blueEndpointName  = "blue"
+	greenEndpointName = "green"
+)
+
+// BlueGreenConfig deploys an agent blue/green on AgentCore runtime
+// versions. Every update of the agent's runtime creates a version: the
+// green endpoint is pinned to the version the deployment created, and the
+// blue endpoint to the version that was live before it, which stays
+// deployed so traffic can return to it at once. The versions are recorded
+// in the SSM parameter /agentcore/{stack}/{agent}/versions, which the next
+// deployment reads.
+//
+// The live endpoint, which callers, schedules and workflows invoke, is the
+// stable alias of the agent. It follows green once all traffic has
+// shifted and stays on blue otherwise. With the weighted strategy the
+// agent also gets a router URL, like agents with replicas, and its
+// subdomain proxy splits traffic the same way: GreenWeight percent of
+// sessions go to green and the rest to blue. Raise the weight in later
+// updates and set it to 100 to promote green; set Rollback to return all
+// traffic to blue.
+//
+// With the canary strategy the shift is automatic: the live endpoint stays
+// on the version before the deployment, the router sends Canary.Weight
+// percent of sessions to green, and a controller promotes green to live
+// after the bake time or rolls it back when an alarm fires.
+type BlueGreenConfig struct {
+	// Strategy is how traffic shifts to a new version.
+	// Supported: "all-at-once", "weighted", "canary"
+	// Default: "all-at-once"
+	Strategy string `json:"strategy,omitempty" yaml:"strategy,omitempty"`
+
+	// GreenWeight is the percentage of sessions routed to the new version
+	// with the weighted strategy.
+	// Range: 0-100
+	// Default: 10
+	GreenWeight *int `json:"greenWeight,omitempty" yaml:"greenWeight,omitempty"`
+
+	// Canary configures the canary strategy.
+	// Default: a CanaryConfig with defaults when the strategy is canary
+	Canary *CanaryConfig `json:"canary,omitempty" yaml:"canary,omitempty"`
+
+	// Rollback routes all traffic to the previous version. The new version
+	// stays deployed on the green endpoint.
+	Rollback bool `json:"rollback,omitempty" yaml:"rollback,omitempty"`
+
+	// RetainVersions is the number of recent runtime versions kept
+	// invokable on endpoints named v{version}. Endpoints of older versions
+	// are deleted after each deployment. AgentCore has no API to delete
+	// runtime versions, so the versions themselves remain.
+	// Range: 0-10
+	// Default: 0 (no version endpoints)
+	RetainVersions int `json:"retainVersions,omitempty" yaml:"retainVersions,omitempty"`
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *BlueGreenConfig) ApplyDefaults() {
+	if c.Strategy == "" {
+		c.Strategy = "all-at-once"
+	}
+	if c.GreenWeight == nil && c.Strategy == "weighted" {
+		weight := 10
+		c.GreenWeight = &weight
+	}
+	if c.Canary == nil && c.Strategy == "canary" {
+		c.Canary = &CanaryConfig{}
+	}
+	if c.Canary != nil {
+		c.Canary.ApplyDefaults()
+	}
+}
+
+// Validate validates the BlueGreenConfig for the named agent.
+func (c *BlueGreenConfig) Validate(agentName string, replicas int) error {
+	if c.Strategy != "all-at-once" && c.Strategy != "weighted" && c.Strategy != "canary" {
+		return fmt.Errorf("agents[%s].blueGreen.strategy must be one of [all-at-once weighted canary]", agentName)
+	}
+	if c.GreenWeight != nil {
+		if c.Strategy != "weighted" {
+			return fmt.Errorf("agents[%s].blueGreen.greenWeight requires strategy weighted", agentName)
+		}
+		if *c.GreenWeight < 0 || *c.GreenWeight > 100 {
+			return fmt.Errorf("agents[%s].blueGreen.greenWeight must be between 0 and 100", agentName)
+		}
+	}
+	if c.Canary != nil {
+		if c.Strategy != "canary" {
+			return fmt.Errorf("agents[%s].blueGreen.canary requires strategy canary", agentName)
+		}
+		if err := c.Canary.Validate(agentName); err != nil {
+			return err
+		}
+	}
+	if c.RetainVersions < 0 || c.RetainVersions > maxRetainedVersions {
+		return fmt.Errorf("agents[%s].blueGreen.retainVersions must be between 0 and %d", agentName, maxRetainedVersions)
+	}
+	if replicas > 1 {
+		return fmt.Errorf("agents[%s].blueGreen cannot be combined with replicas", agentName)
+	}
+	return nil
+}
+
+// greenWeight returns the percentage of traffic routed to green. For
+// canaries it is the weight of the live endpoint, which the controller
+// moves to green.
+func (c *BlueGreenConfig) greenWeight() int {
+	switch {
+	case c.Rollback, c.Strategy == "canary":
+		return 0
+	case c.Strategy == "weighted":
+		return *c.GreenWeight
+	default:
+		return 100
+	}
+}
+
+// blueGreenVersions records the versions an agent's blue/green deployment
+// served, in an SSM parameter read by the next deployment.
+type blueGreenVersions struct {
+	// Version is the green version.
+	Version string `json:"version"`
+
+	// Previous is the blue version.
+	Previous string `json:"previous"`
+
+	// Live is the version of the live endpoint.
+	Live string `json:"live"`
+}
+
+// blueVersion returns the version the blue endpoint serves when the agent
+// is deployed at version: the version it already serves if the runtime is
+// unchanged, and otherwise the version that was live before. The first
+// deployment has nothing to fall back to and serves version on both
+// endpoints.
+func (v *blueGreenVersions) blueVersion(version string) string {
+	switch {
+	case v == nil:
+		return version
+	case v.Version == version && v.Previous != "":
+		return v.Previous
+	case v.Live != "":
+		return v.Live
+	default:
+		return version
+	}
+}
+
+// deployedVersions returns the versions recorded by the agent's last
+// deployment, or nil before its first. For canaries the live version is
+// taken from the controller's traffic state, since the controller moves
+// the live endpoint.
+func (s *AgentCoreStack) deployedVersions(ctx *pulumi.Context, agentName string) (*blueGreenVersions, error) {
+	prefix := fmt.Sprintf("/agentcore/%s/%s", s.Config.StackName, agentName)
+	params, err := ssm.GetParametersByPath(ctx, &ssm.GetParametersByPathArgs{Path: prefix}, s.invoke())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read deployed versions of agent %s: %w", agentName, err)
+	}
+	values := make(map[string]string)
+	for i, name := range params.Names {
+		if i < len(params.Values) {
+			values[name] = params.Values[i]
+		}
+	}
+
+	data, ok := values[prefix+"/versions"]
+	if !ok {
+		return nil, nil
+	}
+	var versions blueGreenVersions
+	if err := json.Unmarshal([]byte(data), &versions); err != nil {
+		return nil, fmt.Errorf("failed to parse deployed versions of agent %s: %w", agentName, err)
+	}
+
+	var traffic struct {
+		Version  string `json:"version"`
+		Status   string `json:"status"`
+		Previous string `json:"previous"`
+	}
+	if data, ok := values[prefix+"/traffic"]; ok && json.Unmarshal([]byte(data), &traffic) == nil {
+		switch {
+		case traffic.Status == "promoted" && traffic.Version != "":
+			versions.Live = traffic.Version
+		case (traffic.Status == "baking" || traffic.Status == "rolled-back") && traffic.Previous != "":
+			versions.Live = traffic.Previous
+		}
+	}
+	return &versions, nil
+}
+
+// createBlueGreenEndpoints creates the blue and green endpoints of an agent
+// deployed blue/green and returns the version the live endpoint serves.
+// Green serves the runtime's current version and blue the version that
+// was live before it was created, which is recorded in an SSM parameter
+// for the next deployment. Canary agents also get their traffic parameter.
+func (s *AgentCoreStack) createBlueGreenEndpoints(ctx *pulumi.Context, agent iac.AgentConfig, runtime *AgentRuntime, runtimeID pulumi.StringOutput, tags pulumi.StringMap) (pulumi.StringOutput, error) {
+	stackName := s.Config.StackName
+	cfg := s.Options.Agents[agent.Name].BlueGreen
+	deployed, err := s.deployedVersions(ctx, agent.Name)
+	if err != nil {
+		return pulumi.StringOutput{}, err
+	}
+	runtime.PreviousVersion = runtime.Version.ApplyT(deployed.blueVersion).(pulumi.StringOutput)
+
+	endpoints := []struct {
+		name        string
+		version     pulumi.StringOutput
+		description string
+	}{
+		{greenEndpointName, runtime.Version, fmt.Sprintf("New version of %s", agent.Name)},
+		{blueEndpointName, runtime.PreviousVersion, fmt.Sprintf("Previous version of %s", agent.Name)},
+	}
+	var created []pulumi.Resource
+	for _, endpoint := range endpoints {
+		res, err := newCloudControlResource(ctx, s.ResourceName(fmt.Sprintf("%s-runtime-endpoint-%s", agent.Name, endpoint.name)),
+			"AWS::BedrockAgentCore::RuntimeEndpoint", pulumi.Map{
+				"AgentRuntimeId":      runtimeID,
+				"AgentRuntimeVersion": endpoint.version,
+				"Name":                pulumi.String(endpoint.name),
+				"Description":         pulumi.String(endpoint.description),
+				"Tags":                tags,
+			}, s.child())
+		if err != nil {
+			return pulumi.StringOutput{}, err
+		}
+		created = append(created, res)
+	}
+
+	if cfg.Strategy == "canary" {
+		if _, err := s.createTrafficParameter(ctx, agent, tags); err != nil {
+			return pulumi.StringOutput{}, err
+		}
+	}
+
+	live := runtime.PreviousVersion
+	if cfg.greenWeight() == 100 {
+		live = runtime.Version
+	}
+
+	record := pulumi.All(runtime.Version, runtime.PreviousVersion, live).ApplyT(func(args []interface{}) (string, error) {
+		data, err := json.Marshal(blueGreenVersions{Version: args[0].(string), Previous: args[1].(string), Live: args[2].(string)})
+		return string(data), err
+	}).(pulumi.StringOutput)
+	_, err = ssm.NewParameter(ctx, s.ResourceName(fmt.Sprintf("%s-versions", agent.Name)), &ssm.ParameterArgs{
+		Name:          pulumi.Sprintf("/agentcore/%s/%s/versions", stackName, agent.Name),
+		Description:   pulumi.Sprintf("Blue/green versions of agent %s", agent.Name),
+		Type:          pulumi.String("String"),
+		InsecureValue: record,
+		Tags:          mergeTags(tags, pulumi.Sprintf("%s-%s-versions", stackName, agent.Name)),
+	}, s.child(), pulumi.DependsOn(created))
+	if err != nil {
+		return pulumi.StringOutput{}, err
+	}
+	return live, nil
+}
+
+// blueGreenProxyEnv returns the environment that makes an agent's proxy
+// split sessions between its green endpoint and the previous version, and
+// the policy statements it needs, or nil when the agent is not deployed
+// with the weighted or canary strategy.
+func (s *AgentCoreStack) blueGreenProxyEnv(agentName string) (pulumi.StringMap, []policyStatement) {
+	cfg := s.Options.Agents[agentName].BlueGreen
+	if cfg == nil {
+		return nil, nil
+	}
+	switch cfg.Strategy {
+	case "weighted":
+		return pulumi.StringMap{
+			"GREEN_WEIGHT":     pulumi.String(strconv.Itoa(cfg.greenWeight())),
+			"STABLE_QUALIFIER": pulumi.String(blueEndpointName),
+		}, nil
+	case "canary":
+		param := s.canaryParameters[agentName]
+		return pulumi.StringMap{
+			"TRAFFIC_PARAMETER": param.Name,
+			"STABLE_QUALIFIER":  pulumi.String(runtimeEndpointName),
+		}, []policyStatement{{
+			Actions:   []string{"ssm:GetParameter"},
+			Resources: pulumi.StringArray{param.Arn},
+		}}
+	default:
+		return nil, nil
+	}
+}
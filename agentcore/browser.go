@@ -0,0 +1,132 @@
+package agentcore
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// BrowserToolConfig configures the AgentCore managed Browser tool.
+type BrowserToolConfig struct {
+	// NetworkMode controls network access from browser sessions.
+	// Supported: "PUBLIC", "VPC"
+	// Default: "PUBLIC"
+	NetworkMode string `json:"networkMode,omitempty" yaml:"networkMode,omitempty"`
+
+	// SessionTimeoutSeconds is the maximum lifetime of a browser session.
+	// Range: 60-28800
+	// Default: 900
+	SessionTimeoutSeconds int `json:"sessionTimeoutSeconds,omitempty" yaml:"sessionTimeoutSeconds,omitempty"`
+
+	// RecordingBucket is an optional S3 bucket for session recordings.
+	// Requires ExecutionRoleARN with write access to the bucket.
+	RecordingBucket string `json:"recordingBucket,omitempty" yaml:"recordingBucket,omitempty"`
+
+	// RecordingPrefix is the S3 key prefix for session recordings.
+	// Default: "browser-recordings/"
+	RecordingPrefix string `json:"recordingPrefix,omitempty" yaml:"recordingPrefix,omitempty"`
+
+	// ExecutionRoleARN is the role assumed by the browser to write recordings.
+	ExecutionRoleARN string `json:"executionRoleARN,omitempty" yaml:"executionRoleARN,omitempty"`
+
+	// Agents is the list of agent names that may use the browser and
+	// receive its environment variables. If empty, all agents in the stack
+	// are included.
+	Agents []string `json:"agents,omitempty" yaml:"agents,omitempty"`
+}
+
+// DefaultBrowserToolConfig returns a BrowserToolConfig with sensible defaults.
+func DefaultBrowserToolConfig() *BrowserToolConfig {
+	return &BrowserToolConfig{
+		NetworkMode:           "PUBLIC",
+		SessionTimeoutSeconds: 900,
+	}
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *BrowserToolConfig) ApplyDefaults() {
+	if c.NetworkMode == "" {
+		c.NetworkMode = "PUBLIC"
+	}
+	if c.SessionTimeoutSeconds == 0 {
+		c.SessionTimeoutSeconds = 900
+	}
+	if c.RecordingBucket != "" && c.RecordingPrefix == "" {
+		c.RecordingPrefix = "browser-recordings/"
+	}
+}
+
+// Validate validates the BrowserToolConfig against the stack configuration.
+func (c *BrowserToolConfig) Validate(config iac.StackConfig) error {
+	if err := validateToolNetworkMode("browser", c.NetworkMode, []string{"PUBLIC", "VPC"}, config); err != nil {
+		return err
+	}
+	if c.SessionTimeoutSeconds < 60 || c.SessionTimeoutSeconds > 28800 {
+		return fmt.Errorf("browser.sessionTimeoutSeconds must be between 60 and 28800")
+	}
+	if c.RecordingBucket != "" && c.ExecutionRoleARN == "" {
+		return fmt.Errorf("browser.executionRoleARN is required when recordingBucket is set")
+	}
+	return validateAgentNames("browser.agents", c.Agents, config)
+}
+
+// createBrowserTool creates the AgentCore Browser, grants the selected
+// agents access and injects its settings into them.
+func (s *AgentCoreStack) createBrowserTool(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	var err error
+	stackName := s.Config.StackName
+	cfg := s.Options.Browser
+
+	properties := pulumi.Map{
+		"Name":                 pulumi.String(agentCoreName(stackName, "browser")),
+		"Description":          pulumi.Sprintf("Browser for %s agents", stackName),
+		"NetworkConfiguration": s.toolNetworkConfiguration(cfg.NetworkMode),
+		"Tags":                 tags,
+	}
+	if cfg.ExecutionRoleARN != "" {
+		properties["ExecutionRoleArn"] = pulumi.String(cfg.ExecutionRoleARN)
+	}
+	if cfg.RecordingBucket != "" {
+		properties["RecordingConfig"] = pulumi.Map{
+			"Enabled": pulumi.Bool(true),
+			"S3Location": pulumi.Map{
+				"Bucket": pulumi.String(cfg.RecordingBucket),
+				"Prefix": pulumi.String(cfg.RecordingPrefix),
+			},
+		}
+	}
+
+	s.Browser, err = newCloudControlResource(ctx, s.ResourceName("browser"),
+		"AWS::BedrockAgentCore::BrowserCustom", properties, s.child())
+	if err != nil {
+		return err
+	}
+
+	browserID := cloudControlAttribute(s.Browser, "BrowserId")
+	browserArn := cloudControlAttribute(s.Browser, "BrowserArn")
+
+	for _, name := range s.selectedAgents(cfg.Agents) {
+		err = s.attachAgentPolicy(ctx, name, "browser-policy", policyStatement{
+			Actions: []string{
+				"bedrock-agentcore:StartBrowserSession",
+				"bedrock-agentcore:StopBrowserSession",
+				"bedrock-agentcore:GetBrowserSession",
+				"bedrock-agentcore:ListBrowserSessions",
+				"bedrock-agentcore:UpdateBrowserStream",
+				"bedrock-agentcore:ConnectBrowserAutomationStream",
+				"bedrock-agentcore:ConnectBrowserLiveViewStream",
+			},
+			Resources: pulumi.StringArray{browserArn},
+		})
+		if err != nil {
+			return err
+		}
+
+		s.injectEnv(name, "BROWSER_ID", browserID)
+		s.injectEnv(name, "BROWSER_SESSION_TIMEOUT_SECONDS", pulumi.String(strconv.Itoa(cfg.SessionTimeoutSeconds)))
+	}
+
+	return nil
+}
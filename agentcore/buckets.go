@@ -0,0 +1,101 @@
+package agentcore
+
+import (
+	"strings"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/s3"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// maxBucketPrefixLength is the longest prefix S3 accepts for generated bucket names.
+const maxBucketPrefixLength = 37
+
+// bucketPrefix returns a lowercase S3 bucket name prefix for the stack.
+func (s *AgentCoreStack) bucketPrefix(suffix string) string {
+	prefix := strings.ToLower(s.Config.StackName + "-" + suffix + "-")
+	if len(prefix) > maxBucketPrefixLength {
+		prefix = prefix[:maxBucketPrefixLength-1] + "-"
+	}
+	return prefix
+}
+
+// newPrivateBucket creates an encrypted S3 bucket with all public access blocked.
+// The bucket is emptied on destroy unless the stack's removal policy is "retain".
+func (s *AgentCoreStack) newPrivateBucket(ctx *pulumi.Context, name, suffix string, tags pulumi.StringMap) (*s3.BucketV2, error) {
+	retain := s.Config.RemovalPolicy == "retain"
+
+	bucket, err := s3.NewBucketV2(ctx, s.ResourceName(name), &s3.BucketV2Args{
+		BucketPrefix: pulumi.String(s.bucketPrefix(suffix)),
+		ForceDestroy: pulumi.Bool(!retain),
+		Tags:         mergeTags(tags, pulumi.Sprintf("%s-%s", s.Config.StackName, suffix)),
+	}, pulumi.RetainOnDelete(retain), s.child())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.securePrivateBucket(ctx, name, bucket); err != nil {
+		return nil, err
+	}
+
+	return bucket, nil
+}
+
+// newLockedBucket creates a private bucket with S3 Object Lock in compliance
+// mode, so objects cannot be overwritten or deleted by anyone, including the
+// root user, until their retention period expires. The bucket is always
+// retained on destroy.
+func (s *AgentCoreStack) newLockedBucket(ctx *pulumi.Context, name, suffix string, retentionDays int, tags pulumi.StringMap) (*s3.BucketV2, error) {
+	bucket, err := s3.NewBucketV2(ctx, s.ResourceName(name), &s3.BucketV2Args{
+		BucketPrefix:      pulumi.String(s.bucketPrefix(suffix)),
+		ObjectLockEnabled: pulumi.Bool(true),
+		Tags:              mergeTags(tags, pulumi.Sprintf("%s-%s", s.Config.StackName, suffix)),
+	}, pulumi.RetainOnDelete(true), s.child())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.securePrivateBucket(ctx, name, bucket); err != nil {
+		return nil, err
+	}
+
+	_, err = s3.NewBucketObjectLockConfigurationV2(ctx, s.ResourceName(name+"-object-lock"), &s3.BucketObjectLockConfigurationV2Args{
+		Bucket: bucket.ID(),
+		Rule: &s3.BucketObjectLockConfigurationV2RuleArgs{
+			DefaultRetention: &s3.BucketObjectLockConfigurationV2RuleDefaultRetentionArgs{
+				Mode: pulumi.String("COMPLIANCE"),
+				Days: pulumi.Int(retentionDays),
+			},
+		},
+	}, pulumi.RetainOnDelete(true), s.child())
+	if err != nil {
+		return nil, err
+	}
+
+	return bucket, nil
+}
+
+// securePrivateBucket blocks public access to a bucket and enables default encryption.
+func (s *AgentCoreStack) securePrivateBucket(ctx *pulumi.Context, name string, bucket *s3.BucketV2) error {
+	_, err := s3.NewBucketPublicAccessBlock(ctx, s.ResourceName(name+"-public-access-block"), &s3.BucketPublicAccessBlockArgs{
+		Bucket:                bucket.ID(),
+		BlockPublicAcls:       pulumi.Bool(true),
+		BlockPublicPolicy:     pulumi.Bool(true),
+		IgnorePublicAcls:      pulumi.Bool(true),
+		RestrictPublicBuckets: pulumi.Bool(true),
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	_, err = s3.NewBucketServerSideEncryptionConfigurationV2(ctx, s.ResourceName(name+"-encryption"), &s3.BucketServerSideEncryptionConfigurationV2Args{
+		Bucket: bucket.ID(),
+		Rules: s3.BucketServerSideEncryptionConfigurationV2RuleArray{
+			&s3.BucketServerSideEncryptionConfigurationV2RuleArgs{
+				ApplyServerSideEncryptionByDefault: &s3.BucketServerSideEncryptionConfigurationV2RuleApplyServerSideEncryptionByDefaultArgs{
+					SseAlgorithm: pulumi.String("AES256"),
+				},
+			},
+		},
+	}, s.child())
+	return err
+}
@@ -2,13 +2,21 @@
 package agentcore
 
 import (
+	"encoding/json"
+
 	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
 
 // StackBuilder provides a fluent interface for building AgentCore stacks.
 type StackBuilder struct {
-	config iac.StackConfig
+	config  iac.StackConfig
+	options Options
+
+	// err is the first error of a builder method, returned by Validate
+	// and Build.
+	err error
 }
 
 // NewStackBuilder creates a new stack builder.
@@ -40,6 +48,32 @@ func (b *StackBuilder) WithAgents(configs ...iac.AgentConfig) *StackBuilder {
 	return b
 }
 
+// WithAgentsFromDir adds the agents defined by the agent manifests in a
+// directory tree, as loaded by LoadAgentsFromDir.
+func (b *StackBuilder) WithAgentsFromDir(dir string) *StackBuilder {
+	agents, err := LoadAgentsFromDir(dir)
+	if err != nil {
+		if b.err == nil {
+			b.err = err
+		}
+		return b
+	}
+	return b.WithAgents(agents...)
+}
+
+// WithAgentBuilders adds agents along with their Pulumi-specific options.
+func (b *StackBuilder) WithAgentBuilders(builders ...*AgentBuilder) *StackBuilder {
+	for _, ab := range builders {
+		config := ab.Build()
+		b.config.Agents = append(b.config.Agents, config)
+		if b.options.Agents == nil {
+			b.options.Agents = make(map[string]AgentOptions)
+		}
+		b.options.Agents[config.Name] = ab.Options()
+	}
+	return b
+}
+
 // WithSimpleAgent adds an agent with minimal configuration.
 func (b *StackBuilder) WithSimpleAgent(name, containerImage string) *StackBuilder {
 	return b.WithAgent(iac.DefaultAgentConfig(name, containerImage))
@@ -147,7 +181,10 @@ func (b *StackBuilder) WithExistingRole(roleARN string) *StackBuilder {
 	return b
 }
 
-// WithBedrockModels restricts Bedrock access to specific models.
+// WithBedrockModels restricts Bedrock access to specific models. Model IDs
+// may be foundation model IDs, cross-region inference profile IDs such as
+// "us.anthropic.claude-sonnet-4-20250514-v1:0", or foundation model,
+// inference profile and application inference profile ARNs.
 func (b *StackBuilder) WithBedrockModels(modelIDs ...string) *StackBuilder {
 	if b.config.IAM == nil {
 		b.config.IAM = iac.DefaultIAMConfig()
@@ -156,6 +193,559 @@ func (b *StackBuilder) WithBedrockModels(modelIDs ...string) *StackBuilder {
 	return b
 }
 
+// WithBedrockRegions restricts Bedrock access to models and inference
+// profiles in the regions.
+func (b *StackBuilder) WithBedrockRegions(regions ...string) *StackBuilder {
+	b.options.BedrockRegions = regions
+	return b
+}
+
+// WithIAMStatement appends a statement to the agents' execution policy,
+// e.g. WithIAMStatement("Allow", []string{"dynamodb:GetItem"}, []string{tableARN}).
+func (b *StackBuilder) WithIAMStatement(effect string, actions, resources []string) *StackBuilder {
+	return b.WithIAMStatements(Statement{Effect: effect, Action: actions, Resource: resources})
+}
+
+// WithIAMStatements appends statements, such as ones with conditions, to
+// the agents' execution policy.
+func (b *StackBuilder) WithIAMStatements(statements ...Statement) *StackBuilder {
+	b.options.IAMStatements = append(b.options.IAMStatements, statements...)
+	return b
+}
+
+// WithManagedPolicies attaches managed policies to the agents' execution
+// role.
+func (b *StackBuilder) WithManagedPolicies(policyARNs ...string) *StackBuilder {
+	b.options.ManagedPolicyARNs = append(b.options.ManagedPolicyARNs, policyARNs...)
+	return b
+}
+
+// WithIAMRoles sets the path, name prefix and permissions boundary of the
+// IAM roles the stack creates.
+func (b *StackBuilder) WithIAMRoles(config *IAMRoleConfig) *StackBuilder {
+	b.options.IAMRoles = config
+	return b
+}
+
+// WithPermissionsBoundary sets the permissions boundary of the IAM roles
+// the stack creates.
+func (b *StackBuilder) WithPermissionsBoundary(policyARN string) *StackBuilder {
+	if b.config.IAM == nil {
+		b.config.IAM = iac.DefaultIAMConfig()
+	}
+	b.config.IAM.PermissionsBoundaryARN = policyARN
+	return b
+}
+
+// WithWorkQueue provisions SQS work queues that invoke agents with each
+// message.
+func (b *StackBuilder) WithWorkQueue(config *WorkQueueConfig) *StackBuilder {
+	b.options.WorkQueue = config
+	return b
+}
+
+// WithSecretPolicy tags the secrets and parameters the stack creates and
+// limits decryption of secrets to the stack's secrets.
+func (b *StackBuilder) WithSecretPolicy(environment string, tags map[string]string) *StackBuilder {
+	b.options.SecretPolicy = &SecretPolicyConfig{Environment: environment, Tags: tags}
+	return b
+}
+
+// WithAllSecrets grants agents read access to every secret instead of only
+// their declared secrets.
+func (b *StackBuilder) WithAllSecrets() *StackBuilder {
+	b.options.AllowAllSecrets = true
+	return b
+}
+
+// WithCache enables response caching for agent calls.
+func (b *StackBuilder) WithCache(config *CacheConfig) *StackBuilder {
+	b.options.Cache = config
+	return b
+}
+
+// WithCacheTTL enables response caching with a default TTL for all agents.
+func (b *StackBuilder) WithCacheTTL(ttlSeconds int) *StackBuilder {
+	if b.options.Cache == nil {
+		b.options.Cache = DefaultCacheConfig()
+	}
+	b.options.Cache.DefaultTTLSeconds = ttlSeconds
+	return b
+}
+
+// WithAgentCacheTTL sets the cache TTL for a single agent.
+// A negative value disables caching for that agent.
+func (b *StackBuilder) WithAgentCacheTTL(agentName string, ttlSeconds int) *StackBuilder {
+	if b.options.Cache == nil {
+		b.options.Cache = DefaultCacheConfig()
+	}
+	if b.options.Cache.AgentTTLs == nil {
+		b.options.Cache.AgentTTLs = make(map[string]int)
+	}
+	b.options.Cache.AgentTTLs[agentName] = ttlSeconds
+	return b
+}
+
+// WithStateTable provisions a DynamoDB table for agent session and run
+// state. A nil config uses DefaultStateTableConfig.
+func (b *StackBuilder) WithStateTable(config *StateTableConfig) *StackBuilder {
+	if config == nil {
+		config = DefaultStateTableConfig()
+	}
+	b.options.StateTable = config
+	return b
+}
+
+// WithArtifactBucket provisions a versioned S3 bucket for agent inputs and
+// outputs. A nil config uses DefaultArtifactBucketConfig.
+func (b *StackBuilder) WithArtifactBucket(config *ArtifactBucketConfig) *StackBuilder {
+	if config == nil {
+		config = DefaultArtifactBucketConfig()
+	}
+	b.options.ArtifactBucket = config
+	return b
+}
+
+// WithCodeInterpreter provisions the AgentCore Code Interpreter tool with
+// default settings and injects its ID into the named agents, or all agents
+// if none are given.
+func (b *StackBuilder) WithCodeInterpreter(agentNames ...string) *StackBuilder {
+	config := DefaultCodeInterpreterConfig()
+	config.Agents = agentNames
+	b.options.CodeInterpreter = config
+	return b
+}
+
+// WithCodeInterpreterConfig provisions the AgentCore Code Interpreter tool.
+func (b *StackBuilder) WithCodeInterpreterConfig(config *CodeInterpreterConfig) *StackBuilder {
+	b.options.CodeInterpreter = config
+	return b
+}
+
+// WithBrowserTool provisions the AgentCore Browser tool with default
+// settings and injects its ID into the named agents, or all agents if none
+// are given.
+func (b *StackBuilder) WithBrowserTool(agentNames ...string) *StackBuilder {
+	config := DefaultBrowserToolConfig()
+	config.Agents = agentNames
+	b.options.Browser = config
+	return b
+}
+
+// WithBrowserToolConfig provisions the AgentCore Browser tool.
+func (b *StackBuilder) WithBrowserToolConfig(config *BrowserToolConfig) *StackBuilder {
+	b.options.Browser = config
+	return b
+}
+
+// WithResiliencePolicy sets the retry and circuit-breaker policy shared by all agents.
+func (b *StackBuilder) WithResiliencePolicy(policy *ResiliencePolicy) *StackBuilder {
+	b.options.Resilience = policy
+	return b
+}
+
+// WithIdentity creates workload identities and OAuth2 credential providers
+// for the agents.
+func (b *StackBuilder) WithIdentity(config *IdentityConfig) *StackBuilder {
+	b.options.Identity = config
+	return b
+}
+
+// WithOAuth2Provider adds an OAuth2 credential provider, creating workload
+// identities for all agents if none are configured yet.
+func (b *StackBuilder) WithOAuth2Provider(provider OAuth2ProviderConfig) *StackBuilder {
+	if b.options.Identity == nil {
+		b.options.Identity = &IdentityConfig{}
+	}
+	b.options.Identity.OAuth2Providers = append(b.options.Identity.OAuth2Providers, provider)
+	return b
+}
+
+// WithGateway adds an AgentCore Gateway built with a GatewayBuilder.
+func (b *StackBuilder) WithGateway(gateway *GatewayBuilder) *StackBuilder {
+	b.options.Gateways = append(b.options.Gateways, gateway.Build())
+	return b
+}
+
+// WithToolCallPolicy limits agents' calls to external tools. A nil policy
+// uses DefaultToolCallPolicy.
+func (b *StackBuilder) WithToolCallPolicy(policy *ToolCallPolicy) *StackBuilder {
+	if policy == nil {
+		policy = DefaultToolCallPolicy()
+	}
+	b.options.ToolCalls = policy
+	return b
+}
+
+// WithTenancy provisions per-tenant work queues and fairness settings.
+func (b *StackBuilder) WithTenancy(config *TenancyConfig) *StackBuilder {
+	b.options.Tenancy = config
+	return b
+}
+
+// WithTenants provisions work queues for the named tenants with equal weights.
+func (b *StackBuilder) WithTenants(tenantNames ...string) *StackBuilder {
+	config := &TenancyConfig{}
+	for _, name := range tenantNames {
+		config.Tenants = append(config.Tenants, TenantConfig{Name: name})
+	}
+	b.options.Tenancy = config
+	return b
+}
+
+// WithTenantIsolation gives each tenant an isolated namespace.
+// Call after WithTenancy or WithTenants.
+func (b *StackBuilder) WithTenantIsolation() *StackBuilder {
+	if b.options.Tenancy == nil {
+		b.options.Tenancy = &TenancyConfig{}
+	}
+	b.options.Tenancy.Isolation = true
+	return b
+}
+
+// WithVectorStore provisions a vector database created with NewVectorStore.
+func (b *StackBuilder) WithVectorStore(config *VectorStoreConfig) *StackBuilder {
+	b.options.VectorStore = config
+	return b
+}
+
+// WithGraphStore provisions a Neptune Serverless cluster for agent memory graphs.
+func (b *StackBuilder) WithGraphStore(config *GraphStoreConfig) *StackBuilder {
+	b.options.GraphStore = config
+	return b
+}
+
+// WithRetrieval sets the search index agents retrieve documents from.
+func (b *StackBuilder) WithRetrieval(config *RetrievalConfig) *StackBuilder {
+	b.options.Retrieval = config
+	return b
+}
+
+// WithKendraIndex grants all agents query access to an existing Kendra index.
+func (b *StackBuilder) WithKendraIndex(indexID string) *StackBuilder {
+	b.options.Retrieval = &RetrievalConfig{Type: "kendra", IndexID: indexID}
+	return b
+}
+
+// WithSageMakerEndpoint grants all agents access to an existing SageMaker endpoint.
+func (b *StackBuilder) WithSageMakerEndpoint(endpointName string) *StackBuilder {
+	b.options.SageMakerEndpoints = append(b.options.SageMakerEndpoints, SageMakerEndpointConfig{
+		EndpointName: endpointName,
+	})
+	return b
+}
+
+// WithSageMakerEndpointConfig references or provisions a SageMaker endpoint.
+func (b *StackBuilder) WithSageMakerEndpointConfig(config SageMakerEndpointConfig) *StackBuilder {
+	b.options.SageMakerEndpoints = append(b.options.SageMakerEndpoints, config)
+	return b
+}
+
+// WithModelHub lets all agents invoke models owned by a central model hub
+// account through its default hub role.
+func (b *StackBuilder) WithModelHub(accountID string, modelARNs ...string) *StackBuilder {
+	b.options.ModelHub = &ModelHubConfig{AccountID: accountID, ModelARNs: modelARNs}
+	return b
+}
+
+// WithInferenceProfiles creates an application inference profile of the
+// model for each agent and limits the agents' Bedrock access to them.
+func (b *StackBuilder) WithInferenceProfiles(modelID string) *StackBuilder {
+	b.options.InferenceProfiles = &InferenceProfileConfig{ModelID: modelID}
+	return b
+}
+
+// WithBatchJob adds a scheduled Bedrock batch inference job.
+func (b *StackBuilder) WithBatchJob(job BatchJobConfig) *StackBuilder {
+	b.options.BatchJobs = append(b.options.BatchJobs, job)
+	return b
+}
+
+// WithFineTuning adds a Bedrock model customization job.
+func (b *StackBuilder) WithFineTuning(job FineTuningConfig) *StackBuilder {
+	b.options.FineTuning = append(b.options.FineTuning, job)
+	return b
+}
+
+// WithAuditLog records agent tool invocations to immutable storage.
+func (b *StackBuilder) WithAuditLog(config *AuditConfig) *StackBuilder {
+	b.options.Audit = config
+	return b
+}
+
+// WithReports schedules report generation with S3 storage and email delivery.
+func (b *StackBuilder) WithReports(config *ReportConfig) *StackBuilder {
+	b.options.Reports = config
+	return b
+}
+
+// WithApproval provisions a human-in-the-loop approval workflow.
+func (b *StackBuilder) WithApproval(config *ApprovalConfig) *StackBuilder {
+	b.options.Approval = config
+	return b
+}
+
+// WithWorkflow adds a Step Functions workflow built with a WorkflowBuilder.
+func (b *StackBuilder) WithWorkflow(workflow *WorkflowBuilder) *StackBuilder {
+	b.options.Workflows = append(b.options.Workflows, workflow.Build())
+	return b
+}
+
+// WithAppSync provisions a GraphQL API with streaming subscriptions.
+func (b *StackBuilder) WithAppSync(config *AppSyncConfig) *StackBuilder {
+	b.options.AppSync = config
+	return b
+}
+
+// WithFrontend hosts a chat UI on S3 and CloudFront.
+func (b *StackBuilder) WithFrontend(config *FrontendConfig) *StackBuilder {
+	b.options.Frontend = config
+	return b
+}
+
+// SkipPreflight disables region availability checks.
+func (b *StackBuilder) SkipPreflight() *StackBuilder {
+	b.options.SkipPreflight = true
+	return b
+}
+
+// WithWAF protects the AppSync API and frontend with WAFv2 web ACLs using
+// the given rules, such as WAFRuleRateLimit and WAFRuleCommon. Without
+// rules, the default rules are used.
+func (b *StackBuilder) WithWAF(rules ...string) *StackBuilder {
+	b.options.WAF = &WAFConfig{Rules: rules}
+	return b
+}
+
+// WithWAFConfig protects the AppSync API and frontend with WAFv2 web ACLs.
+func (b *StackBuilder) WithWAFConfig(config *WAFConfig) *StackBuilder {
+	b.options.WAF = config
+	return b
+}
+
+// WithPerAgentLogGroups gives each agent its own log group.
+func (b *StackBuilder) WithPerAgentLogGroups() *StackBuilder {
+	b.options.PerAgentLogGroups = true
+	return b
+}
+
+// WithLogArchive archives CloudWatch logs to S3 with a cold storage lifecycle.
+func (b *StackBuilder) WithLogArchive(config *LogArchiveConfig) *StackBuilder {
+	b.options.LogArchive = config
+	return b
+}
+
+// WithHealth creates per-agent health alarms and a composite stack health alarm.
+func (b *StackBuilder) WithHealth(config *HealthConfig) *StackBuilder {
+	b.options.Health = config
+	return b
+}
+
+// WithRegistry registers the stack and its agents in Service Catalog AppRegistry.
+func (b *StackBuilder) WithRegistry(config *RegistryConfig) *StackBuilder {
+	b.options.Registry = config
+	return b
+}
+
+// WithSecretsAudit schedules detection of granted secrets the agents never read.
+func (b *StackBuilder) WithSecretsAudit(config *SecretsAuditConfig) *StackBuilder {
+	b.options.SecretsAudit = config
+	return b
+}
+
+// WithKeyRotation tracks the age of granted secrets and alarms when they are overdue.
+func (b *StackBuilder) WithKeyRotation(config *KeyRotationConfig) *StackBuilder {
+	b.options.KeyRotation = config
+	return b
+}
+
+// WithGuardDuty enables threat detection and routes the stack's findings.
+func (b *StackBuilder) WithGuardDuty(config *GuardDutyConfig) *StackBuilder {
+	b.options.GuardDuty = config
+	return b
+}
+
+// WithSecurityHub creates insights scoped to the stack and routes its findings.
+func (b *StackBuilder) WithSecurityHub(config *SecurityHubConfig) *StackBuilder {
+	b.options.SecurityHub = config
+	return b
+}
+
+// WithAlarms creates per-agent alarms on runtime errors, p95 latency and
+// throttles. Pass nil to use DefaultAlarmsConfig.
+func (b *StackBuilder) WithAlarms(config *AlarmsConfig) *StackBuilder {
+	if config == nil {
+		config = DefaultAlarmsConfig()
+	}
+	b.options.Alarms = config
+	return b
+}
+
+// WithDashboard creates a CloudWatch dashboard summarizing all agents.
+// Pass nil to use the defaults.
+func (b *StackBuilder) WithDashboard(config *DashboardConfig) *StackBuilder {
+	if config == nil {
+		config = &DashboardConfig{}
+	}
+	b.options.Dashboard = config
+	return b
+}
+
+// WithAgentSubdomains gives each agent a DNS name under domain, such as
+// research.agents.example.com, with records in the hosted zone zoneID.
+// Use WithAgentSubdomainsConfig for private zones or existing certificates.
+func (b *StackBuilder) WithAgentSubdomains(zoneID, domain string) *StackBuilder {
+	b.options.AgentSubdomains = &AgentSubdomainsConfig{ZoneID: zoneID, Domain: domain}
+	return b
+}
+
+// WithAgentSubdomainsConfig gives agents DNS names under a delegated subdomain.
+func (b *StackBuilder) WithAgentSubdomainsConfig(config *AgentSubdomainsConfig) *StackBuilder {
+	b.options.AgentSubdomains = config
+	return b
+}
+
+// WithKMSKey encrypts log groups, created secrets and notification topics
+// with an existing customer-managed key.
+func (b *StackBuilder) WithKMSKey(keyARN string) *StackBuilder {
+	b.options.KMS = &KMSConfig{KeyARN: keyARN}
+	return b
+}
+
+// WithManagedKMSKey creates a customer-managed key with automatic rotation
+// and encrypts log groups, created secrets and notification topics with it.
+func (b *StackBuilder) WithManagedKMSKey() *StackBuilder {
+	b.options.KMS = &KMSConfig{}
+	return b
+}
+
+// WithQuotaReport outputs the quota-limited resources the stack creates
+// against the account's quotas, warning at 80% usage.
+func (b *StackBuilder) WithQuotaReport() *StackBuilder {
+	b.options.QuotaReport = &QuotaReportConfig{}
+	return b
+}
+
+// WithFeatureFlags provisions AppConfig feature flags and configuration
+// profiles that agents read at runtime.
+func (b *StackBuilder) WithFeatureFlags(config *FeatureFlagConfig) *StackBuilder {
+	b.options.FeatureFlags = config
+	return b
+}
+
+// WithFeatureFlag adds a feature flag, provisioning AppConfig with default
+// settings if no flags are configured yet.
+func (b *StackBuilder) WithFeatureFlag(name string, enabled bool) *StackBuilder {
+	if b.options.FeatureFlags == nil {
+		b.options.FeatureFlags = &FeatureFlagConfig{}
+	}
+	if b.options.FeatureFlags.Flags == nil {
+		b.options.FeatureFlags.Flags = make(map[string]FeatureFlag)
+	}
+	b.options.FeatureFlags.Flags[name] = FeatureFlag{Enabled: enabled}
+	return b
+}
+
+// WithConfigProfile adds a freeform AppConfig configuration profile, such as
+// prompts, rolled out with rollout or the feature flag rollout if nil.
+func (b *StackBuilder) WithConfigProfile(name string, content json.RawMessage, rollout *FeatureFlagRollout) *StackBuilder {
+	if b.options.FeatureFlags == nil {
+		b.options.FeatureFlags = &FeatureFlagConfig{}
+	}
+	flags := b.options.FeatureFlags
+	if flags.Profiles == nil {
+		flags.Profiles = make(map[string]json.RawMessage)
+	}
+	flags.Profiles[name] = content
+	if rollout != nil {
+		if flags.ProfileRollouts == nil {
+			flags.ProfileRollouts = make(map[string]*FeatureFlagRollout)
+		}
+		flags.ProfileRollouts[name] = rollout
+	}
+	return b
+}
+
+// WithTopology publishes the stack topology for runtime discovery to an SSM
+// parameter ("ssm") or an AppConfig profile ("appconfig").
+func (b *StackBuilder) WithTopology(target string) *StackBuilder {
+	b.options.Topology = &TopologyConfig{Target: target}
+	return b
+}
+
+// WithMaintenanceMode answers requests to the agents' subdomain APIs and
+// replica routers with a 503 and message while enabled. An empty message
+// uses the default.
+func (b *StackBuilder) WithMaintenanceMode(enabled bool, message string) *StackBuilder {
+	b.options.Maintenance = &MaintenanceConfig{Enabled: enabled, Message: message}
+	return b
+}
+
+// WithGuardrail creates or references the Bedrock guardrail agents apply.
+func (b *StackBuilder) WithGuardrail(config *GuardrailConfig) *StackBuilder {
+	b.options.Guardrail = config
+	return b
+}
+
+// WithSharedGuardrail has all agents apply a centrally managed guardrail.
+// An empty version applies the draft.
+func (b *StackBuilder) WithSharedGuardrail(arn, version string) *StackBuilder {
+	b.options.Guardrail = &GuardrailConfig{ARN: arn, Version: version}
+	return b
+}
+
+// WithPromptMonitoring alarms on suspected prompt injection and jailbreak attempts.
+func (b *StackBuilder) WithPromptMonitoring(config *PromptMonitoringConfig) *StackBuilder {
+	b.options.PromptMonitoring = config
+	return b
+}
+
+// WithLogging sets the structured log schema shared by all agents.
+func (b *StackBuilder) WithLogging(config *LoggingConfig) *StackBuilder {
+	b.options.Logging = config
+	return b
+}
+
+// WithSecretOutputs exports the named outputs as Pulumi secrets. Names may
+// be path.Match patterns.
+func (b *StackBuilder) WithSecretOutputs(names ...string) *StackBuilder {
+	b.options.SecretOutputs = append(b.options.SecretOutputs, names...)
+	return b
+}
+
+// WithResourceFactory substitutes the creation of pieces of the stack.
+func (b *StackBuilder) WithResourceFactory(factory ResourceFactory) *StackBuilder {
+	b.options.ResourceFactory = factory
+	return b
+}
+
+// WithDependsOn creates the agent runtimes after the given resources.
+func (b *StackBuilder) WithDependsOn(resources ...pulumi.Resource) *StackBuilder {
+	b.options.DependsOn = append(b.options.DependsOn, resources...)
+	return b
+}
+
+// WithProvider creates the stack's resources with an AWS provider, e.g. for
+// another region or account.
+func (b *StackBuilder) WithProvider(provider *aws.Provider) *StackBuilder {
+	b.options.Provider = provider
+	return b
+}
+
+// WithRegion creates the stack's resources in a region, with an AWS
+// provider of the stack's own.
+func (b *StackBuilder) WithRegion(region string) *StackBuilder {
+	b.options.Region = region
+	return b
+}
+
+// WithAssumeRole creates the stack's resources in another account with the
+// credentials of a role there. externalID may be empty.
+func (b *StackBuilder) WithAssumeRole(roleARN, externalID string) *StackBuilder {
+	b.options.AssumeRole = &AssumeRoleConfig{RoleARN: roleARN, ExternalID: externalID}
+	return b
+}
+
 // WithTags adds tags to all resources.
 func (b *StackBuilder) WithTags(tags map[string]string) *StackBuilder {
 	for k, v := range tags {
@@ -191,20 +781,44 @@ func (b *StackBuilder) Config() iac.StackConfig {
 	return b.config
 }
 
+// Options returns the current Pulumi-specific options.
+func (b *StackBuilder) Options() Options {
+	return b.options
+}
+
 // Validate validates the current configuration.
 func (b *StackBuilder) Validate() error {
+	if b.err != nil {
+		return b.err
+	}
 	b.config.ApplyDefaults()
-	return b.config.Validate()
+	if err := b.config.Validate(); err != nil {
+		return err
+	}
+	b.options.ApplyDefaults()
+	return b.options.Validate(b.config)
 }
 
-// Build creates the AgentCore stack.
-func (b *StackBuilder) Build(ctx *pulumi.Context) (*AgentCoreStack, error) {
-	return NewAgentCoreStack(ctx, b.config)
+// Build creates the AgentCore stack with optional component resource options.
+func (b *StackBuilder) Build(ctx *pulumi.Context, opts ...pulumi.ResourceOption) (*AgentCoreStack, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return NewAgentCoreStackWithOptions(ctx, b.config, b.options, opts...)
+}
+
+// BuildMultiRegion creates the AgentCore stack in each region with
+// NewMultiRegionStack. The first region is the primary.
+func (b *StackBuilder) BuildMultiRegion(ctx *pulumi.Context, regions []string, opts ...pulumi.ResourceOption) (*MultiRegionStack, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return NewMultiRegionStack(ctx, b.config, b.options, regions, opts...)
 }
 
 // MustBuild creates the AgentCore stack, panicking on error.
-func (b *StackBuilder) MustBuild(ctx *pulumi.Context) *AgentCoreStack {
-	stack, err := b.Build(ctx)
+func (b *StackBuilder) MustBuild(ctx *pulumi.Context, opts ...pulumi.ResourceOption) *AgentCoreStack {
+	stack, err := b.Build(ctx, opts...)
 	if err != nil {
 		panic(err)
 	}
@@ -213,7 +827,8 @@ func (b *StackBuilder) MustBuild(ctx *pulumi.Context) *AgentCoreStack {
 
 // AgentBuilder provides a fluent interface for building agent configurations.
 type AgentBuilder struct {
-	config iac.AgentConfig
+	config  iac.AgentConfig
+	options AgentOptions
 }
 
 // NewAgentBuilder creates a new agent builder.
@@ -229,13 +844,15 @@ func (b *AgentBuilder) WithDescription(description string) *AgentBuilder {
 	return b
 }
 
-// WithMemory sets the memory allocation in MB.
+// WithMemory sets the memory allocation in MB. Only Lambda agents may
+// change it; AgentCore runtimes have no memory setting.
 func (b *AgentBuilder) WithMemory(memoryMB int) *AgentBuilder {
 	b.config.MemoryMB = memoryMB
 	return b
 }
 
-// WithTimeout sets the timeout in seconds.
+// WithTimeout sets the timeout in seconds. Only Lambda agents may change
+// it; AgentCore runtimes have no request timeout setting.
 func (b *AgentBuilder) WithTimeout(timeoutSeconds int) *AgentBuilder {
 	b.config.TimeoutSeconds = timeoutSeconds
 	return b
@@ -267,7 +884,207 @@ func (b *AgentBuilder) AsDefault() *AgentBuilder {
 	return b
 }
 
+// WithInputSchema sets the JSON Schema for the agent's request payload,
+// enforced by the agent's router URL.
+func (b *AgentBuilder) WithInputSchema(schema string) *AgentBuilder {
+	b.options.InputSchema = json.RawMessage(schema)
+	return b
+}
+
+// WithSchedule invokes the agent with a JSON payload on an EventBridge
+// Scheduler cron or rate expression, e.g. "cron(0 2 * * ? *)".
+func (b *AgentBuilder) WithSchedule(cronOrRate, payload string) *AgentBuilder {
+	b.options.Schedules = append(b.options.Schedules, AgentSchedule{
+		Expression: cronOrRate,
+		Payload:    json.RawMessage(payload),
+	})
+	return b
+}
+
+// WithOutputSchema sets the JSON Schema for the agent's response payload,
+// enforced by the agent's router URL.
+func (b *AgentBuilder) WithOutputSchema(schema string) *AgentBuilder {
+	b.options.OutputSchema = json.RawMessage(schema)
+	return b
+}
+
+// WithLogRetention sets the retention of the agent's own log group.
+// The agent gets its own log group even without StackBuilder.WithPerAgentLogGroups.
+func (b *AgentBuilder) WithLogRetention(days int) *AgentBuilder {
+	b.options.LogRetentionDays = days
+	return b
+}
+
+// WithReplicas creates n identical runtimes for the agent behind a
+// load-balanced router URL, for static capacity independent of AgentCore's
+// session scaling.
+func (b *AgentBuilder) WithReplicas(n int) *AgentBuilder {
+	b.options.Replicas = n
+	return b
+}
+
+// AsImmutable replaces the agent's runtime on every configuration change
+// instead of updating it in place.
+func (b *AgentBuilder) AsImmutable() *AgentBuilder {
+	b.options.Immutable = true
+	return b
+}
+
+// WithBlueGreen deploys the agent blue/green with the given strategy,
+// "all-at-once" or "weighted", keeping the previous version deployed for
+// rollback. greenWeight is the percentage of sessions routed to the new
+// version with the weighted strategy.
+func (b *AgentBuilder) WithBlueGreen(strategy string, greenWeight int) *AgentBuilder {
+	b.options.BlueGreen = &BlueGreenConfig{Strategy: strategy}
+	if strategy == "weighted" {
+		b.options.BlueGreen.GreenWeight = &greenWeight
+	}
+	return b
+}
+
+// WithCanary deploys the agent as a canary: weight percent of sessions go
+// to each new version for bakeMinutes, after which it is promoted unless
+// its error alarm or one of alarmNames fired. Zero values use the defaults.
+func (b *AgentBuilder) WithCanary(weight, bakeMinutes int, alarmNames ...string) *AgentBuilder {
+	b.options.BlueGreen = &BlueGreenConfig{
+		Strategy: "canary",
+		Canary: &CanaryConfig{
+			Weight:      weight,
+			BakeMinutes: bakeMinutes,
+			AlarmNames:  alarmNames,
+		},
+	}
+	return b
+}
+
+// AsLambda deploys the agent's image as a Lambda function with a function
+// URL instead of an AgentCore runtime. architecture is "x86_64" or "arm64";
+// ephemeralStorageMB sizes /tmp. Zero values use the defaults. The image
+// needs the Lambda Web Adapter extension (see LambdaTargetConfig).
+func (b *AgentBuilder) AsLambda(architecture string, ephemeralStorageMB int) *AgentBuilder {
+	b.options.Lambda = &LambdaTargetConfig{
+		Architecture:       architecture,
+		EphemeralStorageMB: ephemeralStorageMB,
+	}
+	return b
+}
+
+// WithLambdaAPI exposes a Lambda agent at a POST /invocations route of an
+// HTTP API instead of a function URL.
+func (b *AgentBuilder) WithLambdaAPI() *AgentBuilder {
+	if b.options.Lambda == nil {
+		b.options.Lambda = &LambdaTargetConfig{}
+	}
+	b.options.Lambda.Endpoint = "api"
+	return b
+}
+
+// WithLambdaCapacity sets a Lambda agent's capacity strategy, "on-demand"
+// or "spot", with baseConcurrency execution environments kept initialized
+// and, for on-demand, maxConcurrency reserved (see LambdaCapacityConfig).
+func (b *AgentBuilder) WithLambdaCapacity(strategy string, baseConcurrency, maxConcurrency int) *AgentBuilder {
+	if b.options.Lambda == nil {
+		b.options.Lambda = &LambdaTargetConfig{}
+	}
+	b.options.Lambda.Capacity = &LambdaCapacityConfig{
+		Strategy:        strategy,
+		BaseConcurrency: baseConcurrency,
+		MaxConcurrency:  maxConcurrency,
+	}
+	return b
+}
+
+// WithCapacitySchedule sets a Lambda agent's provisioned concurrency to
+// minConcurrency, up to maxConcurrency, on an Application Auto Scaling
+// schedule expression such as "cron(0 9 ? * MON-FRI *)" in UTC.
+func (b *AgentBuilder) WithCapacitySchedule(name, expression string, minConcurrency, maxConcurrency int) *AgentBuilder {
+	if b.options.Lambda == nil {
+		b.options.Lambda = &LambdaTargetConfig{}
+	}
+	if b.options.Lambda.Capacity == nil {
+		b.options.Lambda.Capacity = &LambdaCapacityConfig{}
+	}
+	b.options.Lambda.Capacity.Schedules = append(b.options.Lambda.Capacity.Schedules, CapacitySchedule{
+		Name:           name,
+		Expression:     expression,
+		MinConcurrency: minConcurrency,
+		MaxConcurrency: maxConcurrency,
+	})
+	return b
+}
+
+// WithRollback routes all of a blue/green agent's traffic to its previous
+// version.
+func (b *AgentBuilder) WithRollback() *AgentBuilder {
+	if b.options.BlueGreen == nil {
+		b.options.BlueGreen = &BlueGreenConfig{}
+	}
+	b.options.BlueGreen.Rollback = true
+	return b
+}
+
+// WithRetainedVersions keeps the given number of recent runtime versions
+// invokable on endpoints named v{version}.
+func (b *AgentBuilder) WithRetainedVersions(count int) *AgentBuilder {
+	if b.options.BlueGreen == nil {
+		b.options.BlueGreen = &BlueGreenConfig{}
+	}
+	b.options.BlueGreen.RetainVersions = count
+	return b
+}
+
+// WithKnowledgeBase grants the agent retrieval from a knowledge base created
+// with NewKnowledgeBase and injects its ID.
+func (b *AgentBuilder) WithKnowledgeBase(kb *KnowledgeBase) *AgentBuilder {
+	b.options.KnowledgeBases = append(b.options.KnowledgeBases, kb)
+	return b
+}
+
+// WithLabel sets a label of the agent, such as LabelOwner.
+func (b *AgentBuilder) WithLabel(key, value string) *AgentBuilder {
+	if b.options.Labels == nil {
+		b.options.Labels = make(map[string]string)
+	}
+	b.options.Labels[key] = value
+	return b
+}
+
+// WithCodeInterpreter gives the agent the stack's AgentCore Code
+// Interpreter, which is created with default settings unless the stack
+// configures it with StackBuilder.WithCodeInterpreterConfig.
+func (b *AgentBuilder) WithCodeInterpreter() *AgentBuilder {
+	b.options.CodeInterpreter = true
+	return b
+}
+
+// WithBrowserTool gives the agent the stack's AgentCore Browser, which is
+// created with default settings unless the stack configures it with
+// StackBuilder.WithBrowserToolConfig.
+func (b *AgentBuilder) WithBrowserTool() *AgentBuilder {
+	b.options.Browser = true
+	return b
+}
+
+// WithDeadLetterQueue captures failed asynchronous invocations in an SQS
+// queue created by the stack, so they can be replayed.
+func (b *AgentBuilder) WithDeadLetterQueue() *AgentBuilder {
+	b.options.OnFailure = &FailureDestination{Type: "sqs"}
+	return b
+}
+
+// WithOnFailure sets the destination for failed asynchronous invocations.
+func (b *AgentBuilder) WithOnFailure(destination *FailureDestination) *AgentBuilder {
+	b.options.OnFailure = destination
+	return b
+}
+
 // Build returns the agent configuration.
 func (b *AgentBuilder) Build() iac.AgentConfig {
 	return b.config
 }
+
+// Options returns the agent's Pulumi-specific options.
+// Use StackBuilder.WithAgentBuilders to add the agent together with them.
+func (b *AgentBuilder) Options() AgentOptions {
+	return b.options
+}
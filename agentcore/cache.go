@@ -0,0 +1,156 @@
+package agentcore
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/ec2"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/elasticache"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// CacheConfig configures an ElastiCache-backed response cache for agent calls.
+//
+// Agents receive the cache endpoint and their TTL as environment variables and
+// cache responses keyed on a hash of the request, so repeated identical
+// queries don't re-invoke models.
+type CacheConfig struct {
+	// Engine is the ElastiCache Serverless engine.
+	// Supported: "valkey", "redis"
+	// Default: "valkey"
+	Engine string `json:"engine,omitempty" yaml:"engine,omitempty"`
+
+	// MaxStorageGB caps the cache data storage.
+	// Default: 1
+	MaxStorageGB int `json:"maxStorageGB,omitempty" yaml:"maxStorageGB,omitempty"`
+
+	// DefaultTTLSeconds is the TTL for agents not listed in AgentTTLs.
+	// Default: 300
+	DefaultTTLSeconds int `json:"defaultTTLSeconds,omitempty" yaml:"defaultTTLSeconds,omitempty"`
+
+	// AgentTTLs overrides the TTL in seconds per agent name.
+	// A negative value disables caching for that agent.
+	AgentTTLs map[string]int `json:"agentTTLs,omitempty" yaml:"agentTTLs,omitempty"`
+}
+
+// DefaultCacheConfig returns a CacheConfig with sensible defaults.
+func DefaultCacheConfig() *CacheConfig {
+	return &CacheConfig{
+		Engine:            "valkey",
+		MaxStorageGB:      1,
+		DefaultTTLSeconds: 300,
+		AgentTTLs:         make(map[string]int),
+	}
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *CacheConfig) ApplyDefaults() {
+	if c.Engine == "" {
+		c.Engine = "valkey"
+	}
+	if c.MaxStorageGB == 0 {
+		c.MaxStorageGB = 1
+	}
+	if c.DefaultTTLSeconds == 0 {
+		c.DefaultTTLSeconds = 300
+	}
+}
+
+// Validate validates the CacheConfig against the stack configuration.
+func (c *CacheConfig) Validate(config iac.StackConfig) error {
+	if c.Engine != "valkey" && c.Engine != "redis" {
+		return fmt.Errorf("cache.engine must be one of [valkey redis]")
+	}
+	if !hasVPC(config) {
+		return fmt.Errorf("cache requires a VPC (vpc.createVPC or vpc.vpcId)")
+	}
+	if c.MaxStorageGB < 1 || c.MaxStorageGB > 5000 {
+		return fmt.Errorf("cache.maxStorageGB must be between 1 and 5000")
+	}
+	for name := range c.AgentTTLs {
+		if !hasAgent(config, name) {
+			return fmt.Errorf("cache.agentTTLs: '%s' does not match any agent name", name)
+		}
+	}
+	return nil
+}
+
+// TTLFor returns the cache TTL in seconds for an agent, or 0 if caching is disabled.
+func (c *CacheConfig) TTLFor(agentName string) int {
+	ttl, ok := c.AgentTTLs[agentName]
+	if !ok {
+		ttl = c.DefaultTTLSeconds
+	}
+	if ttl < 0 {
+		return 0
+	}
+	return ttl
+}
+
+// createCache creates the ElastiCache Serverless cache and injects its settings into agents.
+func (s *AgentCoreStack) createCache(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	stackName := s.Config.StackName
+	cfg := s.Options.Cache
+
+	// Dedicated security group so only agents can reach the cache
+	cacheSG, err := ec2.NewSecurityGroup(ctx, s.ResourceName("cache-sg"), &ec2.SecurityGroupArgs{
+		Name:        pulumi.Sprintf("%s-cache-sg", stackName),
+		Description: pulumi.Sprintf("Security group for %s response cache", stackName),
+		VpcId:       s.vpcID(),
+		Tags:        mergeTags(tags, pulumi.Sprintf("%s-cache-sg", stackName)),
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	_, err = ec2.NewSecurityGroupRule(ctx, s.ResourceName("cache-sg-agent-ingress"), &ec2.SecurityGroupRuleArgs{
+		Type:                  pulumi.String("ingress"),
+		SecurityGroupId:       cacheSG.ID(),
+		SourceSecurityGroupId: s.SecurityGroup.ID(),
+		Protocol:              pulumi.String("tcp"),
+		FromPort:              pulumi.Int(6379),
+		ToPort:                pulumi.Int(6380),
+		Description:           pulumi.String("Allow agents to reach the response cache"),
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	s.Cache, err = elasticache.NewServerlessCache(ctx, s.ResourceName("cache"), &elasticache.ServerlessCacheArgs{
+		Name:        pulumi.Sprintf("%s-cache", stackName),
+		Description: pulumi.Sprintf("Response cache for %s agents", stackName),
+		Engine:      pulumi.String(cfg.Engine),
+		CacheUsageLimits: &elasticache.ServerlessCacheCacheUsageLimitsArgs{
+			DataStorage: &elasticache.ServerlessCacheCacheUsageLimitsDataStorageArgs{
+				Maximum: pulumi.Int(cfg.MaxStorageGB),
+				Unit:    pulumi.String("GB"),
+			},
+		},
+		SubnetIds:        s.privateSubnetIDs(),
+		SecurityGroupIds: pulumi.StringArray{cacheSG.ID()},
+		Tags:             mergeTags(tags, pulumi.Sprintf("%s-cache", stackName)),
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	endpoint := s.Cache.Endpoints.Index(pulumi.Int(0))
+	address := endpoint.Address()
+	port := endpoint.Port().ApplyT(strconv.Itoa).(pulumi.StringOutput)
+
+	for _, agent := range s.Config.Agents {
+		ttl := cfg.TTLFor(agent.Name)
+		if ttl == 0 {
+			continue
+		}
+		s.injectEnv(agent.Name, "CACHE_HOST", address)
+		s.injectEnv(agent.Name, "CACHE_PORT", port)
+		s.injectEnv(agent.Name, "CACHE_TLS", pulumi.String("true"))
+		s.injectEnv(agent.Name, "CACHE_TTL_SECONDS", pulumi.String(strconv.Itoa(ttl)))
+		s.injectEnv(agent.Name, "CACHE_KEY_STRATEGY", pulumi.String("request-sha256"))
+		s.injectEnv(agent.Name, "CACHE_KEY_PREFIX", pulumi.Sprintf("%s:%s:", stackName, agent.Name))
+	}
+
+	return nil
+}
@@ -0,0 +1,314 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/cloudwatch"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/scheduler"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/ssm"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// canaryControllerHandler advances the canary deployments of a stack. Each
+// agent's traffic parameter holds the state of its canary, which the
+// agent's router and subdomain proxy read for the green weight:
+//
+//	{"version": "5", "weight": 10, "status": "baking", "since": 1700000000000, "previous": "4"}
+//
+// A new green version starts a canary. While it bakes, any of its alarms
+// in ALARM rolls it back; once the bake time passes without alarms, the
+// live endpoint moves to it. previous is the live version before the
+// canary, restored when a promoted version is rolled back.
+const canaryControllerHandler = `import { SSMClient, GetParameterCommand, PutParameterCommand } from "@aws-sdk/client-ssm";
+import { CloudWatchClient, DescribeAlarmsCommand } from "@aws-sdk/client-cloudwatch";
+import { BedrockAgentCoreControlClient, GetAgentRuntimeEndpointCommand, UpdateAgentRuntimeEndpointCommand } from "@aws-sdk/client-bedrock-agentcore-control";
+import { SNSClient, PublishCommand } from "@aws-sdk/client-sns";
+
+const ssm = new SSMClient({});
+const cloudwatch = new CloudWatchClient({});
+const control = new BedrockAgentCoreControlClient({});
+const sns = new SNSClient({});
+const canaries = JSON.parse(process.env.CANARIES);
+
+const notify = async (agent, message) => {
+  console.log(JSON.stringify({ agent, message }));
+  if (!process.env.TOPIC_ARN) return;
+  await sns.send(new PublishCommand({
+    TopicArn: process.env.TOPIC_ARN,
+    Subject: process.env.STACK_NAME + " canary: " + agent,
+    Message: message,
+  }));
+};
+
+const setLive = (canary, version) =>
+  control.send(new UpdateAgentRuntimeEndpointCommand({
+    agentRuntimeId: canary.runtimeId,
+    endpointName: "live",
+    agentRuntimeVersion: version,
+  }));
+
+const advance = async (agent, canary) => {
+  const { Parameter } = await ssm.send(new GetParameterCommand({ Name: canary.parameter }));
+  const state = JSON.parse(Parameter.Value);
+  const save = (next) =>
+    ssm.send(new PutParameterCommand({ Name: canary.parameter, Value: JSON.stringify(next), Overwrite: true }));
+  const endpoint = await control.send(new GetAgentRuntimeEndpointCommand({
+    agentRuntimeId: canary.runtimeId,
+    endpointName: "live",
+  }));
+  const live = endpoint.liveVersion;
+
+  if (canary.rollback) {
+    if (state.version === canary.version && state.status === "rolled-back") return;
+    if (live === canary.version && state.previous && state.previous !== live) await setLive(canary, state.previous);
+    await save({ ...state, version: canary.version, weight: 0, status: "rolled-back" });
+    await notify(agent, "Version " + canary.version + " was rolled back on request");
+    return;
+  }
+
+  if (state.version !== canary.version) {
+    if (live === canary.version) {
+      await save({ version: canary.version, weight: 100, status: "promoted", previous: state.previous });
+      return;
+    }
+    await save({ version: canary.version, weight: canary.weight, status: "baking", since: Date.now(), previous: live });
+    await notify(agent, "Version " + canary.version + " receives " + canary.weight + "% of sessions for " + canary.bakeMinutes + " minutes");
+    return;
+  }
+  if (state.status !== "baking") return;
+
+  const alarms = await cloudwatch.send(new DescribeAlarmsCommand({
+    AlarmNames: canary.alarms,
+    AlarmTypes: ["MetricAlarm", "CompositeAlarm"],
+    StateValue: "ALARM",
+  }));
+  const firing = [...(alarms.MetricAlarms ?? []), ...(alarms.CompositeAlarms ?? [])].map((a) => a.AlarmName);
+  if (firing.length) {
+    await save({ ...state, weight: 0, status: "rolled-back" });
+    await notify(agent, "Version " + canary.version + " was rolled back: " + firing.join(", ") + " in ALARM");
+    return;
+  }
+
+  if (Date.now() - state.since >= canary.bakeMinutes * 60 * 1000) {
+    await setLive(canary, canary.version);
+    await save({ ...state, weight: 100, status: "promoted" });
+    await notify(agent, "Version " + canary.version + " was promoted");
+  }
+};
+
+export const handler = async () => {
+  for (const [agent, canary] of Object.entries(canaries)) {
+    await advance(agent, canary);
+  }
+};
+`
+
+// canaryControllerSchedule is how often the canary controller runs.
+const canaryControllerSchedule = "rate(1 minute)"
+
+// CanaryConfig shifts an agent's traffic to a new version automatically.
+// A controller function routes Weight percent of sessions to the new
+// version, watches its alarms for BakeMinutes, and then promotes it to the
+// live endpoint or rolls it back.
+type CanaryConfig struct {
+	// Weight is the percentage of sessions routed to the new version while
+	// it bakes.
+	// Range: 1-99
+	// Default: 10
+	Weight int `json:"weight,omitempty" yaml:"weight,omitempty"`
+
+	// BakeMinutes is how long the new version serves canary traffic
+	// without alarms before it is promoted.
+	// Range: 1-1440
+	// Default: 30
+	BakeMinutes int `json:"bakeMinutes,omitempty" yaml:"bakeMinutes,omitempty"`
+
+	// ErrorThreshold is the number of system errors of the new version per
+	// minute that rolls it back.
+	// Default: 1
+	ErrorThreshold int `json:"errorThreshold,omitempty" yaml:"errorThreshold,omitempty"`
+
+	// AlarmNames are further CloudWatch alarms that roll the new version
+	// back while it bakes, e.g. alarms on business metrics or downstream
+	// services.
+	AlarmNames []string `json:"alarmNames,omitempty" yaml:"alarmNames,omitempty"`
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *CanaryConfig) ApplyDefaults() {
+	if c.Weight == 0 {
+		c.Weight = 10
+	}
+	if c.BakeMinutes == 0 {
+		c.BakeMinutes = 30
+	}
+	if c.ErrorThreshold == 0 {
+		c.ErrorThreshold = 1
+	}
+}
+
+// Validate validates the CanaryConfig for the named agent.
+func (c *CanaryConfig) Validate(agentName string) error {
+	if c.Weight < 1 || c.Weight > 99 {
+		return fmt.Errorf("agents[%s].blueGreen.canary.weight must be between 1 and 99", agentName)
+	}
+	if c.BakeMinutes < 1 || c.BakeMinutes > 1440 {
+		return fmt.Errorf("agents[%s].blueGreen.canary.bakeMinutes must be between 1 and 1440", agentName)
+	}
+	if c.ErrorThreshold < 1 {
+		return fmt.Errorf("agents[%s].blueGreen.canary.errorThreshold must be at least 1", agentName)
+	}
+	return nil
+}
+
+// canaryAgents returns the names of the agents deployed with the canary
+// strategy, in configuration order.
+func (s *AgentCoreStack) canaryAgents() []string {
+	var names []string
+	for _, agent := range s.Config.Agents {
+		if cfg := s.Options.Agents[agent.Name].BlueGreen; cfg != nil && cfg.Strategy == "canary" {
+			names = append(names, agent.Name)
+		}
+	}
+	return names
+}
+
+// createTrafficParameter creates the parameter holding the state of an
+// agent's canary. The controller owns its value after creation.
+func (s *AgentCoreStack) createTrafficParameter(ctx *pulumi.Context, agent iac.AgentConfig, tags pulumi.StringMap) (*ssm.Parameter, error) {
+	stackName := s.Config.StackName
+	initial, err := json.Marshal(map[string]interface{}{"version": "", "weight": 0, "status": "pending"})
+	if err != nil {
+		return nil, err
+	}
+	param, err := ssm.NewParameter(ctx, s.ResourceName(fmt.Sprintf("%s-traffic", agent.Name)), &ssm.ParameterArgs{
+		Name:          pulumi.Sprintf("/agentcore/%s/%s/traffic", stackName, agent.Name),
+		Description:   pulumi.Sprintf("Canary traffic of agent %s", agent.Name),
+		Type:          pulumi.String("String"),
+		InsecureValue: pulumi.String(string(initial)),
+		Tags:          mergeTags(tags, pulumi.Sprintf("%s-%s-traffic", stackName, agent.Name)),
+	}, pulumi.IgnoreChanges([]string{"insecureValue"}), s.child())
+	if err != nil {
+		return nil, err
+	}
+	if s.canaryParameters == nil {
+		s.canaryParameters = make(map[string]*ssm.Parameter)
+	}
+	s.canaryParameters[agent.Name] = param
+	return param, nil
+}
+
+// createCanaries creates the green error alarms of the canary agents and
+// the controller that advances their canaries. It runs after createAlarms,
+// so the controller notifies the alarm topic.
+func (s *AgentCoreStack) createCanaries(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	stackName := s.Config.StackName
+
+	canaries := pulumi.Map{}
+	var runtimeResources, parameterArns pulumi.StringArray
+	for _, agentName := range s.canaryAgents() {
+		cfg := s.Options.Agents[agentName].BlueGreen
+		runtime := s.AgentRuntimes[agentName]
+
+		alarmName := fmt.Sprintf("%s-%s-canary-errors", stackName, agentName)
+		_, err := cloudwatch.NewMetricAlarm(ctx, s.ResourceName(fmt.Sprintf("%s-canary-errors-alarm", agentName)), &cloudwatch.MetricAlarmArgs{
+			Name:             pulumi.String(alarmName),
+			AlarmDescription: pulumi.Sprintf("Agent %s's new version has system errors", agentName),
+			Namespace:        pulumi.String(runtimeMetricNamespace),
+			MetricName:       pulumi.String("SystemErrors"),
+			Dimensions: pulumi.StringMap{
+				"Operation": pulumi.String("InvokeAgentRuntime"),
+				"Resource":  runtime.RuntimeArn,
+				"Name":      pulumi.Sprintf("%s::%s", runtime.Name, greenEndpointName),
+			},
+			Statistic:          pulumi.String("Sum"),
+			Period:             pulumi.Int(60),
+			EvaluationPeriods:  pulumi.Int(1),
+			Threshold:          pulumi.Float64(float64(cfg.Canary.ErrorThreshold)),
+			ComparisonOperator: pulumi.String("GreaterThanOrEqualToThreshold"),
+			TreatMissingData:   pulumi.String("notBreaching"),
+			Tags:               mergeTags(s.agentTags(agentName, tags), pulumi.String(alarmName)),
+		}, s.child())
+		if err != nil {
+			return err
+		}
+
+		param := s.canaryParameters[agentName]
+		canaries[agentName] = pulumi.Map{
+			"runtimeId":   cloudControlAttribute(runtime.Runtime, "AgentRuntimeId"),
+			"version":     runtime.Version,
+			"parameter":   param.Name,
+			"weight":      pulumi.Int(cfg.Canary.Weight),
+			"bakeMinutes": pulumi.Int(cfg.Canary.BakeMinutes),
+			"alarms":      pulumi.ToStringArray(append([]string{alarmName}, cfg.Canary.AlarmNames...)),
+			"rollback":    pulumi.Bool(cfg.Rollback),
+		}
+		runtimeResources = append(runtimeResources, runtime.RuntimeArn, pulumi.Sprintf("%s/runtime-endpoint/*", runtime.RuntimeArn))
+		parameterArns = append(parameterArns, param.Arn)
+	}
+
+	canariesJSON := canaries.ToMapOutput().ApplyT(func(value map[string]interface{}) (string, error) {
+		data, err := json.Marshal(value)
+		return string(data), err
+	}).(pulumi.StringOutput)
+
+	env := pulumi.StringMap{
+		"STACK_NAME": pulumi.String(stackName),
+		"CANARIES":   canariesJSON,
+	}
+	statements := []policyStatement{
+		{
+			Actions:   []string{"ssm:GetParameter", "ssm:PutParameter"},
+			Resources: parameterArns,
+		},
+		{
+			Actions:   []string{"bedrock-agentcore:GetAgentRuntimeEndpoint", "bedrock-agentcore:UpdateAgentRuntimeEndpoint"},
+			Resources: runtimeResources,
+		},
+		{
+			Actions:   []string{"cloudwatch:DescribeAlarms"},
+			Resources: pulumi.StringArray{pulumi.String("*")},
+		},
+	}
+	if s.Options.Alarms != nil {
+		env["TOPIC_ARN"] = s.AlarmTopic
+		statements = append(statements, policyStatement{
+			Actions:   []string{"sns:Publish"},
+			Resources: pulumi.StringArray{s.AlarmTopic},
+		})
+	}
+
+	function, err := s.newInlineFunction(ctx, "canary-controller", canaryControllerHandler, 60, env, tags, statements...)
+	if err != nil {
+		return err
+	}
+
+	// Role assumed by EventBridge Scheduler to run the controller
+	schedulerRole, err := s.newServiceRole(ctx, "canary-controller-scheduler-role", "scheduler.amazonaws.com", tags)
+	if err != nil {
+		return err
+	}
+	err = s.newRolePolicy(ctx, "canary-controller-scheduler-policy", schedulerRole.Name, policyStatement{
+		Actions:   []string{"lambda:InvokeFunction"},
+		Resources: pulumi.StringArray{function.Arn},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = scheduler.NewSchedule(ctx, s.ResourceName("canary-controller-schedule"), &scheduler.ScheduleArgs{
+		Name:               pulumi.Sprintf("%s-canary-controller", stackName),
+		Description:        pulumi.Sprintf("Canary deployments of %s agents", stackName),
+		ScheduleExpression: pulumi.String(canaryControllerSchedule),
+		FlexibleTimeWindow: &scheduler.ScheduleFlexibleTimeWindowArgs{
+			Mode: pulumi.String("OFF"),
+		},
+		Target: &scheduler.ScheduleTargetArgs{
+			Arn:     function.Arn,
+			RoleArn: schedulerRole.Arn,
+		},
+	}, s.child())
+	return err
+}
@@ -0,0 +1,57 @@
+package agentcore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"unicode"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/cloudcontrol"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// newCloudControlResource creates a resource through the AWS Cloud Control API.
+//
+// Bedrock AgentCore resource types have no native Pulumi AWS resources yet,
+// so they are managed through their CloudFormation type schemas.
+func newCloudControlResource(ctx *pulumi.Context, name, typeName string, properties pulumi.Map, opts ...pulumi.ResourceOption) (*cloudcontrol.Resource, error) {
+	return cloudcontrol.NewResource(ctx, name, &cloudcontrol.ResourceArgs{
+		TypeName:     pulumi.String(typeName),
+		DesiredState: pulumi.JSONMarshal(properties),
+	}, opts...)
+}
+
+// cloudControlAttribute returns a top-level string attribute of a Cloud Control resource.
+func cloudControlAttribute(res *cloudcontrol.Resource, key string) pulumi.StringOutput {
+	return res.Properties.ApplyT(func(properties string) (string, error) {
+		var props map[string]interface{}
+		if err := json.Unmarshal([]byte(properties), &props); err != nil {
+			return "", err
+		}
+		value, _ := props[key].(string)
+		return value, nil
+	}).(pulumi.StringOutput)
+}
+
+// agentCoreName joins parts into a name matching the AgentCore naming
+// pattern [a-zA-Z][a-zA-Z0-9_]{0,47}. Longer names are truncated and end in
+// a hash of the full name, so names sharing a prefix stay distinct.
+func agentCoreName(parts ...string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, strings.Join(parts, "_"))
+	if name == "" || !unicode.IsLetter(rune(name[0])) {
+		name = "a" + name
+	}
+	if len(name) > 48 {
+		sum := sha256.Sum256([]byte(name))
+		name = name[:39] + "_" + hex.EncodeToString(sum[:4])
+	}
+	return name
+}
@@ -0,0 +1,120 @@
+package agentcore
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// CodeInterpreterConfig configures the AgentCore Code Interpreter tool.
+type CodeInterpreterConfig struct {
+	// NetworkMode controls network access from interpreter sessions.
+	// Supported: "SANDBOX", "PUBLIC", "VPC"
+	// Default: "SANDBOX"
+	NetworkMode string `json:"networkMode,omitempty" yaml:"networkMode,omitempty"`
+
+	// SessionTimeoutSeconds is the maximum lifetime of an interpreter session.
+	// Range: 60-28800
+	// Default: 900
+	SessionTimeoutSeconds int `json:"sessionTimeoutSeconds,omitempty" yaml:"sessionTimeoutSeconds,omitempty"`
+
+	// MaxConcurrentSessions limits the sessions an agent keeps open at once.
+	// Default: 5
+	MaxConcurrentSessions int `json:"maxConcurrentSessions,omitempty" yaml:"maxConcurrentSessions,omitempty"`
+
+	// ExecutionRoleARN is an optional role assumed by interpreter sessions
+	// to access AWS resources from executed code.
+	ExecutionRoleARN string `json:"executionRoleARN,omitempty" yaml:"executionRoleARN,omitempty"`
+
+	// Agents is the list of agent names that may use the interpreter and
+	// receive its environment variables. If empty, all agents in the stack
+	// are included.
+	Agents []string `json:"agents,omitempty" yaml:"agents,omitempty"`
+}
+
+// DefaultCodeInterpreterConfig returns a CodeInterpreterConfig with sensible defaults.
+func DefaultCodeInterpreterConfig() *CodeInterpreterConfig {
+	return &CodeInterpreterConfig{
+		NetworkMode:           "SANDBOX",
+		SessionTimeoutSeconds: 900,
+		MaxConcurrentSessions: 5,
+	}
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *CodeInterpreterConfig) ApplyDefaults() {
+	if c.NetworkMode == "" {
+		c.NetworkMode = "SANDBOX"
+	}
+	if c.SessionTimeoutSeconds == 0 {
+		c.SessionTimeoutSeconds = 900
+	}
+	if c.MaxConcurrentSessions == 0 {
+		c.MaxConcurrentSessions = 5
+	}
+}
+
+// Validate validates the CodeInterpreterConfig against the stack configuration.
+func (c *CodeInterpreterConfig) Validate(config iac.StackConfig) error {
+	if err := validateToolNetworkMode("codeInterpreter", c.NetworkMode, []string{"SANDBOX", "PUBLIC", "VPC"}, config); err != nil {
+		return err
+	}
+	if c.SessionTimeoutSeconds < 60 || c.SessionTimeoutSeconds > 28800 {
+		return fmt.Errorf("codeInterpreter.sessionTimeoutSeconds must be between 60 and 28800")
+	}
+	if c.MaxConcurrentSessions < 1 {
+		return fmt.Errorf("codeInterpreter.maxConcurrentSessions must be at least 1")
+	}
+	return validateAgentNames("codeInterpreter.agents", c.Agents, config)
+}
+
+// createCodeInterpreter creates the AgentCore Code Interpreter, grants the
+// selected agents access and injects its settings into them.
+func (s *AgentCoreStack) createCodeInterpreter(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	var err error
+	stackName := s.Config.StackName
+	cfg := s.Options.CodeInterpreter
+
+	properties := pulumi.Map{
+		"Name":                 pulumi.String(agentCoreName(stackName, "code_interpreter")),
+		"Description":          pulumi.Sprintf("Code interpreter for %s agents", stackName),
+		"NetworkConfiguration": s.toolNetworkConfiguration(cfg.NetworkMode),
+		"Tags":                 tags,
+	}
+	if cfg.ExecutionRoleARN != "" {
+		properties["ExecutionRoleArn"] = pulumi.String(cfg.ExecutionRoleARN)
+	}
+
+	s.CodeInterpreter, err = newCloudControlResource(ctx, s.ResourceName("code-interpreter"),
+		"AWS::BedrockAgentCore::CodeInterpreterCustom", properties, s.child())
+	if err != nil {
+		return err
+	}
+
+	interpreterID := cloudControlAttribute(s.CodeInterpreter, "CodeInterpreterId")
+	interpreterArn := cloudControlAttribute(s.CodeInterpreter, "CodeInterpreterArn")
+
+	for _, name := range s.selectedAgents(cfg.Agents) {
+		err = s.attachAgentPolicy(ctx, name, "code-interpreter-policy", policyStatement{
+			Actions: []string{
+				"bedrock-agentcore:StartCodeInterpreterSession",
+				"bedrock-agentcore:InvokeCodeInterpreter",
+				"bedrock-agentcore:StopCodeInterpreterSession",
+				"bedrock-agentcore:GetCodeInterpreterSession",
+				"bedrock-agentcore:ListCodeInterpreterSessions",
+			},
+			Resources: pulumi.StringArray{interpreterArn},
+		})
+		if err != nil {
+			return err
+		}
+
+		s.injectEnv(name, "CODE_INTERPRETER_ID", interpreterID)
+		s.injectEnv(name, "CODE_INTERPRETER_SESSION_TIMEOUT_SECONDS", pulumi.String(strconv.Itoa(cfg.SessionTimeoutSeconds)))
+		s.injectEnv(name, "CODE_INTERPRETER_MAX_SESSIONS", pulumi.String(strconv.Itoa(cfg.MaxConcurrentSessions)))
+	}
+
+	return nil
+}
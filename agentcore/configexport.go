@@ -0,0 +1,103 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"gopkg.in/yaml.v3"
+)
+
+// schemaComment points YAML editors at the published config schema.
+const schemaComment = "# yaml-language-server: $schema=https://raw.githubusercontent.com/plexusone/agentkit-aws-pulumi/main/agentcore/stackconfig.schema.json\n"
+
+// ExportStackConfig returns the effective StackConfig of a stack, with
+// defaults applied, as a YAML config file that NewStackFromFile deploys
+// the same way. It lets stacks built with StackBuilder move to file-driven
+// configuration and shows the settings a stack resolved to.
+//
+// The stack's Options are written under "options", which NewStackFromFile
+// reads back; Options that are Go values, such as Provider, are left out.
+// Secret values are not written: each becomes an ${env:KEY} placeholder,
+// resolved from the environment when the file is loaded.
+func ExportStackConfig(stack *AgentCoreStack) ([]byte, error) {
+	data, err := yaml.Marshal(exportedStackConfig(stack))
+	if err != nil {
+		return nil, fmt.Errorf("failed to export stack config: %w", err)
+	}
+	return append([]byte(schemaComment), data...), nil
+}
+
+// ExportStackConfigJSON is like ExportStackConfig but returns JSON.
+func ExportStackConfigJSON(stack *AgentCoreStack) ([]byte, error) {
+	data, err := json.MarshalIndent(exportedStackConfig(stack), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to export stack config: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// configFile is a config file document: a StackConfig with the stack's
+// Options under "options".
+type configFile struct {
+	iac.StackConfig `yaml:",inline"`
+
+	// Options are the stack's Options, if any are set.
+	Options *Options `json:"options,omitempty" yaml:"options,omitempty"`
+}
+
+// exportedStackConfig returns a copy of a stack's StackConfig and Options
+// with their secret values replaced by environment placeholders, and
+// without the ARNs of the secrets the stack created in the agents'
+// SecretsARNs, which the stack adds again when it is deployed from the
+// file.
+func exportedStackConfig(stack *AgentCoreStack) configFile {
+	config := stack.Config
+
+	config.Agents = make([]iac.AgentConfig, len(stack.Config.Agents))
+	for i, agent := range stack.Config.Agents {
+		agent.SecretsARNs = slices.DeleteFunc(slices.Clone(agent.SecretsARNs), func(arn string) bool {
+			_, name, found := strings.Cut(arn, ":secret:")
+			_, created := stack.Secrets[name]
+			return found && created
+		})
+		config.Agents[i] = agent
+	}
+
+	if config.Secrets != nil && len(config.Secrets.SecretValues) > 0 {
+		secrets := *config.Secrets
+		secrets.SecretValues = make(map[string]string, len(config.Secrets.SecretValues))
+		for key := range config.Secrets.SecretValues {
+			secrets.SecretValues[key] = fmt.Sprintf("${env:%s}", key)
+		}
+		config.Secrets = &secrets
+	}
+	return configFile{StackConfig: config, Options: exportedOptions(stack.Options)}
+}
+
+// exportedOptions returns a copy of Options without the settings that
+// cannot be written to a file and with OAuth2 client secrets replaced by
+// environment placeholders, or nil if no other settings are set.
+func exportedOptions(options Options) *Options {
+	options.ResourceFactory = nil
+	options.DependsOn = nil
+	options.Provider = nil
+	if reflect.ValueOf(options).IsZero() {
+		return nil
+	}
+
+	if options.Identity != nil && len(options.Identity.OAuth2Providers) > 0 {
+		identity := *options.Identity
+		identity.OAuth2Providers = slices.Clone(identity.OAuth2Providers)
+		for i, provider := range identity.OAuth2Providers {
+			if provider.ClientSecret != "" {
+				identity.OAuth2Providers[i].ClientSecret = fmt.Sprintf("${env:%s_CLIENT_SECRET}", strings.ToUpper(strings.ReplaceAll(provider.Name, "-", "_")))
+			}
+		}
+		options.Identity = &identity
+	}
+	return &options
+}
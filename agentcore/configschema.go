@@ -0,0 +1,180 @@
+package agentcore
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"gopkg.in/yaml.v3"
+)
+
+//go:generate go run ./internal/schemagen -o stackconfig.schema.json
+
+// stackConfigSchema is the JSON Schema of StackConfig.
+//
+//go:embed stackconfig.schema.json
+var stackConfigSchema []byte
+
+// StackConfigSchema returns the JSON Schema of StackConfig files, for
+// editors and CI pipelines. YAML files can reference it with a
+// "# yaml-language-server: $schema=..." comment pointing at the published
+// stackconfig.schema.json.
+func StackConfigSchema() []byte {
+	return slices.Clone(stackConfigSchema)
+}
+
+// LoadStackConfigFromFileStrict is like LoadStackConfigFromFile but rejects
+// unknown fields and values of the wrong type, reporting each with its
+// line and column, e.g. "config.yaml:12:5: unknown field \"memory\" in
+// agents[0]".
+func LoadStackConfigFromFileStrict(path string) (*iac.StackConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".json" && ext != ".yaml" && ext != ".yml" {
+		return nil, fmt.Errorf("unsupported config file extension: %s", ext)
+	}
+
+	// JSON is YAML, so both parse to nodes with positions
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, fmt.Errorf("%s: config file is empty", path)
+	}
+	if errs := checkConfigNode(path, "", doc.Content[0], reflect.TypeOf(configFile{})); len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	var config *iac.StackConfig
+	if ext == ".json" {
+		config, err = iac.LoadStackConfigFromJSON(data)
+	} else {
+		config, err = iac.LoadStackConfigFromYAML(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return config, nil
+}
+
+// ValidateConfigFile checks that a config file only has known fields with
+// values of the right type and passes StackConfig validation, reporting
+// every field error. It suits CI pipelines that check config changes
+// before deployment.
+func ValidateConfigFile(path string) error {
+	_, err := LoadStackConfigFromFileStrict(path)
+	return err
+}
+
+// checkConfigNode checks a config node against the Go type it decodes into,
+// using the JSON field names, and returns an error per unknown field or
+// mistyped value.
+func checkConfigNode(path, field string, node *yaml.Node, t reflect.Type) []error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return []error{checkConfigNodeError(path, field, node, "expected an object")}
+		}
+		fields := configFields(t)
+		var errs []error
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			fieldType, ok := fields[key.Value]
+			if !ok {
+				errs = append(errs, checkConfigNodeError(path, field, key, "unknown field %q", key.Value))
+				continue
+			}
+			errs = append(errs, checkConfigNode(path, joinConfigField(field, key.Value), value, fieldType)...)
+		}
+		return errs
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return []error{checkConfigNodeError(path, field, node, "expected an object")}
+		}
+		var errs []error
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			errs = append(errs, checkConfigNode(path, joinConfigField(field, node.Content[i].Value), node.Content[i+1], t.Elem())...)
+		}
+		return errs
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return []error{checkConfigNodeError(path, field, node, "expected a list")}
+		}
+		var errs []error
+		for i, item := range node.Content {
+			errs = append(errs, checkConfigNode(path, fmt.Sprintf("%s[%d]", field, i), item, t.Elem())...)
+		}
+		return errs
+	case reflect.String:
+		if node.Kind != yaml.ScalarNode || node.Tag != "!!str" {
+			return []error{checkConfigNodeError(path, field, node, "expected a string")}
+		}
+	case reflect.Bool:
+		if node.Kind != yaml.ScalarNode || node.Tag != "!!bool" {
+			return []error{checkConfigNodeError(path, field, node, "expected true or false")}
+		}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		if node.Kind != yaml.ScalarNode || node.Tag != "!!int" {
+			return []error{checkConfigNodeError(path, field, node, "expected an integer")}
+		}
+	case reflect.Float32, reflect.Float64:
+		if node.Kind != yaml.ScalarNode || (node.Tag != "!!int" && node.Tag != "!!float") {
+			return []error{checkConfigNodeError(path, field, node, "expected a number")}
+		}
+	}
+	return nil
+}
+
+// configFields returns the types of a struct's fields keyed by their JSON
+// names, including the fields of embedded structs.
+func configFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" && field.Anonymous && field.Type.Kind() == reflect.Struct {
+			for name, fieldType := range configFields(field.Type) {
+				fields[name] = fieldType
+			}
+		} else if name != "" && name != "-" {
+			fields[name] = field.Type
+		}
+	}
+	return fields
+}
+
+// checkConfigNodeError returns an error at the position of a node.
+func checkConfigNodeError(path, field string, node *yaml.Node, format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	if field != "" {
+		msg += " in " + field
+	}
+	return fmt.Errorf("%s:%d:%d: %s", path, node.Line, node.Column, msg)
+}
+
+// joinConfigField appends a key to a field path.
+func joinConfigField(field, key string) string {
+	if field == "" {
+		return key
+	}
+	return field + "." + key
+}
@@ -0,0 +1,192 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/cloudwatch"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// dashboardNamePattern matches the names CloudWatch accepts for dashboards.
+var dashboardNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,255}$`)
+
+// DashboardConfig creates a CloudWatch dashboard summarizing the stack's
+// agents: invocations, errors and latency percentiles per agent, and the
+// recent errors in the stack's log groups.
+type DashboardConfig struct {
+	// Name is the dashboard name.
+	// Default: {stackName}-agents
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+
+	// PeriodSeconds is the period of the metric widgets. Must be a multiple of 60.
+	// Default: 300
+	PeriodSeconds int `json:"periodSeconds,omitempty" yaml:"periodSeconds,omitempty"`
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *DashboardConfig) ApplyDefaults() {
+	if c.PeriodSeconds == 0 {
+		c.PeriodSeconds = 300
+	}
+}
+
+// Validate validates the DashboardConfig.
+func (c *DashboardConfig) Validate() error {
+	if c.Name != "" && !dashboardNamePattern.MatchString(c.Name) {
+		return fmt.Errorf("dashboard.name: '%s' is not a valid dashboard name", c.Name)
+	}
+	if c.PeriodSeconds < 60 || c.PeriodSeconds%60 != 0 {
+		return fmt.Errorf("dashboard.periodSeconds must be a multiple of 60")
+	}
+	return nil
+}
+
+// dashboardWidget is a widget of a CloudWatch dashboard body.
+type dashboardWidget struct {
+	Type       string                 `json:"type"`
+	X          int                    `json:"x"`
+	Y          int                    `json:"y"`
+	Width      int                    `json:"width"`
+	Height     int                    `json:"height"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// runtimeMetricSearch returns a SEARCH expression for a runtime metric of
+// the live endpoint of the runtime with the given AgentCore name.
+func runtimeMetricSearch(runtimeName, metric, statistic string, period int) string {
+	return fmt.Sprintf(`SEARCH('{%s,Name,Operation,Resource} MetricName="%s" Name="%s::%s"', '%s', %d)`,
+		runtimeMetricNamespace, metric, runtimeName, runtimeEndpointName, statistic, period)
+}
+
+// dashboardBody builds the dashboard widgets for the stack's agents and
+// log groups. runtimeNames maps runtime names to their AgentCore names.
+func (s *AgentCoreStack) dashboardBody(region string, logGroups []string, runtimeNames map[string]string) (string, error) {
+	stackName := s.Config.StackName
+	period := s.Options.Dashboard.PeriodSeconds
+
+	metricWidget := func(x, y int, title string, expressions map[string]string) dashboardWidget {
+		var metrics []interface{}
+		i := 0
+		for _, label := range sortedLabels(expressions) {
+			metrics = append(metrics, []interface{}{map[string]interface{}{
+				"expression": expressions[label],
+				"label":      label,
+				"id":         fmt.Sprintf("e%d", i),
+			}})
+			i++
+		}
+		return dashboardWidget{
+			Type: "metric", X: x, Y: y, Width: 8, Height: 6,
+			Properties: map[string]interface{}{
+				"title":   title,
+				"region":  region,
+				"period":  period,
+				"view":    "timeSeries",
+				"metrics": metrics,
+			},
+		}
+	}
+
+	widgets := []dashboardWidget{{
+		Type: "text", Width: 24, Height: 1,
+		Properties: map[string]interface{}{
+			"markdown": fmt.Sprintf("# %s agents", stackName),
+		},
+	}}
+	y := 1
+	for _, agent := range s.Config.Agents {
+		invocations := map[string]string{}
+		errors := map[string]string{}
+		latency := map[string]string{}
+		for _, name := range s.agentRuntimeNames(agent.Name) {
+			runtimeName := runtimeNames[name]
+			invocations[name] = runtimeMetricSearch(runtimeName, "Invocations", "Sum", period)
+			errors[name+" system"] = runtimeMetricSearch(runtimeName, "SystemErrors", "Sum", period)
+			errors[name+" user"] = runtimeMetricSearch(runtimeName, "UserErrors", "Sum", period)
+			for _, p := range []string{"p50", "p95", "p99"} {
+				latency[name+" "+p] = runtimeMetricSearch(runtimeName, "Latency", p, period)
+			}
+		}
+		widgets = append(widgets,
+			metricWidget(0, y, fmt.Sprintf("%s invocations", agent.Name), invocations),
+			metricWidget(8, y, fmt.Sprintf("%s errors", agent.Name), errors),
+			metricWidget(16, y, fmt.Sprintf("%s latency (ms)", agent.Name), latency),
+		)
+		y += 6
+	}
+
+	if len(logGroups) > 0 {
+		f := s.logging().Fields
+		var sources []string
+		for _, group := range logGroups {
+			sources = append(sources, fmt.Sprintf("SOURCE '%s'", group))
+		}
+		query := fmt.Sprintf("%s | fields @timestamp, %s, %s, %s\n| filter %s = \"ERROR\"\n| sort @timestamp desc\n| limit 50",
+			strings.Join(sources, " | "), f.Agent, f.CorrelationID, f.Message, f.Level)
+		widgets = append(widgets, dashboardWidget{
+			Type: "log", X: 0, Y: y, Width: 24, Height: 8,
+			Properties: map[string]interface{}{
+				"title":  "Recent errors",
+				"region": region,
+				"query":  query,
+				"view":   "table",
+			},
+		})
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"widgets": widgets})
+	return string(body), err
+}
+
+// sortedLabels returns the keys of a widget's expressions in order.
+func sortedLabels(expressions map[string]string) []string {
+	labels := make([]string, 0, len(expressions))
+	for label := range expressions {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return labels
+}
+
+// createDashboard creates the agent dashboard. It runs after
+// createAgentRuntimes.
+func (s *AgentCoreStack) createDashboard(ctx *pulumi.Context) error {
+	cfg := s.Options.Dashboard
+
+	region, err := aws.GetRegion(ctx, nil, s.invoke())
+	if err != nil {
+		return err
+	}
+
+	name := cfg.Name
+	if name == "" {
+		name = fmt.Sprintf("%s-agents", s.Config.StackName)
+	}
+
+	runtimeNames := pulumi.StringMap{}
+	for name, runtime := range s.AgentRuntimes {
+		runtimeNames[name] = runtime.Name
+	}
+	body := pulumi.All(s.logGroupNames().ToStringArrayOutput(), runtimeNames.ToStringMapOutput()).ApplyT(func(args []interface{}) (string, error) {
+		return s.dashboardBody(region.Name, args[0].([]string), args[1].(map[string]string))
+	}).(pulumi.StringOutput)
+
+	s.Dashboard, err = cloudwatch.NewDashboard(ctx, s.ResourceName("dashboard"), &cloudwatch.DashboardArgs{
+		DashboardName: pulumi.String(name),
+		DashboardBody: body,
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	s.DashboardURL = s.Dashboard.DashboardName.ApplyT(func(dashboard string) string {
+		return fmt.Sprintf("https://%s.console.aws.amazon.com/cloudwatch/home?region=%s#dashboards/dashboard/%s",
+			region.Name, region.Name, dashboard)
+	}).(pulumi.StringOutput)
+	return nil
+}
@@ -0,0 +1,320 @@
+package agentcore
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentcorecontrol"
+	controltypes "github.com/aws/aws-sdk-go-v2/service/bedrockagentcorecontrol/types"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	taggingtypes "github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+)
+
+const (
+	// stackTagKey tags every stack resource with the stack name so deployed
+	// stacks can be described from AWS independently of Pulumi state.
+	stackTagKey = "agentkit:stack"
+
+	// agentTagKey tags per-agent resources with the agent name.
+	agentTagKey = "agentkit:agent"
+)
+
+// StackDescription is the live topology of a deployed stack as reported by AWS.
+type StackDescription struct {
+	// StackName is the described stack.
+	StackName string `json:"stackName"`
+
+	// Agents are the stack's agent runtimes, sorted by name.
+	Agents []AgentDescription `json:"agents"`
+
+	// Roles are the stack's IAM roles with their policies, sorted by name.
+	Roles []RoleDescription `json:"roles"`
+
+	// Resources maps "service:type" (e.g. "logs:log-group") to the ARNs of
+	// all tagged resources of that type.
+	Resources map[string][]string `json:"resources"`
+}
+
+// AgentDescription describes a deployed agent runtime.
+type AgentDescription struct {
+	// Name is the agent name, from the agent tag or the runtime name.
+	Name string `json:"name"`
+
+	// RuntimeARN is the agent runtime ARN.
+	RuntimeARN string `json:"runtimeArn"`
+
+	// Version is the runtime's latest version.
+	Version string `json:"version"`
+
+	// Status is the runtime status.
+	Status string `json:"status"`
+
+	// Image is the container URI, if the runtime is container based.
+	Image string `json:"image,omitempty"`
+
+	// RoleARN is the role the runtime executes as.
+	RoleARN string `json:"roleArn"`
+
+	// Environment contains the runtime's environment variables.
+	Environment map[string]string `json:"environment,omitempty"`
+
+	// Endpoints are the runtime's endpoints.
+	Endpoints []EndpointDescription `json:"endpoints"`
+
+	// Labels are the agent's labels, from its label tags.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// EndpointDescription describes an agent runtime endpoint.
+type EndpointDescription struct {
+	// Name is the endpoint name.
+	Name string `json:"name"`
+
+	// ARN is the endpoint ARN.
+	ARN string `json:"arn"`
+
+	// LiveVersion is the runtime version currently served.
+	LiveVersion string `json:"liveVersion,omitempty"`
+
+	// TargetVersion is the version being rolled out, if any.
+	TargetVersion string `json:"targetVersion,omitempty"`
+
+	// Status is the endpoint status.
+	Status string `json:"status"`
+}
+
+// RoleDescription describes an IAM role and its policies.
+type RoleDescription struct {
+	// Name is the role name.
+	Name string `json:"name"`
+
+	// ARN is the role ARN.
+	ARN string `json:"arn"`
+
+	// InlinePolicies maps inline policy names to their JSON documents.
+	InlinePolicies map[string]string `json:"inlinePolicies,omitempty"`
+
+	// AttachedPolicies are the ARNs of attached managed policies.
+	AttachedPolicies []string `json:"attachedPolicies,omitempty"`
+}
+
+// Describe queries AWS for the resources tagged with the stack name and
+// returns the live topology of the stack. It only reads from AWS and does
+// not consult Pulumi state, so it can be compared against state for drift.
+//
+// cfg selects the account and region; IAM roles are global and are found
+// regardless of region.
+func Describe(ctx context.Context, cfg aws.Config, stackName string) (*StackDescription, error) {
+	mappings, err := taggedResources(ctx, cfg, stackName)
+	if err != nil {
+		return nil, err
+	}
+
+	desc := &StackDescription{
+		StackName: stackName,
+		Agents:    []AgentDescription{},
+		Roles:     []RoleDescription{},
+		Resources: make(map[string][]string),
+	}
+
+	for _, mapping := range mappings {
+		resourceArn := aws.ToString(mapping.ResourceARN)
+		parsed, err := arn.Parse(resourceArn)
+		if err != nil {
+			continue
+		}
+		resourceType := parsed.Resource
+		if i := strings.IndexAny(resourceType, "/:"); i >= 0 {
+			resourceType = resourceType[:i]
+		}
+		key := parsed.Service + ":" + resourceType
+		desc.Resources[key] = append(desc.Resources[key], resourceArn)
+
+		switch {
+		case parsed.Service == "bedrock-agentcore" && resourceType == "runtime":
+			agent, err := describeAgent(ctx, cfg, resourceArn, tagValue(mapping.Tags, agentTagKey))
+			if err != nil {
+				return nil, err
+			}
+			agent.Labels = labelsFromTags(mapping.Tags)
+			desc.Agents = append(desc.Agents, *agent)
+		case parsed.Service == "iam" && resourceType == "role":
+			role, err := describeRole(ctx, cfg, resourceArn)
+			if err != nil {
+				return nil, err
+			}
+			desc.Roles = append(desc.Roles, *role)
+		}
+	}
+
+	for key := range desc.Resources {
+		sort.Strings(desc.Resources[key])
+	}
+	sort.Slice(desc.Agents, func(i, j int) bool { return desc.Agents[i].Name < desc.Agents[j].Name })
+	sort.Slice(desc.Roles, func(i, j int) bool { return desc.Roles[i].Name < desc.Roles[j].Name })
+
+	return desc, nil
+}
+
+// taggedResources returns all resources in the configured region, plus IAM
+// roles, tagged with the stack name.
+func taggedResources(ctx context.Context, cfg aws.Config, stackName string) ([]taggingtypes.ResourceTagMapping, error) {
+	filters := []taggingtypes.TagFilter{{Key: aws.String(stackTagKey), Values: []string{stackName}}}
+
+	var mappings []taggingtypes.ResourceTagMapping
+	regional := resourcegroupstaggingapi.NewFromConfig(cfg)
+	// IAM resources are only returned by the tagging API in us-east-1
+	global := resourcegroupstaggingapi.NewFromConfig(cfg, func(o *resourcegroupstaggingapi.Options) {
+		o.Region = "us-east-1"
+	})
+
+	for _, query := range []struct {
+		client *resourcegroupstaggingapi.Client
+		types  []string
+	}{
+		{regional, nil},
+		{global, []string{"iam:role"}},
+	} {
+		paginator := resourcegroupstaggingapi.NewGetResourcesPaginator(query.client, &resourcegroupstaggingapi.GetResourcesInput{
+			TagFilters:          filters,
+			ResourceTypeFilters: query.types,
+		})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list resources for stack %s: %w", stackName, err)
+			}
+			mappings = append(mappings, page.ResourceTagMappingList...)
+		}
+	}
+
+	return mappings, nil
+}
+
+// describeAgent returns the runtime details and endpoints of an agent runtime.
+func describeAgent(ctx context.Context, cfg aws.Config, runtimeArn, agentName string) (*AgentDescription, error) {
+	client := bedrockagentcorecontrol.NewFromConfig(cfg)
+	runtimeID := runtimeArn[strings.LastIndex(runtimeArn, "/")+1:]
+
+	runtime, err := client.GetAgentRuntime(ctx, &bedrockagentcorecontrol.GetAgentRuntimeInput{
+		AgentRuntimeId: aws.String(runtimeID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe agent runtime %s: %w", runtimeID, err)
+	}
+
+	if agentName == "" {
+		agentName = aws.ToString(runtime.AgentRuntimeName)
+	}
+	agent := &AgentDescription{
+		Name:        agentName,
+		RuntimeARN:  aws.ToString(runtime.AgentRuntimeArn),
+		Version:     aws.ToString(runtime.AgentRuntimeVersion),
+		Status:      string(runtime.Status),
+		RoleARN:     aws.ToString(runtime.RoleArn),
+		Environment: runtime.EnvironmentVariables,
+		Endpoints:   []EndpointDescription{},
+	}
+	if container, ok := runtime.AgentRuntimeArtifact.(*controltypes.AgentRuntimeArtifactMemberContainerConfiguration); ok {
+		agent.Image = aws.ToString(container.Value.ContainerUri)
+	}
+
+	paginator := bedrockagentcorecontrol.NewListAgentRuntimeEndpointsPaginator(client, &bedrockagentcorecontrol.ListAgentRuntimeEndpointsInput{
+		AgentRuntimeId: aws.String(runtimeID),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list endpoints of agent runtime %s: %w", runtimeID, err)
+		}
+		for _, endpoint := range page.RuntimeEndpoints {
+			agent.Endpoints = append(agent.Endpoints, EndpointDescription{
+				Name:          aws.ToString(endpoint.Name),
+				ARN:           aws.ToString(endpoint.AgentRuntimeEndpointArn),
+				LiveVersion:   aws.ToString(endpoint.LiveVersion),
+				TargetVersion: aws.ToString(endpoint.TargetVersion),
+				Status:        string(endpoint.Status),
+			})
+		}
+	}
+
+	return agent, nil
+}
+
+// describeRole returns the inline and attached policies of an IAM role.
+func describeRole(ctx context.Context, cfg aws.Config, roleArn string) (*RoleDescription, error) {
+	client := iam.NewFromConfig(cfg)
+	roleName := roleArn[strings.LastIndex(roleArn, "/")+1:]
+
+	role := &RoleDescription{
+		Name:           roleName,
+		ARN:            roleArn,
+		InlinePolicies: make(map[string]string),
+	}
+
+	inline := iam.NewListRolePoliciesPaginator(client, &iam.ListRolePoliciesInput{RoleName: aws.String(roleName)})
+	for inline.HasMorePages() {
+		page, err := inline.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list policies of role %s: %w", roleName, err)
+		}
+		for _, policyName := range page.PolicyNames {
+			out, err := client.GetRolePolicy(ctx, &iam.GetRolePolicyInput{
+				RoleName:   aws.String(roleName),
+				PolicyName: aws.String(policyName),
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get policy %s of role %s: %w", policyName, roleName, err)
+			}
+			// IAM returns policy documents URL-encoded
+			document, err := url.QueryUnescape(aws.ToString(out.PolicyDocument))
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode policy %s of role %s: %w", policyName, roleName, err)
+			}
+			role.InlinePolicies[policyName] = document
+		}
+	}
+
+	attached := iam.NewListAttachedRolePoliciesPaginator(client, &iam.ListAttachedRolePoliciesInput{RoleName: aws.String(roleName)})
+	for attached.HasMorePages() {
+		page, err := attached.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list attached policies of role %s: %w", roleName, err)
+		}
+		for _, policy := range page.AttachedPolicies {
+			role.AttachedPolicies = append(role.AttachedPolicies, aws.ToString(policy.PolicyArn))
+		}
+	}
+
+	return role, nil
+}
+
+// tagValue returns the value of the tag with the given key, or "".
+func tagValue(tags []taggingtypes.Tag, key string) string {
+	for _, tag := range tags {
+		if aws.ToString(tag.Key) == key {
+			return aws.ToString(tag.Value)
+		}
+	}
+	return ""
+}
+
+// labelsFromTags returns the agent labels among resource tags, or nil.
+func labelsFromTags(tags []taggingtypes.Tag) map[string]string {
+	var labels map[string]string
+	for _, tag := range tags {
+		if key, ok := strings.CutPrefix(aws.ToString(tag.Key), labelTagPrefix); ok {
+			if labels == nil {
+				labels = make(map[string]string)
+			}
+			labels[key] = aws.ToString(tag.Value)
+		}
+	}
+	return labels
+}
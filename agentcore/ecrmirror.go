@@ -0,0 +1,374 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/codebuild"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/ecr"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/iam"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/lambda"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// imageMirrorBuildspec copies SOURCE_IMAGE to TARGET_IMAGE. The build runs
+// on ARM, so the arm64 variant AgentCore runs is pulled.
+const imageMirrorBuildspec = `version: 0.2
+phases:
+  build:
+    commands:
+      - if [ -n "$SOURCE_USERNAME" ]; then echo "$SOURCE_PASSWORD" | docker login --username "$SOURCE_USERNAME" --password-stdin "${SOURCE_IMAGE%%/*}"; fi
+      - aws ecr get-login-password | docker login --username AWS --password-stdin "${TARGET_IMAGE%%/*}"
+      - docker pull --platform linux/arm64 "$SOURCE_IMAGE"
+      - docker tag "$SOURCE_IMAGE" "$TARGET_IMAGE"
+      - docker push "$TARGET_IMAGE"
+`
+
+// imageMirrorHandler runs the mirror build for one image and waits for it,
+// so deployment fails if the image could not be copied.
+const imageMirrorHandler = `import { CodeBuildClient, StartBuildCommand, BatchGetBuildsCommand } from "@aws-sdk/client-codebuild";
+
+const codebuild = new CodeBuildClient({});
+const sleep = (ms) => new Promise((resolve) => setTimeout(resolve, ms));
+
+export const handler = async ({ source, target }) => {
+  const { build } = await codebuild.send(new StartBuildCommand({
+    projectName: process.env.PROJECT_NAME,
+    environmentVariablesOverride: [
+      { name: "SOURCE_IMAGE", value: source, type: "PLAINTEXT" },
+      { name: "TARGET_IMAGE", value: target, type: "PLAINTEXT" },
+    ],
+  }));
+
+  for (;;) {
+    await sleep(10000);
+    const { builds } = await codebuild.send(new BatchGetBuildsCommand({ ids: [build.id] }));
+    const status = builds[0].buildStatus;
+    if (status === "SUCCEEDED") return { image: target };
+    if (status !== "IN_PROGRESS") throw new Error("mirroring " + source + " failed: " + status + " (build " + build.id + ")");
+  }
+};
+`
+
+// ECRMirrorConfig configures ECR repositories for the agents' images.
+type ECRMirrorConfig struct {
+	// Agents are the agents whose images are moved to ECR.
+	// Default: all agents whose images are not already in ECR
+	Agents []string `json:"agents,omitempty" yaml:"agents,omitempty"`
+
+	// Mirror copies the agents' current images into the repositories with
+	// CodeBuild during deployment. Images are copied again when the image
+	// reference changes. If false, only the repositories are created and
+	// images must be pushed separately.
+	// Default: false
+	Mirror bool `json:"mirror,omitempty" yaml:"mirror,omitempty"`
+
+	// SourceCredentialsSecretARN is a secret with "username" and "password"
+	// keys for pulling from a private source registry.
+	SourceCredentialsSecretARN string `json:"sourceCredentialsSecretARN,omitempty" yaml:"sourceCredentialsSecretARN,omitempty"`
+
+	// ScanOnPush scans images for vulnerabilities when they are pushed.
+	// Default: false
+	ScanOnPush bool `json:"scanOnPush,omitempty" yaml:"scanOnPush,omitempty"`
+}
+
+// Validate validates the ECRMirrorConfig against the stack configuration.
+func (c *ECRMirrorConfig) Validate(config iac.StackConfig) error {
+	if err := validateAgentNames("ecrMirror.agents", c.Agents, config); err != nil {
+		return err
+	}
+	if arn := c.SourceCredentialsSecretARN; arn != "" {
+		if !strings.HasPrefix(arn, "arn:") {
+			return fmt.Errorf("ecrMirror.sourceCredentialsSecretARN: '%s' is not an ARN", arn)
+		}
+		if !c.Mirror {
+			return fmt.Errorf("ecrMirror.sourceCredentialsSecretARN requires mirror")
+		}
+	}
+	return nil
+}
+
+// ECRMirror is a component that creates an ECR repository per agent and
+// optionally copies the agents' images into them.
+//
+// Config is the stack configuration with the agents' ContainerImage
+// rewritten to the ECR image URIs. Create the stack from it and pass the
+// mirror in Options.DependsOn so runtimes are created after the images
+// are copied:
+//
+//	mirror, err := agentcore.NewECRMirror(ctx, "images", config, &agentcore.ECRMirrorConfig{Mirror: true})
+//	stack, err := agentcore.NewAgentCoreStackWithOptions(ctx, mirror.Config, agentcore.Options{
+//		DependsOn: []pulumi.Resource{mirror},
+//	})
+type ECRMirror struct {
+	pulumi.ResourceState
+
+	// Config is the stack configuration using the ECR images.
+	Config iac.StackConfig
+
+	// Repositories are the ECR repositories keyed by agent name.
+	Repositories map[string]*ecr.Repository
+
+	// Images are the ECR image URIs keyed by agent name.
+	Images map[string]string
+}
+
+// isECRImage reports whether an image reference is in a private ECR registry.
+func isECRImage(image string) bool {
+	host, _, _ := strings.Cut(image, "/")
+	return strings.Contains(host, ".dkr.ecr.")
+}
+
+// imageTag returns the tag of an image reference. Digest references are
+// tagged with the digest, and untagged references with "latest".
+func imageTag(image string) string {
+	if _, digest, ok := strings.Cut(image, "@"); ok {
+		return strings.ReplaceAll(digest, ":", "-")
+	}
+	name := image[strings.LastIndex(image, "/")+1:]
+	if _, tag, ok := strings.Cut(name, ":"); ok {
+		return tag
+	}
+	return "latest"
+}
+
+// NewECRMirror creates ECR repositories for the agents' images and, with
+// Mirror, copies the images into them.
+func NewECRMirror(ctx *pulumi.Context, name string, config iac.StackConfig, mirrorConfig *ECRMirrorConfig, opts ...pulumi.ResourceOption) (*ECRMirror, error) {
+	if mirrorConfig == nil {
+		mirrorConfig = &ECRMirrorConfig{}
+	}
+	if err := mirrorConfig.Validate(config); err != nil {
+		return nil, fmt.Errorf("invalid ECR mirror configuration: %w", err)
+	}
+
+	mirror := &ECRMirror{
+		Repositories: make(map[string]*ecr.Repository),
+		Images:       make(map[string]string),
+	}
+	if err := ctx.RegisterComponentResource("agentkit:agentcore:ECRMirror", name, mirror, opts...); err != nil {
+		return nil, err
+	}
+	parent := pulumi.Parent(mirror)
+	stackName := config.StackName
+
+	tags := pulumi.StringMap{}
+	for k, v := range config.Tags {
+		tags[k] = pulumi.String(v)
+	}
+	tags["ManagedBy"] = pulumi.String("agentkit-pulumi")
+	tags[stackTagKey] = pulumi.String(stackName)
+
+	region, err := aws.GetRegion(ctx, nil, parent)
+	if err != nil {
+		return nil, err
+	}
+	caller, err := aws.GetCallerIdentity(ctx, nil, parent)
+	if err != nil {
+		return nil, err
+	}
+	registry := fmt.Sprintf("%s.dkr.ecr.%s.amazonaws.com", caller.AccountId, region.Name)
+
+	selected := make(map[string]bool)
+	for _, agentName := range mirrorConfig.Agents {
+		selected[agentName] = true
+	}
+
+	// Copy the agents so the caller's configuration is not modified
+	agents := make([]iac.AgentConfig, len(config.Agents))
+	copy(agents, config.Agents)
+	sources := make(map[string]string)
+	var repositoryArns pulumi.StringArray
+	for i, agent := range agents {
+		if len(selected) > 0 && !selected[agent.Name] || len(selected) == 0 && isECRImage(agent.ContainerImage) {
+			continue
+		}
+
+		repositoryName := strings.ToLower(fmt.Sprintf("%s/%s", stackName, agent.Name))
+		repository, err := ecr.NewRepository(ctx, fmt.Sprintf("%s-%s-repository", name, agent.Name), &ecr.RepositoryArgs{
+			Name: pulumi.String(repositoryName),
+			ImageScanningConfiguration: &ecr.RepositoryImageScanningConfigurationArgs{
+				ScanOnPush: pulumi.Bool(mirrorConfig.ScanOnPush),
+			},
+			// Mirrored repositories only hold copies of the source images
+			ForceDelete: pulumi.Bool(mirrorConfig.Mirror),
+			Tags:        mergeTags(tags, pulumi.String(repositoryName)),
+		}, parent)
+		if err != nil {
+			return nil, err
+		}
+
+		image := fmt.Sprintf("%s/%s:%s", registry, repositoryName, imageTag(agent.ContainerImage))
+		sources[agent.Name] = agent.ContainerImage
+		agents[i].ContainerImage = image
+		mirror.Repositories[agent.Name] = repository
+		mirror.Images[agent.Name] = image
+		repositoryArns = append(repositoryArns, repository.Arn)
+	}
+	mirror.Config = config
+	mirror.Config.Agents = agents
+
+	if mirrorConfig.Mirror && len(sources) > 0 {
+		if err := mirror.copyImages(ctx, name, stackName, sources, repositoryArns, mirrorConfig, tags, parent); err != nil {
+			return nil, err
+		}
+	}
+
+	images := pulumi.StringMap{}
+	for agentName, image := range mirror.Images {
+		images[agentName] = pulumi.String(image)
+	}
+	if err := ctx.RegisterResourceOutputs(mirror, pulumi.Map{"images": images}); err != nil {
+		return nil, err
+	}
+	return mirror, nil
+}
+
+// copyImages creates the mirror build project and function and copies each
+// source image into its repository. Resource names are prefixed with the
+// component's name.
+func (m *ECRMirror) copyImages(ctx *pulumi.Context, name, stackName string, sources map[string]string, repositoryArns pulumi.StringArray, cfg *ECRMirrorConfig, tags pulumi.StringMap, parent pulumi.ResourceOption) error {
+	buildRole, err := iam.NewRole(ctx, name+"-image-mirror-build-role", &iam.RoleArgs{
+		Name:             pulumi.Sprintf("%s-image-mirror-build", stackName),
+		AssumeRolePolicy: pulumi.String(assumeRolePolicyFor("codebuild.amazonaws.com")),
+		Tags:             mergeTags(tags, pulumi.Sprintf("%s-image-mirror-build", stackName)),
+	}, parent)
+	if err != nil {
+		return err
+	}
+	statements := []policyStatement{
+		{
+			Actions:   []string{"ecr:GetAuthorizationToken"},
+			Resources: pulumi.StringArray{pulumi.String("*")},
+		},
+		{
+			Actions: []string{
+				"ecr:BatchCheckLayerAvailability",
+				"ecr:CompleteLayerUpload",
+				"ecr:InitiateLayerUpload",
+				"ecr:PutImage",
+				"ecr:UploadLayerPart",
+			},
+			Resources: repositoryArns,
+		},
+		{
+			Actions:   []string{"logs:CreateLogGroup", "logs:CreateLogStream", "logs:PutLogEvents"},
+			Resources: pulumi.StringArray{pulumi.Sprintf("arn:aws:logs:*:*:log-group:/aws/codebuild/%s-image-mirror*", stackName)},
+		},
+	}
+	var env codebuild.ProjectEnvironmentEnvironmentVariableArray
+	if secretArn := cfg.SourceCredentialsSecretARN; secretArn != "" {
+		statements = append(statements, policyStatement{
+			Actions:   []string{"secretsmanager:GetSecretValue"},
+			Resources: pulumi.ToStringArray(secretResources([]string{secretArn})),
+		})
+		for _, key := range []string{"username", "password"} {
+			env = append(env, &codebuild.ProjectEnvironmentEnvironmentVariableArgs{
+				Name:  pulumi.String("SOURCE_" + strings.ToUpper(key)),
+				Type:  pulumi.String("SECRETS_MANAGER"),
+				Value: pulumi.String(secretArn + ":" + key),
+			})
+		}
+	}
+	buildPolicy, err := iam.NewRolePolicy(ctx, name+"-image-mirror-build-policy", &iam.RolePolicyArgs{
+		Role:   buildRole.Name,
+		Policy: policyDocument(statements...),
+	}, parent)
+	if err != nil {
+		return err
+	}
+
+	project, err := codebuild.NewProject(ctx, name+"-image-mirror-project", &codebuild.ProjectArgs{
+		Name:        pulumi.Sprintf("%s-image-mirror", stackName),
+		Description: pulumi.Sprintf("Copies %s agent images into ECR", stackName),
+		ServiceRole: buildRole.Arn,
+		Artifacts: &codebuild.ProjectArtifactsArgs{
+			Type: pulumi.String("NO_ARTIFACTS"),
+		},
+		Environment: &codebuild.ProjectEnvironmentArgs{
+			Type:                 pulumi.String("ARM_CONTAINER"),
+			ComputeType:          pulumi.String("BUILD_GENERAL1_SMALL"),
+			Image:                pulumi.String("aws/codebuild/amazonlinux2-aarch64-standard:3.0"),
+			PrivilegedMode:       pulumi.Bool(true),
+			EnvironmentVariables: env,
+		},
+		Source: &codebuild.ProjectSourceArgs{
+			Type:      pulumi.String("NO_SOURCE"),
+			Buildspec: pulumi.String(imageMirrorBuildspec),
+		},
+		Tags: mergeTags(tags, pulumi.Sprintf("%s-image-mirror", stackName)),
+	}, parent, pulumi.DependsOn([]pulumi.Resource{buildPolicy}))
+	if err != nil {
+		return err
+	}
+
+	functionRole, err := iam.NewRole(ctx, name+"-image-mirror-role", &iam.RoleArgs{
+		Name:             pulumi.Sprintf("%s-image-mirror", stackName),
+		AssumeRolePolicy: pulumi.String(assumeRolePolicyFor("lambda.amazonaws.com")),
+		Tags:             mergeTags(tags, pulumi.Sprintf("%s-image-mirror", stackName)),
+	}, parent)
+	if err != nil {
+		return err
+	}
+	basicExecution, err := iam.NewRolePolicyAttachment(ctx, name+"-image-mirror-basic-execution", &iam.RolePolicyAttachmentArgs{
+		Role:      functionRole.Name,
+		PolicyArn: pulumi.String("arn:aws:iam::aws:policy/service-role/AWSLambdaBasicExecutionRole"),
+	}, parent)
+	if err != nil {
+		return err
+	}
+	functionPolicy, err := iam.NewRolePolicy(ctx, name+"-image-mirror-policy", &iam.RolePolicyArgs{
+		Role: functionRole.Name,
+		Policy: policyDocument(policyStatement{
+			Actions:   []string{"codebuild:StartBuild", "codebuild:BatchGetBuilds"},
+			Resources: pulumi.StringArray{project.Arn},
+		}),
+	}, parent)
+	if err != nil {
+		return err
+	}
+
+	function, err := lambda.NewFunction(ctx, name+"-image-mirror-function", &lambda.FunctionArgs{
+		Name:    pulumi.Sprintf("%s-image-mirror", stackName),
+		Role:    functionRole.Arn,
+		Runtime: pulumi.String(nodejsRuntime),
+		Handler: pulumi.String("index.handler"),
+		Timeout: pulumi.Int(900),
+		Code: pulumi.NewAssetArchive(map[string]interface{}{
+			"index.mjs": pulumi.NewStringAsset(imageMirrorHandler),
+		}),
+		Environment: &lambda.FunctionEnvironmentArgs{
+			Variables: pulumi.StringMap{"PROJECT_NAME": project.Name},
+		},
+		Tags: mergeTags(tags, pulumi.Sprintf("%s-image-mirror", stackName)),
+	}, parent, pulumi.DependsOn([]pulumi.Resource{basicExecution, functionPolicy}))
+	if err != nil {
+		return err
+	}
+
+	agentNames := make([]string, 0, len(sources))
+	for agentName := range sources {
+		agentNames = append(agentNames, agentName)
+	}
+	sort.Strings(agentNames)
+
+	for _, agentName := range agentNames {
+		input, err := json.Marshal(map[string]string{"source": sources[agentName], "target": m.Images[agentName]})
+		if err != nil {
+			return err
+		}
+		_, err = lambda.NewInvocation(ctx, fmt.Sprintf("%s-%s-image-mirror", name, agentName), &lambda.InvocationArgs{
+			FunctionName: function.Name,
+			Input:        pulumi.String(string(input)),
+		}, parent, pulumi.DependsOn([]pulumi.Resource{m.Repositories[agentName]}))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
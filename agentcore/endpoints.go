@@ -0,0 +1,31 @@
+package agentcore
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// DefaultQualifier is the endpoint qualifier AgentCore creates for every runtime.
+const DefaultQualifier = "DEFAULT"
+
+// AgentEndpoint describes how to invoke one agent runtime endpoint.
+type AgentEndpoint struct {
+	// RuntimeArn is the ARN of the agent runtime.
+	RuntimeArn string `json:"runtimeArn"`
+
+	// Qualifier is the endpoint name or version alias, e.g. "DEFAULT".
+	Qualifier string `json:"qualifier"`
+
+	// InvokeURL is the HTTPS URL for InvokeAgentRuntime requests.
+	InvokeURL string `json:"invokeUrl"`
+}
+
+// AgentInvokeURL returns the InvokeAgentRuntime URL for a runtime ARN and qualifier.
+// An empty qualifier uses DefaultQualifier.
+func AgentInvokeURL(region, runtimeArn, qualifier string) string {
+	if qualifier == "" {
+		qualifier = DefaultQualifier
+	}
+	return fmt.Sprintf("https://bedrock-agentcore.%s.amazonaws.com/runtimes/%s/invocations?qualifier=%s",
+		region, url.QueryEscape(runtimeArn), url.QueryEscape(qualifier))
+}
@@ -0,0 +1,236 @@
+package agentcore
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/ec2"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// ExampleCatalogVersion is the version of the example catalog. It changes
+// whenever an example changes what it deploys, so stacks bootstrapped from
+// an example can tell which revision they started from.
+const ExampleCatalogVersion = "v2"
+
+// Example profiles.
+const (
+	// ExampleSingleAgentLambda is a single agent on public networking
+	// without a VPC.
+	ExampleSingleAgentLambda = "single-agent-lambda"
+
+	// ExampleRAGTeamWithKB is an orchestrated agent team whose retriever
+	// answers from a Bedrock Knowledge Base.
+	ExampleRAGTeamWithKB = "rag-team-with-kb"
+
+	// ExampleZeroEgressEnterprise is an agent team whose agents can only
+	// reach AWS services through VPC endpoints, with a customer-managed key
+	// and an immutable audit log.
+	ExampleZeroEgressEnterprise = "zero-egress-enterprise"
+)
+
+// exampleTagKey tags stacks created from an example with its profile and
+// catalog version.
+const exampleTagKey = "agentkit:example"
+
+// ExampleStack is a complete example deployment: the stack configuration,
+// the Pulumi-specific options and the knowledge bases its agents use.
+type ExampleStack struct {
+	// Profile is the example profile, e.g. ExampleRAGTeamWithKB.
+	Profile string
+
+	// Version is the ExampleCatalogVersion the example is from.
+	Version string
+
+	// Description describes the topology.
+	Description string
+
+	// Config is the stack configuration.
+	Config StackConfig
+
+	// Options are the stack options.
+	Options Options
+
+	// KnowledgeBases maps agent names to the knowledge bases Build creates
+	// and grants to them.
+	KnowledgeBases map[string]*KnowledgeBaseConfig
+}
+
+// Build creates the example's knowledge bases and the stack.
+func (e *ExampleStack) Build(ctx *pulumi.Context, opts ...pulumi.ResourceOption) (*AgentCoreStack, error) {
+	options := e.Options
+	agentNames := make([]string, 0, len(e.KnowledgeBases))
+	for name := range e.KnowledgeBases {
+		agentNames = append(agentNames, name)
+	}
+	sort.Strings(agentNames)
+	if len(agentNames) > 0 {
+		agents := make(map[string]AgentOptions, len(options.Agents)+len(agentNames))
+		for name, agent := range options.Agents {
+			agents[name] = agent
+		}
+		for _, name := range agentNames {
+			kbConfig := *e.KnowledgeBases[name]
+			kb, err := NewKnowledgeBase(ctx, fmt.Sprintf("%s-%s-kb", e.Config.StackName, name), &kbConfig, opts...)
+			if err != nil {
+				return nil, err
+			}
+			agent := agents[name]
+			agent.KnowledgeBases = append(agent.KnowledgeBases, kb)
+			agents[name] = agent
+		}
+		options.Agents = agents
+	}
+	return NewAgentCoreStackWithOptions(ctx, e.Config, options, opts...)
+}
+
+// ExampleProfiles returns the profiles of the example catalog.
+func ExampleProfiles() []string {
+	return []string{ExampleRAGTeamWithKB, ExampleSingleAgentLambda, ExampleZeroEgressEnterprise}
+}
+
+// ExampleConfig returns the stack configuration of an example profile, to
+// bootstrap a deployment programmatically. Profiles that also need options
+// or knowledge bases are deployed with Example(profile).Build.
+func ExampleConfig(profile string) (StackConfig, error) {
+	example, err := Example(profile)
+	if err != nil {
+		return StackConfig{}, err
+	}
+	return example.Config, nil
+}
+
+// Example returns a complete example deployment. The agents run the
+// plexusone sample images; replace ContainerImage with your own.
+func Example(profile string) (*ExampleStack, error) {
+	example := &ExampleStack{Profile: profile, Version: ExampleCatalogVersion}
+
+	switch profile {
+	case ExampleSingleAgentLambda:
+		example.Description = "A single agent on public networking"
+		agent := iac.DefaultAgentConfig("assistant", "ghcr.io/plexusone/stats-orchestration:latest")
+		agent.Description = "General-purpose assistant"
+		agent.IsDefault = true
+		agent.Authorizer = &iac.AuthorizerConfig{Type: "IAM"}
+		example.Config = StackConfig{
+			StackName:   "single-agent",
+			Description: example.Description,
+			Agents:      []AgentConfig{agent},
+			Observability: &ObservabilityConfig{
+				Provider:             "cloudwatch",
+				EnableCloudWatchLogs: true,
+				LogRetentionDays:     14,
+			},
+			IAM: DefaultIAMConfig(),
+		}
+
+	case ExampleRAGTeamWithKB:
+		example.Description = "An orchestrated team whose retriever answers from a Bedrock Knowledge Base"
+		orchestrator := iac.DefaultAgentConfig("orchestrator", "ghcr.io/plexusone/stats-orchestration:latest")
+		orchestrator.Description = "Plans the answer and delegates to the team"
+		orchestrator.IsDefault = true
+		orchestrator.EnableMemory = true
+		retriever := iac.DefaultAgentConfig("retriever", "ghcr.io/plexusone/stats-research:latest")
+		retriever.Description = "Retrieves passages from the knowledge base"
+		writer := iac.DefaultAgentConfig("writer", "ghcr.io/plexusone/stats-synthesis:latest")
+		writer.Description = "Writes the answer from the retrieved passages"
+
+		iamConfig := DefaultIAMConfig()
+		iamConfig.BedrockModelIDs = []string{
+			"anthropic.claude-3-5-sonnet-20241022-v2:0",
+			"amazon.titan-embed-text-v2:0",
+		}
+		example.Config = StackConfig{
+			StackName:     "rag-team",
+			Description:   example.Description,
+			Agents:        []AgentConfig{orchestrator, retriever, writer},
+			VPC:           DefaultVPCConfig(),
+			Observability: DefaultObservabilityConfig(),
+			IAM:           iamConfig,
+		}
+		example.Config.Observability.Provider = "cloudwatch"
+		example.KnowledgeBases = map[string]*KnowledgeBaseConfig{
+			"retriever": {
+				CollectionName: "rag-team-docs",
+				Description:    "Documents the rag-team retriever answers from",
+			},
+		}
+
+	case ExampleZeroEgressEnterprise:
+		example.Description = "An agent team without internet egress, reaching AWS services through VPC endpoints"
+		intake := iac.DefaultAgentConfig("intake", "ghcr.io/plexusone/stats-orchestration:latest")
+		intake.Description = "Receives requests and routes them"
+		intake.IsDefault = true
+		intake.Authorizer = &iac.AuthorizerConfig{Type: "IAM"}
+		analyst := iac.DefaultAgentConfig("analyst", "ghcr.io/plexusone/stats-research:latest")
+		analyst.Description = "Analyzes internal data"
+		analyst.Authorizer = &iac.AuthorizerConfig{Type: "IAM"}
+
+		vpc := DefaultVPCConfig()
+		vpc.MaxAZs = 3
+		example.Config = StackConfig{
+			StackName:     "enterprise-agents",
+			Description:   example.Description,
+			Agents:        []AgentConfig{intake, analyst},
+			VPC:           vpc,
+			Observability: DefaultObservabilityConfig(),
+			IAM:           DefaultIAMConfig(),
+			RemovalPolicy: "retain",
+		}
+		example.Config.Observability.Provider = "cloudwatch"
+		example.Config.Observability.LogRetentionDays = 365
+		example.Options = Options{
+			KMS:             &KMSConfig{},
+			Audit:           DefaultAuditConfig(),
+			ResourceFactory: zeroEgressFactory{},
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown example profile %q, must be one of %v", profile, ExampleProfiles())
+	}
+
+	example.Config.Tags = map[string]string{
+		exampleTagKey: profile + "@" + ExampleCatalogVersion,
+	}
+	return example, nil
+}
+
+// zeroEgressFactory limits the agents' security group egress to the VPC,
+// where the interface endpoints are, and to S3 through its gateway
+// endpoint.
+type zeroEgressFactory struct {
+	DefaultResourceFactory
+}
+
+// CreateSecurityGroup replaces the security group's egress rules.
+func (f zeroEgressFactory) CreateSecurityGroup(ctx *pulumi.Context, stack *AgentCoreStack, args *ec2.SecurityGroupArgs, opts ...pulumi.ResourceOption) (*ec2.SecurityGroup, error) {
+	region, err := aws.GetRegion(ctx, nil, stack.invoke())
+	if err != nil {
+		return nil, err
+	}
+	s3Name := fmt.Sprintf("com.amazonaws.%s.s3", region.Name)
+	s3PrefixList, err := ec2.LookupManagedPrefixList(ctx, &ec2.LookupManagedPrefixListArgs{Name: &s3Name}, stack.invoke())
+	if err != nil {
+		return nil, err
+	}
+
+	args.Egress = ec2.SecurityGroupEgressArray{
+		&ec2.SecurityGroupEgressArgs{
+			Description: pulumi.String("HTTPS to VPC endpoints"),
+			Protocol:    pulumi.String("tcp"),
+			FromPort:    pulumi.Int(443),
+			ToPort:      pulumi.Int(443),
+			CidrBlocks:  pulumi.StringArray{pulumi.String(stack.Config.VPC.VPCCidr)},
+		},
+		&ec2.SecurityGroupEgressArgs{
+			Description:   pulumi.String("HTTPS to S3 through the gateway endpoint"),
+			Protocol:      pulumi.String("tcp"),
+			FromPort:      pulumi.Int(443),
+			ToPort:        pulumi.Int(443),
+			PrefixListIds: pulumi.StringArray{pulumi.String(s3PrefixList.Id)},
+		},
+	}
+	return f.DefaultResourceFactory.CreateSecurityGroup(ctx, stack, args, opts...)
+}
@@ -0,0 +1,83 @@
+package agentcore
+
+import (
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/ec2"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/iam"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// ResourceFactory creates pieces of the stack, so programs can substitute
+// their own logic, such as organization-mandated security group rules,
+// while the stack creates everything else.
+//
+// Implementations embed DefaultResourceFactory and override the methods
+// they need. The stack passes the arguments it would use, which
+// implementations may modify, and the options parenting the resource to
+// the stack. Resources are named with stack.ResourceName, so stacks with a
+// NamePrefix do not collide.
+type ResourceFactory interface {
+	// CreateVPC creates the VPC when VPC.CreateVPC is set. The stack
+	// creates the subnets, gateways and routes in it.
+	CreateVPC(ctx *pulumi.Context, stack *AgentCoreStack, args *ec2.VpcArgs, opts ...pulumi.ResourceOption) (*ec2.Vpc, error)
+
+	// CreateSecurityGroup creates the agents' security group and its rules.
+	CreateSecurityGroup(ctx *pulumi.Context, stack *AgentCoreStack, args *ec2.SecurityGroupArgs, opts ...pulumi.ResourceOption) (*ec2.SecurityGroup, error)
+
+	// CreateRole creates the IAM roles the stack needs, such as the
+	// execution role and service roles. name is the Pulumi resource name.
+	CreateRole(ctx *pulumi.Context, stack *AgentCoreStack, name string, args *iam.RoleArgs, opts ...pulumi.ResourceOption) (*iam.Role, error)
+
+	// ExtraResources creates additional resources once the stack's
+	// resources exist, before its outputs are exported. Values added to
+	// stack.Outputs are registered as stack outputs.
+	ExtraResources(ctx *pulumi.Context, stack *AgentCoreStack) error
+}
+
+// DefaultResourceFactory creates resources the way the stack does without a
+// ResourceFactory.
+type DefaultResourceFactory struct{}
+
+// CreateVPC creates the VPC.
+func (DefaultResourceFactory) CreateVPC(ctx *pulumi.Context, stack *AgentCoreStack, args *ec2.VpcArgs, opts ...pulumi.ResourceOption) (*ec2.Vpc, error) {
+	return ec2.NewVpc(ctx, stack.ResourceName("vpc"), args, opts...)
+}
+
+// CreateSecurityGroup creates the security group with a self-referencing
+// ingress rule for agent-to-agent communication.
+func (DefaultResourceFactory) CreateSecurityGroup(ctx *pulumi.Context, stack *AgentCoreStack, args *ec2.SecurityGroupArgs, opts ...pulumi.ResourceOption) (*ec2.SecurityGroup, error) {
+	sg, err := ec2.NewSecurityGroup(ctx, stack.ResourceName("sg"), args, opts...)
+	if err != nil {
+		return nil, err
+	}
+	_, err = ec2.NewSecurityGroupRule(ctx, stack.ResourceName("sg-self-ingress"), &ec2.SecurityGroupRuleArgs{
+		Type:                  pulumi.String("ingress"),
+		SecurityGroupId:       sg.ID(),
+		SourceSecurityGroupId: sg.ID(),
+		Protocol:              pulumi.String("-1"),
+		FromPort:              pulumi.Int(0),
+		ToPort:                pulumi.Int(0),
+		Description:           pulumi.String("Allow communication between agents"),
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return sg, nil
+}
+
+// CreateRole creates the role.
+func (DefaultResourceFactory) CreateRole(ctx *pulumi.Context, stack *AgentCoreStack, name string, args *iam.RoleArgs, opts ...pulumi.ResourceOption) (*iam.Role, error) {
+	return iam.NewRole(ctx, name, args, opts...)
+}
+
+// ExtraResources creates no resources.
+func (DefaultResourceFactory) ExtraResources(ctx *pulumi.Context, stack *AgentCoreStack) error {
+	return nil
+}
+
+// factory returns the stack's ResourceFactory.
+func (s *AgentCoreStack) factory() ResourceFactory {
+	if s.Options.ResourceFactory != nil {
+		return s.Options.ResourceFactory
+	}
+	return DefaultResourceFactory{}
+}
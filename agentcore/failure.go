@@ -0,0 +1,201 @@
+package agentcore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/sns"
+	awssqs "github.com/pulumi/pulumi-aws/sdk/v6/go/aws/sqs"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// FailureDestination configures where failed asynchronous invocations of an agent are sent.
+type FailureDestination struct {
+	// Type is the destination type.
+	// Supported: "sqs", "sns"
+	// Default: "sqs"
+	Type string `json:"type,omitempty" yaml:"type,omitempty"`
+
+	// ARN is an existing queue or topic. If empty, one is created.
+	ARN string `json:"arn,omitempty" yaml:"arn,omitempty"`
+
+	// RetentionDays is how long failed invocations are kept in a created queue.
+	// Range: 1-14
+	// Default: 14
+	RetentionDays int `json:"retentionDays,omitempty" yaml:"retentionDays,omitempty"`
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (d *FailureDestination) ApplyDefaults() {
+	if d.Type == "" {
+		d.Type = "sqs"
+	}
+	if d.RetentionDays == 0 {
+		d.RetentionDays = 14
+	}
+}
+
+// Validate validates the FailureDestination for the named agent.
+func (d *FailureDestination) Validate(agentName string) error {
+	if d.Type != "sqs" && d.Type != "sns" {
+		return fmt.Errorf("agents[%s].onFailure.type must be one of [sqs sns]", agentName)
+	}
+	if d.RetentionDays < 1 || d.RetentionDays > 14 {
+		return fmt.Errorf("agents[%s].onFailure.retentionDays must be between 1 and 14", agentName)
+	}
+	return nil
+}
+
+// FailedInvocation is the message agents publish to their failure destination.
+type FailedInvocation struct {
+	// Agent is the name of the agent that failed.
+	Agent string `json:"agent"`
+
+	// Payload is the original invocation payload.
+	Payload json.RawMessage `json:"payload"`
+
+	// Error is the failure message.
+	Error string `json:"error,omitempty"`
+
+	// SessionID is the runtime session of the failed invocation, if any.
+	SessionID string `json:"sessionId,omitempty"`
+
+	// Timestamp is when the invocation failed.
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// createFailureDestinations creates failure queues or topics for agents with OnFailure set.
+func (s *AgentCoreStack) createFailureDestinations(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	stackName := s.Config.StackName
+
+	for _, agent := range s.Config.Agents {
+		dest := s.Options.Agents[agent.Name].OnFailure
+		if dest == nil {
+			continue
+		}
+
+		var arn pulumi.StringOutput
+		switch {
+		case dest.ARN != "":
+			arn = pulumi.String(dest.ARN).ToStringOutput()
+		case dest.Type == "sqs":
+			queue, err := awssqs.NewQueue(ctx, s.ResourceName(fmt.Sprintf("%s-dlq", agent.Name)), &awssqs.QueueArgs{
+				Name:                    pulumi.Sprintf("%s-%s-dlq", stackName, agent.Name),
+				MessageRetentionSeconds: pulumi.Int(dest.RetentionDays * 24 * 60 * 60),
+				SqsManagedSseEnabled:    pulumi.Bool(true),
+				Tags:                    mergeTags(tags, pulumi.Sprintf("%s-%s-dlq", stackName, agent.Name)),
+			}, s.child())
+			if err != nil {
+				return err
+			}
+			arn = queue.Arn
+		default:
+			topic, err := sns.NewTopic(ctx, s.ResourceName(fmt.Sprintf("%s-failures", agent.Name)), &sns.TopicArgs{
+				Name: pulumi.Sprintf("%s-%s-failures", stackName, agent.Name),
+				Tags: mergeTags(tags, pulumi.Sprintf("%s-%s-failures", stackName, agent.Name)),
+			}, s.child())
+			if err != nil {
+				return err
+			}
+			arn = topic.Arn
+		}
+		s.FailureDestinations[agent.Name] = arn
+
+		s.injectEnv(agent.Name, "FAILURE_DESTINATION_TYPE", pulumi.String(dest.Type))
+		s.injectEnv(agent.Name, "FAILURE_DESTINATION_ARN", arn)
+
+		// Each agent may only write to its own destination
+		statement := policyStatement{Actions: []string{"sns:Publish"}, Resources: pulumi.StringArray{arn}}
+		if dest.Type == "sqs" {
+			statement.Actions = []string{"sqs:SendMessage"}
+		}
+		if err := s.attachAgentPolicy(ctx, agent.Name, "failure-destination-policy", statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReplayFunc re-invokes an agent with a failed invocation.
+type ReplayFunc func(ctx context.Context, failed FailedInvocation) error
+
+// ReplayResult summarizes a replay run.
+type ReplayResult struct {
+	// Replayed is the number of invocations that succeeded and were removed from the queue.
+	Replayed int
+
+	// Failed is the number of invocations that failed again and remain in the queue.
+	Failed int
+
+	// Errors contains the replay error for each failed invocation.
+	Errors []error
+}
+
+// ReplayFailedInvocations drains up to maxMessages failed invocations from
+// an agent's failure queue and passes each one to replay. Messages are
+// deleted only after replay succeeds; failures stay in the queue and become
+// visible again after the queue's visibility timeout.
+//
+// queueArn is the agent's entry in the failureDestinations stack output.
+func ReplayFailedInvocations(ctx context.Context, cfg aws.Config, queueArn string, maxMessages int, replay ReplayFunc) (*ReplayResult, error) {
+	parsed, err := arn.Parse(queueArn)
+	if err != nil || parsed.Service != "sqs" {
+		return nil, fmt.Errorf("invalid failure queue ARN %q", queueArn)
+	}
+
+	client := sqs.NewFromConfig(cfg, func(o *sqs.Options) {
+		o.Region = parsed.Region
+	})
+	urlOut, err := client.GetQueueUrl(ctx, &sqs.GetQueueUrlInput{
+		QueueName:              aws.String(parsed.Resource),
+		QueueOwnerAWSAccountId: aws.String(parsed.AccountID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve failure queue URL: %w", err)
+	}
+	queueURL := urlOut.QueueUrl
+	result := &ReplayResult{}
+
+	for result.Replayed+result.Failed < maxMessages {
+		batch := int32(min(10, maxMessages-result.Replayed-result.Failed)) //nolint:gosec // bounded by 10
+		out, err := client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            queueURL,
+			MaxNumberOfMessages: batch,
+			WaitTimeSeconds:     1,
+		})
+		if err != nil {
+			return result, fmt.Errorf("failed to receive failed invocations: %w", err)
+		}
+		if len(out.Messages) == 0 {
+			break
+		}
+
+		for _, msg := range out.Messages {
+			var failed FailedInvocation
+			if err := json.Unmarshal([]byte(aws.ToString(msg.Body)), &failed); err != nil {
+				result.Failed++
+				result.Errors = append(result.Errors, fmt.Errorf("message %s: %w", aws.ToString(msg.MessageId), err))
+				continue
+			}
+			if err := replay(ctx, failed); err != nil {
+				result.Failed++
+				result.Errors = append(result.Errors, fmt.Errorf("message %s: %w", aws.ToString(msg.MessageId), err))
+				continue
+			}
+			if _, err := client.DeleteMessage(ctx, &sqs.DeleteMessageInput{
+				QueueUrl:      queueURL,
+				ReceiptHandle: msg.ReceiptHandle,
+			}); err != nil {
+				return result, fmt.Errorf("failed to delete replayed message %s: %w", aws.ToString(msg.MessageId), err)
+			}
+			result.Replayed++
+		}
+	}
+
+	return result, nil
+}
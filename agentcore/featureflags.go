@@ -0,0 +1,455 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/appconfig"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// featureFlagKeyPattern matches AppConfig feature flag and attribute keys.
+var featureFlagKeyPattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]{0,63}$`)
+
+// appConfigProfileNamePattern matches the configuration profile names the
+// stack accepts, which also name environment variables.
+var appConfigProfileNamePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]{0,63}$`)
+
+// FeatureFlagConfig provisions AWS AppConfig feature flags and freeform
+// configuration profiles for agents, so prompts, model choices and tool
+// toggles can change at runtime without redeploying the agents.
+//
+// The flags and profiles declared here are deployed with the stack using
+// the Rollout strategy. Versions deployed from the AppConfig console or CLI
+// stay in effect until the declared content changes.
+//
+// Agents get FEATURE_FLAGS_APPLICATION, FEATURE_FLAGS_ENVIRONMENT and
+// FEATURE_FLAGS_PROFILE for AppConfig Data sessions, and
+// APPCONFIG_{NAME}_PROFILE for each freeform profile.
+type FeatureFlagConfig struct {
+	// Environment is the AppConfig environment name.
+	// Default: "default"
+	Environment string `json:"environment,omitempty" yaml:"environment,omitempty"`
+
+	// Flags are the feature flags, keyed by flag name.
+	Flags map[string]FeatureFlag `json:"flags,omitempty" yaml:"flags,omitempty"`
+
+	// Profiles are freeform JSON configuration profiles, such as prompts or
+	// model choices, keyed by profile name.
+	Profiles map[string]json.RawMessage `json:"profiles,omitempty" yaml:"profiles,omitempty"`
+
+	// Rollout is the deployment strategy of flag and profile changes.
+	// Default: linear over 10 minutes in 20% steps with a 10 minute bake
+	Rollout *FeatureFlagRollout `json:"rollout,omitempty" yaml:"rollout,omitempty"`
+
+	// ProfileRollouts override Rollout for individual profiles, keyed by
+	// profile name ("flags" for the feature flags), e.g. a slower canary
+	// for prompt changes.
+	ProfileRollouts map[string]*FeatureFlagRollout `json:"profileRollouts,omitempty" yaml:"profileRollouts,omitempty"`
+
+	// RollbackAlarmARNs are CloudWatch alarms that roll back a deployment
+	// in progress when they fire.
+	RollbackAlarmARNs []string `json:"rollbackAlarmARNs,omitempty" yaml:"rollbackAlarmARNs,omitempty"`
+
+	// RollbackOnHealthAlarm rolls back a deployment in progress when the
+	// stack health alarm fires. Requires Options.Health.
+	RollbackOnHealthAlarm bool `json:"rollbackOnHealthAlarm,omitempty" yaml:"rollbackOnHealthAlarm,omitempty"`
+
+	// Agents is the list of agent names that read the flags.
+	// If empty, all agents in the stack are included.
+	Agents []string `json:"agents,omitempty" yaml:"agents,omitempty"`
+}
+
+// FeatureFlag is an AppConfig feature flag.
+type FeatureFlag struct {
+	// Enabled is the flag's value.
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Description describes the flag.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+
+	// Attributes are values that vary with the flag, such as a model ID.
+	// Supported: string, number and boolean values
+	Attributes map[string]interface{} `json:"attributes,omitempty" yaml:"attributes,omitempty"`
+}
+
+// FeatureFlagRollout is a gradual deployment strategy.
+type FeatureFlagRollout struct {
+	// Strategy is a predefined AppConfig deployment strategy, such as
+	// "AppConfig.Canary10Percent20Minutes". If set, the other fields are
+	// ignored.
+	Strategy string `json:"strategy,omitempty" yaml:"strategy,omitempty"`
+
+	// DurationMinutes is how long the rollout takes.
+	// Range: 0-1440
+	// Default: 10
+	DurationMinutes *int `json:"durationMinutes,omitempty" yaml:"durationMinutes,omitempty"`
+
+	// GrowthPercent is the percentage of targets that receive the change
+	// in each step.
+	// Range: 1-100
+	// Default: 20
+	GrowthPercent float64 `json:"growthPercent,omitempty" yaml:"growthPercent,omitempty"`
+
+	// GrowthType is how the percentage grows.
+	// Supported: "LINEAR", "EXPONENTIAL"
+	// Default: "LINEAR"
+	GrowthType string `json:"growthType,omitempty" yaml:"growthType,omitempty"`
+
+	// BakeMinutes is how long the rollback alarms are watched after the
+	// rollout completes.
+	// Range: 0-1440
+	// Default: 10
+	BakeMinutes *int `json:"bakeMinutes,omitempty" yaml:"bakeMinutes,omitempty"`
+}
+
+// LinearRollout returns a rollout that deploys to percent of targets per
+// step over minutes, then watches the rollback alarms for bakeMinutes.
+func LinearRollout(percent float64, minutes, bakeMinutes int) *FeatureFlagRollout {
+	return &FeatureFlagRollout{
+		DurationMinutes: &minutes,
+		GrowthPercent:   percent,
+		GrowthType:      "LINEAR",
+		BakeMinutes:     &bakeMinutes,
+	}
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *FeatureFlagConfig) ApplyDefaults() {
+	if c.Environment == "" {
+		c.Environment = "default"
+	}
+	if c.Rollout == nil {
+		c.Rollout = &FeatureFlagRollout{}
+	}
+	c.Rollout.ApplyDefaults()
+	for _, rollout := range c.ProfileRollouts {
+		if rollout != nil {
+			rollout.ApplyDefaults()
+		}
+	}
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (r *FeatureFlagRollout) ApplyDefaults() {
+	if r.Strategy != "" {
+		return
+	}
+	if r.DurationMinutes == nil {
+		duration := 10
+		r.DurationMinutes = &duration
+	}
+	if r.GrowthPercent == 0 {
+		r.GrowthPercent = 20
+	}
+	if r.GrowthType == "" {
+		r.GrowthType = "LINEAR"
+	}
+	if r.BakeMinutes == nil {
+		bake := 10
+		r.BakeMinutes = &bake
+	}
+}
+
+// validate validates the FeatureFlagRollout at the given field.
+func (r *FeatureFlagRollout) validate(field string) error {
+	if r.Strategy != "" {
+		if !strings.HasPrefix(r.Strategy, "AppConfig.") {
+			return fmt.Errorf("%s.strategy must be a predefined AppConfig strategy", field)
+		}
+		return nil
+	}
+	if *r.DurationMinutes < 0 || *r.DurationMinutes > 1440 {
+		return fmt.Errorf("%s.durationMinutes must be between 0 and 1440", field)
+	}
+	if r.GrowthPercent < 1 || r.GrowthPercent > 100 {
+		return fmt.Errorf("%s.growthPercent must be between 1 and 100", field)
+	}
+	if r.GrowthType != "LINEAR" && r.GrowthType != "EXPONENTIAL" {
+		return fmt.Errorf("%s.growthType must be one of [LINEAR EXPONENTIAL]", field)
+	}
+	if *r.BakeMinutes < 0 || *r.BakeMinutes > 1440 {
+		return fmt.Errorf("%s.bakeMinutes must be between 0 and 1440", field)
+	}
+	return nil
+}
+
+// Validate validates the FeatureFlagConfig against the stack configuration.
+func (c *FeatureFlagConfig) Validate(config iac.StackConfig) error {
+	if len(c.Flags) == 0 && len(c.Profiles) == 0 {
+		return fmt.Errorf("featureFlags: at least one flag or profile is required")
+	}
+	if !appConfigProfileNamePattern.MatchString(c.Environment) {
+		return fmt.Errorf("featureFlags.environment: '%s' is not a valid environment name", c.Environment)
+	}
+	for name, flag := range c.Flags {
+		if !featureFlagKeyPattern.MatchString(name) {
+			return fmt.Errorf("featureFlags.flags: '%s' is not a valid flag name", name)
+		}
+		for key, value := range flag.Attributes {
+			if !featureFlagKeyPattern.MatchString(key) {
+				return fmt.Errorf("featureFlags.flags[%s].attributes: '%s' is not a valid attribute name", name, key)
+			}
+			if featureFlagAttributeType(value) == "" {
+				return fmt.Errorf("featureFlags.flags[%s].attributes[%s] must be a string, number or boolean", name, key)
+			}
+		}
+	}
+	for name, content := range c.Profiles {
+		if !appConfigProfileNamePattern.MatchString(name) || name == featureFlagsProfile {
+			return fmt.Errorf("featureFlags.profiles: '%s' is not a valid profile name", name)
+		}
+		if !json.Valid(content) {
+			return fmt.Errorf("featureFlags.profiles[%s] must be valid JSON", name)
+		}
+	}
+	if err := c.Rollout.validate("featureFlags.rollout"); err != nil {
+		return err
+	}
+	for name, rollout := range c.ProfileRollouts {
+		_, isProfile := c.Profiles[name]
+		if !isProfile && (name != featureFlagsProfile || len(c.Flags) == 0) {
+			return fmt.Errorf("featureFlags.profileRollouts: '%s' does not match any profile", name)
+		}
+		if rollout == nil {
+			return fmt.Errorf("featureFlags.profileRollouts[%s] must not be empty", name)
+		}
+		if err := rollout.validate(fmt.Sprintf("featureFlags.profileRollouts[%s]", name)); err != nil {
+			return err
+		}
+	}
+	return validateAgentNames("featureFlags.agents", c.Agents, config)
+}
+
+// featureFlagsProfile is the name of the feature flag profile.
+const featureFlagsProfile = "flags"
+
+// featureFlagAttributeType returns the AppConfig constraint type of an
+// attribute value, or "" if the value is not supported.
+func featureFlagAttributeType(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int, int32, int64, float32, float64:
+		return "number"
+	default:
+		return ""
+	}
+}
+
+// featureFlagsDocument renders flags in the AWS.AppConfig.FeatureFlags format.
+func featureFlagsDocument(flags map[string]FeatureFlag) (string, error) {
+	definitions := map[string]interface{}{}
+	values := map[string]interface{}{}
+	for name, flag := range flags {
+		definition := map[string]interface{}{"name": name}
+		if flag.Description != "" {
+			definition["description"] = flag.Description
+		}
+		value := map[string]interface{}{"enabled": flag.Enabled}
+		if len(flag.Attributes) > 0 {
+			attributes := map[string]interface{}{}
+			for key, attr := range flag.Attributes {
+				attributes[key] = map[string]interface{}{
+					"constraints": map[string]interface{}{"type": featureFlagAttributeType(attr)},
+				}
+				value[key] = attr
+			}
+			definition["attributes"] = attributes
+		}
+		definitions[name] = definition
+		values[name] = value
+	}
+	doc, err := json.Marshal(map[string]interface{}{
+		"version": "1",
+		"flags":   definitions,
+		"values":  values,
+	})
+	return string(doc), err
+}
+
+// createFeatureFlags creates the AppConfig application, environment,
+// profiles and deployments, and grants the selected agents read access.
+func (s *AgentCoreStack) createFeatureFlags(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	var err error
+	stackName := s.Config.StackName
+	cfg := s.Options.FeatureFlags
+
+	appName := fmt.Sprintf("%s-flags", stackName)
+	s.FeatureFlagApplication, err = appconfig.NewApplication(ctx, s.ResourceName("feature-flags"), &appconfig.ApplicationArgs{
+		Name:        pulumi.String(appName),
+		Description: pulumi.Sprintf("Feature flags of the %s agents", stackName),
+		Tags:        mergeTags(tags, pulumi.String(appName)),
+	}, s.child())
+	if err != nil {
+		return err
+	}
+	appID := s.FeatureFlagApplication.ID()
+
+	alarmArns := pulumi.ToStringArray(cfg.RollbackAlarmARNs)
+	if cfg.RollbackOnHealthAlarm {
+		alarmArns = append(alarmArns, s.HealthAlarm.Arn)
+	}
+	var monitors appconfig.EnvironmentMonitorArray
+	if len(alarmArns) > 0 {
+		monitorRole, err := s.newServiceRole(ctx, "feature-flags-monitor", "appconfig.amazonaws.com", tags)
+		if err != nil {
+			return err
+		}
+		err = s.newRolePolicy(ctx, "feature-flags-monitor-policy", monitorRole.Name, policyStatement{
+			Actions:   []string{"cloudwatch:DescribeAlarms"},
+			Resources: alarmArns,
+		})
+		if err != nil {
+			return err
+		}
+		for _, alarmArn := range alarmArns {
+			monitors = append(monitors, appconfig.EnvironmentMonitorArgs{
+				AlarmArn:     alarmArn,
+				AlarmRoleArn: monitorRole.Arn,
+			})
+		}
+	}
+
+	environment, err := appconfig.NewEnvironment(ctx, s.ResourceName("feature-flags-env"), &appconfig.EnvironmentArgs{
+		ApplicationId: appID,
+		Name:          pulumi.String(cfg.Environment),
+		Monitors:      monitors,
+		Tags:          tags,
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	strategy, err := s.newDeploymentStrategy(ctx, "feature-flags-rollout",
+		fmt.Sprintf("%s-flags-rollout", stackName), cfg.Rollout, tags)
+	if err != nil {
+		return err
+	}
+
+	// Profiles in a stable order, the feature flags first
+	type profile struct {
+		name, profileType, content string
+	}
+	var profiles []profile
+	if len(cfg.Flags) > 0 {
+		content, err := featureFlagsDocument(cfg.Flags)
+		if err != nil {
+			return err
+		}
+		profiles = append(profiles, profile{featureFlagsProfile, "AWS.AppConfig.FeatureFlags", content})
+	}
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		profiles = append(profiles, profile{name, "AWS.Freeform", string(cfg.Profiles[name])})
+	}
+
+	agents := s.selectedAgents(cfg.Agents)
+	for _, p := range profiles {
+		configProfile, err := appconfig.NewConfigurationProfile(ctx, s.ResourceName(fmt.Sprintf("feature-flags-%s", p.name)), &appconfig.ConfigurationProfileArgs{
+			ApplicationId: appID,
+			Name:          pulumi.String(p.name),
+			LocationUri:   pulumi.String("hosted"),
+			Type:          pulumi.String(p.profileType),
+			Tags:          tags,
+		}, s.child())
+		if err != nil {
+			return err
+		}
+		version, err := appconfig.NewHostedConfigurationVersion(ctx, s.ResourceName(fmt.Sprintf("feature-flags-%s-version", p.name)), &appconfig.HostedConfigurationVersionArgs{
+			ApplicationId:          appID,
+			ConfigurationProfileId: configProfile.ConfigurationProfileId,
+			ContentType:            pulumi.String("application/json"),
+			Content:                pulumi.String(p.content),
+		}, s.child())
+		if err != nil {
+			return err
+		}
+		profileStrategy := strategy
+		if rollout, ok := cfg.ProfileRollouts[p.name]; ok {
+			profileStrategy, err = s.newDeploymentStrategy(ctx, fmt.Sprintf("feature-flags-%s-rollout", p.name),
+				fmt.Sprintf("%s-%s-rollout", stackName, p.name), rollout, tags)
+			if err != nil {
+				return err
+			}
+		}
+		_, err = appconfig.NewDeployment(ctx, s.ResourceName(fmt.Sprintf("feature-flags-%s-deployment", p.name)), &appconfig.DeploymentArgs{
+			ApplicationId:          appID,
+			EnvironmentId:          environment.EnvironmentId,
+			ConfigurationProfileId: configProfile.ConfigurationProfileId,
+			ConfigurationVersion:   version.VersionNumber.ApplyT(strconv.Itoa).(pulumi.StringOutput),
+			DeploymentStrategyId:   profileStrategy,
+			Description:            pulumi.Sprintf("%s profile of the %s stack", p.name, stackName),
+			Tags:                   tags,
+		}, s.child())
+		if err != nil {
+			return err
+		}
+
+		key := "FEATURE_FLAGS_PROFILE"
+		if p.name != featureFlagsProfile {
+			key = appConfigProfileEnvVar(p.name)
+		}
+		for _, name := range agents {
+			s.injectEnv(name, key, pulumi.String(p.name))
+		}
+	}
+
+	for _, name := range agents {
+		s.injectEnv(name, "FEATURE_FLAGS_APPLICATION", pulumi.String(appName))
+		s.injectEnv(name, "FEATURE_FLAGS_ENVIRONMENT", pulumi.String(cfg.Environment))
+		err := s.attachAgentPolicy(ctx, name, "feature-flags-policy", policyStatement{
+			Actions: []string{
+				"appconfig:StartConfigurationSession",
+				"appconfig:GetLatestConfiguration",
+			},
+			Resources: pulumi.StringArray{
+				pulumi.Sprintf("%s/environment/%s/configuration/*", s.FeatureFlagApplication.Arn, environment.EnvironmentId),
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newDeploymentStrategy returns the ID of a rollout's deployment strategy,
+// creating it unless the rollout names a predefined strategy.
+func (s *AgentCoreStack) newDeploymentStrategy(ctx *pulumi.Context, resourceName, name string,
+	rollout *FeatureFlagRollout, tags pulumi.StringMap) (pulumi.StringInput, error) {
+	if rollout.Strategy != "" {
+		return pulumi.String(rollout.Strategy), nil
+	}
+	strategy, err := appconfig.NewDeploymentStrategy(ctx, s.ResourceName(resourceName), &appconfig.DeploymentStrategyArgs{
+		Name:                        pulumi.String(name),
+		Description:                 pulumi.Sprintf("%s%% %s over %d minutes", strconv.FormatFloat(rollout.GrowthPercent, 'f', -1, 64), strings.ToLower(rollout.GrowthType), *rollout.DurationMinutes),
+		DeploymentDurationInMinutes: pulumi.Int(*rollout.DurationMinutes),
+		GrowthFactor:                pulumi.Float64(rollout.GrowthPercent),
+		GrowthType:                  pulumi.String(rollout.GrowthType),
+		FinalBakeTimeInMinutes:      pulumi.Int(*rollout.BakeMinutes),
+		ReplicateTo:                 pulumi.String("NONE"),
+		Tags:                        tags,
+	}, s.child())
+	if err != nil {
+		return nil, err
+	}
+	return strategy.ID(), nil
+}
+
+// appConfigProfileEnvVar returns the environment variable holding the name
+// of a freeform profile.
+func appConfigProfileEnvVar(profileName string) string {
+	return fmt.Sprintf("APPCONFIG_%s_PROFILE", strings.ToUpper(strings.ReplaceAll(profileName, "-", "_")))
+}
@@ -0,0 +1,209 @@
+package agentcore
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/bedrock"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// FineTuningConfig configures a Bedrock model customization job trained on
+// data captured from agent traffic.
+//
+// Customization jobs run asynchronously. Agents listed in SwapAgents keep
+// using BaseModelID until an update observes the job as completed, after
+// which the custom model ARN is injected in its place.
+type FineTuningConfig struct {
+	// Name is the unique job name within the stack.
+	Name string `json:"name" yaml:"name"`
+
+	// BaseModelID is the Bedrock model to customize.
+	BaseModelID string `json:"baseModelId" yaml:"baseModelId"`
+
+	// CustomizationType is the kind of customization job.
+	// Supported: "FINE_TUNING", "CONTINUED_PRE_TRAINING"
+	// Default: "FINE_TUNING"
+	CustomizationType string `json:"customizationType,omitempty" yaml:"customizationType,omitempty"`
+
+	// TrainingDataURI is the S3 URI of the JSONL training data.
+	// If empty, a stack bucket is created and training data is read from
+	// "fine-tuning/{name}/training.jsonl".
+	TrainingDataURI string `json:"trainingDataURI,omitempty" yaml:"trainingDataURI,omitempty"`
+
+	// ValidationDataURI is the S3 URI of the JSONL validation data.
+	// Optional.
+	ValidationDataURI string `json:"validationDataURI,omitempty" yaml:"validationDataURI,omitempty"`
+
+	// OutputURI is the S3 prefix job metrics and artifacts are written to.
+	// Default: "fine-tuning/{name}/output/" in the training data bucket
+	OutputURI string `json:"outputURI,omitempty" yaml:"outputURI,omitempty"`
+
+	// Hyperparameters are passed to the customization job, e.g. "epochCount".
+	Hyperparameters map[string]string `json:"hyperparameters,omitempty" yaml:"hyperparameters,omitempty"`
+
+	// SwapAgents are the agents switched to the custom model on completion.
+	SwapAgents []string `json:"swapAgents,omitempty" yaml:"swapAgents,omitempty"`
+
+	// ModelEnvVar is the environment variable agents read their model ID from.
+	// Default: "MODEL_ID"
+	ModelEnvVar string `json:"modelEnvVar,omitempty" yaml:"modelEnvVar,omitempty"`
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *FineTuningConfig) ApplyDefaults() {
+	if c.CustomizationType == "" {
+		c.CustomizationType = "FINE_TUNING"
+	}
+	if c.ModelEnvVar == "" {
+		c.ModelEnvVar = "MODEL_ID"
+	}
+}
+
+// Validate validates the FineTuningConfig against the stack configuration.
+func (c *FineTuningConfig) Validate(config iac.StackConfig) error {
+	if !batchJobNamePattern.MatchString(c.Name) {
+		return fmt.Errorf("fineTuning: name %q must match %s", c.Name, batchJobNamePattern)
+	}
+	if c.BaseModelID == "" {
+		return fmt.Errorf("fineTuning[%s]: baseModelId is required", c.Name)
+	}
+	if c.CustomizationType != "FINE_TUNING" && c.CustomizationType != "CONTINUED_PRE_TRAINING" {
+		return fmt.Errorf("fineTuning[%s]: customizationType must be one of [FINE_TUNING CONTINUED_PRE_TRAINING]", c.Name)
+	}
+	for field, uri := range map[string]string{
+		"trainingDataURI":   c.TrainingDataURI,
+		"validationDataURI": c.ValidationDataURI,
+		"outputURI":         c.OutputURI,
+	} {
+		if uri != "" && !strings.HasPrefix(uri, "s3://") {
+			return fmt.Errorf("fineTuning[%s]: %s must be an s3:// URI", c.Name, field)
+		}
+	}
+	if c.TrainingDataURI == "" && c.OutputURI != "" {
+		return fmt.Errorf("fineTuning[%s]: outputURI requires trainingDataURI", c.Name)
+	}
+	return validateAgentNames(fmt.Sprintf("fineTuning[%s].swapAgents", c.Name), c.SwapAgents, config)
+}
+
+// createFineTuningJobs creates Bedrock model customization jobs and their IAM role.
+func (s *AgentCoreStack) createFineTuningJobs(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	stackName := s.Config.StackName
+
+	role, err := s.newServiceRole(ctx, "fine-tuning-role", "bedrock.amazonaws.com", tags)
+	if err != nil {
+		return err
+	}
+
+	var readArns, writeArns, bucketArns pulumi.StringArray
+	for _, job := range s.Options.FineTuning {
+		training := pulumi.String(job.TrainingDataURI).ToStringOutput()
+		output := pulumi.String(job.OutputURI).ToStringOutput()
+
+		if job.TrainingDataURI == "" {
+			if s.FineTuningBucket == nil {
+				s.FineTuningBucket, err = s.newPrivateBucket(ctx, "fine-tuning-bucket", "fine-tuning", tags)
+				if err != nil {
+					return err
+				}
+			}
+			training = pulumi.Sprintf("s3://%s/fine-tuning/%s/training.jsonl", s.FineTuningBucket.Bucket, job.Name)
+		}
+		if job.OutputURI == "" {
+			output = training.ApplyT(func(uri string) string {
+				bucket := strings.SplitN(strings.TrimPrefix(uri, "s3://"), "/", 2)[0]
+				return fmt.Sprintf("s3://%s/fine-tuning/%s/output/", bucket, job.Name)
+			}).(pulumi.StringOutput)
+		}
+
+		readArns = append(readArns, s3ObjectArn(training))
+		writeArns = append(writeArns, pulumi.Sprintf("%s*", s3ObjectArn(output)))
+		bucketArns = append(bucketArns, s3BucketArn(training), s3BucketArn(output))
+
+		args := &bedrock.CustomModelArgs{
+			BaseModelIdentifier: pulumi.String(job.BaseModelID),
+			CustomModelName:     pulumi.Sprintf("%s-%s", stackName, job.Name),
+			JobName:             pulumi.Sprintf("%s-%s", stackName, job.Name),
+			CustomizationType:   pulumi.String(job.CustomizationType),
+			Hyperparameters:     pulumi.ToStringMap(job.Hyperparameters),
+			RoleArn:             role.Arn,
+			TrainingDataConfig: &bedrock.CustomModelTrainingDataConfigArgs{
+				S3Uri: training,
+			},
+			OutputDataConfig: &bedrock.CustomModelOutputDataConfigArgs{
+				S3Uri: output,
+			},
+			Tags: mergeTags(tags, pulumi.Sprintf("%s-%s", stackName, job.Name)),
+		}
+		if job.ValidationDataURI != "" {
+			args.ValidationDataConfig = &bedrock.CustomModelValidationDataConfigArgs{
+				Validators: bedrock.CustomModelValidationDataConfigValidatorArray{
+					&bedrock.CustomModelValidationDataConfigValidatorArgs{
+						S3Uri: pulumi.String(job.ValidationDataURI),
+					},
+				},
+			}
+			readArns = append(readArns, s3ObjectArn(pulumi.String(job.ValidationDataURI).ToStringOutput()))
+		}
+
+		model, err := bedrock.NewCustomModel(ctx, s.ResourceName(fmt.Sprintf("fine-tuning-%s", job.Name)), args, s.child())
+		if err != nil {
+			return err
+		}
+		s.CustomModels[job.Name] = model.CustomModelArn
+
+		// Swap to the custom model once the job has completed
+		modelID := pulumi.All(model.JobStatus, model.CustomModelArn).ApplyT(func(args []interface{}) string {
+			status, _ := args[0].(string)
+			arn, _ := args[1].(string)
+			if status == "Completed" && arn != "" {
+				return arn
+			}
+			return job.BaseModelID
+		}).(pulumi.StringOutput)
+		for _, name := range job.SwapAgents {
+			s.injectEnv(name, job.ModelEnvVar, modelID)
+		}
+	}
+
+	err = s.newRolePolicy(ctx, "fine-tuning-policy", role.Name,
+		policyStatement{
+			Actions:   []string{"s3:ListBucket"},
+			Resources: bucketArns,
+		},
+		policyStatement{
+			Actions:   []string{"s3:GetObject"},
+			Resources: readArns,
+		},
+		policyStatement{
+			Actions:   []string{"s3:PutObject"},
+			Resources: writeArns,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	return s.attachRolePolicy(ctx, "custom-model-invoke-policy", policyStatement{
+		Actions: []string{"bedrock:InvokeModel", "bedrock:InvokeModelWithResponseStream"},
+		Resources: pulumi.StringArray{
+			pulumi.String("arn:aws:bedrock:*:*:custom-model/*"),
+			pulumi.String("arn:aws:bedrock:*:*:provisioned-model/*"),
+		},
+	})
+}
+
+// s3ObjectArn converts an s3:// URI to an object ARN.
+func s3ObjectArn(uri pulumi.StringOutput) pulumi.StringOutput {
+	return uri.ApplyT(func(u string) string {
+		return "arn:aws:s3:::" + strings.TrimPrefix(u, "s3://")
+	}).(pulumi.StringOutput)
+}
+
+// s3BucketArn returns the ARN of the bucket in an s3:// URI.
+func s3BucketArn(uri pulumi.StringOutput) pulumi.StringOutput {
+	return uri.ApplyT(func(u string) string {
+		return "arn:aws:s3:::" + strings.SplitN(strings.TrimPrefix(u, "s3://"), "/", 2)[0]
+	}).(pulumi.StringOutput)
+}
@@ -0,0 +1,240 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/cloudfront"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/s3"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// cachingOptimizedPolicyID is the ID of the AWS managed CachingOptimized cache policy.
+const cachingOptimizedPolicyID = "658327ea-f89d-4fab-a63d-7e88639e58f6"
+
+// FrontendConfig configures S3 + CloudFront hosting for an agent chat UI.
+//
+// The build directory is uploaded as-is, and a runtime configuration file
+// with the API endpoint and auth settings is written next to it so the UI
+// doesn't need rebuilding per environment.
+type FrontendConfig struct {
+	// BuildDir is the local directory containing the built UI.
+	BuildDir string `json:"buildDir" yaml:"buildDir"`
+
+	// IndexDocument is served for the root and, for single-page apps, unknown paths.
+	// Default: "index.html"
+	IndexDocument string `json:"indexDocument,omitempty" yaml:"indexDocument,omitempty"`
+
+	// SinglePageApp serves IndexDocument for 403/404 responses so client-side routes resolve.
+	// Default: true
+	SinglePageApp *bool `json:"singlePageApp,omitempty" yaml:"singlePageApp,omitempty"`
+
+	// ConfigFile is the object key of the generated runtime configuration.
+	// Default: "config.json"
+	ConfigFile string `json:"configFile,omitempty" yaml:"configFile,omitempty"`
+
+	// APIEndpoint is the agent API URL the UI calls.
+	APIEndpoint string `json:"apiEndpoint,omitempty" yaml:"apiEndpoint,omitempty"`
+
+	// Auth contains auth settings for the UI, e.g. user pool and client IDs.
+	Auth map[string]string `json:"auth,omitempty" yaml:"auth,omitempty"`
+
+	// PriceClass is the CloudFront price class.
+	// Supported: "PriceClass_100", "PriceClass_200", "PriceClass_All"
+	// Default: "PriceClass_100"
+	PriceClass string `json:"priceClass,omitempty" yaml:"priceClass,omitempty"`
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *FrontendConfig) ApplyDefaults() {
+	if c.IndexDocument == "" {
+		c.IndexDocument = "index.html"
+	}
+	if c.SinglePageApp == nil {
+		spa := true
+		c.SinglePageApp = &spa
+	}
+	if c.ConfigFile == "" {
+		c.ConfigFile = "config.json"
+	}
+	if c.PriceClass == "" {
+		c.PriceClass = "PriceClass_100"
+	}
+}
+
+// Validate validates the FrontendConfig.
+func (c *FrontendConfig) Validate() error {
+	info, err := os.Stat(c.BuildDir)
+	if err != nil || !info.IsDir() {
+		return fmt.Errorf("frontend.buildDir '%s' must be an existing directory", c.BuildDir)
+	}
+	switch c.PriceClass {
+	case "PriceClass_100", "PriceClass_200", "PriceClass_All":
+	default:
+		return fmt.Errorf("frontend.priceClass must be one of [PriceClass_100 PriceClass_200 PriceClass_All]")
+	}
+	return nil
+}
+
+// createFrontend creates the frontend bucket and distribution and uploads the build directory.
+func (s *AgentCoreStack) createFrontend(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	stackName := s.Config.StackName
+	cfg := s.Options.Frontend
+
+	bucket, err := s.newPrivateBucket(ctx, "frontend-bucket", "frontend", tags)
+	if err != nil {
+		return err
+	}
+
+	oac, err := cloudfront.NewOriginAccessControl(ctx, s.ResourceName("frontend-oac"), &cloudfront.OriginAccessControlArgs{
+		Name:                          pulumi.Sprintf("%s-frontend", stackName),
+		Description:                   pulumi.Sprintf("Origin access for %s frontend", stackName),
+		OriginAccessControlOriginType: pulumi.String("s3"),
+		SigningBehavior:               pulumi.String("always"),
+		SigningProtocol:               pulumi.String("sigv4"),
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	var errorResponses cloudfront.DistributionCustomErrorResponseArray
+	if *cfg.SinglePageApp {
+		for _, code := range []int{403, 404} {
+			errorResponses = append(errorResponses, &cloudfront.DistributionCustomErrorResponseArgs{
+				ErrorCode:        pulumi.Int(code),
+				ResponseCode:     pulumi.Int(200),
+				ResponsePagePath: pulumi.String("/" + cfg.IndexDocument),
+			})
+		}
+	}
+
+	originID := "frontend-s3"
+	s.Frontend, err = cloudfront.NewDistribution(ctx, s.ResourceName("frontend-distribution"), &cloudfront.DistributionArgs{
+		Enabled:           pulumi.Bool(true),
+		Comment:           pulumi.Sprintf("%s chat UI", stackName),
+		DefaultRootObject: pulumi.String(cfg.IndexDocument),
+		PriceClass:        pulumi.String(cfg.PriceClass),
+		IsIpv6Enabled:     pulumi.Bool(true),
+		Origins: cloudfront.DistributionOriginArray{
+			&cloudfront.DistributionOriginArgs{
+				OriginId:              pulumi.String(originID),
+				DomainName:            bucket.BucketRegionalDomainName,
+				OriginAccessControlId: oac.ID(),
+			},
+		},
+		DefaultCacheBehavior: &cloudfront.DistributionDefaultCacheBehaviorArgs{
+			TargetOriginId:       pulumi.String(originID),
+			ViewerProtocolPolicy: pulumi.String("redirect-to-https"),
+			AllowedMethods:       pulumi.ToStringArray([]string{"GET", "HEAD", "OPTIONS"}),
+			CachedMethods:        pulumi.ToStringArray([]string{"GET", "HEAD"}),
+			CachePolicyId:        pulumi.String(cachingOptimizedPolicyID),
+			Compress:             pulumi.Bool(true),
+		},
+		CustomErrorResponses: errorResponses,
+		Restrictions: &cloudfront.DistributionRestrictionsArgs{
+			GeoRestriction: &cloudfront.DistributionRestrictionsGeoRestrictionArgs{
+				RestrictionType: pulumi.String("none"),
+			},
+		},
+		ViewerCertificate: &cloudfront.DistributionViewerCertificateArgs{
+			CloudfrontDefaultCertificate: pulumi.Bool(true),
+		},
+		WebAclId: s.cloudFrontWebACLArn(),
+		Tags:     mergeTags(tags, pulumi.Sprintf("%s-frontend", stackName)),
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	// Only the distribution may read from the bucket
+	policy := pulumi.All(bucket.Arn, s.Frontend.Arn).ApplyT(func(args []interface{}) (string, error) {
+		data, err := json.Marshal(map[string]interface{}{
+			"Version": "2012-10-17",
+			"Statement": []map[string]interface{}{
+				{
+					"Effect":    "Allow",
+					"Principal": map[string]string{"Service": "cloudfront.amazonaws.com"},
+					"Action":    "s3:GetObject",
+					"Resource":  args[0].(string) + "/*",
+					"Condition": map[string]interface{}{
+						"StringEquals": map[string]string{"AWS:SourceArn": args[1].(string)},
+					},
+				},
+			},
+		})
+		return string(data), err
+	}).(pulumi.StringOutput)
+
+	_, err = s3.NewBucketPolicy(ctx, s.ResourceName("frontend-bucket-policy"), &s3.BucketPolicyArgs{
+		Bucket: bucket.ID(),
+		Policy: policy,
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	err = filepath.WalkDir(cfg.BuildDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(cfg.BuildDir, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if key == cfg.ConfigFile {
+			return nil
+		}
+		_, err = s3.NewBucketObjectv2(ctx, s.ResourceName("frontend-"+key), &s3.BucketObjectv2Args{
+			Bucket:       bucket.ID(),
+			Key:          pulumi.String(key),
+			Source:       pulumi.NewFileAsset(path),
+			ContentType:  pulumi.String(contentType(key)),
+			CacheControl: pulumi.String(cacheControl(key, cfg.IndexDocument)),
+		}, s.child())
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", cfg.BuildDir, err)
+	}
+
+	runtimeConfig, err := json.MarshalIndent(map[string]interface{}{
+		"stackName":   stackName,
+		"apiEndpoint": cfg.APIEndpoint,
+		"auth":        cfg.Auth,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = s3.NewBucketObjectv2(ctx, s.ResourceName("frontend-config"), &s3.BucketObjectv2Args{
+		Bucket:       bucket.ID(),
+		Key:          pulumi.String(cfg.ConfigFile),
+		Content:      pulumi.String(string(runtimeConfig)),
+		ContentType:  pulumi.String("application/json"),
+		CacheControl: pulumi.String("no-cache"),
+	}, s.child())
+	return err
+}
+
+// contentType returns the MIME type for an uploaded file.
+func contentType(key string) string {
+	if t := mime.TypeByExtension(filepath.Ext(key)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// cacheControl returns the Cache-Control header for an uploaded file.
+// HTML is revalidated on every request so new deployments take effect.
+func cacheControl(key, indexDocument string) string {
+	if key == indexDocument || strings.HasSuffix(key, ".html") {
+		return "no-cache"
+	}
+	return "public, max-age=31536000, immutable"
+}
@@ -0,0 +1,54 @@
+package agentcore
+
+import (
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/iam"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/lambda"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// nodejsRuntime is the Lambda runtime of the stack's Node.js functions.
+// Their handlers use the AWS SDK the runtime bundles, which must include
+// @aws-sdk/client-bedrock-agentcore-control for the canary controller.
+const nodejsRuntime = "nodejs22.x"
+
+// newInlineFunction creates a Node.js Lambda function from a single ES
+// module source file, with its own role allowed the given statements.
+// Resources are named "{name}-function", "{name}-role" and so on.
+func (s *AgentCoreStack) newInlineFunction(ctx *pulumi.Context, name, source string, timeoutSeconds int, env pulumi.StringMap, tags pulumi.StringMap, statements ...policyStatement) (*lambda.Function, error) {
+	stackName := s.Config.StackName
+
+	role, err := s.newServiceRole(ctx, name+"-role", "lambda.amazonaws.com", tags)
+	if err != nil {
+		return nil, err
+	}
+	_, err = iam.NewRolePolicyAttachment(ctx, s.ResourceName(name+"-basic-execution"), &iam.RolePolicyAttachmentArgs{
+		Role:      role.Name,
+		PolicyArn: pulumi.String("arn:aws:iam::aws:policy/service-role/AWSLambdaBasicExecutionRole"),
+	}, s.child())
+	if err != nil {
+		return nil, err
+	}
+	if s.Options.KMS != nil {
+		statements = append(statements, s.kmsKeyStatement())
+	}
+	if len(statements) > 0 {
+		if err := s.newRolePolicy(ctx, name+"-policy", role.Name, statements...); err != nil {
+			return nil, err
+		}
+	}
+
+	return lambda.NewFunction(ctx, s.ResourceName(name+"-function"), &lambda.FunctionArgs{
+		Name:    pulumi.Sprintf("%s-%s", stackName, name),
+		Role:    role.Arn,
+		Runtime: pulumi.String(nodejsRuntime),
+		Handler: pulumi.String("index.handler"),
+		Timeout: pulumi.Int(timeoutSeconds),
+		Code: pulumi.NewAssetArchive(map[string]interface{}{
+			"index.mjs": pulumi.NewStringAsset(source),
+		}),
+		Environment: &lambda.FunctionEnvironmentArgs{
+			Variables: env,
+		},
+		Tags: mergeTags(tags, pulumi.Sprintf("%s-%s", stackName, name)),
+	}, s.child())
+}
@@ -0,0 +1,471 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// gatewayNamePattern matches gateway and target names: letters, digits and
+// single hyphens.
+var gatewayNamePattern = regexp.MustCompile(`^([0-9a-zA-Z]-?){1,48}$`)
+
+// Gateway target types.
+const (
+	GatewayTargetLambda  = "lambda"
+	GatewayTargetOpenAPI = "openapi"
+	GatewayTargetSmithy  = "smithy"
+)
+
+// GatewayConfig configures an AgentCore Gateway, which exposes Lambda
+// functions and APIs to agents as MCP tools.
+//
+// Create one with GatewayBuilder. The gateway's MCP URL is injected into
+// the selected agents as GATEWAY_{NAME}_URL, and the first gateway of an
+// agent also as GATEWAY_URL.
+type GatewayConfig struct {
+	// Name identifies the gateway within the stack.
+	Name string `json:"name" yaml:"name"`
+
+	// Description describes the gateway.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+
+	// AuthorizerType controls how callers authenticate to the gateway.
+	// With AWS_IAM the selected agents are granted InvokeGateway.
+	// Supported: "AWS_IAM", "CUSTOM_JWT"
+	// Default: "AWS_IAM"
+	AuthorizerType string `json:"authorizerType,omitempty" yaml:"authorizerType,omitempty"`
+
+	// JWT configures the CUSTOM_JWT authorizer.
+	JWT *GatewayJWTConfig `json:"jwt,omitempty" yaml:"jwt,omitempty"`
+
+	// SemanticSearch lets agents search the gateway's tools by meaning
+	// rather than listing them all.
+	// Default: false
+	SemanticSearch bool `json:"semanticSearch,omitempty" yaml:"semanticSearch,omitempty"`
+
+	// Targets are the tool sources behind the gateway.
+	Targets []GatewayTarget `json:"targets" yaml:"targets"`
+
+	// Agents are the agents that use the gateway. If empty, all agents.
+	Agents []string `json:"agents,omitempty" yaml:"agents,omitempty"`
+}
+
+// GatewayJWTConfig configures a JWT authorizer for a gateway.
+type GatewayJWTConfig struct {
+	// DiscoveryURL is the identity provider's OpenID Connect discovery URL.
+	DiscoveryURL string `json:"discoveryURL" yaml:"discoveryURL"`
+
+	// AllowedAudiences are accepted token audiences.
+	AllowedAudiences []string `json:"allowedAudiences,omitempty" yaml:"allowedAudiences,omitempty"`
+
+	// AllowedClients are accepted client IDs.
+	AllowedClients []string `json:"allowedClients,omitempty" yaml:"allowedClients,omitempty"`
+}
+
+// GatewayTarget is a source of tools behind a gateway.
+type GatewayTarget struct {
+	// Name identifies the target; tools are exposed as {Name}___{tool}.
+	Name string `json:"name" yaml:"name"`
+
+	// Description describes the target.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+
+	// Type is the kind of target.
+	// Supported: "lambda", "openapi", "smithy"
+	Type string `json:"type" yaml:"type"`
+
+	// LambdaARN is the function invoked for lambda targets.
+	LambdaARN string `json:"lambdaARN,omitempty" yaml:"lambdaARN,omitempty"`
+
+	// Schema describes the target's tools: a JSON array of tool
+	// definitions for lambda targets, an OpenAPI document for openapi
+	// targets, or a Smithy JSON model for smithy targets. It is either the
+	// document itself or an s3:// URI of it.
+	Schema string `json:"schema" yaml:"schema"`
+
+	// Credential is how the gateway authenticates to the target. Required
+	// for openapi targets; others use the gateway's role.
+	Credential *GatewayCredential `json:"credential,omitempty" yaml:"credential,omitempty"`
+}
+
+// GatewayCredential is an AgentCore Identity credential provider used by a
+// gateway to call a target.
+type GatewayCredential struct {
+	// Type is the kind of credential provider.
+	// Supported: "API_KEY", "OAUTH"
+	Type string `json:"type" yaml:"type"`
+
+	// ProviderARN is the ARN of the credential provider.
+	ProviderARN string `json:"providerARN" yaml:"providerARN"`
+
+	// Scopes are the OAuth scopes requested.
+	Scopes []string `json:"scopes,omitempty" yaml:"scopes,omitempty"`
+
+	// Location is where an API key is sent.
+	// Supported: "HEADER", "QUERY_PARAMETER"
+	// Default: "HEADER"
+	Location string `json:"location,omitempty" yaml:"location,omitempty"`
+
+	// ParameterName is the header or query parameter carrying an API key.
+	// Default: "Authorization"
+	ParameterName string `json:"parameterName,omitempty" yaml:"parameterName,omitempty"`
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *GatewayConfig) ApplyDefaults() {
+	if c.AuthorizerType == "" {
+		c.AuthorizerType = "AWS_IAM"
+	}
+	for i := range c.Targets {
+		if cred := c.Targets[i].Credential; cred != nil && cred.Type == "API_KEY" {
+			if cred.Location == "" {
+				cred.Location = "HEADER"
+			}
+			if cred.ParameterName == "" {
+				cred.ParameterName = "Authorization"
+			}
+		}
+	}
+}
+
+// Validate validates the GatewayConfig.
+func (c *GatewayConfig) Validate(config iac.StackConfig) error {
+	if !gatewayNamePattern.MatchString(c.Name) {
+		return fmt.Errorf("gateways: '%s' is not a valid gateway name", c.Name)
+	}
+	field := fmt.Sprintf("gateways[%s]", c.Name)
+	switch c.AuthorizerType {
+	case "AWS_IAM":
+	case "CUSTOM_JWT":
+		if c.JWT == nil || !strings.HasPrefix(c.JWT.DiscoveryURL, "https://") {
+			return fmt.Errorf("%s.jwt.discoveryURL must be an https URL for CUSTOM_JWT", field)
+		}
+		if len(c.JWT.AllowedAudiences) == 0 && len(c.JWT.AllowedClients) == 0 {
+			return fmt.Errorf("%s.jwt requires allowedAudiences or allowedClients", field)
+		}
+	default:
+		return fmt.Errorf("%s.authorizerType must be one of [AWS_IAM CUSTOM_JWT]", field)
+	}
+	if len(c.Targets) == 0 {
+		return fmt.Errorf("%s.targets must not be empty", field)
+	}
+	seen := make(map[string]bool)
+	for _, t := range c.Targets {
+		if err := t.validate(field); err != nil {
+			return err
+		}
+		if seen[t.Name] {
+			return fmt.Errorf("%s.targets: duplicate target '%s'", field, t.Name)
+		}
+		seen[t.Name] = true
+	}
+	return validateAgentNames(field+".agents", c.Agents, config)
+}
+
+// validate validates a GatewayTarget of the gateway reported under field.
+func (t *GatewayTarget) validate(field string) error {
+	if !gatewayNamePattern.MatchString(t.Name) {
+		return fmt.Errorf("%s.targets: '%s' is not a valid target name", field, t.Name)
+	}
+	field = fmt.Sprintf("%s.targets[%s]", field, t.Name)
+	if !slices.Contains([]string{GatewayTargetLambda, GatewayTargetOpenAPI, GatewayTargetSmithy}, t.Type) {
+		return fmt.Errorf("%s.type must be one of [lambda openapi smithy]", field)
+	}
+	if t.Type == GatewayTargetLambda && !strings.HasPrefix(t.LambdaARN, "arn:") {
+		return fmt.Errorf("%s.lambdaARN is required for lambda targets", field)
+	}
+	if t.Schema == "" {
+		return fmt.Errorf("%s.schema is required", field)
+	}
+	if t.Type == GatewayTargetLambda && !strings.HasPrefix(t.Schema, "s3://") && !json.Valid([]byte(t.Schema)) {
+		return fmt.Errorf("%s.schema must be a JSON array of tool definitions or an s3:// URI", field)
+	}
+	if t.Type == GatewayTargetOpenAPI && t.Credential == nil {
+		return fmt.Errorf("%s.credential is required for openapi targets", field)
+	}
+	if cred := t.Credential; cred != nil {
+		if cred.Type != "API_KEY" && cred.Type != "OAUTH" {
+			return fmt.Errorf("%s.credential.type must be one of [API_KEY OAUTH]", field)
+		}
+		if !strings.HasPrefix(cred.ProviderARN, "arn:") {
+			return fmt.Errorf("%s.credential.providerARN: '%s' is not an ARN", field, cred.ProviderARN)
+		}
+		if cred.Type == "API_KEY" && cred.Location != "HEADER" && cred.Location != "QUERY_PARAMETER" {
+			return fmt.Errorf("%s.credential.location must be one of [HEADER QUERY_PARAMETER]", field)
+		}
+	}
+	return nil
+}
+
+// GatewayBuilder provides a fluent interface for building gateway configurations.
+type GatewayBuilder struct {
+	config GatewayConfig
+}
+
+// NewGatewayBuilder creates a new gateway builder.
+func NewGatewayBuilder(name string) *GatewayBuilder {
+	return &GatewayBuilder{config: GatewayConfig{Name: name}}
+}
+
+// WithDescription sets the gateway description.
+func (b *GatewayBuilder) WithDescription(description string) *GatewayBuilder {
+	b.config.Description = description
+	return b
+}
+
+// WithJWTAuthorizer authenticates callers with tokens from an OpenID
+// Connect provider instead of IAM.
+func (b *GatewayBuilder) WithJWTAuthorizer(discoveryURL string, allowedAudiences ...string) *GatewayBuilder {
+	b.config.AuthorizerType = "CUSTOM_JWT"
+	b.config.JWT = &GatewayJWTConfig{DiscoveryURL: discoveryURL, AllowedAudiences: allowedAudiences}
+	return b
+}
+
+// WithSemanticSearch enables semantic search over the gateway's tools.
+func (b *GatewayBuilder) WithSemanticSearch() *GatewayBuilder {
+	b.config.SemanticSearch = true
+	return b
+}
+
+// WithLambdaTarget exposes a Lambda function's tools, described by a JSON
+// array of tool definitions or an s3:// URI of one.
+func (b *GatewayBuilder) WithLambdaTarget(name, lambdaARN, toolSchema string) *GatewayBuilder {
+	return b.WithTarget(GatewayTarget{Name: name, Type: GatewayTargetLambda, LambdaARN: lambdaARN, Schema: toolSchema})
+}
+
+// WithOpenAPITarget exposes the operations of a REST API described by an
+// OpenAPI document or an s3:// URI of one.
+func (b *GatewayBuilder) WithOpenAPITarget(name, schema string, credential *GatewayCredential) *GatewayBuilder {
+	return b.WithTarget(GatewayTarget{Name: name, Type: GatewayTargetOpenAPI, Schema: schema, Credential: credential})
+}
+
+// WithSmithyTarget exposes the operations of an AWS service described by a
+// Smithy JSON model or an s3:// URI of one.
+func (b *GatewayBuilder) WithSmithyTarget(name, model string) *GatewayBuilder {
+	return b.WithTarget(GatewayTarget{Name: name, Type: GatewayTargetSmithy, Schema: model})
+}
+
+// WithTarget adds a target.
+func (b *GatewayBuilder) WithTarget(target GatewayTarget) *GatewayBuilder {
+	b.config.Targets = append(b.config.Targets, target)
+	return b
+}
+
+// ForAgents limits the gateway to the named agents.
+func (b *GatewayBuilder) ForAgents(names ...string) *GatewayBuilder {
+	b.config.Agents = append(b.config.Agents, names...)
+	return b
+}
+
+// Build returns the gateway configuration.
+func (b *GatewayBuilder) Build() *GatewayConfig {
+	config := b.config
+	return &config
+}
+
+// gatewayEnvName returns the environment variable holding a gateway's URL.
+func gatewayEnvName(gatewayName string) string {
+	return fmt.Sprintf("GATEWAY_%s_URL", strings.ToUpper(strings.ReplaceAll(gatewayName, "-", "_")))
+}
+
+// gatewaySchema returns an S3 or inline schema property.
+func gatewaySchema(schema string, inline pulumi.Input) pulumi.Map {
+	if strings.HasPrefix(schema, "s3://") {
+		return pulumi.Map{"S3": pulumi.Map{"Uri": pulumi.String(schema)}}
+	}
+	return pulumi.Map{"InlinePayload": inline}
+}
+
+// gatewayTargetConfiguration builds the TargetConfiguration of a target.
+func gatewayTargetConfiguration(t GatewayTarget) (pulumi.Map, error) {
+	var mcp pulumi.Map
+	switch t.Type {
+	case GatewayTargetLambda:
+		var tools interface{}
+		if !strings.HasPrefix(t.Schema, "s3://") {
+			if err := json.Unmarshal([]byte(t.Schema), &tools); err != nil {
+				return nil, err
+			}
+		}
+		mcp = pulumi.Map{"Lambda": pulumi.Map{
+			"LambdaArn":  pulumi.String(t.LambdaARN),
+			"ToolSchema": gatewaySchema(t.Schema, pulumi.Any(tools)),
+		}}
+	case GatewayTargetOpenAPI:
+		mcp = pulumi.Map{"OpenApiSchema": gatewaySchema(t.Schema, pulumi.String(t.Schema))}
+	case GatewayTargetSmithy:
+		mcp = pulumi.Map{"SmithyModel": gatewaySchema(t.Schema, pulumi.String(t.Schema))}
+	}
+	return pulumi.Map{"Mcp": mcp}, nil
+}
+
+// gatewayCredentialConfiguration builds the credential provider of a target.
+func gatewayCredentialConfiguration(t GatewayTarget) pulumi.Map {
+	cred := t.Credential
+	switch {
+	case cred == nil:
+		return pulumi.Map{"CredentialProviderType": pulumi.String("GATEWAY_IAM_ROLE")}
+	case cred.Type == "API_KEY":
+		return pulumi.Map{
+			"CredentialProviderType": pulumi.String("API_KEY"),
+			"CredentialProvider": pulumi.Map{"ApiKeyCredentialProvider": pulumi.Map{
+				"ProviderArn":             pulumi.String(cred.ProviderARN),
+				"CredentialLocation":      pulumi.String(cred.Location),
+				"CredentialParameterName": pulumi.String(cred.ParameterName),
+			}},
+		}
+	default:
+		return pulumi.Map{
+			"CredentialProviderType": pulumi.String("OAUTH"),
+			"CredentialProvider": pulumi.Map{"OauthCredentialProvider": pulumi.Map{
+				"ProviderArn": pulumi.String(cred.ProviderARN),
+				"Scopes":      pulumi.ToStringArray(cred.Scopes),
+			}},
+		}
+	}
+}
+
+// createGateways creates the gateways, their roles and targets, and wires
+// their URLs into the selected agents.
+func (s *AgentCoreStack) createGateways(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	stackName := s.Config.StackName
+
+	for _, cfg := range s.Options.Gateways {
+		name := fmt.Sprintf("gateway-%s", cfg.Name)
+
+		// Role the gateway assumes to call its targets
+		role, err := s.newServiceRole(ctx, name, "bedrock-agentcore.amazonaws.com", tags)
+		if err != nil {
+			return err
+		}
+		var lambdaArns, schemaObjects pulumi.StringArray
+		credentials := false
+		for _, t := range cfg.Targets {
+			if t.LambdaARN != "" {
+				lambdaArns = append(lambdaArns, pulumi.String(t.LambdaARN))
+			}
+			if strings.HasPrefix(t.Schema, "s3://") {
+				schemaObjects = append(schemaObjects, pulumi.String("arn:aws:s3:::"+strings.TrimPrefix(t.Schema, "s3://")))
+			}
+			credentials = credentials || t.Credential != nil
+		}
+		var statements []policyStatement
+		if len(lambdaArns) > 0 {
+			statements = append(statements, policyStatement{
+				Actions:   []string{"lambda:InvokeFunction"},
+				Resources: lambdaArns,
+			})
+		}
+		if len(schemaObjects) > 0 {
+			statements = append(statements, policyStatement{
+				Actions:   []string{"s3:GetObject"},
+				Resources: schemaObjects,
+			})
+		}
+		if credentials {
+			statements = append(statements,
+				policyStatement{
+					Actions: []string{
+						"bedrock-agentcore:GetWorkloadAccessToken",
+						"bedrock-agentcore:GetResourceApiKey",
+						"bedrock-agentcore:GetResourceOauth2Token",
+					},
+					Resources: pulumi.StringArray{pulumi.String("*")},
+				},
+				policyStatement{
+					Actions:   []string{"secretsmanager:GetSecretValue"},
+					Resources: pulumi.StringArray{pulumi.String("arn:aws:secretsmanager:*:*:secret:bedrock-agentcore-identity!*")},
+				},
+			)
+		}
+		if len(statements) > 0 {
+			if err := s.newRolePolicy(ctx, name+"-policy", role.Name, statements...); err != nil {
+				return err
+			}
+		}
+
+		mcp := pulumi.Map{}
+		if cfg.SemanticSearch {
+			mcp["SearchType"] = pulumi.String("SEMANTIC")
+		}
+		properties := pulumi.Map{
+			"Name":                  pulumi.String(fmt.Sprintf("%s-%s", strings.ReplaceAll(stackName, "_", "-"), cfg.Name)),
+			"RoleArn":               role.Arn,
+			"ProtocolType":          pulumi.String("MCP"),
+			"ProtocolConfiguration": pulumi.Map{"Mcp": mcp},
+			"AuthorizerType":        pulumi.String(cfg.AuthorizerType),
+			"Tags":                  tags,
+		}
+		if cfg.Description != "" {
+			properties["Description"] = pulumi.String(cfg.Description)
+		}
+		if cfg.JWT != nil && cfg.AuthorizerType == "CUSTOM_JWT" {
+			authorizer := pulumi.Map{"DiscoveryUrl": pulumi.String(cfg.JWT.DiscoveryURL)}
+			if len(cfg.JWT.AllowedAudiences) > 0 {
+				authorizer["AllowedAudience"] = pulumi.ToStringArray(cfg.JWT.AllowedAudiences)
+			}
+			if len(cfg.JWT.AllowedClients) > 0 {
+				authorizer["AllowedClients"] = pulumi.ToStringArray(cfg.JWT.AllowedClients)
+			}
+			properties["AuthorizerConfiguration"] = pulumi.Map{"CustomJWTAuthorizer": authorizer}
+		}
+		if s.Options.KMS != nil {
+			properties["KmsKeyArn"] = s.KMSKeyArn
+		}
+
+		gateway, err := newCloudControlResource(ctx, s.ResourceName(name), "AWS::BedrockAgentCore::Gateway", properties, s.child())
+		if err != nil {
+			return err
+		}
+		gatewayID := cloudControlAttribute(gateway, "GatewayIdentifier")
+		gatewayArn := cloudControlAttribute(gateway, "GatewayArn")
+		gatewayURL := cloudControlAttribute(gateway, "GatewayUrl")
+
+		for _, t := range cfg.Targets {
+			target, err := gatewayTargetConfiguration(t)
+			if err != nil {
+				return fmt.Errorf("gateway %s target %s: %w", cfg.Name, t.Name, err)
+			}
+			targetProperties := pulumi.Map{
+				"GatewayIdentifier":                gatewayID,
+				"Name":                             pulumi.String(t.Name),
+				"TargetConfiguration":              target,
+				"CredentialProviderConfigurations": pulumi.Array{gatewayCredentialConfiguration(t)},
+			}
+			if t.Description != "" {
+				targetProperties["Description"] = pulumi.String(t.Description)
+			}
+			_, err = newCloudControlResource(ctx, s.ResourceName(fmt.Sprintf("%s-%s", name, t.Name)),
+				"AWS::BedrockAgentCore::GatewayTarget", targetProperties, s.child())
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, agentName := range s.selectedAgents(cfg.Agents) {
+			if cfg.AuthorizerType == "AWS_IAM" {
+				err = s.attachAgentPolicy(ctx, agentName, name+"-invoke-policy", policyStatement{
+					Actions:   []string{"bedrock-agentcore:InvokeGateway"},
+					Resources: pulumi.StringArray{gatewayArn},
+				})
+				if err != nil {
+					return err
+				}
+			}
+			if _, ok := s.AgentEnvironment[agentName]["GATEWAY_URL"]; !ok {
+				s.injectEnv(agentName, "GATEWAY_URL", gatewayURL)
+			}
+			s.injectEnv(agentName, gatewayEnvName(cfg.Name), gatewayURL)
+		}
+
+		s.GatewayURLs[cfg.Name] = gatewayURL
+	}
+
+	return nil
+}
@@ -0,0 +1,190 @@
+package agentcore
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/ec2"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/neptune"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// neptunePort is the port Neptune clusters accept connections on.
+const neptunePort = 8182
+
+// GraphStoreConfig configures a Neptune Serverless cluster for agents that
+// maintain knowledge graphs. Agents authenticate with IAM database auth
+// using the execution role.
+type GraphStoreConfig struct {
+	// MinCapacity is the minimum Neptune capacity units (NCUs).
+	// Range: 1-128
+	// Default: 1
+	MinCapacity float64 `json:"minCapacity,omitempty" yaml:"minCapacity,omitempty"`
+
+	// MaxCapacity is the maximum Neptune capacity units (NCUs).
+	// Range: 1-128
+	// Default: 8
+	MaxCapacity float64 `json:"maxCapacity,omitempty" yaml:"maxCapacity,omitempty"`
+
+	// EngineVersion is the Neptune engine version.
+	// Default: "1.3.2.1"
+	EngineVersion string `json:"engineVersion,omitempty" yaml:"engineVersion,omitempty"`
+
+	// BackupRetentionDays is how long automated backups are kept.
+	// Default: 7
+	BackupRetentionDays int `json:"backupRetentionDays,omitempty" yaml:"backupRetentionDays,omitempty"`
+
+	// Agents is the list of agent names that access the graph.
+	// If empty, all agents in the stack are included.
+	Agents []string `json:"agents,omitempty" yaml:"agents,omitempty"`
+}
+
+// DefaultGraphStoreConfig returns a GraphStoreConfig with sensible defaults.
+func DefaultGraphStoreConfig() *GraphStoreConfig {
+	return &GraphStoreConfig{
+		MinCapacity:         1,
+		MaxCapacity:         8,
+		EngineVersion:       "1.3.2.1",
+		BackupRetentionDays: 7,
+	}
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *GraphStoreConfig) ApplyDefaults() {
+	if c.MinCapacity == 0 {
+		c.MinCapacity = 1
+	}
+	if c.MaxCapacity == 0 {
+		c.MaxCapacity = 8
+	}
+	if c.EngineVersion == "" {
+		c.EngineVersion = "1.3.2.1"
+	}
+	if c.BackupRetentionDays == 0 {
+		c.BackupRetentionDays = 7
+	}
+}
+
+// Validate validates the GraphStoreConfig against the stack configuration.
+func (c *GraphStoreConfig) Validate(config iac.StackConfig) error {
+	// Neptune subnet groups must span at least two availability zones
+	createdMultiAZ := config.VPC.CreateVPC && vpcMaxAZs(config.VPC) >= 2
+	existingMultiAZ := config.VPC.VPCID != "" && len(config.VPC.SubnetIDs) >= 2
+	if !createdMultiAZ && !existingMultiAZ {
+		return fmt.Errorf("graphStore requires a VPC with subnets in at least two availability zones (vpc.maxAZs or vpc.subnetIds)")
+	}
+	if c.MinCapacity < 1 || c.MaxCapacity > 128 || c.MinCapacity > c.MaxCapacity {
+		return fmt.Errorf("graphStore capacity must satisfy 1 <= minCapacity <= maxCapacity <= 128")
+	}
+	if c.BackupRetentionDays < 1 || c.BackupRetentionDays > 35 {
+		return fmt.Errorf("graphStore.backupRetentionDays must be between 1 and 35")
+	}
+	return validateAgentNames("graphStore.agents", c.Agents, config)
+}
+
+// createGraphStore creates the Neptune Serverless cluster and grants agents IAM database access.
+func (s *AgentCoreStack) createGraphStore(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	stackName := s.Config.StackName
+	cfg := s.Options.GraphStore
+	retain := s.Config.RemovalPolicy == "retain"
+
+	// Dedicated security group so only agents can reach the cluster
+	graphSG, err := ec2.NewSecurityGroup(ctx, s.ResourceName("graph-sg"), &ec2.SecurityGroupArgs{
+		Name:        pulumi.Sprintf("%s-graph-sg", stackName),
+		Description: pulumi.Sprintf("Security group for %s graph store", stackName),
+		VpcId:       s.vpcID(),
+		Tags:        mergeTags(tags, pulumi.Sprintf("%s-graph-sg", stackName)),
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	_, err = ec2.NewSecurityGroupRule(ctx, s.ResourceName("graph-sg-agent-ingress"), &ec2.SecurityGroupRuleArgs{
+		Type:                  pulumi.String("ingress"),
+		SecurityGroupId:       graphSG.ID(),
+		SourceSecurityGroupId: s.SecurityGroup.ID(),
+		Protocol:              pulumi.String("tcp"),
+		FromPort:              pulumi.Int(neptunePort),
+		ToPort:                pulumi.Int(neptunePort),
+		Description:           pulumi.String("Allow agents to reach the graph store"),
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	subnetGroup, err := neptune.NewSubnetGroup(ctx, s.ResourceName("graph-subnet-group"), &neptune.SubnetGroupArgs{
+		Name:        pulumi.Sprintf("%s-graph", stackName),
+		Description: pulumi.Sprintf("Subnets for %s graph store", stackName),
+		SubnetIds:   s.privateSubnetIDs(),
+		Tags:        mergeTags(tags, pulumi.Sprintf("%s-graph", stackName)),
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	s.GraphStore, err = neptune.NewCluster(ctx, s.ResourceName("graph-cluster"), &neptune.ClusterArgs{
+		ClusterIdentifier:                pulumi.Sprintf("%s-graph", stackName),
+		Engine:                           pulumi.String("neptune"),
+		EngineVersion:                    pulumi.String(cfg.EngineVersion),
+		NeptuneSubnetGroupName:           subnetGroup.Name,
+		VpcSecurityGroupIds:              pulumi.StringArray{graphSG.ID()},
+		IamDatabaseAuthenticationEnabled: pulumi.Bool(true),
+		StorageEncrypted:                 pulumi.Bool(true),
+		BackupRetentionPeriod:            pulumi.Int(cfg.BackupRetentionDays),
+		DeletionProtection:               pulumi.Bool(retain),
+		SkipFinalSnapshot:                pulumi.Bool(!retain),
+		FinalSnapshotIdentifier:          finalSnapshotIdentifier(retain, stackName+"-graph-final"),
+		ServerlessV2ScalingConfiguration: &neptune.ClusterServerlessV2ScalingConfigurationArgs{
+			MinCapacity: pulumi.Float64(cfg.MinCapacity),
+			MaxCapacity: pulumi.Float64(cfg.MaxCapacity),
+		},
+		Tags: mergeTags(tags, pulumi.Sprintf("%s-graph", stackName)),
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	_, err = neptune.NewClusterInstance(ctx, s.ResourceName("graph-instance"), &neptune.ClusterInstanceArgs{
+		Identifier:        pulumi.Sprintf("%s-graph-1", stackName),
+		ClusterIdentifier: s.GraphStore.ID(),
+		Engine:            pulumi.String("neptune"),
+		InstanceClass:     pulumi.String("db.serverless"),
+		Tags:              mergeTags(tags, pulumi.Sprintf("%s-graph-1", stackName)),
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	err = s.attachRolePolicy(ctx, "graph-access-policy", policyStatement{
+		Actions: []string{
+			"neptune-db:connect",
+			"neptune-db:ReadDataViaQuery",
+			"neptune-db:WriteDataViaQuery",
+			"neptune-db:DeleteDataViaQuery",
+		},
+		Resources: pulumi.StringArray{
+			pulumi.Sprintf("arn:aws:neptune-db:*:*:%s/*", s.GraphStore.ClusterResourceId),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, name := range s.selectedAgents(cfg.Agents) {
+		s.injectEnv(name, "GRAPH_ENDPOINT", s.GraphStore.Endpoint)
+		s.injectEnv(name, "GRAPH_READER_ENDPOINT", s.GraphStore.ReaderEndpoint)
+		s.injectEnv(name, "GRAPH_PORT", pulumi.String(strconv.Itoa(neptunePort)))
+		s.injectEnv(name, "GRAPH_IAM_AUTH", pulumi.String("true"))
+	}
+
+	return nil
+}
+
+// finalSnapshotIdentifier returns the snapshot identifier to use on delete, or nil if none is taken.
+func finalSnapshotIdentifier(retain bool, identifier string) pulumi.StringPtrInput {
+	if !retain {
+		return nil
+	}
+	return pulumi.String(identifier)
+}
@@ -0,0 +1,170 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/cloudwatch"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/guardduty"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// GuardDutyConfig enables GuardDuty threat detection for the stack's compute
+// and routes findings for the stack's resources to a notification topic.
+//
+// AgentCore runtimes are managed compute that GuardDuty does not monitor;
+// coverage applies to the stack's Lambda functions and, with
+// RuntimeMonitoring, to EC2, ECS and EKS workloads in the account.
+// Detector features are account-wide settings for the region.
+type GuardDutyConfig struct {
+	// DetectorID is an existing GuardDuty detector. A region allows only one
+	// detector per account, so set this if GuardDuty is already enabled.
+	// If empty, a detector is created.
+	DetectorID string `json:"detectorId,omitempty" yaml:"detectorId,omitempty"`
+
+	// RuntimeMonitoring enables GuardDuty Runtime Monitoring with automated
+	// agent management for EC2, ECS Fargate and EKS.
+	// Default: false
+	RuntimeMonitoring bool `json:"runtimeMonitoring,omitempty" yaml:"runtimeMonitoring,omitempty"`
+
+	// MinSeverity is the lowest finding severity routed to the topic.
+	// Range: 1-10 (low 1-3.9, medium 4-6.9, high 7-8.9, critical 9-10)
+	// Default: 4
+	MinSeverity float64 `json:"minSeverity,omitempty" yaml:"minSeverity,omitempty"`
+
+	// TopicARN is an existing SNS topic for findings. If empty, one is created.
+	TopicARN string `json:"topicARN,omitempty" yaml:"topicARN,omitempty"`
+
+	// Recipients are email addresses subscribed to a created findings topic.
+	Recipients []string `json:"recipients,omitempty" yaml:"recipients,omitempty"`
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *GuardDutyConfig) ApplyDefaults() {
+	if c.MinSeverity == 0 {
+		c.MinSeverity = 4
+	}
+}
+
+// Validate validates the GuardDutyConfig.
+func (c *GuardDutyConfig) Validate() error {
+	if c.MinSeverity < 1 || c.MinSeverity > 10 {
+		return fmt.Errorf("guardDuty.minSeverity must be between 1 and 10")
+	}
+	if c.TopicARN != "" && !strings.HasPrefix(c.TopicARN, "arn:") {
+		return fmt.Errorf("guardDuty.topicARN: '%s' is not an ARN", c.TopicARN)
+	}
+	if c.TopicARN != "" && len(c.Recipients) > 0 {
+		return fmt.Errorf("guardDuty.recipients can only be set when the topic is created")
+	}
+	for _, email := range c.Recipients {
+		if !strings.Contains(email, "@") {
+			return fmt.Errorf("guardDuty.recipients: '%s' is not an email address", email)
+		}
+	}
+	return nil
+}
+
+// guardDutyFindingPattern matches findings at or above minSeverity for
+// resources tagged with the stack name.
+func guardDutyFindingPattern(stackName string, minSeverity float64) (string, error) {
+	stackTag := map[string]interface{}{
+		"tags": map[string]interface{}{
+			"key":   []string{stackTagKey},
+			"value": []string{stackName},
+		},
+	}
+	pattern, err := json.Marshal(map[string]interface{}{
+		"source":      []string{"aws.guardduty"},
+		"detail-type": []string{"GuardDuty Finding"},
+		"detail": map[string]interface{}{
+			"severity": []map[string]interface{}{{"numeric": []interface{}{">=", minSeverity}}},
+			"$or": []map[string]interface{}{
+				{"resource": map[string]interface{}{"lambdaDetails": stackTag}},
+				{"resource": map[string]interface{}{"instanceDetails": stackTag}},
+				{"resource": map[string]interface{}{"ecsClusterDetails": stackTag}},
+				{"resource": map[string]interface{}{"eksClusterDetails": stackTag}},
+			},
+		},
+	})
+	return string(pattern), err
+}
+
+// createGuardDuty enables GuardDuty coverage and routes the stack's findings.
+func (s *AgentCoreStack) createGuardDuty(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	stackName := s.Config.StackName
+	cfg := s.Options.GuardDuty
+
+	detectorID := pulumi.String(cfg.DetectorID).ToStringOutput()
+	if cfg.DetectorID == "" {
+		detector, err := guardduty.NewDetector(ctx, s.ResourceName("guardduty-detector"), &guardduty.DetectorArgs{
+			Enable: pulumi.Bool(true),
+			Tags:   mergeTags(tags, pulumi.Sprintf("%s-guardduty", stackName)),
+		}, s.child())
+		if err != nil {
+			return err
+		}
+		detectorID = detector.ID().ToStringOutput()
+	}
+
+	_, err := guardduty.NewDetectorFeature(ctx, s.ResourceName("guardduty-lambda-network-logs"), &guardduty.DetectorFeatureArgs{
+		DetectorId: detectorID,
+		Name:       pulumi.String("LAMBDA_NETWORK_LOGS"),
+		Status:     pulumi.String("ENABLED"),
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	if cfg.RuntimeMonitoring {
+		var agents guardduty.DetectorFeatureAdditionalConfigurationArray
+		for _, name := range []string{"EKS_ADDON_MANAGEMENT", "ECS_FARGATE_AGENT_MANAGEMENT", "EC2_AGENT_MANAGEMENT"} {
+			agents = append(agents, &guardduty.DetectorFeatureAdditionalConfigurationArgs{
+				Name:   pulumi.String(name),
+				Status: pulumi.String("ENABLED"),
+			})
+		}
+		_, err = guardduty.NewDetectorFeature(ctx, s.ResourceName("guardduty-runtime-monitoring"), &guardduty.DetectorFeatureArgs{
+			DetectorId:               detectorID,
+			Name:                     pulumi.String("RUNTIME_MONITORING"),
+			Status:                   pulumi.String("ENABLED"),
+			AdditionalConfigurations: agents,
+		}, s.child())
+		if err != nil {
+			return err
+		}
+	}
+
+	topic := pulumi.String(cfg.TopicARN).ToStringOutput()
+	if cfg.TopicARN == "" {
+		created, err := s.newEventTopic(ctx, "guardduty-findings", fmt.Sprintf("%s-guardduty-findings", stackName), tags)
+		if err != nil {
+			return err
+		}
+		if err := s.subscribeEmails(ctx, "guardduty-recipient", created.Arn, cfg.Recipients); err != nil {
+			return err
+		}
+		topic = created.Arn
+	}
+	s.GuardDutyTopic = topic
+
+	pattern, err := guardDutyFindingPattern(stackName, cfg.MinSeverity)
+	if err != nil {
+		return err
+	}
+	rule, err := cloudwatch.NewEventRule(ctx, s.ResourceName("guardduty-findings-rule"), &cloudwatch.EventRuleArgs{
+		Name:         pulumi.Sprintf("%s-guardduty-findings", stackName),
+		Description:  pulumi.Sprintf("GuardDuty findings for %s resources", stackName),
+		EventPattern: pulumi.String(pattern),
+		Tags:         mergeTags(tags, pulumi.Sprintf("%s-guardduty-findings", stackName)),
+	}, s.child())
+	if err != nil {
+		return err
+	}
+	_, err = cloudwatch.NewEventTarget(ctx, s.ResourceName("guardduty-findings-target"), &cloudwatch.EventTargetArgs{
+		Rule: rule.Name,
+		Arn:  topic,
+	}, s.child())
+	return err
+}
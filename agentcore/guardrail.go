@@ -0,0 +1,271 @@
+package agentcore
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/bedrock"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/cloudcontrol"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+var (
+	// guardrailTopicNamePattern matches the denied topic names Bedrock accepts.
+	guardrailTopicNamePattern = regexp.MustCompile(`^[0-9a-zA-Z-_ !?.]{1,100}$`)
+
+	// guardrailPIIEntityPattern matches Bedrock PII entity types, e.g.
+	// "EMAIL" or "US_SOCIAL_SECURITY_NUMBER".
+	guardrailPIIEntityPattern = regexp.MustCompile(`^[A-Z][A-Z_]*$`)
+)
+
+// GuardrailConfig configures the Bedrock guardrail agents apply.
+//
+// With ARN it references a centrally managed guardrail, such as one shared
+// across an organization from a security account. Preflight checks that
+// the guardrail is in the stack's region and can be read by the deploying
+// account. Without ARN, a guardrail is created from the denied topics, PII
+// filters and word filters, and a version of it is published.
+//
+// Agents get GUARDRAIL_ID and GUARDRAIL_VERSION and may apply the
+// guardrail. Prompt monitoring evaluates with a shared guardrail unless it
+// sets its own.
+type GuardrailConfig struct {
+	// ARN is a shared guardrail. If empty, a guardrail is created.
+	ARN string `json:"arn,omitempty" yaml:"arn,omitempty"`
+
+	// Version is the version of the shared guardrail agents apply.
+	// Created guardrails use their published version.
+	// Default: "DRAFT"
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+
+	// DeniedTopics are subjects the created guardrail blocks.
+	DeniedTopics []GuardrailTopic `json:"deniedTopics,omitempty" yaml:"deniedTopics,omitempty"`
+
+	// PIIEntities are the PII types the created guardrail filters, e.g.
+	// "EMAIL", "PHONE", "US_SOCIAL_SECURITY_NUMBER".
+	PIIEntities []string `json:"piiEntities,omitempty" yaml:"piiEntities,omitempty"`
+
+	// PIIAction is what the created guardrail does with PII.
+	// Supported: "BLOCK", "ANONYMIZE"
+	// Default: "ANONYMIZE"
+	PIIAction string `json:"piiAction,omitempty" yaml:"piiAction,omitempty"`
+
+	// BlockedWords are words and phrases the created guardrail blocks.
+	BlockedWords []string `json:"blockedWords,omitempty" yaml:"blockedWords,omitempty"`
+
+	// ProfanityFilter blocks the AWS managed profanity word list.
+	// Default: false
+	ProfanityFilter bool `json:"profanityFilter,omitempty" yaml:"profanityFilter,omitempty"`
+
+	// BlockedMessage replaces blocked prompts and responses.
+	// Default: "Sorry, I can't help with that."
+	BlockedMessage string `json:"blockedMessage,omitempty" yaml:"blockedMessage,omitempty"`
+
+	// Agents is the list of agent names that apply the guardrail.
+	// If empty, all agents in the stack are included.
+	Agents []string `json:"agents,omitempty" yaml:"agents,omitempty"`
+}
+
+// GuardrailTopic is a subject a guardrail denies.
+type GuardrailTopic struct {
+	// Name identifies the topic.
+	Name string `json:"name" yaml:"name"`
+
+	// Definition describes the topic in up to 200 characters.
+	Definition string `json:"definition" yaml:"definition"`
+
+	// Examples are up to 5 prompts that belong to the topic.
+	Examples []string `json:"examples,omitempty" yaml:"examples,omitempty"`
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *GuardrailConfig) ApplyDefaults() {
+	if c.ARN != "" && c.Version == "" {
+		c.Version = "DRAFT"
+	}
+	if c.ARN == "" {
+		if len(c.PIIEntities) > 0 && c.PIIAction == "" {
+			c.PIIAction = "ANONYMIZE"
+		}
+		if c.BlockedMessage == "" {
+			c.BlockedMessage = "Sorry, I can't help with that."
+		}
+	}
+}
+
+// Validate validates the GuardrailConfig.
+func (c *GuardrailConfig) Validate(config iac.StackConfig) error {
+	if c.ARN != "" {
+		if !strings.HasPrefix(c.ARN, "arn:") || !strings.Contains(c.ARN, ":guardrail/") {
+			return fmt.Errorf("guardrail.arn: '%s' is not a guardrail ARN", c.ARN)
+		}
+		if c.hasPolicies() {
+			return fmt.Errorf("guardrail: deniedTopics, piiEntities, blockedWords and profanityFilter only apply to created guardrails; leave arn empty")
+		}
+		return validateAgentNames("guardrail.agents", c.Agents, config)
+	}
+
+	if c.Version != "" {
+		return fmt.Errorf("guardrail.version only applies with guardrail.arn")
+	}
+	if !c.hasPolicies() {
+		return fmt.Errorf("guardrail requires an arn or at least one of deniedTopics, piiEntities, blockedWords or profanityFilter")
+	}
+	for _, topic := range c.DeniedTopics {
+		if !guardrailTopicNamePattern.MatchString(topic.Name) {
+			return fmt.Errorf("guardrail.deniedTopics: name %q must match %s", topic.Name, guardrailTopicNamePattern)
+		}
+		if topic.Definition == "" || len(topic.Definition) > 200 {
+			return fmt.Errorf("guardrail.deniedTopics[%s].definition must be 1-200 characters", topic.Name)
+		}
+		if len(topic.Examples) > 5 {
+			return fmt.Errorf("guardrail.deniedTopics[%s].examples must have at most 5 entries", topic.Name)
+		}
+	}
+	for _, entity := range c.PIIEntities {
+		if !guardrailPIIEntityPattern.MatchString(entity) {
+			return fmt.Errorf("guardrail.piiEntities: '%s' is not a PII entity type", entity)
+		}
+	}
+	if len(c.PIIEntities) > 0 && c.PIIAction != "BLOCK" && c.PIIAction != "ANONYMIZE" {
+		return fmt.Errorf("guardrail.piiAction must be one of [BLOCK ANONYMIZE]")
+	}
+	for _, word := range c.BlockedWords {
+		if word == "" || len(word) > 100 {
+			return fmt.Errorf("guardrail.blockedWords: '%s' must be 1-100 characters", word)
+		}
+	}
+	if len(c.BlockedMessage) > 500 {
+		return fmt.Errorf("guardrail.blockedMessage must be at most 500 characters")
+	}
+	return validateAgentNames("guardrail.agents", c.Agents, config)
+}
+
+// hasPolicies reports whether any policy of a created guardrail is set.
+func (c *GuardrailConfig) hasPolicies() bool {
+	return len(c.DeniedTopics) > 0 || len(c.PIIEntities) > 0 || len(c.BlockedWords) > 0 || c.ProfanityFilter
+}
+
+// guardrailRegion returns the region of the guardrail ARN.
+func (c *GuardrailConfig) guardrailRegion() string {
+	parts := strings.SplitN(c.ARN, ":", 6)
+	if len(parts) < 6 {
+		return ""
+	}
+	return parts[3]
+}
+
+// checkGuardrail checks that the shared guardrail is in the deployment
+// region and that the deploying account can read it.
+func checkGuardrail(ctx *pulumi.Context, cfg *GuardrailConfig, region string, opts ...pulumi.InvokeOption) RegionCheck {
+	check := RegionCheck{Service: "bedrock guardrail " + cfg.ARN, RequiredBy: "guardrail"}
+	if r := cfg.guardrailRegion(); r != region {
+		check.Detail = fmt.Sprintf("guardrail is in %s; guardrails must be in the agents' region", r)
+		return check
+	}
+	_, err := cloudcontrol.LookupResource(ctx, &cloudcontrol.LookupResourceArgs{
+		TypeName:   "AWS::Bedrock::Guardrail",
+		Identifier: cfg.ARN,
+	}, opts...)
+	if err != nil {
+		check.Detail = fmt.Sprintf("guardrail is not shared with this account: %v", err)
+		return check
+	}
+	check.Available = true
+	return check
+}
+
+// createGuardrail creates the guardrail unless a shared one is configured,
+// and wires it into the agents.
+func (s *AgentCoreStack) createGuardrail(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	cfg := s.Options.Guardrail
+	s.GuardrailArn = pulumi.String(cfg.ARN).ToStringOutput()
+	s.GuardrailVersion = pulumi.String(cfg.Version).ToStringOutput()
+	if cfg.ARN == "" {
+		if err := s.newGuardrail(ctx, tags); err != nil {
+			return err
+		}
+	}
+
+	for _, agentName := range s.selectedAgents(cfg.Agents) {
+		s.injectEnv(agentName, "GUARDRAIL_ID", s.GuardrailArn)
+		s.injectEnv(agentName, "GUARDRAIL_VERSION", s.GuardrailVersion)
+	}
+	return s.attachRolePolicy(ctx, "guardrail-policy", policyStatement{
+		Actions: []string{
+			"bedrock:ApplyGuardrail",
+			"bedrock:GetGuardrail",
+		},
+		Resources: pulumi.StringArray{s.GuardrailArn},
+	})
+}
+
+// newGuardrail creates the stack's guardrail and publishes a version of it.
+func (s *AgentCoreStack) newGuardrail(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	stackName := s.Config.StackName
+	cfg := s.Options.Guardrail
+
+	args := &bedrock.GuardrailArgs{
+		Name:                    pulumi.Sprintf("%s-guardrail", stackName),
+		Description:             pulumi.Sprintf("Guardrail applied by %s agents", stackName),
+		BlockedInputMessaging:   pulumi.String(cfg.BlockedMessage),
+		BlockedOutputsMessaging: pulumi.String(cfg.BlockedMessage),
+		Tags:                    mergeTags(tags, pulumi.Sprintf("%s-guardrail", stackName)),
+	}
+	if len(cfg.DeniedTopics) > 0 {
+		topics := bedrock.GuardrailTopicPolicyConfigTopicsConfigArray{}
+		for _, topic := range cfg.DeniedTopics {
+			topics = append(topics, &bedrock.GuardrailTopicPolicyConfigTopicsConfigArgs{
+				Name:       pulumi.String(topic.Name),
+				Definition: pulumi.String(topic.Definition),
+				Examples:   pulumi.ToStringArray(topic.Examples),
+				Type:       pulumi.String("DENY"),
+			})
+		}
+		args.TopicPolicyConfig = &bedrock.GuardrailTopicPolicyConfigArgs{TopicsConfigs: topics}
+	}
+	if len(cfg.PIIEntities) > 0 {
+		entities := bedrock.GuardrailSensitiveInformationPolicyConfigPiiEntitiesConfigArray{}
+		for _, entity := range slices.Compact(slices.Sorted(slices.Values(cfg.PIIEntities))) {
+			entities = append(entities, &bedrock.GuardrailSensitiveInformationPolicyConfigPiiEntitiesConfigArgs{
+				Type:   pulumi.String(entity),
+				Action: pulumi.String(cfg.PIIAction),
+			})
+		}
+		args.SensitiveInformationPolicyConfig = &bedrock.GuardrailSensitiveInformationPolicyConfigArgs{PiiEntitiesConfigs: entities}
+	}
+	if len(cfg.BlockedWords) > 0 || cfg.ProfanityFilter {
+		words := &bedrock.GuardrailWordPolicyConfigArgs{}
+		if len(cfg.BlockedWords) > 0 {
+			configs := bedrock.GuardrailWordPolicyConfigWordsConfigArray{}
+			for _, word := range cfg.BlockedWords {
+				configs = append(configs, &bedrock.GuardrailWordPolicyConfigWordsConfigArgs{Text: pulumi.String(word)})
+			}
+			words.WordsConfigs = configs
+		}
+		if cfg.ProfanityFilter {
+			words.ManagedWordListsConfigs = bedrock.GuardrailWordPolicyConfigManagedWordListsConfigArray{
+				&bedrock.GuardrailWordPolicyConfigManagedWordListsConfigArgs{Type: pulumi.String("PROFANITY")},
+			}
+		}
+		args.WordPolicyConfig = words
+	}
+
+	guardrail, err := bedrock.NewGuardrail(ctx, s.ResourceName("guardrail"), args, s.child())
+	if err != nil {
+		return err
+	}
+	version, err := bedrock.NewGuardrailVersion(ctx, s.ResourceName("guardrail-version"), &bedrock.GuardrailVersionArgs{
+		GuardrailArn: guardrail.GuardrailArn,
+		Description:  pulumi.Sprintf("Deployed by %s", stackName),
+	}, s.child())
+	if err != nil {
+		return err
+	}
+	s.GuardrailArn = guardrail.GuardrailArn
+	s.GuardrailVersion = version.Version
+	return nil
+}
@@ -0,0 +1,239 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/cloudwatch"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/lambda"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// healthHandler reports the composite alarm state, with the state of each
+// agent's health alarm, as 200 when healthy and 503 otherwise.
+const healthHandler = `import { CloudWatchClient, DescribeAlarmsCommand } from "@aws-sdk/client-cloudwatch";
+
+const cloudwatch = new CloudWatchClient({});
+const agentAlarms = JSON.parse(process.env.AGENT_ALARMS);
+
+export const handler = async () => {
+  const { CompositeAlarms = [], MetricAlarms = [] } = await cloudwatch.send(new DescribeAlarmsCommand({
+    AlarmNames: [process.env.HEALTH_ALARM_NAME, ...Object.values(agentAlarms)],
+    AlarmTypes: ["CompositeAlarm", "MetricAlarm"],
+  }));
+
+  const states = Object.fromEntries(MetricAlarms.map((a) => [a.AlarmName, a.StateValue]));
+  const agents = Object.fromEntries(
+    Object.entries(agentAlarms).map(([agent, alarm]) => [agent, states[alarm] === "ALARM" ? "unhealthy" : "healthy"]),
+  );
+  const healthy = CompositeAlarms.every((a) => a.StateValue !== "ALARM");
+
+  return {
+    statusCode: healthy ? 200 : 503,
+    headers: { "Content-Type": "application/json", "Cache-Control": "no-store" },
+    body: JSON.stringify({ healthy, agents }),
+  };
+};
+`
+
+// HealthConfig configures per-agent health alarms, a composite stack health
+// alarm and an optional public health endpoint for uptime monitors.
+//
+// Agents are considered unhealthy when they log more than ErrorThreshold
+// structured error events ({"level": "ERROR", "agent": ...}) in a period.
+// Field names follow Options.Logging.
+type HealthConfig struct {
+	// ErrorThreshold is the number of errors per period that marks an agent unhealthy.
+	// Default: 5
+	ErrorThreshold int `json:"errorThreshold,omitempty" yaml:"errorThreshold,omitempty"`
+
+	// PeriodSeconds is the alarm evaluation period. Must be a multiple of 60.
+	// Default: 300
+	PeriodSeconds int `json:"periodSeconds,omitempty" yaml:"periodSeconds,omitempty"`
+
+	// EvaluationPeriods is the number of consecutive breaching periods
+	// before an agent is unhealthy.
+	// Default: 1
+	EvaluationPeriods int `json:"evaluationPeriods,omitempty" yaml:"evaluationPeriods,omitempty"`
+
+	// AlarmActions are ARNs notified when the stack becomes unhealthy.
+	AlarmActions []string `json:"alarmActions,omitempty" yaml:"alarmActions,omitempty"`
+
+	// Endpoint creates a public /health Lambda function URL returning the
+	// stack and per-agent health, with 503 when the stack is unhealthy.
+	// Default: false
+	Endpoint bool `json:"endpoint,omitempty" yaml:"endpoint,omitempty"`
+}
+
+// DefaultHealthConfig returns a HealthConfig with sensible defaults.
+func DefaultHealthConfig() *HealthConfig {
+	return &HealthConfig{
+		ErrorThreshold:    5,
+		PeriodSeconds:     300,
+		EvaluationPeriods: 1,
+	}
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *HealthConfig) ApplyDefaults() {
+	if c.ErrorThreshold == 0 {
+		c.ErrorThreshold = 5
+	}
+	if c.PeriodSeconds == 0 {
+		c.PeriodSeconds = 300
+	}
+	if c.EvaluationPeriods == 0 {
+		c.EvaluationPeriods = 1
+	}
+}
+
+// Validate validates the HealthConfig.
+func (c *HealthConfig) Validate() error {
+	if c.ErrorThreshold < 1 {
+		return fmt.Errorf("health.errorThreshold must be at least 1")
+	}
+	if c.PeriodSeconds < 60 || c.PeriodSeconds%60 != 0 {
+		return fmt.Errorf("health.periodSeconds must be a positive multiple of 60")
+	}
+	if c.EvaluationPeriods < 1 {
+		return fmt.Errorf("health.evaluationPeriods must be at least 1")
+	}
+	return nil
+}
+
+// agentHealthAlarmName returns the name of an agent's health alarm.
+func agentHealthAlarmName(stackName, agentName string) string {
+	return fmt.Sprintf("%s-%s-health", stackName, agentName)
+}
+
+// createHealth creates per-agent error alarms, the composite stack health
+// alarm and, if enabled, the health endpoint.
+func (s *AgentCoreStack) createHealth(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	var err error
+	stackName := s.Config.StackName
+	cfg := s.Options.Health
+	fields := s.logging().Fields
+
+	// Agents log errors to the stack log group or their own log group
+	var filterGroups []pulumi.StringInput
+	if s.LogGroup != nil {
+		filterGroups = append(filterGroups, s.LogGroup.Name)
+	}
+	for _, agent := range s.Config.Agents {
+		if group, ok := s.AgentLogGroups[agent.Name]; ok {
+			filterGroups = append(filterGroups, group)
+		}
+	}
+
+	var filters []pulumi.Resource
+	for i, group := range filterGroups {
+		filter, err := cloudwatch.NewLogMetricFilter(ctx, s.ResourceName(fmt.Sprintf("agent-errors-filter-%d", i)), &cloudwatch.LogMetricFilterArgs{
+			Name:         pulumi.Sprintf("%s-agent-errors", stackName),
+			LogGroupName: group,
+			Pattern:      pulumi.Sprintf(`{ $.%s = "ERROR" }`, fields.Level),
+			MetricTransformation: &cloudwatch.LogMetricFilterMetricTransformationArgs{
+				Name:      pulumi.String("AgentErrors"),
+				Namespace: pulumi.String(s.metricNamespace()),
+				Value:     pulumi.String("1"),
+				Dimensions: pulumi.StringMap{
+					"Agent": pulumi.String("$." + fields.Agent),
+				},
+			},
+		}, s.child())
+		if err != nil {
+			return err
+		}
+		filters = append(filters, filter)
+	}
+
+	agentAlarms := make(map[string]string)
+	var alarms []pulumi.Resource
+	var rules []string
+	for _, agent := range s.Config.Agents {
+		alarmName := agentHealthAlarmName(stackName, agent.Name)
+		alarm, err := cloudwatch.NewMetricAlarm(ctx, s.ResourceName(fmt.Sprintf("%s-health-alarm", agent.Name)), &cloudwatch.MetricAlarmArgs{
+			Name:               pulumi.String(alarmName),
+			AlarmDescription:   pulumi.Sprintf("Agent %s is logging errors", agent.Name),
+			Namespace:          pulumi.String(s.metricNamespace()),
+			MetricName:         pulumi.String("AgentErrors"),
+			Dimensions:         pulumi.StringMap{"Agent": pulumi.String(agent.Name)},
+			Statistic:          pulumi.String("Sum"),
+			Period:             pulumi.Int(cfg.PeriodSeconds),
+			EvaluationPeriods:  pulumi.Int(cfg.EvaluationPeriods),
+			Threshold:          pulumi.Float64(float64(cfg.ErrorThreshold)),
+			ComparisonOperator: pulumi.String("GreaterThanOrEqualToThreshold"),
+			TreatMissingData:   pulumi.String("notBreaching"),
+			Tags:               mergeTags(tags, pulumi.String(alarmName)),
+		}, pulumi.DependsOn(filters), s.child())
+		if err != nil {
+			return err
+		}
+		agentAlarms[agent.Name] = alarmName
+		alarms = append(alarms, alarm)
+		rules = append(rules, fmt.Sprintf("ALARM(%q)", alarmName))
+	}
+
+	s.HealthAlarm, err = cloudwatch.NewCompositeAlarm(ctx, s.ResourceName("health-alarm"), &cloudwatch.CompositeAlarmArgs{
+		AlarmName:        pulumi.Sprintf("%s-health", stackName),
+		AlarmDescription: pulumi.Sprintf("One or more %s agents are unhealthy", stackName),
+		AlarmRule:        pulumi.String(strings.Join(rules, " OR ")),
+		AlarmActions:     pulumi.ToStringArray(cfg.AlarmActions),
+		OkActions:        pulumi.ToStringArray(cfg.AlarmActions),
+		Tags:             mergeTags(tags, pulumi.Sprintf("%s-health", stackName)),
+	}, pulumi.DependsOn(alarms), s.child())
+	if err != nil {
+		return err
+	}
+
+	if cfg.Endpoint {
+		return s.createHealthEndpoint(ctx, agentAlarms, tags)
+	}
+	return nil
+}
+
+// createHealthEndpoint creates the health check function and its public URL.
+func (s *AgentCoreStack) createHealthEndpoint(ctx *pulumi.Context, agentAlarms map[string]string, tags pulumi.StringMap) error {
+	stackName := s.Config.StackName
+
+	alarmsJSON, err := json.Marshal(agentAlarms)
+	if err != nil {
+		return err
+	}
+
+	function, err := s.newInlineFunction(ctx, "health", healthHandler, 10,
+		pulumi.StringMap{
+			"HEALTH_ALARM_NAME": s.HealthAlarm.AlarmName,
+			"AGENT_ALARMS":      pulumi.String(string(alarmsJSON)),
+		},
+		tags,
+		policyStatement{
+			Actions:   []string{"cloudwatch:DescribeAlarms"},
+			Resources: pulumi.StringArray{pulumi.Sprintf("arn:aws:cloudwatch:*:*:alarm:%s-*", stackName)},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	// Uptime monitors call the endpoint unauthenticated; it exposes only alarm states
+	url, err := lambda.NewFunctionUrl(ctx, s.ResourceName("health-url"), &lambda.FunctionUrlArgs{
+		FunctionName:      function.Name,
+		AuthorizationType: pulumi.String("NONE"),
+	}, s.child())
+	if err != nil {
+		return err
+	}
+	_, err = lambda.NewPermission(ctx, s.ResourceName("health-url-permission"), &lambda.PermissionArgs{
+		Function:            function.Name,
+		Action:              pulumi.String("lambda:InvokeFunctionUrl"),
+		Principal:           pulumi.String("*"),
+		FunctionUrlAuthType: pulumi.String("NONE"),
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	s.HealthURL = url.FunctionUrl
+	return nil
+}
@@ -0,0 +1,370 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/lambda"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/secretsmanager"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// credentialProviderNamePattern matches AgentCore Identity credential provider names.
+var credentialProviderNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,128}$`)
+
+// oauth2Vendors maps the supported OAuth2 vendors to the field of their
+// provider configuration in the AgentCore Identity API.
+var oauth2Vendors = map[string]string{
+	"GoogleOauth2":     "googleOauth2ProviderConfig",
+	"GithubOauth2":     "githubOauth2ProviderConfig",
+	"SlackOauth2":      "slackOauth2ProviderConfig",
+	"SalesforceOauth2": "salesforceOauth2ProviderConfig",
+	"MicrosoftOauth2":  "microsoftOauth2ProviderConfig",
+	"CustomOauth2":     "customOauth2ProviderConfig",
+}
+
+// credentialProviderHandler creates, updates and deletes an OAuth2
+// credential provider. It runs as a CRUD Lambda invocation, reading the
+// client secret from Secrets Manager so it never appears in the input.
+const credentialProviderHandler = `import {
+  BedrockAgentCoreControlClient,
+  CreateOauth2CredentialProviderCommand,
+  UpdateOauth2CredentialProviderCommand,
+  DeleteOauth2CredentialProviderCommand,
+} from "@aws-sdk/client-bedrock-agentcore-control";
+import { SecretsManagerClient, GetSecretValueCommand } from "@aws-sdk/client-secrets-manager";
+
+const control = new BedrockAgentCoreControlClient({});
+const secrets = new SecretsManagerClient({});
+
+const providerInput = async ({ name, vendor, configField, clientId, clientSecretArn, discoveryUrl }) => {
+  const secret = await secrets.send(new GetSecretValueCommand({ SecretId: clientSecretArn }));
+  const config = { clientId, clientSecret: secret.SecretString };
+  if (discoveryUrl) config.oauthDiscovery = { discoveryUrl };
+  return {
+    name,
+    credentialProviderVendor: vendor,
+    oauth2ProviderConfigInput: { [configField]: config },
+  };
+};
+
+const remove = async (name) => {
+  try {
+    await control.send(new DeleteOauth2CredentialProviderCommand({ name }));
+  } catch (err) {
+    if (err.name !== "ResourceNotFoundException") throw err;
+  }
+};
+
+export const handler = async (event) => {
+  const { action, prev_input: previous } = event.tf ?? { action: "create" };
+  if (action === "delete") {
+    await remove(event.name);
+    return {};
+  }
+
+  const input = await providerInput(event);
+  let result;
+  if (action === "update" && previous?.name === event.name) {
+    result = await control.send(new UpdateOauth2CredentialProviderCommand(input));
+  } else {
+    if (action === "update") await remove(previous.name);
+    result = await control.send(new CreateOauth2CredentialProviderCommand(input));
+  }
+  return {
+    credentialProviderArn: result.credentialProviderArn,
+    secretArn: result.clientSecretArn?.secretArn,
+  };
+};
+`
+
+// IdentityConfig configures AgentCore Identity for agents' outbound
+// calls to tools that require OAuth2 tokens.
+//
+// Each selected agent gets a workload identity, injected as
+// WORKLOAD_IDENTITY_NAME, and the names of the providers it may use as
+// OAUTH2_PROVIDERS. Client secrets are kept in Secrets Manager and are only
+// readable by the function that registers the providers. Each agent's role
+// may only obtain tokens for its own workload identity and providers.
+type IdentityConfig struct {
+	// OAuth2Providers are the OAuth2 credential providers agents obtain
+	// tokens from.
+	OAuth2Providers []OAuth2ProviderConfig `json:"oauth2Providers,omitempty" yaml:"oauth2Providers,omitempty"`
+
+	// AllowedReturnURLs are the URLs users may be returned to after
+	// authorizing an agent in user-delegated flows.
+	AllowedReturnURLs []string `json:"allowedReturnURLs,omitempty" yaml:"allowedReturnURLs,omitempty"`
+
+	// Agents are the agents given a workload identity. If empty, all agents.
+	Agents []string `json:"agents,omitempty" yaml:"agents,omitempty"`
+}
+
+// OAuth2ProviderConfig configures an OAuth2 credential provider.
+type OAuth2ProviderConfig struct {
+	// Name is the provider name agents request tokens with.
+	Name string `json:"name" yaml:"name"`
+
+	// Vendor is the identity provider.
+	// Supported: "GoogleOauth2", "GithubOauth2", "SlackOauth2",
+	// "SalesforceOauth2", "MicrosoftOauth2", "CustomOauth2"
+	Vendor string `json:"vendor" yaml:"vendor"`
+
+	// ClientID is the OAuth2 client ID.
+	ClientID string `json:"clientId" yaml:"clientId"`
+
+	// ClientSecret is the OAuth2 client secret, stored in a secret the
+	// stack creates. Set either ClientSecret or ClientSecretARN.
+	ClientSecret string `json:"clientSecret,omitempty" yaml:"clientSecret,omitempty"`
+
+	// ClientSecretARN is an existing secret holding the client secret as a
+	// plain string.
+	ClientSecretARN string `json:"clientSecretARN,omitempty" yaml:"clientSecretARN,omitempty"`
+
+	// DiscoveryURL is the OpenID Connect discovery URL. Required for
+	// CustomOauth2.
+	DiscoveryURL string `json:"discoveryURL,omitempty" yaml:"discoveryURL,omitempty"`
+
+	// Agents are the agents that use the provider. If empty, all agents
+	// with a workload identity.
+	Agents []string `json:"agents,omitempty" yaml:"agents,omitempty"`
+}
+
+// Validate validates the IdentityConfig.
+func (c *IdentityConfig) Validate(config iac.StackConfig) error {
+	if err := validateAgentNames("identity.agents", c.Agents, config); err != nil {
+		return err
+	}
+	for _, returnURL := range c.AllowedReturnURLs {
+		if !strings.HasPrefix(returnURL, "https://") && !strings.HasPrefix(returnURL, "http://localhost") {
+			return fmt.Errorf("identity.allowedReturnURLs: '%s' must be an https URL", returnURL)
+		}
+	}
+	seen := make(map[string]bool)
+	for _, p := range c.OAuth2Providers {
+		if !credentialProviderNamePattern.MatchString(p.Name) {
+			return fmt.Errorf("identity.oauth2Providers: '%s' is not a valid provider name", p.Name)
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("identity.oauth2Providers: duplicate provider '%s'", p.Name)
+		}
+		seen[p.Name] = true
+
+		field := fmt.Sprintf("identity.oauth2Providers[%s]", p.Name)
+		if _, ok := oauth2Vendors[p.Vendor]; !ok {
+			return fmt.Errorf("%s.vendor: '%s' is not a supported vendor", field, p.Vendor)
+		}
+		if p.ClientID == "" {
+			return fmt.Errorf("%s.clientId is required", field)
+		}
+		if (p.ClientSecret == "") == (p.ClientSecretARN == "") {
+			return fmt.Errorf("%s requires exactly one of clientSecret or clientSecretARN", field)
+		}
+		if p.ClientSecretARN != "" && !strings.HasPrefix(p.ClientSecretARN, "arn:") {
+			return fmt.Errorf("%s.clientSecretARN: '%s' is not an ARN", field, p.ClientSecretARN)
+		}
+		if p.Vendor == "CustomOauth2" && !strings.HasPrefix(p.DiscoveryURL, "https://") {
+			return fmt.Errorf("%s.discoveryURL must be an https URL for CustomOauth2", field)
+		}
+		for _, agent := range p.Agents {
+			if len(c.Agents) > 0 && !slices.Contains(c.Agents, agent) {
+				return fmt.Errorf("%s.agents: agent '%s' has no workload identity", field, agent)
+			}
+		}
+		if err := validateAgentNames(field+".agents", p.Agents, config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// workloadIdentityName returns the name of an agent's workload identity.
+func workloadIdentityName(stackName, agentName string) string {
+	return fmt.Sprintf("%s-%s", stackName, agentName)
+}
+
+// createIdentity creates the workload identities and OAuth2 credential
+// providers and grants the agents tokens from them.
+func (s *AgentCoreStack) createIdentity(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	stackName := s.Config.StackName
+	cfg := s.Options.Identity
+
+	region, err := aws.GetRegion(ctx, nil, s.invoke())
+	if err != nil {
+		return err
+	}
+	caller, err := aws.GetCallerIdentity(ctx, nil, s.invoke())
+	if err != nil {
+		return err
+	}
+	arnPrefix := fmt.Sprintf("arn:aws:bedrock-agentcore:%s:%s", region.Name, caller.AccountId)
+	directory := arnPrefix + ":workload-identity-directory/default"
+	vault := arnPrefix + ":token-vault/default"
+
+	agents := s.selectedAgents(cfg.Agents)
+	identityArns := make(map[string]pulumi.StringArray)
+	for _, agentName := range agents {
+		name := workloadIdentityName(stackName, agentName)
+		properties := pulumi.Map{
+			"Name": pulumi.String(name),
+			"Tags": s.agentTags(agentName, tags),
+		}
+		if len(cfg.AllowedReturnURLs) > 0 {
+			properties["AllowedResourceOauth2ReturnUrls"] = pulumi.ToStringArray(cfg.AllowedReturnURLs)
+		}
+		identity, err := newCloudControlResource(ctx, s.ResourceName(fmt.Sprintf("%s-workload-identity", agentName)),
+			"AWS::BedrockAgentCore::WorkloadIdentity", properties, s.child())
+		if err != nil {
+			return err
+		}
+		identityArns[agentName] = pulumi.StringArray{pulumi.String(directory), cloudControlAttribute(identity, "WorkloadIdentityArn")}
+		s.injectEnv(agentName, "WORKLOAD_IDENTITY_NAME", pulumi.String(name))
+
+		err = s.attachAgentPolicy(ctx, agentName, "workload-identity-policy", policyStatement{
+			Actions: []string{
+				"bedrock-agentcore:GetWorkloadAccessToken",
+				"bedrock-agentcore:GetWorkloadAccessTokenForJWT",
+				"bedrock-agentcore:GetWorkloadAccessTokenForUserId",
+			},
+			Resources: identityArns[agentName],
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if len(cfg.OAuth2Providers) == 0 {
+		return nil
+	}
+
+	// Client secrets the stack stores
+	clientSecrets := make(map[string]pulumi.StringOutput)
+	secretVersions := make(map[string]pulumi.StringOutput)
+	var secretArns pulumi.StringArray
+	for _, p := range cfg.OAuth2Providers {
+		if p.ClientSecretARN != "" {
+			clientSecrets[p.Name] = pulumi.String(p.ClientSecretARN).ToStringOutput()
+			secretVersions[p.Name] = pulumi.String("").ToStringOutput()
+			secretArns = append(secretArns, pulumi.String(p.ClientSecretARN))
+			continue
+		}
+		secretName := fmt.Sprintf("%s/identity/%s", stackName, p.Name)
+		secret, err := secretsmanager.NewSecret(ctx, s.ResourceName(fmt.Sprintf("oauth2-%s-client-secret", p.Name)), &secretsmanager.SecretArgs{
+			Name:        pulumi.String(secretName),
+			Description: pulumi.Sprintf("OAuth2 client secret of the %s credential provider", p.Name),
+			KmsKeyId:    s.kmsKeyID(),
+			Tags:        mergeTags(s.secretTags(ctx, "", tags), pulumi.String(secretName)),
+		}, s.child())
+		if err != nil {
+			return err
+		}
+		version, err := secretsmanager.NewSecretVersion(ctx, s.ResourceName(fmt.Sprintf("oauth2-%s-client-secret-version", p.Name)), &secretsmanager.SecretVersionArgs{
+			SecretId:     secret.ID(),
+			SecretString: pulumi.ToSecret(pulumi.String(p.ClientSecret)).(pulumi.StringOutput),
+		}, s.child())
+		if err != nil {
+			return err
+		}
+		clientSecrets[p.Name] = secret.Arn
+		secretVersions[p.Name] = version.VersionId
+		secretArns = append(secretArns, secret.Arn)
+	}
+
+	// Function that registers the providers with the token vault
+	function, err := s.newInlineFunction(ctx, "oauth2-providers", credentialProviderHandler, 60, nil, tags,
+		policyStatement{
+			Actions:   []string{"secretsmanager:GetSecretValue"},
+			Resources: secretArns,
+		},
+		policyStatement{
+			Actions: []string{
+				"bedrock-agentcore:CreateOauth2CredentialProvider",
+				"bedrock-agentcore:UpdateOauth2CredentialProvider",
+				"bedrock-agentcore:DeleteOauth2CredentialProvider",
+				"bedrock-agentcore:GetOauth2CredentialProvider",
+				"bedrock-agentcore:CreateTokenVault",
+				"bedrock-agentcore:GetTokenVault",
+			},
+			Resources: pulumi.StringArray{pulumi.String(vault), pulumi.String(vault + "/*")},
+		},
+		// The token vault keeps its copy of the client secret in Secrets Manager
+		policyStatement{
+			Actions:   []string{"secretsmanager:CreateSecret", "secretsmanager:PutSecretValue", "secretsmanager:DeleteSecret", "secretsmanager:DescribeSecret"},
+			Resources: pulumi.StringArray{pulumi.Sprintf("arn:aws:secretsmanager:%s:%s:secret:bedrock-agentcore-identity!default/oauth2/*", region.Name, caller.AccountId)},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	// Each agent may obtain tokens only from its own providers
+	tokenResources := make(map[string]pulumi.StringArray)
+	vaultSecretArns := make(map[string]pulumi.StringArray)
+	providersByAgent := make(map[string][]string)
+	for _, agentName := range agents {
+		tokenResources[agentName] = append(pulumi.StringArray{pulumi.String(vault)}, identityArns[agentName]...)
+	}
+	for _, p := range cfg.OAuth2Providers {
+		input := pulumi.All(clientSecrets[p.Name], secretVersions[p.Name]).ApplyT(func(args []interface{}) (string, error) {
+			data, err := json.Marshal(map[string]string{
+				"name":            p.Name,
+				"vendor":          p.Vendor,
+				"configField":     oauth2Vendors[p.Vendor],
+				"clientId":        p.ClientID,
+				"clientSecretArn": args[0].(string),
+				"secretVersion":   args[1].(string),
+				"discoveryUrl":    p.DiscoveryURL,
+			})
+			return string(data), err
+		}).(pulumi.StringOutput)
+		provider, err := lambda.NewInvocation(ctx, s.ResourceName(fmt.Sprintf("oauth2-%s-provider", p.Name)), &lambda.InvocationArgs{
+			FunctionName:   function.Name,
+			Input:          input,
+			LifecycleScope: pulumi.String("CRUD"),
+		}, s.child())
+		if err != nil {
+			return err
+		}
+		result := func(key string) pulumi.StringOutput {
+			return provider.Result.ApplyT(func(result string) (string, error) {
+				var values map[string]string
+				err := json.Unmarshal([]byte(result), &values)
+				return values[key], err
+			}).(pulumi.StringOutput)
+		}
+		providerArn, vaultSecretArn := result("credentialProviderArn"), result("secretArn")
+
+		for _, agentName := range agents {
+			if len(p.Agents) == 0 || slices.Contains(p.Agents, agentName) {
+				providersByAgent[agentName] = append(providersByAgent[agentName], p.Name)
+				tokenResources[agentName] = append(tokenResources[agentName], providerArn)
+				vaultSecretArns[agentName] = append(vaultSecretArns[agentName], vaultSecretArn)
+			}
+		}
+	}
+
+	for _, agentName := range agents {
+		names := providersByAgent[agentName]
+		if len(names) == 0 {
+			continue
+		}
+		s.injectEnv(agentName, "OAUTH2_PROVIDERS", pulumi.String(strings.Join(names, ",")))
+		err := s.attachAgentPolicy(ctx, agentName, "oauth2-providers-policy",
+			policyStatement{
+				Actions:   []string{"bedrock-agentcore:GetResourceOauth2Token"},
+				Resources: tokenResources[agentName],
+			},
+			policyStatement{
+				Actions:   []string{"secretsmanager:GetSecretValue"},
+				Resources: vaultSecretArns[agentName],
+			},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package agentcore
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/bedrock"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// InferenceProfileConfig creates a Bedrock application inference profile
+// for each agent, so Bedrock usage can be attributed to agents in Cost
+// Explorer by the profile's cost allocation tag.
+//
+// Agents receive their profile ARN as BEDROCK_INFERENCE_PROFILE_ARN and
+// pass it as the model ID. Each agent's Bedrock access is limited to its
+// own profile in place of IAM.BedrockModelIDs.
+type InferenceProfileConfig struct {
+	// ModelID is the model the profiles track: a foundation model ID, a
+	// cross-region inference profile ID such as
+	// "us.anthropic.claude-sonnet-4-20250514-v1:0", or a foundation model
+	// or inference profile ARN.
+	// Required.
+	ModelID string `json:"modelId" yaml:"modelId"`
+
+	// Models overrides ModelID for agents, keyed by agent name.
+	// Optional.
+	Models map[string]string `json:"models,omitempty" yaml:"models,omitempty"`
+
+	// TagKey is the tag holding the agent name. Activate it as a cost
+	// allocation tag to break down Bedrock costs by agent.
+	// Default: "agentkit:agent"
+	TagKey string `json:"tagKey,omitempty" yaml:"tagKey,omitempty"`
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *InferenceProfileConfig) ApplyDefaults() {
+	if c.TagKey == "" {
+		c.TagKey = agentTagKey
+	}
+}
+
+// Validate validates the InferenceProfileConfig.
+func (c *InferenceProfileConfig) Validate(config iac.StackConfig) error {
+	if config.IAM == nil || !config.IAM.EnableBedrockAccess {
+		return fmt.Errorf("inferenceProfiles requires iam.enableBedrockAccess")
+	}
+	if c.ModelID == "" {
+		return fmt.Errorf("inferenceProfiles.modelId is required")
+	}
+	if err := validateProfileModelID("inferenceProfiles.modelId", c.ModelID); err != nil {
+		return err
+	}
+	for _, agentName := range slices.Sorted(maps.Keys(c.Models)) {
+		if !hasAgent(config, agentName) {
+			return fmt.Errorf("inferenceProfiles.models: '%s' does not match any agent name", agentName)
+		}
+		if err := validateProfileModelID(fmt.Sprintf("inferenceProfiles.models[%s]", agentName), c.Models[agentName]); err != nil {
+			return err
+		}
+	}
+	if !labelKeyPattern.MatchString(c.TagKey) {
+		return fmt.Errorf("inferenceProfiles.tagKey: '%s' is not a valid tag key", c.TagKey)
+	}
+	return nil
+}
+
+// validateProfileModelID checks that an application inference profile can
+// copy from the model.
+func validateProfileModelID(field, modelID string) error {
+	if !strings.HasPrefix(modelID, "arn:") {
+		return nil
+	}
+	resourceType, _, ok := parseBedrockModelARN(modelID)
+	if !ok || resourceType == "application-inference-profile" {
+		return fmt.Errorf("%s: '%s' is not a foundation model or inference profile ARN", field, modelID)
+	}
+	return nil
+}
+
+// modelID returns the model of the agent's profile.
+func (c *InferenceProfileConfig) modelID(agentName string) string {
+	if modelID, ok := c.Models[agentName]; ok {
+		return modelID
+	}
+	return c.ModelID
+}
+
+// createInferenceProfiles creates an application inference profile for
+// each agent and limits each agent's Bedrock access to its own.
+func (s *AgentCoreStack) createInferenceProfiles(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	cfg := s.Options.InferenceProfiles
+
+	region, err := aws.GetRegion(ctx, nil, s.invoke())
+	if err != nil {
+		return err
+	}
+	caller, err := aws.GetCallerIdentity(ctx, nil, s.invoke())
+	if err != nil {
+		return err
+	}
+
+	for _, agent := range s.Config.Agents {
+		name := fmt.Sprintf("%s-%s", s.Config.StackName, agent.Name)
+
+		// Profiles copy from model ARNs, not IDs
+		source := cfg.modelID(agent.Name)
+		switch {
+		case strings.HasPrefix(source, "arn:"):
+		case inferenceProfilePattern.MatchString(source):
+			source = fmt.Sprintf("arn:aws:bedrock:%s:%s:inference-profile/%s", region.Name, caller.AccountId, source)
+		default:
+			source = fmt.Sprintf("arn:aws:bedrock:%s::foundation-model/%s", region.Name, source)
+		}
+
+		profileTags := mergeTags(tags, pulumi.String(name))
+		profileTags[cfg.TagKey] = pulumi.String(agent.Name)
+		profile, err := bedrock.NewInferenceProfile(ctx, s.ResourceName("inference-profile-"+agent.Name), &bedrock.InferenceProfileArgs{
+			Name:        pulumi.String(name),
+			Description: pulumi.Sprintf("Bedrock usage of the %s agent", agent.Name),
+			ModelSource: &bedrock.InferenceProfileModelSourceArgs{
+				CopyFrom: pulumi.String(source),
+			},
+			Tags: profileTags,
+		}, s.child())
+		if err != nil {
+			return err
+		}
+
+		s.InferenceProfiles[agent.Name] = profile.Arn
+		s.injectEnv(agent.Name, "BEDROCK_INFERENCE_PROFILE_ARN", profile.Arn)
+
+		// Invoking a profile also needs its models, and the models behind
+		// cross-region profiles, in every region they route to. Those are
+		// only reachable through a profile, and the agent may only invoke
+		// its own.
+		err = s.attachAgentPolicy(ctx, agent.Name, "inference-profile-policy",
+			policyStatement{
+				Actions:   bedrockInvokeActions,
+				Resources: pulumi.StringArray{profile.Arn},
+			},
+			policyStatement{
+				Actions: bedrockInvokeActions,
+				Resources: pulumi.ToStringArray([]string{
+					"arn:aws:bedrock:*::foundation-model/*",
+					"arn:aws:bedrock:*:*:inference-profile/*",
+				}),
+				Conditions: map[string]map[string]interface{}{
+					"StringLike": {"bedrock:InferenceProfileArn": "arn:aws:bedrock:*:*:application-inference-profile/*"},
+				},
+			},
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
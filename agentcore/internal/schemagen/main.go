@@ -0,0 +1,234 @@
+// Command schemagen writes the JSON Schema of iac.StackConfig that the
+// agentcore package embeds, with agentcore.Options under "options".
+// Descriptions, enums and ranges come from the doc comments of the config
+// types.
+//
+// Usage:
+//
+//	go run ./internal/schemagen -o stackconfig.schema.json
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/plexusone/agentkit-aws-pulumi/agentcore"
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+)
+
+// schemaID is the published location of the schema.
+const schemaID = "https://raw.githubusercontent.com/plexusone/agentkit-aws-pulumi/main/agentcore/stackconfig.schema.json"
+
+var (
+	// supportedPattern matches the quoted values of a "Supported:" line.
+	supportedPattern = regexp.MustCompile(`"([^"]*)"`)
+
+	// rangePattern matches a "Range: 1-900" line.
+	rangePattern = regexp.MustCompile(`^Range: ([0-9]+)-([0-9]+)`)
+)
+
+func main() {
+	out := flag.String("o", "stackconfig.schema.json", "output file")
+	flag.Parse()
+
+	docs := map[string][]string{}
+	for _, t := range []reflect.Type{reflect.TypeOf(iac.StackConfig{}), reflect.TypeOf(agentcore.Options{})} {
+		if err := fieldDocs(t.PkgPath(), docs); err != nil {
+			fmt.Fprintln(os.Stderr, "schemagen:", err)
+			os.Exit(1)
+		}
+	}
+
+	g := &generator{docs: docs, defs: map[string]interface{}{}, types: map[string]reflect.Type{}}
+	schema := g.object(reflect.TypeOf(iac.StackConfig{}))
+	schema["properties"].(map[string]interface{})["options"] = map[string]interface{}{
+		"allOf":       []interface{}{g.schema(reflect.TypeOf(agentcore.Options{}))},
+		"description": "Options are the Pulumi-specific stack settings, see agentcore.Options.",
+	}
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["$id"] = schemaID
+	schema["title"] = "AgentCore stack configuration"
+	schema["$defs"] = g.defs
+
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "schemagen:", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*out, append(data, '\n'), 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, "schemagen:", err)
+		os.Exit(1)
+	}
+}
+
+// fieldDocs adds the doc comment lines of a package's struct fields to
+// docs, keyed by "importPath.Type.Field".
+func fieldDocs(importPath string, docs map[string][]string) error {
+	pkg, err := build.Import(importPath, "", build.FindOnly)
+	if err != nil {
+		return err
+	}
+	pkgs, err := parser.ParseDir(token.NewFileSet(), pkg.Dir, nil, parser.ParseComments)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range pkgs {
+		for _, file := range p.Files {
+			ast.Inspect(file, func(n ast.Node) bool {
+				spec, ok := n.(*ast.TypeSpec)
+				if !ok {
+					return true
+				}
+				st, ok := spec.Type.(*ast.StructType)
+				if !ok {
+					return false
+				}
+				for _, field := range st.Fields.List {
+					if field.Doc == nil {
+						continue
+					}
+					lines := strings.Split(strings.TrimSpace(field.Doc.Text()), "\n")
+					for _, name := range field.Names {
+						docs[importPath+"."+spec.Name.Name+"."+name.Name] = lines
+					}
+				}
+				return false
+			})
+		}
+	}
+	return nil
+}
+
+// generator builds schemas for Go types, collecting structs in $defs.
+type generator struct {
+	docs  map[string][]string
+	defs  map[string]interface{}
+	types map[string]reflect.Type
+}
+
+// defName returns the $defs name of a struct. Structs named like a struct
+// of another package are prefixed with their package name.
+func (g *generator) defName(t reflect.Type) string {
+	name := t.Name()
+	if other, ok := g.types[name]; ok && other != t {
+		name = strings.ToUpper(path.Base(t.PkgPath())[:1]) + path.Base(t.PkgPath())[1:] + name
+	}
+	g.types[name] = t
+	return name
+}
+
+// schema returns the schema of a type, referencing structs by $defs.
+func (g *generator) schema(t reflect.Type) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.schema(t.Elem())
+	case reflect.Struct:
+		name := g.defName(t)
+		if _, ok := g.defs[name]; !ok {
+			g.defs[name] = nil
+			g.defs[name] = g.object(t)
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + name}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// object returns the schema of a struct. Fields without omitempty are
+// required.
+func (g *generator) object(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+
+		prop := g.schema(field.Type)
+		if _, isRef := prop["$ref"]; isRef {
+			prop = map[string]interface{}{"allOf": []interface{}{prop}}
+		}
+		g.describe(prop, g.docs[t.PkgPath()+"."+t.Name()+"."+field.Name])
+		properties[name] = prop
+	}
+
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// describe adds the description, enum and range of a doc comment to a
+// property schema.
+func (g *generator) describe(prop map[string]interface{}, doc []string) {
+	if len(doc) == 0 {
+		return
+	}
+	// The first line names the Go field, e.g. "MemoryMB is the memory..."
+	if _, rest, ok := strings.Cut(doc[0], " "); ok {
+		rest = strings.TrimPrefix(strings.TrimPrefix(rest, "is "), "are ")
+		doc[0] = strings.ToUpper(rest[:1]) + rest[1:]
+	}
+	prop["description"] = strings.Join(doc, "\n")
+
+	for _, line := range doc {
+		switch {
+		case strings.HasPrefix(line, "Supported: ") && prop["type"] == "string":
+			var values []string
+			for _, match := range supportedPattern.FindAllStringSubmatch(line, -1) {
+				values = append(values, match[1])
+			}
+			if len(values) > 0 {
+				prop["enum"] = values
+			}
+		case strings.HasPrefix(line, "Valid values: ") && prop["type"] == "integer":
+			var values []int
+			for _, value := range strings.Split(strings.TrimPrefix(line, "Valid values: "), ",") {
+				if n, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+					values = append(values, n)
+				}
+			}
+			if len(values) > 0 {
+				prop["enum"] = values
+			}
+		case rangePattern.MatchString(line) && prop["type"] == "integer":
+			match := rangePattern.FindStringSubmatch(line)
+			prop["minimum"], _ = strconv.Atoi(match[1])
+			prop["maximum"], _ = strconv.Atoi(match[2])
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/cloudwatch"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/iam"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/kms"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// tenantTagKey is the resource tag and session tag that partitions tenant access.
+const tenantTagKey = "tenant"
+
+// tenantPrincipalTag is the IAM policy variable for the caller's tenant session tag.
+const tenantPrincipalTag = "${aws:PrincipalTag/" + tenantTagKey + "}"
+
+// tenantTags merges base tags with a name tag and the tenant tag.
+func tenantTags(base pulumi.StringMap, tenant string, name pulumi.StringInput) pulumi.StringMap {
+	result := mergeTags(base, name)
+	result[tenantTagKey] = pulumi.String(tenant)
+	return result
+}
+
+// tenantSecretsPrefix returns the Secrets Manager name prefix for tenant secrets.
+func (s *AgentCoreStack) tenantSecretsPrefix() string {
+	return s.Config.StackName + "/tenants/"
+}
+
+// createTenantIsolation creates per-tenant namespaces and the tenant access
+// role. Agents assume the role with a "tenant" session tag per request, and
+// its policy only matches resources belonging to that tenant.
+func (s *AgentCoreStack) createTenantIsolation(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	stackName := s.Config.StackName
+	cfg := s.Options.Tenancy
+	retain := s.Config.RemovalPolicy == "retain"
+
+	deletionWindow := 7
+	if retain {
+		deletionWindow = 30
+	}
+
+	tenantNames := make([]string, len(cfg.Tenants))
+	keyArns := make([]interface{}, len(cfg.Tenants))
+	var keyResources pulumi.StringArray
+	for i, tenant := range cfg.Tenants {
+		tenantNames[i] = tenant.Name
+
+		key, err := kms.NewKey(ctx, s.ResourceName(fmt.Sprintf("tenant-%s-key", tenant.Name)), &kms.KeyArgs{
+			Description:          pulumi.Sprintf("Data key for tenant %s in %s", tenant.Name, stackName),
+			EnableKeyRotation:    pulumi.Bool(true),
+			DeletionWindowInDays: pulumi.Int(deletionWindow),
+			Tags:                 tenantTags(tags, tenant.Name, pulumi.Sprintf("%s-%s", stackName, tenant.Name)),
+		}, pulumi.RetainOnDelete(retain), s.child())
+		if err != nil {
+			return err
+		}
+		_, err = kms.NewAlias(ctx, s.ResourceName(fmt.Sprintf("tenant-%s-key-alias", tenant.Name)), &kms.AliasArgs{
+			Name:        pulumi.Sprintf("alias/%s/%s", stackName, tenant.Name),
+			TargetKeyId: key.KeyId,
+		}, s.child())
+		if err != nil {
+			return err
+		}
+		keyArns[i] = key.Arn
+		keyResources = append(keyResources, key.Arn)
+
+		_, err = cloudwatch.NewLogStream(ctx, s.ResourceName(fmt.Sprintf("tenant-%s-log-stream", tenant.Name)), &cloudwatch.LogStreamArgs{
+			LogGroupName: s.LogGroup.Name,
+			Name:         pulumi.Sprintf("tenant/%s", tenant.Name),
+		}, s.child())
+		if err != nil {
+			return err
+		}
+	}
+
+	// Only the agents' execution roles may assume the tenant role, and only
+	// for known tenants
+	trustPolicy := s.agentRoleArns(s.selectedAgents(nil)).ToStringArrayOutput().ApplyT(func(arns []string) (string, error) {
+		data, err := json.Marshal(map[string]interface{}{
+			"Version": "2012-10-17",
+			"Statement": []map[string]interface{}{
+				{
+					"Effect":    "Allow",
+					"Principal": map[string][]string{"AWS": arns},
+					"Action":    []string{"sts:AssumeRole", "sts:TagSession"},
+					"Condition": map[string]interface{}{
+						"StringEquals": map[string]interface{}{
+							"aws:RequestTag/" + tenantTagKey: tenantNames,
+						},
+						"ForAllValues:StringEquals": map[string]interface{}{
+							"aws:TagKeys": []string{tenantTagKey},
+						},
+					},
+				},
+			},
+		})
+		return string(data), err
+	}).(pulumi.StringOutput)
+
+	tenantRole, err := s.newRole(ctx, "tenant-access-role", stackName+"-tenant-access-role", &iam.RoleArgs{
+		Description:      pulumi.Sprintf("Tenant-scoped access for %s agents", stackName),
+		AssumeRolePolicy: trustPolicy,
+		Tags:             mergeTags(tags, pulumi.Sprintf("%s-tenant-access-role", stackName)),
+	})
+	if err != nil {
+		return err
+	}
+
+	sameTenant := map[string]map[string]interface{}{
+		"StringEquals": {"aws:ResourceTag/" + tenantTagKey: tenantPrincipalTag},
+	}
+	err = s.newRolePolicy(ctx, "tenant-access-policy", tenantRole.Name,
+		policyStatement{
+			Actions: []string{"logs:CreateLogStream", "logs:PutLogEvents"},
+			Resources: pulumi.StringArray{
+				pulumi.Sprintf("%s:log-stream:tenant/%s*", s.LogGroup.Arn, tenantPrincipalTag),
+			},
+		},
+		policyStatement{
+			Actions: []string{"secretsmanager:GetSecretValue", "secretsmanager:DescribeSecret"},
+			Resources: pulumi.StringArray{
+				pulumi.Sprintf("arn:aws:secretsmanager:*:*:secret:%s%s/*", s.tenantSecretsPrefix(), tenantPrincipalTag),
+			},
+		},
+		policyStatement{
+			Actions:    []string{"kms:Encrypt", "kms:Decrypt", "kms:GenerateDataKey", "kms:GenerateDataKeyWithoutPlaintext"},
+			Resources:  keyResources,
+			Conditions: sameTenant,
+		},
+		policyStatement{
+			Actions: []string{
+				"sqs:ReceiveMessage",
+				"sqs:DeleteMessage",
+				"sqs:ChangeMessageVisibility",
+				"sqs:GetQueueAttributes",
+				"sqs:SendMessage",
+			},
+			Resources:  pulumi.StringArray{pulumi.Sprintf("arn:aws:sqs:*:*:%s-*-requests", stackName)},
+			Conditions: sameTenant,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	err = s.attachRolePolicy(ctx, "tenant-assume-policy", policyStatement{
+		Actions:   []string{"sts:AssumeRole", "sts:TagSession"},
+		Resources: pulumi.StringArray{tenantRole.Arn},
+	})
+	if err != nil {
+		return err
+	}
+
+	tenantKeys := pulumi.All(keyArns...).ApplyT(func(args []interface{}) (string, error) {
+		keys := make(map[string]string, len(args))
+		for i, arn := range args {
+			keys[tenantNames[i]] = arn.(string)
+		}
+		data, err := json.Marshal(keys)
+		return string(data), err
+	}).(pulumi.StringOutput)
+
+	for _, name := range s.selectedAgents(cfg.Agents) {
+		s.injectEnv(name, "TENANT_ACCESS_ROLE_ARN", tenantRole.Arn)
+		s.injectEnv(name, "TENANT_SESSION_TAG", pulumi.String(tenantTagKey))
+		s.injectEnv(name, "TENANT_SECRETS_PREFIX", pulumi.String(s.tenantSecretsPrefix()))
+		s.injectEnv(name, "TENANT_LOG_STREAM_PREFIX", pulumi.String("tenant/"))
+		s.injectEnv(name, "TENANT_KMS_KEYS", tenantKeys)
+	}
+
+	return nil
+}
@@ -0,0 +1,295 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/cloudwatch"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/scheduler"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+const (
+	// secretMaxAgeTag overrides KeyRotationConfig.MaxAgeDays for a secret.
+	secretMaxAgeTag = "agentkit:max-age-days"
+
+	// secretExpiresTag records when a secret's credential expires at its
+	// provider, as a date or RFC 3339 timestamp.
+	secretExpiresTag = "agentkit:expires-at"
+)
+
+// keyRotationHandler measures the age of each secret's current version and
+// publishes SecretAgeDays, SecretDaysRemaining and SecretOverdue metrics per
+// secret. Secrets due within the reminder window are sent as a reminder.
+const keyRotationHandler = `import { SecretsManagerClient, DescribeSecretCommand, ListSecretVersionIdsCommand } from "@aws-sdk/client-secrets-manager";
+import { CloudWatchClient, PutMetricDataCommand } from "@aws-sdk/client-cloudwatch";
+import { SNSClient, PublishCommand } from "@aws-sdk/client-sns";
+
+const secretsmanager = new SecretsManagerClient({});
+const cloudwatch = new CloudWatchClient({});
+const sns = new SNSClient({});
+
+const DAY = 24 * 60 * 60 * 1000;
+const secrets = JSON.parse(process.env.SECRETS);
+const maxAgeDays = Number(process.env.MAX_AGE_DAYS);
+const reminderDays = Number(process.env.REMINDER_DAYS);
+
+const currentVersionDate = async (arn) => {
+  let NextToken;
+  do {
+    const page = await secretsmanager.send(new ListSecretVersionIdsCommand({ SecretId: arn, NextToken }));
+    const current = (page.Versions ?? []).find((v) => v.VersionStages?.includes("AWSCURRENT"));
+    if (current) return current.CreatedDate;
+    NextToken = page.NextToken;
+  } while (NextToken);
+};
+
+export const handler = async () => {
+  const now = Date.now();
+  const metrics = [];
+  const due = [];
+
+  for (const [arn, { name, agents }] of Object.entries(secrets)) {
+    const secret = await secretsmanager.send(new DescribeSecretCommand({ SecretId: arn }));
+    const tags = Object.fromEntries((secret.Tags ?? []).map((t) => [t.Key, t.Value]));
+    const changed = (await currentVersionDate(arn)) ?? secret.LastRotatedDate ?? secret.CreatedDate;
+
+    const ageDays = (now - changed.getTime()) / DAY;
+    let daysRemaining = Number(tags["` + secretMaxAgeTag + `"] ?? maxAgeDays) - ageDays;
+    const expiresAt = tags["` + secretExpiresTag + `"];
+    if (expiresAt && !Number.isNaN(Date.parse(expiresAt))) {
+      daysRemaining = Math.min(daysRemaining, (Date.parse(expiresAt) - now) / DAY);
+    }
+
+    const dimensions = [{ Name: "Secret", Value: name }];
+    metrics.push(
+      { MetricName: "SecretAgeDays", Dimensions: dimensions, Value: ageDays },
+      { MetricName: "SecretDaysRemaining", Dimensions: dimensions, Value: daysRemaining },
+      { MetricName: "SecretOverdue", Dimensions: dimensions, Value: daysRemaining <= 0 ? 1 : 0 },
+    );
+    if (daysRemaining > 0 && daysRemaining <= reminderDays) {
+      due.push({ secret: name, arn, agents, daysRemaining: Math.floor(daysRemaining) });
+    }
+  }
+
+  for (let i = 0; i < metrics.length; i += 150) {
+    await cloudwatch.send(new PutMetricDataCommand({
+      Namespace: process.env.METRIC_NAMESPACE,
+      MetricData: metrics.slice(i, i + 150),
+    }));
+  }
+
+  if (due.length > 0) {
+    await sns.send(new PublishCommand({
+      TopicArn: process.env.TOPIC_ARN,
+      Subject: ("Secrets due for rotation in " + process.env.STACK_NAME).slice(0, 100),
+      Message: JSON.stringify({ stack: process.env.STACK_NAME, due }, null, 2),
+    }));
+  }
+  return { checked: Object.keys(secrets).length, due };
+};
+`
+
+// secretSuffixPattern matches the random suffix Secrets Manager appends to secret ARNs.
+var secretSuffixPattern = regexp.MustCompile(`-[A-Za-z0-9]{6}$`)
+
+// secretNameFromArn returns the secret name from a full or partial secret ARN.
+func secretNameFromArn(secretArn string) string {
+	_, name, found := strings.Cut(secretArn, ":secret:")
+	if !found {
+		return secretArn
+	}
+	return secretSuffixPattern.ReplaceAllString(name, "")
+}
+
+// KeyRotationConfig configures rotation reminders and overdue alarms for the
+// secrets granted to agents through AgentConfig.SecretsARNs.
+//
+// A secret's age is measured from when its current version was created.
+// Individual secrets can override the maximum age with the
+// "agentkit:max-age-days" tag, and API keys that expire at their provider
+// can record the expiry in the "agentkit:expires-at" tag.
+type KeyRotationConfig struct {
+	// MaxAgeDays is the age after which a secret is overdue for rotation.
+	// Default: 90
+	MaxAgeDays int `json:"maxAgeDays,omitempty" yaml:"maxAgeDays,omitempty"`
+
+	// ReminderDays sends a reminder this many days before a secret is overdue.
+	// Default: 14
+	ReminderDays int `json:"reminderDays,omitempty" yaml:"reminderDays,omitempty"`
+
+	// Schedule is an EventBridge Scheduler expression.
+	// Default: "rate(1 day)"
+	Schedule string `json:"schedule,omitempty" yaml:"schedule,omitempty"`
+
+	// Recipients are email addresses subscribed to reminders and alarms.
+	Recipients []string `json:"recipients,omitempty" yaml:"recipients,omitempty"`
+}
+
+// DefaultKeyRotationConfig returns a KeyRotationConfig with sensible defaults.
+func DefaultKeyRotationConfig() *KeyRotationConfig {
+	return &KeyRotationConfig{
+		MaxAgeDays:   90,
+		ReminderDays: 14,
+		Schedule:     "rate(1 day)",
+	}
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *KeyRotationConfig) ApplyDefaults() {
+	if c.MaxAgeDays == 0 {
+		c.MaxAgeDays = 90
+	}
+	if c.ReminderDays == 0 {
+		c.ReminderDays = 14
+	}
+	if c.Schedule == "" {
+		c.Schedule = "rate(1 day)"
+	}
+}
+
+// Validate validates the KeyRotationConfig against the stack configuration.
+func (c *KeyRotationConfig) Validate(config iac.StackConfig) error {
+	if c.MaxAgeDays < 1 {
+		return fmt.Errorf("keyRotation.maxAgeDays must be at least 1")
+	}
+	if c.ReminderDays < 1 || c.ReminderDays >= c.MaxAgeDays {
+		return fmt.Errorf("keyRotation.reminderDays must be at least 1 and less than maxAgeDays")
+	}
+	for _, email := range c.Recipients {
+		if !strings.Contains(email, "@") {
+			return fmt.Errorf("keyRotation.recipients: '%s' is not an email address", email)
+		}
+	}
+	if len(grantedSecrets(config)) == 0 && !createsSecrets(config) {
+		return fmt.Errorf("keyRotation requires at least one agent with secretsARNs")
+	}
+	return nil
+}
+
+// createKeyRotation creates the secret age checker, its schedule, the
+// notification topic and an overdue alarm per secret.
+func (s *AgentCoreStack) createKeyRotation(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	var err error
+	stackName := s.Config.StackName
+	cfg := s.Options.KeyRotation
+
+	s.KeyRotationTopic, err = s.newEventTopic(ctx, "key-rotation-topic", fmt.Sprintf("%s-key-rotation", stackName), tags)
+	if err != nil {
+		return err
+	}
+	if err := s.subscribeEmails(ctx, "key-rotation-recipient", s.KeyRotationTopic.Arn, cfg.Recipients); err != nil {
+		return err
+	}
+
+	type trackedSecret struct {
+		Name   string   `json:"name"`
+		Agents []string `json:"agents"`
+	}
+	granted := grantedSecrets(s.Config)
+	secretArns := make([]string, 0, len(granted))
+	for secretArn := range granted {
+		secretArns = append(secretArns, secretArn)
+	}
+	sort.Strings(secretArns)
+
+	tracked := make(map[string]trackedSecret, len(granted))
+	var resources pulumi.StringArray
+	for _, secretArn := range secretArns {
+		tracked[secretArn] = trackedSecret{Name: secretNameFromArn(secretArn), Agents: granted[secretArn]}
+		resources = append(resources, pulumi.String(secretArn), pulumi.String(secretArn+"-*"))
+	}
+	secrets, err := json.Marshal(tracked)
+	if err != nil {
+		return err
+	}
+
+	function, err := s.newInlineFunction(ctx, "key-rotation", keyRotationHandler, 300,
+		pulumi.StringMap{
+			"STACK_NAME":       pulumi.String(stackName),
+			"SECRETS":          pulumi.String(string(secrets)),
+			"MAX_AGE_DAYS":     pulumi.Sprintf("%d", cfg.MaxAgeDays),
+			"REMINDER_DAYS":    pulumi.Sprintf("%d", cfg.ReminderDays),
+			"METRIC_NAMESPACE": pulumi.String(s.metricNamespace()),
+			"TOPIC_ARN":        s.KeyRotationTopic.Arn,
+		},
+		tags,
+		policyStatement{
+			Actions:   []string{"secretsmanager:DescribeSecret", "secretsmanager:ListSecretVersionIds"},
+			Resources: resources,
+		},
+		policyStatement{
+			Actions:   []string{"cloudwatch:PutMetricData"},
+			Resources: pulumi.StringArray{pulumi.String("*")},
+			Conditions: map[string]map[string]interface{}{
+				"StringEquals": {"cloudwatch:namespace": s.metricNamespace()},
+			},
+		},
+		policyStatement{
+			Actions:   []string{"sns:Publish"},
+			Resources: pulumi.StringArray{s.KeyRotationTopic.Arn},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	// Role assumed by EventBridge Scheduler to run the checker
+	schedulerRole, err := s.newServiceRole(ctx, "key-rotation-scheduler-role", "scheduler.amazonaws.com", tags)
+	if err != nil {
+		return err
+	}
+	err = s.newRolePolicy(ctx, "key-rotation-scheduler-policy", schedulerRole.Name, policyStatement{
+		Actions:   []string{"lambda:InvokeFunction"},
+		Resources: pulumi.StringArray{function.Arn},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = scheduler.NewSchedule(ctx, s.ResourceName("key-rotation-schedule"), &scheduler.ScheduleArgs{
+		Name:               pulumi.Sprintf("%s-key-rotation", stackName),
+		Description:        pulumi.Sprintf("Secret age checks for %s agents", stackName),
+		ScheduleExpression: pulumi.String(cfg.Schedule),
+		FlexibleTimeWindow: &scheduler.ScheduleFlexibleTimeWindowArgs{
+			Mode: pulumi.String("OFF"),
+		},
+		Target: &scheduler.ScheduleTargetArgs{
+			Arn:     function.Arn,
+			RoleArn: schedulerRole.Arn,
+		},
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	// The checker runs daily, so alarms keep their state between data points
+	for _, secretArn := range secretArns {
+		name := tracked[secretArn].Name
+		_, err = cloudwatch.NewMetricAlarm(ctx, s.ResourceName(fmt.Sprintf("secret-%s-overdue-alarm", name)), &cloudwatch.MetricAlarmArgs{
+			Name:               pulumi.Sprintf("%s-secret-%s-overdue", stackName, name),
+			AlarmDescription:   pulumi.Sprintf("Secret %s is overdue for rotation or has expired", name),
+			Namespace:          pulumi.String(s.metricNamespace()),
+			MetricName:         pulumi.String("SecretOverdue"),
+			Dimensions:         pulumi.StringMap{"Secret": pulumi.String(name)},
+			Statistic:          pulumi.String("Maximum"),
+			Period:             pulumi.Int(86400),
+			EvaluationPeriods:  pulumi.Int(1),
+			Threshold:          pulumi.Float64(1),
+			ComparisonOperator: pulumi.String("GreaterThanOrEqualToThreshold"),
+			TreatMissingData:   pulumi.String("ignore"),
+			AlarmActions:       pulumi.Array{s.KeyRotationTopic.Arn},
+			OkActions:          pulumi.Array{s.KeyRotationTopic.Arn},
+			Tags:               mergeTags(tags, pulumi.Sprintf("%s-secret-%s-overdue", stackName, name)),
+		}, s.child())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/kms"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// KMSConfig encrypts the stack's log groups, created secrets and
+// notification topics with a customer-managed KMS key.
+//
+// S3 buckets keep SSE-S3 encryption, since the services writing to them
+// would each need grants on the key. The execution role and the stack's
+// Lambda functions are granted use of the key.
+type KMSConfig struct {
+	// KeyARN is an existing customer-managed key. Its key policy must allow
+	// CloudWatch Logs for the /aws/agentcore/{stack} log groups, and
+	// EventBridge and CloudWatch to publish to encrypted topics.
+	// If empty, a key with automatic rotation is created.
+	KeyARN string `json:"keyARN,omitempty" yaml:"keyARN,omitempty"`
+}
+
+// Validate validates the KMSConfig.
+func (c *KMSConfig) Validate() error {
+	if c.KeyARN != "" && !strings.HasPrefix(c.KeyARN, "arn:") {
+		return fmt.Errorf("kms.keyARN: '%s' is not an ARN", c.KeyARN)
+	}
+	return nil
+}
+
+// kmsKeyID returns the stack key for KmsKeyId arguments, or nil if the
+// stack has no key.
+func (s *AgentCoreStack) kmsKeyID() pulumi.StringPtrInput {
+	if s.Options.KMS == nil {
+		return nil
+	}
+	return s.KMSKeyArn
+}
+
+// kmsKeyStatement grants use of the stack key for encryption and decryption.
+func (s *AgentCoreStack) kmsKeyStatement() policyStatement {
+	return policyStatement{
+		Actions:   []string{"kms:Decrypt", "kms:GenerateDataKey"},
+		Resources: pulumi.StringArray{s.KMSKeyArn},
+	}
+}
+
+// createKMSKey creates the stack key, or references an existing one.
+func (s *AgentCoreStack) createKMSKey(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	stackName := s.Config.StackName
+	cfg := s.Options.KMS
+
+	if cfg.KeyARN != "" {
+		s.KMSKeyArn = pulumi.String(cfg.KeyARN).ToStringOutput()
+		return nil
+	}
+
+	region, err := aws.GetRegion(ctx, nil, s.invoke())
+	if err != nil {
+		return err
+	}
+	caller, err := aws.GetCallerIdentity(ctx, nil, s.invoke())
+	if err != nil {
+		return err
+	}
+
+	policy, err := json.Marshal(map[string]interface{}{
+		"Version": "2012-10-17",
+		"Statement": []map[string]interface{}{
+			{
+				"Sid":       "AccountAdministration",
+				"Effect":    "Allow",
+				"Principal": map[string]interface{}{"AWS": fmt.Sprintf("arn:aws:iam::%s:root", caller.AccountId)},
+				"Action":    "kms:*",
+				"Resource":  "*",
+			},
+			{
+				"Sid":       "StackLogGroups",
+				"Effect":    "Allow",
+				"Principal": map[string]interface{}{"Service": fmt.Sprintf("logs.%s.amazonaws.com", region.Name)},
+				"Action":    []string{"kms:Encrypt*", "kms:Decrypt*", "kms:ReEncrypt*", "kms:GenerateDataKey*", "kms:Describe*"},
+				"Resource":  "*",
+				"Condition": map[string]interface{}{
+					"ArnLike": map[string]interface{}{
+						"kms:EncryptionContext:aws:logs:arn": fmt.Sprintf("arn:aws:logs:%s:%s:log-group:/aws/agentcore/%s*",
+							region.Name, caller.AccountId, stackName),
+					},
+				},
+			},
+			{
+				"Sid":       "NotificationPublishers",
+				"Effect":    "Allow",
+				"Principal": map[string]interface{}{"Service": []string{"events.amazonaws.com", "cloudwatch.amazonaws.com"}},
+				"Action":    []string{"kms:GenerateDataKey*", "kms:Decrypt"},
+				"Resource":  "*",
+				"Condition": map[string]interface{}{
+					"StringEquals": map[string]interface{}{"aws:SourceAccount": caller.AccountId},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	retain := s.Config.RemovalPolicy == "retain"
+	deletionWindow := 7
+	if retain {
+		deletionWindow = 30
+	}
+
+	s.KMSKey, err = kms.NewKey(ctx, s.ResourceName("kms-key"), &kms.KeyArgs{
+		Description:          pulumi.Sprintf("Encryption key for %s", stackName),
+		EnableKeyRotation:    pulumi.Bool(true),
+		DeletionWindowInDays: pulumi.Int(deletionWindow),
+		Policy:               pulumi.String(string(policy)),
+		Tags:                 mergeTags(tags, pulumi.Sprintf("%s-key", stackName)),
+	}, pulumi.RetainOnDelete(retain), s.child())
+	if err != nil {
+		return err
+	}
+	_, err = kms.NewAlias(ctx, s.ResourceName("kms-key-alias"), &kms.AliasArgs{
+		Name:        pulumi.Sprintf("alias/%s", stackName),
+		TargetKeyId: s.KMSKey.KeyId,
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	s.KMSKeyArn = s.KMSKey.Arn
+	return nil
+}
@@ -0,0 +1,527 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/bedrock"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/iam"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/lambda"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/opensearch"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/s3"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// collectionNamePattern matches OpenSearch Serverless collection names short
+// enough to suffix the names of their security policies.
+var collectionNamePattern = regexp.MustCompile(`^[a-z][a-z0-9-]{2,26}$`)
+
+// Field names of the vector index, which Bedrock writes chunks into.
+const (
+	knowledgeBaseVectorField   = "bedrock-knowledge-base-default-vector"
+	knowledgeBaseTextField     = "AMAZON_BEDROCK_TEXT_CHUNK"
+	knowledgeBaseMetadataField = "AMAZON_BEDROCK_METADATA"
+)
+
+// vectorIndexHandler creates the vector index in the collection with a
+// signed request. Data access policies take effect asynchronously, so
+// requests denied shortly after the policy was created are retried.
+const vectorIndexHandler = `import { SignatureV4 } from "@smithy/signature-v4";
+import { Sha256 } from "@aws-crypto/sha256-js";
+import { defaultProvider } from "@aws-sdk/credential-provider-node";
+
+const signer = new SignatureV4({
+  service: "aoss",
+  region: process.env.AWS_REGION,
+  credentials: defaultProvider(),
+  sha256: Sha256,
+});
+const sleep = (ms) => new Promise((resolve) => setTimeout(resolve, ms));
+
+export const handler = async ({ endpoint, index, mappings }) => {
+  const url = new URL(endpoint + "/" + index);
+  const body = JSON.stringify({ settings: { "index.knn": true }, mappings });
+
+  for (let attempt = 1; ; attempt++) {
+    const request = await signer.sign({
+      method: "PUT",
+      protocol: "https:",
+      hostname: url.hostname,
+      path: url.pathname,
+      headers: { host: url.hostname, "content-type": "application/json" },
+      body,
+    });
+    const response = await fetch(url, { method: "PUT", headers: request.headers, body });
+    const text = await response.text();
+    if (response.ok || text.includes("resource_already_exists_exception")) {
+      // Give the index time to become visible to Bedrock
+      await sleep(30000);
+      return { index };
+    }
+    if (response.status !== 403 || attempt === 12) {
+      throw new Error("creating index " + index + " failed: " + response.status + " " + text);
+    }
+    await sleep(10000);
+  }
+};
+`
+
+// KnowledgeBaseConfig configures a Bedrock Knowledge Base backed by an
+// OpenSearch Serverless vector collection and an S3 data source.
+type KnowledgeBaseConfig struct {
+	// CollectionName is the name of the OpenSearch Serverless collection,
+	// also used for its security policies and the knowledge base.
+	// Default: the component name
+	CollectionName string `json:"collectionName,omitempty" yaml:"collectionName,omitempty"`
+
+	// Description describes the knowledge base.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+
+	// EmbeddingModelID is the Bedrock embedding model.
+	// Default: amazon.titan-embed-text-v2:0
+	EmbeddingModelID string `json:"embeddingModelId,omitempty" yaml:"embeddingModelId,omitempty"`
+
+	// Dimensions is the embedding vector size. It must be supported by
+	// the embedding model.
+	// Default: 1024
+	Dimensions int `json:"dimensions,omitempty" yaml:"dimensions,omitempty"`
+
+	// BucketARN is an existing bucket holding the documents. If empty, a
+	// bucket is created.
+	BucketARN string `json:"bucketARN,omitempty" yaml:"bucketARN,omitempty"`
+
+	// InclusionPrefixes limit ingestion to objects under these prefixes.
+	InclusionPrefixes []string `json:"inclusionPrefixes,omitempty" yaml:"inclusionPrefixes,omitempty"`
+
+	// StandbyReplicas keeps standby replicas of the collection in another
+	// availability zone, doubling its minimum capacity.
+	// Default: false
+	StandbyReplicas bool `json:"standbyReplicas,omitempty" yaml:"standbyReplicas,omitempty"`
+
+	// Tags are applied to all resources.
+	Tags map[string]string `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *KnowledgeBaseConfig) ApplyDefaults() {
+	if c.EmbeddingModelID == "" {
+		c.EmbeddingModelID = "amazon.titan-embed-text-v2:0"
+	}
+	if c.Dimensions == 0 {
+		c.Dimensions = 1024
+	}
+}
+
+// Validate validates the KnowledgeBaseConfig.
+func (c *KnowledgeBaseConfig) Validate() error {
+	if !collectionNamePattern.MatchString(c.CollectionName) {
+		return fmt.Errorf("knowledgeBase.collectionName: '%s' must be 3-27 lowercase letters, digits or hyphens, starting with a letter", c.CollectionName)
+	}
+	if c.Dimensions < 1 || c.Dimensions > 16000 {
+		return fmt.Errorf("knowledgeBase.dimensions must be between 1 and 16000")
+	}
+	if c.BucketARN != "" && !strings.HasPrefix(c.BucketARN, "arn:") {
+		return fmt.Errorf("knowledgeBase.bucketARN: '%s' is not an ARN", c.BucketARN)
+	}
+	return nil
+}
+
+// KnowledgeBase is a component that provisions a Bedrock Knowledge Base
+// with an OpenSearch Serverless vector store and an S3 data source.
+//
+// Grant agents retrieval with AgentBuilder.WithKnowledgeBase. Documents
+// added to the bucket are indexed when an ingestion job is started for
+// the data source.
+type KnowledgeBase struct {
+	pulumi.ResourceState
+
+	// KnowledgeBaseID is the knowledge base ID agents retrieve with.
+	KnowledgeBaseID pulumi.StringOutput
+
+	// KnowledgeBaseArn is the ARN of the knowledge base.
+	KnowledgeBaseArn pulumi.StringOutput
+
+	// DataSourceID is the ID of the S3 data source.
+	DataSourceID pulumi.StringOutput
+
+	// CollectionArn is the ARN of the vector collection.
+	CollectionArn pulumi.StringOutput
+
+	// Bucket is the document bucket (nil if BucketARN was given).
+	Bucket *s3.BucketV2
+
+	// BucketArn is the ARN of the document bucket.
+	BucketArn pulumi.StringOutput
+}
+
+// NewKnowledgeBase creates a Bedrock Knowledge Base and its vector store.
+func NewKnowledgeBase(ctx *pulumi.Context, name string, config *KnowledgeBaseConfig, opts ...pulumi.ResourceOption) (*KnowledgeBase, error) {
+	cfg := KnowledgeBaseConfig{}
+	if config != nil {
+		cfg = *config
+	}
+	if cfg.CollectionName == "" {
+		cfg.CollectionName = name
+	}
+	cfg.ApplyDefaults()
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid knowledge base configuration: %w", err)
+	}
+
+	kb := &KnowledgeBase{}
+	if err := ctx.RegisterComponentResource("agentkit:agentcore:KnowledgeBase", name, kb, opts...); err != nil {
+		return nil, err
+	}
+	parent := pulumi.Parent(kb)
+	collectionName := cfg.CollectionName
+
+	tags := pulumi.StringMap{}
+	for k, v := range cfg.Tags {
+		tags[k] = pulumi.String(v)
+	}
+	tags["ManagedBy"] = pulumi.String("agentkit-pulumi")
+
+	region, err := aws.GetRegion(ctx, nil, parent)
+	if err != nil {
+		return nil, err
+	}
+
+	kb.BucketArn = pulumi.String(cfg.BucketARN).ToStringOutput()
+	if cfg.BucketARN == "" {
+		if err := kb.createBucket(ctx, name, collectionName, tags, parent); err != nil {
+			return nil, err
+		}
+	}
+
+	// Collections require an encryption policy, and a network policy to be reachable
+	encryptionPolicy, err := json.Marshal(map[string]interface{}{
+		"Rules": []map[string]interface{}{
+			{"ResourceType": "collection", "Resource": []string{"collection/" + collectionName}},
+		},
+		"AWSOwnedKey": true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	encryption, err := opensearch.NewServerlessSecurityPolicy(ctx, name+"-encryption-policy", &opensearch.ServerlessSecurityPolicyArgs{
+		Name:   pulumi.String(collectionName + "-enc"),
+		Type:   pulumi.String("encryption"),
+		Policy: pulumi.String(string(encryptionPolicy)),
+	}, parent)
+	if err != nil {
+		return nil, err
+	}
+	networkPolicy, err := json.Marshal([]map[string]interface{}{
+		{
+			"Rules": []map[string]interface{}{
+				{"ResourceType": "collection", "Resource": []string{"collection/" + collectionName}},
+			},
+			"AllowFromPublic": true,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	network, err := opensearch.NewServerlessSecurityPolicy(ctx, name+"-network-policy", &opensearch.ServerlessSecurityPolicyArgs{
+		Name:   pulumi.String(collectionName + "-net"),
+		Type:   pulumi.String("network"),
+		Policy: pulumi.String(string(networkPolicy)),
+	}, parent)
+	if err != nil {
+		return nil, err
+	}
+
+	standbyReplicas := "DISABLED"
+	if cfg.StandbyReplicas {
+		standbyReplicas = "ENABLED"
+	}
+	collection, err := opensearch.NewServerlessCollection(ctx, name+"-collection", &opensearch.ServerlessCollectionArgs{
+		Name:            pulumi.String(collectionName),
+		Type:            pulumi.String("VECTORSEARCH"),
+		StandbyReplicas: pulumi.String(standbyReplicas),
+		Tags:            mergeTags(tags, pulumi.String(collectionName)),
+	}, parent, pulumi.DependsOn([]pulumi.Resource{encryption, network}))
+	if err != nil {
+		return nil, err
+	}
+	kb.CollectionArn = collection.Arn
+
+	// Role Bedrock assumes to embed documents and write them to the index
+	embeddingModelArn := fmt.Sprintf("arn:aws:bedrock:%s::foundation-model/%s", region.Name, cfg.EmbeddingModelID)
+	kbRole, err := iam.NewRole(ctx, name+"-role", &iam.RoleArgs{
+		Name:             pulumi.Sprintf("%s-knowledge-base", collectionName),
+		AssumeRolePolicy: pulumi.String(assumeRolePolicyFor("bedrock.amazonaws.com")),
+		Tags:             mergeTags(tags, pulumi.Sprintf("%s-knowledge-base", collectionName)),
+	}, parent)
+	if err != nil {
+		return nil, err
+	}
+	kbPolicy, err := iam.NewRolePolicy(ctx, name+"-policy", &iam.RolePolicyArgs{
+		Role: kbRole.Name,
+		Policy: policyDocument(
+			policyStatement{
+				Actions:   []string{"bedrock:InvokeModel"},
+				Resources: pulumi.StringArray{pulumi.String(embeddingModelArn)},
+			},
+			policyStatement{
+				Actions:   []string{"aoss:APIAccessAll"},
+				Resources: pulumi.StringArray{collection.Arn},
+			},
+			policyStatement{
+				Actions:   []string{"s3:ListBucket"},
+				Resources: pulumi.StringArray{kb.BucketArn},
+			},
+			policyStatement{
+				Actions:   []string{"s3:GetObject"},
+				Resources: pulumi.StringArray{pulumi.Sprintf("%s/*", kb.BucketArn)},
+			},
+		),
+	}, parent)
+	if err != nil {
+		return nil, err
+	}
+
+	// Function that creates the vector index, which has no AWS resource
+	indexRole, err := iam.NewRole(ctx, name+"-index-role", &iam.RoleArgs{
+		Name:             pulumi.Sprintf("%s-vector-index", collectionName),
+		AssumeRolePolicy: pulumi.String(assumeRolePolicyFor("lambda.amazonaws.com")),
+		Tags:             mergeTags(tags, pulumi.Sprintf("%s-vector-index", collectionName)),
+	}, parent)
+	if err != nil {
+		return nil, err
+	}
+	basicExecution, err := iam.NewRolePolicyAttachment(ctx, name+"-index-basic-execution", &iam.RolePolicyAttachmentArgs{
+		Role:      indexRole.Name,
+		PolicyArn: pulumi.String("arn:aws:iam::aws:policy/service-role/AWSLambdaBasicExecutionRole"),
+	}, parent)
+	if err != nil {
+		return nil, err
+	}
+	indexPolicy, err := iam.NewRolePolicy(ctx, name+"-index-policy", &iam.RolePolicyArgs{
+		Role: indexRole.Name,
+		Policy: policyDocument(policyStatement{
+			Actions:   []string{"aoss:APIAccessAll"},
+			Resources: pulumi.StringArray{collection.Arn},
+		}),
+	}, parent)
+	if err != nil {
+		return nil, err
+	}
+
+	dataPolicy := pulumi.All(kbRole.Arn, indexRole.Arn).ApplyT(func(args []interface{}) (string, error) {
+		data, err := json.Marshal([]map[string]interface{}{
+			{
+				"Rules": []map[string]interface{}{
+					{
+						"ResourceType": "collection",
+						"Resource":     []string{"collection/" + collectionName},
+						"Permission":   []string{"aoss:DescribeCollectionItems", "aoss:CreateCollectionItems", "aoss:UpdateCollectionItems"},
+					},
+					{
+						"ResourceType": "index",
+						"Resource":     []string{"index/" + collectionName + "/*"},
+						"Permission": []string{
+							"aoss:CreateIndex", "aoss:DescribeIndex", "aoss:UpdateIndex",
+							"aoss:ReadDocument", "aoss:WriteDocument",
+						},
+					},
+				},
+				"Principal": []interface{}{args[0], args[1]},
+			},
+		})
+		return string(data), err
+	}).(pulumi.StringOutput)
+	access, err := opensearch.NewServerlessAccessPolicy(ctx, name+"-access-policy", &opensearch.ServerlessAccessPolicyArgs{
+		Name:   pulumi.String(collectionName + "-data"),
+		Type:   pulumi.String("data"),
+		Policy: dataPolicy,
+	}, parent)
+	if err != nil {
+		return nil, err
+	}
+
+	function, err := lambda.NewFunction(ctx, name+"-index-function", &lambda.FunctionArgs{
+		Name:    pulumi.Sprintf("%s-vector-index", collectionName),
+		Role:    indexRole.Arn,
+		Runtime: pulumi.String(nodejsRuntime),
+		Handler: pulumi.String("index.handler"),
+		Timeout: pulumi.Int(300),
+		Code: pulumi.NewAssetArchive(map[string]interface{}{
+			"index.mjs": pulumi.NewStringAsset(vectorIndexHandler),
+		}),
+		Tags: mergeTags(tags, pulumi.Sprintf("%s-vector-index", collectionName)),
+	}, parent, pulumi.DependsOn([]pulumi.Resource{basicExecution, indexPolicy}))
+	if err != nil {
+		return nil, err
+	}
+
+	indexName := collectionName + "-index"
+	input := collection.CollectionEndpoint.ApplyT(func(endpoint string) (string, error) {
+		data, err := json.Marshal(map[string]interface{}{
+			"endpoint": endpoint,
+			"index":    indexName,
+			"mappings": map[string]interface{}{
+				"properties": map[string]interface{}{
+					knowledgeBaseVectorField: map[string]interface{}{
+						"type":      "knn_vector",
+						"dimension": cfg.Dimensions,
+						"method":    map[string]interface{}{"name": "hnsw", "engine": "faiss", "space_type": "l2"},
+					},
+					knowledgeBaseTextField:     map[string]interface{}{"type": "text"},
+					knowledgeBaseMetadataField: map[string]interface{}{"type": "text", "index": false},
+				},
+			},
+		})
+		return string(data), err
+	}).(pulumi.StringOutput)
+	index, err := lambda.NewInvocation(ctx, name+"-index", &lambda.InvocationArgs{
+		FunctionName: function.Name,
+		Input:        input,
+	}, parent, pulumi.DependsOn([]pulumi.Resource{access}))
+	if err != nil {
+		return nil, err
+	}
+
+	description := cfg.Description
+	if description == "" {
+		description = fmt.Sprintf("%s knowledge base", collectionName)
+	}
+	knowledgeBase, err := bedrock.NewAgentKnowledgeBase(ctx, name+"-knowledge-base", &bedrock.AgentKnowledgeBaseArgs{
+		Name:        pulumi.String(collectionName),
+		Description: pulumi.String(description),
+		RoleArn:     kbRole.Arn,
+		KnowledgeBaseConfiguration: &bedrock.AgentKnowledgeBaseKnowledgeBaseConfigurationArgs{
+			Type: pulumi.String("VECTOR"),
+			VectorKnowledgeBaseConfiguration: &bedrock.AgentKnowledgeBaseKnowledgeBaseConfigurationVectorKnowledgeBaseConfigurationArgs{
+				EmbeddingModelArn: pulumi.String(embeddingModelArn),
+				EmbeddingModelConfiguration: &bedrock.AgentKnowledgeBaseKnowledgeBaseConfigurationVectorKnowledgeBaseConfigurationEmbeddingModelConfigurationArgs{
+					BedrockEmbeddingModelConfiguration: &bedrock.AgentKnowledgeBaseKnowledgeBaseConfigurationVectorKnowledgeBaseConfigurationEmbeddingModelConfigurationBedrockEmbeddingModelConfigurationArgs{
+						Dimensions: pulumi.Int(cfg.Dimensions),
+					},
+				},
+			},
+		},
+		StorageConfiguration: &bedrock.AgentKnowledgeBaseStorageConfigurationArgs{
+			Type: pulumi.String("OPENSEARCH_SERVERLESS"),
+			OpensearchServerlessConfiguration: &bedrock.AgentKnowledgeBaseStorageConfigurationOpensearchServerlessConfigurationArgs{
+				CollectionArn:   collection.Arn,
+				VectorIndexName: pulumi.String(indexName),
+				FieldMapping: &bedrock.AgentKnowledgeBaseStorageConfigurationOpensearchServerlessConfigurationFieldMappingArgs{
+					VectorField:   pulumi.String(knowledgeBaseVectorField),
+					TextField:     pulumi.String(knowledgeBaseTextField),
+					MetadataField: pulumi.String(knowledgeBaseMetadataField),
+				},
+			},
+		},
+		Tags: mergeTags(tags, pulumi.String(collectionName)),
+	}, parent, pulumi.DependsOn([]pulumi.Resource{index, kbPolicy}))
+	if err != nil {
+		return nil, err
+	}
+	kb.KnowledgeBaseID = knowledgeBase.ID().ToStringOutput()
+	kb.KnowledgeBaseArn = knowledgeBase.Arn
+
+	s3Config := &bedrock.AgentDataSourceDataSourceConfigurationS3ConfigurationArgs{
+		BucketArn: kb.BucketArn,
+	}
+	if len(cfg.InclusionPrefixes) > 0 {
+		s3Config.InclusionPrefixes = pulumi.ToStringArray(cfg.InclusionPrefixes)
+	}
+	dataSource, err := bedrock.NewAgentDataSource(ctx, name+"-data-source", &bedrock.AgentDataSourceArgs{
+		KnowledgeBaseId: kb.KnowledgeBaseID,
+		Name:            pulumi.Sprintf("%s-s3", collectionName),
+		DataSourceConfiguration: &bedrock.AgentDataSourceDataSourceConfigurationArgs{
+			Type:            pulumi.String("S3"),
+			S3Configuration: s3Config,
+		},
+	}, parent)
+	if err != nil {
+		return nil, err
+	}
+	kb.DataSourceID = dataSource.DataSourceId
+
+	if err := ctx.RegisterResourceOutputs(kb, pulumi.Map{
+		"knowledgeBaseId":  kb.KnowledgeBaseID,
+		"knowledgeBaseArn": kb.KnowledgeBaseArn,
+		"dataSourceId":     kb.DataSourceID,
+		"bucketArn":        kb.BucketArn,
+	}); err != nil {
+		return nil, err
+	}
+	return kb, nil
+}
+
+// createBucket creates a private, encrypted document bucket.
+func (kb *KnowledgeBase) createBucket(ctx *pulumi.Context, name, collectionName string, tags pulumi.StringMap, parent pulumi.ResourceOption) error {
+	bucket, err := s3.NewBucketV2(ctx, name+"-bucket", &s3.BucketV2Args{
+		BucketPrefix: pulumi.String(collectionName + "-"),
+		Tags:         mergeTags(tags, pulumi.Sprintf("%s-documents", collectionName)),
+	}, parent)
+	if err != nil {
+		return err
+	}
+	_, err = s3.NewBucketPublicAccessBlock(ctx, name+"-bucket-public-access-block", &s3.BucketPublicAccessBlockArgs{
+		Bucket:                bucket.ID(),
+		BlockPublicAcls:       pulumi.Bool(true),
+		BlockPublicPolicy:     pulumi.Bool(true),
+		IgnorePublicAcls:      pulumi.Bool(true),
+		RestrictPublicBuckets: pulumi.Bool(true),
+	}, parent)
+	if err != nil {
+		return err
+	}
+	_, err = s3.NewBucketServerSideEncryptionConfigurationV2(ctx, name+"-bucket-encryption", &s3.BucketServerSideEncryptionConfigurationV2Args{
+		Bucket: bucket.ID(),
+		Rules: s3.BucketServerSideEncryptionConfigurationV2RuleArray{
+			&s3.BucketServerSideEncryptionConfigurationV2RuleArgs{
+				ApplyServerSideEncryptionByDefault: &s3.BucketServerSideEncryptionConfigurationV2RuleApplyServerSideEncryptionByDefaultArgs{
+					SseAlgorithm: pulumi.String("AES256"),
+				},
+			},
+		},
+	}, parent)
+	if err != nil {
+		return err
+	}
+
+	kb.Bucket = bucket
+	kb.BucketArn = bucket.Arn
+	return nil
+}
+
+// grantKnowledgeBases grants each agent retrieval from its own knowledge
+// bases and injects their IDs into the agent.
+func (s *AgentCoreStack) grantKnowledgeBases(ctx *pulumi.Context) error {
+	for _, agent := range s.Config.Agents {
+		kbs := s.Options.Agents[agent.Name].KnowledgeBases
+		if len(kbs) == 0 {
+			continue
+		}
+		var ids []interface{}
+		var resources pulumi.StringArray
+		for _, kb := range kbs {
+			ids = append(ids, kb.KnowledgeBaseID)
+			resources = append(resources, kb.KnowledgeBaseArn)
+		}
+		s.injectEnv(agent.Name, "KNOWLEDGE_BASE_ID", kbs[0].KnowledgeBaseID)
+		s.injectEnv(agent.Name, "KNOWLEDGE_BASE_IDS", pulumi.All(ids...).ApplyT(func(ids []interface{}) string {
+			parts := make([]string, len(ids))
+			for i, id := range ids {
+				parts[i] = id.(string)
+			}
+			return strings.Join(parts, ",")
+		}).(pulumi.StringOutput))
+
+		err := s.attachAgentPolicy(ctx, agent.Name, "knowledge-bases-policy", policyStatement{
+			Actions:   []string{"bedrock:Retrieve", "bedrock:RetrieveAndGenerate"},
+			Resources: resources,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
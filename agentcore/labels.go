@@ -0,0 +1,77 @@
+package agentcore
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// labelTagPrefix prefixes the tag keys of agent labels.
+const labelTagPrefix = "agentkit:label:"
+
+// Well-known agent labels.
+const (
+	LabelOwner              = "owner"
+	LabelTier               = "tier"
+	LabelDataClassification = "dataClassification"
+)
+
+// labelKeyPattern matches label keys that are valid in tag keys after the
+// prefix.
+var labelKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_.:/=+@-]{1,113}$`)
+
+// labelValuePattern matches the characters AWS accepts in tag values.
+var labelValuePattern = regexp.MustCompile(`^[\p{L}\p{Z}\p{N}_.:/=+\-@]{0,256}$`)
+
+// validateLabels validates AgentOptions.Labels for the named agent.
+func validateLabels(agentName string, labels map[string]string) error {
+	for key, value := range labels {
+		if !labelKeyPattern.MatchString(key) {
+			return fmt.Errorf("agents[%s].labels: '%s' is not a valid label key", agentName, key)
+		}
+		if !labelValuePattern.MatchString(value) {
+			return fmt.Errorf("agents[%s].labels[%s]: '%s' is not a valid tag value", agentName, key, value)
+		}
+	}
+	return nil
+}
+
+// agentTags returns the tags of an agent's resources: the stack tags, the
+// agent name and the agent's labels.
+func (s *AgentCoreStack) agentTags(agentName string, tags pulumi.StringMap) pulumi.StringMap {
+	agentTags := pulumi.StringMap{agentTagKey: pulumi.String(agentName)}
+	for k, v := range tags {
+		agentTags[k] = v
+	}
+	for k, v := range s.Options.Agents[agentName].Labels {
+		agentTags[labelTagPrefix+k] = pulumi.String(v)
+	}
+	return agentTags
+}
+
+// exportAgentLabels exports the labels of the agents that have them.
+func (s *AgentCoreStack) exportAgentLabels(ctx *pulumi.Context) {
+	all := pulumi.Map{}
+	for _, agent := range s.Config.Agents {
+		labels := s.Options.Agents[agent.Name].Labels
+		if len(labels) == 0 {
+			continue
+		}
+		keys := make([]string, 0, len(labels))
+		for key := range labels {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		values := pulumi.StringMap{}
+		for _, key := range keys {
+			values[key] = pulumi.String(labels[key])
+			s.Outputs[fmt.Sprintf("agents.%s.labels.%s", agent.Name, key)] = pulumi.String(labels[key]).ToStringOutput()
+		}
+		all[agent.Name] = values
+	}
+	if len(all) > 0 {
+		s.export(ctx, "agentLabels", all)
+	}
+}
@@ -0,0 +1,553 @@
+package agentcore
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/apigatewayv2"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/appautoscaling"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/iam"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/lambda"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// agentContainerPort is the port AgentCore agent containers serve
+// /invocations and /ping on.
+const agentContainerPort = "8080"
+
+// LambdaTargetConfig deploys an agent's container image as a Lambda
+// function instead of an AgentCore runtime, for cheap, bursty agents. The
+// function gets the agent's environment and execution role, like a
+// runtime, and runs in the stack's VPC if it has one.
+//
+// Lambda invokes the image through the Lambda Web Adapter extension, which
+// forwards requests to the agent's HTTP server on port 8080; add it to the
+// image, e.g. with
+//
+//	COPY --from=public.ecr.aws/awsguru/aws-lambda-adapter:0.9.1 /lambda-adapter /opt/extensions/lambda-adapter
+//
+// The image must be in an ECR repository of the deployment region. Lambda
+// agents are invoked at their function URL or HTTP API with SigV4; they
+// cannot have replicas, blue/green deployments, schedules, subdomains,
+// runtime alarms or be used by workflows and work queues, which invoke
+// AgentCore runtimes.
+type LambdaTargetConfig struct {
+	// Architecture is the instruction set of the image.
+	// Supported: "x86_64", "arm64"
+	// Default: "x86_64"
+	Architecture string `json:"architecture,omitempty" yaml:"architecture,omitempty"`
+
+	// EphemeralStorageMB is the size of the function's /tmp.
+	// Range: 512-10240
+	// Default: 512
+	EphemeralStorageMB int `json:"ephemeralStorageMB,omitempty" yaml:"ephemeralStorageMB,omitempty"`
+
+	// Endpoint is how the function is invoked: a function URL, or a
+	// POST /invocations route of an HTTP API.
+	// Supported: "url", "api"
+	// Default: "url"
+	Endpoint string `json:"endpoint,omitempty" yaml:"endpoint,omitempty"`
+
+	// Capacity configures the function's concurrency.
+	// Optional.
+	Capacity *LambdaCapacityConfig `json:"capacity,omitempty" yaml:"capacity,omitempty"`
+}
+
+// Lambda capacity strategies.
+const (
+	CapacityOnDemand = "on-demand"
+	CapacitySpot     = "spot"
+)
+
+// functionAliasName is the alias Lambda agents with a base concurrency are
+// invoked through, which holds their provisioned concurrency.
+const functionAliasName = "live"
+
+// LambdaCapacityConfig configures the concurrency of a Lambda agent: a
+// base of initialized execution environments, and how the function gets
+// capacity beyond it.
+type LambdaCapacityConfig struct {
+	// Strategy is how the function gets capacity beyond its base. With
+	// "on-demand", MaxConcurrency is reserved for the function, so it is
+	// both guaranteed and capped. With "spot", for non-critical agents, the
+	// function draws on the account's unreserved concurrency, which it
+	// shares with other functions and loses to functions with reserved
+	// concurrency: under load it is throttled instead of crowding out
+	// critical agents. Lambda has no discounted spot capacity, and never
+	// interrupts running invocations, so there is nothing to drain.
+	// Supported: "on-demand", "spot"
+	// Default: "on-demand"
+	Strategy string `json:"strategy,omitempty" yaml:"strategy,omitempty"`
+
+	// BaseConcurrency is the number of execution environments kept
+	// initialized with provisioned concurrency, which are never throttled.
+	// With a base or schedules, the function is published and invoked
+	// through its live alias.
+	// Default: 0
+	BaseConcurrency int `json:"baseConcurrency,omitempty" yaml:"baseConcurrency,omitempty"`
+
+	// MaxConcurrency is the concurrency reserved for the function with the
+	// on-demand strategy. Zero leaves the function unreserved.
+	// Default: 0
+	MaxConcurrency int `json:"maxConcurrency,omitempty" yaml:"maxConcurrency,omitempty"`
+
+	// Schedules change the provisioned concurrency at scheduled times,
+	// e.g. to keep 3 execution environments during business hours and none
+	// otherwise. BaseConcurrency applies until the first schedule runs.
+	Schedules []CapacitySchedule `json:"schedules,omitempty" yaml:"schedules,omitempty"`
+}
+
+// CapacitySchedule sets a Lambda agent's provisioned concurrency at
+// scheduled times, with an Application Auto Scaling scheduled action on
+// the function's live alias.
+type CapacitySchedule struct {
+	// Name identifies the schedule within the agent.
+	// Default: "schedule-{n}", numbered from 1
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+
+	// Expression is an Application Auto Scaling schedule expression, e.g.
+	// "cron(0 9 ? * MON-FRI *)".
+	Expression string `json:"expression" yaml:"expression"`
+
+	// Timezone is the IANA timezone for cron schedules.
+	// Default: "UTC"
+	Timezone string `json:"timezone,omitempty" yaml:"timezone,omitempty"`
+
+	// MinConcurrency is the provisioned concurrency from the scheduled time.
+	MinConcurrency int `json:"minConcurrency" yaml:"minConcurrency"`
+
+	// MaxConcurrency is the upper bound of the provisioned concurrency.
+	// Default: MinConcurrency
+	MaxConcurrency int `json:"maxConcurrency,omitempty" yaml:"maxConcurrency,omitempty"`
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *LambdaCapacityConfig) ApplyDefaults() {
+	if c.Strategy == "" {
+		c.Strategy = CapacityOnDemand
+	}
+	for i := range c.Schedules {
+		schedule := &c.Schedules[i]
+		if schedule.Name == "" {
+			schedule.Name = fmt.Sprintf("schedule-%d", i+1)
+		}
+		if schedule.Timezone == "" {
+			schedule.Timezone = "UTC"
+		}
+		if schedule.MaxConcurrency == 0 {
+			schedule.MaxConcurrency = schedule.MinConcurrency
+		}
+	}
+}
+
+// Validate validates the LambdaCapacityConfig for the named agent.
+func (c *LambdaCapacityConfig) Validate(agentName string) error {
+	field := fmt.Sprintf("agents[%s].lambda.capacity", agentName)
+	if c.Strategy != CapacityOnDemand && c.Strategy != CapacitySpot {
+		return fmt.Errorf("%s.strategy must be one of [on-demand spot]", field)
+	}
+	if c.BaseConcurrency < 0 {
+		return fmt.Errorf("%s.baseConcurrency must not be negative", field)
+	}
+	if c.MaxConcurrency < 0 {
+		return fmt.Errorf("%s.maxConcurrency must not be negative", field)
+	}
+	if c.Strategy == CapacitySpot && c.MaxConcurrency > 0 {
+		return fmt.Errorf("%s.maxConcurrency reserves concurrency and requires strategy on-demand", field)
+	}
+	if c.MaxConcurrency > 0 && c.BaseConcurrency > c.MaxConcurrency {
+		return fmt.Errorf("%s.baseConcurrency must not exceed maxConcurrency", field)
+	}
+	names := make(map[string]bool)
+	for _, schedule := range c.Schedules {
+		if !scheduleNamePattern.MatchString(schedule.Name) {
+			return fmt.Errorf("%s.schedules: name %q must match %s", field, schedule.Name, scheduleNamePattern)
+		}
+		if names[schedule.Name] {
+			return fmt.Errorf("%s.schedules: duplicate name %q", field, schedule.Name)
+		}
+		names[schedule.Name] = true
+		scheduleField := fmt.Sprintf("%s.schedules[%s]", field, schedule.Name)
+		if !scheduleExpressionPattern.MatchString(schedule.Expression) {
+			return fmt.Errorf("%s.expression: '%s' is not a cron, rate or at expression", scheduleField, schedule.Expression)
+		}
+		if schedule.MinConcurrency < 0 || schedule.MaxConcurrency < schedule.MinConcurrency {
+			return fmt.Errorf("%s: minConcurrency must not be negative or exceed maxConcurrency", scheduleField)
+		}
+		// Provisioned concurrency comes out of the reserved concurrency
+		if c.MaxConcurrency > 0 && schedule.MaxConcurrency > c.MaxConcurrency {
+			return fmt.Errorf("%s.maxConcurrency must not exceed %s.maxConcurrency", scheduleField, field)
+		}
+	}
+	return nil
+}
+
+// maxProvisioned returns the largest provisioned concurrency of the base
+// and the schedules.
+func (c *LambdaCapacityConfig) maxProvisioned() int {
+	result := c.BaseConcurrency
+	for _, schedule := range c.Schedules {
+		result = max(result, schedule.MaxConcurrency)
+	}
+	return result
+}
+
+// aliased reports whether the function is invoked through its live alias.
+func (c *LambdaCapacityConfig) aliased() bool {
+	return c != nil && (c.BaseConcurrency > 0 || len(c.Schedules) > 0)
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *LambdaTargetConfig) ApplyDefaults() {
+	if c.Architecture == "" {
+		c.Architecture = "x86_64"
+	}
+	if c.EphemeralStorageMB == 0 {
+		c.EphemeralStorageMB = 512
+	}
+	if c.Endpoint == "" {
+		c.Endpoint = "url"
+	}
+	if c.Capacity != nil {
+		c.Capacity.ApplyDefaults()
+	}
+}
+
+// Validate validates the LambdaTargetConfig for the named agent.
+func (c *LambdaTargetConfig) Validate(agentName string) error {
+	if c.Architecture != "x86_64" && c.Architecture != "arm64" {
+		return fmt.Errorf("agents[%s].lambda.architecture must be one of [x86_64 arm64]", agentName)
+	}
+	if c.EphemeralStorageMB < 512 || c.EphemeralStorageMB > 10240 {
+		return fmt.Errorf("agents[%s].lambda.ephemeralStorageMB must be between 512 and 10240", agentName)
+	}
+	if c.Endpoint != "url" && c.Endpoint != "api" {
+		return fmt.Errorf("agents[%s].lambda.endpoint must be one of [url api]", agentName)
+	}
+	if c.Capacity != nil {
+		if err := c.Capacity.Validate(agentName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AgentFunction holds the Lambda function of an agent deployed with
+// AgentOptions.Lambda.
+type AgentFunction struct {
+	// Function is the agent's Lambda function.
+	Function *lambda.Function
+
+	// Alias is the live alias the function is invoked through (nil unless
+	// the agent has a base concurrency).
+	Alias *lambda.Alias
+
+	// InvokeURL is the URL callers POST to: the function URL, or the
+	// /invocations route of the agent's HTTP API.
+	InvokeURL pulumi.StringOutput
+}
+
+// runtimeTarget returns the runtime target the agent deploys to.
+func (a AgentOptions) runtimeTarget() string {
+	if a.Lambda != nil {
+		return RuntimeTargetLambda
+	}
+	return RuntimeTargetAgentCore
+}
+
+// isLambdaAgent reports whether the agent is deployed as a Lambda function.
+func (s *AgentCoreStack) isLambdaAgent(agentName string) bool {
+	return s.Options.Agents[agentName].Lambda != nil
+}
+
+// runtimeAgents returns the agents in the selection that run on AgentCore,
+// or all of them if the selection is empty.
+func (s *AgentCoreStack) runtimeAgents(names []string) []string {
+	var agents []string
+	for _, name := range s.selectedAgents(names) {
+		if !s.isLambdaAgent(name) {
+			agents = append(agents, name)
+		}
+	}
+	return agents
+}
+
+// lambdaAdapterEnv returns the Lambda Web Adapter settings of Lambda agents.
+func lambdaAdapterEnv() pulumi.StringMap {
+	return pulumi.StringMap{
+		"AWS_LWA_PORT":                 pulumi.String(agentContainerPort),
+		"AWS_LWA_READINESS_CHECK_PATH": pulumi.String("/ping"),
+	}
+}
+
+// validateLambdaAgents checks that Lambda agents are not used by options
+// that need an AgentCore runtime.
+func validateLambdaAgents(o *Options, config iac.StackConfig) error {
+	for _, agent := range config.Agents {
+		a := o.Agents[agent.Name]
+		if a.Lambda == nil {
+			continue
+		}
+		if !ecrImagePattern.MatchString(agent.ContainerImage) {
+			return fmt.Errorf("agents[%s].lambda: containerImage must be an ECR image URI", agent.Name)
+		}
+		switch {
+		case a.Replicas > 1:
+			return fmt.Errorf("agents[%s].lambda cannot be combined with replicas", agent.Name)
+		case a.BlueGreen != nil:
+			return fmt.Errorf("agents[%s].lambda cannot be combined with blueGreen", agent.Name)
+		case len(a.Schedules) > 0:
+			return fmt.Errorf("agents[%s].lambda cannot be combined with schedules", agent.Name)
+		}
+
+		if o.Alarms != nil && slices.Contains(o.Alarms.Agents, agent.Name) {
+			return fmt.Errorf("alarms.agents: '%s' is a Lambda agent", agent.Name)
+		}
+		if o.AgentSubdomains != nil && slices.Contains(o.AgentSubdomains.Agents, agent.Name) {
+			return fmt.Errorf("agentSubdomains.agents: '%s' is a Lambda agent", agent.Name)
+		}
+		if o.AppSync != nil && slices.Contains(o.AppSync.InvokeAgents, agent.Name) && o.AppSync.RuntimeARNs[agent.Name] == "" {
+			return fmt.Errorf("appSync.invokeAgents: '%s' is a Lambda agent", agent.Name)
+		}
+		if o.Reports != nil && o.Reports.Agent == agent.Name && o.Reports.RuntimeARN == "" {
+			return fmt.Errorf("reports.agent: '%s' is a Lambda agent", agent.Name)
+		}
+		if o.WorkQueue != nil && slices.Contains(o.WorkQueue.Agents, agent.Name) {
+			return fmt.Errorf("workQueue.agents: '%s' is a Lambda agent", agent.Name)
+		}
+		for _, workflow := range o.Workflows {
+			for _, step := range workflow.Steps {
+				if step.Agent == agent.Name {
+					return fmt.Errorf("workflows[%s].steps[%s].agent: '%s' is a Lambda agent", workflow.Name, step.Name, agent.Name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// createFunctionCapacity provisions concurrency on a Lambda agent's live
+// alias: its base concurrency, and the scheduled actions that change it.
+func (s *AgentCoreStack) createFunctionCapacity(ctx *pulumi.Context, agentName, functionName string, alias *lambda.Alias) error {
+	cfg := s.Options.Agents[agentName].Lambda.Capacity
+
+	// Scheduled actions own the provisioned concurrency after creation
+	var provisioned pulumi.Resource
+	if cfg.BaseConcurrency > 0 {
+		opts := []pulumi.ResourceOption{s.child()}
+		if len(cfg.Schedules) > 0 {
+			opts = append(opts, pulumi.IgnoreChanges([]string{"provisionedConcurrentExecutions"}))
+		}
+		config, err := lambda.NewProvisionedConcurrencyConfig(ctx, s.ResourceName(fmt.Sprintf("%s-function-concurrency", agentName)), &lambda.ProvisionedConcurrencyConfigArgs{
+			FunctionName:                    alias.FunctionName,
+			Qualifier:                       alias.Name,
+			ProvisionedConcurrentExecutions: pulumi.Int(cfg.BaseConcurrency),
+		}, opts...)
+		if err != nil {
+			return err
+		}
+		provisioned = config
+	}
+	if len(cfg.Schedules) == 0 {
+		return nil
+	}
+
+	targetOpts := []pulumi.ResourceOption{s.child()}
+	if provisioned != nil {
+		targetOpts = append(targetOpts, pulumi.DependsOn([]pulumi.Resource{provisioned}))
+	}
+	target, err := appautoscaling.NewTarget(ctx, s.ResourceName(fmt.Sprintf("%s-function-capacity", agentName)), &appautoscaling.TargetArgs{
+		ServiceNamespace:  pulumi.String("lambda"),
+		ScalableDimension: pulumi.String("lambda:function:ProvisionedConcurrency"),
+		ResourceId:        pulumi.Sprintf("function:%s:%s", functionName, alias.Name),
+		MinCapacity:       pulumi.Int(cfg.BaseConcurrency),
+		MaxCapacity:       pulumi.Int(cfg.maxProvisioned()),
+	}, targetOpts...)
+	if err != nil {
+		return err
+	}
+	for _, schedule := range cfg.Schedules {
+		_, err := appautoscaling.NewScheduledAction(ctx, s.ResourceName(fmt.Sprintf("%s-capacity-%s", agentName, schedule.Name)), &appautoscaling.ScheduledActionArgs{
+			Name:              pulumi.Sprintf("%s-%s", functionName, schedule.Name),
+			ServiceNamespace:  target.ServiceNamespace,
+			ScalableDimension: target.ScalableDimension,
+			ResourceId:        target.ResourceId,
+			Schedule:          pulumi.String(schedule.Expression),
+			Timezone:          pulumi.String(schedule.Timezone),
+			ScalableTargetAction: &appautoscaling.ScheduledActionScalableTargetActionArgs{
+				MinCapacity: pulumi.Int(schedule.MinConcurrency),
+				MaxCapacity: pulumi.Int(schedule.MaxConcurrency),
+			},
+		}, s.child())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createAgentFunction creates the Lambda function of an agent deployed with
+// AgentOptions.Lambda, and its function URL or HTTP API.
+func (s *AgentCoreStack) createAgentFunction(ctx *pulumi.Context, agent iac.AgentConfig, tags pulumi.StringMap) (*AgentFunction, error) {
+	stackName := s.Config.StackName
+	cfg := s.Options.Agents[agent.Name].Lambda
+	agentTags := s.agentTags(agent.Name, tags)
+	functionName := fmt.Sprintf("%s-%s", stackName, agent.Name)
+
+	description := agent.Description
+	if description == "" {
+		description = fmt.Sprintf("%s agent in %s", agent.Name, stackName)
+	}
+
+	env := s.agentEnvironment(agent.Name)
+	for k, v := range lambdaAdapterEnv() {
+		env[k] = v
+	}
+
+	timeout := agent.TimeoutSeconds
+	if timeout == 0 {
+		timeout = 300
+	}
+	memory := agent.MemoryMB
+	if memory == 0 {
+		memory = 1024
+	}
+
+	args := &lambda.FunctionArgs{
+		Name:          pulumi.String(functionName),
+		Description:   pulumi.String(description),
+		Role:          s.AgentRoles[agent.Name].Arn,
+		PackageType:   pulumi.String("Image"),
+		ImageUri:      pulumi.String(agent.ContainerImage),
+		Architectures: pulumi.StringArray{pulumi.String(cfg.Architecture)},
+		MemorySize:    pulumi.Int(memory),
+		Timeout:       pulumi.Int(timeout),
+		EphemeralStorage: &lambda.FunctionEphemeralStorageArgs{
+			Size: pulumi.Int(cfg.EphemeralStorageMB),
+		},
+		Environment: &lambda.FunctionEnvironmentArgs{
+			Variables: env,
+		},
+		Tags: mergeTags(agentTags, pulumi.String(functionName)),
+	}
+	if capacity := cfg.Capacity; capacity != nil {
+		args.Publish = pulumi.Bool(capacity.aliased())
+		if capacity.Strategy == CapacityOnDemand && capacity.MaxConcurrency > 0 {
+			args.ReservedConcurrentExecutions = pulumi.Int(capacity.MaxConcurrency)
+		}
+	}
+	if logGroup, ok := env["LOG_GROUP_NAME"]; ok {
+		args.LoggingConfig = &lambda.FunctionLoggingConfigArgs{
+			LogFormat: pulumi.String("Text"),
+			LogGroup:  logGroup,
+		}
+	}
+
+	dependsOn := slices.Clone(s.Options.DependsOn)
+	if hasVPC(s.Config) {
+		args.VpcConfig = &lambda.FunctionVpcConfigArgs{
+			SubnetIds:        s.privateSubnetIDs(),
+			SecurityGroupIds: pulumi.StringArray{s.SecurityGroup.ID()},
+		}
+		attachment, err := iam.NewRolePolicyAttachment(ctx, s.ResourceName(agent.Name+"-execution-lambda-vpc-access"), &iam.RolePolicyAttachmentArgs{
+			Role:      s.AgentRoles[agent.Name].Name,
+			PolicyArn: pulumi.String("arn:aws:iam::aws:policy/service-role/AWSLambdaVPCAccessExecutionRole"),
+		}, s.child())
+		if err != nil {
+			return nil, err
+		}
+		dependsOn = append(dependsOn, attachment)
+	}
+
+	function, err := lambda.NewFunction(ctx, s.ResourceName(fmt.Sprintf("%s-function", agent.Name)), args,
+		pulumi.DependsOn(dependsOn), s.child())
+	if err != nil {
+		return nil, err
+	}
+	result := &AgentFunction{Function: function}
+
+	// Invoke the live alias, which holds the provisioned concurrency
+	var qualifier pulumi.StringPtrInput
+	invokeArn := function.InvokeArn
+	if cfg.Capacity.aliased() {
+		result.Alias, err = lambda.NewAlias(ctx, s.ResourceName(fmt.Sprintf("%s-function-alias", agent.Name)), &lambda.AliasArgs{
+			Name:            pulumi.String(functionAliasName),
+			Description:     pulumi.Sprintf("Deployed version of %s", agent.Name),
+			FunctionName:    function.Name,
+			FunctionVersion: function.Version,
+		}, s.child())
+		if err != nil {
+			return nil, err
+		}
+		if err := s.createFunctionCapacity(ctx, agent.Name, functionName, result.Alias); err != nil {
+			return nil, err
+		}
+		qualifier = result.Alias.Name
+		invokeArn = result.Alias.InvokeArn
+	}
+
+	if cfg.Endpoint == "url" {
+		url, err := lambda.NewFunctionUrl(ctx, s.ResourceName(fmt.Sprintf("%s-function-url", agent.Name)), &lambda.FunctionUrlArgs{
+			FunctionName:      function.Name,
+			Qualifier:         qualifier,
+			AuthorizationType: pulumi.String("AWS_IAM"),
+		}, s.child())
+		if err != nil {
+			return nil, err
+		}
+		result.InvokeURL = pulumi.Sprintf("%sinvocations", url.FunctionUrl)
+		return result, nil
+	}
+
+	name := fmt.Sprintf("%s-function", agent.Name)
+	api, err := apigatewayv2.NewApi(ctx, s.ResourceName(name+"-api"), &apigatewayv2.ApiArgs{
+		Name:         pulumi.String(functionName),
+		Description:  pulumi.String(description),
+		ProtocolType: pulumi.String("HTTP"),
+		Tags:         mergeTags(agentTags, pulumi.String(functionName)),
+	}, s.child())
+	if err != nil {
+		return nil, err
+	}
+	_, err = lambda.NewPermission(ctx, s.ResourceName(name+"-api-permission"), &lambda.PermissionArgs{
+		Function:  function.Name,
+		Action:    pulumi.String("lambda:InvokeFunction"),
+		Principal: pulumi.String("apigateway.amazonaws.com"),
+		Qualifier: qualifier,
+		SourceArn: pulumi.Sprintf("%s/*", api.ExecutionArn),
+	}, s.child())
+	if err != nil {
+		return nil, err
+	}
+	integration, err := apigatewayv2.NewIntegration(ctx, s.ResourceName(name+"-integration"), &apigatewayv2.IntegrationArgs{
+		ApiId:                api.ID(),
+		IntegrationType:      pulumi.String("AWS_PROXY"),
+		IntegrationUri:       invokeArn,
+		PayloadFormatVersion: pulumi.String("2.0"),
+		TimeoutMilliseconds:  pulumi.Int(30000),
+	}, s.child())
+	if err != nil {
+		return nil, err
+	}
+	_, err = apigatewayv2.NewRoute(ctx, s.ResourceName(name+"-route"), &apigatewayv2.RouteArgs{
+		ApiId:             api.ID(),
+		RouteKey:          pulumi.String("POST /invocations"),
+		AuthorizationType: pulumi.String("AWS_IAM"),
+		Target:            pulumi.Sprintf("integrations/%s", integration.ID()),
+	}, s.child())
+	if err != nil {
+		return nil, err
+	}
+	_, err = apigatewayv2.NewStage(ctx, s.ResourceName(name+"-stage"), &apigatewayv2.StageArgs{
+		ApiId:      api.ID(),
+		Name:       pulumi.String("$default"),
+		AutoDeploy: pulumi.Bool(true),
+		Tags:       mergeTags(agentTags, pulumi.String(functionName)),
+	}, s.child())
+	if err != nil {
+		return nil, err
+	}
+	result.InvokeURL = pulumi.Sprintf("%s/invocations", api.ApiEndpoint)
+	return result, nil
+}
@@ -0,0 +1,322 @@
+package agentcore
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/ecr"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// Runtime targets agents deploy to.
+const (
+	// RuntimeTargetAgentCore is AgentCore Runtime, the default target.
+	RuntimeTargetAgentCore = "agentcore"
+
+	// RuntimeTargetLambda is a Lambda function, for agents with
+	// AgentOptions.Lambda.
+	RuntimeTargetLambda = "lambda"
+)
+
+// RuntimeLimits are the platform limits of a runtime target. Zero means the
+// target has no such limit.
+type RuntimeLimits struct {
+	// MaxEnvironmentVariables is the number of environment variables.
+	MaxEnvironmentVariables int
+
+	// MaxEnvironmentKeyBytes is the length of an environment variable name.
+	MaxEnvironmentKeyBytes int
+
+	// MaxEnvironmentValueBytes is the length of an environment variable
+	// value.
+	MaxEnvironmentValueBytes int
+
+	// MaxEnvironmentBytes is the total length of the environment variable
+	// names and values.
+	MaxEnvironmentBytes int
+
+	// MaxSecrets is the number of secrets granted to an agent, which keeps
+	// its execution role's policies within the IAM size limit.
+	MaxSecrets int
+
+	// MaxImageBytes is the compressed size of a container image.
+	MaxImageBytes int64
+
+	// MaxTimeoutSeconds is the longest agent timeout.
+	MaxTimeoutSeconds int
+
+	// MemoryMB are the supported memory allocations.
+	MemoryMB []int
+
+	// MaxMemoryMB is the largest memory allocation of targets that support
+	// any allocation from 128 MB up to it, instead of MemoryMB.
+	MaxMemoryMB int
+
+	// FixedResources reports that the target sizes agents and limits their
+	// requests itself, so agents keep the MemoryMB and TimeoutSeconds that
+	// StackConfig.ApplyDefaults sets.
+	FixedResources bool
+}
+
+// Agent settings StackConfig.ApplyDefaults sets.
+const (
+	defaultAgentMemoryMB       = 512
+	defaultAgentTimeoutSeconds = 300
+)
+
+// RuntimeLimitTables are the limits of each runtime target. Update an entry
+// when AWS raises a limit, or override it for accounts with raised quotas.
+var RuntimeLimitTables = map[string]RuntimeLimits{
+	RuntimeTargetAgentCore: {
+		MaxEnvironmentVariables:  50,
+		MaxEnvironmentKeyBytes:   100,
+		MaxEnvironmentValueBytes: 5000,
+		MaxEnvironmentBytes:      16384,
+		MaxSecrets:               25,
+		MaxImageBytes:            2 << 30,
+		FixedResources:           true,
+	},
+	RuntimeTargetLambda: {
+		MaxEnvironmentBytes: 4096,
+		MaxSecrets:          25,
+		MaxImageBytes:       10 << 30,
+		MaxTimeoutSeconds:   900,
+		MaxMemoryMB:         10240,
+	},
+}
+
+// ecrImagePattern matches ECR image URIs, capturing the registry, region,
+// repository, tag and digest.
+var ecrImagePattern = regexp.MustCompile(`^([0-9]{12})\.dkr\.ecr\.([a-z0-9-]+)\.amazonaws\.com/([^:@]+)(?::([^@]+))?(?:@(.+))?$`)
+
+// LimitViolation is a setting that exceeds a runtime target's limits.
+type LimitViolation struct {
+	// Agent is the agent, or empty for stack-wide limits.
+	Agent string
+
+	// Limit is the exceeded RuntimeLimits field.
+	Limit string
+
+	// Detail explains the violation.
+	Detail string
+}
+
+// LimitError reports settings that exceed a runtime target's limits.
+type LimitError struct {
+	// Target is the runtime target.
+	Target string
+
+	// Violations are the exceeded limits.
+	Violations []LimitViolation
+}
+
+// Error implements the error interface.
+func (e *LimitError) Error() string {
+	lines := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		if v.Agent == "" {
+			lines = append(lines, fmt.Sprintf("  - %s: %s", v.Limit, v.Detail))
+		} else {
+			lines = append(lines, fmt.Sprintf("  - agents[%s] %s: %s", v.Agent, v.Limit, v.Detail))
+		}
+	}
+	return fmt.Sprintf("%s limits exceeded:\n%s", e.Target, strings.Join(lines, "\n"))
+}
+
+// errorOrNil returns the error if it has violations.
+func (e *LimitError) errorOrNil() error {
+	if len(e.Violations) == 0 {
+		return nil
+	}
+	return e
+}
+
+// checkEnvironment checks an agent's environment variables. Values that are
+// not known until deployment count only their names toward the total.
+func (l RuntimeLimits) checkEnvironment(agentName string, env pulumi.StringMap, result *LimitError) {
+	if l.MaxEnvironmentVariables > 0 && len(env) > l.MaxEnvironmentVariables {
+		result.Violations = append(result.Violations, LimitViolation{agentName, "MaxEnvironmentVariables",
+			fmt.Sprintf("%d environment variables, at most %d are allowed", len(env), l.MaxEnvironmentVariables)})
+	}
+
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	total := 0
+	for _, key := range keys {
+		total += len(key)
+		if l.MaxEnvironmentKeyBytes > 0 && len(key) > l.MaxEnvironmentKeyBytes {
+			result.Violations = append(result.Violations, LimitViolation{agentName, "MaxEnvironmentKeyBytes",
+				fmt.Sprintf("%s is %d bytes, at most %d are allowed", key, len(key), l.MaxEnvironmentKeyBytes)})
+		}
+		value, ok := env[key].(pulumi.String)
+		if !ok {
+			continue
+		}
+		total += len(value)
+		if l.MaxEnvironmentValueBytes > 0 && len(value) > l.MaxEnvironmentValueBytes {
+			result.Violations = append(result.Violations, LimitViolation{agentName, "MaxEnvironmentValueBytes",
+				fmt.Sprintf("the value of %s is %d bytes, at most %d are allowed", key, len(value), l.MaxEnvironmentValueBytes)})
+		}
+	}
+	if l.MaxEnvironmentBytes > 0 && total > l.MaxEnvironmentBytes {
+		result.Violations = append(result.Violations, LimitViolation{agentName, "MaxEnvironmentBytes",
+			fmt.Sprintf("environment is %d bytes, at most %d are allowed", total, l.MaxEnvironmentBytes)})
+	}
+}
+
+// validateRuntimeLimits checks the agents' configured settings against the
+// limits of their runtime targets.
+func validateRuntimeLimits(config iac.StackConfig, agents map[string]AgentOptions) error {
+	for _, target := range []string{RuntimeTargetAgentCore, RuntimeTargetLambda} {
+		limits := RuntimeLimitTables[target]
+		result := &LimitError{Target: target}
+		for _, agent := range config.Agents {
+			if agents[agent.Name].runtimeTarget() != target {
+				continue
+			}
+			if limits.FixedResources {
+				limits.checkFixedResources(agent, result)
+			} else {
+				if agent.TimeoutSeconds > limits.MaxTimeoutSeconds {
+					result.Violations = append(result.Violations, LimitViolation{agent.Name, "MaxTimeoutSeconds",
+						fmt.Sprintf("timeoutSeconds is %d, at most %d is allowed", agent.TimeoutSeconds, limits.MaxTimeoutSeconds)})
+				}
+				limits.checkMemory(agent.Name, agent.MemoryMB, result)
+			}
+			limits.checkEnvironment(agent.Name, pulumi.ToStringMap(agent.Environment), result)
+			if secrets := len(slices.Compact(slices.Sorted(slices.Values(agent.SecretsARNs)))); secrets > limits.MaxSecrets {
+				result.Violations = append(result.Violations, LimitViolation{agent.Name, "MaxSecrets",
+					fmt.Sprintf("agent is granted %d secrets, at most %d are allowed", secrets, limits.MaxSecrets)})
+			}
+		}
+		if err := result.errorOrNil(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkFixedResources checks that an agent on a target that sizes agents
+// itself keeps the default memory and timeout; zero is the default.
+func (l RuntimeLimits) checkFixedResources(agent iac.AgentConfig, result *LimitError) {
+	if agent.MemoryMB != 0 && agent.MemoryMB != defaultAgentMemoryMB {
+		result.Violations = append(result.Violations, LimitViolation{agent.Name, "FixedResources",
+			fmt.Sprintf("memoryMB is %d, but AgentCore runtimes have no memory setting; deploy the agent to Lambda to size it", agent.MemoryMB)})
+	}
+	if agent.TimeoutSeconds != 0 && agent.TimeoutSeconds != defaultAgentTimeoutSeconds {
+		result.Violations = append(result.Violations, LimitViolation{agent.Name, "FixedResources",
+			fmt.Sprintf("timeoutSeconds is %d, but AgentCore runtimes have no request timeout setting; deploy the agent to Lambda to limit it", agent.TimeoutSeconds)})
+	}
+}
+
+// checkMemory checks an agent's memory allocation; zero is the default.
+func (l RuntimeLimits) checkMemory(agentName string, memoryMB int, result *LimitError) {
+	switch {
+	case memoryMB == 0:
+	case l.MaxMemoryMB > 0:
+		if memoryMB < 128 || memoryMB > l.MaxMemoryMB {
+			result.Violations = append(result.Violations, LimitViolation{agentName, "MaxMemoryMB",
+				fmt.Sprintf("memoryMB is %d, must be between 128 and %d", memoryMB, l.MaxMemoryMB)})
+		}
+	case !containsInt(l.MemoryMB, memoryMB):
+		result.Violations = append(result.Violations, LimitViolation{agentName, "MemoryMB",
+			fmt.Sprintf("memoryMB is %d, must be one of %v", memoryMB, l.MemoryMB)})
+	}
+}
+
+// checkImageSizes checks the size of agent images in ECR repositories of the
+// deployment region. Images that are not pushed yet or that are in other
+// registries are not checked.
+func (s *AgentCoreStack) checkImageSizes(ctx *pulumi.Context) error {
+	region, err := aws.GetRegion(ctx, nil, s.invoke())
+	if err != nil {
+		return err
+	}
+	results := make(map[string]*LimitError)
+	for _, agent := range s.Config.Agents {
+		target := s.Options.Agents[agent.Name].runtimeTarget()
+		limits := RuntimeLimitTables[target]
+		if results[target] == nil {
+			results[target] = &LimitError{Target: target}
+		}
+		result := results[target]
+
+		m := ecrImagePattern.FindStringSubmatch(agent.ContainerImage)
+		if m == nil || m[2] != region.Name {
+			continue
+		}
+		args := &ecr.GetImageArgs{RegistryId: &m[1], RepositoryName: m[3]}
+		switch {
+		case m[5] != "":
+			args.ImageDigest = &m[5]
+		case m[4] != "":
+			args.ImageTag = &m[4]
+		default:
+			latest := "latest"
+			args.ImageTag = &latest
+		}
+		image, err := ecr.GetImage(ctx, args, s.invoke())
+		if err != nil {
+			continue
+		}
+		if size := int64(image.ImageSizeInBytes); size > limits.MaxImageBytes {
+			result.Violations = append(result.Violations, LimitViolation{agent.Name, "MaxImageBytes",
+				fmt.Sprintf("%s is %d bytes, at most %d are allowed", agent.ContainerImage, size, limits.MaxImageBytes)})
+		}
+	}
+	for _, target := range []string{RuntimeTargetAgentCore, RuntimeTargetLambda} {
+		if result := results[target]; result != nil {
+			if err := result.errorOrNil(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkAgentEnvironments checks the agents' environments, including the
+// variables injected by stack components, before the runtimes are created.
+func (s *AgentCoreStack) checkAgentEnvironments() error {
+	for _, target := range []string{RuntimeTargetAgentCore, RuntimeTargetLambda} {
+		limits := RuntimeLimitTables[target]
+		result := &LimitError{Target: target}
+		for _, agent := range s.Config.Agents {
+			if s.Options.Agents[agent.Name].runtimeTarget() != target {
+				continue
+			}
+			env := s.agentEnvironment(agent.Name)
+			if s.Options.Agents[agent.Name].Replicas > 1 {
+				env["AGENT_REPLICA"] = pulumi.String("0")
+			}
+			if target == RuntimeTargetLambda {
+				for k, v := range lambdaAdapterEnv() {
+					env[k] = v
+				}
+			}
+			limits.checkEnvironment(agent.Name, env, result)
+		}
+		if err := result.errorOrNil(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// containsInt reports whether values contains v.
+func containsInt(values []int, v int) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
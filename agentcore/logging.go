@@ -0,0 +1,316 @@
+package agentcore
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/cloudwatch"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/iam"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/kinesis"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/s3"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// logRetentionDays are the retention periods CloudWatch Logs accepts.
+var logRetentionDays = []int{1, 3, 5, 7, 14, 30, 60, 90, 120, 150, 180, 365, 400, 545, 731, 1096, 1827, 2192, 2557, 2922, 3288, 3653}
+
+// validateLogRetention checks that days is a retention period CloudWatch Logs accepts.
+func validateLogRetention(field string, days int) error {
+	for _, valid := range logRetentionDays {
+		if days == valid {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s must be one of %v", field, logRetentionDays)
+}
+
+// agentLogGroupName returns the name of an agent's log group.
+func agentLogGroupName(stackName, agentName string) string {
+	return fmt.Sprintf("/aws/agentcore/%s/%s", stackName, agentName)
+}
+
+// logRetention returns the stack's log retention in days.
+func (s *AgentCoreStack) logRetention() int {
+	if s.Config.Observability != nil && s.Config.Observability.LogRetentionDays != 0 {
+		return s.Config.Observability.LogRetentionDays
+	}
+	return 30
+}
+
+// hasAgentLogGroup reports whether the agent gets its own log group, either
+// because all agents do or because it overrides the retention.
+func (s *AgentCoreStack) hasAgentLogGroup(agentName string) bool {
+	return s.Options.PerAgentLogGroups || s.Options.Agents[agentName].LogRetentionDays != 0
+}
+
+// hasAgentLogGroups reports whether any agent gets its own log group.
+func (s *AgentCoreStack) hasAgentLogGroups() bool {
+	for _, agent := range s.Config.Agents {
+		if s.hasAgentLogGroup(agent.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// createAgentLogGroups creates a log group with its own retention for each
+// agent that has one, and limits write access to each group to its agent.
+// Other agents fall back to the shared log group.
+func (s *AgentCoreStack) createAgentLogGroups(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	stackName := s.Config.StackName
+
+	groupArns := make(map[string]pulumi.StringOutput)
+	for _, agent := range s.Config.Agents {
+		if !s.hasAgentLogGroup(agent.Name) {
+			continue
+		}
+		retention := s.logRetention()
+		if days := s.Options.Agents[agent.Name].LogRetentionDays; days != 0 {
+			retention = days
+		}
+
+		name := agentLogGroupName(stackName, agent.Name)
+		group, err := cloudwatch.NewLogGroup(ctx, s.ResourceName(fmt.Sprintf("log-group-%s", agent.Name)), &cloudwatch.LogGroupArgs{
+			Name:            pulumi.String(name),
+			RetentionInDays: pulumi.Int(retention),
+			KmsKeyId:        s.kmsKeyID(),
+			Tags:            mergeTags(s.agentTags(agent.Name, tags), pulumi.Sprintf("%s-%s-logs", stackName, agent.Name)),
+		}, s.child())
+		if err != nil {
+			return err
+		}
+
+		s.AgentLogGroups[agent.Name] = group.Name
+		groupArns[agent.Name] = pulumi.Sprintf("%s:*", group.Arn)
+		s.injectEnv(agent.Name, "LOG_GROUP_NAME", group.Name)
+	}
+
+	// The execution policy allows writing to any log group, so agents are
+	// denied the other agents' groups
+	for _, agent := range s.Config.Agents {
+		var statements []policyStatement
+		var others pulumi.StringArray
+		for _, other := range s.Config.Agents {
+			groupArn, ok := groupArns[other.Name]
+			switch {
+			case !ok:
+			case other.Name == agent.Name:
+				statements = append(statements, policyStatement{
+					Actions:   []string{"logs:CreateLogStream", "logs:PutLogEvents", "logs:DescribeLogStreams"},
+					Resources: pulumi.StringArray{groupArn},
+				})
+			default:
+				others = append(others, groupArn)
+			}
+		}
+		if len(others) > 0 {
+			statements = append(statements, policyStatement{
+				Actions:   []string{"logs:CreateLogStream", "logs:PutLogEvents"},
+				Resources: others,
+				Deny:      true,
+			})
+		}
+		if len(statements) == 0 {
+			continue
+		}
+		if err := s.attachAgentPolicy(ctx, agent.Name, "agent-log-groups-policy", statements...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LogArchiveConfig configures archival of CloudWatch logs to S3.
+//
+// Each log group is streamed through a subscription filter to a Firehose
+// delivery stream that writes to an archive bucket, where a lifecycle rule
+// moves objects to a cold storage class. This keeps CloudWatch retention
+// short while preserving a cheap long-term copy.
+type LogArchiveConfig struct {
+	// StorageClass is the storage class archived logs transition to.
+	// Supported: "GLACIER_IR", "GLACIER", "DEEP_ARCHIVE"
+	// Default: "GLACIER"
+	StorageClass string `json:"storageClass,omitempty" yaml:"storageClass,omitempty"`
+
+	// TransitionDays is how many days after delivery archived logs move to
+	// StorageClass.
+	// Default: 30
+	TransitionDays int `json:"transitionDays,omitempty" yaml:"transitionDays,omitempty"`
+
+	// ExpirationDays deletes archived logs after this many days.
+	// Must be greater than TransitionDays. Zero keeps them indefinitely.
+	// Default: 0
+	ExpirationDays int `json:"expirationDays,omitempty" yaml:"expirationDays,omitempty"`
+
+	// FilterPattern selects which log events are archived.
+	// Default: "" (all events)
+	FilterPattern string `json:"filterPattern,omitempty" yaml:"filterPattern,omitempty"`
+
+	// BufferSeconds is how long Firehose buffers logs before writing to S3.
+	// Range: 0-900
+	// Default: 300
+	BufferSeconds int `json:"bufferSeconds,omitempty" yaml:"bufferSeconds,omitempty"`
+}
+
+// DefaultLogArchiveConfig returns a LogArchiveConfig with sensible defaults.
+func DefaultLogArchiveConfig() *LogArchiveConfig {
+	return &LogArchiveConfig{
+		StorageClass:   "GLACIER",
+		TransitionDays: 30,
+		BufferSeconds:  300,
+	}
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *LogArchiveConfig) ApplyDefaults() {
+	if c.StorageClass == "" {
+		c.StorageClass = "GLACIER"
+	}
+	if c.TransitionDays == 0 {
+		c.TransitionDays = 30
+	}
+	if c.BufferSeconds == 0 {
+		c.BufferSeconds = 300
+	}
+}
+
+// Validate validates the LogArchiveConfig.
+func (c *LogArchiveConfig) Validate() error {
+	switch c.StorageClass {
+	case "GLACIER_IR", "GLACIER", "DEEP_ARCHIVE":
+	default:
+		return fmt.Errorf("logArchive.storageClass must be 'GLACIER_IR', 'GLACIER' or 'DEEP_ARCHIVE'")
+	}
+	if c.TransitionDays < 0 {
+		return fmt.Errorf("logArchive.transitionDays must not be negative")
+	}
+	if c.ExpirationDays != 0 && c.ExpirationDays <= c.TransitionDays {
+		return fmt.Errorf("logArchive.expirationDays must be greater than transitionDays")
+	}
+	if c.BufferSeconds < 0 || c.BufferSeconds > 900 {
+		return fmt.Errorf("logArchive.bufferSeconds must be between 0 and 900")
+	}
+	return nil
+}
+
+// createLogArchive creates the archive bucket and delivery stream and
+// subscribes the stack's log groups to it.
+func (s *AgentCoreStack) createLogArchive(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	var err error
+	stackName := s.Config.StackName
+	cfg := s.Options.LogArchive
+
+	s.LogArchiveBucket, err = s.newPrivateBucket(ctx, "log-archive-bucket", "log-archive", tags)
+	if err != nil {
+		return err
+	}
+
+	rule := &s3.BucketLifecycleConfigurationV2RuleArgs{
+		Id:     pulumi.String("archive"),
+		Status: pulumi.String("Enabled"),
+		Filter: &s3.BucketLifecycleConfigurationV2RuleFilterArgs{
+			Prefix: pulumi.String(""),
+		},
+		Transitions: s3.BucketLifecycleConfigurationV2RuleTransitionArray{
+			&s3.BucketLifecycleConfigurationV2RuleTransitionArgs{
+				Days:         pulumi.Int(cfg.TransitionDays),
+				StorageClass: pulumi.String(cfg.StorageClass),
+			},
+		},
+	}
+	if cfg.ExpirationDays != 0 {
+		rule.Expiration = &s3.BucketLifecycleConfigurationV2RuleExpirationArgs{
+			Days: pulumi.Int(cfg.ExpirationDays),
+		}
+	}
+	_, err = s3.NewBucketLifecycleConfigurationV2(ctx, s.ResourceName("log-archive-lifecycle"), &s3.BucketLifecycleConfigurationV2Args{
+		Bucket: s.LogArchiveBucket.ID(),
+		Rules:  s3.BucketLifecycleConfigurationV2RuleArray{rule},
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	// Role assumed by Firehose to write to the archive bucket
+	firehoseRole, err := s.newServiceRole(ctx, "log-archive-firehose-role", "firehose.amazonaws.com", tags)
+	if err != nil {
+		return err
+	}
+	err = s.newRolePolicy(ctx, "log-archive-firehose-policy", firehoseRole.Name,
+		policyStatement{
+			Actions:   []string{"s3:GetBucketLocation", "s3:ListBucket", "s3:ListBucketMultipartUploads"},
+			Resources: pulumi.StringArray{s.LogArchiveBucket.Arn},
+		},
+		policyStatement{
+			Actions:   []string{"s3:PutObject", "s3:AbortMultipartUpload"},
+			Resources: pulumi.StringArray{pulumi.Sprintf("%s/*", s.LogArchiveBucket.Arn)},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	// Subscription filters deliver gzipped batches, so Firehose must not
+	// compress them again
+	stream, err := kinesis.NewFirehoseDeliveryStream(ctx, s.ResourceName("log-archive-stream"), &kinesis.FirehoseDeliveryStreamArgs{
+		Name:        pulumi.Sprintf("%s-log-archive", stackName),
+		Destination: pulumi.String("extended_s3"),
+		ExtendedS3Configuration: &kinesis.FirehoseDeliveryStreamExtendedS3ConfigurationArgs{
+			RoleArn:           firehoseRole.Arn,
+			BucketArn:         s.LogArchiveBucket.Arn,
+			Prefix:            pulumi.String("logs/dt=!{timestamp:yyyy-MM-dd}/"),
+			ErrorOutputPrefix: pulumi.String("errors/!{firehose:error-output-type}/dt=!{timestamp:yyyy-MM-dd}/"),
+			BufferingInterval: pulumi.Int(cfg.BufferSeconds),
+			BufferingSize:     pulumi.Int(5),
+			CompressionFormat: pulumi.String("UNCOMPRESSED"),
+		},
+		Tags: mergeTags(tags, pulumi.Sprintf("%s-log-archive", stackName)),
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	// Role assumed by CloudWatch Logs to put records on the stream
+	logsRole, err := s.newServiceRole(ctx, "log-archive-subscription-role", "logs.amazonaws.com", tags)
+	if err != nil {
+		return err
+	}
+	logsPolicy, err := iam.NewRolePolicy(ctx, s.ResourceName("log-archive-subscription-policy"), &iam.RolePolicyArgs{
+		Role: logsRole.Name,
+		Policy: policyDocument(policyStatement{
+			Actions:   []string{"firehose:PutRecord", "firehose:PutRecordBatch"},
+			Resources: pulumi.StringArray{stream.Arn},
+		}),
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	groups := map[string]pulumi.StringInput{}
+	var names []string
+	if s.LogGroup != nil {
+		groups["log-archive-filter"] = s.LogGroup.Name
+		names = append(names, "log-archive-filter")
+	}
+	for name, group := range s.AgentLogGroups {
+		resourceName := fmt.Sprintf("log-archive-filter-%s", name)
+		groups[resourceName] = group
+		names = append(names, resourceName)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		_, err = cloudwatch.NewLogSubscriptionFilter(ctx, s.ResourceName(name), &cloudwatch.LogSubscriptionFilterArgs{
+			Name:           pulumi.Sprintf("%s-log-archive", stackName),
+			LogGroup:       groups[name],
+			FilterPattern:  pulumi.String(cfg.FilterPattern),
+			DestinationArn: stream.Arn,
+			RoleArn:        logsRole.Arn,
+		}, pulumi.DependsOn([]pulumi.Resource{logsPolicy}), s.child())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,220 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/cloudwatch"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// logFieldPattern matches log field names usable in filter patterns and
+// Insights queries without quoting.
+var logFieldPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// LoggingConfig defines the structured log schema shared by all agents.
+// It is injected into the agents as LOG_FORMAT, LOG_LEVEL, LOG_FIELDS and
+// CORRELATION_ID_HEADER, and the stack's metric filters and Insights
+// queries are built from the same field names.
+type LoggingConfig struct {
+	// Format is the log record format.
+	// Supported: json
+	// Default: json
+	Format string `json:"format,omitempty" yaml:"format,omitempty"`
+
+	// Level is the minimum level agents log.
+	// Supported: DEBUG, INFO, WARN, ERROR
+	// Default: INFO
+	Level string `json:"level,omitempty" yaml:"level,omitempty"`
+
+	// Fields are the names of the fields in each log record.
+	Fields LogFields `json:"fields,omitempty" yaml:"fields,omitempty"`
+
+	// CorrelationIDHeader is the request header carrying the correlation ID
+	// agents log and forward to the agents they call.
+	// Default: X-Correlation-Id
+	CorrelationIDHeader string `json:"correlationIdHeader,omitempty" yaml:"correlationIdHeader,omitempty"`
+}
+
+// LogFields are the field names of structured log records.
+type LogFields struct {
+	// Level is the log level field, with values DEBUG, INFO, WARN or ERROR.
+	// Default: level
+	Level string `json:"level,omitempty" yaml:"level,omitempty"`
+
+	// Agent is the name of the agent that logged the record.
+	// Default: agent
+	Agent string `json:"agent,omitempty" yaml:"agent,omitempty"`
+
+	// Message is the human-readable message.
+	// Default: message
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+
+	// Event names well-known events, such as circuit_open.
+	// Default: event
+	Event string `json:"event,omitempty" yaml:"event,omitempty"`
+
+	// CorrelationID is the ID shared by all records of a request across agents.
+	// Default: correlationId
+	CorrelationID string `json:"correlationId,omitempty" yaml:"correlationId,omitempty"`
+
+	// Prompt is the prompt an agent received.
+	// Default: prompt
+	Prompt string `json:"prompt,omitempty" yaml:"prompt,omitempty"`
+
+	// Response is the response an agent returned.
+	// Default: response
+	Response string `json:"response,omitempty" yaml:"response,omitempty"`
+}
+
+// DefaultLoggingConfig returns a LoggingConfig with sensible defaults.
+func DefaultLoggingConfig() *LoggingConfig {
+	c := &LoggingConfig{}
+	c.ApplyDefaults()
+	return c
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *LoggingConfig) ApplyDefaults() {
+	if c.Format == "" {
+		c.Format = "json"
+	}
+	if c.Level == "" {
+		c.Level = "INFO"
+	}
+	if c.CorrelationIDHeader == "" {
+		c.CorrelationIDHeader = "X-Correlation-Id"
+	}
+	defaults := []struct {
+		field *string
+		name  string
+	}{
+		{&c.Fields.Level, "level"},
+		{&c.Fields.Agent, "agent"},
+		{&c.Fields.Message, "message"},
+		{&c.Fields.Event, "event"},
+		{&c.Fields.CorrelationID, "correlationId"},
+		{&c.Fields.Prompt, "prompt"},
+		{&c.Fields.Response, "response"},
+	}
+	for _, d := range defaults {
+		if *d.field == "" {
+			*d.field = d.name
+		}
+	}
+}
+
+// Validate validates the LoggingConfig.
+func (c *LoggingConfig) Validate() error {
+	if c.Format != "json" {
+		return fmt.Errorf("logging.format must be 'json'")
+	}
+	switch c.Level {
+	case "DEBUG", "INFO", "WARN", "ERROR":
+	default:
+		return fmt.Errorf("logging.level must be 'DEBUG', 'INFO', 'WARN' or 'ERROR'")
+	}
+	fields := map[string]string{
+		"level":         c.Fields.Level,
+		"agent":         c.Fields.Agent,
+		"message":       c.Fields.Message,
+		"event":         c.Fields.Event,
+		"correlationId": c.Fields.CorrelationID,
+		"prompt":        c.Fields.Prompt,
+		"response":      c.Fields.Response,
+	}
+	seen := make(map[string]bool)
+	for field, name := range fields {
+		if !logFieldPattern.MatchString(name) {
+			return fmt.Errorf("logging.fields.%s: '%s' is not a valid field name", field, name)
+		}
+		if seen[name] {
+			return fmt.Errorf("logging.fields: '%s' is used for more than one field", name)
+		}
+		seen[name] = true
+	}
+	if c.CorrelationIDHeader == "" {
+		return fmt.Errorf("logging.correlationIdHeader is required")
+	}
+	return nil
+}
+
+// logging returns the stack's log schema, or the default schema if
+// Options.Logging is not set.
+func (s *AgentCoreStack) logging() *LoggingConfig {
+	if s.Options.Logging != nil {
+		return s.Options.Logging
+	}
+	return DefaultLoggingConfig()
+}
+
+// logGroupNames returns the names of the stack and per-agent log groups.
+func (s *AgentCoreStack) logGroupNames() pulumi.StringArray {
+	var names pulumi.StringArray
+	if s.LogGroup != nil {
+		names = append(names, s.LogGroup.Name)
+	}
+	for _, agent := range s.Config.Agents {
+		if group, ok := s.AgentLogGroups[agent.Name]; ok {
+			names = append(names, group)
+		}
+	}
+	return names
+}
+
+// applyLogging injects the log schema into all agents and creates Insights
+// queries over the stack's log groups.
+func (s *AgentCoreStack) applyLogging(ctx *pulumi.Context) error {
+	stackName := s.Config.StackName
+	cfg := s.Options.Logging
+
+	fields, err := json.Marshal(cfg.Fields)
+	if err != nil {
+		return err
+	}
+	for _, agent := range s.Config.Agents {
+		s.injectEnv(agent.Name, "LOG_FORMAT", pulumi.String(cfg.Format))
+		s.injectEnv(agent.Name, "LOG_LEVEL", pulumi.String(cfg.Level))
+		s.injectEnv(agent.Name, "LOG_FIELDS", pulumi.String(string(fields)))
+		s.injectEnv(agent.Name, "CORRELATION_ID_HEADER", pulumi.String(cfg.CorrelationIDHeader))
+	}
+
+	groups := s.logGroupNames()
+	if len(groups) == 0 {
+		return nil
+	}
+
+	f := cfg.Fields
+	queries := []struct {
+		name  string
+		query string
+	}{
+		{
+			name: "errors",
+			query: fmt.Sprintf("fields @timestamp, %s, %s, %s\n| filter %s = \"ERROR\"\n| sort @timestamp desc",
+				f.Agent, f.CorrelationID, f.Message, f.Level),
+		},
+		{
+			name: "requests",
+			query: fmt.Sprintf("filter ispresent(%s)\n| stats count(*) as records, count_distinct(%s) as agents, min(@timestamp) as started, max(@timestamp) as ended by %s\n| sort started desc",
+				f.CorrelationID, f.Agent, f.CorrelationID),
+		},
+		{
+			name:  "levels",
+			query: fmt.Sprintf("stats count(*) as records by %s, %s", f.Agent, f.Level),
+		},
+	}
+	for _, q := range queries {
+		_, err = cloudwatch.NewQueryDefinition(ctx, s.ResourceName(fmt.Sprintf("log-query-%s", q.name)), &cloudwatch.QueryDefinitionArgs{
+			Name:          pulumi.Sprintf("agentcore/%s/%s", stackName, q.name),
+			QueryString:   pulumi.String(q.query),
+			LogGroupNames: groups,
+		}, s.child())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// MaintenanceConfig puts the agents' HTTP entry points into maintenance
+// mode. While Enabled, the agent subdomain APIs and replica routers answer
+// every request with a 503 and a JSON MaintenanceResponse instead of
+// invoking the runtimes, so operators can perform risky maintenance without
+// editing the APIs by hand. Runtimes invoked directly are not affected.
+//
+// Toggle it with auto.SetMaintenanceMode, or by deploying with Enabled
+// changed; only the proxy functions are updated.
+type MaintenanceConfig struct {
+	// Enabled turns maintenance mode on.
+	Enabled bool `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+
+	// Message is returned to callers.
+	// Default: "The agent is undergoing maintenance. Please try again later."
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+
+	// RetryAfterSeconds is returned in the Retry-After header.
+	// Range: 0-86400; 0 omits the header
+	// Default: 300
+	RetryAfterSeconds *int `json:"retryAfterSeconds,omitempty" yaml:"retryAfterSeconds,omitempty"`
+
+	// Agents is the list of agent names put into maintenance.
+	// If empty, all agents in the stack are included.
+	Agents []string `json:"agents,omitempty" yaml:"agents,omitempty"`
+}
+
+// MaintenanceResponse is the body of responses in maintenance mode.
+type MaintenanceResponse struct {
+	// Message is MaintenanceConfig.Message.
+	Message string `json:"message"`
+
+	// Maintenance is always true, so clients can tell maintenance from
+	// other 503s.
+	Maintenance bool `json:"maintenance"`
+
+	// RetryAfterSeconds is MaintenanceConfig.RetryAfterSeconds, if set.
+	RetryAfterSeconds int `json:"retryAfterSeconds,omitempty"`
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *MaintenanceConfig) ApplyDefaults() {
+	if c.Message == "" {
+		c.Message = "The agent is undergoing maintenance. Please try again later."
+	}
+	if c.RetryAfterSeconds == nil {
+		retryAfter := 300
+		c.RetryAfterSeconds = &retryAfter
+	}
+}
+
+// Validate validates the MaintenanceConfig.
+func (c *MaintenanceConfig) Validate(config iac.StackConfig) error {
+	if c.RetryAfterSeconds != nil && (*c.RetryAfterSeconds < 0 || *c.RetryAfterSeconds > 86400) {
+		return fmt.Errorf("maintenance.retryAfterSeconds must be between 0 and 86400")
+	}
+	return validateAgentNames("maintenance.agents", c.Agents, config)
+}
+
+// maintenanceEnv returns the environment that makes an agent's proxy answer
+// with the maintenance response, or nil when the agent is not in
+// maintenance.
+func (s *AgentCoreStack) maintenanceEnv(agentName string) (pulumi.StringMap, error) {
+	cfg := s.Options.Maintenance
+	if cfg == nil || !cfg.Enabled || !slices.Contains(s.selectedAgents(cfg.Agents), agentName) {
+		return nil, nil
+	}
+
+	response := MaintenanceResponse{Message: cfg.Message, Maintenance: true}
+	if cfg.RetryAfterSeconds != nil {
+		response.RetryAfterSeconds = *cfg.RetryAfterSeconds
+	}
+	body, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+
+	env := pulumi.StringMap{"MAINTENANCE_RESPONSE": pulumi.String(string(body))}
+	if response.RetryAfterSeconds > 0 {
+		env["MAINTENANCE_RETRY_AFTER"] = pulumi.String(strconv.Itoa(response.RetryAfterSeconds))
+	}
+	return env, nil
+}
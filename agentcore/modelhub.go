@@ -0,0 +1,188 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// accountIDPattern matches AWS account IDs.
+var accountIDPattern = regexp.MustCompile(`^[0-9]{12}$`)
+
+// ModelHubConfig lets agents invoke Bedrock models, inference profiles and
+// provisioned throughput owned by a central model hub account.
+//
+// Bedrock models cannot be shared with resource-based policies, so agents
+// assume a role in the hub account and invoke the models with its
+// credentials. The stack grants the selected agents' execution roles
+// sts:AssumeRole on the hub role and injects MODEL_HUB_ROLE_ARN, MODEL_HUB_REGION,
+// MODEL_HUB_MODEL_ARNS (a JSON array) and, if set, MODEL_HUB_EXTERNAL_ID.
+//
+// The hub role is managed in the hub account. The stack exports the trust
+// and permissions policies it needs as modelHubTrustPolicy and
+// modelHubPermissionsPolicy; programs deploying the hub account can build
+// them with ModelHubTrustPolicy and ModelHubPermissionsPolicy.
+type ModelHubConfig struct {
+	// AccountID is the hub account.
+	AccountID string `json:"accountID" yaml:"accountID"`
+
+	// RoleName is the role agents assume in the hub account.
+	// Default: "agentcore-model-hub"
+	RoleName string `json:"roleName,omitempty" yaml:"roleName,omitempty"`
+
+	// Region is the hub's region.
+	// Default: the stack's region
+	Region string `json:"region,omitempty" yaml:"region,omitempty"`
+
+	// ModelARNs are the hub's foundation models, inference profiles,
+	// provisioned models and custom models agents invoke.
+	ModelARNs []string `json:"modelARNs" yaml:"modelARNs"`
+
+	// ExternalID is required by the hub role's trust policy, if set.
+	ExternalID string `json:"externalID,omitempty" yaml:"externalID,omitempty"`
+
+	// Agents is the list of agent names that use the hub.
+	// If empty, all agents in the stack are included.
+	Agents []string `json:"agents,omitempty" yaml:"agents,omitempty"`
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *ModelHubConfig) ApplyDefaults() {
+	if c.RoleName == "" {
+		c.RoleName = "agentcore-model-hub"
+	}
+}
+
+// Validate validates the ModelHubConfig.
+func (c *ModelHubConfig) Validate(config iac.StackConfig) error {
+	if !accountIDPattern.MatchString(c.AccountID) {
+		return fmt.Errorf("modelHub.accountID: '%s' is not an account ID", c.AccountID)
+	}
+	if len(c.ModelARNs) == 0 {
+		return fmt.Errorf("modelHub.modelARNs is required")
+	}
+	for _, arn := range c.ModelARNs {
+		// arn:aws:bedrock:{region}:{account}:{resource}
+		parts := strings.SplitN(arn, ":", 6)
+		if len(parts) != 6 || parts[0] != "arn" || parts[2] != "bedrock" {
+			return fmt.Errorf("modelHub.modelARNs: '%s' is not a Bedrock ARN", arn)
+		}
+		// Foundation models have no account
+		if parts[4] != "" && parts[4] != c.AccountID {
+			return fmt.Errorf("modelHub.modelARNs: '%s' is not owned by the hub account", arn)
+		}
+	}
+	return validateAgentNames("modelHub.agents", c.Agents, config)
+}
+
+// roleARN returns the ARN of the hub role.
+func (c *ModelHubConfig) roleARN() string {
+	return fmt.Sprintf("arn:aws:iam::%s:role/%s", c.AccountID, c.RoleName)
+}
+
+// ModelHubTrustPolicy returns the trust policy of a hub role assumed by
+// workload accounts' agent execution roles.
+func ModelHubTrustPolicy(executionRoleARNs []string, externalID string) PolicyDocument {
+	statement := Statement{
+		Effect:    "Allow",
+		Principal: map[string][]string{"AWS": executionRoleARNs},
+		Action:    []string{"sts:AssumeRole"},
+	}
+	if externalID != "" {
+		statement.Condition = map[string]map[string]interface{}{
+			"StringEquals": {"sts:ExternalId": externalID},
+		}
+	}
+	return NewPolicyDocument(statement)
+}
+
+// ModelHubPermissionsPolicy returns the permissions policy of a hub role
+// that invokes the models. Inference profiles also need their foundation
+// models, which are covered by a statement scoped to profile use.
+func ModelHubPermissionsPolicy(modelARNs []string) PolicyDocument {
+	statements := []Statement{{
+		Effect: "Allow",
+		Action: []string{
+			"bedrock:InvokeModel",
+			"bedrock:InvokeModelWithResponseStream",
+			"bedrock:GetInferenceProfile",
+			"bedrock:GetProvisionedModelThroughput",
+		},
+		Resource: modelARNs,
+	}}
+
+	var profiles []string
+	for _, arn := range modelARNs {
+		if strings.Contains(arn, ":inference-profile/") || strings.Contains(arn, ":application-inference-profile/") {
+			profiles = append(profiles, arn)
+		}
+	}
+	if len(profiles) > 0 {
+		statements = append(statements, Statement{
+			Effect: "Allow",
+			Action: []string{
+				"bedrock:InvokeModel",
+				"bedrock:InvokeModelWithResponseStream",
+			},
+			Resource: []string{"arn:aws:bedrock:*::foundation-model/*"},
+			Condition: map[string]map[string]interface{}{
+				"StringLike": {"bedrock:InferenceProfileArn": profiles},
+			},
+		})
+	}
+	return NewPolicyDocument(statements...)
+}
+
+// createModelHubAccess lets agents assume the hub role and exports the
+// hub role's policies.
+func (s *AgentCoreStack) createModelHubAccess(ctx *pulumi.Context) error {
+	cfg := s.Options.ModelHub
+
+	region := cfg.Region
+	if region == "" {
+		current, err := aws.GetRegion(ctx, nil, s.invoke())
+		if err != nil {
+			return err
+		}
+		region = current.Name
+	}
+	modelARNs, err := json.Marshal(cfg.ModelARNs)
+	if err != nil {
+		return err
+	}
+
+	agentNames := s.selectedAgents(cfg.Agents)
+	for _, agentName := range agentNames {
+		s.injectEnv(agentName, "MODEL_HUB_ROLE_ARN", pulumi.String(cfg.roleARN()))
+		s.injectEnv(agentName, "MODEL_HUB_REGION", pulumi.String(region))
+		s.injectEnv(agentName, "MODEL_HUB_MODEL_ARNS", pulumi.String(string(modelARNs)))
+		if cfg.ExternalID != "" {
+			s.injectEnv(agentName, "MODEL_HUB_EXTERNAL_ID", pulumi.String(cfg.ExternalID))
+		}
+	}
+
+	s.ModelHubTrustPolicy = s.agentRoleArns(agentNames).ToStringArrayOutput().ApplyT(func(arns []string) (string, error) {
+		return ModelHubTrustPolicy(arns, cfg.ExternalID).JSON()
+	}).(pulumi.StringOutput)
+	permissions, err := ModelHubPermissionsPolicy(cfg.ModelARNs).JSON()
+	if err != nil {
+		return err
+	}
+	s.ModelHubPermissionsPolicy = pulumi.String(permissions).ToStringOutput()
+
+	for _, agentName := range agentNames {
+		err := s.attachAgentPolicy(ctx, agentName, "model-hub-policy", policyStatement{
+			Actions:   []string{"sts:AssumeRole"},
+			Resources: pulumi.StringArray{pulumi.String(cfg.roleARN())},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package agentcore
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// namePrefixPattern matches Options.NamePrefix values.
+var namePrefixPattern = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+
+// multiRegionComponentType is the Pulumi type token of MultiRegionStack.
+const multiRegionComponentType = "agentkit:agentcore:MultiRegionStack"
+
+// MultiRegionStack replicates an AgentCoreStack across regions in one
+// Pulumi program, for latency and disaster recovery. Each region gets its
+// own AWS provider and stack named "{stack}-{region}", whose resources and
+// outputs are prefixed with the region, e.g. the output
+// "eu-west-1.agentUrls".
+//
+// The first region is the primary: the secrets the stack creates are
+// created there and replicated to the other regions, whose agents read
+// the replicas. Give AgentSubdomains a RegionRouting config so the regions
+// share the agents' DNS names.
+type MultiRegionStack struct {
+	pulumi.ResourceState
+
+	// Regions are the stack's regions, the primary first.
+	Regions []string
+
+	// Providers are the AWS providers of the regional stacks, keyed by
+	// region.
+	Providers map[string]*aws.Provider
+
+	// Stacks are the regional stacks, keyed by region.
+	Stacks map[string]*AgentCoreStack
+}
+
+// NewMultiRegionStack creates an AgentCoreStack from a StackConfig and
+// options in each region. Options.Provider, Options.Region and the secret
+// replication options are set per region and must not be set; with
+// Options.AssumeRole every region is deployed with the role.
+func NewMultiRegionStack(ctx *pulumi.Context, config iac.StackConfig, options Options, regions []string, opts ...pulumi.ResourceOption) (*MultiRegionStack, error) {
+	if len(regions) == 0 {
+		return nil, fmt.Errorf("at least one region is required")
+	}
+	for i, region := range regions {
+		if !regionPattern.MatchString(region) {
+			return nil, fmt.Errorf("'%s' is not a region", region)
+		}
+		if slices.Contains(regions[:i], region) {
+			return nil, fmt.Errorf("region %s is listed more than once", region)
+		}
+	}
+	if options.Provider != nil || options.Region != "" || len(options.SecretReplicaRegions) > 0 || options.ReplicatedSecrets {
+		return nil, fmt.Errorf("provider, region, secretReplicaRegions and replicatedSecrets are set per region and must not be set")
+	}
+
+	m := &MultiRegionStack{
+		Regions:   regions,
+		Providers: make(map[string]*aws.Provider),
+		Stacks:    make(map[string]*AgentCoreStack),
+	}
+	if err := ctx.RegisterComponentResource(multiRegionComponentType, config.StackName, m, opts...); err != nil {
+		return nil, fmt.Errorf("failed to register multi-region stack component: %w", err)
+	}
+
+	// Every region names the secrets after the primary's
+	if createsSecrets(config) && config.Secrets.SecretName == "" {
+		secrets := *config.Secrets
+		secrets.SecretName = fmt.Sprintf("%s-secrets", config.StackName)
+		config.Secrets = &secrets
+	}
+
+	primary := regions[0]
+	for _, region := range regions {
+		prefix := region
+		if options.NamePrefix != "" {
+			prefix = options.NamePrefix + "-" + region
+		}
+
+		regionConfig := config
+		regionConfig.StackName = fmt.Sprintf("%s-%s", config.StackName, region)
+
+		regionOptions := options
+		regionOptions.Region = region
+		regionOptions.NamePrefix = prefix
+		if createsSecrets(config) {
+			if region == primary {
+				regionOptions.SecretReplicaRegions = regions[1:]
+			} else {
+				regionOptions.ReplicatedSecrets = true
+				regionOptions.DependsOn = append(slices.Clone(options.DependsOn), m.Stacks[primary].createdSecrets()...)
+			}
+		}
+
+		stack, err := NewAgentCoreStackWithOptions(ctx, regionConfig, regionOptions, pulumi.Parent(m))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create stack in %s: %w", region, err)
+		}
+		m.Stacks[region] = stack
+		m.Providers[region] = stack.Provider
+	}
+
+	regionsOutput := pulumi.ToStringArray(regions)
+	if options.NamePrefix != "" {
+		ctx.Export(options.NamePrefix+".regions", regionsOutput)
+	} else {
+		ctx.Export("regions", regionsOutput)
+	}
+	if err := ctx.RegisterResourceOutputs(m, pulumi.Map{"regions": regionsOutput}); err != nil {
+		return nil, fmt.Errorf("failed to register multi-region stack outputs: %w", err)
+	}
+
+	return m, nil
+}
+
+// createdSecrets returns the secrets the stack created, in name order.
+func (s *AgentCoreStack) createdSecrets() []pulumi.Resource {
+	names := make([]string, 0, len(s.Secrets))
+	for name := range s.Secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	resources := make([]pulumi.Resource, 0, len(names))
+	for _, name := range names {
+		resources = append(resources, s.Secrets[name])
+	}
+	return resources
+}
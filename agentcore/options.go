@@ -0,0 +1,998 @@
+// Package agentcore provides Pulumi components for AgentCore deployments on AWS.
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// Options contains Pulumi-specific stack settings that extend iac.StackConfig.
+//
+// iac.StackConfig is shared with the CDK, Terraform and CloudFormation
+// modules; Options covers resources that only this module provisions.
+type Options struct {
+	// Cache configures response caching for idempotent agent calls.
+	// Optional.
+	Cache *CacheConfig `json:"cache,omitempty" yaml:"cache,omitempty"`
+
+	// StateTable provisions a DynamoDB table for agent session and run state.
+	// Optional.
+	StateTable *StateTableConfig `json:"stateTable,omitempty" yaml:"stateTable,omitempty"`
+
+	// ArtifactBucket provisions a versioned S3 bucket for agent inputs and
+	// outputs.
+	// Optional.
+	ArtifactBucket *ArtifactBucketConfig `json:"artifactBucket,omitempty" yaml:"artifactBucket,omitempty"`
+
+	// CodeInterpreter provisions the AgentCore Code Interpreter tool.
+	// Optional.
+	CodeInterpreter *CodeInterpreterConfig `json:"codeInterpreter,omitempty" yaml:"codeInterpreter,omitempty"`
+
+	// Browser provisions the AgentCore managed Browser tool.
+	// Optional.
+	Browser *BrowserToolConfig `json:"browser,omitempty" yaml:"browser,omitempty"`
+
+	// Resilience is the retry and circuit-breaker policy shared by all agents.
+	// Optional.
+	Resilience *ResiliencePolicy `json:"resilience,omitempty" yaml:"resilience,omitempty"`
+
+	// Identity creates workload identities and OAuth2 credential providers
+	// for agents' outbound tool authentication.
+	// Optional.
+	Identity *IdentityConfig `json:"identity,omitempty" yaml:"identity,omitempty"`
+
+	// Gateways are AgentCore Gateways exposing tools to agents over MCP.
+	// Build them with GatewayBuilder.
+	// Optional.
+	Gateways []*GatewayConfig `json:"gateways,omitempty" yaml:"gateways,omitempty"`
+
+	// ToolCalls limits agents' calls to external tools and alarms on
+	// looping and budget exhaustion.
+	// Optional.
+	ToolCalls *ToolCallPolicy `json:"toolCalls,omitempty" yaml:"toolCalls,omitempty"`
+
+	// Tenancy provisions per-tenant work queues and fairness settings.
+	// Optional.
+	Tenancy *TenancyConfig `json:"tenancy,omitempty" yaml:"tenancy,omitempty"`
+
+	// GraphStore provisions a Neptune Serverless cluster for agent memory graphs.
+	// Optional.
+	GraphStore *GraphStoreConfig `json:"graphStore,omitempty" yaml:"graphStore,omitempty"`
+
+	// VectorStore provisions a vector database for agent embeddings.
+	// Create it with NewVectorStore.
+	// Optional.
+	VectorStore *VectorStoreConfig `json:"vectorStore,omitempty" yaml:"vectorStore,omitempty"`
+
+	// Retrieval configures the search index agents retrieve documents from.
+	// Optional.
+	Retrieval *RetrievalConfig `json:"retrieval,omitempty" yaml:"retrieval,omitempty"`
+
+	// SageMakerEndpoints are SageMaker real-time endpoints agents may invoke.
+	// Optional.
+	SageMakerEndpoints []SageMakerEndpointConfig `json:"sageMakerEndpoints,omitempty" yaml:"sageMakerEndpoints,omitempty"`
+
+	// ModelHub gives agents access to models owned by a central model hub
+	// account.
+	// Optional.
+	ModelHub *ModelHubConfig `json:"modelHub,omitempty" yaml:"modelHub,omitempty"`
+
+	// InferenceProfiles creates a Bedrock application inference profile
+	// per agent for per-agent cost allocation.
+	// Optional.
+	InferenceProfiles *InferenceProfileConfig `json:"inferenceProfiles,omitempty" yaml:"inferenceProfiles,omitempty"`
+
+	// BatchJobs are scheduled Bedrock batch inference jobs.
+	// Optional.
+	BatchJobs []BatchJobConfig `json:"batchJobs,omitempty" yaml:"batchJobs,omitempty"`
+
+	// FineTuning are Bedrock model customization jobs.
+	// Optional.
+	FineTuning []FineTuningConfig `json:"fineTuning,omitempty" yaml:"fineTuning,omitempty"`
+
+	// Audit records agent tool invocations to immutable storage.
+	// Optional.
+	Audit *AuditConfig `json:"audit,omitempty" yaml:"audit,omitempty"`
+
+	// Reports schedules report generation with S3 storage and email delivery.
+	// Optional.
+	Reports *ReportConfig `json:"reports,omitempty" yaml:"reports,omitempty"`
+
+	// Approval provisions a human-in-the-loop approval workflow.
+	// Optional.
+	Approval *ApprovalConfig `json:"approval,omitempty" yaml:"approval,omitempty"`
+
+	// Workflows orchestrate agents as Step Functions state machines.
+	// Build them with WorkflowBuilder.
+	// Optional.
+	Workflows []*WorkflowConfig `json:"workflows,omitempty" yaml:"workflows,omitempty"`
+
+	// AppSync provisions a GraphQL API with streaming subscriptions.
+	// Optional.
+	AppSync *AppSyncConfig `json:"appSync,omitempty" yaml:"appSync,omitempty"`
+
+	// Frontend hosts a chat UI on S3 and CloudFront.
+	// Optional.
+	Frontend *FrontendConfig `json:"frontend,omitempty" yaml:"frontend,omitempty"`
+
+	// WAF protects the AppSync API and frontend with WAFv2 web ACLs.
+	// Optional.
+	WAF *WAFConfig `json:"waf,omitempty" yaml:"waf,omitempty"`
+
+	// PerAgentLogGroups gives each agent its own log group,
+	// /aws/agentcore/{stack}/{agent}, instead of sharing the stack log group.
+	// Agents with AgentOptions.LogRetentionDays always get their own group.
+	// Only its agent may write to a group.
+	// Default: false
+	PerAgentLogGroups bool `json:"perAgentLogGroups,omitempty" yaml:"perAgentLogGroups,omitempty"`
+
+	// SecretsPerKey creates one secret per SecretsConfig.SecretValues key,
+	// named {secretName}/{key}, instead of a single JSON secret.
+	// Default: false
+	SecretsPerKey bool `json:"secretsPerKey,omitempty" yaml:"secretsPerKey,omitempty"`
+
+	// Logging defines the structured log schema injected into all agents
+	// and used by the stack's metric filters and Insights queries.
+	// Optional; metric filters use the default schema if unset.
+	Logging *LoggingConfig `json:"logging,omitempty" yaml:"logging,omitempty"`
+
+	// LogArchive archives CloudWatch logs to S3 with a cold storage lifecycle.
+	// Requires observability.enableCloudWatchLogs or PerAgentLogGroups.
+	// Optional.
+	LogArchive *LogArchiveConfig `json:"logArchive,omitempty" yaml:"logArchive,omitempty"`
+
+	// Health creates per-agent health alarms, a composite stack health alarm
+	// and an optional health endpoint.
+	// Requires observability.enableCloudWatchLogs or PerAgentLogGroups.
+	// Optional.
+	Health *HealthConfig `json:"health,omitempty" yaml:"health,omitempty"`
+
+	// Guardrail creates or references a Bedrock guardrail that agents apply.
+	// Optional.
+	Guardrail *GuardrailConfig `json:"guardrail,omitempty" yaml:"guardrail,omitempty"`
+
+	// PromptMonitoring evaluates sampled prompts and responses with a
+	// guardrail and alarms on suspected prompt injection or jailbreaks.
+	// Optional.
+	PromptMonitoring *PromptMonitoringConfig `json:"promptMonitoring,omitempty" yaml:"promptMonitoring,omitempty"`
+
+	// Registry registers the stack and its agents in Service Catalog AppRegistry.
+	// Optional.
+	Registry *RegistryConfig `json:"registry,omitempty" yaml:"registry,omitempty"`
+
+	// SecretsAudit schedules detection of granted secrets the agents never read.
+	// Optional.
+	SecretsAudit *SecretsAuditConfig `json:"secretsAudit,omitempty" yaml:"secretsAudit,omitempty"`
+
+	// KeyRotation tracks the age of granted secrets and alarms when they are
+	// overdue for rotation.
+	// Optional.
+	KeyRotation *KeyRotationConfig `json:"keyRotation,omitempty" yaml:"keyRotation,omitempty"`
+
+	// GuardDuty enables threat detection and routes the stack's findings.
+	// Optional.
+	GuardDuty *GuardDutyConfig `json:"guardDuty,omitempty" yaml:"guardDuty,omitempty"`
+
+	// SecurityHub creates insights scoped to the stack and routes its
+	// high-severity findings.
+	// Optional.
+	SecurityHub *SecurityHubConfig `json:"securityHub,omitempty" yaml:"securityHub,omitempty"`
+
+	// Alarms creates per-agent alarms on runtime errors, latency and
+	// throttles with notifications to an SNS topic.
+	// Optional.
+	Alarms *AlarmsConfig `json:"alarms,omitempty" yaml:"alarms,omitempty"`
+
+	// Dashboard creates a CloudWatch dashboard summarizing all agents.
+	// Optional.
+	Dashboard *DashboardConfig `json:"dashboard,omitempty" yaml:"dashboard,omitempty"`
+
+	// AgentSubdomains gives each agent a DNS name under a delegated subdomain.
+	// Optional.
+	AgentSubdomains *AgentSubdomainsConfig `json:"agentSubdomains,omitempty" yaml:"agentSubdomains,omitempty"`
+
+	// KMS encrypts log groups, created secrets and notification topics with
+	// a customer-managed key.
+	// Optional.
+	KMS *KMSConfig `json:"kms,omitempty" yaml:"kms,omitempty"`
+
+	// QuotaReport outputs the quota-limited resources the stack creates
+	// against the account's quotas.
+	// Optional.
+	QuotaReport *QuotaReportConfig `json:"quotaReport,omitempty" yaml:"quotaReport,omitempty"`
+
+	// FeatureFlags provisions AppConfig feature flags and configuration
+	// profiles agents read at runtime.
+	// Optional.
+	FeatureFlags *FeatureFlagConfig `json:"featureFlags,omitempty" yaml:"featureFlags,omitempty"`
+
+	// Topology publishes the resolved stack configuration and topology to
+	// SSM or AppConfig for runtime discovery.
+	// Optional.
+	Topology *TopologyConfig `json:"topology,omitempty" yaml:"topology,omitempty"`
+
+	// Maintenance answers requests to the agents' subdomain APIs and
+	// replica routers with a 503 while operators perform maintenance.
+	// Optional.
+	Maintenance *MaintenanceConfig `json:"maintenance,omitempty" yaml:"maintenance,omitempty"`
+
+	// IAMStatements are appended to the agents' execution policy, for
+	// access the stack does not grant itself.
+	// Optional.
+	IAMStatements []Statement `json:"iamStatements,omitempty" yaml:"iamStatements,omitempty"`
+
+	// IAMRoles sets the path, name prefix and permissions boundary of the
+	// IAM roles the stack creates.
+	// Optional.
+	IAMRoles *IAMRoleConfig `json:"iamRoles,omitempty" yaml:"iamRoles,omitempty"`
+
+	// ManagedPolicyARNs are managed policies attached to the agents'
+	// execution role, e.g. "arn:aws:iam::aws:policy/AmazonDynamoDBReadOnlyAccess".
+	// Optional.
+	ManagedPolicyARNs []string `json:"managedPolicyARNs,omitempty" yaml:"managedPolicyARNs,omitempty"`
+
+	// WorkQueue provisions SQS queues that invoke agents with each message.
+	// Optional.
+	WorkQueue *WorkQueueConfig `json:"workQueue,omitempty" yaml:"workQueue,omitempty"`
+
+	// SecretPolicy tags the secrets and SSM parameters the stack creates
+	// and limits decryption of secrets to the stack's secrets.
+	// Optional.
+	SecretPolicy *SecretPolicyConfig `json:"secretPolicy,omitempty" yaml:"secretPolicy,omitempty"`
+
+	// AllowAllSecrets grants the execution role secretsmanager:GetSecretValue
+	// on every secret instead of only the agents' SecretsARNs and the
+	// secrets the stack creates, e.g. for agents that look up secrets by
+	// name at runtime.
+	AllowAllSecrets bool `json:"allowAllSecrets,omitempty" yaml:"allowAllSecrets,omitempty"`
+
+	// BedrockRegions limits the agents' Bedrock access to models and
+	// inference profiles in these regions, e.g. "us-east-1". Models behind
+	// cross-region inference profiles stay reachable in every region, but
+	// only through the profiles.
+	// Default: all regions
+	BedrockRegions []string `json:"bedrockRegions,omitempty" yaml:"bedrockRegions,omitempty"`
+
+	// SecretOutputs are names of outputs exported as Pulumi secrets, so
+	// they are encrypted in state, in addition to the sensitive outputs the
+	// stack always encrypts, such as healthUrl. Names may be path.Match
+	// patterns, e.g. "agents.*.url".
+	// Optional.
+	SecretOutputs []string `json:"secretOutputs,omitempty" yaml:"secretOutputs,omitempty"`
+
+	// ResourceFactory substitutes the creation of the VPC, security group
+	// and IAM roles, and adds resources to the stack.
+	// Optional.
+	ResourceFactory ResourceFactory `json:"-" yaml:"-"`
+
+	// DependsOn are resources the agent runtimes are created after, such as
+	// an ECRMirror copying their images.
+	DependsOn []pulumi.Resource `json:"-" yaml:"-"`
+
+	// Provider is the AWS provider of the stack's resources and lookups,
+	// e.g. for another region or account.
+	// Default: the program's default AWS provider
+	Provider *aws.Provider `json:"-" yaml:"-"`
+
+	// Region creates the stack's resources in this region, with an AWS
+	// provider of the stack's own.
+	// Default: the region of the program's default provider
+	Region string `json:"region,omitempty" yaml:"region,omitempty"`
+
+	// AssumeRole creates the stack's resources with the credentials of a
+	// role in another account.
+	// Optional.
+	AssumeRole *AssumeRoleConfig `json:"assumeRole,omitempty" yaml:"assumeRole,omitempty"`
+
+	// NamePrefix is prepended to the Pulumi names of the stack's resources
+	// and outputs, so several stacks can be created in one program, as
+	// NewMultiRegionStack does. Resources are named "{prefix}-{name}" and
+	// outputs "{prefix}.{name}". Changing it replaces every resource.
+	// Optional.
+	NamePrefix string `json:"namePrefix,omitempty" yaml:"namePrefix,omitempty"`
+
+	// SecretReplicaRegions replicates the secrets the stack creates to
+	// these regions, for stacks in them with ReplicatedSecrets. Replicas
+	// are encrypted with the aws/secretsmanager key of their region.
+	// Optional.
+	SecretReplicaRegions []string `json:"secretReplicaRegions,omitempty" yaml:"secretReplicaRegions,omitempty"`
+
+	// ReplicatedSecrets grants the agents the replicas in the stack's region
+	// of the secrets a stack in another region created with
+	// SecretReplicaRegions, instead of creating the secrets. The
+	// secrets.secretName and secrets.secretValues keys must match the
+	// other stack's; the values are not used.
+	ReplicatedSecrets bool `json:"replicatedSecrets,omitempty" yaml:"replicatedSecrets,omitempty"`
+
+	// SkipPreflight disables region availability checks, e.g. for unit
+	// tests with mocked providers.
+	SkipPreflight bool `json:"skipPreflight,omitempty" yaml:"skipPreflight,omitempty"`
+
+	// Agents contains per-agent options keyed by agent name.
+	Agents map[string]AgentOptions `json:"agents,omitempty" yaml:"agents,omitempty"`
+}
+
+// AgentOptions contains Pulumi-specific settings for a single agent.
+type AgentOptions struct {
+	// InputSchema is a JSON Schema describing the agent's request payload.
+	// Published with the agent's OpenAPI document, agent card and gateway
+	// tool definitions (see AgentToolDefinitions), and enforced by the
+	// agent's router, which is created for agents with a schema, and its
+	// subdomain proxy. Invoking the runtime directly bypasses it. Not
+	// supported for Lambda agents.
+	InputSchema json.RawMessage `json:"inputSchema,omitempty" yaml:"inputSchema,omitempty"`
+
+	// OutputSchema is a JSON Schema describing the agent's response payload.
+	// Enforced like InputSchema.
+	OutputSchema json.RawMessage `json:"outputSchema,omitempty" yaml:"outputSchema,omitempty"`
+
+	// OnFailure captures failed asynchronous invocations for replay.
+	// Optional.
+	OnFailure *FailureDestination `json:"onFailure,omitempty" yaml:"onFailure,omitempty"`
+
+	// LogRetentionDays gives the agent its own log group with this
+	// retention, even without Options.PerAgentLogGroups. Agents without it
+	// log to the shared group unless PerAgentLogGroups is set.
+	LogRetentionDays int `json:"logRetentionDays,omitempty" yaml:"logRetentionDays,omitempty"`
+
+	// Replicas is the number of identical runtimes created for the agent,
+	// named {agent}-0 to {agent}-{n-1} and load balanced by a router URL.
+	// AgentCore already scales sessions within a runtime; replicas add
+	// static capacity with separate per-runtime quotas.
+	// Range: 1-10
+	// Default: 1
+	Replicas int `json:"replicas,omitempty" yaml:"replicas,omitempty"`
+
+	// BlueGreen deploys the agent blue/green, keeping the previous runtime
+	// version deployed for rollback.
+	// Optional.
+	BlueGreen *BlueGreenConfig `json:"blueGreen,omitempty" yaml:"blueGreen,omitempty"`
+
+	// Immutable replaces the agent's runtime whenever its configuration
+	// changes instead of updating it in place. The new runtime is named
+	// after a hash of its configuration and is created before the old one
+	// is deleted, at the end of the update. Roll back by deploying the
+	// previous configuration. Cannot be combined with BlueGreen.
+	Immutable bool `json:"immutable,omitempty" yaml:"immutable,omitempty"`
+
+	// Lambda deploys the agent as a Lambda function instead of an AgentCore
+	// runtime.
+	// Optional.
+	Lambda *LambdaTargetConfig `json:"lambda,omitempty" yaml:"lambda,omitempty"`
+
+	// KnowledgeBases are knowledge bases the agent may retrieve from. The
+	// first one's ID is injected as KNOWLEDGE_BASE_ID, and all of them as
+	// KNOWLEDGE_BASE_IDS.
+	KnowledgeBases []*KnowledgeBase `json:"-" yaml:"-"`
+
+	// Labels are metadata about the agent, such as its owner, tier and
+	// data classification (see LabelOwner, LabelTier and
+	// LabelDataClassification). They are applied to the agent's resources
+	// as agentkit:label:{key} tags and exported as agentLabels.
+	Labels map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+
+	// CodeInterpreter adds the agent to Options.CodeInterpreter.Agents,
+	// creating the stack's Code Interpreter with default settings if it is
+	// not configured.
+	CodeInterpreter bool `json:"codeInterpreter,omitempty" yaml:"codeInterpreter,omitempty"`
+
+	// Browser adds the agent to Options.Browser.Agents, creating the
+	// stack's Browser with default settings if it is not configured.
+	Browser bool `json:"browser,omitempty" yaml:"browser,omitempty"`
+
+	// Schedules invoke the agent on EventBridge Scheduler schedules.
+	Schedules []AgentSchedule `json:"schedules,omitempty" yaml:"schedules,omitempty"`
+}
+
+// Validate validates the AgentOptions for the named agent.
+func (a *AgentOptions) Validate(agentName string) error {
+	if err := validateSchema(fmt.Sprintf("agents[%s].inputSchema", agentName), a.InputSchema); err != nil {
+		return err
+	}
+	if err := validateSchema(fmt.Sprintf("agents[%s].outputSchema", agentName), a.OutputSchema); err != nil {
+		return err
+	}
+	if a.OnFailure != nil {
+		if err := a.OnFailure.Validate(agentName); err != nil {
+			return err
+		}
+	}
+	if a.LogRetentionDays != 0 {
+		if err := validateLogRetention(fmt.Sprintf("agents[%s].logRetentionDays", agentName), a.LogRetentionDays); err != nil {
+			return err
+		}
+	}
+	if err := validateLabels(agentName, a.Labels); err != nil {
+		return err
+	}
+	if err := validateReplicas(agentName, a.Replicas); err != nil {
+		return err
+	}
+	if a.BlueGreen != nil {
+		if err := a.BlueGreen.Validate(agentName, a.Replicas); err != nil {
+			return err
+		}
+		// A replaced runtime has no previous version for blue to serve
+		if a.Immutable {
+			return fmt.Errorf("agents[%s]: immutable cannot be combined with blueGreen", agentName)
+		}
+	}
+	if err := validateSchedules(agentName, a.Schedules); err != nil {
+		return err
+	}
+	if a.Lambda != nil {
+		if err := a.Lambda.Validate(agentName); err != nil {
+			return err
+		}
+		// Lambda agents are invoked without a proxy to enforce schemas
+		if len(a.InputSchema) > 0 || len(a.OutputSchema) > 0 {
+			return fmt.Errorf("agents[%s]: inputSchema and outputSchema are not supported for Lambda agents", agentName)
+		}
+	}
+	return nil
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (o *Options) ApplyDefaults() {
+	if o.Cache != nil {
+		o.Cache.ApplyDefaults()
+	}
+	if o.StateTable != nil {
+		o.StateTable.ApplyDefaults()
+	}
+	if o.ArtifactBucket != nil {
+		o.ArtifactBucket.ApplyDefaults()
+	}
+	if agents := o.agentsWith(func(agent AgentOptions) bool { return agent.CodeInterpreter }); len(agents) > 0 {
+		if o.CodeInterpreter == nil {
+			o.CodeInterpreter = &CodeInterpreterConfig{Agents: agents}
+		} else if len(o.CodeInterpreter.Agents) > 0 {
+			o.CodeInterpreter.Agents = appendAgentNames(o.CodeInterpreter.Agents, agents)
+		}
+	}
+	if o.CodeInterpreter != nil {
+		o.CodeInterpreter.ApplyDefaults()
+	}
+	if agents := o.agentsWith(func(agent AgentOptions) bool { return agent.Browser }); len(agents) > 0 {
+		if o.Browser == nil {
+			o.Browser = &BrowserToolConfig{Agents: agents}
+		} else if len(o.Browser.Agents) > 0 {
+			o.Browser.Agents = appendAgentNames(o.Browser.Agents, agents)
+		}
+	}
+	if o.Browser != nil {
+		o.Browser.ApplyDefaults()
+	}
+	if o.Resilience != nil {
+		o.Resilience.ApplyDefaults()
+	}
+	for _, gateway := range o.Gateways {
+		gateway.ApplyDefaults()
+	}
+	if o.ToolCalls != nil {
+		o.ToolCalls.ApplyDefaults()
+	}
+	if o.Tenancy != nil {
+		o.Tenancy.ApplyDefaults()
+	}
+	if o.GraphStore != nil {
+		o.GraphStore.ApplyDefaults()
+	}
+	if o.VectorStore != nil {
+		o.VectorStore.ApplyDefaults()
+	}
+	if o.Retrieval != nil {
+		o.Retrieval.ApplyDefaults()
+	}
+	for i := range o.SageMakerEndpoints {
+		o.SageMakerEndpoints[i].ApplyDefaults()
+	}
+	if o.ModelHub != nil {
+		o.ModelHub.ApplyDefaults()
+	}
+	if o.InferenceProfiles != nil {
+		o.InferenceProfiles.ApplyDefaults()
+	}
+	for i := range o.BatchJobs {
+		o.BatchJobs[i].ApplyDefaults()
+	}
+	for i := range o.FineTuning {
+		o.FineTuning[i].ApplyDefaults()
+	}
+	if o.LogArchive != nil {
+		o.LogArchive.ApplyDefaults()
+	}
+	if o.Logging != nil {
+		o.Logging.ApplyDefaults()
+	}
+	if o.Health != nil {
+		o.Health.ApplyDefaults()
+	}
+	if o.Guardrail != nil {
+		o.Guardrail.ApplyDefaults()
+		// Evaluate prompts with the shared guardrail
+		if o.PromptMonitoring != nil && o.PromptMonitoring.GuardrailARN == "" && o.Guardrail.ARN != "" {
+			o.PromptMonitoring.GuardrailARN = o.Guardrail.ARN
+			o.PromptMonitoring.GuardrailVersion = o.Guardrail.Version
+		}
+	}
+	if o.PromptMonitoring != nil {
+		o.PromptMonitoring.ApplyDefaults()
+	}
+	if o.Registry != nil {
+		o.Registry.ApplyDefaults()
+	}
+	if o.SecretsAudit != nil {
+		o.SecretsAudit.ApplyDefaults()
+	}
+	if o.KeyRotation != nil {
+		o.KeyRotation.ApplyDefaults()
+	}
+	if o.GuardDuty != nil {
+		o.GuardDuty.ApplyDefaults()
+	}
+	if o.SecurityHub != nil {
+		o.SecurityHub.ApplyDefaults()
+	}
+	if o.Alarms != nil {
+		o.Alarms.ApplyDefaults()
+	}
+	if o.Dashboard != nil {
+		o.Dashboard.ApplyDefaults()
+	}
+	if o.QuotaReport != nil {
+		o.QuotaReport.ApplyDefaults()
+	}
+	if o.FeatureFlags != nil {
+		o.FeatureFlags.ApplyDefaults()
+	}
+	if o.Topology != nil {
+		o.Topology.ApplyDefaults()
+	}
+	if o.Maintenance != nil {
+		o.Maintenance.ApplyDefaults()
+	}
+	if o.AgentSubdomains != nil && o.AgentSubdomains.RegionRouting != nil {
+		o.AgentSubdomains.RegionRouting.ApplyDefaults()
+	}
+	for i := range o.IAMStatements {
+		o.IAMStatements[i].ApplyDefaults()
+	}
+	if o.IAMRoles != nil {
+		o.IAMRoles.ApplyDefaults()
+	}
+	if o.WorkQueue != nil {
+		o.WorkQueue.ApplyDefaults()
+	}
+	if o.Audit != nil {
+		o.Audit.ApplyDefaults()
+	}
+	if o.Reports != nil {
+		o.Reports.ApplyDefaults()
+	}
+	if o.Approval != nil {
+		o.Approval.ApplyDefaults()
+	}
+	for _, workflow := range o.Workflows {
+		workflow.ApplyDefaults()
+	}
+	if o.AppSync != nil {
+		o.AppSync.ApplyDefaults()
+	}
+	if o.Frontend != nil {
+		o.Frontend.ApplyDefaults()
+	}
+	if o.WAF != nil {
+		o.WAF.ApplyDefaults()
+	}
+	for _, agent := range o.Agents {
+		if agent.OnFailure != nil {
+			agent.OnFailure.ApplyDefaults()
+		}
+		if agent.BlueGreen != nil {
+			agent.BlueGreen.ApplyDefaults()
+		}
+		if agent.Lambda != nil {
+			agent.Lambda.ApplyDefaults()
+		}
+		for i := range agent.Schedules {
+			agent.Schedules[i].ApplyDefaults(i + 1)
+		}
+	}
+}
+
+// agentsWith returns the sorted names of agents whose options match.
+func (o *Options) agentsWith(match func(AgentOptions) bool) []string {
+	var names []string
+	for name, agent := range o.Agents {
+		if match(agent) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// appendAgentNames appends the names not already in agents.
+func appendAgentNames(agents, names []string) []string {
+	for _, name := range names {
+		if !slices.Contains(agents, name) {
+			agents = append(agents, name)
+		}
+	}
+	return agents
+}
+
+// Validate validates the options against the stack configuration.
+func (o *Options) Validate(config iac.StackConfig) error {
+	if err := validateRuntimeLimits(config, o.Agents); err != nil {
+		return err
+	}
+	if createsSecrets(config) {
+		if err := validateSecretValues(config); err != nil {
+			return err
+		}
+	} else if o.SecretsPerKey {
+		return fmt.Errorf("secretsPerKey requires secrets.createSecrets")
+	}
+	if len(o.SecretReplicaRegions) > 0 || o.ReplicatedSecrets {
+		if !createsSecrets(config) {
+			return fmt.Errorf("secretReplicaRegions and replicatedSecrets require secrets.createSecrets")
+		}
+		if len(o.SecretReplicaRegions) > 0 && o.ReplicatedSecrets {
+			return fmt.Errorf("secretReplicaRegions cannot be set with replicatedSecrets")
+		}
+		for _, region := range o.SecretReplicaRegions {
+			if !regionPattern.MatchString(region) {
+				return fmt.Errorf("secretReplicaRegions: '%s' is not a region", region)
+			}
+		}
+	}
+	if o.Provider != nil && (o.Region != "" || o.AssumeRole != nil) {
+		return fmt.Errorf("provider cannot be set with region or assumeRole")
+	}
+	if o.Region != "" && !regionPattern.MatchString(o.Region) {
+		return fmt.Errorf("region: '%s' is not a region", o.Region)
+	}
+	if o.AssumeRole != nil {
+		if err := o.AssumeRole.Validate(); err != nil {
+			return err
+		}
+	}
+	if o.NamePrefix != "" && !namePrefixPattern.MatchString(o.NamePrefix) {
+		return fmt.Errorf("namePrefix: '%s' must contain only letters, digits and hyphens", o.NamePrefix)
+	}
+	if o.Cache != nil {
+		if err := o.Cache.Validate(config); err != nil {
+			return err
+		}
+	}
+	if o.StateTable != nil {
+		if err := o.StateTable.Validate(); err != nil {
+			return err
+		}
+	}
+	if o.ArtifactBucket != nil {
+		if err := o.ArtifactBucket.Validate(); err != nil {
+			return err
+		}
+	}
+	if o.CodeInterpreter != nil {
+		if err := o.CodeInterpreter.Validate(config); err != nil {
+			return err
+		}
+	}
+	if o.Browser != nil {
+		if err := o.Browser.Validate(config); err != nil {
+			return err
+		}
+	}
+	if o.Resilience != nil {
+		if err := o.Resilience.Validate(); err != nil {
+			return err
+		}
+	}
+	if o.Identity != nil {
+		if err := o.Identity.Validate(config); err != nil {
+			return err
+		}
+	}
+	gatewayNames := make(map[string]bool)
+	for _, gateway := range o.Gateways {
+		if err := gateway.Validate(config); err != nil {
+			return err
+		}
+		if gatewayNames[gateway.Name] {
+			return fmt.Errorf("gateways: duplicate gateway '%s'", gateway.Name)
+		}
+		gatewayNames[gateway.Name] = true
+	}
+	if o.ToolCalls != nil {
+		if err := o.ToolCalls.Validate(config); err != nil {
+			return err
+		}
+	}
+	if o.Tenancy != nil {
+		if err := o.Tenancy.Validate(config); err != nil {
+			return err
+		}
+	}
+	if o.GraphStore != nil {
+		if err := o.GraphStore.Validate(config); err != nil {
+			return err
+		}
+	}
+	if o.VectorStore != nil {
+		if err := o.VectorStore.Validate(config); err != nil {
+			return err
+		}
+	}
+	if o.Retrieval != nil {
+		if err := o.Retrieval.Validate(config); err != nil {
+			return err
+		}
+	}
+	endpointNames := make(map[string]bool)
+	for i := range o.SageMakerEndpoints {
+		endpoint := &o.SageMakerEndpoints[i]
+		if err := endpoint.Validate(config); err != nil {
+			return err
+		}
+		if endpointNames[endpoint.EndpointName] {
+			return fmt.Errorf("sageMakerEndpoints: duplicate endpoint name '%s'", endpoint.EndpointName)
+		}
+		endpointNames[endpoint.EndpointName] = true
+	}
+	if o.ModelHub != nil {
+		if err := o.ModelHub.Validate(config); err != nil {
+			return err
+		}
+	}
+	if o.InferenceProfiles != nil {
+		if err := o.InferenceProfiles.Validate(config); err != nil {
+			return err
+		}
+	}
+	jobNames := make(map[string]bool)
+	for i := range o.BatchJobs {
+		job := &o.BatchJobs[i]
+		if err := job.Validate(); err != nil {
+			return err
+		}
+		if jobNames[job.Name] {
+			return fmt.Errorf("batchJobs: duplicate job name '%s'", job.Name)
+		}
+		jobNames[job.Name] = true
+	}
+	tuningNames := make(map[string]bool)
+	for i := range o.FineTuning {
+		job := &o.FineTuning[i]
+		if err := job.Validate(config); err != nil {
+			return err
+		}
+		if tuningNames[job.Name] {
+			return fmt.Errorf("fineTuning: duplicate job name '%s'", job.Name)
+		}
+		tuningNames[job.Name] = true
+	}
+	if o.Logging != nil {
+		if err := o.Logging.Validate(); err != nil {
+			return err
+		}
+	}
+	if o.LogArchive != nil {
+		if err := o.LogArchive.Validate(); err != nil {
+			return err
+		}
+		cloudWatchLogs := config.Observability != nil && config.Observability.EnableCloudWatchLogs
+		if !cloudWatchLogs && !o.PerAgentLogGroups {
+			return fmt.Errorf("logArchive requires observability.enableCloudWatchLogs or perAgentLogGroups")
+		}
+	}
+	if o.Health != nil {
+		if err := o.Health.Validate(); err != nil {
+			return err
+		}
+		cloudWatchLogs := config.Observability != nil && config.Observability.EnableCloudWatchLogs
+		if !cloudWatchLogs && !o.PerAgentLogGroups {
+			return fmt.Errorf("health requires observability.enableCloudWatchLogs or perAgentLogGroups")
+		}
+	}
+	if o.Guardrail != nil {
+		if err := o.Guardrail.Validate(config); err != nil {
+			return err
+		}
+	}
+	if o.PromptMonitoring != nil {
+		if err := o.PromptMonitoring.Validate(); err != nil {
+			return err
+		}
+		cloudWatchLogs := config.Observability != nil && config.Observability.EnableCloudWatchLogs
+		if !cloudWatchLogs && !o.PerAgentLogGroups {
+			return fmt.Errorf("promptMonitoring requires observability.enableCloudWatchLogs or perAgentLogGroups")
+		}
+	}
+	if o.Registry != nil {
+		if err := o.Registry.Validate(config); err != nil {
+			return err
+		}
+	}
+	if o.SecretsAudit != nil {
+		if err := o.SecretsAudit.Validate(config); err != nil {
+			return err
+		}
+	}
+	if o.KeyRotation != nil {
+		if err := o.KeyRotation.Validate(config); err != nil {
+			return err
+		}
+	}
+	if o.GuardDuty != nil {
+		if err := o.GuardDuty.Validate(); err != nil {
+			return err
+		}
+	}
+	if o.SecurityHub != nil {
+		if err := o.SecurityHub.Validate(); err != nil {
+			return err
+		}
+	}
+	if o.Alarms != nil {
+		if err := o.Alarms.Validate(config); err != nil {
+			return err
+		}
+	}
+	if o.Dashboard != nil {
+		if err := o.Dashboard.Validate(); err != nil {
+			return err
+		}
+	}
+	if o.KMS != nil {
+		if err := o.KMS.Validate(); err != nil {
+			return err
+		}
+	}
+	if o.QuotaReport != nil {
+		if err := o.QuotaReport.Validate(); err != nil {
+			return err
+		}
+	}
+	if o.FeatureFlags != nil {
+		if err := o.FeatureFlags.Validate(config); err != nil {
+			return err
+		}
+		if o.FeatureFlags.RollbackOnHealthAlarm && o.Health == nil {
+			return fmt.Errorf("featureFlags.rollbackOnHealthAlarm requires health")
+		}
+	}
+	if o.Topology != nil {
+		if err := o.Topology.Validate(); err != nil {
+			return err
+		}
+	}
+	if o.Maintenance != nil {
+		if err := o.Maintenance.Validate(config); err != nil {
+			return err
+		}
+	}
+	if err := validateBedrockModelIDs(config, o.BedrockRegions); err != nil {
+		return err
+	}
+	for i := range o.IAMStatements {
+		if err := o.IAMStatements[i].validate(fmt.Sprintf("iamStatements[%d]", i)); err != nil {
+			return err
+		}
+	}
+	if o.IAMRoles != nil {
+		if err := o.IAMRoles.Validate(); err != nil {
+			return err
+		}
+	}
+	if o.SecretPolicy != nil {
+		if err := o.SecretPolicy.Validate(); err != nil {
+			return err
+		}
+	}
+	if o.WorkQueue != nil {
+		if err := o.WorkQueue.Validate(config); err != nil {
+			return err
+		}
+	}
+	if err := validateSecretOutputs(o.SecretOutputs); err != nil {
+		return err
+	}
+	if config.IAM != nil && config.IAM.PermissionsBoundaryARN != "" && !iamPolicyARN(config.IAM.PermissionsBoundaryARN) {
+		return fmt.Errorf("iam.permissionsBoundaryARN: '%s' is not an IAM policy ARN", config.IAM.PermissionsBoundaryARN)
+	}
+	policyNames := make(map[string]bool)
+	for _, arn := range o.ManagedPolicyARNs {
+		if !iamPolicyARN(arn) {
+			return fmt.Errorf("managedPolicyARNs: '%s' is not an IAM policy ARN", arn)
+		}
+		name := arn[strings.LastIndex(arn, "/")+1:]
+		if policyNames[name] {
+			return fmt.Errorf("managedPolicyARNs: duplicate policy name '%s'", name)
+		}
+		policyNames[name] = true
+	}
+	if o.AgentSubdomains != nil {
+		if err := o.AgentSubdomains.Validate(config); err != nil {
+			return err
+		}
+	}
+	if o.Audit != nil {
+		if err := o.Audit.Validate(config); err != nil {
+			return err
+		}
+	}
+	if o.Reports != nil {
+		if err := o.Reports.Validate(config); err != nil {
+			return err
+		}
+	}
+	if o.Approval != nil {
+		if err := o.Approval.Validate(config); err != nil {
+			return err
+		}
+	}
+	workflowNames := make(map[string]bool)
+	for _, workflow := range o.Workflows {
+		if err := workflow.Validate(config); err != nil {
+			return err
+		}
+		if workflowNames[workflow.Name] {
+			return fmt.Errorf("workflows: duplicate workflow '%s'", workflow.Name)
+		}
+		workflowNames[workflow.Name] = true
+	}
+	if o.AppSync != nil {
+		if err := o.AppSync.Validate(config); err != nil {
+			return err
+		}
+	}
+	if o.Frontend != nil {
+		if err := o.Frontend.Validate(); err != nil {
+			return err
+		}
+	}
+	if o.WAF != nil {
+		if err := o.WAF.Validate(); err != nil {
+			return err
+		}
+		if o.AppSync == nil && o.Frontend == nil {
+			return fmt.Errorf("waf requires appSync or frontend; API Gateway HTTP APIs cannot be protected by WAF")
+		}
+	}
+	if err := validateVPCLayout(config); err != nil {
+		return err
+	}
+	if err := validateAgentRuntimes(config); err != nil {
+		return err
+	}
+	for name, agent := range o.Agents {
+		if !hasAgent(config, name) {
+			return fmt.Errorf("agent options: '%s' does not match any agent name", name)
+		}
+		if err := agent.Validate(name); err != nil {
+			return err
+		}
+	}
+	return validateLambdaAgents(o, config)
+}
+
+// validateAgentNames checks that every name references an agent in the stack.
+func validateAgentNames(field string, names []string, config iac.StackConfig) error {
+	for _, name := range names {
+		if !hasAgent(config, name) {
+			return fmt.Errorf("%s: '%s' does not match any agent name", field, name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package agentcore
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// sensitiveOutputs are outputs that grant access on their own, such as
+// unauthenticated URLs, and are always exported as secrets.
+var sensitiveOutputs = []string{
+	"healthUrl",
+}
+
+// validateSecretOutputs validates Options.SecretOutputs.
+func validateSecretOutputs(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			return fmt.Errorf("secretOutputs: '%s' is not a valid output name or pattern", pattern)
+		}
+	}
+	return nil
+}
+
+// isSecretOutput reports whether an output is exported as a secret: a
+// sensitive output, or one matching Options.SecretOutputs.
+func (s *AgentCoreStack) isSecretOutput(name string) bool {
+	for _, pattern := range append(sensitiveOutputs, s.Options.SecretOutputs...) {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// export exports a stack output, as a secret if it is classified as one.
+func (s *AgentCoreStack) export(ctx *pulumi.Context, name string, value pulumi.Input) {
+	if s.isSecretOutput(name) {
+		value = pulumi.ToSecret(value)
+	}
+	if s.Options.NamePrefix != "" {
+		name = s.Options.NamePrefix + "." + name
+	}
+	ctx.Export(name, value)
+}
+
+// maskOutputs marks the classified entries of Outputs as secrets.
+func (s *AgentCoreStack) maskOutputs() {
+	for name, value := range s.Outputs {
+		if s.isSecretOutput(name) {
+			s.Outputs[name] = pulumi.ToSecret(value).(pulumi.StringOutput)
+		}
+	}
+}
+
+// AgentCoreStackOutputs are the outputs downstream stacks most often
+// consume, with their types. Get them from a stack in the same program
+// with AgentCoreStack.TypedOutputs, or from another program's stack with
+// GetOutputs.
+type AgentCoreStackOutputs struct {
+	// VPCID is the VPC the agents run in (empty without a VPC).
+	VPCID pulumi.StringOutput
+
+	// PrivateSubnetIDs are the subnets the agents run in.
+	PrivateSubnetIDs pulumi.StringArrayOutput
+
+	// SecurityGroupID is the agents' security group (empty without a VPC).
+	SecurityGroupID pulumi.StringOutput
+
+	// AgentRoleArns maps agent names to their execution role ARNs.
+	AgentRoleArns pulumi.StringMapOutput
+
+	// AgentRuntimeArns maps agent and replica names to their runtime ARNs.
+	AgentRuntimeArns pulumi.StringMapOutput
+
+	// AgentInvokeURLs maps agent and replica names to their
+	// InvokeAgentRuntime URLs.
+	AgentInvokeURLs pulumi.StringMapOutput
+}
+
+// TypedOutputs returns the stack's outputs with their types.
+func (s *AgentCoreStack) TypedOutputs() AgentCoreStackOutputs {
+	outputs := AgentCoreStackOutputs{
+		VPCID:            pulumi.String("").ToStringOutput(),
+		PrivateSubnetIDs: s.privateSubnetIDs().ToStringArrayOutput(),
+		SecurityGroupID:  pulumi.String("").ToStringOutput(),
+	}
+	if vpcID := s.vpcID(); vpcID != nil {
+		outputs.VPCID = vpcID.ToStringOutput()
+	}
+	if s.SecurityGroup != nil {
+		outputs.SecurityGroupID = s.SecurityGroup.ID().ToStringOutput()
+	}
+
+	roleArns := pulumi.StringMap{}
+	for name, role := range s.AgentRoles {
+		roleArns[name] = role.Arn
+	}
+	outputs.AgentRoleArns = roleArns.ToStringMapOutput()
+
+	runtimeArns := pulumi.StringMap{}
+	invokeURLs := pulumi.StringMap{}
+	for name, runtime := range s.AgentRuntimes {
+		runtimeArns[name] = runtime.RuntimeArn
+		invokeURLs[name] = runtime.InvokeURL
+	}
+	outputs.AgentRuntimeArns = runtimeArns.ToStringMapOutput()
+	outputs.AgentInvokeURLs = invokeURLs.ToStringMapOutput()
+	return outputs
+}
+
+// GetOutputs reads the outputs of an AgentCoreStack deployed by another
+// Pulumi program through a stack reference. Outputs the stack does not
+// export are empty.
+func GetOutputs(ref *pulumi.StackReference) AgentCoreStackOutputs {
+	return AgentCoreStackOutputs{
+		VPCID:            stringOutput(ref, "vpcId"),
+		PrivateSubnetIDs: stringArrayOutput(ref, "privateSubnetIds"),
+		SecurityGroupID:  stringOutput(ref, "securityGroupId"),
+		AgentRoleArns:    stringMapOutput(ref, "agentRoleArns"),
+		AgentRuntimeArns: stringMapOutput(ref, "agentRuntimeArns"),
+		AgentInvokeURLs:  stringMapOutput(ref, "agentInvokeUrls"),
+	}
+}
+
+// stringOutput reads a string output of a stack reference.
+func stringOutput(ref *pulumi.StackReference, name string) pulumi.StringOutput {
+	return ref.GetOutput(pulumi.String(name)).ApplyT(func(v interface{}) string {
+		value, _ := v.(string)
+		return value
+	}).(pulumi.StringOutput)
+}
+
+// stringArrayOutput reads a list output of a stack reference.
+func stringArrayOutput(ref *pulumi.StackReference, name string) pulumi.StringArrayOutput {
+	return ref.GetOutput(pulumi.String(name)).ApplyT(func(v interface{}) []string {
+		items, _ := v.([]interface{})
+		values := make([]string, 0, len(items))
+		for _, item := range items {
+			if value, ok := item.(string); ok {
+				values = append(values, value)
+			}
+		}
+		return values
+	}).(pulumi.StringArrayOutput)
+}
+
+// stringMapOutput reads a map output of a stack reference.
+func stringMapOutput(ref *pulumi.StackReference, name string) pulumi.StringMapOutput {
+	return ref.GetOutput(pulumi.String(name)).ApplyT(func(v interface{}) map[string]string {
+		items, _ := v.(map[string]interface{})
+		values := make(map[string]string, len(items))
+		for key, item := range items {
+			if value, ok := item.(string); ok {
+				values[key] = value
+			}
+		}
+		return values
+	}).(pulumi.StringMapOutput)
+}
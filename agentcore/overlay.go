@@ -0,0 +1,127 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadStackConfigWithOverlay loads a StackConfig from a base JSON or YAML
+// file merged with an environment overlay, such as dev.yaml, that holds
+// only the settings that differ from the base.
+//
+// The files are merged before defaults are applied, so the overlay wins for
+// every setting it contains:
+//   - Objects, such as vpc and tags, merge key by key, recursively.
+//   - Lists of objects that all have a name, such as agents, merge by name:
+//     an overlay entry merges into the base entry with its name, and
+//     entries with new names are appended.
+//   - Other lists and scalars replace the base value.
+//   - Zero values, such as 0, "", false and [], are set like any other
+//     value; null removes the base value, restoring its default.
+//
+// ${env:VAR} placeholders in the merged config are resolved as
+// LoadStackConfig does. SSM and Secrets Manager placeholders need a Pulumi
+// context and fail here.
+func LoadStackConfigWithOverlay(basePath, overlayPath string) (*iac.StackConfig, error) {
+	base, err := readConfigDocument(basePath)
+	if err != nil {
+		return nil, err
+	}
+	overlay, err := readConfigDocument(overlayPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeStackConfig(nil, mergeConfigValues(base, overlay).(map[string]interface{}))
+}
+
+// readConfigDocument reads a JSON or YAML config file as a generic document.
+func readConfigDocument(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	doc := map[string]interface{}{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		err = json.Unmarshal(data, &doc)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &doc)
+	default:
+		return nil, fmt.Errorf("unsupported config file extension: %s", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return doc, nil
+}
+
+// mergeConfigValues merges an overlay value into a base value following the
+// rules of LoadStackConfigWithOverlay.
+func mergeConfigValues(base, overlay interface{}) interface{} {
+	switch overlay := overlay.(type) {
+	case map[string]interface{}:
+		base, ok := base.(map[string]interface{})
+		if !ok {
+			return overlay
+		}
+		merged := make(map[string]interface{}, len(base)+len(overlay))
+		for key, value := range base {
+			merged[key] = value
+		}
+		for key, value := range overlay {
+			if value == nil {
+				delete(merged, key)
+				continue
+			}
+			merged[key] = mergeConfigValues(base[key], value)
+		}
+		return merged
+	case []interface{}:
+		base, ok := base.([]interface{})
+		if !ok || !namedObjects(base) || !namedObjects(overlay) {
+			return overlay
+		}
+		merged := append([]interface{}{}, base...)
+		for _, item := range overlay {
+			name := item.(map[string]interface{})["name"]
+			i := 0
+			for i < len(merged) && merged[i].(map[string]interface{})["name"] != name {
+				i++
+			}
+			if i < len(merged) {
+				merged[i] = mergeConfigValues(merged[i], item)
+			} else {
+				merged = append(merged, item)
+			}
+		}
+		return merged
+	default:
+		return overlay
+	}
+}
+
+// namedObjects reports whether every item of a non-empty list is an object
+// with a string name.
+func namedObjects(items []interface{}) bool {
+	if len(items) == 0 {
+		return false
+	}
+	for _, item := range items {
+		object, ok := item.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if _, ok := object["name"].(string); !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,161 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/secretsmanager"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/ssm"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// placeholderPattern matches config placeholders such as ${env:IMAGE_TAG}.
+var placeholderPattern = regexp.MustCompile(`\$\{(env|ssm|secretsmanager):([^}]+)\}`)
+
+// LoadStackConfig loads a StackConfig from a JSON or YAML file, resolving
+// placeholders in its string values:
+//   - ${env:VAR} is the environment variable VAR.
+//   - ${ssm:/path/param} is the value of an SSM parameter, decrypted if it
+//     is a SecureString.
+//   - ${secretsmanager:arn} is the value of a secret, and
+//     ${secretsmanager:arn#KEY} is the KEY field of a JSON secret.
+//
+// Placeholders may be part of a value, e.g.
+// "ghcr.io/example/research:${env:IMAGE_TAG}". They are resolved once, when
+// the program runs, so values that change in SSM or Secrets Manager are
+// picked up by the next update. Resolved values are stored in the Pulumi
+// state like any other setting; secret values belong in
+// secrets.secretValues, which the stack stores as Pulumi secrets.
+//
+// SSM and Secrets Manager placeholders are read with the program's AWS
+// provider, so they need a Pulumi context; with a nil ctx only environment
+// variables are resolved.
+func LoadStackConfig(ctx *pulumi.Context, path string) (*iac.StackConfig, error) {
+	doc, err := readConfigDocument(path)
+	if err != nil {
+		return nil, err
+	}
+	return decodeStackConfig(ctx, doc)
+}
+
+// LoadOptions loads the Options under the "options" key of a JSON or YAML
+// config file, resolving placeholders as LoadStackConfig does. It returns
+// empty Options if the file has no options.
+func LoadOptions(ctx *pulumi.Context, path string) (Options, error) {
+	doc, err := readConfigDocument(path)
+	if err != nil {
+		return Options{}, err
+	}
+	optionsDoc, ok := doc["options"].(map[string]interface{})
+	if !ok {
+		return Options{}, nil
+	}
+	options, err := decodeOptions(ctx, optionsDoc)
+	if err != nil {
+		return Options{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return options, nil
+}
+
+// decodeStackConfig resolves the placeholders of a config document and
+// decodes it, applying defaults and validating the result.
+func decodeStackConfig(ctx *pulumi.Context, doc map[string]interface{}) (*iac.StackConfig, error) {
+	resolved, err := resolvePlaceholders(ctx, doc)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(resolved)
+	if err != nil {
+		return nil, err
+	}
+	return iac.LoadStackConfigFromJSON(data)
+}
+
+// resolvePlaceholders returns a config document with the placeholders in
+// its string values resolved.
+func resolvePlaceholders(ctx *pulumi.Context, value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		resolved := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			r, err := resolvePlaceholders(ctx, item)
+			if err != nil {
+				return nil, err
+			}
+			resolved[key] = r
+		}
+		return resolved, nil
+	case []interface{}:
+		resolved := make([]interface{}, len(value))
+		for i, item := range value {
+			r, err := resolvePlaceholders(ctx, item)
+			if err != nil {
+				return nil, err
+			}
+			resolved[i] = r
+		}
+		return resolved, nil
+	case string:
+		var resolveErr error
+		resolved := placeholderPattern.ReplaceAllStringFunc(value, func(placeholder string) string {
+			match := placeholderPattern.FindStringSubmatch(placeholder)
+			r, err := resolvePlaceholder(ctx, match[1], match[2])
+			if err != nil && resolveErr == nil {
+				resolveErr = fmt.Errorf("failed to resolve %s: %w", placeholder, err)
+			}
+			return r
+		})
+		return resolved, resolveErr
+	default:
+		return value, nil
+	}
+}
+
+// resolvePlaceholder returns the value a placeholder refers to.
+func resolvePlaceholder(ctx *pulumi.Context, source, ref string) (string, error) {
+	if source == "env" {
+		value, ok := os.LookupEnv(ref)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", ref)
+		}
+		return value, nil
+	}
+	if ctx == nil {
+		return "", fmt.Errorf("%s placeholders require a Pulumi context", source)
+	}
+
+	if source == "ssm" {
+		param, err := ssm.LookupParameter(ctx, &ssm.LookupParameterArgs{
+			Name:           ref,
+			WithDecryption: pulumi.BoolRef(true),
+		})
+		if err != nil {
+			return "", err
+		}
+		return param.Value, nil
+	}
+
+	secretID, key, hasKey := strings.Cut(ref, "#")
+	secret, err := secretsmanager.LookupSecretVersion(ctx, &secretsmanager.LookupSecretVersionArgs{
+		SecretId: secretID,
+	})
+	if err != nil {
+		return "", err
+	}
+	if !hasKey {
+		return secret.SecretString, nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(secret.SecretString), &fields); err != nil {
+		return "", fmt.Errorf("secret %s is not a JSON object", secretID)
+	}
+	value, ok := fields[key].(string)
+	if !ok {
+		return "", fmt.Errorf("secret %s has no string field %s", secretID, key)
+	}
+	return value, nil
+}
@@ -0,0 +1,92 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// PolicyVersion is the IAM policy language version of policy documents.
+const PolicyVersion = "2012-10-17"
+
+// PolicyDocument is an IAM policy document.
+type PolicyDocument struct {
+	Version   string      `json:"Version"`
+	Statement []Statement `json:"Statement"`
+}
+
+// Statement is a statement of an IAM policy document.
+type Statement struct {
+	// Sid identifies the statement.
+	Sid string `json:"Sid,omitempty" yaml:"sid,omitempty"`
+
+	// Effect is "Allow" or "Deny".
+	// Default: "Allow"
+	Effect string `json:"Effect" yaml:"effect,omitempty"`
+
+	// Principal maps principal types, such as "Service" or "AWS", to
+	// principals. It is only used in trust policies.
+	Principal map[string][]string `json:"Principal,omitempty" yaml:"principal,omitempty"`
+
+	// Action are the actions the statement applies to.
+	Action []string `json:"Action" yaml:"action"`
+
+	// Resource are the resources the statement applies to. Trust policies
+	// have none.
+	Resource []string `json:"Resource,omitempty" yaml:"resource,omitempty"`
+
+	// Condition maps condition operators to condition keys and values.
+	Condition map[string]map[string]interface{} `json:"Condition,omitempty" yaml:"condition,omitempty"`
+}
+
+// NewPolicyDocument returns a policy document with the statements.
+func NewPolicyDocument(statements ...Statement) PolicyDocument {
+	return PolicyDocument{Version: PolicyVersion, Statement: statements}
+}
+
+// JSON returns the policy document as JSON.
+func (d PolicyDocument) JSON() (string, error) {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (s *Statement) ApplyDefaults() {
+	if s.Effect == "" {
+		s.Effect = "Allow"
+	}
+}
+
+// validate validates a statement of a permissions policy.
+func (s *Statement) validate(field string) error {
+	if s.Effect != "Allow" && s.Effect != "Deny" {
+		return fmt.Errorf("%s.effect must be one of [Allow Deny]", field)
+	}
+	if len(s.Action) == 0 {
+		return fmt.Errorf("%s.action is required", field)
+	}
+	for _, action := range s.Action {
+		if action != "*" && !strings.Contains(action, ":") {
+			return fmt.Errorf("%s.action: '%s' is not a service:action name", field, action)
+		}
+	}
+	if len(s.Resource) == 0 {
+		return fmt.Errorf("%s.resource is required", field)
+	}
+	if len(s.Principal) > 0 {
+		return fmt.Errorf("%s.principal is not allowed in permissions policies", field)
+	}
+	return nil
+}
+
+// serviceTrustStatement allows AWS service principals to assume a role.
+func serviceTrustStatement(services ...string) Statement {
+	return Statement{
+		Effect:    "Allow",
+		Principal: map[string][]string{"Service": services},
+		Action:    []string{"sts:AssumeRole"},
+	}
+}
@@ -0,0 +1,207 @@
+package agentcore
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/bedrock"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/bedrockfoundation"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// AgentCoreRegions lists the regions where Amazon Bedrock AgentCore is
+// available. The AWS provider's endpoint metadata doesn't cover AgentCore
+// yet, so preflight checks use this list. Append to it as AgentCore
+// launches in new regions.
+var AgentCoreRegions = []string{
+	"us-east-1",
+	"us-east-2",
+	"us-west-2",
+	"ap-south-1",
+	"ap-southeast-1",
+	"ap-southeast-2",
+	"ap-northeast-1",
+	"eu-central-1",
+	"eu-west-1",
+}
+
+// inferenceProfilePattern matches cross-region inference profile IDs such as "us.anthropic.claude...".
+var inferenceProfilePattern = regexp.MustCompile(`^(us|eu|apac|us-gov|global)\.`)
+
+// RegionCheck is the availability of one service or model in the deployment region.
+type RegionCheck struct {
+	// Service is the service or model checked.
+	Service string
+
+	// RequiredBy is the stack setting that needs the service.
+	RequiredBy string
+
+	// Available reports whether the service is available in the region.
+	Available bool
+
+	// Detail explains an unavailable result.
+	Detail string
+}
+
+// PreflightError reports services that are unavailable in the deployment region.
+type PreflightError struct {
+	// Region is the deployment region.
+	Region string
+
+	// Checks are all checks performed, available or not.
+	Checks []RegionCheck
+}
+
+// Error implements the error interface.
+func (e *PreflightError) Error() string {
+	var lines []string
+	for _, check := range e.Checks {
+		if check.Available {
+			continue
+		}
+		line := fmt.Sprintf("  - %s (required by %s)", check.Service, check.RequiredBy)
+		if check.Detail != "" {
+			line += ": " + check.Detail
+		}
+		lines = append(lines, line)
+	}
+	return fmt.Sprintf("services unavailable in %s:\n%s", e.Region, strings.Join(lines, "\n"))
+}
+
+// requiredServices returns the AWS service IDs the configured stack needs,
+// mapped to the setting that needs them.
+func (s *AgentCoreStack) requiredServices() map[string]string {
+	services := map[string]string{}
+	o := s.Options
+	if o.Cache != nil {
+		services["elasticache"] = "cache"
+	}
+	if o.GraphStore != nil {
+		services["rds"] = "graphStore (Neptune)"
+	}
+	if o.Retrieval != nil {
+		services["kendra"] = "retrieval"
+	}
+	if len(o.SageMakerEndpoints) > 0 {
+		services["sagemaker"] = "sageMakerEndpoints"
+	}
+	if len(o.BatchJobs) > 0 || o.Reports != nil {
+		services["scheduler"] = "batchJobs/reports"
+	}
+	for name, agent := range o.Agents {
+		if len(agent.Schedules) > 0 {
+			services["scheduler"] = fmt.Sprintf("agents[%s].schedules", name)
+		}
+	}
+	if o.Approval != nil {
+		services["states"] = "approval"
+	}
+	if o.AppSync != nil {
+		services["appsync"] = "appSync"
+	}
+	if o.Audit != nil {
+		services["firehose"] = "audit"
+		services["athena"] = "audit"
+	}
+	return services
+}
+
+// preflightRegion checks that the deployment region supports the configured
+// services and Bedrock models, so unsupported settings fail before any
+// resource is created rather than mid-deploy.
+func (s *AgentCoreStack) preflightRegion(ctx *pulumi.Context) error {
+	region, err := aws.GetRegion(ctx, nil, s.invoke())
+	if err != nil {
+		return err
+	}
+	result := &PreflightError{Region: region.Name}
+
+	agentCore := RegionCheck{Service: "bedrock-agentcore", RequiredBy: "agents"}
+	for _, r := range AgentCoreRegions {
+		if r == region.Name {
+			agentCore.Available = true
+		}
+	}
+	if !agentCore.Available {
+		agentCore.Detail = fmt.Sprintf("available in %s", strings.Join(AgentCoreRegions, ", "))
+	}
+	result.Checks = append(result.Checks, agentCore)
+
+	services := s.requiredServices()
+	ids := make([]string, 0, len(services))
+	for id := range services {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		check := RegionCheck{Service: id, RequiredBy: services[id]}
+		serviceID := id
+		svc, err := aws.GetService(ctx, &aws.GetServiceArgs{ServiceId: &serviceID, Region: &region.Name}, s.invoke())
+		switch {
+		case err != nil:
+			check.Detail = err.Error()
+		case !svc.Supported:
+			check.Detail = "not offered in this region"
+		default:
+			check.Available = true
+		}
+		result.Checks = append(result.Checks, check)
+	}
+
+	if iamCfg := s.Config.IAM; iamCfg != nil && iamCfg.EnableBedrockAccess {
+		for _, modelID := range iamCfg.BedrockModelIDs {
+			result.Checks = append(result.Checks, checkBedrockModel(ctx, modelID, "iam.bedrockModelIds", s.invoke()))
+		}
+	}
+	if cfg := s.Options.InferenceProfiles; cfg != nil {
+		modelIDs := make([]string, len(s.Config.Agents))
+		for i, agent := range s.Config.Agents {
+			modelIDs[i] = cfg.modelID(agent.Name)
+		}
+		for _, modelID := range uniqueStrings(modelIDs) {
+			result.Checks = append(result.Checks, checkBedrockModel(ctx, modelID, "inferenceProfiles", s.invoke()))
+		}
+	}
+	if s.Options.Guardrail != nil && s.Options.Guardrail.ARN != "" {
+		result.Checks = append(result.Checks, checkGuardrail(ctx, s.Options.Guardrail, region.Name, s.invoke()))
+	}
+	for _, job := range s.Options.BatchJobs {
+		result.Checks = append(result.Checks, checkBedrockModel(ctx, job.ModelID, fmt.Sprintf("batchJobs[%s]", job.Name), s.invoke()))
+	}
+	for _, job := range s.Options.FineTuning {
+		result.Checks = append(result.Checks, checkBedrockModel(ctx, job.BaseModelID, fmt.Sprintf("fineTuning[%s]", job.Name), s.invoke()))
+	}
+
+	for _, check := range result.Checks {
+		if !check.Available {
+			return result
+		}
+	}
+	return nil
+}
+
+// checkBedrockModel checks that a foundation model or inference profile is available.
+func checkBedrockModel(ctx *pulumi.Context, modelID, requiredBy string, opts ...pulumi.InvokeOption) RegionCheck {
+	check := RegionCheck{Service: "bedrock model " + modelID, RequiredBy: requiredBy}
+	var err error
+	if resourceType, id, ok := parseBedrockModelARN(modelID); ok {
+		if resourceType == "foundation-model" {
+			_, err = bedrockfoundation.GetModel(ctx, &bedrockfoundation.GetModelArgs{ModelId: id}, opts...)
+		} else {
+			_, err = bedrock.LookupInferenceProfile(ctx, &bedrock.LookupInferenceProfileArgs{InferenceProfileId: id}, opts...)
+		}
+	} else if inferenceProfilePattern.MatchString(modelID) {
+		_, err = bedrock.LookupInferenceProfile(ctx, &bedrock.LookupInferenceProfileArgs{InferenceProfileId: modelID}, opts...)
+	} else {
+		_, err = bedrockfoundation.GetModel(ctx, &bedrockfoundation.GetModelArgs{ModelId: modelID}, opts...)
+	}
+	if err != nil {
+		check.Detail = err.Error()
+		return check
+	}
+	check.Available = true
+	return check
+}
@@ -0,0 +1,362 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/bedrock"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/cloudwatch"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/lambda"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// promptMonitorHandler samples prompt and response log events, evaluates
+// them against the guardrail and publishes per-agent counts of sampled
+// messages, guardrail interventions and suspected prompt attacks.
+const promptMonitorHandler = `import { gunzipSync } from "node:zlib";
+import { BedrockRuntimeClient, ApplyGuardrailCommand } from "@aws-sdk/client-bedrock-runtime";
+import { CloudWatchClient, PutMetricDataCommand } from "@aws-sdk/client-cloudwatch";
+
+const bedrock = new BedrockRuntimeClient({});
+const cloudwatch = new CloudWatchClient({});
+
+const sampleRate = Number(process.env.SAMPLE_RATE);
+const logGroupAgents = JSON.parse(process.env.LOG_GROUP_AGENTS);
+const fields = JSON.parse(process.env.LOG_FIELDS);
+
+const promptAttack = (assessments = []) =>
+  assessments.some((a) => (a.contentPolicy?.filters ?? []).some((f) => f.type === "PROMPT_ATTACK" && f.action === "BLOCKED"));
+
+export const handler = async (event) => {
+  const { logGroup, logEvents } = JSON.parse(gunzipSync(Buffer.from(event.awslogs.data, "base64")));
+  const counts = {};
+
+  for (const { message } of logEvents) {
+    if (Math.random() >= sampleRate) continue;
+    let record;
+    try {
+      record = JSON.parse(message);
+    } catch {
+      continue;
+    }
+    const agent = record[fields.agent] ?? logGroupAgents[logGroup] ?? "unknown";
+    const count = (counts[agent] ??= { SampledMessages: 0, GuardrailInterventions: 0, PromptAttacks: 0 });
+
+    for (const [field, source] of [[fields.prompt, "INPUT"], [fields.response, "OUTPUT"]]) {
+      const text = record[field];
+      if (typeof text !== "string" || text === "") continue;
+      count.SampledMessages++;
+      const result = await bedrock.send(new ApplyGuardrailCommand({
+        guardrailIdentifier: process.env.GUARDRAIL_ID,
+        guardrailVersion: process.env.GUARDRAIL_VERSION,
+        source,
+        content: [{ text: { text } }],
+      }));
+      if (result.action !== "GUARDRAIL_INTERVENED") continue;
+      count.GuardrailInterventions++;
+      if (promptAttack(result.assessments)) {
+        count.PromptAttacks++;
+        console.warn(JSON.stringify({ agent, source, correlationId: record[fields.correlationId], assessments: result.assessments }));
+      }
+    }
+  }
+
+  const metrics = Object.entries(counts).flatMap(([agent, count]) =>
+    Object.entries(count).map(([MetricName, Value]) => ({
+      MetricName,
+      Value,
+      Unit: "Count",
+      Dimensions: [{ Name: "Agent", Value: agent }],
+    })),
+  );
+  for (let i = 0; i < metrics.length; i += 1000) {
+    await cloudwatch.send(new PutMetricDataCommand({
+      Namespace: process.env.METRIC_NAMESPACE,
+      MetricData: metrics.slice(i, i + 1000),
+    }));
+  }
+};
+`
+
+// PromptMonitoringConfig samples the prompts and responses agents log and
+// evaluates them with a Bedrock guardrail, alarming on suspected prompt
+// injection and jailbreak attempts.
+//
+// Agents log prompts and responses as structured events
+// ({"agent": ..., "prompt": ..., "response": ...}, with field names from
+// Options.Logging) to the stack log group or their own log group. Monitoring is asynchronous and never blocks
+// the agents.
+type PromptMonitoringConfig struct {
+	// GuardrailARN is an existing guardrail used for evaluation. If empty,
+	// a guardrail with a prompt attack filter is created.
+	GuardrailARN string `json:"guardrailARN,omitempty" yaml:"guardrailARN,omitempty"`
+
+	// GuardrailVersion is the version of an existing guardrail.
+	// Default: DRAFT
+	GuardrailVersion string `json:"guardrailVersion,omitempty" yaml:"guardrailVersion,omitempty"`
+
+	// FilterStrength is the prompt attack filter strength of a created guardrail.
+	// Supported: LOW, MEDIUM, HIGH
+	// Default: HIGH
+	FilterStrength string `json:"filterStrength,omitempty" yaml:"filterStrength,omitempty"`
+
+	// SampleRate is the fraction of matching log events evaluated.
+	// Range: greater than 0, up to 1
+	// Default: 0.1
+	SampleRate float64 `json:"sampleRate,omitempty" yaml:"sampleRate,omitempty"`
+
+	// FilterPattern selects the log events carrying prompts or responses.
+	// Default: events with the prompt or response field
+	FilterPattern string `json:"filterPattern,omitempty" yaml:"filterPattern,omitempty"`
+
+	// AttackThreshold is the number of suspected attacks per period that
+	// raises an agent's alarm.
+	// Default: 1
+	AttackThreshold int `json:"attackThreshold,omitempty" yaml:"attackThreshold,omitempty"`
+
+	// PeriodSeconds is the alarm evaluation period. Must be a multiple of 60.
+	// Default: 300
+	PeriodSeconds int `json:"periodSeconds,omitempty" yaml:"periodSeconds,omitempty"`
+
+	// TopicARN is an existing SNS topic for alerts. If empty, one is created.
+	TopicARN string `json:"topicARN,omitempty" yaml:"topicARN,omitempty"`
+
+	// Recipients are email addresses subscribed to a created alert topic.
+	Recipients []string `json:"recipients,omitempty" yaml:"recipients,omitempty"`
+}
+
+// DefaultPromptMonitoringConfig returns a PromptMonitoringConfig with sensible defaults.
+func DefaultPromptMonitoringConfig() *PromptMonitoringConfig {
+	return &PromptMonitoringConfig{
+		FilterStrength:  "HIGH",
+		SampleRate:      0.1,
+		AttackThreshold: 1,
+		PeriodSeconds:   300,
+	}
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *PromptMonitoringConfig) ApplyDefaults() {
+	if c.GuardrailARN != "" && c.GuardrailVersion == "" {
+		c.GuardrailVersion = "DRAFT"
+	}
+	if c.FilterStrength == "" {
+		c.FilterStrength = "HIGH"
+	}
+	if c.SampleRate == 0 {
+		c.SampleRate = 0.1
+	}
+	if c.AttackThreshold == 0 {
+		c.AttackThreshold = 1
+	}
+	if c.PeriodSeconds == 0 {
+		c.PeriodSeconds = 300
+	}
+}
+
+// Validate validates the PromptMonitoringConfig.
+func (c *PromptMonitoringConfig) Validate() error {
+	if c.GuardrailARN != "" && !strings.HasPrefix(c.GuardrailARN, "arn:") {
+		return fmt.Errorf("promptMonitoring.guardrailARN: '%s' is not an ARN", c.GuardrailARN)
+	}
+	if c.GuardrailARN == "" && c.GuardrailVersion != "" {
+		return fmt.Errorf("promptMonitoring.guardrailVersion can only be set with guardrailARN")
+	}
+	switch c.FilterStrength {
+	case "LOW", "MEDIUM", "HIGH":
+	default:
+		return fmt.Errorf("promptMonitoring.filterStrength: unsupported strength '%s'", c.FilterStrength)
+	}
+	if c.SampleRate <= 0 || c.SampleRate > 1 {
+		return fmt.Errorf("promptMonitoring.sampleRate must be greater than 0 and at most 1")
+	}
+	if c.AttackThreshold < 1 {
+		return fmt.Errorf("promptMonitoring.attackThreshold must be at least 1")
+	}
+	if c.PeriodSeconds < 60 || c.PeriodSeconds%60 != 0 {
+		return fmt.Errorf("promptMonitoring.periodSeconds must be a positive multiple of 60")
+	}
+	if c.TopicARN != "" && !strings.HasPrefix(c.TopicARN, "arn:") {
+		return fmt.Errorf("promptMonitoring.topicARN: '%s' is not an ARN", c.TopicARN)
+	}
+	if c.TopicARN != "" && len(c.Recipients) > 0 {
+		return fmt.Errorf("promptMonitoring.recipients can only be set when the topic is created")
+	}
+	for _, email := range c.Recipients {
+		if !strings.Contains(email, "@") {
+			return fmt.Errorf("promptMonitoring.recipients: '%s' is not an email address", email)
+		}
+	}
+	return nil
+}
+
+// createPromptMonitoring creates the guardrail, the evaluation function
+// subscribed to the agents' logs and a suspected attack alarm per agent.
+func (s *AgentCoreStack) createPromptMonitoring(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	stackName := s.Config.StackName
+	cfg := s.Options.PromptMonitoring
+
+	guardrailArn := pulumi.String(cfg.GuardrailARN).ToStringOutput()
+	guardrailVersion := pulumi.String(cfg.GuardrailVersion).ToStringOutput()
+	if cfg.GuardrailARN == "" {
+		// Prompt attack filters only apply to inputs
+		guardrail, err := bedrock.NewGuardrail(ctx, s.ResourceName("prompt-guardrail"), &bedrock.GuardrailArgs{
+			Name:                    pulumi.Sprintf("%s-prompt-attacks", stackName),
+			Description:             pulumi.Sprintf("Prompt injection and jailbreak detection for %s agents", stackName),
+			BlockedInputMessaging:   pulumi.String("Suspected prompt attack."),
+			BlockedOutputsMessaging: pulumi.String("Suspected prompt attack."),
+			ContentPolicyConfig: &bedrock.GuardrailContentPolicyConfigArgs{
+				FiltersConfigs: bedrock.GuardrailContentPolicyConfigFiltersConfigArray{
+					&bedrock.GuardrailContentPolicyConfigFiltersConfigArgs{
+						Type:           pulumi.String("PROMPT_ATTACK"),
+						InputStrength:  pulumi.String(cfg.FilterStrength),
+						OutputStrength: pulumi.String("NONE"),
+					},
+				},
+			},
+			Tags: mergeTags(tags, pulumi.Sprintf("%s-prompt-attacks", stackName)),
+		}, s.child())
+		if err != nil {
+			return err
+		}
+		version, err := bedrock.NewGuardrailVersion(ctx, s.ResourceName("prompt-guardrail-version"), &bedrock.GuardrailVersionArgs{
+			GuardrailArn: guardrail.GuardrailArn,
+			Description:  pulumi.Sprintf("Deployed by %s", stackName),
+		}, s.child())
+		if err != nil {
+			return err
+		}
+		guardrailArn = guardrail.GuardrailArn
+		guardrailVersion = version.Version
+	}
+	s.PromptGuardrailArn = guardrailArn
+
+	topic := pulumi.String(cfg.TopicARN).ToStringOutput()
+	if cfg.TopicARN == "" {
+		created, err := s.newEventTopic(ctx, "prompt-monitoring-topic", fmt.Sprintf("%s-prompt-attacks", stackName), tags)
+		if err != nil {
+			return err
+		}
+		if err := s.subscribeEmails(ctx, "prompt-monitoring-recipient", created.Arn, cfg.Recipients); err != nil {
+			return err
+		}
+		topic = created.Arn
+	}
+	s.PromptMonitoringTopic = topic
+
+	// Prompts and responses are logged to the stack log group or per-agent groups
+	groups := map[string]pulumi.StringOutput{}
+	var names []string
+	logGroupAgents := map[string]string{}
+	if s.LogGroup != nil {
+		groups["prompt-monitoring-filter"] = s.LogGroup.Name
+		names = append(names, "prompt-monitoring-filter")
+	}
+	for _, agent := range s.Config.Agents {
+		if group, ok := s.AgentLogGroups[agent.Name]; ok {
+			resourceName := fmt.Sprintf("prompt-monitoring-filter-%s", agent.Name)
+			groups[resourceName] = group
+			names = append(names, resourceName)
+			logGroupAgents[agentLogGroupName(stackName, agent.Name)] = agent.Name
+		}
+	}
+	sort.Strings(names)
+	agentsJSON, err := json.Marshal(logGroupAgents)
+	if err != nil {
+		return err
+	}
+	fields := s.logging().Fields
+	fieldsJSON, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	filterPattern := cfg.FilterPattern
+	if filterPattern == "" {
+		filterPattern = fmt.Sprintf(`{ $.%s = "*" || $.%s = "*" }`, fields.Prompt, fields.Response)
+	}
+
+	function, err := s.newInlineFunction(ctx, "prompt-monitoring", promptMonitorHandler, 60,
+		pulumi.StringMap{
+			"GUARDRAIL_ID":      guardrailArn,
+			"GUARDRAIL_VERSION": guardrailVersion,
+			"SAMPLE_RATE":       pulumi.Sprintf("%g", cfg.SampleRate),
+			"LOG_GROUP_AGENTS":  pulumi.String(string(agentsJSON)),
+			"LOG_FIELDS":        pulumi.String(string(fieldsJSON)),
+			"METRIC_NAMESPACE":  pulumi.String(s.metricNamespace()),
+		},
+		tags,
+		policyStatement{
+			Actions:   []string{"bedrock:ApplyGuardrail"},
+			Resources: pulumi.StringArray{guardrailArn},
+		},
+		policyStatement{
+			Actions:   []string{"cloudwatch:PutMetricData"},
+			Resources: pulumi.StringArray{pulumi.String("*")},
+			Conditions: map[string]map[string]interface{}{
+				"StringEquals": {"cloudwatch:namespace": s.metricNamespace()},
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	region, err := aws.GetRegion(ctx, nil, s.invoke())
+	if err != nil {
+		return err
+	}
+	caller, err := aws.GetCallerIdentity(ctx, nil, s.invoke())
+	if err != nil {
+		return err
+	}
+
+	var filters []pulumi.Resource
+	for _, name := range names {
+		permission, err := lambda.NewPermission(ctx, s.ResourceName(name+"-permission"), &lambda.PermissionArgs{
+			Function:  function.Name,
+			Action:    pulumi.String("lambda:InvokeFunction"),
+			Principal: pulumi.String("logs.amazonaws.com"),
+			SourceArn: pulumi.Sprintf("arn:aws:logs:%s:%s:log-group:%s:*", region.Name, caller.AccountId, groups[name]),
+		}, s.child())
+		if err != nil {
+			return err
+		}
+		filter, err := cloudwatch.NewLogSubscriptionFilter(ctx, s.ResourceName(name), &cloudwatch.LogSubscriptionFilterArgs{
+			Name:           pulumi.Sprintf("%s-prompt-monitoring", stackName),
+			LogGroup:       groups[name],
+			FilterPattern:  pulumi.String(filterPattern),
+			DestinationArn: function.Arn,
+		}, pulumi.DependsOn([]pulumi.Resource{permission}), s.child())
+		if err != nil {
+			return err
+		}
+		filters = append(filters, filter)
+	}
+
+	for _, agent := range s.Config.Agents {
+		alarmName := fmt.Sprintf("%s-%s-prompt-attacks", stackName, agent.Name)
+		_, err = cloudwatch.NewMetricAlarm(ctx, s.ResourceName(fmt.Sprintf("%s-prompt-attacks-alarm", agent.Name)), &cloudwatch.MetricAlarmArgs{
+			Name:               pulumi.String(alarmName),
+			AlarmDescription:   pulumi.Sprintf("Suspected prompt injection or jailbreak attempts against agent %s", agent.Name),
+			Namespace:          pulumi.String(s.metricNamespace()),
+			MetricName:         pulumi.String("PromptAttacks"),
+			Dimensions:         pulumi.StringMap{"Agent": pulumi.String(agent.Name)},
+			Statistic:          pulumi.String("Sum"),
+			Period:             pulumi.Int(cfg.PeriodSeconds),
+			EvaluationPeriods:  pulumi.Int(1),
+			Threshold:          pulumi.Float64(float64(cfg.AttackThreshold)),
+			ComparisonOperator: pulumi.String("GreaterThanOrEqualToThreshold"),
+			TreatMissingData:   pulumi.String("notBreaching"),
+			AlarmActions:       pulumi.Array{topic},
+			Tags:               mergeTags(tags, pulumi.String(alarmName)),
+		}, pulumi.DependsOn(filters), s.child())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package agentcore
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// StackConfigKey is the Pulumi config value holding the StackConfig read by
+// NewStackFromPulumiConfig, as a structured object in Pulumi.<stack>.yaml:
+//
+//	config:
+//	  agentcore:stack:
+//	    agents:
+//	      - name: research
+//	        containerImage: ghcr.io/example/research:v1.2.0
+//	    secrets:
+//	      createSecrets: true
+//	      secretValues:
+//	        GEMINI_API_KEY:
+//	          secure: AAABAHb...
+//
+// Values set with "pulumi config set --path --secret" are decrypted when
+// the config is read, and placeholders are resolved as LoadStackConfig
+// does. Every stack carries its own complete value; Pulumi does not merge
+// objects across Pulumi.yaml and Pulumi.<stack>.yaml.
+const StackConfigKey = "agentcore:stack"
+
+// OptionsConfigKey is the Pulumi config value holding the Options read by
+// NewStackFromPulumiConfig, as a structured object next to StackConfigKey:
+//
+//	config:
+//	  agentcore:options:
+//	    stateTable: {}
+//	    agents:
+//	      research:
+//	        replicas: 2
+//
+// Options that are Go values, such as Provider, DependsOn and
+// ResourceFactory, cannot be set in config.
+const OptionsConfigKey = "agentcore:options"
+
+// LoadStackConfigFromPulumiConfig reads a StackConfig from the StackConfigKey
+// value of the current Pulumi stack. StackName defaults to
+// "{project}-{stack}", so stacks of one project get distinct resource names.
+func LoadStackConfigFromPulumiConfig(ctx *pulumi.Context) (*iac.StackConfig, error) {
+	data, ok := ctx.GetConfig(StackConfigKey)
+	if !ok {
+		return nil, fmt.Errorf("pulumi config %s is not set", StackConfigKey)
+	}
+
+	doc := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(data), &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse pulumi config %s: %w", StackConfigKey, err)
+	}
+	if name, _ := doc["stackName"].(string); name == "" {
+		doc["stackName"] = fmt.Sprintf("%s-%s", ctx.Project(), ctx.Stack())
+	}
+	return decodeStackConfig(ctx, doc)
+}
+
+// LoadOptionsFromPulumiConfig reads Options from the OptionsConfigKey value
+// of the current Pulumi stack, resolving placeholders as LoadStackConfig
+// does. It returns empty Options if the value is not set.
+func LoadOptionsFromPulumiConfig(ctx *pulumi.Context) (Options, error) {
+	data, ok := ctx.GetConfig(OptionsConfigKey)
+	if !ok {
+		return Options{}, nil
+	}
+
+	doc := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(data), &doc); err != nil {
+		return Options{}, fmt.Errorf("failed to parse pulumi config %s: %w", OptionsConfigKey, err)
+	}
+	options, err := decodeOptions(ctx, doc)
+	if err != nil {
+		return Options{}, fmt.Errorf("pulumi config %s: %w", OptionsConfigKey, err)
+	}
+	return options, nil
+}
+
+// decodeOptions resolves the placeholders of an options document and
+// decodes it, rejecting unknown fields.
+func decodeOptions(ctx *pulumi.Context, doc map[string]interface{}) (Options, error) {
+	resolved, err := resolvePlaceholders(ctx, doc)
+	if err != nil {
+		return Options{}, err
+	}
+	data, err := json.Marshal(resolved)
+	if err != nil {
+		return Options{}, err
+	}
+	var options Options
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&options); err != nil {
+		return Options{}, fmt.Errorf("failed to parse options: %w", err)
+	}
+	return options, nil
+}
+
+// NewStackFromPulumiConfig creates an AgentCoreStack from the StackConfigKey
+// and OptionsConfigKey values of the current Pulumi stack, so settings that
+// differ between stacks, such as dev and prod, live in Pulumi.<stack>.yaml.
+func NewStackFromPulumiConfig(ctx *pulumi.Context) (*AgentCoreStack, error) {
+	config, err := LoadStackConfigFromPulumiConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	options, err := LoadOptionsFromPulumiConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewAgentCoreStackWithOptions(ctx, *config, options)
+}
+
+// MustNewStackFromPulumiConfig is like NewStackFromPulumiConfig but panics on error.
+func MustNewStackFromPulumiConfig(ctx *pulumi.Context) *AgentCoreStack {
+	stack, err := NewStackFromPulumiConfig(ctx)
+	if err != nil {
+		panic(fmt.Sprintf("failed to create stack from pulumi config: %v", err))
+	}
+	return stack
+}
@@ -0,0 +1,191 @@
+package agentcore
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/ec2"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/iam"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/lambda"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/servicequotas"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// QuotaReportConfig reports the quota-limited resources the stack creates
+// against the account's quotas and current usage, so teams deploying many
+// stacks can see their headroom with every deployment.
+//
+// Usage is measured when the program runs, before the deployment's own
+// changes, so on a stack's first deployment it does not yet include the
+// stack's resources. Network interfaces are an estimate covering interface
+// endpoints, NAT gateways and VPC functions; managed services add their own.
+type QuotaReportConfig struct {
+	// WarnPercent logs a warning when usage of a quota reaches this
+	// percentage.
+	// Range: 1-100
+	// Default: 80
+	WarnPercent int `json:"warnPercent,omitempty" yaml:"warnPercent,omitempty"`
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *QuotaReportConfig) ApplyDefaults() {
+	if c.WarnPercent == 0 {
+		c.WarnPercent = 80
+	}
+}
+
+// Validate validates the QuotaReportConfig.
+func (c *QuotaReportConfig) Validate() error {
+	if c.WarnPercent < 1 || c.WarnPercent > 100 {
+		return fmt.Errorf("quotaReport.warnPercent must be between 1 and 100")
+	}
+	return nil
+}
+
+// QuotaUsage is the use of one account quota.
+type QuotaUsage struct {
+	// Created is the number the stack creates.
+	Created int
+
+	// InUse is the number in use in the account and region, or for
+	// per-VPC quotas the number the stack creates in its VPC.
+	InUse int
+
+	// Quota is the applied quota, or zero if it could not be read.
+	Quota int
+
+	// Headroom is Quota minus InUse.
+	Headroom int
+
+	// StacksRemaining is how many more copies of the stack fit in Headroom.
+	StacksRemaining int
+
+	// Detail explains a quota or usage that could not be read.
+	Detail string
+}
+
+// quotaDefinition identifies a quota in Service Quotas.
+type quotaDefinition struct {
+	serviceCode string
+	quotaCode   string
+}
+
+// reportedQuotas are the quotas in the report, keyed by output name.
+var reportedQuotas = map[string]quotaDefinition{
+	"elasticIps":         {"ec2", "L-0263D0A3"},
+	"networkInterfaces":  {"vpc", "L-DF5E4CA3"},
+	"interfaceEndpoints": {"vpc", "L-29B6F2EB"},
+	"iamRoles":           {"iam", "L-FE177D64"},
+}
+
+// resourceCounter counts the quota-limited resources registered under the
+// stack through a transformation.
+type resourceCounter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// transformation counts a resource and leaves it unchanged.
+func (c *resourceCounter) transformation(args *pulumi.ResourceTransformationArgs) *pulumi.ResourceTransformationResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch props := args.Props.(type) {
+	case *ec2.EipArgs:
+		c.counts["elasticIps"]++
+	case *ec2.NatGatewayArgs:
+		c.counts["networkInterfaces"]++
+	case *ec2.VpcEndpointArgs:
+		if endpointType, ok := props.VpcEndpointType.(pulumi.String); ok && endpointType == "Interface" {
+			c.counts["interfaceEndpoints"]++
+			if subnets, ok := props.SubnetIds.(pulumi.StringArray); ok {
+				c.counts["networkInterfaces"] += len(subnets)
+			}
+		}
+	case *lambda.FunctionArgs:
+		if vpc, ok := props.VpcConfig.(*lambda.FunctionVpcConfigArgs); ok {
+			if subnets, ok := vpc.SubnetIds.(pulumi.StringArray); ok {
+				c.counts["networkInterfaces"] += len(subnets)
+			}
+		}
+	case *iam.RoleArgs:
+		c.counts["iamRoles"]++
+	}
+	return nil
+}
+
+// quotaInUse returns the account usage counted against a quota.
+func (s *AgentCoreStack) quotaInUse(ctx *pulumi.Context, name string) (int, error) {
+	switch name {
+	case "elasticIps":
+		eips, err := ec2.GetEips(ctx, nil, s.invoke())
+		if err != nil {
+			return 0, err
+		}
+		return len(eips.AllocationIds), nil
+	case "networkInterfaces":
+		enis, err := ec2.GetNetworkInterfaces(ctx, nil, s.invoke())
+		if err != nil {
+			return 0, err
+		}
+		return len(enis.Ids), nil
+	case "iamRoles":
+		roles, err := iam.GetRoles(ctx, nil, s.invoke())
+		if err != nil {
+			return 0, err
+		}
+		return len(roles.Arns), nil
+	default:
+		return s.quotaCounter.counts[name], nil
+	}
+}
+
+// reportQuotaUsage compares the counted resources with the account quotas
+// and warns about quotas nearing their limit. It runs after all resources
+// are created.
+func (s *AgentCoreStack) reportQuotaUsage(ctx *pulumi.Context) error {
+	warnPercent := s.Options.QuotaReport.WarnPercent
+
+	names := make([]string, 0, len(reportedQuotas))
+	for name := range reportedQuotas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		def := reportedQuotas[name]
+		usage := QuotaUsage{Created: s.quotaCounter.counts[name]}
+
+		inUse, err := s.quotaInUse(ctx, name)
+		if err != nil {
+			usage.Detail = fmt.Sprintf("reading usage: %v", err)
+		}
+		usage.InUse = inUse
+
+		quotaCode := def.quotaCode
+		quota, err := servicequotas.LookupServiceQuota(ctx, &servicequotas.LookupServiceQuotaArgs{
+			ServiceCode: def.serviceCode,
+			QuotaCode:   &quotaCode,
+		}, s.invoke())
+		if err != nil {
+			usage.Detail = fmt.Sprintf("reading quota %s: %v", quotaCode, err)
+			s.QuotaUsage[name] = usage
+			continue
+		}
+		usage.Quota = int(quota.Value)
+		usage.Headroom = usage.Quota - usage.InUse
+		if usage.Created > 0 && usage.Headroom > 0 {
+			usage.StacksRemaining = usage.Headroom / usage.Created
+		}
+		s.QuotaUsage[name] = usage
+
+		if usage.Quota > 0 && usage.InUse*100 >= usage.Quota*warnPercent {
+			if err := ctx.Log.Warn(fmt.Sprintf("%s: %d of %d in use (%s %s)",
+				quota.QuotaName, usage.InUse, usage.Quota, def.serviceCode, quotaCode), &pulumi.LogArgs{Resource: s}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
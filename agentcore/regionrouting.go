@@ -0,0 +1,150 @@
+package agentcore
+
+import (
+	"fmt"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/route53"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi/config"
+)
+
+// RegionWeightConfigKey is the Pulumi config value that overrides
+// RegionRoutingConfig.Weight, so traffic can be shifted between regions
+// with "pulumi config set agentcore:regionWeight 20" and an update, without
+// changing code.
+const RegionWeightConfigKey = "agentcore:regionWeight"
+
+// RegionRoutingConfig routes the agent subdomain names between regions when
+// the same stack is deployed to several regions with the same zone and
+// domain. Each regional stack creates its own weighted or latency record
+// for every agent name, identified by SetIdentifier.
+//
+// With weighted routing, traffic is split in proportion to the regions'
+// weights; lower a region's weight step by step to drain it during a
+// rollout or incident, and set it to 0 to stop routing to it.
+type RegionRoutingConfig struct {
+	// Policy is the Route 53 routing policy.
+	// Supported: "weighted", "latency"
+	// Default: "weighted"
+	Policy string `json:"policy,omitempty" yaml:"policy,omitempty"`
+
+	// SetIdentifier distinguishes this region's records from the other
+	// regions'.
+	// Default: the stack's region
+	SetIdentifier string `json:"setIdentifier,omitempty" yaml:"setIdentifier,omitempty"`
+
+	// Weight is this region's share of traffic with weighted routing. The
+	// agentcore:regionWeight config value overrides it.
+	// Range: 0-255
+	// Default: 100
+	Weight *int `json:"weight,omitempty" yaml:"weight,omitempty"`
+
+	// HealthCheckID is a Route 53 health check of this region. Records of
+	// unhealthy regions are not returned while a healthy region remains.
+	HealthCheckID string `json:"healthCheckID,omitempty" yaml:"healthCheckID,omitempty"`
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *RegionRoutingConfig) ApplyDefaults() {
+	if c.Policy == "" {
+		c.Policy = "weighted"
+	}
+	if c.Weight == nil && c.Policy == "weighted" {
+		weight := 100
+		c.Weight = &weight
+	}
+}
+
+// Validate validates the RegionRoutingConfig.
+func (c *RegionRoutingConfig) Validate() error {
+	if c.Policy != "weighted" && c.Policy != "latency" {
+		return fmt.Errorf("agentSubdomains.regionRouting.policy must be one of [weighted latency]")
+	}
+	if c.Weight != nil {
+		if c.Policy != "weighted" {
+			return fmt.Errorf("agentSubdomains.regionRouting.weight requires policy weighted")
+		}
+		if err := validateRegionWeight(*c.Weight); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateRegionWeight validates a Route 53 record weight.
+func validateRegionWeight(weight int) error {
+	if weight < 0 || weight > 255 {
+		return fmt.Errorf("agentSubdomains.regionRouting.weight must be between 0 and 255")
+	}
+	return nil
+}
+
+// regionRouting is the resolved routing of this region's records.
+type regionRouting struct {
+	policy        string
+	setIdentifier string
+	region        string
+	weight        int
+}
+
+// resolveRegionRouting resolves the region, set identifier and weight of
+// the subdomain records, applying the agentcore:regionWeight config value.
+func (s *AgentCoreStack) resolveRegionRouting(ctx *pulumi.Context) (*regionRouting, error) {
+	cfg := s.Options.AgentSubdomains.RegionRouting
+
+	region, err := aws.GetRegion(ctx, nil, s.invoke())
+	if err != nil {
+		return nil, err
+	}
+	routing := &regionRouting{
+		policy:        cfg.Policy,
+		setIdentifier: cfg.SetIdentifier,
+		region:        region.Name,
+	}
+	if routing.setIdentifier == "" {
+		routing.setIdentifier = region.Name
+	}
+	if cfg.Policy != "weighted" {
+		return routing, nil
+	}
+
+	routing.weight = *cfg.Weight
+	if weight, err := config.TryInt(ctx, RegionWeightConfigKey); err == nil {
+		if err := validateRegionWeight(weight); err != nil {
+			return nil, err
+		}
+		routing.weight = weight
+	}
+	return routing, nil
+}
+
+// apply sets the routing policy of a subdomain record.
+func (r *regionRouting) apply(args *route53.RecordArgs, healthCheckID string) {
+	args.SetIdentifier = pulumi.String(r.setIdentifier)
+	if healthCheckID != "" {
+		args.HealthCheckId = pulumi.String(healthCheckID)
+	}
+	if r.policy == "latency" {
+		args.LatencyRoutingPolicies = route53.RecordLatencyRoutingPolicyArray{
+			&route53.RecordLatencyRoutingPolicyArgs{Region: pulumi.String(r.region)},
+		}
+		return
+	}
+	args.WeightedRoutingPolicies = route53.RecordWeightedRoutingPolicyArray{
+		&route53.RecordWeightedRoutingPolicyArgs{Weight: pulumi.Int(r.weight)},
+	}
+}
+
+// output returns the routing as a stack output.
+func (r *regionRouting) output() pulumi.Map {
+	output := pulumi.Map{
+		"policy":        pulumi.String(r.policy),
+		"setIdentifier": pulumi.String(r.setIdentifier),
+		"region":        pulumi.String(r.region),
+	}
+	if r.policy == "weighted" {
+		output["weight"] = pulumi.Int(r.weight)
+	}
+	return output
+}
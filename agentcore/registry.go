@@ -0,0 +1,237 @@
+package agentcore
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/servicecatalog"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// RegistryConfig registers the stack and its agents in AWS Service Catalog
+// AppRegistry so enterprise inventory systems can track agent deployments.
+//
+// The stack becomes an AppRegistry application. Every resource is tagged
+// with the application tag, owner and data classification, and each agent
+// gets an attribute group describing it.
+type RegistryConfig struct {
+	// Owner is the team or contact responsible for the agents. Required.
+	Owner string `json:"owner" yaml:"owner"`
+
+	// DataClassification is the sensitivity of data the agents handle.
+	// Supported: "public", "internal", "confidential", "restricted"
+	// Default: "internal"
+	DataClassification string `json:"dataClassification,omitempty" yaml:"dataClassification,omitempty"`
+
+	// Attributes are additional attributes recorded for every agent,
+	// e.g. cost center or compliance scope.
+	Attributes map[string]string `json:"attributes,omitempty" yaml:"attributes,omitempty"`
+
+	// RuntimeARNs overrides the runtime ARNs recorded with the agents,
+	// keyed by agent name. Agents are recorded with the live endpoints of
+	// the stack's runtimes by default.
+	// Optional.
+	RuntimeARNs map[string]string `json:"runtimeARNs,omitempty" yaml:"runtimeARNs,omitempty"`
+
+	// WebhookURL receives the registration as JSON after each deployment,
+	// for inventory systems outside AWS. Delivery failures are logged as
+	// warnings and do not fail the deployment.
+	WebhookURL string `json:"webhookUrl,omitempty" yaml:"webhookUrl,omitempty"`
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *RegistryConfig) ApplyDefaults() {
+	if c.DataClassification == "" {
+		c.DataClassification = "internal"
+	}
+}
+
+// Validate validates the RegistryConfig against the stack configuration.
+func (c *RegistryConfig) Validate(config iac.StackConfig) error {
+	if c.Owner == "" {
+		return fmt.Errorf("registry.owner is required")
+	}
+	switch c.DataClassification {
+	case "public", "internal", "confidential", "restricted":
+	default:
+		return fmt.Errorf("registry.dataClassification must be one of [public internal confidential restricted]")
+	}
+	for name, arn := range c.RuntimeARNs {
+		if !hasAgent(config, name) {
+			return fmt.Errorf("registry.runtimeARNs: '%s' does not match any agent name", name)
+		}
+		if !strings.HasPrefix(arn, "arn:") {
+			return fmt.Errorf("registry.runtimeARNs[%s]: '%s' is not an ARN", name, arn)
+		}
+	}
+	if c.WebhookURL != "" && !strings.HasPrefix(c.WebhookURL, "https://") {
+		return fmt.Errorf("registry.webhookUrl must be an https URL")
+	}
+	return nil
+}
+
+// AgentRegistration is the inventory record of a deployed agent. It is
+// stored as the agent's AppRegistry attribute group and sent to the webhook.
+type AgentRegistration struct {
+	Agent              string            `json:"agent"`
+	Stack              string            `json:"stack"`
+	Description        string            `json:"description,omitempty"`
+	Owner              string            `json:"owner"`
+	DataClassification string            `json:"dataClassification"`
+	Image              string            `json:"image"`
+	Protocol           string            `json:"protocol,omitempty"`
+	Endpoints          []AgentEndpoint   `json:"endpoints,omitempty"`
+	Attributes         map[string]string `json:"attributes,omitempty"`
+}
+
+// StackRegistration is the payload sent to RegistryConfig.WebhookURL.
+type StackRegistration struct {
+	Stack          string              `json:"stack"`
+	Region         string              `json:"region"`
+	ApplicationARN string              `json:"applicationArn"`
+	Agents         []AgentRegistration `json:"agents"`
+	DeployedAt     time.Time           `json:"deployedAt"`
+}
+
+// registryTags returns the tags applied to every stack resource once the
+// AppRegistry application exists.
+func (s *AgentCoreStack) registryTags() pulumi.StringMap {
+	cfg := s.Options.Registry
+	return pulumi.StringMap{
+		"awsApplication":     s.Application.ApplicationTag.MapIndex(pulumi.String("awsApplication")),
+		"Owner":              pulumi.String(cfg.Owner),
+		"DataClassification": pulumi.String(cfg.DataClassification),
+	}
+}
+
+// createRegistry creates the AppRegistry application, whose tag the
+// stack's resources carry. The agents are registered by
+// createRegistryAgents once their runtimes exist.
+func (s *AgentCoreStack) createRegistry(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	var err error
+	stackName := s.Config.StackName
+
+	s.Application, err = servicecatalog.NewAppregistryApplication(ctx, s.ResourceName("application"), &servicecatalog.AppregistryApplicationArgs{
+		Name:        pulumi.String(stackName),
+		Description: pulumi.Sprintf("AgentCore agents deployed by %s", stackName),
+		Tags:        mergeTags(tags, pulumi.String(stackName)),
+	}, s.child())
+	return err
+}
+
+// createRegistryAgents creates an attribute group per agent with its
+// endpoints, and sends the registration to the webhook if configured. It
+// runs after createAgentRuntimes.
+func (s *AgentCoreStack) createRegistryAgents(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	stackName := s.Config.StackName
+	cfg := s.Options.Registry
+
+	region, err := aws.GetRegion(ctx, nil, s.invoke())
+	if err != nil {
+		return err
+	}
+
+	var registrations []interface{}
+	for _, agent := range s.Config.Agents {
+		registration := AgentRegistration{
+			Agent:              agent.Name,
+			Stack:              stackName,
+			Description:        agent.Description,
+			Owner:              cfg.Owner,
+			DataClassification: cfg.DataClassification,
+			Image:              agent.ContainerImage,
+			Protocol:           agent.Protocol,
+			Attributes:         cfg.Attributes,
+		}
+
+		// Replicas are recorded as one endpoint each
+		var runtimeArns []interface{}
+		qualifier := runtimeEndpointName
+		if arn, ok := cfg.RuntimeARNs[agent.Name]; ok {
+			runtimeArns = append(runtimeArns, pulumi.String(arn))
+			qualifier = DefaultQualifier
+		} else {
+			for _, name := range s.agentRuntimeNames(agent.Name) {
+				runtimeArns = append(runtimeArns, s.AgentRuntimes[name].RuntimeArn)
+			}
+		}
+		attributes := pulumi.All(runtimeArns...).ApplyT(func(arns []interface{}) (string, error) {
+			r := registration
+			for _, arn := range arns {
+				r.Endpoints = append(r.Endpoints, AgentEndpoint{
+					RuntimeArn: arn.(string),
+					Qualifier:  qualifier,
+					InvokeURL:  AgentInvokeURL(region.Name, arn.(string), qualifier),
+				})
+			}
+			data, err := json.Marshal(r)
+			return string(data), err
+		}).(pulumi.StringOutput)
+		registrations = append(registrations, attributes)
+
+		group, err := servicecatalog.NewAppregistryAttributeGroup(ctx, s.ResourceName(fmt.Sprintf("%s-attribute-group", agent.Name)), &servicecatalog.AppregistryAttributeGroupArgs{
+			Name:        pulumi.Sprintf("%s-%s", stackName, agent.Name),
+			Description: pulumi.Sprintf("Inventory record for agent %s", agent.Name),
+			Attributes:  attributes,
+			Tags:        mergeTags(tags, pulumi.Sprintf("%s-%s", stackName, agent.Name)),
+		}, s.child())
+		if err != nil {
+			return err
+		}
+		_, err = servicecatalog.NewAppregistryAttributeGroupAssociation(ctx, s.ResourceName(fmt.Sprintf("%s-attribute-group-association", agent.Name)), &servicecatalog.AppregistryAttributeGroupAssociationArgs{
+			ApplicationId:    s.Application.ID(),
+			AttributeGroupId: group.ID(),
+		}, s.child())
+		if err != nil {
+			return err
+		}
+	}
+
+	if cfg.WebhookURL != "" && !ctx.DryRun() {
+		pulumi.All(append([]interface{}{s.Application.Arn}, registrations...)...).ApplyT(func(values []interface{}) error {
+			registration := StackRegistration{
+				Stack:          stackName,
+				Region:         region.Name,
+				ApplicationARN: values[0].(string),
+				DeployedAt:     time.Now().UTC(),
+			}
+			for _, attributes := range values[1:] {
+				var agent AgentRegistration
+				if err := json.Unmarshal([]byte(attributes.(string)), &agent); err != nil {
+					return err
+				}
+				registration.Agents = append(registration.Agents, agent)
+			}
+			if err := postRegistration(cfg.WebhookURL, registration); err != nil {
+				_ = ctx.Log.Warn(fmt.Sprintf("registry webhook failed: %v", err), nil)
+			}
+			return nil
+		})
+	}
+
+	return nil
+}
+
+// postRegistration sends a stack registration to an inventory webhook.
+func postRegistration(webhookURL string, registration StackRegistration) error {
+	body, err := json.Marshal(registration)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(webhookURL, "application/json", bytes.NewReader(body)) //nolint:noctx // runs inside an apply without a context
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,286 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/lambda"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// maxReplicas is the largest number of runtimes created for one agent.
+const maxReplicas = 10
+
+// routerHandler forwards function URL and HTTP API requests to one of an
+// agent's runtimes. Requests with a session ID are routed by its hash, so a
+// session always reaches the same replica; others get a new session. For
+// agents deployed blue/green with weights or as canaries, the hash also
+// picks the green endpoint or the stable one.
+//
+// In maintenance mode every request is answered with a 503 and the
+// MAINTENANCE_RESPONSE body. Requests that do not match the agent's input
+// schema are rejected before a runtime is invoked, and JSON responses that do not match its output
+// schema are replaced with a 502. The schemas are appended to the source by
+// newAgentProxy; the validator covers the common JSON Schema keywords.
+const routerHandler = `import { createHash, randomUUID } from "node:crypto";
+import { BedrockAgentCoreClient, InvokeAgentRuntimeCommand } from "@aws-sdk/client-bedrock-agentcore";
+import { SSMClient, GetParameterCommand } from "@aws-sdk/client-ssm";
+
+const client = new BedrockAgentCoreClient({});
+const ssm = new SSMClient({});
+const replicas = JSON.parse(process.env.REPLICA_ARNS);
+const sessionHeader = "x-amzn-bedrock-agentcore-runtime-session-id";
+
+const typeOf = (value) =>
+  Array.isArray(value) ? "array" : value === null ? "null" : Number.isInteger(value) ? "integer" : typeof value;
+
+const validate = (schema, value, path = "$") => {
+  if (!schema) return [];
+  const type = typeOf(value);
+  const types = [].concat(schema.type ?? []);
+  if (types.length && !types.includes(type) && !(type === "integer" && types.includes("number"))) {
+    return [path + " must be " + types.join(" or ")];
+  }
+  const errors = [];
+  if (schema.enum && !schema.enum.some((e) => JSON.stringify(e) === JSON.stringify(value))) {
+    errors.push(path + " must be one of " + JSON.stringify(schema.enum));
+  }
+  if (type === "string") {
+    if (value.length < (schema.minLength ?? 0)) errors.push(path + " is shorter than " + schema.minLength);
+    if (value.length > (schema.maxLength ?? Infinity)) errors.push(path + " is longer than " + schema.maxLength);
+    if (schema.pattern && !new RegExp(schema.pattern, "u").test(value)) errors.push(path + " must match " + schema.pattern);
+  }
+  if (type === "number" || type === "integer") {
+    if (value < (schema.minimum ?? -Infinity)) errors.push(path + " is less than " + schema.minimum);
+    if (value > (schema.maximum ?? Infinity)) errors.push(path + " is greater than " + schema.maximum);
+  }
+  if (type === "object") {
+    for (const key of schema.required ?? []) {
+      if (!(key in value)) errors.push(path + "." + key + " is required");
+    }
+    for (const [key, child] of Object.entries(value)) {
+      const property = schema.properties?.[key];
+      if (property) errors.push(...validate(property, child, path + "." + key));
+      else if (schema.additionalProperties === false) errors.push(path + "." + key + " is not allowed");
+    }
+  }
+  if (type === "array") {
+    if (value.length < (schema.minItems ?? 0)) errors.push(path + " has fewer than " + schema.minItems + " items");
+    if (value.length > (schema.maxItems ?? Infinity)) errors.push(path + " has more than " + schema.maxItems + " items");
+    value.forEach((item, i) => errors.push(...validate(schema.items, item, path + "[" + i + "]")));
+  }
+  return errors;
+};
+
+// Canaries read their weight from the controller's traffic parameter,
+// cached for 30 seconds; weighted deployments have a fixed GREEN_WEIGHT.
+let traffic = { weight: 0, expires: 0 };
+const greenWeight = async () => {
+  if (!process.env.TRAFFIC_PARAMETER) return Number(process.env.GREEN_WEIGHT);
+  if (Date.now() >= traffic.expires) {
+    const { Parameter } = await ssm.send(new GetParameterCommand({ Name: process.env.TRAFFIC_PARAMETER }));
+    traffic = { weight: JSON.parse(Parameter.Value).weight, expires: Date.now() + 30000 };
+  }
+  return traffic.weight;
+};
+
+const reject = (statusCode, message, errors = []) => ({
+  statusCode,
+  headers: { "Content-Type": "application/json" },
+  body: JSON.stringify({ message, errors }),
+});
+
+const parse = (text) => {
+  try {
+    return { value: JSON.parse(text) };
+  } catch {
+    return {};
+  }
+};
+
+export const handler = async (event) => {
+  if (process.env.MAINTENANCE_RESPONSE) {
+    return {
+      statusCode: 503,
+      headers: {
+        "Content-Type": "application/json",
+        ...(process.env.MAINTENANCE_RETRY_AFTER && { "Retry-After": process.env.MAINTENANCE_RETRY_AFTER }),
+      },
+      body: process.env.MAINTENANCE_RESPONSE,
+    };
+  }
+
+  const headers = event.headers ?? {};
+  const payload = Buffer.from(event.body ?? "", event.isBase64Encoded ? "base64" : "utf8");
+
+  if (inputSchema) {
+    const request = parse(payload.toString("utf8"));
+    if (!("value" in request)) return reject(400, "request body must be JSON");
+    const errors = validate(inputSchema, request.value);
+    if (errors.length) return reject(400, "request does not match the agent's input schema", errors);
+  }
+
+  // Session IDs must be at least 33 characters
+  const sessionId = headers[sessionHeader] ?? randomUUID() + randomUUID();
+  const hash = createHash("sha256").update(sessionId).digest();
+  const replica = hash.readUInt32BE(0) % replicas.length;
+
+  // Blue/green agents send a share of sessions to the new version
+  let qualifier = process.env.QUALIFIER;
+  if (process.env.STABLE_QUALIFIER) {
+    qualifier = hash.readUInt32BE(4) % 100 < await greenWeight() ? "green" : process.env.STABLE_QUALIFIER;
+  }
+
+  const result = await client.send(new InvokeAgentRuntimeCommand({
+    agentRuntimeArn: replicas[replica],
+    qualifier,
+    runtimeSessionId: sessionId,
+    contentType: headers["content-type"] ?? "application/json",
+    accept: headers.accept ?? "application/json",
+    payload,
+  }));
+  const contentType = result.contentType ?? "application/json";
+  const body = await result.response.transformToString();
+
+  if (outputSchema && contentType.includes("json")) {
+    const response = parse(body);
+    const errors = "value" in response ? validate(outputSchema, response.value) : ["$ must be JSON"];
+    if (errors.length) return reject(502, "agent response does not match its output schema", errors);
+  }
+
+  return {
+    statusCode: result.statusCode ?? 200,
+    headers: { "Content-Type": contentType, [sessionHeader]: sessionId },
+    body,
+  };
+};
+`
+
+// agentProxySource returns the proxy source for an agent, with its declared
+// schemas, or null for those it does not declare.
+func (s *AgentCoreStack) agentProxySource(agentName string) string {
+	opts := s.Options.Agents[agentName]
+	schema := func(raw json.RawMessage) string {
+		if len(raw) == 0 {
+			return "null"
+		}
+		return string(raw)
+	}
+	return fmt.Sprintf("%s\nconst inputSchema = %s;\nconst outputSchema = %s;\n",
+		routerHandler, schema(opts.InputSchema), schema(opts.OutputSchema))
+}
+
+// replicaName returns the name of an agent's replica, such as research-0.
+func replicaName(agentName string, replica int) string {
+	return fmt.Sprintf("%s-%d", agentName, replica)
+}
+
+// agentRuntimeNames returns the names of an agent's runtimes in
+// AgentRuntimes: the agent name, or one name per replica. Lambda agents
+// have none.
+func (s *AgentCoreStack) agentRuntimeNames(agentName string) []string {
+	if s.isLambdaAgent(agentName) {
+		return nil
+	}
+	replicas := s.Options.Agents[agentName].Replicas
+	if replicas <= 1 {
+		return []string{agentName}
+	}
+	names := make([]string, replicas)
+	for i := range names {
+		names[i] = replicaName(agentName, i)
+	}
+	return names
+}
+
+// validateReplicas validates AgentOptions.Replicas for the named agent.
+func validateReplicas(agentName string, replicas int) error {
+	if replicas < 0 || replicas > maxReplicas {
+		return fmt.Errorf("agents[%s].replicas must be between 0 and %d (0 means 1)", agentName, maxReplicas)
+	}
+	return nil
+}
+
+// newAgentProxy creates a function that validates requests against the
+// agent's schemas and forwards them to its runtimes, routing by session ID
+// when there is more than one. In maintenance mode it answers requests
+// itself.
+func (s *AgentCoreStack) newAgentProxy(ctx *pulumi.Context, name, agentName string, tags pulumi.StringMap) (*lambda.Function, error) {
+	runtimeArns := s.agentRuntimeArns(agentName)
+	arnsJSON := runtimeArns.ToStringArrayOutput().ApplyT(func(arns []string) (string, error) {
+		data, err := json.Marshal(arns)
+		return string(data), err
+	}).(pulumi.StringOutput)
+
+	var resources pulumi.StringArray
+	for _, arn := range runtimeArns {
+		resources = append(resources, arn, pulumi.Sprintf("%s/runtime-endpoint/*", arn))
+	}
+
+	env := pulumi.StringMap{
+		"REPLICA_ARNS": arnsJSON,
+		"QUALIFIER":    pulumi.String(runtimeEndpointName),
+	}
+	maintenance, err := s.maintenanceEnv(agentName)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range maintenance {
+		env[k] = v
+	}
+	blueGreenEnv, statements := s.blueGreenProxyEnv(agentName)
+	for k, v := range blueGreenEnv {
+		env[k] = v
+	}
+	statements = append([]policyStatement{{
+		Actions:   []string{"bedrock-agentcore:InvokeAgentRuntime"},
+		Resources: resources,
+	}}, statements...)
+
+	return s.newInlineFunction(ctx, name, s.agentProxySource(agentName), 900, env,
+		s.agentTags(agentName, tags), statements...)
+}
+
+// agentRuntimeArns returns the ARNs of an agent's runtimes.
+func (s *AgentCoreStack) agentRuntimeArns(agentName string) pulumi.StringArray {
+	var arns pulumi.StringArray
+	for _, name := range s.agentRuntimeNames(agentName) {
+		arns = append(arns, s.AgentRuntimes[name].RuntimeArn)
+	}
+	return arns
+}
+
+// needsRouter reports whether a single-runtime agent needs a router: to
+// split traffic between its blue and green versions or to enforce its
+// schemas.
+func (s *AgentCoreStack) needsRouter(agentName string) bool {
+	opts := s.Options.Agents[agentName]
+	if len(opts.InputSchema) > 0 || len(opts.OutputSchema) > 0 {
+		return true
+	}
+	env, _ := s.blueGreenProxyEnv(agentName)
+	return env != nil
+}
+
+// createAgentRouter creates a function URL that load balances requests
+// across an agent's replicas, or its blue and green versions. Callers sign requests with SigV4, as they
+// would when invoking a runtime directly.
+func (s *AgentCoreStack) createAgentRouter(ctx *pulumi.Context, agentName string, tags pulumi.StringMap) error {
+	name := fmt.Sprintf("%s-router", agentName)
+
+	function, err := s.newAgentProxy(ctx, name, agentName, tags)
+	if err != nil {
+		return err
+	}
+
+	url, err := lambda.NewFunctionUrl(ctx, s.ResourceName(name+"-url"), &lambda.FunctionUrlArgs{
+		FunctionName:      function.Name,
+		AuthorizationType: pulumi.String("AWS_IAM"),
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	s.AgentRouters[agentName] = url.FunctionUrl
+	return nil
+}
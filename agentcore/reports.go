@@ -0,0 +1,220 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/scheduler"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// ReportConfig configures scheduled report generation: a schedule that
+// invokes the orchestrator agent, a bucket for generated reports, and an
+// SNS topic the orchestrator publishes delivery notices to.
+type ReportConfig struct {
+	// Agent is the orchestrator agent that generates reports.
+	Agent string `json:"agent" yaml:"agent"`
+
+	// RuntimeARN overrides the runtime ARN the schedule invokes, e.g. for an
+	// orchestrator whose runtime another stack manages.
+	// Optional.
+	RuntimeARN string `json:"runtimeARN,omitempty" yaml:"runtimeARN,omitempty"`
+
+	// Qualifier is the runtime endpoint the schedule invokes.
+	// Default: "live" for the stack's runtime, "DEFAULT" for RuntimeARN
+	Qualifier string `json:"qualifier,omitempty" yaml:"qualifier,omitempty"`
+
+	// Schedule is an EventBridge Scheduler expression, e.g. "cron(0 8 ? * MON *)".
+	Schedule string `json:"schedule" yaml:"schedule"`
+
+	// Timezone is the IANA timezone for cron schedules.
+	// Default: "UTC"
+	Timezone string `json:"timezone,omitempty" yaml:"timezone,omitempty"`
+
+	// Payload is the JSON request sent to the orchestrator on each run.
+	// Default: {"task":"generate-report"}
+	Payload json.RawMessage `json:"payload,omitempty" yaml:"payload,omitempty"`
+
+	// Prefix is the S3 key prefix reports are written under.
+	// Default: "reports/"
+	Prefix string `json:"prefix,omitempty" yaml:"prefix,omitempty"`
+
+	// Recipients are email addresses subscribed to report delivery.
+	Recipients []string `json:"recipients,omitempty" yaml:"recipients,omitempty"`
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *ReportConfig) ApplyDefaults() {
+	if c.Timezone == "" {
+		c.Timezone = "UTC"
+	}
+	if len(c.Payload) == 0 {
+		c.Payload = json.RawMessage(`{"task":"generate-report"}`)
+	}
+	if c.Prefix == "" {
+		c.Prefix = "reports/"
+	}
+}
+
+// Validate validates the ReportConfig against the stack configuration.
+func (c *ReportConfig) Validate(config iac.StackConfig) error {
+	if !hasAgent(config, c.Agent) {
+		return fmt.Errorf("reports.agent: '%s' does not match any agent name", c.Agent)
+	}
+	if c.RuntimeARN != "" && !strings.HasPrefix(c.RuntimeARN, "arn:") {
+		return fmt.Errorf("reports.runtimeARN: '%s' is not an ARN", c.RuntimeARN)
+	}
+	if c.Schedule == "" {
+		return fmt.Errorf("reports.schedule is required")
+	}
+	if !json.Valid(c.Payload) {
+		return fmt.Errorf("reports.payload must be valid JSON")
+	}
+	for _, email := range c.Recipients {
+		if !strings.Contains(email, "@") {
+			return fmt.Errorf("reports.recipients: '%s' is not an email address", email)
+		}
+	}
+	return nil
+}
+
+// createReports creates the report bucket and delivery topic, and wires
+// them into the orchestrator. Its schedule is created by
+// createReportSchedule once the orchestrator's runtime exists.
+func (s *AgentCoreStack) createReports(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	var err error
+	stackName := s.Config.StackName
+	cfg := s.Options.Reports
+
+	s.ReportBucket, err = s.newPrivateBucket(ctx, "report-bucket", "reports", tags)
+	if err != nil {
+		return err
+	}
+
+	s.ReportDelivery, err = s.newEventTopic(ctx, "report-delivery", fmt.Sprintf("%s-reports", stackName), tags)
+	if err != nil {
+		return err
+	}
+
+	if err := s.subscribeEmails(ctx, "report-recipient", s.ReportDelivery.Arn, cfg.Recipients); err != nil {
+		return err
+	}
+
+	err = s.attachAgentPolicy(ctx, cfg.Agent, "report-delivery-policy",
+		policyStatement{
+			Actions:   []string{"s3:PutObject", "s3:GetObject"},
+			Resources: pulumi.StringArray{pulumi.Sprintf("%s/%s*", s.ReportBucket.Arn, cfg.Prefix)},
+		},
+		policyStatement{
+			Actions:   []string{"sns:Publish"},
+			Resources: pulumi.StringArray{s.ReportDelivery.Arn},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	s.injectEnv(cfg.Agent, "REPORT_BUCKET", s.ReportBucket.Bucket)
+	s.injectEnv(cfg.Agent, "REPORT_PREFIX", pulumi.String(cfg.Prefix))
+	s.injectEnv(cfg.Agent, "REPORT_TOPIC_ARN", s.ReportDelivery.Arn)
+
+	return nil
+}
+
+// createReportSchedule creates the schedule that invokes the orchestrator.
+// It runs after createAgentRuntimes.
+func (s *AgentCoreStack) createReportSchedule(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	stackName := s.Config.StackName
+	cfg := s.Options.Reports
+	runtimeArn, qualifier := s.agentInvokeTarget(cfg.Agent, cfg.RuntimeARN)
+	if cfg.Qualifier != "" {
+		qualifier = cfg.Qualifier
+	}
+
+	// Role assumed by EventBridge Scheduler to invoke the orchestrator
+	schedulerRole, err := s.newServiceRole(ctx, "report-scheduler-role", "scheduler.amazonaws.com", tags)
+	if err != nil {
+		return err
+	}
+	err = s.newRolePolicy(ctx, "report-scheduler-policy", schedulerRole.Name, policyStatement{
+		Actions:   []string{"bedrock-agentcore:InvokeAgentRuntime"},
+		Resources: pulumi.StringArray{runtimeArn, pulumi.Sprintf("%s/*", runtimeArn)},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Scheduler execution IDs are UUIDs, long enough for runtime session IDs
+	input := runtimeArn.ApplyT(func(arn string) (string, error) {
+		data, err := json.Marshal(map[string]string{
+			"AgentRuntimeArn":  arn,
+			"Qualifier":        qualifier,
+			"RuntimeSessionId": "<aws.scheduler.execution-id>",
+			"ContentType":      "application/json",
+			"Payload":          string(cfg.Payload),
+		})
+		return string(data), err
+	}).(pulumi.StringOutput)
+
+	_, err = scheduler.NewSchedule(ctx, s.ResourceName("report-schedule"), &scheduler.ScheduleArgs{
+		Name:                       pulumi.Sprintf("%s-reports", stackName),
+		Description:                pulumi.Sprintf("Scheduled reports from %s", cfg.Agent),
+		ScheduleExpression:         pulumi.String(cfg.Schedule),
+		ScheduleExpressionTimezone: pulumi.String(cfg.Timezone),
+		FlexibleTimeWindow: &scheduler.ScheduleFlexibleTimeWindowArgs{
+			Mode: pulumi.String("OFF"),
+		},
+		Target: &scheduler.ScheduleTargetArgs{
+			Arn:     pulumi.String("arn:aws:scheduler:::aws-sdk:bedrockagentcore:invokeAgentRuntime"),
+			RoleArn: schedulerRole.Arn,
+			Input:   input,
+		},
+	}, s.child())
+	return err
+}
+
+// ScheduledReportTeamConfig describes an agent team that generates reports on a schedule.
+type ScheduledReportTeamConfig struct {
+	// StackName is the name of the stack.
+	StackName string
+
+	// Orchestrator is the agent invoked on schedule. It is made the default agent.
+	Orchestrator iac.AgentConfig
+
+	// Workers are the agents the orchestrator delegates to.
+	Workers []iac.AgentConfig
+
+	// Report configures the schedule and delivery. Report.Agent defaults
+	// to the orchestrator's name.
+	Report ReportConfig
+
+	// Options contains any further stack options. Options.Reports is
+	// overwritten with Report.
+	Options Options
+}
+
+// NewScheduledReportTeam creates a stack with an orchestrator agent, its
+// workers, a report bucket and scheduled invocation with email delivery.
+func NewScheduledReportTeam(ctx *pulumi.Context, config ScheduledReportTeamConfig) (*AgentCoreStack, error) {
+	orchestrator := config.Orchestrator
+	orchestrator.IsDefault = true
+
+	report := config.Report
+	if report.Agent == "" {
+		report.Agent = orchestrator.Name
+	}
+
+	options := config.Options
+	options.Reports = &report
+
+	stackConfig := iac.StackConfig{
+		StackName:   config.StackName,
+		Description: fmt.Sprintf("Scheduled report team for %s", orchestrator.Name),
+		Agents:      append([]iac.AgentConfig{orchestrator}, config.Workers...),
+		Tags:        make(map[string]string),
+	}
+
+	return NewAgentCoreStackWithOptions(ctx, stackConfig, options)
+}
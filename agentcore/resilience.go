@@ -0,0 +1,182 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/cloudwatch"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// ResiliencePolicy configures retries, backoff and circuit breaking for
+// inter-agent calls. The policy is injected into every agent as JSON in the
+// RESILIENCE_POLICY environment variable so all agents behave consistently.
+type ResiliencePolicy struct {
+	// MaxRetries is the number of retries after the first attempt.
+	// Default: 3
+	MaxRetries int `json:"maxRetries,omitempty" yaml:"maxRetries,omitempty"`
+
+	// InitialBackoffMs is the delay before the first retry.
+	// Default: 200
+	InitialBackoffMs int `json:"initialBackoffMs,omitempty" yaml:"initialBackoffMs,omitempty"`
+
+	// MaxBackoffMs caps the delay between retries.
+	// Default: 5000
+	MaxBackoffMs int `json:"maxBackoffMs,omitempty" yaml:"maxBackoffMs,omitempty"`
+
+	// BackoffMultiplier is applied to the delay after each retry.
+	// Default: 2
+	BackoffMultiplier float64 `json:"backoffMultiplier,omitempty" yaml:"backoffMultiplier,omitempty"`
+
+	// CallTimeoutSeconds is the timeout for a single inter-agent call.
+	// Default: 60
+	CallTimeoutSeconds int `json:"callTimeoutSeconds,omitempty" yaml:"callTimeoutSeconds,omitempty"`
+
+	// CircuitBreaker configures the circuit breaker.
+	// Default: enabled with DefaultCircuitBreakerConfig settings
+	CircuitBreaker *CircuitBreakerConfig `json:"circuitBreaker,omitempty" yaml:"circuitBreaker,omitempty"`
+
+	// AlarmActions are ARNs notified when a circuit opens.
+	AlarmActions []string `json:"alarmActions,omitempty" yaml:"alarmActions,omitempty"`
+}
+
+// CircuitBreakerConfig configures when a circuit opens and how it recovers.
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens the circuit.
+	// Default: 5
+	FailureThreshold int `json:"failureThreshold,omitempty" yaml:"failureThreshold,omitempty"`
+
+	// OpenSeconds is how long the circuit stays open before probing.
+	// Default: 30
+	OpenSeconds int `json:"openSeconds,omitempty" yaml:"openSeconds,omitempty"`
+
+	// HalfOpenRequests is the number of probe requests allowed while half-open.
+	// Default: 1
+	HalfOpenRequests int `json:"halfOpenRequests,omitempty" yaml:"halfOpenRequests,omitempty"`
+}
+
+// DefaultResiliencePolicy returns a ResiliencePolicy with sensible defaults.
+func DefaultResiliencePolicy() *ResiliencePolicy {
+	policy := &ResiliencePolicy{}
+	policy.ApplyDefaults()
+	return policy
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (p *ResiliencePolicy) ApplyDefaults() {
+	if p.MaxRetries == 0 {
+		p.MaxRetries = 3
+	}
+	if p.InitialBackoffMs == 0 {
+		p.InitialBackoffMs = 200
+	}
+	if p.MaxBackoffMs == 0 {
+		p.MaxBackoffMs = 5000
+	}
+	if p.BackoffMultiplier == 0 {
+		p.BackoffMultiplier = 2
+	}
+	if p.CallTimeoutSeconds == 0 {
+		p.CallTimeoutSeconds = 60
+	}
+	if p.CircuitBreaker == nil {
+		p.CircuitBreaker = &CircuitBreakerConfig{}
+	}
+	if p.CircuitBreaker.FailureThreshold == 0 {
+		p.CircuitBreaker.FailureThreshold = 5
+	}
+	if p.CircuitBreaker.OpenSeconds == 0 {
+		p.CircuitBreaker.OpenSeconds = 30
+	}
+	if p.CircuitBreaker.HalfOpenRequests == 0 {
+		p.CircuitBreaker.HalfOpenRequests = 1
+	}
+}
+
+// Validate validates the ResiliencePolicy.
+func (p *ResiliencePolicy) Validate() error {
+	if p.MaxRetries < 0 || p.MaxRetries > 10 {
+		return fmt.Errorf("resilience.maxRetries must be between 0 and 10")
+	}
+	if p.InitialBackoffMs < 1 || p.MaxBackoffMs < p.InitialBackoffMs {
+		return fmt.Errorf("resilience.maxBackoffMs must be at least initialBackoffMs")
+	}
+	if p.BackoffMultiplier < 1 {
+		return fmt.Errorf("resilience.backoffMultiplier must be at least 1")
+	}
+	if p.CallTimeoutSeconds < 1 || p.CallTimeoutSeconds > 900 {
+		return fmt.Errorf("resilience.callTimeoutSeconds must be between 1 and 900")
+	}
+	if p.CircuitBreaker.FailureThreshold < 1 {
+		return fmt.Errorf("resilience.circuitBreaker.failureThreshold must be at least 1")
+	}
+	if p.CircuitBreaker.OpenSeconds < 1 {
+		return fmt.Errorf("resilience.circuitBreaker.openSeconds must be at least 1")
+	}
+	if p.CircuitBreaker.HalfOpenRequests < 1 {
+		return fmt.Errorf("resilience.circuitBreaker.halfOpenRequests must be at least 1")
+	}
+	return nil
+}
+
+// applyResiliencePolicy injects the policy into all agents and creates
+// circuit-open alarms from the agents' structured logs.
+func (s *AgentCoreStack) applyResiliencePolicy(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	stackName := s.Config.StackName
+	policy := *s.Options.Resilience
+	policy.AlarmActions = nil
+
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+	for _, agent := range s.Config.Agents {
+		s.injectEnv(agent.Name, "RESILIENCE_POLICY", pulumi.String(string(data)))
+	}
+
+	// Circuit-open events are logged as {"event": "circuit_open", "agent": ...}
+	if s.LogGroup == nil {
+		return nil
+	}
+	fields := s.logging().Fields
+
+	_, err = cloudwatch.NewLogMetricFilter(ctx, s.ResourceName("circuit-open-filter"), &cloudwatch.LogMetricFilterArgs{
+		Name:         pulumi.Sprintf("%s-circuit-open", stackName),
+		LogGroupName: s.LogGroup.Name,
+		Pattern:      pulumi.Sprintf(`{ $.%s = "circuit_open" }`, fields.Event),
+		MetricTransformation: &cloudwatch.LogMetricFilterMetricTransformationArgs{
+			Name:      pulumi.String("CircuitOpen"),
+			Namespace: pulumi.String(s.metricNamespace()),
+			Value:     pulumi.String("1"),
+			Dimensions: pulumi.StringMap{
+				"Agent": pulumi.String("$." + fields.Agent),
+			},
+		},
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	for _, agent := range s.Config.Agents {
+		_, err = cloudwatch.NewMetricAlarm(ctx, s.ResourceName(fmt.Sprintf("%s-circuit-open-alarm", agent.Name)), &cloudwatch.MetricAlarmArgs{
+			Name:               pulumi.Sprintf("%s-%s-circuit-open", stackName, agent.Name),
+			AlarmDescription:   pulumi.Sprintf("Circuit breaker opened in agent %s", agent.Name),
+			Namespace:          pulumi.String(s.metricNamespace()),
+			MetricName:         pulumi.String("CircuitOpen"),
+			Dimensions:         pulumi.StringMap{"Agent": pulumi.String(agent.Name)},
+			Statistic:          pulumi.String("Sum"),
+			Period:             pulumi.Int(300),
+			EvaluationPeriods:  pulumi.Int(1),
+			Threshold:          pulumi.Float64(1),
+			ComparisonOperator: pulumi.String("GreaterThanOrEqualToThreshold"),
+			TreatMissingData:   pulumi.String("notBreaching"),
+			AlarmActions:       toArray(s.Options.Resilience.AlarmActions),
+			Tags:               mergeTags(tags, pulumi.Sprintf("%s-%s-circuit-open", stackName, agent.Name)),
+		}, s.child())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/lambda"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// versionRetentionHandler keeps an endpoint named v{version} for the most
+// recent versions of an agent's runtime and deletes the endpoints of older
+// versions. It runs as a CRUD Lambda invocation after each deployment of
+// a new version and deletes all version endpoints when the agent is
+// removed, so its runtime can be deleted.
+const versionRetentionHandler = `import {
+  BedrockAgentCoreControlClient,
+  ListAgentRuntimeEndpointsCommand,
+  CreateAgentRuntimeEndpointCommand,
+  DeleteAgentRuntimeEndpointCommand,
+} from "@aws-sdk/client-bedrock-agentcore-control";
+
+const control = new BedrockAgentCoreControlClient({});
+const versionEndpoint = /^v[0-9]+$/;
+
+const listEndpoints = async (agentRuntimeId) => {
+  const endpoints = [];
+  let nextToken;
+  do {
+    const page = await control.send(new ListAgentRuntimeEndpointsCommand({ agentRuntimeId, nextToken }));
+    endpoints.push(...(page.runtimeEndpoints ?? []));
+    nextToken = page.nextToken;
+  } while (nextToken);
+  return endpoints;
+};
+
+const remove = async (agentRuntimeId, endpointName) => {
+  try {
+    await control.send(new DeleteAgentRuntimeEndpointCommand({ agentRuntimeId, endpointName }));
+  } catch (err) {
+    if (err.name !== "ResourceNotFoundException") throw err;
+  }
+};
+
+export const handler = async (event) => {
+  const { action } = event.tf ?? { action: "create" };
+  const { runtimeId, version } = event;
+  const keep = action === "delete" ? 0 : event.keep;
+
+  const endpoints = await listEndpoints(runtimeId);
+  const name = "v" + version;
+  if (keep > 0 && version && !endpoints.some((e) => e.name === name)) {
+    await control.send(new CreateAgentRuntimeEndpointCommand({
+      agentRuntimeId: runtimeId,
+      name,
+      agentRuntimeVersion: version,
+      description: "Retained version " + version,
+    }));
+    endpoints.push({ name });
+  }
+
+  const versions = endpoints
+    .filter((e) => versionEndpoint.test(e.name))
+    .sort((a, b) => Number(b.name.slice(1)) - Number(a.name.slice(1)));
+  for (const e of versions.slice(keep)) {
+    await remove(runtimeId, e.name);
+  }
+  return { retained: versions.slice(0, keep).map((e) => e.name).join(",") };
+};
+`
+
+// maxRetainedVersions is the largest BlueGreenConfig.RetainVersions.
+const maxRetainedVersions = 10
+
+// retentionAgents returns the names of the agents that retain versions.
+func (s *AgentCoreStack) retentionAgents() []string {
+	var names []string
+	for _, agent := range s.Config.Agents {
+		if cfg := s.Options.Agents[agent.Name].BlueGreen; cfg != nil && cfg.RetainVersions > 0 {
+			names = append(names, agent.Name)
+		}
+	}
+	return names
+}
+
+// createVersionRetention creates the function that prunes the version
+// endpoints of agents with BlueGreenConfig.RetainVersions, and invokes it
+// for each agent whenever its runtime version changes.
+func (s *AgentCoreStack) createVersionRetention(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	agents := s.retentionAgents()
+
+	var runtimeResources pulumi.StringArray
+	for _, agentName := range agents {
+		runtime := s.AgentRuntimes[agentName]
+		runtimeResources = append(runtimeResources, runtime.RuntimeArn, pulumi.Sprintf("%s/runtime-endpoint/*", runtime.RuntimeArn))
+	}
+	function, err := s.newInlineFunction(ctx, "version-retention", versionRetentionHandler, 300, nil, tags, policyStatement{
+		Actions: []string{
+			"bedrock-agentcore:ListAgentRuntimeEndpoints",
+			"bedrock-agentcore:CreateAgentRuntimeEndpoint",
+			"bedrock-agentcore:DeleteAgentRuntimeEndpoint",
+		},
+		Resources: runtimeResources,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, agentName := range agents {
+		runtime := s.AgentRuntimes[agentName]
+		keep := s.Options.Agents[agentName].BlueGreen.RetainVersions
+		input := pulumi.All(cloudControlAttribute(runtime.Runtime, "AgentRuntimeId"), runtime.Version).ApplyT(func(args []interface{}) (string, error) {
+			data, err := json.Marshal(map[string]interface{}{
+				"runtimeId": args[0],
+				"version":   args[1],
+				"keep":      keep,
+			})
+			return string(data), err
+		}).(pulumi.StringOutput)
+		_, err := lambda.NewInvocation(ctx, s.ResourceName(fmt.Sprintf("%s-version-retention", agentName)), &lambda.InvocationArgs{
+			FunctionName:   function.Name,
+			Input:          input,
+			LifecycleScope: pulumi.String("CRUD"),
+		}, s.child(), pulumi.DependsOn([]pulumi.Resource{runtime.Endpoint}))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
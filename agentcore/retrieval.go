@@ -0,0 +1,132 @@
+package agentcore
+
+import (
+	"fmt"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/kendra"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// RetrievalConfig configures the search index agents retrieve documents from.
+type RetrievalConfig struct {
+	// Type is the retrieval backend.
+	// Supported: "kendra"
+	// Default: "kendra"
+	Type string `json:"type,omitempty" yaml:"type,omitempty"`
+
+	// IndexID references an existing Kendra index.
+	// If empty, an index is provisioned.
+	IndexID string `json:"indexId,omitempty" yaml:"indexId,omitempty"`
+
+	// Edition is the Kendra edition for provisioned indexes.
+	// Supported: "DEVELOPER_EDITION", "ENTERPRISE_EDITION", "GEN_AI_ENTERPRISE_EDITION"
+	// Default: "DEVELOPER_EDITION"
+	Edition string `json:"edition,omitempty" yaml:"edition,omitempty"`
+
+	// Agents is the list of agent names that query the index.
+	// If empty, all agents in the stack are included.
+	Agents []string `json:"agents,omitempty" yaml:"agents,omitempty"`
+}
+
+// DefaultRetrievalConfig returns a RetrievalConfig with sensible defaults.
+func DefaultRetrievalConfig() *RetrievalConfig {
+	return &RetrievalConfig{
+		Type:    "kendra",
+		Edition: "DEVELOPER_EDITION",
+	}
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *RetrievalConfig) ApplyDefaults() {
+	if c.Type == "" {
+		c.Type = "kendra"
+	}
+	if c.Edition == "" && c.IndexID == "" {
+		c.Edition = "DEVELOPER_EDITION"
+	}
+}
+
+// Validate validates the RetrievalConfig against the stack configuration.
+func (c *RetrievalConfig) Validate(config iac.StackConfig) error {
+	if c.Type != "kendra" {
+		return fmt.Errorf("retrieval.type must be one of [kendra]")
+	}
+	if c.IndexID != "" && c.Edition != "" {
+		return fmt.Errorf("retrieval.edition only applies to provisioned indexes")
+	}
+	switch c.Edition {
+	case "", "DEVELOPER_EDITION", "ENTERPRISE_EDITION", "GEN_AI_ENTERPRISE_EDITION":
+	default:
+		return fmt.Errorf("retrieval.edition must be one of [DEVELOPER_EDITION ENTERPRISE_EDITION GEN_AI_ENTERPRISE_EDITION]")
+	}
+	return validateAgentNames("retrieval.agents", c.Agents, config)
+}
+
+// createRetrieval references or provisions the Kendra index and grants agents query access.
+func (s *AgentCoreStack) createRetrieval(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	stackName := s.Config.StackName
+	cfg := s.Options.Retrieval
+
+	indexID := pulumi.String(cfg.IndexID).ToStringOutput()
+	indexArn := pulumi.Sprintf("arn:aws:kendra:*:*:index/%s", cfg.IndexID)
+
+	if cfg.IndexID == "" {
+		// Role assumed by Kendra to publish metrics and logs
+		role, err := s.newServiceRole(ctx, "kendra-role", "kendra.amazonaws.com", tags)
+		if err != nil {
+			return err
+		}
+		err = s.newRolePolicy(ctx, "kendra-policy", role.Name,
+			policyStatement{
+				Actions:   []string{"cloudwatch:PutMetricData"},
+				Resources: pulumi.StringArray{pulumi.String("*")},
+			},
+			policyStatement{
+				Actions: []string{
+					"logs:CreateLogGroup",
+					"logs:CreateLogStream",
+					"logs:DescribeLogGroups",
+					"logs:DescribeLogStreams",
+					"logs:PutLogEvents",
+				},
+				Resources: pulumi.StringArray{pulumi.String("arn:aws:logs:*:*:log-group:/aws/kendra/*")},
+			},
+		)
+		if err != nil {
+			return err
+		}
+
+		s.KendraIndex, err = kendra.NewIndex(ctx, s.ResourceName("kendra-index"), &kendra.IndexArgs{
+			Name:        pulumi.Sprintf("%s-index", stackName),
+			Description: pulumi.Sprintf("Search index for %s agents", stackName),
+			Edition:     pulumi.String(cfg.Edition),
+			RoleArn:     role.Arn,
+			Tags:        mergeTags(tags, pulumi.Sprintf("%s-index", stackName)),
+		}, s.child())
+		if err != nil {
+			return err
+		}
+		indexID = s.KendraIndex.ID().ToStringOutput()
+		indexArn = s.KendraIndex.Arn
+	}
+
+	err := s.attachRolePolicy(ctx, "kendra-query-policy", policyStatement{
+		Actions: []string{
+			"kendra:Query",
+			"kendra:Retrieve",
+			"kendra:DescribeIndex",
+		},
+		Resources: pulumi.StringArray{indexArn},
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, name := range s.selectedAgents(cfg.Agents) {
+		s.injectEnv(name, "RETRIEVAL_TYPE", pulumi.String(cfg.Type))
+		s.injectEnv(name, "KENDRA_INDEX_ID", indexID)
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package agentcore
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/iam"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+var (
+	// iamPathPattern matches IAM paths such as "/" or "/agentcore/prod/".
+	iamPathPattern = regexp.MustCompile(`^/([\x21-\x7e]+/)?$`)
+
+	// iamNamePrefixPattern matches the characters allowed in IAM names.
+	iamNamePrefixPattern = regexp.MustCompile(`^[\w+=,.@-]*$`)
+)
+
+// IAMRoleConfig standardizes the IAM roles and managed policies the stack
+// creates, such as the execution role, to meet enterprise conventions.
+// Roles of standalone components such as knowledge bases and ECR mirrors
+// are not affected.
+//
+// The permissions boundary of iac.IAMConfig applies to the roles even
+// without an IAMRoleConfig.
+type IAMRoleConfig struct {
+	// Path is the path of the roles and managed policies, e.g.
+	// "/agentcore/".
+	// Default: "/"
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+
+	// NamePrefix is prepended to the names of the roles and managed
+	// policies, e.g. "app-".
+	NamePrefix string `json:"namePrefix,omitempty" yaml:"namePrefix,omitempty"`
+
+	// PermissionsBoundaryARN is the permissions boundary of the roles.
+	// Default: the StackConfig's iam.permissionsBoundaryARN
+	PermissionsBoundaryARN string `json:"permissionsBoundaryARN,omitempty" yaml:"permissionsBoundaryARN,omitempty"`
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *IAMRoleConfig) ApplyDefaults() {
+	if c.Path == "" {
+		c.Path = "/"
+	}
+}
+
+// Validate validates the IAMRoleConfig.
+func (c *IAMRoleConfig) Validate() error {
+	if !iamPathPattern.MatchString(c.Path) || len(c.Path) > 512 {
+		return fmt.Errorf("iamRoles.path: '%s' must begin and end with /", c.Path)
+	}
+	if !iamNamePrefixPattern.MatchString(c.NamePrefix) || len(c.NamePrefix) > 32 {
+		return fmt.Errorf("iamRoles.namePrefix: '%s' is not a valid IAM name prefix", c.NamePrefix)
+	}
+	if c.PermissionsBoundaryARN != "" && !iamPolicyARN(c.PermissionsBoundaryARN) {
+		return fmt.Errorf("iamRoles.permissionsBoundaryARN: '%s' is not an IAM policy ARN", c.PermissionsBoundaryARN)
+	}
+	return nil
+}
+
+// iamName returns the name of a role or managed policy the stack creates.
+func (s *AgentCoreStack) iamName(name string) pulumi.String {
+	if cfg := s.Options.IAMRoles; cfg != nil {
+		return pulumi.String(cfg.NamePrefix + name)
+	}
+	return pulumi.String(name)
+}
+
+// iamPath returns the path of roles and managed policies, or nil for the
+// default.
+func (s *AgentCoreStack) iamPath() pulumi.StringPtrInput {
+	if cfg := s.Options.IAMRoles; cfg != nil && cfg.Path != "/" {
+		return pulumi.String(cfg.Path)
+	}
+	return nil
+}
+
+// permissionsBoundary returns the permissions boundary of roles, or nil.
+func (s *AgentCoreStack) permissionsBoundary() pulumi.StringPtrInput {
+	if cfg := s.Options.IAMRoles; cfg != nil && cfg.PermissionsBoundaryARN != "" {
+		return pulumi.String(cfg.PermissionsBoundaryARN)
+	}
+	if s.Config.IAM != nil && s.Config.IAM.PermissionsBoundaryARN != "" {
+		return pulumi.String(s.Config.IAM.PermissionsBoundaryARN)
+	}
+	return nil
+}
+
+// newRole creates a role with the stack's role name prefix, path and
+// permissions boundary. name is the role name before the prefix.
+func (s *AgentCoreStack) newRole(ctx *pulumi.Context, resourceName, name string, args *iam.RoleArgs) (*iam.Role, error) {
+	args.Name = s.iamName(name)
+	args.Path = s.iamPath()
+	args.PermissionsBoundary = s.permissionsBoundary()
+	return s.factory().CreateRole(ctx, s, s.ResourceName(resourceName), args, s.child())
+}
+
+// iamPolicyARN reports whether arn is an IAM managed policy ARN.
+func iamPolicyARN(arn string) bool {
+	return strings.HasPrefix(arn, "arn:") && strings.Contains(arn, ":iam::") && strings.Contains(arn, ":policy/")
+}
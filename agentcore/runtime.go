@@ -0,0 +1,253 @@
+package agentcore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"strconv"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/cloudcontrol"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// runtimeEndpointName is the endpoint created for every agent runtime. It
+// is pinned to the runtime version created by the deployment, unlike the
+// DEFAULT endpoint AgentCore manages, which always follows the latest version.
+const runtimeEndpointName = "live"
+
+// AgentRuntime holds the AgentCore runtime and endpoint of a deployed agent.
+type AgentRuntime struct {
+	// Runtime is the AgentCore agent runtime.
+	Runtime *cloudcontrol.Resource
+
+	// Endpoint is the live runtime endpoint, pinned to LiveVersion.
+	Endpoint *cloudcontrol.Resource
+
+	// Name is the AgentCore name of the runtime.
+	Name pulumi.StringOutput
+
+	// RuntimeArn is the ARN of the agent runtime.
+	RuntimeArn pulumi.StringOutput
+
+	// Version is the runtime version created by the deployment.
+	Version pulumi.StringOutput
+
+	// LiveVersion is the runtime version the live endpoint serves: Version,
+	// or PreviousVersion for agents deployed blue/green until all traffic
+	// has shifted to Version.
+	LiveVersion pulumi.StringOutput
+
+	// PreviousVersion is the runtime version that was live before Version
+	// was deployed, served by the blue endpoint of agents deployed
+	// blue/green.
+	PreviousVersion pulumi.StringOutput
+
+	// InvokeURL is the InvokeAgentRuntime URL of the endpoint.
+	InvokeURL pulumi.StringOutput
+}
+
+// validateAgentRuntimes checks that agent settings can be applied to AgentCore runtimes.
+func validateAgentRuntimes(config iac.StackConfig) error {
+	for _, agent := range config.Agents {
+		// Runtimes authorize inbound calls with SigV4 or JWT only
+		if agent.Authorizer != nil && agent.Authorizer.Type == "LAMBDA" {
+			return fmt.Errorf("agents[%s].authorizer.type LAMBDA is not supported by AgentCore runtimes", agent.Name)
+		}
+	}
+	return nil
+}
+
+// runtimeNetworkConfiguration builds the NetworkConfiguration property of an agent runtime.
+func (s *AgentCoreStack) runtimeNetworkConfiguration() pulumi.Map {
+	if !hasVPC(s.Config) {
+		return pulumi.Map{"NetworkMode": pulumi.String("PUBLIC")}
+	}
+	return pulumi.Map{
+		"NetworkMode": pulumi.String("VPC"),
+		"NetworkModeConfig": pulumi.Map{
+			"SecurityGroups": pulumi.StringArray{s.SecurityGroup.ID()},
+			"Subnets":        s.privateSubnetIDs(),
+		},
+	}
+}
+
+// agentEnvironment merges the agent's configured environment with the
+// variables injected by stack components, which take precedence.
+func (s *AgentCoreStack) agentEnvironment(agentName string) pulumi.StringMap {
+	env := pulumi.StringMap{}
+	for _, agent := range s.Config.Agents {
+		if agent.Name != agentName {
+			continue
+		}
+		for k, v := range agent.Environment {
+			env[k] = pulumi.String(v)
+		}
+	}
+	for k, v := range s.AgentEnvironment[agentName] {
+		env[k] = v
+	}
+	return env
+}
+
+// createAgentRuntimes creates an AgentCore runtime and endpoint for each
+// agent, or one per replica behind a router for agents with replicas.
+// Lambda agents get a function instead.
+// It runs after all components have injected their environment variables.
+func (s *AgentCoreStack) createAgentRuntimes(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	if err := s.checkAgentEnvironments(); err != nil {
+		return err
+	}
+
+	region, err := aws.GetRegion(ctx, nil, s.invoke())
+	if err != nil {
+		return err
+	}
+
+	for _, agent := range s.Config.Agents {
+		if s.isLambdaAgent(agent.Name) {
+			function, err := s.createAgentFunction(ctx, agent, tags)
+			if err != nil {
+				return err
+			}
+			s.AgentFunctions[agent.Name] = function
+			continue
+		}
+
+		replicas := s.Options.Agents[agent.Name].Replicas
+		if replicas <= 1 {
+			runtime, err := s.createAgentRuntime(ctx, agent, agent.Name, s.agentEnvironment(agent.Name), tags, region.Name)
+			if err != nil {
+				return err
+			}
+			s.AgentRuntimes[agent.Name] = runtime
+			if s.needsRouter(agent.Name) {
+				if err := s.createAgentRouter(ctx, agent.Name, tags); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		for i := 0; i < replicas; i++ {
+			name := replicaName(agent.Name, i)
+			env := s.agentEnvironment(agent.Name)
+			env["AGENT_REPLICA"] = pulumi.String(strconv.Itoa(i))
+			runtime, err := s.createAgentRuntime(ctx, agent, name, env, tags, region.Name)
+			if err != nil {
+				return err
+			}
+			s.AgentRuntimes[name] = runtime
+		}
+		if err := s.createAgentRouter(ctx, agent.Name, tags); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createAgentRuntime creates an AgentCore runtime for the agent under the
+// given name, with an endpoint pinned to the deployed version.
+func (s *AgentCoreStack) createAgentRuntime(ctx *pulumi.Context, agent iac.AgentConfig, name string, env pulumi.StringMap, tags pulumi.StringMap, region string) (*AgentRuntime, error) {
+	stackName := s.Config.StackName
+
+	agentTags := s.agentTags(agent.Name, tags)
+
+	description := agent.Description
+	if description == "" {
+		description = fmt.Sprintf("%s agent in %s", agent.Name, stackName)
+	}
+
+	properties := pulumi.Map{
+		"Description": pulumi.String(description),
+		"AgentRuntimeArtifact": pulumi.Map{
+			"ContainerConfiguration": pulumi.Map{
+				"ContainerUri": pulumi.String(agent.ContainerImage),
+			},
+		},
+		"RoleArn":               s.AgentRoles[agent.Name].Arn,
+		"NetworkConfiguration":  s.runtimeNetworkConfiguration(),
+		"ProtocolConfiguration": pulumi.String(agent.Protocol),
+		"EnvironmentVariables":  env,
+		"Tags":                  agentTags,
+	}
+	runtimeOpts := []pulumi.ResourceOption{pulumi.DependsOn(s.Options.DependsOn), s.child()}
+	// Immutable runtimes are named after their configuration, so the
+	// replacement can be created before the old runtime is deleted
+	runtimeName := pulumi.String(agentCoreName(stackName, name)).ToStringOutput()
+	immutable := s.Options.Agents[agent.Name].Immutable
+	if immutable {
+		runtimeName = pulumi.JSONMarshal(maps.Clone(properties)).ApplyT(func(config string) string {
+			sum := sha256.Sum256([]byte(config))
+			return agentCoreName(stackName, name, hex.EncodeToString(sum[:4]))
+		}).(pulumi.StringOutput)
+		runtimeOpts = append(runtimeOpts, pulumi.ReplaceOnChanges([]string{"desiredState"}))
+	}
+	properties["AgentRuntimeName"] = runtimeName
+	runtime, err := newCloudControlResource(ctx, s.ResourceName(fmt.Sprintf("%s-runtime", name)),
+		"AWS::BedrockAgentCore::Runtime", properties, runtimeOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	runtimeID := cloudControlAttribute(runtime, "AgentRuntimeId")
+	version := cloudControlAttribute(runtime, "AgentRuntimeVersion")
+	runtimeArn := cloudControlAttribute(runtime, "AgentRuntimeArn")
+
+	result := &AgentRuntime{
+		Runtime:     runtime,
+		Name:        runtimeName,
+		RuntimeArn:  runtimeArn,
+		Version:     version,
+		LiveVersion: version,
+		InvokeURL: runtimeArn.ApplyT(func(arn string) string {
+			return AgentInvokeURL(region, arn, runtimeEndpointName)
+		}).(pulumi.StringOutput),
+	}
+	if s.Options.Agents[agent.Name].BlueGreen != nil {
+		result.LiveVersion, err = s.createBlueGreenEndpoints(ctx, agent, result, runtimeID, agentTags)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The canary controller owns the live endpoint's version after creation
+	canary := s.Options.Agents[agent.Name].BlueGreen != nil && s.Options.Agents[agent.Name].BlueGreen.Strategy == "canary"
+	endpointOpts := []pulumi.ResourceOption{s.child()}
+	if canary {
+		endpointOpts = append(endpointOpts, pulumi.IgnoreChanges([]string{"desiredState"}))
+	}
+	if immutable {
+		endpointOpts = append(endpointOpts, pulumi.ReplaceOnChanges([]string{"desiredState"}))
+	}
+	result.Endpoint, err = newCloudControlResource(ctx, s.ResourceName(fmt.Sprintf("%s-runtime-endpoint", name)),
+		"AWS::BedrockAgentCore::RuntimeEndpoint", pulumi.Map{
+			"AgentRuntimeId":      runtimeID,
+			"AgentRuntimeVersion": result.LiveVersion,
+			"Name":                pulumi.String(runtimeEndpointName),
+			"Description":         pulumi.Sprintf("Deployed version of %s", name),
+			"Tags":                agentTags,
+		}, endpointOpts...)
+	if err != nil {
+		return nil, err
+	}
+	if canary {
+		result.LiveVersion = cloudControlAttribute(result.Endpoint, "LiveVersion")
+	}
+	return result, nil
+}
+
+// agentInvokeTarget returns the runtime ARN and qualifier that integrations
+// such as AppSync and scheduled reports invoke for an agent: the stack's
+// runtime and its live endpoint, or the first replica's for agents with
+// replicas. A non-empty override ARN is invoked at its DEFAULT endpoint
+// instead. It runs after createAgentRuntimes.
+func (s *AgentCoreStack) agentInvokeTarget(agentName, override string) (pulumi.StringOutput, string) {
+	if override != "" {
+		return pulumi.String(override).ToStringOutput(), DefaultQualifier
+	}
+	return s.AgentRuntimes[s.agentRuntimeNames(agentName)[0]].RuntimeArn, runtimeEndpointName
+}
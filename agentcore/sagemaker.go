@@ -0,0 +1,214 @@
+package agentcore
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/sagemaker"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// sageMakerNamePattern matches valid SageMaker endpoint names.
+var sageMakerNamePattern = regexp.MustCompile(`^[a-zA-Z0-9](-*[a-zA-Z0-9]){0,62}$`)
+
+// SageMakerEndpointConfig configures a SageMaker real-time endpoint that
+// agents call for custom or self-hosted models.
+//
+// If Image is empty, EndpointName references an existing endpoint;
+// otherwise a model, endpoint configuration and endpoint are provisioned.
+type SageMakerEndpointConfig struct {
+	// EndpointName is the SageMaker endpoint name.
+	EndpointName string `json:"endpointName" yaml:"endpointName"`
+
+	// Image is the inference container image URI. Setting it provisions the endpoint.
+	Image string `json:"image,omitempty" yaml:"image,omitempty"`
+
+	// ModelDataURL is the S3 URL of the model artifacts (model.tar.gz).
+	ModelDataURL string `json:"modelDataURL,omitempty" yaml:"modelDataURL,omitempty"`
+
+	// Environment is passed to the inference container.
+	Environment map[string]string `json:"environment,omitempty" yaml:"environment,omitempty"`
+
+	// InstanceType is the instance type for provisioned endpoints.
+	// Default: "ml.g5.xlarge"
+	InstanceType string `json:"instanceType,omitempty" yaml:"instanceType,omitempty"`
+
+	// InstanceCount is the initial instance count for provisioned endpoints.
+	// Default: 1
+	InstanceCount int `json:"instanceCount,omitempty" yaml:"instanceCount,omitempty"`
+
+	// EnvVar is the environment variable that receives the endpoint name.
+	// Default: "SAGEMAKER_ENDPOINT_{ENDPOINT_NAME}"
+	EnvVar string `json:"envVar,omitempty" yaml:"envVar,omitempty"`
+
+	// Agents is the list of agent names that call the endpoint.
+	// If empty, all agents in the stack are included.
+	Agents []string `json:"agents,omitempty" yaml:"agents,omitempty"`
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *SageMakerEndpointConfig) ApplyDefaults() {
+	if c.Image != "" {
+		if c.InstanceType == "" {
+			c.InstanceType = "ml.g5.xlarge"
+		}
+		if c.InstanceCount == 0 {
+			c.InstanceCount = 1
+		}
+	}
+	if c.EnvVar == "" {
+		c.EnvVar = "SAGEMAKER_ENDPOINT_" + strings.ToUpper(strings.ReplaceAll(c.EndpointName, "-", "_"))
+	}
+}
+
+// Validate validates the SageMakerEndpointConfig against the stack configuration.
+func (c *SageMakerEndpointConfig) Validate(config iac.StackConfig) error {
+	if !sageMakerNamePattern.MatchString(c.EndpointName) {
+		return fmt.Errorf("sageMakerEndpoints: endpointName %q is not a valid SageMaker endpoint name", c.EndpointName)
+	}
+	if c.Image == "" && (c.ModelDataURL != "" || len(c.Environment) > 0) {
+		return fmt.Errorf("sageMakerEndpoints[%s]: modelDataURL and environment require image", c.EndpointName)
+	}
+	if c.ModelDataURL != "" && !strings.HasPrefix(c.ModelDataURL, "s3://") {
+		return fmt.Errorf("sageMakerEndpoints[%s]: modelDataURL must be an s3:// URL", c.EndpointName)
+	}
+	if c.Image != "" && c.InstanceCount < 1 {
+		return fmt.Errorf("sageMakerEndpoints[%s]: instanceCount must be at least 1", c.EndpointName)
+	}
+	return validateAgentNames(fmt.Sprintf("sageMakerEndpoints[%s].agents", c.EndpointName), c.Agents, config)
+}
+
+// createSageMakerEndpoints provisions or references SageMaker endpoints and grants agents access.
+func (s *AgentCoreStack) createSageMakerEndpoints(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	var provisioned bool
+	var modelDataArns pulumi.StringArray
+	for _, cfg := range s.Options.SageMakerEndpoints {
+		if cfg.Image == "" {
+			continue
+		}
+		provisioned = true
+		if cfg.ModelDataURL != "" {
+			modelDataArns = append(modelDataArns, pulumi.String("arn:aws:s3:::"+strings.TrimPrefix(cfg.ModelDataURL, "s3://")))
+		}
+	}
+
+	// Role assumed by SageMaker to pull images and model artifacts
+	var modelRoleArn pulumi.StringOutput
+	if provisioned {
+		role, err := s.newServiceRole(ctx, "sagemaker-model-role", "sagemaker.amazonaws.com", tags)
+		if err != nil {
+			return err
+		}
+		modelRoleArn = role.Arn
+
+		statements := []policyStatement{
+			{
+				Actions: []string{
+					"ecr:GetAuthorizationToken",
+					"ecr:BatchGetImage",
+					"ecr:GetDownloadUrlForLayer",
+				},
+				Resources: pulumi.StringArray{pulumi.String("*")},
+			},
+			{
+				Actions: []string{
+					"logs:CreateLogGroup",
+					"logs:CreateLogStream",
+					"logs:PutLogEvents",
+				},
+				Resources: pulumi.StringArray{pulumi.String("arn:aws:logs:*:*:log-group:/aws/sagemaker/Endpoints/*")},
+			},
+		}
+		if len(modelDataArns) > 0 {
+			statements = append(statements, policyStatement{
+				Actions:   []string{"s3:GetObject"},
+				Resources: modelDataArns,
+			})
+		}
+		if err := s.newRolePolicy(ctx, "sagemaker-model-policy", role.Name, statements...); err != nil {
+			return err
+		}
+	}
+
+	// Each agent may invoke only the endpoints it is given
+	endpointArns := make(map[string]pulumi.StringArray)
+	for _, cfg := range s.Options.SageMakerEndpoints {
+		endpointName := pulumi.String(cfg.EndpointName).ToStringOutput()
+		endpointArn := pulumi.Sprintf("arn:aws:sagemaker:*:*:endpoint/%s", strings.ToLower(cfg.EndpointName))
+
+		if cfg.Image != "" {
+			endpoint, err := s.provisionSageMakerEndpoint(ctx, cfg, modelRoleArn, tags)
+			if err != nil {
+				return err
+			}
+			endpointName = endpoint.Name
+			endpointArn = endpoint.Arn
+		}
+
+		s.SageMakerEndpoints[cfg.EndpointName] = endpointName
+		for _, name := range s.selectedAgents(cfg.Agents) {
+			s.injectEnv(name, cfg.EnvVar, endpointName)
+			endpointArns[name] = append(endpointArns[name], endpointArn)
+		}
+	}
+
+	for _, agent := range s.Config.Agents {
+		if len(endpointArns[agent.Name]) == 0 {
+			continue
+		}
+		err := s.attachAgentPolicy(ctx, agent.Name, "sagemaker-invoke-policy", policyStatement{
+			Actions: []string{
+				"sagemaker:InvokeEndpoint",
+				"sagemaker:InvokeEndpointWithResponseStream",
+			},
+			Resources: endpointArns[agent.Name],
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// provisionSageMakerEndpoint creates the model, endpoint configuration and endpoint for cfg.
+func (s *AgentCoreStack) provisionSageMakerEndpoint(ctx *pulumi.Context, cfg SageMakerEndpointConfig, roleArn pulumi.StringInput, tags pulumi.StringMap) (*sagemaker.Endpoint, error) {
+	container := &sagemaker.ModelPrimaryContainerArgs{
+		Image:       pulumi.String(cfg.Image),
+		Environment: pulumi.ToStringMap(cfg.Environment),
+	}
+	if cfg.ModelDataURL != "" {
+		container.ModelDataUrl = pulumi.String(cfg.ModelDataURL)
+	}
+
+	model, err := sagemaker.NewModel(ctx, s.ResourceName(fmt.Sprintf("sagemaker-%s-model", cfg.EndpointName)), &sagemaker.ModelArgs{
+		ExecutionRoleArn: roleArn,
+		PrimaryContainer: container,
+		Tags:             mergeTags(tags, pulumi.Sprintf("%s-model", cfg.EndpointName)),
+	}, s.child())
+	if err != nil {
+		return nil, err
+	}
+
+	endpointConfig, err := sagemaker.NewEndpointConfiguration(ctx, s.ResourceName(fmt.Sprintf("sagemaker-%s-config", cfg.EndpointName)), &sagemaker.EndpointConfigurationArgs{
+		ProductionVariants: sagemaker.EndpointConfigurationProductionVariantArray{
+			&sagemaker.EndpointConfigurationProductionVariantArgs{
+				VariantName:          pulumi.String("primary"),
+				ModelName:            model.Name,
+				InstanceType:         pulumi.String(cfg.InstanceType),
+				InitialInstanceCount: pulumi.Int(cfg.InstanceCount),
+			},
+		},
+		Tags: mergeTags(tags, pulumi.Sprintf("%s-config", cfg.EndpointName)),
+	}, s.child())
+	if err != nil {
+		return nil, err
+	}
+
+	return sagemaker.NewEndpoint(ctx, s.ResourceName(fmt.Sprintf("sagemaker-%s-endpoint", cfg.EndpointName)), &sagemaker.EndpointArgs{
+		Name:               pulumi.String(cfg.EndpointName),
+		EndpointConfigName: endpointConfig.Name,
+		Tags:               mergeTags(tags, pulumi.String(cfg.EndpointName)),
+	}, s.child())
+}
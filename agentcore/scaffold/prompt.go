@@ -0,0 +1,123 @@
+package scaffold
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// prompter asks questions on a terminal.
+type prompter struct {
+	in  *bufio.Scanner
+	out io.Writer
+}
+
+// ask asks a question and returns the answer, or def if the answer is empty.
+func (p *prompter) ask(question, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", question)
+	}
+	if !p.in.Scan() {
+		if err := p.in.Err(); err != nil {
+			return "", err
+		}
+		return "", io.ErrUnexpectedEOF
+	}
+	answer := strings.TrimSpace(p.in.Text())
+	if answer == "" {
+		return def, nil
+	}
+	return answer, nil
+}
+
+// choose asks a question until the answer is one of choices.
+func (p *prompter) choose(question string, choices []string, def string) (string, error) {
+	for {
+		answer, err := p.ask(fmt.Sprintf("%s (%s)", question, strings.Join(choices, ", ")), def)
+		if err != nil {
+			return "", err
+		}
+		for _, choice := range choices {
+			if answer == choice {
+				return answer, nil
+			}
+		}
+		fmt.Fprintf(p.out, "Please answer one of %s.\n", strings.Join(choices, ", "))
+	}
+}
+
+// Ask asks the questions a project is generated from on a terminal and
+// returns the answers. Empty answers take the suggested default.
+func Ask(in io.Reader, out io.Writer) (*Answers, error) {
+	p := &prompter{in: bufio.NewScanner(in), out: out}
+	answers := &Answers{}
+	var err error
+
+	if answers.Name, err = p.ask("Project name", "my-agents"); err != nil {
+		return nil, err
+	}
+
+	names, err := p.ask("Agent names, comma-separated, default agent first", "assistant")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		image, err := p.ask(fmt.Sprintf("Container image of %s", name), fmt.Sprintf("ghcr.io/example/%s:latest", name))
+		if err != nil {
+			return nil, err
+		}
+		answers.Agents = append(answers.Agents, Agent{Name: name, Image: image})
+	}
+
+	if answers.RuntimeTarget, err = p.choose("Runtime target", []string{TargetAgentCore, TargetLightsail}, TargetAgentCore); err != nil {
+		return nil, err
+	}
+	if answers.RuntimeTarget == TargetAgentCore {
+		answers.Networking, err = p.choose("Networking mode", []string{NetworkPublic, NetworkNewVPC, NetworkExistingVPC}, NetworkNewVPC)
+		if err != nil {
+			return nil, err
+		}
+		if answers.Networking == NetworkExistingVPC {
+			if answers.VPCID, err = p.ask("VPC ID", ""); err != nil {
+				return nil, err
+			}
+			subnets, err := p.ask("Private subnet IDs, comma-separated", "")
+			if err != nil {
+				return nil, err
+			}
+			for _, subnet := range strings.Split(subnets, ",") {
+				if subnet = strings.TrimSpace(subnet); subnet != "" {
+					answers.SubnetIDs = append(answers.SubnetIDs, subnet)
+				}
+			}
+		}
+
+		answers.Observability, err = p.choose("Observability provider", []string{"cloudwatch", "opik", "langfuse", "phoenix"}, "cloudwatch")
+		if err != nil {
+			return nil, err
+		}
+		if answers.Observability != "cloudwatch" {
+			answers.ObservabilitySecretARN, err = p.ask(fmt.Sprintf("ARN of the secret holding the %s API key", answers.Observability), "")
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if answers.Region, err = p.ask("AWS region", "us-east-1"); err != nil {
+		return nil, err
+	}
+
+	answers.ApplyDefaults()
+	if err := answers.Validate(); err != nil {
+		return nil, err
+	}
+	return answers, nil
+}
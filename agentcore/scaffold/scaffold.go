@@ -0,0 +1,394 @@
+// Package scaffold generates new deployment projects wired to this module:
+// a Pulumi Go program with its configuration files, from a few answers
+// about the agents and how they are deployed.
+package scaffold
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/plexusone/agentkit-aws-pulumi/agentcore"
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"gopkg.in/yaml.v3"
+)
+
+// Runtime targets.
+const (
+	// TargetAgentCore deploys the agents to AgentCore Runtime with an
+	// AgentCore stack.
+	TargetAgentCore = agentcore.RuntimeTargetAgentCore
+
+	// TargetLightsail deploys a single agent to a Lightsail container
+	// service.
+	TargetLightsail = "lightsail"
+)
+
+// Networking modes.
+const (
+	// NetworkPublic runs the agents without a VPC.
+	NetworkPublic = "public"
+
+	// NetworkNewVPC creates a VPC for the agents.
+	NetworkNewVPC = "new-vpc"
+
+	// NetworkExistingVPC runs the agents in an existing VPC.
+	NetworkExistingVPC = "existing-vpc"
+)
+
+// namePattern matches project, stack and agent names.
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9-]{0,39}$`)
+
+// Agent is an agent of the generated project.
+type Agent struct {
+	// Name is the agent name.
+	Name string `json:"name" yaml:"name"`
+
+	// Image is the agent's container image.
+	Image string `json:"image" yaml:"image"`
+}
+
+// Answers are the choices a project is generated from.
+type Answers struct {
+	// Name is the project and stack name.
+	Name string `json:"name" yaml:"name"`
+
+	// Agents are the agents. The first agent is the default agent.
+	Agents []Agent `json:"agents" yaml:"agents"`
+
+	// RuntimeTarget is where the agents run.
+	// Supported: "agentcore", "lightsail"
+	// Default: "agentcore"
+	RuntimeTarget string `json:"runtimeTarget,omitempty" yaml:"runtimeTarget,omitempty"`
+
+	// Networking is how the agents are networked. Lightsail services are
+	// always public.
+	// Supported: "public", "new-vpc", "existing-vpc"
+	// Default: "new-vpc" for agentcore, "public" for lightsail
+	Networking string `json:"networking,omitempty" yaml:"networking,omitempty"`
+
+	// VPCID is the existing VPC for "existing-vpc" networking.
+	VPCID string `json:"vpcId,omitempty" yaml:"vpcId,omitempty"`
+
+	// SubnetIDs are the existing subnets for "existing-vpc" networking.
+	SubnetIDs []string `json:"subnetIds,omitempty" yaml:"subnetIds,omitempty"`
+
+	// Observability is the observability provider.
+	// Supported: "opik", "langfuse", "phoenix", "cloudwatch"
+	// Default: "cloudwatch"
+	Observability string `json:"observability,omitempty" yaml:"observability,omitempty"`
+
+	// ObservabilitySecretARN is the secret holding the provider's API key,
+	// required for providers other than cloudwatch.
+	ObservabilitySecretARN string `json:"observabilitySecretArn,omitempty" yaml:"observabilitySecretArn,omitempty"`
+
+	// Region is the AWS region.
+	// Default: "us-east-1"
+	Region string `json:"region,omitempty" yaml:"region,omitempty"`
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (a *Answers) ApplyDefaults() {
+	if a.RuntimeTarget == "" {
+		a.RuntimeTarget = TargetAgentCore
+	}
+	if a.Networking == "" {
+		if a.RuntimeTarget == TargetLightsail {
+			a.Networking = NetworkPublic
+		} else {
+			a.Networking = NetworkNewVPC
+		}
+	}
+	if a.Observability == "" {
+		a.Observability = "cloudwatch"
+	}
+	if a.Region == "" {
+		a.Region = "us-east-1"
+	}
+}
+
+// Validate validates the Answers.
+func (a *Answers) Validate() error {
+	if !namePattern.MatchString(a.Name) {
+		return fmt.Errorf("name: '%s' must be up to 40 lowercase letters, digits or hyphens, starting with a letter", a.Name)
+	}
+	if len(a.Agents) == 0 {
+		return fmt.Errorf("agents: at least one agent is required")
+	}
+	names := make(map[string]bool)
+	for _, agent := range a.Agents {
+		if !namePattern.MatchString(agent.Name) {
+			return fmt.Errorf("agents: name '%s' must be up to 40 lowercase letters, digits or hyphens, starting with a letter", agent.Name)
+		}
+		if names[agent.Name] {
+			return fmt.Errorf("agents: duplicate name '%s'", agent.Name)
+		}
+		names[agent.Name] = true
+		if agent.Image == "" {
+			return fmt.Errorf("agents[%s].image is required", agent.Name)
+		}
+	}
+
+	switch a.RuntimeTarget {
+	case TargetAgentCore:
+	case TargetLightsail:
+		if len(a.Agents) > 1 {
+			return fmt.Errorf("runtimeTarget lightsail deploys a single agent")
+		}
+		if a.Networking != NetworkPublic {
+			return fmt.Errorf("runtimeTarget lightsail only supports public networking")
+		}
+	default:
+		return fmt.Errorf("runtimeTarget must be one of [agentcore lightsail]")
+	}
+
+	switch a.Networking {
+	case NetworkPublic, NetworkNewVPC:
+	case NetworkExistingVPC:
+		if a.VPCID == "" || len(a.SubnetIDs) == 0 {
+			return fmt.Errorf("networking existing-vpc requires vpcId and subnetIds")
+		}
+	default:
+		return fmt.Errorf("networking must be one of [public new-vpc existing-vpc]")
+	}
+
+	switch a.Observability {
+	case "cloudwatch":
+	case "opik", "langfuse", "phoenix":
+		if a.ObservabilitySecretARN == "" {
+			return fmt.Errorf("observability %s requires observabilitySecretArn", a.Observability)
+		}
+	default:
+		return fmt.Errorf("observability must be one of %v", iac.ValidObservabilityProviders())
+	}
+	return nil
+}
+
+// StackConfig returns the AgentCore stack configuration of the answers.
+func (a *Answers) StackConfig() iac.StackConfig {
+	config := iac.StackConfig{
+		StackName: a.Name,
+		Agents:    make([]iac.AgentConfig, 0, len(a.Agents)),
+		Observability: &iac.ObservabilityConfig{
+			Provider:             a.Observability,
+			Project:              a.Name,
+			APIKeySecretARN:      a.ObservabilitySecretARN,
+			EnableCloudWatchLogs: true,
+			LogRetentionDays:     30,
+		},
+		Tags: map[string]string{"Project": a.Name},
+	}
+	for i, agent := range a.Agents {
+		agentConfig := iac.DefaultAgentConfig(agent.Name, agent.Image)
+		agentConfig.Environment = nil
+		agentConfig.SecretsARNs = nil
+		agentConfig.IsDefault = i == 0
+		config.Agents = append(config.Agents, agentConfig)
+	}
+	switch a.Networking {
+	case NetworkNewVPC:
+		config.VPC = iac.DefaultVPCConfig()
+	case NetworkExistingVPC:
+		config.VPC = &iac.VPCConfig{VPCID: a.VPCID, SubnetIDs: a.SubnetIDs, EnableVPCEndpoints: true}
+	}
+	return config
+}
+
+// Generate returns the files of the project, keyed by their path relative
+// to the project directory.
+func Generate(answers Answers) (map[string][]byte, error) {
+	answers.ApplyDefaults()
+	if err := answers.Validate(); err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte)
+	var err error
+	files["go.mod"], err = render(goModTemplate, answers)
+	if err != nil {
+		return nil, err
+	}
+
+	var program []byte
+	if answers.RuntimeTarget == TargetLightsail {
+		files["deploy.yaml"], err = render(deployConfigTemplate, answers)
+		if err != nil {
+			return nil, err
+		}
+		program, err = render(lightsailProgramTemplate, answers)
+	} else {
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(answers.StackConfig()); err != nil {
+			return nil, fmt.Errorf("scaffold: failed to encode config.yaml: %w", err)
+		}
+		files["config.yaml"] = buf.Bytes()
+		files["Pulumi.yaml"], err = render(pulumiProjectTemplate, answers)
+		if err != nil {
+			return nil, err
+		}
+		files[fmt.Sprintf("Pulumi.%s.yaml", answers.Name)], err = render(pulumiStackTemplate, answers)
+		if err != nil {
+			return nil, err
+		}
+		program, err = render(agentCoreProgramTemplate, answers)
+	}
+	if err != nil {
+		return nil, err
+	}
+	files["main.go"], err = format.Source(program)
+	if err != nil {
+		return nil, fmt.Errorf("scaffold: failed to format main.go: %w", err)
+	}
+	return files, nil
+}
+
+// Write writes the project's files to dir. Existing files are only
+// overwritten with overwrite set.
+func Write(dir string, files map[string][]byte, overwrite bool) error {
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	if !overwrite {
+		var existing []string
+		for _, path := range paths {
+			if _, err := os.Stat(filepath.Join(dir, path)); err == nil {
+				existing = append(existing, path)
+			}
+		}
+		if len(existing) > 0 {
+			return fmt.Errorf("scaffold: %s already exist in %s", strings.Join(existing, ", "), dir)
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("scaffold: %w", err)
+	}
+	for _, path := range paths {
+		if err := os.WriteFile(filepath.Join(dir, path), files[path], 0o644); err != nil {
+			return fmt.Errorf("scaffold: %w", err)
+		}
+	}
+	return nil
+}
+
+// render executes a file template with the answers.
+func render(tmpl *template.Template, answers Answers) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, answers); err != nil {
+		return nil, fmt.Errorf("scaffold: failed to render %s: %w", tmpl.Name(), err)
+	}
+	return buf.Bytes(), nil
+}
+
+// splitImage splits an image reference into its repository and tag.
+func splitImage(image string) []string {
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return []string{image[:i], image[i+1:]}
+	}
+	return []string{image, ""}
+}
+
+var pulumiProjectTemplate = template.Must(template.New("Pulumi.yaml").Parse(`name: {{.Name}}
+description: {{.Name}} agents
+runtime: go
+`))
+
+var pulumiStackTemplate = template.Must(template.New("Pulumi.stack.yaml").Parse(`config:
+  aws:region: {{.Region}}
+`))
+
+var goModTemplate = template.Must(template.New("go.mod").Parse(`module {{.Name}}
+
+go 1.26
+`))
+
+var agentCoreProgramTemplate = template.Must(template.New("main.go").Parse(`// Program {{.Name}} deploys its agents to AgentCore Runtime. The stack is
+// configured in config.yaml.
+//
+// Deploy with:
+//
+//	go mod tidy
+//	pulumi up
+package main
+
+import (
+	"github.com/plexusone/agentkit-aws-pulumi/agentcore"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+func main() {
+	pulumi.Run(func(ctx *pulumi.Context) error {
+		_, err := agentcore.NewStackFromFile(ctx, "config.yaml")
+		return err
+	})
+}
+`))
+
+var lightsailProgramTemplate = template.Must(template.New("main.go").Parse(`// Program {{.Name}} deploys its agent to a Lightsail container service. The
+// deployment is configured in deploy.yaml.
+//
+// Deploy with:
+//
+//	go mod tidy
+//	go run .
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	_ "github.com/plexusone/agentkit-aws-pulumi/deploy/providers/lightsail"
+	"github.com/plexusone/agentkit/deploy"
+)
+
+func main() {
+	ctx := context.Background()
+
+	cfg, err := deploy.LoadDeployConfig("deploy.yaml")
+	if err != nil {
+		log.Fatal(err)
+	}
+	provider, err := deploy.GetProvider(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() { _ = provider.Close() }()
+
+	status, err := provider.Deploy(ctx, cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(status.Outputs["serviceUrl"])
+}
+`))
+
+var deployConfigTemplate = template.Must(template.New("deploy.yaml").Funcs(template.FuncMap{
+	"splitImage": splitImage,
+}).Parse(`{{$image := splitImage (index .Agents 0).Image}}stack:
+  name: {{.Name}}
+  project: {{.Name}}
+  region: {{.Region}}
+  image:
+    repository: {{index $image 0}}
+{{- if index $image 1}}
+    tag: {{index $image 1}}
+{{- end}}
+  resources:
+    port: 8080
+  tags:
+    Project: {{.Name}}
+
+provider: lightsail
+`))
@@ -0,0 +1,154 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/scheduler"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+var (
+	// scheduleExpressionPattern matches EventBridge Scheduler cron, rate and
+	// one-time expressions.
+	scheduleExpressionPattern = regexp.MustCompile(`^(cron|rate|at)\(.+\)$`)
+
+	// scheduleNamePattern matches the characters allowed in schedule names.
+	scheduleNamePattern = regexp.MustCompile(`^[0-9a-zA-Z-_.]{1,32}$`)
+)
+
+// AgentSchedule invokes an agent on a schedule with a fixed payload, for
+// cron-style runs such as nightly research jobs.
+//
+// Each run is a new runtime session. Agents with replicas have their
+// schedules spread across the replicas.
+type AgentSchedule struct {
+	// Name identifies the schedule within the agent.
+	// Default: "schedule-{n}", numbered from 1
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+
+	// Expression is an EventBridge Scheduler expression, e.g.
+	// "cron(0 2 * * ? *)" or "rate(6 hours)".
+	Expression string `json:"expression" yaml:"expression"`
+
+	// Timezone is the IANA timezone for cron schedules.
+	// Default: "UTC"
+	Timezone string `json:"timezone,omitempty" yaml:"timezone,omitempty"`
+
+	// Payload is the JSON request sent to the agent on each run.
+	// Default: {}
+	Payload json.RawMessage `json:"payload,omitempty" yaml:"payload,omitempty"`
+}
+
+// ApplyDefaults applies default values to unset fields. n is the schedule's
+// position among the agent's schedules, from 1.
+func (c *AgentSchedule) ApplyDefaults(n int) {
+	if c.Name == "" {
+		c.Name = fmt.Sprintf("schedule-%d", n)
+	}
+	if c.Timezone == "" {
+		c.Timezone = "UTC"
+	}
+	if len(c.Payload) == 0 {
+		c.Payload = json.RawMessage(`{}`)
+	}
+}
+
+// validateSchedules validates AgentOptions.Schedules for the named agent.
+func validateSchedules(agentName string, schedules []AgentSchedule) error {
+	names := make(map[string]bool)
+	for _, schedule := range schedules {
+		field := fmt.Sprintf("agents[%s].schedules[%s]", agentName, schedule.Name)
+		if !scheduleNamePattern.MatchString(schedule.Name) {
+			return fmt.Errorf("agents[%s].schedules: name %q must match %s", agentName, schedule.Name, scheduleNamePattern)
+		}
+		if names[schedule.Name] {
+			return fmt.Errorf("agents[%s].schedules: duplicate name %q", agentName, schedule.Name)
+		}
+		names[schedule.Name] = true
+		if !scheduleExpressionPattern.MatchString(schedule.Expression) {
+			return fmt.Errorf("%s.expression: '%s' is not a cron, rate or at expression", field, schedule.Expression)
+		}
+		if !json.Valid(schedule.Payload) {
+			return fmt.Errorf("%s.payload must be valid JSON", field)
+		}
+	}
+	return nil
+}
+
+// createAgentSchedules creates the agents' schedules and the role EventBridge
+// Scheduler assumes to invoke them. It runs after the runtimes are created.
+func (s *AgentCoreStack) createAgentSchedules(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	stackName := s.Config.StackName
+
+	agents := make([]string, 0, len(s.Options.Agents))
+	for name, agent := range s.Options.Agents {
+		if len(agent.Schedules) > 0 {
+			agents = append(agents, name)
+		}
+	}
+	if len(agents) == 0 {
+		return nil
+	}
+	sort.Strings(agents)
+
+	var runtimeArns pulumi.StringArray
+	for _, agentName := range agents {
+		for _, name := range s.agentRuntimeNames(agentName) {
+			arn := s.AgentRuntimes[name].RuntimeArn
+			runtimeArns = append(runtimeArns, arn, pulumi.Sprintf("%s/*", arn))
+		}
+	}
+	role, err := s.newServiceRole(ctx, "agent-schedule-role", "scheduler.amazonaws.com", tags)
+	if err != nil {
+		return err
+	}
+	err = s.newRolePolicy(ctx, "agent-schedule-policy", role.Name, policyStatement{
+		Actions:   []string{"bedrock-agentcore:InvokeAgentRuntime"},
+		Resources: runtimeArns,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, agentName := range agents {
+		runtimeNames := s.agentRuntimeNames(agentName)
+		for i, schedule := range s.Options.Agents[agentName].Schedules {
+			payload := string(schedule.Payload)
+			// Scheduler execution IDs are UUIDs, long enough for runtime session IDs
+			input := s.AgentRuntimes[runtimeNames[i%len(runtimeNames)]].RuntimeArn.ApplyT(func(arn string) (string, error) {
+				data, err := json.Marshal(map[string]string{
+					"AgentRuntimeArn":  arn,
+					"Qualifier":        runtimeEndpointName,
+					"RuntimeSessionId": "<aws.scheduler.execution-id>",
+					"ContentType":      "application/json",
+					"Payload":          payload,
+				})
+				return string(data), err
+			}).(pulumi.StringOutput)
+
+			key := fmt.Sprintf("%s-%s", agentName, schedule.Name)
+			s.AgentSchedules[key], err = scheduler.NewSchedule(ctx, s.ResourceName(key+"-schedule"), &scheduler.ScheduleArgs{
+				Name:                       pulumi.Sprintf("%s-%s", stackName, key),
+				Description:                pulumi.Sprintf("Scheduled runs of %s agent %s", stackName, agentName),
+				ScheduleExpression:         pulumi.String(schedule.Expression),
+				ScheduleExpressionTimezone: pulumi.String(schedule.Timezone),
+				FlexibleTimeWindow: &scheduler.ScheduleFlexibleTimeWindowArgs{
+					Mode: pulumi.String("OFF"),
+				},
+				Target: &scheduler.ScheduleTargetArgs{
+					Arn:     pulumi.String("arn:aws:scheduler:::aws-sdk:bedrockagentcore:invokeAgentRuntime"),
+					RoleArn: role.Arn,
+					Input:   input,
+				},
+			}, s.child())
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
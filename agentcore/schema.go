@@ -0,0 +1,238 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/ssm"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// ssmStandardTierLimit is the maximum value size of a Standard tier SSM parameter.
+const ssmStandardTierLimit = 4096
+
+// validateSchema checks that a schema is a JSON object with a "type" keyword.
+func validateSchema(field string, schema json.RawMessage) error {
+	if len(schema) == 0 {
+		return nil
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(schema, &doc); err != nil {
+		return fmt.Errorf("%s must be a JSON Schema object: %w", field, err)
+	}
+	if _, ok := doc["type"]; !ok {
+		return fmt.Errorf("%s must declare a \"type\"", field)
+	}
+	return nil
+}
+
+// AgentOpenAPI returns an OpenAPI 3 document describing an agent's
+// invocation endpoint with its declared request and response schemas.
+func AgentOpenAPI(agent iac.AgentConfig, options AgentOptions) ([]byte, error) {
+	anyObject := json.RawMessage(`{"type":"object"}`)
+	input := options.InputSchema
+	if len(input) == 0 {
+		input = anyObject
+	}
+	output := options.OutputSchema
+	if len(output) == 0 {
+		output = anyObject
+	}
+
+	doc := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       agent.Name,
+			"description": agent.Description,
+			"version":     "1.0.0",
+		},
+		"paths": map[string]interface{}{
+			"/invocations": map[string]interface{}{
+				"post": map[string]interface{}{
+					"operationId": "invoke_" + agentCoreName(agent.Name),
+					"requestBody": map[string]interface{}{
+						"required": true,
+						"content": map[string]interface{}{
+							"application/json": map[string]interface{}{"schema": input},
+						},
+					},
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{
+							"description": "Agent response",
+							"content": map[string]interface{}{
+								"application/json": map[string]interface{}{"schema": output},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// AgentCard returns an agent card describing an agent and its declared
+// request and response schemas, in the format of A2A agent cards. The
+// card's single skill invokes the agent.
+func AgentCard(agent iac.AgentConfig, options AgentOptions) ([]byte, error) {
+	skill := map[string]interface{}{
+		"id":          "invoke",
+		"name":        agent.Name,
+		"description": agent.Description,
+		"inputModes":  []string{"application/json"},
+		"outputModes": []string{"application/json"},
+	}
+	if len(options.InputSchema) > 0 {
+		skill["inputSchema"] = options.InputSchema
+	}
+	if len(options.OutputSchema) > 0 {
+		skill["outputSchema"] = options.OutputSchema
+	}
+
+	card := map[string]interface{}{
+		"name":               agent.Name,
+		"description":        agent.Description,
+		"version":            "1.0.0",
+		"defaultInputModes":  []string{"application/json"},
+		"defaultOutputModes": []string{"application/json"},
+		"capabilities":       map[string]interface{}{},
+		"skills":             []interface{}{skill},
+	}
+	if agent.Protocol != "" {
+		card["protocol"] = agent.Protocol
+	}
+
+	return json.MarshalIndent(card, "", "  ")
+}
+
+// AgentToolDefinitions returns a JSON array with a gateway tool definition
+// that invokes an agent, for the schema of a GatewayTarget of type lambda
+// whose function forwards tool calls to the agent. Gateway tool schemas
+// support the type, description, properties, required and items keywords;
+// other keywords of the agent's schemas are dropped.
+func AgentToolDefinitions(agent iac.AgentConfig, options AgentOptions) ([]byte, error) {
+	input, err := gatewaySchemaDefinition(options.InputSchema)
+	if err != nil {
+		return nil, fmt.Errorf("inputSchema: %w", err)
+	}
+	description := agent.Description
+	if description == "" {
+		description = fmt.Sprintf("Invokes the %s agent", agent.Name)
+	}
+	tool := map[string]interface{}{
+		"name":        "invoke_" + agentCoreName(agent.Name),
+		"description": description,
+		"inputSchema": input,
+	}
+	if len(options.OutputSchema) > 0 {
+		output, err := gatewaySchemaDefinition(options.OutputSchema)
+		if err != nil {
+			return nil, fmt.Errorf("outputSchema: %w", err)
+		}
+		tool["outputSchema"] = output
+	}
+
+	return json.MarshalIndent([]interface{}{tool}, "", "  ")
+}
+
+// gatewaySchemaDefinition converts a JSON Schema to the subset gateway tool
+// schemas support. An empty schema becomes an object schema.
+func gatewaySchemaDefinition(schema json.RawMessage) (map[string]interface{}, error) {
+	if len(schema) == 0 {
+		return map[string]interface{}{"type": "object"}, nil
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(schema, &doc); err != nil {
+		return nil, err
+	}
+	return gatewaySchemaSubset(doc), nil
+}
+
+// gatewaySchemaSubset keeps the keywords of a schema that gateway tool
+// schemas support, recursively.
+func gatewaySchemaSubset(doc map[string]interface{}) map[string]interface{} {
+	result := map[string]interface{}{}
+	for _, key := range []string{"type", "description", "required"} {
+		if value, ok := doc[key]; ok {
+			result[key] = value
+		}
+	}
+	if items, ok := doc["items"].(map[string]interface{}); ok {
+		result["items"] = gatewaySchemaSubset(items)
+	}
+	if properties, ok := doc["properties"].(map[string]interface{}); ok {
+		subset := map[string]interface{}{}
+		for name, property := range properties {
+			if property, ok := property.(map[string]interface{}); ok {
+				subset[name] = gatewaySchemaSubset(property)
+			}
+		}
+		result["properties"] = subset
+	}
+	return result
+}
+
+// agentSchemaDocument is a document published for agents that declare
+// schemas.
+type agentSchemaDocument struct {
+	name        string
+	description string
+	render      func(iac.AgentConfig, AgentOptions) ([]byte, error)
+}
+
+// agentSchemaDocuments are the documents published for each agent that
+// declares schemas, stored at /agentcore/{stack}/{agent}/{name}.
+var agentSchemaDocuments = []agentSchemaDocument{
+	{"openapi", "OpenAPI document", AgentOpenAPI},
+	{"card", "Agent card", AgentCard},
+	{"tools", "Gateway tool definitions", AgentToolDefinitions},
+}
+
+// publishAgentSchemas publishes an OpenAPI document, an agent card and
+// gateway tool definitions to SSM for each agent that declares a request
+// or response schema.
+func (s *AgentCoreStack) publishAgentSchemas(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	stackName := s.Config.StackName
+
+	for _, agent := range s.Config.Agents {
+		opts := s.Options.Agents[agent.Name]
+		if len(opts.InputSchema) == 0 && len(opts.OutputSchema) == 0 {
+			continue
+		}
+
+		params := make(map[string]pulumi.StringOutput)
+		for _, document := range agentSchemaDocuments {
+			spec, err := document.render(agent, opts)
+			if err != nil {
+				return fmt.Errorf("agent %s: %w", agent.Name, err)
+			}
+
+			tier := "Standard"
+			if len(spec) > ssmStandardTierLimit {
+				tier = "Advanced"
+			}
+
+			param, err := ssm.NewParameter(ctx, s.ResourceName(fmt.Sprintf("%s-%s", agent.Name, document.name)), &ssm.ParameterArgs{
+				Name:          pulumi.Sprintf("/agentcore/%s/%s/%s", stackName, agent.Name, document.name),
+				Description:   pulumi.Sprintf("%s for agent %s", document.description, agent.Name),
+				Type:          pulumi.String("String"),
+				Tier:          pulumi.String(tier),
+				InsecureValue: pulumi.String(string(spec)),
+				Tags:          mergeTags(s.secretTags(ctx, agent.Name, tags), pulumi.Sprintf("%s-%s-%s", stackName, agent.Name, document.name)),
+			}, s.child())
+			if err != nil {
+				return err
+			}
+			params[document.name] = param.Name
+		}
+
+		s.AgentSchemas[agent.Name] = params["openapi"]
+		s.injectEnv(agent.Name, "AGENT_OPENAPI_PARAMETER", params["openapi"])
+		s.injectEnv(agent.Name, "AGENT_CARD_PARAMETER", params["card"])
+		s.injectEnv(agent.Name, "AGENT_TOOLS_PARAMETER", params["tools"])
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package agentcore
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// environmentTagKey tags secrets and parameters with their environment.
+const environmentTagKey = "agentkit:environment"
+
+// SecretPolicyConfig standardizes the secrets and SSM parameters the stack
+// creates so key policies and audits can tell them apart.
+//
+// Every secret and parameter is tagged with the stack, the environment and,
+// for per-agent parameters, the agent. Secrets Manager and Parameter Store
+// set the KMS encryption context to the secret or parameter ARN, so the
+// execution role may only decrypt with the secrets key through Secrets
+// Manager and for the stack's secrets. The stack exports the condition as
+// secretEncryptionContext for use in key policies.
+type SecretPolicyConfig struct {
+	// Environment is the agentkit:environment tag value.
+	// Default: the Pulumi stack name
+	Environment string `json:"environment,omitempty" yaml:"environment,omitempty"`
+
+	// Tags are added to every secret and parameter, e.g. a data
+	// classification required by a tagging policy.
+	Tags map[string]string `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+// Validate validates the SecretPolicyConfig.
+func (c *SecretPolicyConfig) Validate() error {
+	if c.Environment != "" && !labelValuePattern.MatchString(c.Environment) {
+		return fmt.Errorf("secretPolicy.environment: '%s' is not a valid tag value", c.Environment)
+	}
+	for key, value := range c.Tags {
+		if key == "" || len(key) > 128 || strings.HasPrefix(key, "aws:") {
+			return fmt.Errorf("secretPolicy.tags: '%s' is not a valid tag key", key)
+		}
+		if !labelValuePattern.MatchString(value) {
+			return fmt.Errorf("secretPolicy.tags[%s]: '%s' is not a valid tag value", key, value)
+		}
+	}
+	return nil
+}
+
+// secretTags returns the tags of a secret or parameter. agentName is empty
+// for stack-level secrets and parameters.
+func (s *AgentCoreStack) secretTags(ctx *pulumi.Context, agentName string, tags pulumi.StringMap) pulumi.StringMap {
+	cfg := s.Options.SecretPolicy
+	if cfg == nil {
+		return tags
+	}
+	result := pulumi.StringMap{}
+	for k, v := range tags {
+		result[k] = v
+	}
+	for k, v := range cfg.Tags {
+		result[k] = pulumi.String(v)
+	}
+	environment := cfg.Environment
+	if environment == "" {
+		environment = ctx.Stack()
+	}
+	result[environmentTagKey] = pulumi.String(environment)
+	if agentName != "" {
+		result[agentTagKey] = pulumi.String(agentName)
+	}
+	return result
+}
+
+// resolveSecretEncryptionContext sets the KMS conditions that limit
+// decryption to the agents' secrets, which include the secrets the stack
+// creates.
+func (s *AgentCoreStack) resolveSecretEncryptionContext(ctx *pulumi.Context) error {
+	var secretArns []string
+	for _, agent := range s.Config.Agents {
+		secretArns = append(secretArns, agent.SecretsARNs...)
+	}
+	if len(secretArns) == 0 {
+		return nil
+	}
+	region, err := aws.GetRegion(ctx, nil, s.invoke())
+	if err != nil {
+		return err
+	}
+	resources := secretResources(secretArns)
+	sort.Strings(resources)
+	s.secretEncryptionContext = map[string]map[string]interface{}{
+		"StringEquals": {"kms:ViaService": fmt.Sprintf("secretsmanager.%s.amazonaws.com", region.Name)},
+		"StringLike":   {"kms:EncryptionContext:SecretARN": resources},
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/secretsmanager"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// secretKeyPattern matches secret keys, which become environment variable names.
+var secretKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// createsSecrets reports whether the stack creates secrets from SecretValues.
+func createsSecrets(config iac.StackConfig) bool {
+	return config.Secrets != nil && config.Secrets.CreateSecrets
+}
+
+// validateSecretValues validates the secrets the stack creates.
+func validateSecretValues(config iac.StackConfig) error {
+	if len(config.Secrets.SecretValues) == 0 {
+		return fmt.Errorf("secrets.secretValues is required when createSecrets is set")
+	}
+	for key := range config.Secrets.SecretValues {
+		if !secretKeyPattern.MatchString(key) {
+			return fmt.Errorf("secrets.secretValues: '%s' is not a valid environment variable name", key)
+		}
+	}
+	if kmsKey := config.Secrets.KMSKeyARN; kmsKey != "" && !strings.HasPrefix(kmsKey, "arn:") {
+		return fmt.Errorf("secrets.kmsKeyARN: '%s' is not an ARN", kmsKey)
+	}
+	return nil
+}
+
+// secretName returns the base name of the created secrets.
+func (s *AgentCoreStack) secretName() string {
+	if s.Config.Secrets.SecretName != "" {
+		return s.Config.Secrets.SecretName
+	}
+	return fmt.Sprintf("%s-secrets", s.Config.StackName)
+}
+
+// secretResources returns the IAM resources for secret ARNs. Partial ARNs
+// are also matched with the random suffix Secrets Manager appends.
+func secretResources(secretArns []string) []string {
+	var resources []string
+	seen := make(map[string]bool)
+	for _, secretArn := range secretArns {
+		if seen[secretArn] {
+			continue
+		}
+		seen[secretArn] = true
+		resources = append(resources, secretArn)
+		if !strings.Contains(secretArn, "*") {
+			resources = append(resources, secretArn+"-*")
+		}
+	}
+	return resources
+}
+
+// createSecrets creates the secrets in SecretsConfig.SecretValues, either as
+// a single JSON secret or one secret per key, and grants them to every
+// agent through its SecretsARNs.
+//
+// With Options.ReplicatedSecrets the agents are granted the replicas of
+// another region's secrets of the same names instead.
+//
+// Agents reference the secrets by partial ARN, which is known before the
+// secrets exist, so the execution policy and secret tooling can use them.
+func (s *AgentCoreStack) createSecrets(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	cfg := s.Config.Secrets
+
+	region, err := aws.GetRegion(ctx, nil, s.invoke())
+	if err != nil {
+		return err
+	}
+	caller, err := aws.GetCallerIdentity(ctx, nil, s.invoke())
+	if err != nil {
+		return err
+	}
+
+	values := make(map[string]string)
+	if s.Options.SecretsPerKey {
+		for key, value := range cfg.SecretValues {
+			values[fmt.Sprintf("%s/%s", s.secretName(), key)] = value
+		}
+	} else {
+		data, err := json.Marshal(cfg.SecretValues)
+		if err != nil {
+			return err
+		}
+		values[s.secretName()] = string(data)
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var secretArns []string
+	for _, name := range names {
+		secretArns = append(secretArns, fmt.Sprintf("arn:aws:secretsmanager:%s:%s:secret:%s", region.Name, caller.AccountId, name))
+		if s.Options.ReplicatedSecrets {
+			continue
+		}
+
+		resourceName := fmt.Sprintf("secret-%s", strings.ReplaceAll(name, "/", "-"))
+		args := &secretsmanager.SecretArgs{
+			Name:        pulumi.String(name),
+			Description: pulumi.Sprintf("Secrets for %s agents", s.Config.StackName),
+			Tags:        mergeTags(s.secretTags(ctx, "", tags), pulumi.String(name)),
+		}
+		if cfg.KMSKeyARN != "" {
+			args.KmsKeyId = pulumi.String(cfg.KMSKeyARN)
+		} else {
+			args.KmsKeyId = s.kmsKeyID()
+		}
+		if len(s.Options.SecretReplicaRegions) > 0 {
+			replicas := secretsmanager.SecretReplicaArray{}
+			for _, replicaRegion := range s.Options.SecretReplicaRegions {
+				replicas = append(replicas, &secretsmanager.SecretReplicaArgs{
+					Region: pulumi.String(replicaRegion),
+				})
+			}
+			args.Replicas = replicas
+		}
+		secret, err := secretsmanager.NewSecret(ctx, s.ResourceName(resourceName), args, s.child())
+		if err != nil {
+			return err
+		}
+		_, err = secretsmanager.NewSecretVersion(ctx, s.ResourceName(resourceName+"-version"), &secretsmanager.SecretVersionArgs{
+			SecretId:     secret.ID(),
+			SecretString: pulumi.ToSecret(pulumi.String(values[name])).(pulumi.StringOutput),
+		}, s.child())
+		if err != nil {
+			return err
+		}
+		s.Secrets[name] = secret
+	}
+
+	// Copy the agents so the caller's configuration is not modified
+	agents := make([]iac.AgentConfig, len(s.Config.Agents))
+	copy(agents, s.Config.Agents)
+	for i := range agents {
+		agents[i].SecretsARNs = append(append([]string{}, agents[i].SecretsARNs...), secretArns...)
+	}
+	s.Config.Agents = agents
+
+	return nil
+}
@@ -0,0 +1,224 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/scheduler"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// secretsAuditHandler looks up GetSecretValue calls made by the execution
+// role in CloudTrail and reports granted secrets that were never read.
+// Secrets may be referenced by ARN, partial ARN or name.
+const secretsAuditHandler = `import { CloudTrailClient, LookupEventsCommand } from "@aws-sdk/client-cloudtrail";
+import { CloudWatchClient, PutMetricDataCommand } from "@aws-sdk/client-cloudwatch";
+import { SNSClient, PublishCommand } from "@aws-sdk/client-sns";
+
+const cloudtrail = new CloudTrailClient({});
+const cloudwatch = new CloudWatchClient({});
+const sns = new SNSClient({});
+
+const secrets = JSON.parse(process.env.SECRETS);
+const roleArns = JSON.parse(process.env.ROLE_ARNS);
+const lookbackDays = Number(process.env.LOOKBACK_DAYS);
+
+const secretName = (arn) => (arn.split(":secret:")[1] ?? "").replace(/-[A-Za-z0-9]{6}$/, "");
+const matches = (arn, secretId) =>
+  secretId === arn || secretId === secretName(arn) || arn.startsWith(secretId + "-");
+
+export const handler = async () => {
+  const startTime = new Date(Date.now() - lookbackDays * 24 * 60 * 60 * 1000);
+  const read = new Set();
+
+  let NextToken;
+  do {
+    const page = await cloudtrail.send(new LookupEventsCommand({
+      LookupAttributes: [{ AttributeKey: "EventName", AttributeValue: "GetSecretValue" }],
+      StartTime: startTime,
+      NextToken,
+    }));
+    for (const { CloudTrailEvent } of page.Events ?? []) {
+      const event = JSON.parse(CloudTrailEvent);
+      if (!roleArns.includes(event.userIdentity?.sessionContext?.sessionIssuer?.arn)) continue;
+      const secretId = event.requestParameters?.secretId;
+      for (const arn of Object.keys(secrets)) {
+        if (secretId && matches(arn, secretId)) read.add(arn);
+      }
+    }
+    NextToken = page.NextToken;
+  } while (NextToken);
+
+  const unused = Object.entries(secrets)
+    .filter(([arn]) => !read.has(arn))
+    .map(([arn, agents]) => ({ arn, agents }));
+
+  await cloudwatch.send(new PutMetricDataCommand({
+    Namespace: process.env.METRIC_NAMESPACE,
+    MetricData: [{ MetricName: "UnusedSecrets", Value: unused.length, Unit: "Count" }],
+  }));
+
+  const report = { stack: process.env.STACK_NAME, lookbackDays, granted: Object.keys(secrets).length, unused };
+  if (unused.length > 0) {
+    await sns.send(new PublishCommand({
+      TopicArn: process.env.TOPIC_ARN,
+      Subject: ("Unused secrets in " + process.env.STACK_NAME).slice(0, 100),
+      Message: JSON.stringify(report, null, 2),
+    }));
+  }
+  return report;
+};
+`
+
+// SecretsAuditConfig configures a scheduled analyzer that cross-references
+// the agents' SecretsARNs with CloudTrail GetSecretValue events and reports
+// secrets the agents were granted but never read.
+//
+// Wildcard ARNs are ignored since they cannot be matched to single secrets.
+type SecretsAuditConfig struct {
+	// Schedule is an EventBridge Scheduler expression.
+	// Default: "rate(7 days)"
+	Schedule string `json:"schedule,omitempty" yaml:"schedule,omitempty"`
+
+	// LookbackDays is how far back CloudTrail is searched for reads.
+	// Range: 1-90 (CloudTrail event history retention)
+	// Default: 30
+	LookbackDays int `json:"lookbackDays,omitempty" yaml:"lookbackDays,omitempty"`
+
+	// Recipients are email addresses subscribed to unused secret reports.
+	Recipients []string `json:"recipients,omitempty" yaml:"recipients,omitempty"`
+}
+
+// DefaultSecretsAuditConfig returns a SecretsAuditConfig with sensible defaults.
+func DefaultSecretsAuditConfig() *SecretsAuditConfig {
+	return &SecretsAuditConfig{
+		Schedule:     "rate(7 days)",
+		LookbackDays: 30,
+	}
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *SecretsAuditConfig) ApplyDefaults() {
+	if c.Schedule == "" {
+		c.Schedule = "rate(7 days)"
+	}
+	if c.LookbackDays == 0 {
+		c.LookbackDays = 30
+	}
+}
+
+// Validate validates the SecretsAuditConfig against the stack configuration.
+func (c *SecretsAuditConfig) Validate(config iac.StackConfig) error {
+	if c.LookbackDays < 1 || c.LookbackDays > 90 {
+		return fmt.Errorf("secretsAudit.lookbackDays must be between 1 and 90")
+	}
+	for _, email := range c.Recipients {
+		if !strings.Contains(email, "@") {
+			return fmt.Errorf("secretsAudit.recipients: '%s' is not an email address", email)
+		}
+	}
+	if len(grantedSecrets(config)) == 0 && !createsSecrets(config) {
+		return fmt.Errorf("secretsAudit requires at least one agent with secretsARNs")
+	}
+	return nil
+}
+
+// grantedSecrets maps each granted secret ARN to the agents it is granted to.
+func grantedSecrets(config iac.StackConfig) map[string][]string {
+	secrets := make(map[string][]string)
+	for _, agent := range config.Agents {
+		for _, secretArn := range agent.SecretsARNs {
+			if strings.Contains(secretArn, "*") {
+				continue
+			}
+			secrets[secretArn] = append(secrets[secretArn], agent.Name)
+		}
+	}
+	return secrets
+}
+
+// createSecretsAudit creates the analyzer function, its report topic and schedule.
+func (s *AgentCoreStack) createSecretsAudit(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	var err error
+	stackName := s.Config.StackName
+	cfg := s.Options.SecretsAudit
+
+	s.SecretsAuditTopic, err = s.newEventTopic(ctx, "secrets-audit-topic", fmt.Sprintf("%s-secrets-audit", stackName), tags)
+	if err != nil {
+		return err
+	}
+	if err := s.subscribeEmails(ctx, "secrets-audit-recipient", s.SecretsAuditTopic.Arn, cfg.Recipients); err != nil {
+		return err
+	}
+
+	secrets, err := json.Marshal(grantedSecrets(s.Config))
+	if err != nil {
+		return err
+	}
+
+	roleArns := s.agentRoleArns(s.selectedAgents(nil)).ToStringArrayOutput().ApplyT(func(arns []string) (string, error) {
+		data, err := json.Marshal(arns)
+		return string(data), err
+	}).(pulumi.StringOutput)
+
+	// CloudTrail lookups are limited to 2 requests per second, so busy
+	// accounts need the full Lambda timeout
+	function, err := s.newInlineFunction(ctx, "secrets-audit", secretsAuditHandler, 900,
+		pulumi.StringMap{
+			"STACK_NAME":       pulumi.String(stackName),
+			"SECRETS":          pulumi.String(string(secrets)),
+			"ROLE_ARNS":        roleArns,
+			"LOOKBACK_DAYS":    pulumi.Sprintf("%d", cfg.LookbackDays),
+			"METRIC_NAMESPACE": pulumi.String(s.metricNamespace()),
+			"TOPIC_ARN":        s.SecretsAuditTopic.Arn,
+		},
+		tags,
+		policyStatement{
+			Actions:   []string{"cloudtrail:LookupEvents"},
+			Resources: pulumi.StringArray{pulumi.String("*")},
+		},
+		policyStatement{
+			Actions:   []string{"cloudwatch:PutMetricData"},
+			Resources: pulumi.StringArray{pulumi.String("*")},
+			Conditions: map[string]map[string]interface{}{
+				"StringEquals": {"cloudwatch:namespace": s.metricNamespace()},
+			},
+		},
+		policyStatement{
+			Actions:   []string{"sns:Publish"},
+			Resources: pulumi.StringArray{s.SecretsAuditTopic.Arn},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	// Role assumed by EventBridge Scheduler to run the analyzer
+	schedulerRole, err := s.newServiceRole(ctx, "secrets-audit-scheduler-role", "scheduler.amazonaws.com", tags)
+	if err != nil {
+		return err
+	}
+	err = s.newRolePolicy(ctx, "secrets-audit-scheduler-policy", schedulerRole.Name, policyStatement{
+		Actions:   []string{"lambda:InvokeFunction"},
+		Resources: pulumi.StringArray{function.Arn},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = scheduler.NewSchedule(ctx, s.ResourceName("secrets-audit-schedule"), &scheduler.ScheduleArgs{
+		Name:               pulumi.Sprintf("%s-secrets-audit", stackName),
+		Description:        pulumi.Sprintf("Unused secret detection for %s agents", stackName),
+		ScheduleExpression: pulumi.String(cfg.Schedule),
+		FlexibleTimeWindow: &scheduler.ScheduleFlexibleTimeWindowArgs{
+			Mode: pulumi.String("OFF"),
+		},
+		Target: &scheduler.ScheduleTargetArgs{
+			Arn:     function.Arn,
+			RoleArn: schedulerRole.Arn,
+		},
+	}, s.child())
+	return err
+}
@@ -0,0 +1,203 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/cloudwatch"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/securityhub"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// securityHubSeverityLabels are the ASFF severity labels in ascending order.
+var securityHubSeverityLabels = []string{"INFORMATIONAL", "LOW", "MEDIUM", "HIGH", "CRITICAL"}
+
+// SecurityHubConfig creates Security Hub insights scoped to the stack's
+// resources and routes new findings about them to a notification topic.
+//
+// Findings are matched on the agentkit:stack resource tag, so only
+// resources the stack tags are covered.
+type SecurityHubConfig struct {
+	// EnableAccount enables Security Hub for the account and region.
+	// Security Hub can only be enabled once, so leave this unset if it is
+	// already enabled.
+	// Default: false
+	EnableAccount bool `json:"enableAccount,omitempty" yaml:"enableAccount,omitempty"`
+
+	// MinSeverity is the lowest finding severity label routed to the topic.
+	// Supported: INFORMATIONAL, LOW, MEDIUM, HIGH, CRITICAL
+	// Default: HIGH
+	MinSeverity string `json:"minSeverity,omitempty" yaml:"minSeverity,omitempty"`
+
+	// TopicARN is an existing SNS topic for findings. If empty, one is created.
+	TopicARN string `json:"topicARN,omitempty" yaml:"topicARN,omitempty"`
+
+	// Recipients are email addresses subscribed to a created findings topic.
+	Recipients []string `json:"recipients,omitempty" yaml:"recipients,omitempty"`
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *SecurityHubConfig) ApplyDefaults() {
+	if c.MinSeverity == "" {
+		c.MinSeverity = "HIGH"
+	}
+}
+
+// Validate validates the SecurityHubConfig.
+func (c *SecurityHubConfig) Validate() error {
+	if c.severityLabels() == nil {
+		return fmt.Errorf("securityHub.minSeverity: unsupported severity '%s'", c.MinSeverity)
+	}
+	if c.TopicARN != "" && !strings.HasPrefix(c.TopicARN, "arn:") {
+		return fmt.Errorf("securityHub.topicARN: '%s' is not an ARN", c.TopicARN)
+	}
+	if c.TopicARN != "" && len(c.Recipients) > 0 {
+		return fmt.Errorf("securityHub.recipients can only be set when the topic is created")
+	}
+	for _, email := range c.Recipients {
+		if !strings.Contains(email, "@") {
+			return fmt.Errorf("securityHub.recipients: '%s' is not an email address", email)
+		}
+	}
+	return nil
+}
+
+// severityLabels returns MinSeverity and every label above it, or nil if
+// MinSeverity is not a known label.
+func (c *SecurityHubConfig) severityLabels() []string {
+	for i, label := range securityHubSeverityLabels {
+		if label == c.MinSeverity {
+			return securityHubSeverityLabels[i:]
+		}
+	}
+	return nil
+}
+
+// securityHubFindingPattern matches new, active findings with one of the
+// given severity labels for resources tagged with the stack name.
+func securityHubFindingPattern(stackName string, labels []string) (string, error) {
+	pattern, err := json.Marshal(map[string]interface{}{
+		"source":      []string{"aws.securityhub"},
+		"detail-type": []string{"Security Hub Findings - Imported"},
+		"detail": map[string]interface{}{
+			"findings": map[string]interface{}{
+				"Severity":    map[string]interface{}{"Label": labels},
+				"Workflow":    map[string]interface{}{"Status": []string{"NEW"}},
+				"RecordState": []string{"ACTIVE"},
+				"Resources": map[string]interface{}{
+					"Tags": map[string]interface{}{stackTagKey: []string{stackName}},
+				},
+			},
+		},
+	})
+	return string(pattern), err
+}
+
+// securityHubInsight describes a custom insight over the stack's findings.
+type securityHubInsight struct {
+	name    string
+	groupBy string
+	filters securityhub.InsightFiltersArgs
+}
+
+// createSecurityHub creates the stack-scoped insights and routes the
+// stack's findings.
+func (s *AgentCoreStack) createSecurityHub(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	stackName := s.Config.StackName
+	cfg := s.Options.SecurityHub
+
+	opts := []pulumi.ResourceOption{s.child()}
+	if cfg.EnableAccount {
+		account, err := securityhub.NewAccount(ctx, s.ResourceName("securityhub-account"), &securityhub.AccountArgs{
+			EnableDefaultStandards: pulumi.Bool(true),
+		}, s.child())
+		if err != nil {
+			return err
+		}
+		opts = append(opts, pulumi.DependsOn([]pulumi.Resource{account}))
+	}
+
+	stackTag := securityhub.InsightFiltersResourceTagArray{
+		&securityhub.InsightFiltersResourceTagArgs{
+			Comparison: pulumi.String("EQUALS"),
+			Key:        pulumi.String(stackTagKey),
+			Value:      pulumi.String(stackName),
+		},
+	}
+	active := securityhub.InsightFiltersRecordStateArray{
+		&securityhub.InsightFiltersRecordStateArgs{
+			Comparison: pulumi.String("EQUALS"),
+			Value:      pulumi.String("ACTIVE"),
+		},
+	}
+	insights := []securityHubInsight{
+		{
+			name:    "by-severity",
+			groupBy: "SeverityLabel",
+			filters: securityhub.InsightFiltersArgs{ResourceTags: stackTag, RecordStates: active},
+		},
+		{
+			name:    "by-resource-type",
+			groupBy: "ResourceType",
+			filters: securityhub.InsightFiltersArgs{ResourceTags: stackTag, RecordStates: active},
+		},
+		{
+			name:    "failed-controls",
+			groupBy: "GeneratorId",
+			filters: securityhub.InsightFiltersArgs{
+				ResourceTags: stackTag,
+				RecordStates: active,
+				ComplianceStatuses: securityhub.InsightFiltersComplianceStatusArray{
+					&securityhub.InsightFiltersComplianceStatusArgs{
+						Comparison: pulumi.String("EQUALS"),
+						Value:      pulumi.String("FAILED"),
+					},
+				},
+			},
+		},
+	}
+	for _, insight := range insights {
+		filters := insight.filters
+		_, err := securityhub.NewInsight(ctx, s.ResourceName(fmt.Sprintf("securityhub-insight-%s", insight.name)), &securityhub.InsightArgs{
+			Name:             pulumi.Sprintf("%s-%s", stackName, insight.name),
+			GroupByAttribute: pulumi.String(insight.groupBy),
+			Filters:          &filters,
+		}, opts...)
+		if err != nil {
+			return err
+		}
+	}
+
+	topic := pulumi.String(cfg.TopicARN).ToStringOutput()
+	if cfg.TopicARN == "" {
+		created, err := s.newEventTopic(ctx, "securityhub-findings", fmt.Sprintf("%s-securityhub-findings", stackName), tags)
+		if err != nil {
+			return err
+		}
+		if err := s.subscribeEmails(ctx, "securityhub-recipient", created.Arn, cfg.Recipients); err != nil {
+			return err
+		}
+		topic = created.Arn
+	}
+	s.SecurityHubTopic = topic
+
+	pattern, err := securityHubFindingPattern(stackName, cfg.severityLabels())
+	if err != nil {
+		return err
+	}
+	rule, err := cloudwatch.NewEventRule(ctx, s.ResourceName("securityhub-findings-rule"), &cloudwatch.EventRuleArgs{
+		Name:         pulumi.Sprintf("%s-securityhub-findings", stackName),
+		Description:  pulumi.Sprintf("Security Hub findings for %s resources", stackName),
+		EventPattern: pulumi.String(pattern),
+		Tags:         mergeTags(tags, pulumi.Sprintf("%s-securityhub-findings", stackName)),
+	}, s.child())
+	if err != nil {
+		return err
+	}
+	_, err = cloudwatch.NewEventTarget(ctx, s.ResourceName("securityhub-findings-target"), &cloudwatch.EventTargetArgs{
+		Rule: rule.Name,
+		Arn:  topic,
+	}, s.child())
+	return err
+}
@@ -3,11 +3,33 @@ package agentcore
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/apigatewayv2"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/appconfig"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/appsync"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/cloudcontrol"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/cloudfront"
 	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/cloudwatch"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/dynamodb"
 	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/ec2"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/elasticache"
 	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/iam"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/kendra"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/kinesis"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/kms"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/neptune"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/rds"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/s3"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/scheduler"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/secretsmanager"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/servicecatalog"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/sfn"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/sns"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/ssm"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/wafv2"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
 
@@ -22,18 +44,32 @@ func mergeTags(base pulumi.StringMap, name pulumi.StringInput) pulumi.StringMap
 }
 
 // AgentCoreStack contains all the Pulumi resources for an AgentCore deployment.
+// It is registered as a component resource that parents every resource it creates.
 type AgentCoreStack struct {
+	pulumi.ResourceState
+
 	// Config is the stack configuration.
 	Config iac.StackConfig
 
+	// Options are the Pulumi-specific stack options.
+	Options Options
+
 	// VPC is the VPC resource (nil if using existing VPC).
 	VPC *ec2.Vpc
 
-	// PublicSubnet is the public subnet.
-	PublicSubnet *ec2.Subnet
+	// PublicSubnets are the public subnets, one per availability zone.
+	PublicSubnets []*ec2.Subnet
+
+	// PrivateSubnets are the private subnets, one per availability zone.
+	PrivateSubnets []*ec2.Subnet
 
-	// PrivateSubnet is the private subnet.
-	PrivateSubnet *ec2.Subnet
+	// PrivateRouteTable is the route table of the private subnets
+	// (nil if using existing VPC).
+	PrivateRouteTable *ec2.RouteTable
+
+	// VPCEndpoints maps service names to VPC endpoints
+	// (only with VPCConfig.EnableVPCEndpoints).
+	VPCEndpoints map[string]*ec2.VpcEndpoint
 
 	// InternetGateway is the internet gateway.
 	InternetGateway *ec2.InternetGateway
@@ -44,27 +80,339 @@ type AgentCoreStack struct {
 	// SecurityGroup is the security group for agents.
 	SecurityGroup *ec2.SecurityGroup
 
-	// ExecutionRole is the IAM execution role.
-	ExecutionRole *iam.Role
+	// AgentRoles maps agent names to their IAM execution roles.
+	AgentRoles map[string]*iam.Role
+
+	// Secrets are the secrets created from SecretsConfig.SecretValues,
+	// keyed by secret name.
+	Secrets map[string]*secretsmanager.Secret
+
+	// KMSKey is the stack encryption key (nil unless Options.KMS creates it).
+	KMSKey *kms.Key
+
+	// KMSKeyArn is the ARN of the stack encryption key (only with Options.KMS).
+	KMSKeyArn pulumi.StringOutput
 
 	// LogGroup is the CloudWatch log group.
 	LogGroup *cloudwatch.LogGroup
 
+	// AgentLogGroups maps agent names to their log group names, for
+	// agents that have their own log group.
+	AgentLogGroups map[string]pulumi.StringOutput
+
+	// LogArchiveBucket holds archived CloudWatch logs (only with Options.LogArchive).
+	LogArchiveBucket *s3.BucketV2
+
+	// HealthAlarm is the composite stack health alarm (only with Options.Health).
+	HealthAlarm *cloudwatch.CompositeAlarm
+
+	// HealthURL is the health endpoint URL (only with HealthConfig.Endpoint).
+	HealthURL pulumi.StringOutput
+
+	// GuardrailArn is the guardrail agents apply (only with Options.Guardrail).
+	GuardrailArn pulumi.StringOutput
+
+	// GuardrailVersion is the guardrail version agents apply (only with
+	// Options.Guardrail).
+	GuardrailVersion pulumi.StringOutput
+
+	// PromptGuardrailArn is the guardrail evaluating sampled prompts and
+	// responses (only with Options.PromptMonitoring).
+	PromptGuardrailArn pulumi.StringOutput
+
+	// PromptMonitoringTopic is the SNS topic suspected prompt attack alarms
+	// notify (only with Options.PromptMonitoring).
+	PromptMonitoringTopic pulumi.StringOutput
+
+	// Application is the AppRegistry application (only with Options.Registry).
+	Application *servicecatalog.AppregistryApplication
+
+	// Cache is the ElastiCache Serverless response cache (nil if caching is disabled).
+	Cache *elasticache.ServerlessCache
+
+	// StateTable is the DynamoDB table for agent state (nil if not enabled).
+	StateTable *dynamodb.Table
+
+	// ArtifactBucket holds agent inputs and outputs (nil if not enabled).
+	ArtifactBucket *s3.BucketV2
+
+	// CodeInterpreter is the AgentCore Code Interpreter tool (nil if not enabled).
+	CodeInterpreter *cloudcontrol.Resource
+
+	// Browser is the AgentCore Browser tool (nil if not enabled).
+	Browser *cloudcontrol.Resource
+
+	// AgentSchemas maps agent names to the SSM parameters holding their
+	// OpenAPI documents (only agents that declare schemas).
+	AgentSchemas map[string]pulumi.StringOutput
+
+	// FailureDestinations maps agent names to the ARNs of their failure
+	// queues or topics (only agents with OnFailure set).
+	FailureDestinations map[string]pulumi.StringOutput
+
+	// TenantQueues maps tenant names to their work queue URLs.
+	TenantQueues map[string]pulumi.StringOutput
+
+	// WorkQueues maps agent names, or the stack name for a stack queue, to
+	// their work queue URLs.
+	WorkQueues map[string]pulumi.StringOutput
+
+	// GraphStore is the Neptune Serverless cluster (nil if not enabled).
+	GraphStore *neptune.Cluster
+
+	// VectorStore is the Aurora pgvector cluster (nil if not enabled).
+	VectorStore *rds.Cluster
+
+	// VectorStoreSecretArn is the ARN of the vector store's master
+	// credentials (only with Options.VectorStore).
+	VectorStoreSecretArn pulumi.StringOutput
+
+	// KendraIndex is the provisioned Kendra index (nil if not enabled or
+	// an existing index is referenced).
+	KendraIndex *kendra.Index
+
+	// SageMakerEndpoints maps configured endpoint names to deployed endpoint names.
+	SageMakerEndpoints map[string]pulumi.StringOutput
+
+	// ModelHubTrustPolicy and ModelHubPermissionsPolicy are the policies
+	// the model hub role needs (only with Options.ModelHub).
+	ModelHubTrustPolicy       pulumi.StringOutput
+	ModelHubPermissionsPolicy pulumi.StringOutput
+
+	// InferenceProfiles maps agent names to their application inference
+	// profile ARNs (only with Options.InferenceProfiles).
+	InferenceProfiles map[string]pulumi.StringOutput
+
+	// FineTuningBucket is the S3 bucket for fine-tuning data
+	// (nil if every job supplies its own training data).
+	FineTuningBucket *s3.BucketV2
+
+	// CustomModels maps fine-tuning job names to custom model ARNs.
+	// The ARN is empty until the customization job completes.
+	CustomModels map[string]pulumi.StringOutput
+
+	// BatchBucket is the S3 bucket for batch job input and output
+	// (nil if no batch job needs a stack bucket).
+	BatchBucket *s3.BucketV2
+
+	// BatchNotifications is the SNS topic for batch job completion
+	// (nil if no batch job needs a stack topic).
+	BatchNotifications *sns.Topic
+
+	// AuditBucket is the Object Lock bucket holding audit events (nil if not enabled).
+	AuditBucket *s3.BucketV2
+
+	// AuditStream is the Firehose stream agents write audit events to (nil if not enabled).
+	AuditStream *kinesis.FirehoseDeliveryStream
+
+	// SecretsAuditTopic receives unused secret reports (only with Options.SecretsAudit).
+	SecretsAuditTopic *sns.Topic
+
+	// KeyRotationTopic receives rotation reminders and overdue alarms
+	// (only with Options.KeyRotation).
+	KeyRotationTopic *sns.Topic
+
+	// GuardDutyTopic is the SNS topic GuardDuty findings are routed to
+	// (only with Options.GuardDuty).
+	GuardDutyTopic pulumi.StringOutput
+
+	// SecurityHubTopic is the SNS topic Security Hub findings are routed to
+	// (only with Options.SecurityHub).
+	SecurityHubTopic pulumi.StringOutput
+
+	// AlarmTopic is the SNS topic runtime alarms notify
+	// (only with Options.Alarms).
+	AlarmTopic pulumi.StringOutput
+
+	// Dashboard is the agent dashboard (nil if not enabled).
+	Dashboard *cloudwatch.Dashboard
+
+	// DashboardURL is the console URL of the agent dashboard.
+	DashboardURL pulumi.StringOutput
+
+	// ReportBucket stores generated reports (nil if reports are not enabled).
+	ReportBucket *s3.BucketV2
+
+	// ReportDelivery is the SNS topic for report delivery (nil if reports are not enabled).
+	ReportDelivery *sns.Topic
+
+	// ApprovalWorkflow is the human approval state machine (nil if not enabled).
+	ApprovalWorkflow *sfn.StateMachine
+
+	// ApprovalAPI receives approval decisions (nil if not enabled).
+	ApprovalAPI *apigatewayv2.Api
+
+	// Workflows maps workflow names to their state machines.
+	Workflows map[string]*sfn.StateMachine
+
+	// WorkflowRoles maps workflow names to the ARN of the role their state
+	// machine assumes.
+	WorkflowRoles map[string]pulumi.StringOutput
+
+	// AppSyncAPI is the GraphQL API for invoking agents (nil if not enabled).
+	AppSyncAPI *appsync.GraphQLApi
+
+	// Frontend is the CloudFront distribution serving the chat UI (nil if not enabled).
+	Frontend *cloudfront.Distribution
+
+	// WebACL is the regional web ACL protecting the AppSync API (nil if
+	// not enabled).
+	WebACL *wafv2.WebAcl
+
+	// CloudFrontWebACL is the web ACL protecting the frontend (nil if not
+	// enabled).
+	CloudFrontWebACL *wafv2.WebAcl
+
+	// AgentRuntimes maps agent names to their AgentCore runtimes. Agents
+	// with replicas have one entry per replica, such as research-0.
+	AgentRuntimes map[string]*AgentRuntime
+
+	// AgentFunctions maps the names of agents deployed with
+	// AgentOptions.Lambda to their Lambda functions.
+	AgentFunctions map[string]*AgentFunction
+
+	// AgentSchedules maps {agent}-{schedule} names to the agents'
+	// EventBridge Scheduler schedules.
+	AgentSchedules map[string]*scheduler.Schedule
+
+	// AgentRouters maps the names of agents with replicas, weighted
+	// blue/green deployments or schemas to the function URL that validates
+	// requests and load balances across their runtimes or versions.
+	AgentRouters map[string]pulumi.StringOutput
+
+	// AgentURLs maps agent names to their invocation URL under
+	// Options.AgentSubdomains.
+	AgentURLs map[string]pulumi.StringOutput
+
+	// GatewayURLs maps gateway names to their MCP URL.
+	GatewayURLs map[string]pulumi.StringOutput
+
+	// FeatureFlagApplication is the AppConfig application holding the
+	// feature flags (only with Options.FeatureFlags).
+	FeatureFlagApplication *appconfig.Application
+
+	// TopologyLocation is the SSM parameter name or AppConfig
+	// configuration profile ARN of the topology (only with Options.Topology).
+	TopologyLocation pulumi.StringOutput
+
+	// topologyApp, topologyEnv and topologyProfile hold the topology with
+	// the appconfig target.
+	topologyApp     *appconfig.Application
+	topologyEnv     *appconfig.Environment
+	topologyProfile *appconfig.ConfigurationProfile
+
+	// secretEncryptionContext are the KMS conditions of the stack's secrets
+	// (nil unless SecretPolicyConfig is set).
+	secretEncryptionContext map[string]map[string]interface{}
+
+	// regionRouting is the routing of the agent subdomain records with
+	// AgentSubdomainsConfig.RegionRouting.
+	regionRouting *regionRouting
+
+	// QuotaUsage maps quota names to their use, with Options.QuotaReport.
+	QuotaUsage map[string]QuotaUsage
+
+	// quotaCounter counts quota-limited resources for Options.QuotaReport.
+	quotaCounter *resourceCounter
+
+	// canaryParameters are the traffic parameters of the agents deployed
+	// with the canary strategy, keyed by agent name.
+	canaryParameters map[string]*ssm.Parameter
+
+	// AgentEnvironment contains environment variables injected by stack
+	// components, keyed by agent name. These are merged over
+	// AgentConfig.Environment when the agent runtime is configured.
+	AgentEnvironment map[string]pulumi.StringMap
+
 	// Outputs contains stack output values.
 	Outputs map[string]pulumi.StringOutput
+
+	// Provider is the AWS provider of the stack's resources: Options.Provider,
+	// or the provider created for Options.Region or Options.AssumeRole. Nil
+	// with the program's default provider. A created provider is a child of
+	// the stack, so resources and functions parented to the stack do not
+	// inherit it; pass it with pulumi.Provider.
+	Provider *aws.Provider
 }
 
+// componentType is the Pulumi type token of AgentCoreStack.
+const componentType = "agentkit:agentcore:AgentCoreStack"
+
 // NewAgentCoreStack creates all AgentCore resources from a StackConfig.
-func NewAgentCoreStack(ctx *pulumi.Context, config iac.StackConfig) (*AgentCoreStack, error) {
+func NewAgentCoreStack(ctx *pulumi.Context, config iac.StackConfig, opts ...pulumi.ResourceOption) (*AgentCoreStack, error) {
+	return NewAgentCoreStackWithOptions(ctx, config, Options{}, opts...)
+}
+
+// NewAgentCoreStackWithOptions creates all AgentCore resources from a StackConfig
+// and Pulumi-specific options. The resource options apply to the stack
+// component, e.g. to set a provider for all of its resources.
+func NewAgentCoreStackWithOptions(ctx *pulumi.Context, config iac.StackConfig, options Options, opts ...pulumi.ResourceOption) (*AgentCoreStack, error) {
 	// Validate and apply defaults
 	config.ApplyDefaults()
 	if err := config.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid stack configuration: %w", err)
 	}
+	options.ApplyDefaults()
+	if err := options.Validate(config); err != nil {
+		return nil, fmt.Errorf("invalid stack options: %w", err)
+	}
 
 	stack := &AgentCoreStack{
-		Config:  config,
-		Outputs: make(map[string]pulumi.StringOutput),
+		Config:              config,
+		Options:             options,
+		AgentRoles:          make(map[string]*iam.Role),
+		AgentLogGroups:      make(map[string]pulumi.StringOutput),
+		AgentSchemas:        make(map[string]pulumi.StringOutput),
+		FailureDestinations: make(map[string]pulumi.StringOutput),
+		TenantQueues:        make(map[string]pulumi.StringOutput),
+		WorkQueues:          make(map[string]pulumi.StringOutput),
+		SageMakerEndpoints:  make(map[string]pulumi.StringOutput),
+		CustomModels:        make(map[string]pulumi.StringOutput),
+		InferenceProfiles:   make(map[string]pulumi.StringOutput),
+		VPCEndpoints:        make(map[string]*ec2.VpcEndpoint),
+		Secrets:             make(map[string]*secretsmanager.Secret),
+		AgentRuntimes:       make(map[string]*AgentRuntime),
+		AgentFunctions:      make(map[string]*AgentFunction),
+		AgentSchedules:      make(map[string]*scheduler.Schedule),
+		Workflows:           make(map[string]*sfn.StateMachine),
+		WorkflowRoles:       make(map[string]pulumi.StringOutput),
+		AgentRouters:        make(map[string]pulumi.StringOutput),
+		AgentURLs:           make(map[string]pulumi.StringOutput),
+		GatewayURLs:         make(map[string]pulumi.StringOutput),
+		QuotaUsage:          make(map[string]QuotaUsage),
+		AgentEnvironment:    make(map[string]pulumi.StringMap),
+		Outputs:             make(map[string]pulumi.StringOutput),
+	}
+	if options.QuotaReport != nil {
+		stack.quotaCounter = &resourceCounter{counts: make(map[string]int)}
+		opts = append(opts, pulumi.Transformations([]pulumi.ResourceTransformation{stack.quotaCounter.transformation}))
+	}
+	if options.Provider != nil {
+		opts = append(opts, pulumi.Provider(options.Provider))
+	}
+	if err := ctx.RegisterComponentResource(componentType, config.StackName, stack, opts...); err != nil {
+		return nil, fmt.Errorf("failed to register stack component: %w", err)
+	}
+
+	// The stack's own provider is a child of the stack, so it is created
+	// after the component and passed to each resource by child and invoke
+	stack.Provider = options.Provider
+	if options.Region != "" || options.AssumeRole != nil {
+		provider, err := aws.NewProvider(ctx, stack.ResourceName("aws-provider"), stack.providerArgs(ctx, options.Region), stack.child())
+		if err != nil {
+			return nil, fmt.Errorf("failed to create provider: %w", err)
+		}
+		stack.Provider = provider
+	}
+
+	// Check the region supports the configured services
+	if !options.SkipPreflight {
+		if err := stack.preflightRegion(ctx); err != nil {
+			return nil, fmt.Errorf("region preflight failed: %w", err)
+		}
+		if err := stack.checkImageSizes(ctx); err != nil {
+			return nil, fmt.Errorf("image preflight failed: %w", err)
+		}
 	}
 
 	// Create tags map
@@ -73,6 +421,18 @@ func NewAgentCoreStack(ctx *pulumi.Context, config iac.StackConfig) (*AgentCoreS
 		tags[k] = pulumi.String(v)
 	}
 	tags["ManagedBy"] = pulumi.String("agentkit-pulumi")
+	tags[stackTagKey] = pulumi.String(config.StackName)
+
+	// Register the stack in AppRegistry; resources created afterwards are
+	// associated with the application through its tag
+	if options.Registry != nil {
+		if err := stack.createRegistry(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to register stack: %w", err)
+		}
+		for k, v := range stack.registryTags() {
+			tags[k] = v
+		}
+	}
 
 	// Create VPC resources
 	if config.VPC.CreateVPC {
@@ -86,9 +446,35 @@ func NewAgentCoreStack(ctx *pulumi.Context, config iac.StackConfig) (*AgentCoreS
 		return nil, fmt.Errorf("failed to create security group: %w", err)
 	}
 
+	// Create VPC endpoints
+	if hasVPC(config) && config.VPC.EnableVPCEndpoints {
+		if err := stack.createVPCEndpoints(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create VPC endpoints: %w", err)
+		}
+	}
+
+	// Create the encryption key before the resources it encrypts
+	if options.KMS != nil {
+		if err := stack.createKMSKey(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create KMS key: %w", err)
+		}
+	}
+
+	// Create secrets before the execution policy that grants them
+	if createsSecrets(config) {
+		if err := stack.createSecrets(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create secrets: %w", err)
+		}
+	}
+
 	// Create IAM role
-	if err := stack.createIAMRole(ctx, tags); err != nil {
-		return nil, fmt.Errorf("failed to create IAM role: %w", err)
+	if err := stack.createIAMRoles(ctx, tags); err != nil {
+		return nil, fmt.Errorf("failed to create IAM roles: %w", err)
+	}
+	if options.KMS != nil {
+		if err := stack.attachRolePolicy(ctx, "kms-key-policy", stack.kmsKeyStatement()); err != nil {
+			return nil, fmt.Errorf("failed to grant KMS key: %w", err)
+		}
 	}
 
 	// Create CloudWatch log group
@@ -98,79 +484,491 @@ func NewAgentCoreStack(ctx *pulumi.Context, config iac.StackConfig) (*AgentCoreS
 		}
 	}
 
+	// Create per-agent log groups
+	if stack.hasAgentLogGroups() {
+		if err := stack.createAgentLogGroups(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create agent log groups: %w", err)
+		}
+	}
+
+	// Inject the log schema and create Insights queries
+	if options.Logging != nil {
+		if err := stack.applyLogging(ctx); err != nil {
+			return nil, fmt.Errorf("failed to apply logging configuration: %w", err)
+		}
+	}
+
+	// Archive logs to S3
+	if options.LogArchive != nil {
+		if err := stack.createLogArchive(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create log archive: %w", err)
+		}
+	}
+
+	// Create health alarms and endpoint
+	if options.Health != nil {
+		if err := stack.createHealth(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create health checks: %w", err)
+		}
+	}
+
+	// Create or reference the guardrail agents apply
+	if options.Guardrail != nil {
+		if err := stack.createGuardrail(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create guardrail: %w", err)
+		}
+	}
+
+	// Monitor prompts for injection and jailbreak attempts
+	if options.PromptMonitoring != nil {
+		if err := stack.createPromptMonitoring(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create prompt monitoring: %w", err)
+		}
+	}
+
+	// Create response cache
+	if options.Cache != nil {
+		if err := stack.createCache(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create cache: %w", err)
+		}
+	}
+
+	// Create agent state table
+	if options.StateTable != nil {
+		if err := stack.createStateTable(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create state table: %w", err)
+		}
+	}
+
+	// Create agent artifact bucket
+	if options.ArtifactBucket != nil {
+		if err := stack.createArtifactBucket(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create artifact bucket: %w", err)
+		}
+	}
+
+	// Publish agent request/response schemas
+	if err := stack.publishAgentSchemas(ctx, tags); err != nil {
+		return nil, fmt.Errorf("failed to publish agent schemas: %w", err)
+	}
+
+	// Apply the shared resilience policy
+	if options.Resilience != nil {
+		if err := stack.applyResiliencePolicy(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to apply resilience policy: %w", err)
+		}
+	}
+
+	// Apply the external tool call limits
+	if options.ToolCalls != nil {
+		if err := stack.applyToolCallPolicy(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to apply tool call policy: %w", err)
+		}
+	}
+
+	// Create immutable audit log
+	if options.Audit != nil {
+		if err := stack.createAuditLog(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create audit log: %w", err)
+		}
+	}
+
+	// Schedule unused secret detection
+	if options.SecretsAudit != nil {
+		if err := stack.createSecretsAudit(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create secrets audit: %w", err)
+		}
+	}
+
+	// Track secret ages for rotation
+	if options.KeyRotation != nil {
+		if err := stack.createKeyRotation(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create key rotation checks: %w", err)
+		}
+	}
+
+	// Enable threat detection
+	if options.GuardDuty != nil {
+		if err := stack.createGuardDuty(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to enable guardduty: %w", err)
+		}
+	}
+
+	// Create Security Hub insights and finding routing
+	if options.SecurityHub != nil {
+		if err := stack.createSecurityHub(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create security hub integration: %w", err)
+		}
+	}
+
+	// Create failure destinations for asynchronous invocations
+	if err := stack.createFailureDestinations(ctx, tags); err != nil {
+		return nil, fmt.Errorf("failed to create failure destinations: %w", err)
+	}
+
+	// Create per-tenant work queues
+	if options.Tenancy != nil {
+		if err := stack.createTenantQueues(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create tenant queues: %w", err)
+		}
+		if options.Tenancy.Isolation {
+			if err := stack.createTenantIsolation(ctx, tags); err != nil {
+				return nil, fmt.Errorf("failed to create tenant isolation: %w", err)
+			}
+		}
+	}
+
+	// Create graph store
+	if options.GraphStore != nil {
+		if err := stack.createGraphStore(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create graph store: %w", err)
+		}
+	}
+
+	// Create vector store
+	if options.VectorStore != nil {
+		if err := stack.createVectorStore(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create vector store: %w", err)
+		}
+	}
+
+	// Create or reference the retrieval index
+	if options.Retrieval != nil {
+		if err := stack.createRetrieval(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create retrieval index: %w", err)
+		}
+	}
+
+	// Create or reference SageMaker endpoints for custom models
+	if len(options.SageMakerEndpoints) > 0 {
+		if err := stack.createSageMakerEndpoints(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create sagemaker endpoints: %w", err)
+		}
+	}
+
+	// Grant access to models in a central model hub account
+	if options.ModelHub != nil {
+		if err := stack.createModelHubAccess(ctx); err != nil {
+			return nil, fmt.Errorf("failed to create model hub access: %w", err)
+		}
+	}
+
+	// Create per-agent application inference profiles
+	if options.InferenceProfiles != nil {
+		if err := stack.createInferenceProfiles(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create inference profiles: %w", err)
+		}
+	}
+
+	// Create scheduled batch inference jobs
+	if len(options.BatchJobs) > 0 {
+		if err := stack.createBatchJobs(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create batch jobs: %w", err)
+		}
+	}
+
+	// Create model customization jobs
+	if len(options.FineTuning) > 0 {
+		if err := stack.createFineTuningJobs(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create fine-tuning jobs: %w", err)
+		}
+	}
+
+	// Create scheduled reports
+	if options.Reports != nil {
+		if err := stack.createReports(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create reports: %w", err)
+		}
+	}
+
+	// Create human approval workflow
+	if options.Approval != nil {
+		if err := stack.createApproval(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create approval workflow: %w", err)
+		}
+	}
+
+	// Create built-in tools
+	if options.CodeInterpreter != nil {
+		if err := stack.createCodeInterpreter(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create code interpreter: %w", err)
+		}
+	}
+	if options.Browser != nil {
+		if err := stack.createBrowserTool(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create browser tool: %w", err)
+		}
+	}
+
+	// Create workload identities and OAuth2 credential providers
+	if options.Identity != nil {
+		if err := stack.createIdentity(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create identity: %w", err)
+		}
+	}
+
+	// Create MCP tool gateways
+	if len(options.Gateways) > 0 {
+		if err := stack.createGateways(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create gateways: %w", err)
+		}
+	}
+
+	// Create GraphQL API
+	if options.AppSync != nil {
+		if err := stack.createAppSyncAPI(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create appsync api: %w", err)
+		}
+	}
+
+	// Grant agents retrieval from their knowledge bases
+	if err := stack.grantKnowledgeBases(ctx); err != nil {
+		return nil, fmt.Errorf("failed to grant knowledge base access: %w", err)
+	}
+
+	// Create web ACLs for the public entry points
+	if options.WAF != nil {
+		if err := stack.createWAF(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create waf: %w", err)
+		}
+	}
+
+	// Create chat UI hosting
+	if options.Frontend != nil {
+		if err := stack.createFrontend(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create frontend: %w", err)
+		}
+	}
+
+	// Create feature flags
+	if options.FeatureFlags != nil {
+		if err := stack.createFeatureFlags(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create feature flags: %w", err)
+		}
+	}
+
+	// Grant agents access to the stack topology
+	if options.Topology != nil {
+		if err := stack.prepareTopology(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to prepare topology: %w", err)
+		}
+	}
+
+	// Deploy the agents once every component has injected its environment
+	if err := stack.createAgentRuntimes(ctx, tags); err != nil {
+		return nil, fmt.Errorf("failed to create agent runtimes: %w", err)
+	}
+	if err := stack.createAgentSchedules(ctx, tags); err != nil {
+		return nil, fmt.Errorf("failed to create agent schedules: %w", err)
+	}
+	if options.Reports != nil {
+		if err := stack.createReportSchedule(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create report schedule: %w", err)
+		}
+	}
+	if options.AppSync != nil {
+		if err := stack.createAppSyncResolvers(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create appsync resolvers: %w", err)
+		}
+	}
+	if options.Registry != nil {
+		if err := stack.createRegistryAgents(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to register agents: %w", err)
+		}
+	}
+	if len(options.Workflows) > 0 {
+		if err := stack.createWorkflows(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create workflows: %w", err)
+		}
+	}
+	if options.WorkQueue != nil {
+		if err := stack.createWorkQueues(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create work queues: %w", err)
+		}
+	}
+	if options.AgentSubdomains != nil {
+		if err := stack.createAgentSubdomains(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create agent subdomains: %w", err)
+		}
+	}
+	if options.Alarms != nil {
+		if err := stack.createAlarms(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create alarms: %w", err)
+		}
+	}
+	if len(stack.canaryAgents()) > 0 {
+		if err := stack.createCanaries(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create canaries: %w", err)
+		}
+	}
+	if len(stack.retentionAgents()) > 0 {
+		if err := stack.createVersionRetention(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to create version retention: %w", err)
+		}
+	}
+	if options.Dashboard != nil {
+		if err := stack.createDashboard(ctx); err != nil {
+			return nil, fmt.Errorf("failed to create dashboard: %w", err)
+		}
+	}
+
+	// Publish the stack topology
+	if options.Topology != nil {
+		if err := stack.publishTopology(ctx, tags); err != nil {
+			return nil, fmt.Errorf("failed to publish topology: %w", err)
+		}
+	}
+
+	// Compare created resources with the account quotas
+	if options.QuotaReport != nil {
+		if err := stack.reportQuotaUsage(ctx); err != nil {
+			return nil, fmt.Errorf("failed to report quota usage: %w", err)
+		}
+	}
+
+	if err := stack.factory().ExtraResources(ctx, stack); err != nil {
+		return nil, fmt.Errorf("failed to create extra resources: %w", err)
+	}
+
 	// Export outputs
 	stack.exportOutputs(ctx)
 
+	outputs := pulumi.Map{}
+	for k, v := range stack.Outputs {
+		outputs[k] = v
+	}
+	if err := ctx.RegisterResourceOutputs(stack, outputs); err != nil {
+		return nil, fmt.Errorf("failed to register stack outputs: %w", err)
+	}
+
 	return stack, nil
 }
 
+// child returns the options for resources the stack creates, with the
+// stack's provider. Resources created before the stack was a component
+// keep their identity through parentless aliases.
+func (s *AgentCoreStack) child(aliases ...pulumi.Alias) pulumi.ResourceOption {
+	all := []pulumi.Alias{{NoParent: pulumi.Bool(true)}}
+	for _, alias := range aliases {
+		alias.NoParent = pulumi.Bool(true)
+		all = append(all, alias)
+	}
+	opts := []pulumi.ResourceOption{pulumi.Parent(s), pulumi.Aliases(all)}
+	if s.Provider != nil {
+		opts = append(opts, pulumi.Provider(s.Provider))
+	}
+	return pulumi.Composite(opts...)
+}
+
+// invoke returns the options for provider functions the stack calls, so
+// they run against the stack's provider.
+func (s *AgentCoreStack) invoke() pulumi.InvokeOption {
+	if s.Provider == nil {
+		return pulumi.Parent(s)
+	}
+	return pulumi.CompositeInvoke(pulumi.Parent(s), pulumi.Provider(s.Provider))
+}
+
+// ResourceName returns the Pulumi name of a resource of the stack, with
+// Options.NamePrefix. ResourceFactory implementations name the resources
+// they create with it.
+func (s *AgentCoreStack) ResourceName(name string) string {
+	if s.Options.NamePrefix == "" {
+		return name
+	}
+	return s.Options.NamePrefix + "-" + name
+}
+
 // createVPC creates VPC and networking resources.
 func (s *AgentCoreStack) createVPC(ctx *pulumi.Context, tags pulumi.StringMap) error {
 	var err error
 	stackName := s.Config.StackName
 
 	// Create VPC
-	s.VPC, err = ec2.NewVpc(ctx, "vpc", &ec2.VpcArgs{
+	s.VPC, err = s.factory().CreateVPC(ctx, s, &ec2.VpcArgs{
 		CidrBlock:          pulumi.String(s.Config.VPC.VPCCidr),
 		EnableDnsHostnames: pulumi.Bool(true),
 		EnableDnsSupport:   pulumi.Bool(true),
 		Tags:               mergeTags(tags, pulumi.Sprintf("%s-vpc", stackName)),
-	})
+	}, s.child())
 	if err != nil {
 		return err
 	}
 
 	// Create Internet Gateway
-	s.InternetGateway, err = ec2.NewInternetGateway(ctx, "igw", &ec2.InternetGatewayArgs{
+	s.InternetGateway, err = ec2.NewInternetGateway(ctx, s.ResourceName("igw"), &ec2.InternetGatewayArgs{
 		VpcId: s.VPC.ID(),
 		Tags:  mergeTags(tags, pulumi.Sprintf("%s-igw", stackName)),
-	})
+	}, s.child())
 	if err != nil {
 		return err
 	}
 
-	// Create public subnet
-	s.PublicSubnet, err = ec2.NewSubnet(ctx, "public-subnet", &ec2.SubnetArgs{
-		VpcId:               s.VPC.ID(),
-		CidrBlock:           pulumi.String("10.0.1.0/24"),
-		MapPublicIpOnLaunch: pulumi.Bool(true),
-		Tags:                mergeTags(tags, pulumi.Sprintf("%s-public", stackName)),
-	})
+	// Spread public/private subnet pairs across availability zones
+	zones, err := aws.GetAvailabilityZones(ctx, &aws.GetAvailabilityZonesArgs{
+		State: pulumi.StringRef("available"),
+	}, s.invoke())
 	if err != nil {
 		return err
 	}
+	zoneCount := min(vpcMaxAZs(s.Config.VPC), len(zones.Names))
 
-	// Create private subnet
-	s.PrivateSubnet, err = ec2.NewSubnet(ctx, "private-subnet", &ec2.SubnetArgs{
-		VpcId:     s.VPC.ID(),
-		CidrBlock: pulumi.String("10.0.10.0/24"),
-		Tags:      mergeTags(tags, pulumi.Sprintf("%s-private", stackName)),
-	})
-	if err != nil {
-		return err
+	for i := 0; i < zoneCount; i++ {
+		zone := zones.Names[i]
+
+		publicCidr, err := subnetCIDR(s.Config.VPC.VPCCidr, publicSubnetOffset+i)
+		if err != nil {
+			return err
+		}
+		public, err := ec2.NewSubnet(ctx, s.ResourceName(fmt.Sprintf("public-subnet-%d", i+1)), &ec2.SubnetArgs{
+			VpcId:               s.VPC.ID(),
+			CidrBlock:           pulumi.String(publicCidr),
+			AvailabilityZone:    pulumi.String(zone),
+			MapPublicIpOnLaunch: pulumi.Bool(true),
+			Tags:                mergeTags(tags, pulumi.Sprintf("%s-public-%s", stackName, zone)),
+		}, s.child(subnetAliases("public-subnet", i)...))
+		if err != nil {
+			return err
+		}
+		s.PublicSubnets = append(s.PublicSubnets, public)
+
+		privateCidr, err := subnetCIDR(s.Config.VPC.VPCCidr, privateSubnetOffset+i)
+		if err != nil {
+			return err
+		}
+		private, err := ec2.NewSubnet(ctx, s.ResourceName(fmt.Sprintf("private-subnet-%d", i+1)), &ec2.SubnetArgs{
+			VpcId:            s.VPC.ID(),
+			CidrBlock:        pulumi.String(privateCidr),
+			AvailabilityZone: pulumi.String(zone),
+			Tags:             mergeTags(tags, pulumi.Sprintf("%s-private-%s", stackName, zone)),
+		}, s.child(subnetAliases("private-subnet", i)...))
+		if err != nil {
+			return err
+		}
+		s.PrivateSubnets = append(s.PrivateSubnets, private)
 	}
 
 	// Create Elastic IP for NAT Gateway
-	eip, err := ec2.NewEip(ctx, "nat-eip", &ec2.EipArgs{
+	eip, err := ec2.NewEip(ctx, s.ResourceName("nat-eip"), &ec2.EipArgs{
 		Domain: pulumi.String("vpc"),
 		Tags:   mergeTags(tags, pulumi.Sprintf("%s-nat-eip", stackName)),
-	}, pulumi.DependsOn([]pulumi.Resource{s.InternetGateway}))
+	}, pulumi.DependsOn([]pulumi.Resource{s.InternetGateway}), s.child())
 	if err != nil {
 		return err
 	}
 
 	// Create NAT Gateway
-	s.NatGateway, err = ec2.NewNatGateway(ctx, "nat", &ec2.NatGatewayArgs{
+	s.NatGateway, err = ec2.NewNatGateway(ctx, s.ResourceName("nat"), &ec2.NatGatewayArgs{
 		AllocationId: eip.ID(),
-		SubnetId:     s.PublicSubnet.ID(),
+		SubnetId:     s.PublicSubnets[0].ID(),
 		Tags:         mergeTags(tags, pulumi.Sprintf("%s-nat", stackName)),
-	}, pulumi.DependsOn([]pulumi.Resource{s.InternetGateway}))
+	}, pulumi.DependsOn([]pulumi.Resource{s.InternetGateway}), s.child())
 	if err != nil {
 		return err
 	}
 
 	// Create public route table
-	publicRouteTable, err := ec2.NewRouteTable(ctx, "public-rt", &ec2.RouteTableArgs{
+	publicRouteTable, err := ec2.NewRouteTable(ctx, s.ResourceName("public-rt"), &ec2.RouteTableArgs{
 		VpcId: s.VPC.ID(),
 		Routes: ec2.RouteTableRouteArray{
 			&ec2.RouteTableRouteArgs{
@@ -179,22 +977,24 @@ func (s *AgentCoreStack) createVPC(ctx *pulumi.Context, tags pulumi.StringMap) e
 			},
 		},
 		Tags: mergeTags(tags, pulumi.Sprintf("%s-public-rt", stackName)),
-	})
+	}, s.child())
 	if err != nil {
 		return err
 	}
 
-	// Associate public subnet with public route table
-	_, err = ec2.NewRouteTableAssociation(ctx, "public-rta", &ec2.RouteTableAssociationArgs{
-		SubnetId:     s.PublicSubnet.ID(),
-		RouteTableId: publicRouteTable.ID(),
-	})
-	if err != nil {
-		return err
+	// Associate public subnets with public route table
+	for i, subnet := range s.PublicSubnets {
+		_, err = ec2.NewRouteTableAssociation(ctx, s.ResourceName(fmt.Sprintf("public-rta-%d", i+1)), &ec2.RouteTableAssociationArgs{
+			SubnetId:     subnet.ID(),
+			RouteTableId: publicRouteTable.ID(),
+		}, s.child(subnetAliases("public-rta", i)...))
+		if err != nil {
+			return err
+		}
 	}
 
 	// Create private route table
-	privateRouteTable, err := ec2.NewRouteTable(ctx, "private-rt", &ec2.RouteTableArgs{
+	s.PrivateRouteTable, err = ec2.NewRouteTable(ctx, s.ResourceName("private-rt"), &ec2.RouteTableArgs{
 		VpcId: s.VPC.ID(),
 		Routes: ec2.RouteTableRouteArray{
 			&ec2.RouteTableRouteArgs{
@@ -203,18 +1003,20 @@ func (s *AgentCoreStack) createVPC(ctx *pulumi.Context, tags pulumi.StringMap) e
 			},
 		},
 		Tags: mergeTags(tags, pulumi.Sprintf("%s-private-rt", stackName)),
-	})
+	}, s.child())
 	if err != nil {
 		return err
 	}
 
-	// Associate private subnet with private route table
-	_, err = ec2.NewRouteTableAssociation(ctx, "private-rta", &ec2.RouteTableAssociationArgs{
-		SubnetId:     s.PrivateSubnet.ID(),
-		RouteTableId: privateRouteTable.ID(),
-	})
-	if err != nil {
-		return err
+	// Associate private subnets with private route table
+	for i, subnet := range s.PrivateSubnets {
+		_, err = ec2.NewRouteTableAssociation(ctx, s.ResourceName(fmt.Sprintf("private-rta-%d", i+1)), &ec2.RouteTableAssociationArgs{
+			SubnetId:     subnet.ID(),
+			RouteTableId: s.PrivateRouteTable.ID(),
+		}, s.child(subnetAliases("private-rta", i)...))
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -225,17 +1027,10 @@ func (s *AgentCoreStack) createSecurityGroup(ctx *pulumi.Context, tags pulumi.St
 	var err error
 	stackName := s.Config.StackName
 
-	var vpcId pulumi.StringInput
-	if s.VPC != nil {
-		vpcId = s.VPC.ID()
-	} else if s.Config.VPC.VPCID != "" {
-		vpcId = pulumi.String(s.Config.VPC.VPCID)
-	}
-
-	s.SecurityGroup, err = ec2.NewSecurityGroup(ctx, "sg", &ec2.SecurityGroupArgs{
+	s.SecurityGroup, err = s.factory().CreateSecurityGroup(ctx, s, &ec2.SecurityGroupArgs{
 		Name:        pulumi.Sprintf("%s-sg", stackName),
 		Description: pulumi.Sprintf("Security group for %s AgentCore agents", stackName),
-		VpcId:       vpcId,
+		VpcId:       s.vpcID(),
 		Egress: ec2.SecurityGroupEgressArray{
 			&ec2.SecurityGroupEgressArgs{
 				Protocol:   pulumi.String("-1"),
@@ -245,161 +1040,315 @@ func (s *AgentCoreStack) createSecurityGroup(ctx *pulumi.Context, tags pulumi.St
 			},
 		},
 		Tags: mergeTags(tags, pulumi.Sprintf("%s-sg", stackName)),
-	})
-	if err != nil {
-		return err
+	}, s.child())
+	return err
+}
+
+// vpcID returns the ID of the created or existing VPC, or nil if neither is configured.
+func (s *AgentCoreStack) vpcID() pulumi.StringInput {
+	if s.VPC != nil {
+		return s.VPC.ID()
+	}
+	if s.Config.VPC != nil && s.Config.VPC.VPCID != "" {
+		return pulumi.String(s.Config.VPC.VPCID)
 	}
+	return nil
+}
 
-	// Add self-referencing ingress rule for agent-to-agent communication
-	_, err = ec2.NewSecurityGroupRule(ctx, "sg-self-ingress", &ec2.SecurityGroupRuleArgs{
-		Type:                  pulumi.String("ingress"),
-		SecurityGroupId:       s.SecurityGroup.ID(),
-		SourceSecurityGroupId: s.SecurityGroup.ID(),
-		Protocol:              pulumi.String("-1"),
-		FromPort:              pulumi.Int(0),
-		ToPort:                pulumi.Int(0),
-		Description:           pulumi.String("Allow communication between agents"),
-	})
-	if err != nil {
-		return err
+// privateSubnetIDs returns the subnets agents and their dependencies run in.
+func (s *AgentCoreStack) privateSubnetIDs() pulumi.StringArray {
+	if len(s.PrivateSubnets) > 0 {
+		ids := pulumi.StringArray{}
+		for _, subnet := range s.PrivateSubnets {
+			ids = append(ids, subnet.ID())
+		}
+		return ids
 	}
+	if s.Config.VPC == nil {
+		return pulumi.StringArray{}
+	}
+	return pulumi.ToStringArray(s.Config.VPC.SubnetIDs)
+}
 
-	return nil
+// injectEnv adds an environment variable for an agent to AgentEnvironment.
+func (s *AgentCoreStack) injectEnv(agentName, key string, value pulumi.StringInput) {
+	if s.AgentEnvironment[agentName] == nil {
+		s.AgentEnvironment[agentName] = pulumi.StringMap{}
+	}
+	s.AgentEnvironment[agentName][key] = value
 }
 
-// createIAMRole creates the IAM execution role for agents.
-func (s *AgentCoreStack) createIAMRole(ctx *pulumi.Context, tags pulumi.StringMap) error {
-	var err error
+// selectedAgents returns the agent names in the selection, or all agent names if it is empty.
+func (s *AgentCoreStack) selectedAgents(names []string) []string {
+	if len(names) > 0 {
+		return names
+	}
+	all := make([]string, len(s.Config.Agents))
+	for i, agent := range s.Config.Agents {
+		all[i] = agent.Name
+	}
+	return all
+}
+
+// metricNamespace returns the CloudWatch namespace for custom agent metrics.
+func (s *AgentCoreStack) metricNamespace() string {
+	return "AgentCore/" + s.Config.StackName
+}
+
+// toArray converts strings to a pulumi.Array, as used for alarm actions.
+func toArray(values []string) pulumi.Array {
+	result := pulumi.Array{}
+	for _, v := range values {
+		result = append(result, pulumi.String(v))
+	}
+	return result
+}
+
+// hasVPC reports whether the configuration creates or references a VPC.
+func hasVPC(config iac.StackConfig) bool {
+	return config.VPC != nil && (config.VPC.CreateVPC || config.VPC.VPCID != "")
+}
+
+// hasAgent reports whether the configuration contains an agent with the given name.
+func hasAgent(config iac.StackConfig, name string) bool {
+	for _, agent := range config.Agents {
+		if agent.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// createIAMRoles creates an IAM execution role for each agent, with the
+// execution policy they share and access to the agent's own secrets.
+func (s *AgentCoreStack) createIAMRoles(ctx *pulumi.Context, tags pulumi.StringMap) error {
 	stackName := s.Config.StackName
 
-	// Create assume role policy
-	assumeRolePolicy := `{
-		"Version": "2012-10-17",
-		"Statement": [
-			{
-				"Effect": "Allow",
-				"Principal": {
-					"Service": ["bedrock.amazonaws.com", "lambda.amazonaws.com"]
-				},
-				"Action": "sts:AssumeRole"
-			}
-		]
-	}`
-
-	s.ExecutionRole, err = iam.NewRole(ctx, "execution-role", &iam.RoleArgs{
-		Name:             pulumi.Sprintf("%s-execution-role", stackName),
-		Description:      pulumi.Sprintf("Execution role for %s AgentCore agents", stackName),
-		AssumeRolePolicy: pulumi.String(assumeRolePolicy),
-		Tags:             mergeTags(tags, pulumi.Sprintf("%s-execution-role", stackName)),
-	})
+	caller, err := aws.GetCallerIdentity(ctx, nil, s.invoke())
 	if err != nil {
 		return err
 	}
 
-	// Build IAM policy statements
-	policyStatements := s.buildIAMPolicyStatements()
+	// Create assume role policy. AgentCore assumes the roles to run the
+	// agent runtimes, limited to runtimes in this account.
+	runtimeTrust := serviceTrustStatement("bedrock-agentcore.amazonaws.com")
+	runtimeTrust.Condition = map[string]map[string]interface{}{
+		"StringEquals": {"aws:SourceAccount": caller.AccountId},
+	}
+	assumeRolePolicy, err := NewPolicyDocument(
+		serviceTrustStatement("bedrock.amazonaws.com", "lambda.amazonaws.com"),
+		runtimeTrust,
+	).JSON()
+	if err != nil {
+		return err
+	}
 
-	// Create and attach policy
-	policy, err := iam.NewPolicy(ctx, "execution-policy", &iam.PolicyArgs{
-		Name:        pulumi.Sprintf("%s-execution-policy", stackName),
-		Description: pulumi.Sprintf("Execution policy for %s AgentCore agents", stackName),
-		Policy:      pulumi.String(policyStatements),
-	})
+	if s.Options.SecretPolicy != nil {
+		if err := s.resolveSecretEncryptionContext(ctx); err != nil {
+			return err
+		}
+	}
+
+	// Build IAM policy
+	policyDocument, err := NewPolicyDocument(s.executionPolicyStatements()...).JSON()
 	if err != nil {
 		return err
 	}
 
-	_, err = iam.NewRolePolicyAttachment(ctx, "execution-policy-attachment", &iam.RolePolicyAttachmentArgs{
-		Role:      s.ExecutionRole.Name,
-		PolicyArn: policy.Arn,
-	})
+	// Create the policy the agents' roles share
+	policy, err := iam.NewPolicy(ctx, s.ResourceName("execution-policy"), &iam.PolicyArgs{
+		Name:        s.iamName(stackName + "-execution-policy"),
+		Path:        s.iamPath(),
+		Description: pulumi.Sprintf("Execution policy for %s AgentCore agents", stackName),
+		Policy:      pulumi.String(policyDocument),
+	}, s.child())
 	if err != nil {
 		return err
 	}
 
+	for _, agent := range s.Config.Agents {
+		roleName := fmt.Sprintf("%s-%s-execution-role", stackName, agent.Name)
+		role, err := s.newRole(ctx, agent.Name+"-execution-role", roleName, &iam.RoleArgs{
+			Description:      pulumi.Sprintf("Execution role for the %s agent in %s", agent.Name, stackName),
+			AssumeRolePolicy: pulumi.String(assumeRolePolicy),
+			Tags:             mergeTags(s.agentTags(agent.Name, tags), pulumi.String(roleName)),
+		})
+		if err != nil {
+			return err
+		}
+		s.AgentRoles[agent.Name] = role
+
+		_, err = iam.NewRolePolicyAttachment(ctx, s.ResourceName(agent.Name+"-execution-policy-attachment"), &iam.RolePolicyAttachmentArgs{
+			Role:      role.Name,
+			PolicyArn: policy.Arn,
+		}, s.child())
+		if err != nil {
+			return err
+		}
+
+		// Secrets Manager access, limited to the agent's secrets, which
+		// include the secrets the stack creates
+		if !s.Options.AllowAllSecrets && len(agent.SecretsARNs) > 0 {
+			err = s.attachAgentPolicy(ctx, agent.Name, "secrets-policy", policyStatement{
+				Actions:   []string{"secretsmanager:GetSecretValue"},
+				Resources: pulumi.ToStringArray(secretResources(agent.SecretsARNs)),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, policyArn := range s.Options.ManagedPolicyARNs {
+			policyName := policyArn[strings.LastIndex(policyArn, "/")+1:]
+			_, err = iam.NewRolePolicyAttachment(ctx, s.ResourceName(fmt.Sprintf("%s-execution-managed-policy-%s", agent.Name, policyName)), &iam.RolePolicyAttachmentArgs{
+				Role:      role.Name,
+				PolicyArn: pulumi.String(policyArn),
+			}, s.child())
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// agentRoleArns returns the ARNs of the given agents' execution roles.
+func (s *AgentCoreStack) agentRoleArns(agentNames []string) pulumi.StringArray {
+	arns := make(pulumi.StringArray, len(agentNames))
+	for i, agentName := range agentNames {
+		arns[i] = s.AgentRoles[agentName].Arn
+	}
+	return arns
+}
+
+// assumeRolePolicyFor returns a trust policy for the given AWS service principal.
+func assumeRolePolicyFor(service string) string {
+	policy, _ := NewPolicyDocument(serviceTrustStatement(service)).JSON()
+	return policy
+}
+
+// newServiceRole creates an IAM role that the given AWS service principal can assume.
+func (s *AgentCoreStack) newServiceRole(ctx *pulumi.Context, name, service string, tags pulumi.StringMap) (*iam.Role, error) {
+	stackName := s.Config.StackName
+	return s.newRole(ctx, name, fmt.Sprintf("%s-%s", stackName, name), &iam.RoleArgs{
+		AssumeRolePolicy: pulumi.String(assumeRolePolicyFor(service)),
+		Tags:             mergeTags(tags, pulumi.Sprintf("%s-%s", stackName, name)),
+	})
+}
+
+// policyStatement is an IAM Allow statement, or a Deny statement if Deny is
+// set, whose resources may not be known until deployment.
+type policyStatement struct {
+	Actions    []string
+	Resources  pulumi.StringArrayInput
+	Conditions map[string]map[string]interface{}
+	Deny       bool
+}
+
+// attachRolePolicy attaches an inline policy to every agent's execution
+// role. Components use it to grant access scoped to the resources they
+// create.
+func (s *AgentCoreStack) attachRolePolicy(ctx *pulumi.Context, name string, statements ...policyStatement) error {
+	for _, agent := range s.Config.Agents {
+		if err := s.attachAgentPolicy(ctx, agent.Name, name, statements...); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-// buildIAMPolicyStatements builds the IAM policy JSON.
-func (s *AgentCoreStack) buildIAMPolicyStatements() string {
-	statements := []string{
+// attachAgentPolicy attaches an inline policy to one agent's execution
+// role, for access that belongs to that agent alone.
+func (s *AgentCoreStack) attachAgentPolicy(ctx *pulumi.Context, agentName, name string, statements ...policyStatement) error {
+	return s.newRolePolicy(ctx, fmt.Sprintf("%s-%s", agentName, name), s.AgentRoles[agentName].Name, statements...)
+}
+
+// newRolePolicy creates an inline policy on a role from statements.
+func (s *AgentCoreStack) newRolePolicy(ctx *pulumi.Context, name string, role pulumi.StringInput, statements ...policyStatement) error {
+	_, err := iam.NewRolePolicy(ctx, s.ResourceName(name), &iam.RolePolicyArgs{
+		Role:   role,
+		Policy: policyDocument(statements...),
+	}, s.child())
+	return err
+}
+
+// policyDocument renders statements as an IAM policy document.
+func policyDocument(statements ...policyStatement) pulumi.StringOutput {
+	resources := make([]interface{}, len(statements))
+	for i, stmt := range statements {
+		resources[i] = stmt.Resources.ToStringArrayOutput()
+	}
+
+	return pulumi.All(resources...).ApplyT(func(args []interface{}) (string, error) {
+		stmts := make([]Statement, len(statements))
+		for i, stmt := range statements {
+			stmts[i] = Statement{
+				Effect:    "Allow",
+				Action:    stmt.Actions,
+				Resource:  args[i].([]string),
+				Condition: stmt.Conditions,
+			}
+			if stmt.Deny {
+				stmts[i].Effect = "Deny"
+			}
+		}
+		return NewPolicyDocument(stmts...).JSON()
+	}).(pulumi.StringOutput)
+}
+
+// executionPolicyStatements returns the statements of the execution
+// policy, ending with Options.IAMStatements.
+func (s *AgentCoreStack) executionPolicyStatements() []Statement {
+	statements := []Statement{
 		// CloudWatch Logs
-		`{
-			"Effect": "Allow",
-			"Action": [
+		{
+			Effect: "Allow",
+			Action: []string{
 				"logs:CreateLogGroup",
 				"logs:CreateLogStream",
-				"logs:PutLogEvents"
-			],
-			"Resource": "arn:aws:logs:*:*:*"
-		}`,
+				"logs:PutLogEvents",
+			},
+			Resource: []string{"arn:aws:logs:*:*:*"},
+		},
 		// ECR
-		`{
-			"Effect": "Allow",
-			"Action": [
+		{
+			Effect: "Allow",
+			Action: []string{
 				"ecr:GetAuthorizationToken",
 				"ecr:BatchCheckLayerAvailability",
 				"ecr:GetDownloadUrlForLayer",
-				"ecr:BatchGetImage"
-			],
-			"Resource": "*"
-		}`,
-	}
-
-	// Bedrock access
-	if s.Config.IAM.EnableBedrockAccess {
-		bedrockResource := `"arn:aws:bedrock:*:*:foundation-model/*"`
-		if len(s.Config.IAM.BedrockModelIDs) > 0 {
-			resources := ""
-			for i, modelID := range s.Config.IAM.BedrockModelIDs {
-				if i > 0 {
-					resources += ", "
-				}
-				resources += fmt.Sprintf(`"arn:aws:bedrock:*:*:foundation-model/%s"`, modelID)
-			}
-			bedrockResource = fmt.Sprintf("[%s]", resources)
-		}
-		statements = append(statements, fmt.Sprintf(`{
-			"Effect": "Allow",
-			"Action": [
-				"bedrock:InvokeModel",
-				"bedrock:InvokeModelWithResponseStream"
-			],
-			"Resource": %s
-		}`, bedrockResource))
+				"ecr:BatchGetImage",
+			},
+			Resource: []string{"*"},
+		},
 	}
 
-	// Secrets Manager access
-	hasSecrets := false
-	for _, agent := range s.Config.Agents {
-		if len(agent.SecretsARNs) > 0 {
-			hasSecrets = true
-			break
-		}
-	}
-	if hasSecrets {
-		statements = append(statements, `{
-			"Effect": "Allow",
-			"Action": [
-				"secretsmanager:GetSecretValue"
-			],
-			"Resource": "*"
-		}`)
+	// Bedrock access, which is granted through the agents' inference
+	// profiles when they have them
+	if s.Config.IAM.EnableBedrockAccess && s.Options.InferenceProfiles == nil {
+		statements = append(statements, bedrockModelStatements(s.Config.IAM.BedrockModelIDs, s.Options.BedrockRegions)...)
 	}
 
-	// Build final policy
-	statementsJSON := ""
-	for i, stmt := range statements {
-		if i > 0 {
-			statementsJSON += ","
-		}
-		statementsJSON += stmt
+	// Secrets Manager access to all secrets if allowed. Otherwise each
+	// agent is granted its own secrets by createIAMRoles.
+	if s.Options.AllowAllSecrets {
+		statements = append(statements, Statement{
+			Effect:   "Allow",
+			Action:   []string{"secretsmanager:GetSecretValue"},
+			Resource: []string{"*"},
+		})
+	}
+	if createsSecrets(s.Config) && s.Config.Secrets.KMSKeyARN != "" {
+		statements = append(statements, Statement{
+			Effect:    "Allow",
+			Action:    []string{"kms:Decrypt"},
+			Resource:  []string{s.Config.Secrets.KMSKeyARN},
+			Condition: s.secretEncryptionContext,
+		})
 	}
 
-	return fmt.Sprintf(`{
-		"Version": "2012-10-17",
-		"Statement": [%s]
-	}`, statementsJSON)
+	return append(statements, s.Options.IAMStatements...)
 }
 
 // createLogGroup creates the CloudWatch log group.
@@ -407,60 +1356,480 @@ func (s *AgentCoreStack) createLogGroup(ctx *pulumi.Context, tags pulumi.StringM
 	var err error
 	stackName := s.Config.StackName
 
-	retentionDays := s.Config.Observability.LogRetentionDays
-	if retentionDays == 0 {
-		retentionDays = 30
-	}
+	retentionDays := s.logRetention()
 
-	s.LogGroup, err = cloudwatch.NewLogGroup(ctx, "log-group", &cloudwatch.LogGroupArgs{
+	s.LogGroup, err = cloudwatch.NewLogGroup(ctx, s.ResourceName("log-group"), &cloudwatch.LogGroupArgs{
 		Name:            pulumi.Sprintf("/aws/agentcore/%s", stackName),
 		RetentionInDays: pulumi.Int(retentionDays),
+		KmsKeyId:        s.kmsKeyID(),
 		Tags:            mergeTags(tags, pulumi.Sprintf("%s-logs", stackName)),
-	})
+	}, s.child())
 	if err != nil {
 		return err
 	}
 
+	// Agents with their own log group override this in createAgentLogGroups
+	for _, agent := range s.Config.Agents {
+		s.injectEnv(agent.Name, "LOG_GROUP_NAME", s.LogGroup.Name)
+	}
+
 	return nil
 }
 
 // exportOutputs exports stack outputs.
 func (s *AgentCoreStack) exportOutputs(ctx *pulumi.Context) {
-	if s.VPC != nil {
-		ctx.Export("vpcId", s.VPC.ID())
-		s.Outputs["vpcId"] = s.VPC.ID().ToStringOutput()
+	if vpcID := s.vpcID(); vpcID != nil {
+		s.export(ctx, "vpcId", vpcID)
+		s.Outputs["vpcId"] = vpcID.ToStringOutput()
 	}
 
-	if s.PrivateSubnet != nil {
-		ctx.Export("privateSubnetId", s.PrivateSubnet.ID())
-		s.Outputs["privateSubnetId"] = s.PrivateSubnet.ID().ToStringOutput()
+	if len(s.PrivateSubnets) > 0 {
+		s.export(ctx, "privateSubnetId", s.PrivateSubnets[0].ID())
+		s.Outputs["privateSubnetId"] = s.PrivateSubnets[0].ID().ToStringOutput()
+
+		publicIDs := pulumi.StringArray{}
+		for _, subnet := range s.PublicSubnets {
+			publicIDs = append(publicIDs, subnet.ID())
+		}
+		privateIDs := s.privateSubnetIDs()
+		s.export(ctx, "publicSubnetIds", publicIDs)
+		s.export(ctx, "privateSubnetIds", privateIDs)
+		s.Outputs["publicSubnetIds"] = joinIDs(publicIDs)
+		s.Outputs["privateSubnetIds"] = joinIDs(privateIDs)
+	} else if s.Config.VPC != nil && len(s.Config.VPC.SubnetIDs) > 0 {
+		s.export(ctx, "privateSubnetIds", s.privateSubnetIDs())
+		s.Outputs["privateSubnetIds"] = joinIDs(s.privateSubnetIDs())
 	}
 
 	if s.SecurityGroup != nil {
-		ctx.Export("securityGroupId", s.SecurityGroup.ID())
+		s.export(ctx, "securityGroupId", s.SecurityGroup.ID())
 		s.Outputs["securityGroupId"] = s.SecurityGroup.ID().ToStringOutput()
 	}
 
-	if s.ExecutionRole != nil {
-		ctx.Export("executionRoleArn", s.ExecutionRole.Arn)
-		s.Outputs["executionRoleArn"] = s.ExecutionRole.Arn
+	if len(s.VPCEndpoints) > 0 {
+		endpoints := pulumi.StringMap{}
+		for service, endpoint := range s.VPCEndpoints {
+			endpoints[service] = endpoint.ID().ToStringOutput()
+		}
+		s.export(ctx, "vpcEndpointIds", endpoints)
+	}
+
+	if len(s.AgentRoles) > 0 {
+		roleArns := pulumi.StringMap{}
+		for name, role := range s.AgentRoles {
+			roleArns[name] = role.Arn
+			s.Outputs[fmt.Sprintf("agents.%s.roleArn", name)] = role.Arn
+		}
+		s.export(ctx, "agentRoleArns", roleArns)
+	}
+
+	if len(s.Secrets) > 0 {
+		secretArns := pulumi.StringMap{}
+		for name, secret := range s.Secrets {
+			secretArns[name] = secret.Arn
+		}
+		s.export(ctx, "secretArns", secretArns)
+	}
+
+	if len(s.AgentSchedules) > 0 {
+		schedules := pulumi.StringMap{}
+		for name, schedule := range s.AgentSchedules {
+			schedules[name] = schedule.Arn
+		}
+		s.export(ctx, "agentSchedules", schedules)
+	}
+
+	if s.secretEncryptionContext != nil {
+		s.export(ctx, "secretEncryptionContext", pulumi.JSONMarshal(pulumi.Any(s.secretEncryptionContext)))
 	}
 
 	if s.LogGroup != nil {
-		ctx.Export("logGroupName", s.LogGroup.Name)
+		s.export(ctx, "logGroupName", s.LogGroup.Name)
 		s.Outputs["logGroupName"] = s.LogGroup.Name
 	}
 
-	ctx.Export("agentCount", pulumi.Int(len(s.Config.Agents)))
+	if len(s.AgentLogGroups) > 0 {
+		groups := pulumi.StringMap{}
+		for name, group := range s.AgentLogGroups {
+			groups[name] = group
+		}
+		s.export(ctx, "agentLogGroups", groups)
+	}
+
+	if len(s.AgentRuntimes) > 0 {
+		runtimeArns := pulumi.StringMap{}
+		invokeURLs := pulumi.StringMap{}
+		for name, runtime := range s.AgentRuntimes {
+			runtimeArns[name] = runtime.RuntimeArn
+			invokeURLs[name] = runtime.InvokeURL
+			s.Outputs[fmt.Sprintf("agents.%s.runtimeArn", name)] = runtime.RuntimeArn
+			s.Outputs[fmt.Sprintf("agents.%s.invokeUrl", name)] = runtime.InvokeURL
+		}
+		s.export(ctx, "agentRuntimeArns", runtimeArns)
+		s.export(ctx, "agentInvokeUrls", invokeURLs)
+
+		liveVersions := pulumi.StringMap{}
+		previousVersions := pulumi.StringMap{}
+		for _, agent := range s.Config.Agents {
+			if s.Options.Agents[agent.Name].BlueGreen == nil {
+				continue
+			}
+			runtime := s.AgentRuntimes[agent.Name]
+			liveVersions[agent.Name] = runtime.LiveVersion
+			previousVersions[agent.Name] = runtime.PreviousVersion
+			s.Outputs[fmt.Sprintf("agents.%s.liveVersion", agent.Name)] = runtime.LiveVersion
+			s.Outputs[fmt.Sprintf("agents.%s.previousVersion", agent.Name)] = runtime.PreviousVersion
+		}
+		if len(liveVersions) > 0 {
+			s.export(ctx, "agentLiveVersions", liveVersions)
+			s.export(ctx, "agentPreviousVersions", previousVersions)
+		}
+
+		canaryParameters := pulumi.StringMap{}
+		for _, name := range s.canaryAgents() {
+			param := s.canaryParameters[name].Name
+			canaryParameters[name] = param
+			s.Outputs[fmt.Sprintf("agents.%s.canaryParameter", name)] = param
+		}
+		if len(canaryParameters) > 0 {
+			s.export(ctx, "agentCanaryParameters", canaryParameters)
+		}
+	}
+
+	if len(s.AgentFunctions) > 0 {
+		functionArns := pulumi.StringMap{}
+		functionURLs := pulumi.StringMap{}
+		for name, function := range s.AgentFunctions {
+			functionArns[name] = function.Function.Arn
+			functionURLs[name] = function.InvokeURL
+			s.Outputs[fmt.Sprintf("agents.%s.functionArn", name)] = function.Function.Arn
+			s.Outputs[fmt.Sprintf("agents.%s.functionUrl", name)] = function.InvokeURL
+		}
+		s.export(ctx, "agentFunctionArns", functionArns)
+		s.export(ctx, "agentFunctionUrls", functionURLs)
+	}
+
+	if len(s.AgentRouters) > 0 {
+		routerURLs := pulumi.StringMap{}
+		for name, url := range s.AgentRouters {
+			routerURLs[name] = url
+			s.Outputs[fmt.Sprintf("agents.%s.routerUrl", name)] = url
+		}
+		s.export(ctx, "agentRouterUrls", routerURLs)
+	}
+
+	if len(s.AgentURLs) > 0 {
+		agentURLs := pulumi.StringMap{}
+		for name, url := range s.AgentURLs {
+			agentURLs[name] = url
+			s.Outputs[fmt.Sprintf("agents.%s.url", name)] = url
+		}
+		s.export(ctx, "agentUrls", agentURLs)
+	}
+
+	if s.LogArchiveBucket != nil {
+		s.export(ctx, "logArchiveBucket", s.LogArchiveBucket.Bucket)
+		s.Outputs["logArchiveBucket"] = s.LogArchiveBucket.Bucket
+	}
+
+	if s.HealthAlarm != nil {
+		s.export(ctx, "healthAlarmName", s.HealthAlarm.AlarmName)
+		s.Outputs["healthAlarmName"] = s.HealthAlarm.AlarmName
+		if s.Options.Health.Endpoint {
+			s.export(ctx, "healthUrl", s.HealthURL)
+			s.Outputs["healthUrl"] = s.HealthURL
+		}
+	}
+
+	if s.Options.Guardrail != nil {
+		s.export(ctx, "guardrailArn", s.GuardrailArn)
+		s.Outputs["guardrailArn"] = s.GuardrailArn
+		s.export(ctx, "guardrailVersion", s.GuardrailVersion)
+		s.Outputs["guardrailVersion"] = s.GuardrailVersion
+	}
+
+	if s.Options.PromptMonitoring != nil {
+		s.export(ctx, "promptGuardrailArn", s.PromptGuardrailArn)
+		s.export(ctx, "promptMonitoringTopicArn", s.PromptMonitoringTopic)
+		s.Outputs["promptGuardrailArn"] = s.PromptGuardrailArn
+		s.Outputs["promptMonitoringTopicArn"] = s.PromptMonitoringTopic
+	}
+
+	if s.Application != nil {
+		s.export(ctx, "applicationArn", s.Application.Arn)
+		s.Outputs["applicationArn"] = s.Application.Arn
+	}
+
+	if s.Cache != nil {
+		cacheEndpoint := s.Cache.Endpoints.Index(pulumi.Int(0)).Address()
+		s.export(ctx, "cacheEndpoint", cacheEndpoint)
+		s.Outputs["cacheEndpoint"] = cacheEndpoint
+	}
+
+	if s.StateTable != nil {
+		s.export(ctx, "stateTableName", s.StateTable.Name)
+		s.Outputs["stateTableName"] = s.StateTable.Name
+		s.export(ctx, "stateTableArn", s.StateTable.Arn)
+		s.Outputs["stateTableArn"] = s.StateTable.Arn
+		if s.Options.StateTable.StreamViewType != "" {
+			s.export(ctx, "stateTableStreamArn", s.StateTable.StreamArn)
+			s.Outputs["stateTableStreamArn"] = s.StateTable.StreamArn
+		}
+	}
+
+	if s.ArtifactBucket != nil {
+		s.export(ctx, "artifactBucket", s.ArtifactBucket.Bucket)
+		s.Outputs["artifactBucket"] = s.ArtifactBucket.Bucket
+	}
+
+	if s.CodeInterpreter != nil {
+		interpreterID := cloudControlAttribute(s.CodeInterpreter, "CodeInterpreterId")
+		s.export(ctx, "codeInterpreterId", interpreterID)
+		s.Outputs["codeInterpreterId"] = interpreterID
+	}
+
+	if s.Browser != nil {
+		browserID := cloudControlAttribute(s.Browser, "BrowserId")
+		s.export(ctx, "browserId", browserID)
+		s.Outputs["browserId"] = browserID
+	}
+
+	if len(s.GatewayURLs) > 0 {
+		gatewayURLs := pulumi.StringMap{}
+		for name, url := range s.GatewayURLs {
+			gatewayURLs[name] = url
+			s.Outputs[fmt.Sprintf("gateways.%s.url", name)] = url
+		}
+		s.export(ctx, "gatewayUrls", gatewayURLs)
+	}
+
+	s.exportAgentLabels(ctx)
+
+	if len(s.QuotaUsage) > 0 {
+		quotas := pulumi.Map{}
+		for name, usage := range s.QuotaUsage {
+			quota := pulumi.Map{
+				"created":         pulumi.Int(usage.Created),
+				"inUse":           pulumi.Int(usage.InUse),
+				"quota":           pulumi.Int(usage.Quota),
+				"headroom":        pulumi.Int(usage.Headroom),
+				"stacksRemaining": pulumi.Int(usage.StacksRemaining),
+			}
+			if usage.Detail != "" {
+				quota["detail"] = pulumi.String(usage.Detail)
+			}
+			quotas[name] = quota
+		}
+		s.export(ctx, "quotaUsage", quotas)
+	}
+
+	if len(s.AgentSchemas) > 0 {
+		schemas := pulumi.StringMap{}
+		for name, param := range s.AgentSchemas {
+			schemas[name] = param
+		}
+		s.export(ctx, "agentSchemas", schemas)
+	}
+
+	if len(s.FailureDestinations) > 0 {
+		destinations := pulumi.StringMap{}
+		for name, arn := range s.FailureDestinations {
+			destinations[name] = arn
+		}
+		s.export(ctx, "failureDestinations", destinations)
+	}
+
+	if len(s.TenantQueues) > 0 {
+		queues := pulumi.StringMap{}
+		for name, url := range s.TenantQueues {
+			queues[name] = url
+		}
+		s.export(ctx, "tenantQueues", queues)
+	}
+
+	if len(s.WorkQueues) > 0 {
+		queues := pulumi.StringMap{}
+		for name, url := range s.WorkQueues {
+			queues[name] = url
+		}
+		s.export(ctx, "workQueues", queues)
+	}
+
+	if s.GraphStore != nil {
+		s.export(ctx, "graphEndpoint", s.GraphStore.Endpoint)
+		s.Outputs["graphEndpoint"] = s.GraphStore.Endpoint
+	}
+
+	if s.VectorStore != nil {
+		s.export(ctx, "vectorStoreEndpoint", s.VectorStore.Endpoint)
+		s.Outputs["vectorStoreEndpoint"] = s.VectorStore.Endpoint
+		s.export(ctx, "vectorStoreSecretArn", s.VectorStoreSecretArn)
+		s.Outputs["vectorStoreSecretArn"] = s.VectorStoreSecretArn
+	}
+
+	if s.KendraIndex != nil {
+		indexID := s.KendraIndex.ID().ToStringOutput()
+		s.export(ctx, "kendraIndexId", indexID)
+		s.Outputs["kendraIndexId"] = indexID
+	}
+
+	if len(s.SageMakerEndpoints) > 0 {
+		endpoints := pulumi.StringMap{}
+		for name, endpoint := range s.SageMakerEndpoints {
+			endpoints[name] = endpoint
+		}
+		s.export(ctx, "sageMakerEndpoints", endpoints)
+	}
+
+	if s.Options.ModelHub != nil {
+		s.export(ctx, "modelHubTrustPolicy", s.ModelHubTrustPolicy)
+		s.export(ctx, "modelHubPermissionsPolicy", s.ModelHubPermissionsPolicy)
+		s.Outputs["modelHubTrustPolicy"] = s.ModelHubTrustPolicy
+		s.Outputs["modelHubPermissionsPolicy"] = s.ModelHubPermissionsPolicy
+	}
+
+	if len(s.InferenceProfiles) > 0 {
+		profiles := pulumi.StringMap{}
+		for name, arn := range s.InferenceProfiles {
+			profiles[name] = arn
+			s.Outputs["inferenceProfiles."+name] = arn
+		}
+		s.export(ctx, "inferenceProfiles", profiles)
+	}
+
+	if s.FineTuningBucket != nil {
+		s.export(ctx, "fineTuningBucket", s.FineTuningBucket.Bucket)
+		s.Outputs["fineTuningBucket"] = s.FineTuningBucket.Bucket
+	}
+
+	if len(s.CustomModels) > 0 {
+		models := pulumi.StringMap{}
+		for name, arn := range s.CustomModels {
+			models[name] = arn
+		}
+		s.export(ctx, "customModels", models)
+	}
+
+	if s.BatchBucket != nil {
+		s.export(ctx, "batchBucket", s.BatchBucket.Bucket)
+		s.Outputs["batchBucket"] = s.BatchBucket.Bucket
+	}
+
+	if s.BatchNotifications != nil {
+		s.export(ctx, "batchNotificationTopicArn", s.BatchNotifications.Arn)
+		s.Outputs["batchNotificationTopicArn"] = s.BatchNotifications.Arn
+	}
+
+	if s.AuditBucket != nil {
+		s.export(ctx, "auditBucket", s.AuditBucket.Bucket)
+		s.Outputs["auditBucket"] = s.AuditBucket.Bucket
+		s.export(ctx, "auditStreamName", s.AuditStream.Name)
+		s.Outputs["auditStreamName"] = s.AuditStream.Name
+	}
+
+	if s.SecretsAuditTopic != nil {
+		s.export(ctx, "secretsAuditTopicArn", s.SecretsAuditTopic.Arn)
+		s.Outputs["secretsAuditTopicArn"] = s.SecretsAuditTopic.Arn
+	}
+
+	if s.KeyRotationTopic != nil {
+		s.export(ctx, "keyRotationTopicArn", s.KeyRotationTopic.Arn)
+		s.Outputs["keyRotationTopicArn"] = s.KeyRotationTopic.Arn
+	}
+
+	if s.Options.GuardDuty != nil {
+		s.export(ctx, "guardDutyTopicArn", s.GuardDutyTopic)
+		s.Outputs["guardDutyTopicArn"] = s.GuardDutyTopic
+	}
+
+	if s.Options.SecurityHub != nil {
+		s.export(ctx, "securityHubTopicArn", s.SecurityHubTopic)
+		s.Outputs["securityHubTopicArn"] = s.SecurityHubTopic
+	}
+
+	if s.Options.KMS != nil {
+		s.export(ctx, "kmsKeyArn", s.KMSKeyArn)
+		s.Outputs["kmsKeyArn"] = s.KMSKeyArn
+	}
+
+	if s.Options.Alarms != nil {
+		s.export(ctx, "alarmTopicArn", s.AlarmTopic)
+		s.Outputs["alarmTopicArn"] = s.AlarmTopic
+	}
+
+	if s.Dashboard != nil {
+		s.export(ctx, "dashboardName", s.Dashboard.DashboardName)
+		s.export(ctx, "dashboardUrl", s.DashboardURL)
+		s.Outputs["dashboardName"] = s.Dashboard.DashboardName
+		s.Outputs["dashboardUrl"] = s.DashboardURL
+	}
+
+	if s.ReportBucket != nil {
+		s.export(ctx, "reportBucket", s.ReportBucket.Bucket)
+		s.Outputs["reportBucket"] = s.ReportBucket.Bucket
+		s.export(ctx, "reportTopicArn", s.ReportDelivery.Arn)
+		s.Outputs["reportTopicArn"] = s.ReportDelivery.Arn
+	}
+
+	if s.ApprovalWorkflow != nil {
+		s.export(ctx, "approvalStateMachineArn", s.ApprovalWorkflow.Arn)
+		s.Outputs["approvalStateMachineArn"] = s.ApprovalWorkflow.Arn
+		s.export(ctx, "approvalApiUrl", s.ApprovalAPI.ApiEndpoint)
+		s.Outputs["approvalApiUrl"] = s.ApprovalAPI.ApiEndpoint
+	}
+
+	if len(s.Workflows) > 0 {
+		workflows := pulumi.Map{}
+		for name, machine := range s.Workflows {
+			workflows[name] = pulumi.StringMap{
+				"stateMachineArn": machine.Arn,
+				"roleArn":         s.WorkflowRoles[name],
+			}
+			s.Outputs[fmt.Sprintf("workflows.%s.stateMachineArn", name)] = machine.Arn
+			s.Outputs[fmt.Sprintf("workflows.%s.roleArn", name)] = s.WorkflowRoles[name]
+		}
+		s.export(ctx, "workflows", workflows)
+	}
+
+	if s.AppSyncAPI != nil {
+		graphqlURL := s.AppSyncAPI.Uris.MapIndex(pulumi.String("GRAPHQL"))
+		s.export(ctx, "graphqlUrl", graphqlURL)
+		s.Outputs["graphqlUrl"] = graphqlURL
+	}
+
+	if s.Frontend != nil {
+		frontendURL := pulumi.Sprintf("https://%s", s.Frontend.DomainName)
+		s.export(ctx, "frontendUrl", frontendURL)
+		s.Outputs["frontendUrl"] = frontendURL
+	}
+
+	if s.WebACL != nil {
+		s.export(ctx, "webAclArn", s.WebACL.Arn)
+		s.Outputs["webAclArn"] = s.WebACL.Arn
+	}
+
+	if s.CloudFrontWebACL != nil {
+		s.export(ctx, "cloudFrontWebAclArn", s.CloudFrontWebACL.Arn)
+		s.Outputs["cloudFrontWebAclArn"] = s.CloudFrontWebACL.Arn
+	}
+
+	s.export(ctx, "agentCount", pulumi.Int(len(s.Config.Agents)))
+	s.maskOutputs()
 }
 
-// NewStackFromFile creates an AgentCoreStack from a JSON or YAML config file.
+// NewStackFromFile creates an AgentCoreStack from a JSON or YAML config file,
+// resolving its placeholders as LoadStackConfig does. Options are read
+// from the file's "options" key.
 func NewStackFromFile(ctx *pulumi.Context, configPath string) (*AgentCoreStack, error) {
-	config, err := iac.LoadStackConfigFromFile(configPath)
+	config, err := LoadStackConfig(ctx, configPath)
+	if err != nil {
+		return nil, err
+	}
+	options, err := LoadOptions(ctx, configPath)
 	if err != nil {
 		return nil, err
 	}
-	return NewAgentCoreStack(ctx, *config)
+	return NewAgentCoreStackWithOptions(ctx, *config, options)
 }
 
 // MustNewStackFromFile is like NewStackFromFile but panics on error.
@@ -0,0 +1,166 @@
+package agentcore
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/dynamodb"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// stateTableAttributePattern matches DynamoDB key and TTL attribute names.
+var stateTableAttributePattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]{1,255}$`)
+
+// StateTableConfig configures a DynamoDB table for agent session and run
+// state.
+//
+// The table has a string partition key and sort key and on-demand billing.
+// Agents receive its name and key schema as STATE_TABLE_* environment
+// variables and may only read and write items whose partition key starts
+// with their STATE_TABLE_KEY_PREFIX, "{agent}#".
+type StateTableConfig struct {
+	// PartitionKey is the name of the partition key attribute.
+	// Default: "pk"
+	PartitionKey string `json:"partitionKey,omitempty" yaml:"partitionKey,omitempty"`
+
+	// SortKey is the name of the sort key attribute.
+	// Default: "sk"
+	SortKey string `json:"sortKey,omitempty" yaml:"sortKey,omitempty"`
+
+	// TTLAttribute is the attribute holding the epoch second after which
+	// an item expires.
+	// Default: "expiresAt"
+	TTLAttribute string `json:"ttlAttribute,omitempty" yaml:"ttlAttribute,omitempty"`
+
+	// StreamViewType enables a DynamoDB stream of item changes.
+	// Supported: "", "KEYS_ONLY", "NEW_IMAGE", "OLD_IMAGE", "NEW_AND_OLD_IMAGES"
+	// Default: "" (no stream)
+	StreamViewType string `json:"streamViewType,omitempty" yaml:"streamViewType,omitempty"`
+
+	// PointInTimeRecovery enables continuous backups of the table.
+	// Default: false
+	PointInTimeRecovery bool `json:"pointInTimeRecovery,omitempty" yaml:"pointInTimeRecovery,omitempty"`
+}
+
+// DefaultStateTableConfig returns a StateTableConfig with sensible defaults.
+func DefaultStateTableConfig() *StateTableConfig {
+	return &StateTableConfig{
+		PartitionKey: "pk",
+		SortKey:      "sk",
+		TTLAttribute: "expiresAt",
+	}
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *StateTableConfig) ApplyDefaults() {
+	if c.PartitionKey == "" {
+		c.PartitionKey = "pk"
+	}
+	if c.SortKey == "" {
+		c.SortKey = "sk"
+	}
+	if c.TTLAttribute == "" {
+		c.TTLAttribute = "expiresAt"
+	}
+}
+
+// Validate validates the StateTableConfig.
+func (c *StateTableConfig) Validate() error {
+	for field, name := range map[string]string{
+		"partitionKey": c.PartitionKey,
+		"sortKey":      c.SortKey,
+		"ttlAttribute": c.TTLAttribute,
+	} {
+		if !stateTableAttributePattern.MatchString(name) {
+			return fmt.Errorf("stateTable.%s: '%s' is not a valid attribute name", field, name)
+		}
+	}
+	if c.PartitionKey == c.SortKey {
+		return fmt.Errorf("stateTable.sortKey must differ from partitionKey")
+	}
+	if c.TTLAttribute == c.PartitionKey || c.TTLAttribute == c.SortKey {
+		return fmt.Errorf("stateTable.ttlAttribute must not be a key attribute")
+	}
+	if c.StreamViewType != "" && !slices.Contains([]string{"KEYS_ONLY", "NEW_IMAGE", "OLD_IMAGE", "NEW_AND_OLD_IMAGES"}, c.StreamViewType) {
+		return fmt.Errorf("stateTable.streamViewType must be one of [KEYS_ONLY NEW_IMAGE OLD_IMAGE NEW_AND_OLD_IMAGES]")
+	}
+	return nil
+}
+
+// createStateTable creates the state table, grants agents access to their
+// items and injects the table settings into every agent.
+func (s *AgentCoreStack) createStateTable(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	stackName := s.Config.StackName
+	cfg := s.Options.StateTable
+	retain := s.Config.RemovalPolicy == "retain"
+
+	args := &dynamodb.TableArgs{
+		Name:        pulumi.Sprintf("%s-state", stackName),
+		BillingMode: pulumi.String("PAY_PER_REQUEST"),
+		HashKey:     pulumi.String(cfg.PartitionKey),
+		RangeKey:    pulumi.String(cfg.SortKey),
+		Attributes: dynamodb.TableAttributeArray{
+			&dynamodb.TableAttributeArgs{Name: pulumi.String(cfg.PartitionKey), Type: pulumi.String("S")},
+			&dynamodb.TableAttributeArgs{Name: pulumi.String(cfg.SortKey), Type: pulumi.String("S")},
+		},
+		Ttl: &dynamodb.TableTtlArgs{
+			AttributeName: pulumi.String(cfg.TTLAttribute),
+			Enabled:       pulumi.Bool(true),
+		},
+		PointInTimeRecovery: &dynamodb.TablePointInTimeRecoveryArgs{
+			Enabled: pulumi.Bool(cfg.PointInTimeRecovery),
+		},
+		DeletionProtectionEnabled: pulumi.Bool(retain),
+		Tags:                      mergeTags(tags, pulumi.Sprintf("%s-state", stackName)),
+	}
+	if cfg.StreamViewType != "" {
+		args.StreamEnabled = pulumi.Bool(true)
+		args.StreamViewType = pulumi.String(cfg.StreamViewType)
+	}
+	if s.Options.KMS != nil {
+		args.ServerSideEncryption = &dynamodb.TableServerSideEncryptionArgs{
+			Enabled:   pulumi.Bool(true),
+			KmsKeyArn: s.KMSKeyArn,
+		}
+	}
+
+	var err error
+	s.StateTable, err = dynamodb.NewTable(ctx, s.ResourceName("state-table"), args, pulumi.RetainOnDelete(retain), s.child())
+	if err != nil {
+		return err
+	}
+
+	// Each agent may only use items under its own key prefix
+	for _, agent := range s.Config.Agents {
+		err = s.attachAgentPolicy(ctx, agent.Name, "state-table-policy", policyStatement{
+			Actions: []string{
+				"dynamodb:GetItem",
+				"dynamodb:BatchGetItem",
+				"dynamodb:Query",
+				"dynamodb:PutItem",
+				"dynamodb:UpdateItem",
+				"dynamodb:DeleteItem",
+				"dynamodb:BatchWriteItem",
+				"dynamodb:ConditionCheckItem",
+			},
+			Resources: pulumi.StringArray{s.StateTable.Arn},
+			Conditions: map[string]map[string]interface{}{
+				"ForAllValues:StringLike": {"dynamodb:LeadingKeys": []string{agent.Name + "#*"}},
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, agent := range s.Config.Agents {
+		s.injectEnv(agent.Name, "STATE_TABLE_NAME", s.StateTable.Name)
+		s.injectEnv(agent.Name, "STATE_TABLE_PARTITION_KEY", pulumi.String(cfg.PartitionKey))
+		s.injectEnv(agent.Name, "STATE_TABLE_SORT_KEY", pulumi.String(cfg.SortKey))
+		s.injectEnv(agent.Name, "STATE_TABLE_TTL_ATTRIBUTE", pulumi.String(cfg.TTLAttribute))
+		s.injectEnv(agent.Name, "STATE_TABLE_KEY_PREFIX", pulumi.String(agent.Name+"#"))
+	}
+
+	return nil
+}
@@ -0,0 +1,259 @@
+package agentcore
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/acm"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/apigatewayv2"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/lambda"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/route53"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// dnsLabelPattern matches a single DNS label.
+var dnsLabelPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// AgentSubdomainsConfig gives each agent a DNS name under a delegated
+// subdomain, such as research.agents.example.com.
+//
+// Each name is a regional API Gateway custom domain in front of an HTTP API
+// that forwards IAM-signed requests to the agent's runtimes. API Gateway
+// limits requests to 30 seconds; long-running agents should be invoked
+// through their runtime endpoint or router URL instead.
+type AgentSubdomainsConfig struct {
+	// ZoneID is the Route 53 hosted zone the records are created in.
+	ZoneID string `json:"zoneID" yaml:"zoneID"`
+
+	// Domain is the subdomain the agent names are created under, e.g.
+	// agents.example.com. It must be the zone's domain or within it.
+	Domain string `json:"domain" yaml:"domain"`
+
+	// Private marks ZoneID as a private hosted zone. ACM cannot validate
+	// certificates in private zones, so CertificateARN is required.
+	// Default: false
+	Private bool `json:"private,omitempty" yaml:"private,omitempty"`
+
+	// CertificateARN is an existing ACM certificate covering *.{Domain}.
+	// If empty, one is created and validated in ZoneID.
+	CertificateARN string `json:"certificateARN,omitempty" yaml:"certificateARN,omitempty"`
+
+	// Agents are the agents given a DNS name. If empty, all agents are.
+	Agents []string `json:"agents,omitempty" yaml:"agents,omitempty"`
+
+	// RegionRouting creates weighted or latency records, so the names can
+	// be shared by the stack's deployments in several regions.
+	// Optional.
+	RegionRouting *RegionRoutingConfig `json:"regionRouting,omitempty" yaml:"regionRouting,omitempty"`
+}
+
+// Validate validates the AgentSubdomainsConfig.
+func (c *AgentSubdomainsConfig) Validate(config iac.StackConfig) error {
+	if c.ZoneID == "" {
+		return fmt.Errorf("agentSubdomains.zoneID is required")
+	}
+	if c.Domain == "" {
+		return fmt.Errorf("agentSubdomains.domain is required")
+	}
+	for _, label := range strings.Split(c.Domain, ".") {
+		if !dnsLabelPattern.MatchString(label) {
+			return fmt.Errorf("agentSubdomains.domain: '%s' is not a valid domain name", c.Domain)
+		}
+	}
+	if c.CertificateARN != "" && !strings.HasPrefix(c.CertificateARN, "arn:") {
+		return fmt.Errorf("agentSubdomains.certificateARN: '%s' is not an ARN", c.CertificateARN)
+	}
+	if c.Private && c.CertificateARN == "" {
+		return fmt.Errorf("agentSubdomains.certificateARN is required for private zones")
+	}
+	if err := validateAgentNames("agentSubdomains.agents", c.Agents, config); err != nil {
+		return err
+	}
+	if c.RegionRouting != nil {
+		if err := c.RegionRouting.Validate(); err != nil {
+			return err
+		}
+	}
+	names := c.Agents
+	if len(names) == 0 {
+		for _, agent := range config.Agents {
+			names = append(names, agent.Name)
+		}
+	}
+	for _, name := range names {
+		if !dnsLabelPattern.MatchString(name) {
+			return fmt.Errorf("agentSubdomains: agent name '%s' is not a valid DNS label", name)
+		}
+	}
+	return nil
+}
+
+// agentHostname returns the DNS name of an agent.
+func (c *AgentSubdomainsConfig) agentHostname(agentName string) string {
+	return fmt.Sprintf("%s.%s", agentName, c.Domain)
+}
+
+// subdomainCertificate returns the ARN of the certificate for the agent
+// names, creating and validating one if needed.
+func (s *AgentCoreStack) subdomainCertificate(ctx *pulumi.Context, tags pulumi.StringMap) (pulumi.StringOutput, error) {
+	cfg := s.Options.AgentSubdomains
+	if cfg.CertificateARN != "" {
+		return pulumi.String(cfg.CertificateARN).ToStringOutput(), nil
+	}
+
+	cert, err := acm.NewCertificate(ctx, s.ResourceName("agent-subdomains-certificate"), &acm.CertificateArgs{
+		DomainName:       pulumi.Sprintf("*.%s", cfg.Domain),
+		ValidationMethod: pulumi.String("DNS"),
+		Tags:             mergeTags(tags, pulumi.Sprintf("*.%s", cfg.Domain)),
+	}, s.child())
+	if err != nil {
+		return pulumi.StringOutput{}, err
+	}
+
+	option := cert.DomainValidationOptions.Index(pulumi.Int(0))
+	record, err := route53.NewRecord(ctx, s.ResourceName("agent-subdomains-validation"), &route53.RecordArgs{
+		ZoneId:         pulumi.String(cfg.ZoneID),
+		Name:           option.ResourceRecordName().Elem(),
+		Type:           option.ResourceRecordType().Elem(),
+		Records:        pulumi.StringArray{option.ResourceRecordValue().Elem()},
+		Ttl:            pulumi.Int(60),
+		AllowOverwrite: pulumi.Bool(true),
+	}, s.child())
+	if err != nil {
+		return pulumi.StringOutput{}, err
+	}
+
+	validation, err := acm.NewCertificateValidation(ctx, s.ResourceName("agent-subdomains-certificate-validation"), &acm.CertificateValidationArgs{
+		CertificateArn:        cert.Arn,
+		ValidationRecordFqdns: pulumi.StringArray{record.Fqdn},
+	}, s.child())
+	if err != nil {
+		return pulumi.StringOutput{}, err
+	}
+	return validation.CertificateArn, nil
+}
+
+// createAgentSubdomains creates a DNS name for each selected agent. It runs
+// after createAgentRuntimes.
+func (s *AgentCoreStack) createAgentSubdomains(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	stackName := s.Config.StackName
+	cfg := s.Options.AgentSubdomains
+
+	certificate, err := s.subdomainCertificate(ctx, tags)
+	if err != nil {
+		return err
+	}
+
+	var routing *regionRouting
+	if cfg.RegionRouting != nil {
+		if routing, err = s.resolveRegionRouting(ctx); err != nil {
+			return err
+		}
+		s.regionRouting = routing
+	}
+
+	for _, agentName := range s.runtimeAgents(cfg.Agents) {
+		name := fmt.Sprintf("%s-proxy", agentName)
+		hostname := cfg.agentHostname(agentName)
+		agentTags := s.agentTags(agentName, tags)
+
+		function, err := s.newAgentProxy(ctx, name, agentName, tags)
+		if err != nil {
+			return err
+		}
+
+		api, err := apigatewayv2.NewApi(ctx, s.ResourceName(name+"-api"), &apigatewayv2.ApiArgs{
+			Name:                      pulumi.Sprintf("%s-%s", stackName, agentName),
+			Description:               pulumi.Sprintf("%s agent in %s", agentName, stackName),
+			ProtocolType:              pulumi.String("HTTP"),
+			DisableExecuteApiEndpoint: pulumi.Bool(true),
+			Tags:                      mergeTags(agentTags, pulumi.String(hostname)),
+		}, s.child())
+		if err != nil {
+			return err
+		}
+		_, err = lambda.NewPermission(ctx, s.ResourceName(name+"-api-permission"), &lambda.PermissionArgs{
+			Function:  function.Name,
+			Action:    pulumi.String("lambda:InvokeFunction"),
+			Principal: pulumi.String("apigateway.amazonaws.com"),
+			SourceArn: pulumi.Sprintf("%s/*", api.ExecutionArn),
+		}, s.child())
+		if err != nil {
+			return err
+		}
+		integration, err := apigatewayv2.NewIntegration(ctx, s.ResourceName(name+"-integration"), &apigatewayv2.IntegrationArgs{
+			ApiId:                api.ID(),
+			IntegrationType:      pulumi.String("AWS_PROXY"),
+			IntegrationUri:       function.InvokeArn,
+			PayloadFormatVersion: pulumi.String("2.0"),
+			TimeoutMilliseconds:  pulumi.Int(30000),
+		}, s.child())
+		if err != nil {
+			return err
+		}
+		_, err = apigatewayv2.NewRoute(ctx, s.ResourceName(name+"-route"), &apigatewayv2.RouteArgs{
+			ApiId:             api.ID(),
+			RouteKey:          pulumi.String("POST /invocations"),
+			AuthorizationType: pulumi.String("AWS_IAM"),
+			Target:            pulumi.Sprintf("integrations/%s", integration.ID()),
+		}, s.child())
+		if err != nil {
+			return err
+		}
+		stage, err := apigatewayv2.NewStage(ctx, s.ResourceName(name+"-stage"), &apigatewayv2.StageArgs{
+			ApiId:      api.ID(),
+			Name:       pulumi.String("$default"),
+			AutoDeploy: pulumi.Bool(true),
+			Tags:       mergeTags(agentTags, pulumi.String(hostname)),
+		}, s.child())
+		if err != nil {
+			return err
+		}
+
+		domain, err := apigatewayv2.NewDomainName(ctx, s.ResourceName(name+"-domain"), &apigatewayv2.DomainNameArgs{
+			DomainName: pulumi.String(hostname),
+			DomainNameConfiguration: &apigatewayv2.DomainNameDomainNameConfigurationArgs{
+				CertificateArn: certificate,
+				EndpointType:   pulumi.String("REGIONAL"),
+				SecurityPolicy: pulumi.String("TLS_1_2"),
+			},
+			Tags: mergeTags(agentTags, pulumi.String(hostname)),
+		}, s.child())
+		if err != nil {
+			return err
+		}
+		_, err = apigatewayv2.NewApiMapping(ctx, s.ResourceName(name+"-mapping"), &apigatewayv2.ApiMappingArgs{
+			ApiId:      api.ID(),
+			DomainName: domain.ID(),
+			Stage:      stage.ID(),
+		}, s.child())
+		if err != nil {
+			return err
+		}
+		recordArgs := &route53.RecordArgs{
+			ZoneId: pulumi.String(cfg.ZoneID),
+			Name:   pulumi.String(hostname),
+			Type:   pulumi.String("A"),
+			Aliases: route53.RecordAliasArray{
+				&route53.RecordAliasArgs{
+					Name:                 domain.DomainNameConfiguration.TargetDomainName().Elem(),
+					ZoneId:               domain.DomainNameConfiguration.HostedZoneId().Elem(),
+					EvaluateTargetHealth: pulumi.Bool(false),
+				},
+			},
+		}
+		if routing != nil {
+			routing.apply(recordArgs, cfg.RegionRouting.HealthCheckID)
+		}
+		_, err = route53.NewRecord(ctx, s.ResourceName(name+"-record"), recordArgs, s.child())
+		if err != nil {
+			return err
+		}
+
+		s.AgentURLs[agentName] = pulumi.Sprintf("https://%s/invocations", hostname)
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package agentcore
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// Subnet CIDRs are carved from the VPC CIDR in blocks of subnetNewBits
+// extra prefix bits. Public subnets start at block 1 and private subnets at
+// block 10, so a 10.0.0.0/16 VPC gets 10.0.1.0/24, 10.0.2.0/24, ... and
+// 10.0.10.0/24, 10.0.11.0/24, ...
+const (
+	subnetNewBits       = 8
+	publicSubnetOffset  = 1
+	privateSubnetOffset = 10
+	minSubnetPrefix     = 28
+)
+
+// vpcMaxAZs returns the number of availability zones to spread subnets across.
+func vpcMaxAZs(vpc *iac.VPCConfig) int {
+	if vpc.MaxAZs > 0 {
+		return vpc.MaxAZs
+	}
+	return 2
+}
+
+// subnetCIDR returns the index-th subnet block of vpcCidr.
+func subnetCIDR(vpcCidr string, index int) (string, error) {
+	prefix, err := netip.ParsePrefix(vpcCidr)
+	if err != nil || !prefix.Addr().Is4() {
+		return "", fmt.Errorf("vpc.vpcCidr '%s' is not an IPv4 CIDR", vpcCidr)
+	}
+	prefix = prefix.Masked()
+
+	bits := min(prefix.Bits()+subnetNewBits, minSubnetPrefix)
+	newBits := bits - prefix.Bits()
+	if newBits <= 0 || index >= 1<<newBits {
+		return "", fmt.Errorf("vpc.vpcCidr '%s' is too small for subnet %d", vpcCidr, index)
+	}
+
+	addr := prefix.Addr().As4()
+	base := uint32(addr[0])<<24 | uint32(addr[1])<<16 | uint32(addr[2])<<8 | uint32(addr[3])
+	base += uint32(index) << (32 - bits) //nolint:gosec // index bounded by newBits
+	subnet := netip.AddrFrom4([4]byte{byte(base >> 24), byte(base >> 16), byte(base >> 8), byte(base)})
+	return netip.PrefixFrom(subnet, bits).String(), nil
+}
+
+// validateVPCLayout checks that a created VPC's CIDR fits a subnet pair per availability zone.
+func validateVPCLayout(config iac.StackConfig) error {
+	if config.VPC == nil || !config.VPC.CreateVPC {
+		return nil
+	}
+	if vpcMaxAZs(config.VPC) > privateSubnetOffset-publicSubnetOffset {
+		return fmt.Errorf("vpc.maxAZs must be at most %d", privateSubnetOffset-publicSubnetOffset)
+	}
+	_, err := subnetCIDR(config.VPC.VPCCidr, privateSubnetOffset+vpcMaxAZs(config.VPC)-1)
+	return err
+}
+
+// subnetAliases keeps the first subnet and route table association at the
+// names they had when the VPC had a single subnet pair.
+func subnetAliases(name string, index int) []pulumi.Alias {
+	if index > 0 {
+		return nil
+	}
+	return []pulumi.Alias{{Name: pulumi.String(name)}}
+}
+
+// joinIDs joins resource IDs into a comma-separated string output.
+func joinIDs(ids pulumi.StringArray) pulumi.StringOutput {
+	return ids.ToStringArrayOutput().ApplyT(func(values []string) string {
+		return strings.Join(values, ",")
+	}).(pulumi.StringOutput)
+}
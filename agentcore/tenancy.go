@@ -0,0 +1,194 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/sqs"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// tenantNamePattern restricts tenant names to characters valid in queue names.
+var tenantNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,40}$`)
+
+// TenancyConfig configures per-tenant work queues and fairness settings for
+// multi-tenant agent platforms, so one tenant's burst can't starve others.
+type TenancyConfig struct {
+	// Tenants is the list of tenants sharing the agent team.
+	// At least one tenant is required.
+	Tenants []TenantConfig `json:"tenants" yaml:"tenants"`
+
+	// Agents is the list of agent names that consume tenant queues.
+	// If empty, all agents in the stack are included.
+	Agents []string `json:"agents,omitempty" yaml:"agents,omitempty"`
+
+	// VisibilityTimeoutSeconds is the time a consumer has to process a request.
+	// Default: 900
+	VisibilityTimeoutSeconds int `json:"visibilityTimeoutSeconds,omitempty" yaml:"visibilityTimeoutSeconds,omitempty"`
+
+	// MaxReceiveCount is the number of attempts before a request moves to the dead-letter queue.
+	// Default: 3
+	MaxReceiveCount int `json:"maxReceiveCount,omitempty" yaml:"maxReceiveCount,omitempty"`
+
+	// Isolation gives each tenant its own namespace: a log stream prefix,
+	// a KMS key, a secrets prefix and a tenant access role partitioned by
+	// the "tenant" session tag.
+	// Default: false
+	Isolation bool `json:"isolation,omitempty" yaml:"isolation,omitempty"`
+}
+
+// TenantConfig configures scheduling for a single tenant.
+type TenantConfig struct {
+	// Name is the unique tenant identifier.
+	Name string `json:"name" yaml:"name"`
+
+	// Weight is the tenant's share in weighted fair scheduling.
+	// Default: 1
+	Weight int `json:"weight,omitempty" yaml:"weight,omitempty"`
+
+	// Priority orders tenants when capacity is scarce; higher runs first.
+	// Default: 0
+	Priority int `json:"priority,omitempty" yaml:"priority,omitempty"`
+
+	// MaxConcurrency caps in-flight requests for the tenant.
+	// Default: 10
+	MaxConcurrency int `json:"maxConcurrency,omitempty" yaml:"maxConcurrency,omitempty"`
+
+	// RateLimitPerSecond throttles the tenant's request rate. 0 means unlimited.
+	RateLimitPerSecond float64 `json:"rateLimitPerSecond,omitempty" yaml:"rateLimitPerSecond,omitempty"`
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *TenancyConfig) ApplyDefaults() {
+	if c.VisibilityTimeoutSeconds == 0 {
+		c.VisibilityTimeoutSeconds = 900
+	}
+	if c.MaxReceiveCount == 0 {
+		c.MaxReceiveCount = 3
+	}
+	for i := range c.Tenants {
+		if c.Tenants[i].Weight == 0 {
+			c.Tenants[i].Weight = 1
+		}
+		if c.Tenants[i].MaxConcurrency == 0 {
+			c.Tenants[i].MaxConcurrency = 10
+		}
+	}
+}
+
+// Validate validates the TenancyConfig against the stack configuration.
+func (c *TenancyConfig) Validate(config iac.StackConfig) error {
+	if len(c.Tenants) == 0 {
+		return fmt.Errorf("tenancy: at least one tenant is required")
+	}
+	if c.VisibilityTimeoutSeconds < 0 || c.VisibilityTimeoutSeconds > 43200 {
+		return fmt.Errorf("tenancy.visibilityTimeoutSeconds must be between 0 and 43200")
+	}
+	names := make(map[string]bool)
+	for i, tenant := range c.Tenants {
+		if !tenantNamePattern.MatchString(tenant.Name) {
+			return fmt.Errorf("tenancy.tenants[%d]: name must match %s", i, tenantNamePattern)
+		}
+		if names[tenant.Name] {
+			return fmt.Errorf("tenancy: duplicate tenant name: %s", tenant.Name)
+		}
+		names[tenant.Name] = true
+		if tenant.Weight < 1 {
+			return fmt.Errorf("tenancy.tenants[%d] (%s): weight must be at least 1", i, tenant.Name)
+		}
+		if tenant.MaxConcurrency < 1 {
+			return fmt.Errorf("tenancy.tenants[%d] (%s): maxConcurrency must be at least 1", i, tenant.Name)
+		}
+		if tenant.RateLimitPerSecond < 0 {
+			return fmt.Errorf("tenancy.tenants[%d] (%s): rateLimitPerSecond must not be negative", i, tenant.Name)
+		}
+	}
+	if c.Isolation && (config.Observability == nil || !config.Observability.EnableCloudWatchLogs) {
+		return fmt.Errorf("tenancy.isolation requires observability.enableCloudWatchLogs for per-tenant log streams")
+	}
+	return validateAgentNames("tenancy.agents", c.Agents, config)
+}
+
+// tenantSchedule is the per-tenant entry of the injected TENANCY_CONFIG.
+type tenantSchedule struct {
+	QueueURL           string  `json:"queueUrl"`
+	Weight             int     `json:"weight"`
+	Priority           int     `json:"priority"`
+	MaxConcurrency     int     `json:"maxConcurrency"`
+	RateLimitPerSecond float64 `json:"rateLimitPerSecond,omitempty"`
+}
+
+// createTenantQueues creates a work queue per tenant and injects the
+// fairness configuration into the consuming agents.
+func (s *AgentCoreStack) createTenantQueues(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	stackName := s.Config.StackName
+	cfg := s.Options.Tenancy
+
+	dlq, err := sqs.NewQueue(ctx, s.ResourceName("tenant-dlq"), &sqs.QueueArgs{
+		Name:                    pulumi.Sprintf("%s-tenant-dlq", stackName),
+		MessageRetentionSeconds: pulumi.Int(14 * 24 * 60 * 60),
+		SqsManagedSseEnabled:    pulumi.Bool(true),
+		Tags:                    mergeTags(tags, pulumi.Sprintf("%s-tenant-dlq", stackName)),
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	redrivePolicy := dlq.Arn.ApplyT(func(arn string) (string, error) {
+		data, err := json.Marshal(map[string]interface{}{
+			"deadLetterTargetArn": arn,
+			"maxReceiveCount":     cfg.MaxReceiveCount,
+		})
+		return string(data), err
+	}).(pulumi.StringOutput)
+
+	queueArns := pulumi.StringArray{dlq.Arn}
+	urls := make([]interface{}, len(cfg.Tenants))
+	for i, tenant := range cfg.Tenants {
+		queue, err := sqs.NewQueue(ctx, s.ResourceName(fmt.Sprintf("tenant-%s-queue", tenant.Name)), &sqs.QueueArgs{
+			Name:                     pulumi.Sprintf("%s-%s-requests", stackName, tenant.Name),
+			VisibilityTimeoutSeconds: pulumi.Int(cfg.VisibilityTimeoutSeconds),
+			RedrivePolicy:            redrivePolicy,
+			SqsManagedSseEnabled:     pulumi.Bool(true),
+			Tags:                     tenantTags(tags, tenant.Name, pulumi.Sprintf("%s-%s-requests", stackName, tenant.Name)),
+		}, s.child())
+		if err != nil {
+			return err
+		}
+		queueArns = append(queueArns, queue.Arn)
+		urls[i] = queue.Url
+		s.TenantQueues[tenant.Name] = queue.Url
+	}
+
+	tenancyConfig := pulumi.All(urls...).ApplyT(func(args []interface{}) (string, error) {
+		schedule := make(map[string]tenantSchedule, len(cfg.Tenants))
+		for i, tenant := range cfg.Tenants {
+			schedule[tenant.Name] = tenantSchedule{
+				QueueURL:           args[i].(string),
+				Weight:             tenant.Weight,
+				Priority:           tenant.Priority,
+				MaxConcurrency:     tenant.MaxConcurrency,
+				RateLimitPerSecond: tenant.RateLimitPerSecond,
+			}
+		}
+		data, err := json.Marshal(map[string]interface{}{"tenants": schedule})
+		return string(data), err
+	}).(pulumi.StringOutput)
+
+	for _, name := range s.selectedAgents(cfg.Agents) {
+		s.injectEnv(name, "TENANCY_CONFIG", tenancyConfig)
+	}
+
+	return s.attachRolePolicy(ctx, "tenant-queue-policy", policyStatement{
+		Actions: []string{
+			"sqs:ReceiveMessage",
+			"sqs:DeleteMessage",
+			"sqs:ChangeMessageVisibility",
+			"sqs:GetQueueAttributes",
+			"sqs:SendMessage",
+		},
+		Resources: queueArns,
+	})
+}
@@ -0,0 +1,161 @@
+package testing
+
+import (
+	"encoding/json"
+	"path"
+	"sort"
+	"strings"
+	stdtesting "testing"
+)
+
+// policyInputs are the inputs holding IAM policy documents, by resource type.
+var policyInputs = map[string]string{
+	"aws:iam/policy:Policy":         "policy",
+	"aws:iam/rolePolicy:RolePolicy": "policy",
+	"aws:iam/userPolicy:UserPolicy": "policy",
+}
+
+// ResourceCount asserts that the stack registers n resources of a type.
+func ResourceCount(typeToken string, n int) Assertion {
+	return func(t stdtesting.TB, result *Result) {
+		t.Helper()
+		if got := len(result.OfType(typeToken)); got != n {
+			t.Errorf("expected %d %s resources, got %d", n, typeToken, got)
+		}
+	}
+}
+
+// HasResource asserts that the stack registers a resource with a type and
+// logical name.
+func HasResource(typeToken, name string) Assertion {
+	return func(t stdtesting.TB, result *Result) {
+		t.Helper()
+		if _, ok := result.Find(typeToken, name); !ok {
+			t.Errorf("expected %s resource %q", typeToken, name)
+		}
+	}
+}
+
+// PolicyAllows asserts that an IAM policy of the stack allows an action.
+// Wildcards in policies match, so "s3:*" allows "s3:GetObject".
+func PolicyAllows(action string) Assertion {
+	return func(t stdtesting.TB, result *Result) {
+		t.Helper()
+		if len(allowingPolicies(result, action)) == 0 {
+			t.Errorf("expected a policy allowing %s", action)
+		}
+	}
+}
+
+// NoPolicyAllows asserts that no IAM policy of the stack allows an action,
+// e.g. NoPolicyAllows("iam:PassRole").
+func NoPolicyAllows(action string) Assertion {
+	return func(t stdtesting.TB, result *Result) {
+		t.Helper()
+		if names := allowingPolicies(result, action); len(names) > 0 {
+			t.Errorf("expected no policy allowing %s, allowed by %s", action, strings.Join(names, ", "))
+		}
+	}
+}
+
+// TagsPropagated asserts that every resource with tags has a tag, including
+// the Cloud Control resources AgentCore runtimes and tools are created as.
+func TagsPropagated(key, value string) Assertion {
+	return func(t stdtesting.TB, result *Result) {
+		t.Helper()
+		for _, res := range result.Resources {
+			tags, ok := resourceTags(res)
+			if !ok {
+				continue
+			}
+			if got, ok := tags[key]; !ok || got != value {
+				t.Errorf("%s resource %q: expected tag %s=%s, got %v", res.Type, res.Name, key, value, got)
+			}
+		}
+	}
+}
+
+// resourceTags returns the tags of a resource, if it has any.
+func resourceTags(res Resource) (map[string]interface{}, bool) {
+	if tags, ok := res.Inputs["tags"].(map[string]interface{}); ok {
+		return tags, true
+	}
+	desiredState, ok := res.Inputs["desiredState"].(string)
+	if !ok {
+		return nil, false
+	}
+	var properties struct {
+		Tags json.RawMessage `json:"Tags"`
+	}
+	if json.Unmarshal([]byte(desiredState), &properties) != nil || len(properties.Tags) == 0 {
+		return nil, false
+	}
+	var tags map[string]interface{}
+	if json.Unmarshal(properties.Tags, &tags) == nil {
+		return tags, true
+	}
+	// Some Cloud Control types take tags as a Key/Value list.
+	var list []struct {
+		Key   string `json:"Key"`
+		Value string `json:"Value"`
+	}
+	if json.Unmarshal(properties.Tags, &list) != nil {
+		return nil, false
+	}
+	tags = make(map[string]interface{}, len(list))
+	for _, tag := range list {
+		tags[tag.Key] = tag.Value
+	}
+	return tags, true
+}
+
+// allowingPolicies returns the names of the policies allowing an action.
+func allowingPolicies(result *Result, action string) []string {
+	var names []string
+	for _, res := range result.Resources {
+		input, ok := policyInputs[res.Type]
+		if !ok {
+			continue
+		}
+		document, _ := res.Inputs[input].(string)
+		if policyAllows(document, action) {
+			names = append(names, res.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// policyAllows reports whether a policy document has an Allow statement
+// for an action.
+func policyAllows(document, action string) bool {
+	var policy struct {
+		Statement []struct {
+			Effect string          `json:"Effect"`
+			Action json.RawMessage `json:"Action"`
+		} `json:"Statement"`
+	}
+	if err := json.Unmarshal([]byte(document), &policy); err != nil {
+		return false
+	}
+	for _, stmt := range policy.Statement {
+		if stmt.Effect != "Allow" {
+			continue
+		}
+		var actions []string
+		if err := json.Unmarshal(stmt.Action, &actions); err != nil {
+			var single string
+			if json.Unmarshal(stmt.Action, &single) != nil {
+				continue
+			}
+			actions = []string{single}
+		}
+		for _, pattern := range actions {
+			// IAM actions are case-insensitive.
+			if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(action)); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,211 @@
+// Package testing unit tests AgentCore stacks without AWS credentials. It
+// runs a stack against Pulumi mocks, records the resources it registers and
+// checks them with assertions and golden snapshots:
+//
+//	func TestStack(t *testing.T) {
+//		builder := agentcore.NewStackBuilder("research").
+//			WithSimpleAgent("research", "123456789012.dkr.ecr.us-east-1.amazonaws.com/research:1")
+//		agentcoretesting.RunBuilderTest(t, builder,
+//			agentcoretesting.ResourceCount("aws:iam/role:Role", 1),
+//			agentcoretesting.PolicyAllows("bedrock:InvokeModel"),
+//			agentcoretesting.TagsPropagated("ManagedBy", "agentkit-pulumi"),
+//			agentcoretesting.MatchesSnapshot("testdata/research.json"),
+//		)
+//	}
+package testing
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// Default identity of the mocked AWS account.
+const (
+	DefaultRegion    = "us-east-1"
+	DefaultAccountID = "123456789012"
+)
+
+// Resource is a resource registered by a stack under test.
+type Resource struct {
+	// Type is the resource's type token, e.g. "aws:iam/role:Role".
+	Type string `json:"type"`
+
+	// Name is the resource's logical name.
+	Name string `json:"name"`
+
+	// Inputs are the resource's inputs as plain Go values.
+	Inputs map[string]interface{} `json:"inputs,omitempty"`
+}
+
+// Mocks is a pulumi.MockResourceMonitor that records registered resources.
+// Resources echo their inputs as outputs, with an ID and an ARN added, and
+// provider functions return plausible values for the mocked account.
+type Mocks struct {
+	// Region is the mocked region.
+	// Default: DefaultRegion
+	Region string
+
+	// AccountID is the mocked account ID.
+	// Default: DefaultAccountID
+	AccountID string
+
+	// Calls overrides the results of provider functions by token, e.g.
+	// "aws:ec2/getVpc:getVpc".
+	Calls map[string]func(args pulumi.MockCallArgs) (resource.PropertyMap, error)
+
+	mu        sync.Mutex
+	resources []Resource
+}
+
+// Resources returns the resources registered so far, in registration order.
+func (m *Mocks) Resources() []Resource {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Resource(nil), m.resources...)
+}
+
+// NewResource records a resource and returns its inputs as its state.
+// Providers are not recorded.
+func (m *Mocks) NewResource(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+	if !strings.HasPrefix(args.TypeToken, "pulumi:providers:") {
+		m.mu.Lock()
+		m.resources = append(m.resources, Resource{
+			Type:   args.TypeToken,
+			Name:   args.Name,
+			Inputs: plain(args.Inputs),
+		})
+		m.mu.Unlock()
+	}
+
+	id := args.Name + "-id"
+	if args.ID != "" {
+		id = args.ID
+	}
+
+	state := args.Inputs.Copy()
+	if !args.Custom {
+		return id, state, nil
+	}
+	if _, ok := state["arn"]; !ok {
+		state["arn"] = resource.NewStringProperty(m.arn(args.TypeToken, args.Name))
+	}
+	if _, ok := state["name"]; !ok {
+		state["name"] = resource.NewStringProperty(args.Name)
+	}
+	// Cloud Control resources report their desired state as properties.
+	if desired, ok := state["desiredState"]; ok {
+		state["properties"] = desired
+	}
+	// Certificates report a DNS validation record, which stacks index.
+	if args.TypeToken == "aws:acm/certificate:Certificate" {
+		domain, _ := state["domainName"].V.(string)
+		state["domainValidationOptions"] = resource.NewArrayProperty([]resource.PropertyValue{
+			resource.NewObjectProperty(resource.NewPropertyMapFromMap(map[string]interface{}{
+				"domainName":          domain,
+				"resourceRecordName":  "_validation." + strings.TrimPrefix(domain, "*."),
+				"resourceRecordType":  "CNAME",
+				"resourceRecordValue": "_validation.acm-validations.aws",
+			})),
+		})
+	}
+	// Serverless caches report their endpoint, which stacks index.
+	if args.TypeToken == "aws:elasticache/serverlessCache:ServerlessCache" {
+		endpoint := resource.NewObjectProperty(resource.NewPropertyMapFromMap(map[string]interface{}{
+			"address": fmt.Sprintf("%s.serverless.%s.cache.amazonaws.com", args.Name, m.region()),
+			"port":    6379,
+		}))
+		state["endpoints"] = resource.NewArrayProperty([]resource.PropertyValue{endpoint})
+		state["readerEndpoints"] = resource.NewArrayProperty([]resource.PropertyValue{endpoint})
+	}
+	return id, state, nil
+}
+
+// Call returns the result of a provider function.
+func (m *Mocks) Call(args pulumi.MockCallArgs) (resource.PropertyMap, error) {
+	if call, ok := m.Calls[args.Token]; ok {
+		return call(args)
+	}
+
+	region, accountID := m.region(), m.accountID()
+	switch args.Token {
+	case "aws:index/getRegion:getRegion":
+		return resource.NewPropertyMapFromMap(map[string]interface{}{
+			"id":          region,
+			"name":        region,
+			"description": region,
+			"endpoint":    fmt.Sprintf("ec2.%s.amazonaws.com", region),
+		}), nil
+	case "aws:index/getCallerIdentity:getCallerIdentity":
+		return resource.NewPropertyMapFromMap(map[string]interface{}{
+			"id":        accountID,
+			"accountId": accountID,
+			"arn":       fmt.Sprintf("arn:aws:iam::%s:user/test", accountID),
+			"userId":    "AIDATEST",
+		}), nil
+	case "aws:index/getPartition:getPartition":
+		return resource.NewPropertyMapFromMap(map[string]interface{}{
+			"id":        "aws",
+			"partition": "aws",
+			"dnsSuffix": "amazonaws.com",
+		}), nil
+	case "aws:index/getAvailabilityZones:getAvailabilityZones":
+		return resource.NewPropertyMapFromMap(map[string]interface{}{
+			"id":      region,
+			"names":   []interface{}{region + "a", region + "b", region + "c"},
+			"zoneIds": []interface{}{"use1-az1", "use1-az2", "use1-az3"},
+		}), nil
+	case "aws:index/getService:getService":
+		result := args.Args.Copy()
+		result["supported"] = resource.NewBoolProperty(true)
+		return result, nil
+	}
+
+	// Other functions echo their arguments, with an ARN for lookups.
+	result := args.Args.Copy()
+	if _, ok := result["arn"]; !ok {
+		result["arn"] = resource.NewStringProperty(m.arn(args.Token, "lookup"))
+	}
+	return result, nil
+}
+
+// arn returns a mock ARN for a resource of the given type.
+func (m *Mocks) arn(token, name string) string {
+	service := "mock"
+	if parts := strings.Split(token, ":"); len(parts) == 3 {
+		service = strings.SplitN(parts[1], "/", 2)[0]
+	}
+	region := m.region()
+	if service == "iam" {
+		region = ""
+	}
+	return fmt.Sprintf("arn:aws:%s:%s:%s:%s", service, region, m.accountID(), name)
+}
+
+func (m *Mocks) region() string {
+	if m.Region != "" {
+		return m.Region
+	}
+	return DefaultRegion
+}
+
+func (m *Mocks) accountID() string {
+	if m.AccountID != "" {
+		return m.AccountID
+	}
+	return DefaultAccountID
+}
+
+// plain returns properties as plain Go values, with secrets redacted so
+// they never reach snapshots.
+func plain(properties resource.PropertyMap) map[string]interface{} {
+	return properties.MapRepl(nil, func(v resource.PropertyValue) (interface{}, bool) {
+		if v.IsSecret() {
+			return "[secret]", true
+		}
+		return nil, false
+	})
+}
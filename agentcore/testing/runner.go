@@ -0,0 +1,92 @@
+package testing
+
+import (
+	"fmt"
+	stdtesting "testing"
+
+	"github.com/plexusone/agentkit-aws-pulumi/agentcore"
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// ProjectName is the Pulumi project stacks under test run in.
+const ProjectName = "agentcore"
+
+// Result is the outcome of running a stack against mocks.
+type Result struct {
+	// Stack is the stack under test.
+	Stack *agentcore.AgentCoreStack
+
+	// Resources are the resources the stack registered, in registration
+	// order.
+	Resources []Resource
+}
+
+// OfType returns the resources of a type, e.g. "aws:iam/role:Role".
+func (r *Result) OfType(typeToken string) []Resource {
+	var resources []Resource
+	for _, res := range r.Resources {
+		if res.Type == typeToken {
+			resources = append(resources, res)
+		}
+	}
+	return resources
+}
+
+// Find returns the resource with a type and logical name.
+func (r *Result) Find(typeToken, name string) (Resource, bool) {
+	for _, res := range r.Resources {
+		if res.Type == typeToken && res.Name == name {
+			return res, true
+		}
+	}
+	return Resource{}, false
+}
+
+// Assertion checks the result of a stack test.
+type Assertion func(t stdtesting.TB, result *Result)
+
+// Run runs the stack against mocks and returns the resources it registered.
+// A nil mocks uses the defaults.
+func Run(config iac.StackConfig, options agentcore.Options, mocks *Mocks) (*Result, error) {
+	if mocks == nil {
+		mocks = &Mocks{}
+	}
+	var stack *agentcore.AgentCoreStack
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		var err error
+		stack, err = agentcore.NewAgentCoreStackWithOptions(ctx, config, options)
+		return err
+	}, pulumi.WithMocks(ProjectName, config.StackName, mocks))
+	if err != nil {
+		return nil, fmt.Errorf("stack %s failed: %w", config.StackName, err)
+	}
+	return &Result{Stack: stack, Resources: mocks.Resources()}, nil
+}
+
+// RunStackTest runs the stack with default options against mocks and
+// checks the assertions. It fails the test if the stack fails.
+func RunStackTest(t stdtesting.TB, config iac.StackConfig, asserts ...Assertion) *Result {
+	t.Helper()
+	return RunStackTestWithOptions(t, config, agentcore.Options{}, asserts...)
+}
+
+// RunStackTestWithOptions is RunStackTest with Pulumi-specific options.
+func RunStackTestWithOptions(t stdtesting.TB, config iac.StackConfig, options agentcore.Options, asserts ...Assertion) *Result {
+	t.Helper()
+	result, err := Run(config, options, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, assert := range asserts {
+		assert(t, result)
+	}
+	return result
+}
+
+// RunBuilderTest runs the stack a StackBuilder configures against mocks and
+// checks the assertions.
+func RunBuilderTest(t stdtesting.TB, builder *agentcore.StackBuilder, asserts ...Assertion) *Result {
+	t.Helper()
+	return RunStackTestWithOptions(t, builder.Config(), builder.Options(), asserts...)
+}
@@ -0,0 +1,146 @@
+package testing
+
+import (
+	"encoding/json"
+	stdtesting "testing"
+
+	"github.com/plexusone/agentkit-aws-pulumi/agentcore"
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+)
+
+// testStackConfig returns a two-agent stack in a new VPC, which components
+// that need private subnets run in.
+func testStackConfig() iac.StackConfig {
+	config := agentcore.NewStackBuilder("research").
+		WithSimpleAgent("research", "123456789012.dkr.ecr.us-east-1.amazonaws.com/research:1").
+		WithSimpleAgent("writer", "123456789012.dkr.ecr.us-east-1.amazonaws.com/writer:1").
+		WithNewVPC("10.0.0.0/16", 2).
+		Config()
+	config.Agents[0].SecretsARNs = []string{"arn:aws:secretsmanager:us-east-1:123456789012:secret:research/api-key"}
+	return config
+}
+
+// components enables each stack component with a minimal configuration,
+// and names a resource type the component creates.
+var components = []struct {
+	name     string
+	enable   func(options *agentcore.Options)
+	resource string
+}{
+	{"cache", func(o *agentcore.Options) { o.Cache = agentcore.DefaultCacheConfig() },
+		"aws:elasticache/serverlessCache:ServerlessCache"},
+	{"state table", func(o *agentcore.Options) { o.StateTable = agentcore.DefaultStateTableConfig() },
+		"aws:dynamodb/table:Table"},
+	{"artifact bucket", func(o *agentcore.Options) { o.ArtifactBucket = agentcore.DefaultArtifactBucketConfig() },
+		"aws:s3/bucketV2:BucketV2"},
+	{"code interpreter", func(o *agentcore.Options) { o.CodeInterpreter = agentcore.DefaultCodeInterpreterConfig() },
+		"aws:cloudcontrol/resource:Resource"},
+	{"browser", func(o *agentcore.Options) { o.Browser = agentcore.DefaultBrowserToolConfig() },
+		"aws:cloudcontrol/resource:Resource"},
+	{"resilience", func(o *agentcore.Options) { o.Resilience = agentcore.DefaultResiliencePolicy() },
+		""},
+	{"tool calls", func(o *agentcore.Options) { o.ToolCalls = agentcore.DefaultToolCallPolicy() },
+		""},
+	{"tenancy", func(o *agentcore.Options) {
+		o.Tenancy = &agentcore.TenancyConfig{Tenants: []agentcore.TenantConfig{{Name: "acme"}}, Isolation: true}
+	}, "aws:sqs/queue:Queue"},
+	{"graph store", func(o *agentcore.Options) { o.GraphStore = agentcore.DefaultGraphStoreConfig() },
+		"aws:neptune/cluster:Cluster"},
+	{"vector store", func(o *agentcore.Options) { o.VectorStore = &agentcore.VectorStoreConfig{Engine: "aurora-pgvector"} },
+		"aws:rds/cluster:Cluster"},
+	{"retrieval", func(o *agentcore.Options) { o.Retrieval = agentcore.DefaultRetrievalConfig() },
+		""},
+	{"audit", func(o *agentcore.Options) { o.Audit = agentcore.DefaultAuditConfig() },
+		""},
+	{"reports", func(o *agentcore.Options) {
+		o.Reports = &agentcore.ReportConfig{Agent: "writer", Schedule: "cron(0 8 ? * MON *)"}
+	}, "aws:scheduler/schedule:Schedule"},
+	{"approval", func(o *agentcore.Options) { o.Approval = agentcore.DefaultApprovalConfig() },
+		""},
+	{"workflows", func(o *agentcore.Options) {
+		o.Workflows = []*agentcore.WorkflowConfig{{
+			Name: "research-then-write",
+			Steps: []agentcore.WorkflowStep{
+				{Name: "research", Agent: "research"},
+				{Name: "write", Agent: "writer", DependsOn: []string{"research"}},
+			},
+		}}
+	}, "aws:sfn/stateMachine:StateMachine"},
+	{"appsync", func(o *agentcore.Options) { o.AppSync = &agentcore.AppSyncConfig{} },
+		"aws:appsync/graphQLApi:GraphQLApi"},
+	{"waf", func(o *agentcore.Options) {
+		o.AppSync = &agentcore.AppSyncConfig{}
+		o.WAF = &agentcore.WAFConfig{}
+	}, "aws:wafv2/webAcl:WebAcl"},
+	{"per-agent log groups", func(o *agentcore.Options) { o.PerAgentLogGroups = true },
+		"aws:cloudwatch/logGroup:LogGroup"},
+	{"logging", func(o *agentcore.Options) { o.Logging = agentcore.DefaultLoggingConfig() },
+		""},
+	{"log archive", func(o *agentcore.Options) { o.LogArchive = agentcore.DefaultLogArchiveConfig() },
+		""},
+	{"health", func(o *agentcore.Options) { o.Health = agentcore.DefaultHealthConfig() },
+		""},
+	{"guardrail", func(o *agentcore.Options) { o.Guardrail = &agentcore.GuardrailConfig{ProfanityFilter: true} },
+		"aws:bedrock/guardrail:Guardrail"},
+	{"prompt monitoring", func(o *agentcore.Options) { o.PromptMonitoring = agentcore.DefaultPromptMonitoringConfig() },
+		""},
+	{"registry", func(o *agentcore.Options) { o.Registry = &agentcore.RegistryConfig{Owner: "research-team"} },
+		"aws:servicecatalog/appregistryApplication:AppregistryApplication"},
+	{"secrets audit", func(o *agentcore.Options) { o.SecretsAudit = agentcore.DefaultSecretsAuditConfig() },
+		""},
+	{"key rotation", func(o *agentcore.Options) { o.KeyRotation = agentcore.DefaultKeyRotationConfig() },
+		""},
+	{"guardduty", func(o *agentcore.Options) { o.GuardDuty = &agentcore.GuardDutyConfig{} },
+		""},
+	{"security hub", func(o *agentcore.Options) { o.SecurityHub = &agentcore.SecurityHubConfig{} },
+		""},
+	{"alarms", func(o *agentcore.Options) { o.Alarms = agentcore.DefaultAlarmsConfig() },
+		""},
+	{"dashboard", func(o *agentcore.Options) { o.Dashboard = &agentcore.DashboardConfig{} },
+		"aws:cloudwatch/dashboard:Dashboard"},
+	{"kms", func(o *agentcore.Options) { o.KMS = &agentcore.KMSConfig{} },
+		"aws:kms/key:Key"},
+	{"quota report", func(o *agentcore.Options) { o.QuotaReport = &agentcore.QuotaReportConfig{} },
+		""},
+	{"feature flags", func(o *agentcore.Options) {
+		o.FeatureFlags = &agentcore.FeatureFlagConfig{Flags: map[string]agentcore.FeatureFlag{"beta": {}}}
+	}, ""},
+	{"topology", func(o *agentcore.Options) { o.Topology = &agentcore.TopologyConfig{} },
+		""},
+	{"maintenance", func(o *agentcore.Options) { o.Maintenance = &agentcore.MaintenanceConfig{} },
+		""},
+	{"work queue", func(o *agentcore.Options) { o.WorkQueue = &agentcore.WorkQueueConfig{} },
+		"aws:sqs/queue:Queue"},
+	{"agent options", func(o *agentcore.Options) {
+		o.Agents = map[string]agentcore.AgentOptions{
+			"research": {
+				Replicas:         2,
+				LogRetentionDays: 7,
+				OnFailure:        &agentcore.FailureDestination{},
+				Schedules:        []agentcore.AgentSchedule{{Expression: "rate(1 hour)", Payload: json.RawMessage(`{}`)}},
+			},
+			"writer": {BlueGreen: &agentcore.BlueGreenConfig{}},
+		}
+	}, "aws:scheduler/schedule:Schedule"},
+}
+
+func TestRunStackTest(t *stdtesting.T) {
+	RunStackTest(t, testStackConfig(),
+		ResourceCount("aws:iam/role:Role", 2),
+		PolicyAllows("bedrock:InvokeModel"),
+		TagsPropagated("ManagedBy", "agentkit-pulumi"),
+	)
+}
+
+func TestComponents(t *stdtesting.T) {
+	for _, tt := range components {
+		t.Run(tt.name, func(t *stdtesting.T) {
+			var options agentcore.Options
+			tt.enable(&options)
+			result := RunStackTestWithOptions(t, testStackConfig(), options)
+			if tt.resource != "" && len(result.OfType(tt.resource)) == 0 {
+				t.Errorf("%s created no %s", tt.name, tt.resource)
+			}
+		})
+	}
+}
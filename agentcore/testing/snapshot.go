@@ -0,0 +1,114 @@
+package testing
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	stdtesting "testing"
+)
+
+// UpdateSnapshotsEnv is the environment variable that makes MatchesSnapshot
+// write snapshots instead of comparing them, e.g.
+// AGENTCORE_UPDATE_SNAPSHOTS=1 go test ./...
+const UpdateSnapshotsEnv = "AGENTCORE_UPDATE_SNAPSHOTS"
+
+// Snapshot returns the resources as indented JSON, sorted by type and name
+// so it does not depend on registration order.
+func (r *Result) Snapshot() ([]byte, error) {
+	resources := sortedResources(r.Resources)
+	data, err := json.MarshalIndent(resources, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// MatchesSnapshot asserts that the stack's resources match the golden
+// snapshot at path. A missing snapshot is written, as are all snapshots
+// when UpdateSnapshotsEnv is set; review and commit them.
+func MatchesSnapshot(path string) Assertion {
+	return func(t stdtesting.TB, result *Result) {
+		t.Helper()
+		got, err := result.Snapshot()
+		if err != nil {
+			t.Fatalf("failed to create snapshot: %v", err)
+		}
+
+		want, err := os.ReadFile(path)
+		if os.Getenv(UpdateSnapshotsEnv) != "" || errors.Is(err, fs.ErrNotExist) {
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				t.Fatalf("failed to write snapshot: %v", err)
+			}
+			if err := os.WriteFile(path, got, 0o644); err != nil {
+				t.Fatalf("failed to write snapshot: %v", err)
+			}
+			t.Logf("wrote snapshot %s", path)
+			return
+		}
+		if err != nil {
+			t.Fatalf("failed to read snapshot: %v", err)
+		}
+
+		if !bytes.Equal(got, want) {
+			t.Errorf("resources do not match snapshot %s (set %s=1 to update):\n%s",
+				path, UpdateSnapshotsEnv, snapshotDiff(want, got))
+		}
+	}
+}
+
+// snapshotDiff lists the resources added, removed and changed between two
+// snapshots.
+func snapshotDiff(want, got []byte) string {
+	var before, after []Resource
+	if json.Unmarshal(want, &before) != nil || json.Unmarshal(got, &after) != nil {
+		return "snapshot is not a resource list"
+	}
+
+	index := func(resources []Resource) map[string]string {
+		m := make(map[string]string, len(resources))
+		for _, res := range resources {
+			inputs, _ := json.Marshal(res.Inputs)
+			m[res.Type+" "+res.Name] = string(inputs)
+		}
+		return m
+	}
+	beforeIndex, afterIndex := index(before), index(after)
+
+	var lines []string
+	for key, inputs := range afterIndex {
+		if old, ok := beforeIndex[key]; !ok {
+			lines = append(lines, "+ "+key)
+		} else if old != inputs {
+			lines = append(lines, "~ "+key)
+		}
+	}
+	for key := range beforeIndex {
+		if _, ok := afterIndex[key]; !ok {
+			lines = append(lines, "- "+key)
+		}
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i][2:] < lines[j][2:] })
+
+	var buf bytes.Buffer
+	for _, line := range lines {
+		fmt.Fprintln(&buf, line)
+	}
+	return buf.String()
+}
+
+// sortedResources returns a copy of resources sorted by type and name.
+func sortedResources(resources []Resource) []Resource {
+	sorted := append([]Resource(nil), resources...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Type != sorted[j].Type {
+			return sorted[i].Type < sorted[j].Type
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
@@ -0,0 +1,309 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/cloudwatch"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// toolNamePattern matches external tool names, which are used in alarm names.
+var toolNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// toolLogField is the structured log field naming the external tool called.
+const toolLogField = "tool"
+
+// ToolCallPolicy limits how often agents call external tools, such as
+// search or scraping APIs billed per request. The policy is injected into
+// the agents as JSON in the TOOL_CALL_POLICY environment variable; agents
+// enforce it client-side and log each call as
+// {"event": "tool_call", "tool": ...}, and each call refused by a limit as
+// {"event": "tool_rate_limited", "tool": ...}.
+//
+// Alarms fire when an agent keeps hitting a tool's rate limit, which
+// usually means it is looping, and when a tool's daily usage nears its
+// MaxCallsPerDay budget.
+type ToolCallPolicy struct {
+	// Default is the limit of tools not listed in Tools. Unset fields of
+	// the limits in Tools are also taken from it.
+	Default *ToolLimit `json:"default,omitempty" yaml:"default,omitempty"`
+
+	// Tools are per-tool limits keyed by the tool name agents log.
+	Tools map[string]*ToolLimit `json:"tools,omitempty" yaml:"tools,omitempty"`
+
+	// RetryBudgetPercent caps retries at this percentage of an agent's
+	// recent calls to a tool, so retries cannot multiply load during an
+	// outage.
+	// Range: 0-100
+	// Default: 20
+	RetryBudgetPercent int `json:"retryBudgetPercent,omitempty" yaml:"retryBudgetPercent,omitempty"`
+
+	// Jitter randomizes retry delays so agents do not retry in lockstep.
+	// Supported: "full", "equal", "none"
+	// Default: "full"
+	Jitter string `json:"jitter,omitempty" yaml:"jitter,omitempty"`
+
+	// AlarmPercent is the percentage of MaxCallsPerDay at which the usage
+	// alarm fires.
+	// Range: 1-100
+	// Default: 80
+	AlarmPercent int `json:"alarmPercent,omitempty" yaml:"alarmPercent,omitempty"`
+
+	// AlarmActions are ARNs notified when an alarm fires.
+	AlarmActions []string `json:"alarmActions,omitempty" yaml:"alarmActions,omitempty"`
+
+	// Agents are the agents the policy applies to. If empty, all agents.
+	Agents []string `json:"agents,omitempty" yaml:"agents,omitempty"`
+}
+
+// ToolLimit limits calls to one external tool.
+type ToolLimit struct {
+	// RequestsPerMinute is the sustained call rate allowed per agent.
+	// Default: 60
+	RequestsPerMinute int `json:"requestsPerMinute,omitempty" yaml:"requestsPerMinute,omitempty"`
+
+	// Burst is the number of calls allowed at once above the sustained rate.
+	// Default: 10
+	Burst int `json:"burst,omitempty" yaml:"burst,omitempty"`
+
+	// MaxRetries is the number of retries after a failed call, within the
+	// policy's retry budget.
+	// Default: 2
+	MaxRetries int `json:"maxRetries,omitempty" yaml:"maxRetries,omitempty"`
+
+	// MaxCallsPerDay refuses calls beyond this many per agent per UTC day.
+	// Zero means unlimited.
+	// Default: 0
+	MaxCallsPerDay int `json:"maxCallsPerDay,omitempty" yaml:"maxCallsPerDay,omitempty"`
+}
+
+// DefaultToolCallPolicy returns a ToolCallPolicy with sensible defaults.
+func DefaultToolCallPolicy() *ToolCallPolicy {
+	policy := &ToolCallPolicy{}
+	policy.ApplyDefaults()
+	return policy
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (p *ToolCallPolicy) ApplyDefaults() {
+	if p.Default == nil {
+		p.Default = &ToolLimit{}
+	}
+	if p.Default.RequestsPerMinute == 0 {
+		p.Default.RequestsPerMinute = 60
+	}
+	if p.Default.Burst == 0 {
+		p.Default.Burst = 10
+	}
+	if p.Default.MaxRetries == 0 {
+		p.Default.MaxRetries = 2
+	}
+	for name, limit := range p.Tools {
+		if limit == nil {
+			limit = &ToolLimit{}
+			p.Tools[name] = limit
+		}
+		if limit.RequestsPerMinute == 0 {
+			limit.RequestsPerMinute = p.Default.RequestsPerMinute
+		}
+		if limit.Burst == 0 {
+			limit.Burst = p.Default.Burst
+		}
+		if limit.MaxRetries == 0 {
+			limit.MaxRetries = p.Default.MaxRetries
+		}
+		if limit.MaxCallsPerDay == 0 {
+			limit.MaxCallsPerDay = p.Default.MaxCallsPerDay
+		}
+	}
+	if p.RetryBudgetPercent == 0 {
+		p.RetryBudgetPercent = 20
+	}
+	if p.Jitter == "" {
+		p.Jitter = "full"
+	}
+	if p.AlarmPercent == 0 {
+		p.AlarmPercent = 80
+	}
+}
+
+// Validate validates the ToolCallPolicy.
+func (p *ToolCallPolicy) Validate(config iac.StackConfig) error {
+	if err := p.Default.validate("toolCalls.default"); err != nil {
+		return err
+	}
+	for name, limit := range p.Tools {
+		if !toolNamePattern.MatchString(name) {
+			return fmt.Errorf("toolCalls.tools: '%s' is not a valid tool name", name)
+		}
+		if err := limit.validate(fmt.Sprintf("toolCalls.tools[%s]", name)); err != nil {
+			return err
+		}
+	}
+	if p.RetryBudgetPercent < 0 || p.RetryBudgetPercent > 100 {
+		return fmt.Errorf("toolCalls.retryBudgetPercent must be between 0 and 100")
+	}
+	if !slices.Contains([]string{"full", "equal", "none"}, p.Jitter) {
+		return fmt.Errorf("toolCalls.jitter must be one of [full equal none]")
+	}
+	if p.AlarmPercent < 1 || p.AlarmPercent > 100 {
+		return fmt.Errorf("toolCalls.alarmPercent must be between 1 and 100")
+	}
+	return validateAgentNames("toolCalls.agents", p.Agents, config)
+}
+
+// validate validates a ToolLimit reported under field.
+func (l *ToolLimit) validate(field string) error {
+	if l.RequestsPerMinute < 1 {
+		return fmt.Errorf("%s.requestsPerMinute must be at least 1", field)
+	}
+	if l.Burst < 1 {
+		return fmt.Errorf("%s.burst must be at least 1", field)
+	}
+	if l.MaxRetries < 0 || l.MaxRetries > 10 {
+		return fmt.Errorf("%s.maxRetries must be between 0 and 10", field)
+	}
+	if l.MaxCallsPerDay < 0 {
+		return fmt.Errorf("%s.maxCallsPerDay must not be negative", field)
+	}
+	return nil
+}
+
+// applyToolCallPolicy injects the policy into the selected agents and
+// creates usage alarms for the listed tools from the agents' structured logs.
+func (s *AgentCoreStack) applyToolCallPolicy(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	stackName := s.Config.StackName
+	policy := *s.Options.ToolCalls
+	policy.AlarmActions = nil
+	policy.Agents = nil
+
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+	agents := s.selectedAgents(s.Options.ToolCalls.Agents)
+	for _, agentName := range agents {
+		s.injectEnv(agentName, "TOOL_CALL_POLICY", pulumi.String(string(data)))
+	}
+
+	// Count calls and refusals in each log group the agents write to,
+	// keyed by agent name or "" for the shared group
+	fields := s.logging().Fields
+	groups := map[string]pulumi.StringInput{}
+	for _, agentName := range agents {
+		if s.hasAgentLogGroup(agentName) {
+			groups[agentName] = s.AgentLogGroups[agentName]
+		} else if s.LogGroup != nil {
+			groups[""] = s.LogGroup.Name
+		}
+	}
+	groupKeys := make([]string, 0, len(groups))
+	for key := range groups {
+		groupKeys = append(groupKeys, key)
+	}
+	sort.Strings(groupKeys)
+	for _, key := range groupKeys {
+		for _, m := range []struct{ event, metric string }{
+			{"tool_call", "ToolCalls"},
+			{"tool_rate_limited", "ToolCallsRateLimited"},
+		} {
+			name := strings.ReplaceAll(m.event, "_", "-")
+			if key != "" {
+				name = fmt.Sprintf("%s-%s", key, name)
+			}
+			_, err = cloudwatch.NewLogMetricFilter(ctx, s.ResourceName(name+"-filter"), &cloudwatch.LogMetricFilterArgs{
+				Name:         pulumi.Sprintf("%s-%s", stackName, name),
+				LogGroupName: groups[key],
+				Pattern:      pulumi.Sprintf(`{ $.%s = "%s" }`, fields.Event, m.event),
+				MetricTransformation: &cloudwatch.LogMetricFilterMetricTransformationArgs{
+					Name:      pulumi.String(m.metric),
+					Namespace: pulumi.String(s.metricNamespace()),
+					Value:     pulumi.String("1"),
+					Dimensions: pulumi.StringMap{
+						"Agent": pulumi.String("$." + fields.Agent),
+						"Tool":  pulumi.String("$." + toolLogField),
+					},
+				},
+			}, s.child())
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if len(groupKeys) == 0 {
+		return nil
+	}
+
+	tools := make([]string, 0, len(policy.Tools))
+	for tool := range policy.Tools {
+		tools = append(tools, tool)
+	}
+	sort.Strings(tools)
+
+	for _, agentName := range agents {
+		if !s.hasAgentLogGroup(agentName) && s.LogGroup == nil {
+			continue
+		}
+		for _, tool := range tools {
+			limit := policy.Tools[tool]
+			dimensions := pulumi.StringMap{
+				"Agent": pulumi.String(agentName),
+				"Tool":  pulumi.String(tool),
+			}
+
+			// Refusals in three consecutive periods mean the agent keeps
+			// calling the tool rather than backing off
+			loopName := fmt.Sprintf("%s-%s-%s-tool-looping", stackName, agentName, tool)
+			_, err = cloudwatch.NewMetricAlarm(ctx, s.ResourceName(fmt.Sprintf("%s-%s-tool-looping-alarm", agentName, tool)), &cloudwatch.MetricAlarmArgs{
+				Name:               pulumi.String(loopName),
+				AlarmDescription:   pulumi.Sprintf("Agent %s keeps hitting the %s rate limit", agentName, tool),
+				Namespace:          pulumi.String(s.metricNamespace()),
+				MetricName:         pulumi.String("ToolCallsRateLimited"),
+				Dimensions:         dimensions,
+				Statistic:          pulumi.String("Sum"),
+				Period:             pulumi.Int(300),
+				EvaluationPeriods:  pulumi.Int(3),
+				Threshold:          pulumi.Float64(1),
+				ComparisonOperator: pulumi.String("GreaterThanOrEqualToThreshold"),
+				TreatMissingData:   pulumi.String("notBreaching"),
+				AlarmActions:       toArray(s.Options.ToolCalls.AlarmActions),
+				Tags:               mergeTags(tags, pulumi.String(loopName)),
+			}, s.child())
+			if err != nil {
+				return err
+			}
+
+			if limit.MaxCallsPerDay == 0 {
+				continue
+			}
+			usageName := fmt.Sprintf("%s-%s-%s-tool-usage", stackName, agentName, tool)
+			_, err = cloudwatch.NewMetricAlarm(ctx, s.ResourceName(fmt.Sprintf("%s-%s-tool-usage-alarm", agentName, tool)), &cloudwatch.MetricAlarmArgs{
+				Name: pulumi.String(usageName),
+				AlarmDescription: pulumi.Sprintf("Agent %s used %d%% of its daily %s budget of %d calls",
+					agentName, policy.AlarmPercent, tool, limit.MaxCallsPerDay),
+				Namespace:          pulumi.String(s.metricNamespace()),
+				MetricName:         pulumi.String("ToolCalls"),
+				Dimensions:         dimensions,
+				Statistic:          pulumi.String("Sum"),
+				Period:             pulumi.Int(86400),
+				EvaluationPeriods:  pulumi.Int(1),
+				Threshold:          pulumi.Float64(float64(limit.MaxCallsPerDay*policy.AlarmPercent) / 100),
+				ComparisonOperator: pulumi.String("GreaterThanOrEqualToThreshold"),
+				TreatMissingData:   pulumi.String("notBreaching"),
+				AlarmActions:       toArray(s.Options.ToolCalls.AlarmActions),
+				Tags:               mergeTags(tags, pulumi.String(usageName)),
+			}, s.child())
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package agentcore
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// validateToolNetworkMode validates the network mode of an AgentCore built-in tool.
+func validateToolNetworkMode(field, mode string, allowed []string, config iac.StackConfig) error {
+	if !slices.Contains(allowed, mode) {
+		return fmt.Errorf("%s.networkMode must be one of %v", field, allowed)
+	}
+	if mode == "VPC" && !hasVPC(config) {
+		return fmt.Errorf("%s.networkMode VPC requires a VPC (vpc.createVPC or vpc.vpcId)", field)
+	}
+	return nil
+}
+
+// toolNetworkConfiguration builds the NetworkConfiguration property of an AgentCore built-in tool.
+func (s *AgentCoreStack) toolNetworkConfiguration(mode string) pulumi.Map {
+	network := pulumi.Map{
+		"NetworkMode": pulumi.String(mode),
+	}
+	if mode == "VPC" {
+		network["VpcConfig"] = pulumi.Map{
+			"SecurityGroups": pulumi.StringArray{s.SecurityGroup.ID()},
+			"Subnets":        s.privateSubnetIDs(),
+		}
+	}
+	return network
+}
@@ -0,0 +1,63 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/sns"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// newEventTopic creates an SNS topic that EventBridge and CloudWatch can publish to.
+func (s *AgentCoreStack) newEventTopic(ctx *pulumi.Context, name, topicName string, tags pulumi.StringMap) (*sns.Topic, error) {
+	topic, err := sns.NewTopic(ctx, s.ResourceName(name), &sns.TopicArgs{
+		Name:           pulumi.String(topicName),
+		KmsMasterKeyId: s.kmsKeyID(),
+		Tags:           mergeTags(tags, pulumi.String(topicName)),
+	}, s.child())
+	if err != nil {
+		return nil, err
+	}
+
+	policy := topic.Arn.ApplyT(func(arn string) (string, error) {
+		data, err := json.Marshal(map[string]interface{}{
+			"Version": "2012-10-17",
+			"Statement": []map[string]interface{}{
+				{
+					"Effect": "Allow",
+					"Principal": map[string]interface{}{
+						"Service": []string{"events.amazonaws.com", "cloudwatch.amazonaws.com"},
+					},
+					"Action":   "sns:Publish",
+					"Resource": arn,
+				},
+			},
+		})
+		return string(data), err
+	}).(pulumi.StringOutput)
+
+	_, err = sns.NewTopicPolicy(ctx, s.ResourceName(name+"-policy"), &sns.TopicPolicyArgs{
+		Arn:    topic.Arn,
+		Policy: policy,
+	}, s.child())
+	if err != nil {
+		return nil, err
+	}
+
+	return topic, nil
+}
+
+// subscribeEmails subscribes email addresses to a topic.
+func (s *AgentCoreStack) subscribeEmails(ctx *pulumi.Context, name string, topic pulumi.StringInput, emails []string) error {
+	for i, email := range emails {
+		_, err := sns.NewTopicSubscription(ctx, s.ResourceName(fmt.Sprintf("%s-%d", name, i)), &sns.TopicSubscriptionArgs{
+			Topic:    topic,
+			Protocol: pulumi.String("email"),
+			Endpoint: pulumi.String(email),
+		}, s.child())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
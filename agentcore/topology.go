@@ -0,0 +1,313 @@
+package agentcore
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/appconfig"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/ssm"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// TopologyConfig publishes the resolved, non-secret stack configuration and
+// topology as a JSON Topology document, so agents and sidecars can discover
+// the other agents, their runtimes and URLs at runtime. The document is
+// updated on every deployment; readers pick up changes without redeploying.
+//
+// With the ssm target agents get TOPOLOGY_PARAMETER; with the appconfig
+// target they get TOPOLOGY_APPCONFIG_APPLICATION, TOPOLOGY_APPCONFIG_ENVIRONMENT
+// and TOPOLOGY_APPCONFIG_PROFILE for AppConfig Data sessions.
+type TopologyConfig struct {
+	// Target is where the topology is published.
+	// Supported: "ssm", "appconfig"
+	// Default: "ssm"
+	Target string `json:"target,omitempty" yaml:"target,omitempty"`
+
+	// ParameterName is the SSM parameter holding the topology.
+	// Default: "/agentcore/{stackName}/topology"
+	ParameterName string `json:"parameterName,omitempty" yaml:"parameterName,omitempty"`
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *TopologyConfig) ApplyDefaults() {
+	if c.Target == "" {
+		c.Target = "ssm"
+	}
+}
+
+// Validate validates the TopologyConfig.
+func (c *TopologyConfig) Validate() error {
+	if c.Target != "ssm" && c.Target != "appconfig" {
+		return fmt.Errorf("topology.target must be one of [ssm appconfig]")
+	}
+	if c.ParameterName != "" && c.Target != "ssm" {
+		return fmt.Errorf("topology.parameterName requires target ssm")
+	}
+	return nil
+}
+
+// Topology is the document published with TopologyConfig.
+type Topology struct {
+	// StackName is the stack name.
+	StackName string `json:"stackName"`
+
+	// Description is the stack description.
+	Description string `json:"description,omitempty"`
+
+	// Region is the stack's region.
+	Region string `json:"region"`
+
+	// Agents are the stack's agents, in configuration order.
+	Agents []TopologyAgent `json:"agents"`
+
+	// Gateways maps gateway names to their MCP URL.
+	Gateways map[string]string `json:"gateways,omitempty"`
+
+	// VPC is the network the agents run in, if any.
+	VPC *TopologyVPC `json:"vpc,omitempty"`
+}
+
+// TopologyAgent describes an agent in a Topology.
+type TopologyAgent struct {
+	Name           string            `json:"name"`
+	Description    string            `json:"description,omitempty"`
+	Protocol       string            `json:"protocol,omitempty"`
+	IsDefault      bool              `json:"isDefault,omitempty"`
+	MemoryMB       int               `json:"memoryMB,omitempty"`
+	TimeoutSeconds int               `json:"timeoutSeconds,omitempty"`
+	Labels         map[string]string `json:"labels,omitempty"`
+
+	// RuntimeArns are the agent's runtimes, one per replica.
+	RuntimeArns []string `json:"runtimeArns"`
+
+	// InvokeURLs are the InvokeAgentRuntime URLs of the runtimes.
+	InvokeURLs []string `json:"invokeUrls"`
+
+	// URL is the agent's subdomain or replica router URL, if any.
+	URL string `json:"url,omitempty"`
+}
+
+// TopologyVPC describes the network of a Topology.
+type TopologyVPC struct {
+	VPCID            string   `json:"vpcId,omitempty"`
+	PrivateSubnetIDs []string `json:"privateSubnetIds,omitempty"`
+	SecurityGroupID  string   `json:"securityGroupId,omitempty"`
+}
+
+// defaultTopologyParameter returns the default topology parameter name.
+func defaultTopologyParameter(stackName string) string {
+	return fmt.Sprintf("/agentcore/%s/topology", stackName)
+}
+
+// prepareTopology grants agents read access to the topology and injects
+// where to read it. It runs before the agent runtimes are created; the
+// document itself is published by publishTopology once they exist.
+func (s *AgentCoreStack) prepareTopology(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	var err error
+	stackName := s.Config.StackName
+	cfg := s.Options.Topology
+
+	if cfg.Target == "ssm" {
+		region, err := aws.GetRegion(ctx, nil, s.invoke())
+		if err != nil {
+			return err
+		}
+		caller, err := aws.GetCallerIdentity(ctx, nil, s.invoke())
+		if err != nil {
+			return err
+		}
+		name := s.topologyParameterName()
+		for _, agent := range s.Config.Agents {
+			s.injectEnv(agent.Name, "TOPOLOGY_PARAMETER", pulumi.String(name))
+		}
+		return s.attachRolePolicy(ctx, "topology-policy", policyStatement{
+			Actions: []string{"ssm:GetParameter"},
+			Resources: pulumi.StringArray{pulumi.Sprintf("arn:aws:ssm:%s:%s:parameter/%s",
+				region.Name, caller.AccountId, strings.TrimPrefix(name, "/"))},
+		})
+	}
+
+	appName := fmt.Sprintf("%s-topology", stackName)
+	s.topologyApp, err = appconfig.NewApplication(ctx, s.ResourceName("topology-app"), &appconfig.ApplicationArgs{
+		Name:        pulumi.String(appName),
+		Description: pulumi.Sprintf("Topology of the %s stack", stackName),
+		Tags:        mergeTags(tags, pulumi.String(appName)),
+	}, s.child())
+	if err != nil {
+		return err
+	}
+	s.topologyEnv, err = appconfig.NewEnvironment(ctx, s.ResourceName("topology-env"), &appconfig.EnvironmentArgs{
+		ApplicationId: s.topologyApp.ID(),
+		Name:          pulumi.String("default"),
+		Tags:          tags,
+	}, s.child())
+	if err != nil {
+		return err
+	}
+	s.topologyProfile, err = appconfig.NewConfigurationProfile(ctx, s.ResourceName("topology-profile"), &appconfig.ConfigurationProfileArgs{
+		ApplicationId: s.topologyApp.ID(),
+		Name:          pulumi.String("topology"),
+		LocationUri:   pulumi.String("hosted"),
+		Type:          pulumi.String("AWS.Freeform"),
+		Tags:          tags,
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	for _, agent := range s.Config.Agents {
+		s.injectEnv(agent.Name, "TOPOLOGY_APPCONFIG_APPLICATION", pulumi.String(appName))
+		s.injectEnv(agent.Name, "TOPOLOGY_APPCONFIG_ENVIRONMENT", pulumi.String("default"))
+		s.injectEnv(agent.Name, "TOPOLOGY_APPCONFIG_PROFILE", pulumi.String("topology"))
+	}
+	return s.attachRolePolicy(ctx, "topology-policy", policyStatement{
+		Actions: []string{
+			"appconfig:StartConfigurationSession",
+			"appconfig:GetLatestConfiguration",
+		},
+		Resources: pulumi.StringArray{
+			pulumi.Sprintf("%s/environment/%s/configuration/%s",
+				s.topologyApp.Arn, s.topologyEnv.EnvironmentId, s.topologyProfile.ConfigurationProfileId),
+		},
+	})
+}
+
+// publishTopology publishes the topology document. It runs after the agent
+// runtimes, routers and subdomains are created.
+func (s *AgentCoreStack) publishTopology(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	stackName := s.Config.StackName
+
+	document, err := s.topologyDocument(ctx)
+	if err != nil {
+		return err
+	}
+
+	if s.Options.Topology.Target == "ssm" {
+		content := pulumi.JSONMarshal(document)
+		tier := content.ApplyT(func(doc string) string {
+			if len(doc) > ssmStandardTierLimit {
+				return "Advanced"
+			}
+			return "Standard"
+		}).(pulumi.StringOutput)
+		param, err := ssm.NewParameter(ctx, s.ResourceName("topology"), &ssm.ParameterArgs{
+			Name:          pulumi.String(s.topologyParameterName()),
+			Description:   pulumi.Sprintf("Topology of the %s stack", stackName),
+			Type:          pulumi.String("String"),
+			Tier:          tier,
+			InsecureValue: content,
+			Tags:          mergeTags(s.secretTags(ctx, "", tags), pulumi.Sprintf("%s-topology", stackName)),
+		}, s.child())
+		if err != nil {
+			return err
+		}
+		s.TopologyLocation = param.Name
+		return nil
+	}
+
+	version, err := appconfig.NewHostedConfigurationVersion(ctx, s.ResourceName("topology-version"), &appconfig.HostedConfigurationVersionArgs{
+		ApplicationId:          s.topologyApp.ID(),
+		ConfigurationProfileId: s.topologyProfile.ConfigurationProfileId,
+		ContentType:            pulumi.String("application/json"),
+		Content:                pulumi.JSONMarshal(document),
+	}, s.child())
+	if err != nil {
+		return err
+	}
+	_, err = appconfig.NewDeployment(ctx, s.ResourceName("topology-deployment"), &appconfig.DeploymentArgs{
+		ApplicationId:          s.topologyApp.ID(),
+		EnvironmentId:          s.topologyEnv.EnvironmentId,
+		ConfigurationProfileId: s.topologyProfile.ConfigurationProfileId,
+		ConfigurationVersion:   version.VersionNumber.ApplyT(strconv.Itoa).(pulumi.StringOutput),
+		DeploymentStrategyId:   pulumi.String("AppConfig.AllAtOnce"),
+		Description:            pulumi.Sprintf("Topology of the %s stack", stackName),
+		Tags:                   tags,
+	}, s.child())
+	if err != nil {
+		return err
+	}
+	s.TopologyLocation = s.topologyProfile.Arn
+	return nil
+}
+
+// topologyDocument returns the topology with the keys of Topology.
+func (s *AgentCoreStack) topologyDocument(ctx *pulumi.Context) (pulumi.Map, error) {
+	region, err := aws.GetRegion(ctx, nil, s.invoke())
+	if err != nil {
+		return nil, err
+	}
+
+	agents := pulumi.Array{}
+	for _, agent := range s.Config.Agents {
+		var invokeURLs pulumi.StringArray
+		for _, name := range s.agentRuntimeNames(agent.Name) {
+			invokeURLs = append(invokeURLs, s.AgentRuntimes[name].InvokeURL)
+		}
+		entry := pulumi.Map{
+			"name":        pulumi.String(agent.Name),
+			"runtimeArns": s.agentRuntimeArns(agent.Name),
+			"invokeUrls":  invokeURLs,
+		}
+		if agent.Description != "" {
+			entry["description"] = pulumi.String(agent.Description)
+		}
+		if agent.Protocol != "" {
+			entry["protocol"] = pulumi.String(agent.Protocol)
+		}
+		if agent.IsDefault {
+			entry["isDefault"] = pulumi.Bool(true)
+		}
+		if agent.MemoryMB != 0 {
+			entry["memoryMB"] = pulumi.Int(agent.MemoryMB)
+		}
+		if agent.TimeoutSeconds != 0 {
+			entry["timeoutSeconds"] = pulumi.Int(agent.TimeoutSeconds)
+		}
+		if labels := s.Options.Agents[agent.Name].Labels; len(labels) > 0 {
+			entry["labels"] = pulumi.ToStringMap(labels)
+		}
+		if url, ok := s.AgentURLs[agent.Name]; ok {
+			entry["url"] = url
+		} else if url, ok := s.AgentRouters[agent.Name]; ok {
+			entry["url"] = url
+		}
+		agents = append(agents, entry)
+	}
+
+	document := pulumi.Map{
+		"stackName": pulumi.String(s.Config.StackName),
+		"region":    pulumi.String(region.Name),
+		"agents":    agents,
+	}
+	if s.Config.Description != "" {
+		document["description"] = pulumi.String(s.Config.Description)
+	}
+	if len(s.GatewayURLs) > 0 {
+		gateways := pulumi.StringMap{}
+		for name, url := range s.GatewayURLs {
+			gateways[name] = url
+		}
+		document["gateways"] = gateways
+	}
+	if vpcID := s.vpcID(); vpcID != nil {
+		vpc := pulumi.Map{
+			"vpcId":            vpcID,
+			"privateSubnetIds": s.privateSubnetIDs(),
+		}
+		if s.SecurityGroup != nil {
+			vpc["securityGroupId"] = s.SecurityGroup.ID()
+		}
+		document["vpc"] = vpc
+	}
+	return document, nil
+}
+
+// topologyParameterName returns the SSM parameter holding the topology.
+func (s *AgentCoreStack) topologyParameterName() string {
+	if s.Options.Topology.ParameterName != "" {
+		return s.Options.Topology.ParameterName
+	}
+	return defaultTopologyParameter(s.Config.StackName)
+}
@@ -0,0 +1,287 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/ec2"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/lambda"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/rds"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// Vector store engines.
+const (
+	// VectorStoreAuroraPgvector is Aurora Serverless v2 PostgreSQL with the
+	// pgvector extension.
+	VectorStoreAuroraPgvector = "aurora-pgvector"
+)
+
+// postgresPort is the port Aurora PostgreSQL clusters accept connections on.
+const postgresPort = 5432
+
+// vectorStoreDatabasePattern matches PostgreSQL database names Aurora accepts.
+var vectorStoreDatabasePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]{0,62}$`)
+
+// pgvectorHandler creates the pgvector extension through the RDS Data API.
+// The cluster may still be starting or resuming when it is first invoked,
+// so those errors are retried.
+const pgvectorHandler = `import { RDSDataClient, ExecuteStatementCommand } from "@aws-sdk/client-rds-data";
+
+const client = new RDSDataClient({});
+const sleep = (ms) => new Promise((resolve) => setTimeout(resolve, ms));
+
+export const handler = async ({ resourceArn, secretArn, database }) => {
+  for (let attempt = 1; ; attempt++) {
+    try {
+      await client.send(new ExecuteStatementCommand({
+        resourceArn,
+        secretArn,
+        database,
+        sql: "CREATE EXTENSION IF NOT EXISTS vector",
+      }));
+      return { extension: "vector" };
+    } catch (err) {
+      const retryable = ["DatabaseResumingException", "DatabaseNotFoundException", "BadRequestException"].includes(err.name);
+      if (!retryable || attempt === 20) {
+        throw err;
+      }
+      await sleep(15000);
+    }
+  }
+};
+`
+
+// VectorStoreConfig configures a vector database for agents that manage
+// their own embeddings. Create one with NewVectorStore.
+//
+// The aurora-pgvector engine provisions an Aurora Serverless v2 PostgreSQL
+// cluster in the private subnets, reachable only from the agent security
+// group, and creates the pgvector extension in its database. The master
+// credentials are kept in a Secrets Manager secret managed by RDS. Agents
+// receive the connection settings as VECTOR_STORE_* environment variables.
+type VectorStoreConfig struct {
+	// Engine is the vector database engine.
+	// Supported: "aurora-pgvector"
+	Engine string `json:"engine" yaml:"engine"`
+
+	// DatabaseName is the database the pgvector extension is created in.
+	// Default: "vectors"
+	DatabaseName string `json:"databaseName,omitempty" yaml:"databaseName,omitempty"`
+
+	// EngineVersion is the Aurora PostgreSQL engine version.
+	// Default: "16.6"
+	EngineVersion string `json:"engineVersion,omitempty" yaml:"engineVersion,omitempty"`
+
+	// MinCapacity is the minimum Aurora capacity units (ACUs). Zero lets
+	// the cluster pause when idle.
+	// Range: 0-256
+	// Default: 0.5
+	MinCapacity float64 `json:"minCapacity,omitempty" yaml:"minCapacity,omitempty"`
+
+	// MaxCapacity is the maximum Aurora capacity units (ACUs).
+	// Range: 1-256
+	// Default: 4
+	MaxCapacity float64 `json:"maxCapacity,omitempty" yaml:"maxCapacity,omitempty"`
+
+	// BackupRetentionDays is how long automated backups are kept.
+	// Default: 7
+	BackupRetentionDays int `json:"backupRetentionDays,omitempty" yaml:"backupRetentionDays,omitempty"`
+
+	// Agents is the list of agent names that access the vector store.
+	// If empty, all agents in the stack are included.
+	Agents []string `json:"agents,omitempty" yaml:"agents,omitempty"`
+}
+
+// NewVectorStore returns a VectorStoreConfig for an engine with sensible
+// defaults, e.g. NewVectorStore(VectorStoreAuroraPgvector).
+func NewVectorStore(engine string) *VectorStoreConfig {
+	config := &VectorStoreConfig{Engine: engine}
+	config.ApplyDefaults()
+	return config
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *VectorStoreConfig) ApplyDefaults() {
+	if c.DatabaseName == "" {
+		c.DatabaseName = "vectors"
+	}
+	if c.EngineVersion == "" {
+		c.EngineVersion = "16.6"
+	}
+	if c.MinCapacity == 0 {
+		c.MinCapacity = 0.5
+	}
+	if c.MaxCapacity == 0 {
+		c.MaxCapacity = 4
+	}
+	if c.BackupRetentionDays == 0 {
+		c.BackupRetentionDays = 7
+	}
+}
+
+// Validate validates the VectorStoreConfig against the stack configuration.
+func (c *VectorStoreConfig) Validate(config iac.StackConfig) error {
+	if c.Engine != VectorStoreAuroraPgvector {
+		return fmt.Errorf("vectorStore.engine must be one of [aurora-pgvector]")
+	}
+	// Aurora subnet groups must span at least two availability zones
+	createdMultiAZ := config.VPC.CreateVPC && vpcMaxAZs(config.VPC) >= 2
+	existingMultiAZ := config.VPC.VPCID != "" && len(config.VPC.SubnetIDs) >= 2
+	if !createdMultiAZ && !existingMultiAZ {
+		return fmt.Errorf("vectorStore requires a VPC with subnets in at least two availability zones (vpc.maxAZs or vpc.subnetIds)")
+	}
+	if !vectorStoreDatabasePattern.MatchString(c.DatabaseName) {
+		return fmt.Errorf("vectorStore.databaseName: '%s' is not a valid database name", c.DatabaseName)
+	}
+	if c.MinCapacity < 0 || c.MaxCapacity < 1 || c.MaxCapacity > 256 || c.MinCapacity > c.MaxCapacity {
+		return fmt.Errorf("vectorStore capacity must satisfy 0 <= minCapacity <= maxCapacity <= 256 and maxCapacity >= 1")
+	}
+	if c.BackupRetentionDays < 1 || c.BackupRetentionDays > 35 {
+		return fmt.Errorf("vectorStore.backupRetentionDays must be between 1 and 35")
+	}
+	return validateAgentNames("vectorStore.agents", c.Agents, config)
+}
+
+// createVectorStore creates the Aurora cluster, bootstraps the pgvector
+// extension and grants agents the connection secret.
+func (s *AgentCoreStack) createVectorStore(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	stackName := s.Config.StackName
+	cfg := s.Options.VectorStore
+	retain := s.Config.RemovalPolicy == "retain"
+
+	// Dedicated security group so only agents can reach the cluster
+	vectorSG, err := ec2.NewSecurityGroup(ctx, s.ResourceName("vector-store-sg"), &ec2.SecurityGroupArgs{
+		Name:        pulumi.Sprintf("%s-vector-store-sg", stackName),
+		Description: pulumi.Sprintf("Security group for %s vector store", stackName),
+		VpcId:       s.vpcID(),
+		Tags:        mergeTags(tags, pulumi.Sprintf("%s-vector-store-sg", stackName)),
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	_, err = ec2.NewSecurityGroupRule(ctx, s.ResourceName("vector-store-sg-agent-ingress"), &ec2.SecurityGroupRuleArgs{
+		Type:                  pulumi.String("ingress"),
+		SecurityGroupId:       vectorSG.ID(),
+		SourceSecurityGroupId: s.SecurityGroup.ID(),
+		Protocol:              pulumi.String("tcp"),
+		FromPort:              pulumi.Int(postgresPort),
+		ToPort:                pulumi.Int(postgresPort),
+		Description:           pulumi.String("Allow agents to reach the vector store"),
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	subnetGroup, err := rds.NewSubnetGroup(ctx, s.ResourceName("vector-store-subnet-group"), &rds.SubnetGroupArgs{
+		Name:        pulumi.Sprintf("%s-vector-store", stackName),
+		Description: pulumi.Sprintf("Subnets for %s vector store", stackName),
+		SubnetIds:   s.privateSubnetIDs(),
+		Tags:        mergeTags(tags, pulumi.Sprintf("%s-vector-store", stackName)),
+	}, s.child())
+	if err != nil {
+		return err
+	}
+
+	args := &rds.ClusterArgs{
+		ClusterIdentifier:        pulumi.Sprintf("%s-vector-store", stackName),
+		Engine:                   pulumi.String("aurora-postgresql"),
+		EngineMode:               pulumi.String("provisioned"),
+		EngineVersion:            pulumi.String(cfg.EngineVersion),
+		DatabaseName:             pulumi.String(cfg.DatabaseName),
+		MasterUsername:           pulumi.String("agentkit"),
+		ManageMasterUserPassword: pulumi.Bool(true),
+		DbSubnetGroupName:        subnetGroup.Name,
+		VpcSecurityGroupIds:      pulumi.StringArray{vectorSG.ID()},
+		EnableHttpEndpoint:       pulumi.Bool(true),
+		StorageEncrypted:         pulumi.Bool(true),
+		BackupRetentionPeriod:    pulumi.Int(cfg.BackupRetentionDays),
+		DeletionProtection:       pulumi.Bool(retain),
+		SkipFinalSnapshot:        pulumi.Bool(!retain),
+		FinalSnapshotIdentifier:  finalSnapshotIdentifier(retain, stackName+"-vector-store-final"),
+		Serverlessv2ScalingConfiguration: &rds.ClusterServerlessv2ScalingConfigurationArgs{
+			MinCapacity: pulumi.Float64(cfg.MinCapacity),
+			MaxCapacity: pulumi.Float64(cfg.MaxCapacity),
+		},
+		Tags: mergeTags(tags, pulumi.Sprintf("%s-vector-store", stackName)),
+	}
+	if s.Options.KMS != nil {
+		args.KmsKeyId = s.KMSKeyArn
+		args.MasterUserSecretKmsKeyId = s.KMSKeyArn
+	}
+	s.VectorStore, err = rds.NewCluster(ctx, s.ResourceName("vector-store-cluster"), args, s.child())
+	if err != nil {
+		return err
+	}
+
+	instance, err := rds.NewClusterInstance(ctx, s.ResourceName("vector-store-instance"), &rds.ClusterInstanceArgs{
+		Identifier:        pulumi.Sprintf("%s-vector-store-1", stackName),
+		ClusterIdentifier: s.VectorStore.ID(),
+		Engine:            pulumi.String("aurora-postgresql"),
+		EngineVersion:     s.VectorStore.EngineVersion,
+		InstanceClass:     pulumi.String("db.serverless"),
+		Tags:              mergeTags(tags, pulumi.Sprintf("%s-vector-store-1", stackName)),
+	}, s.child())
+	if err != nil {
+		return err
+	}
+	s.VectorStoreSecretArn = s.VectorStore.MasterUserSecrets.ApplyT(func(secrets []rds.ClusterMasterUserSecret) string {
+		if len(secrets) == 0 || secrets[0].SecretArn == nil {
+			return ""
+		}
+		return *secrets[0].SecretArn
+	}).(pulumi.StringOutput)
+
+	// The extension has no AWS resource, so a function creates it through
+	// the Data API once the instance is available
+	function, err := s.newInlineFunction(ctx, "pgvector", pgvectorHandler, 600, nil, tags,
+		policyStatement{
+			Actions:   []string{"rds-data:ExecuteStatement"},
+			Resources: pulumi.StringArray{s.VectorStore.Arn},
+		},
+		policyStatement{
+			Actions:   []string{"secretsmanager:GetSecretValue"},
+			Resources: pulumi.StringArray{s.VectorStoreSecretArn},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	input := pulumi.All(s.VectorStore.Arn, s.VectorStoreSecretArn).ApplyT(func(args []interface{}) (string, error) {
+		data, err := json.Marshal(map[string]string{
+			"resourceArn": args[0].(string),
+			"secretArn":   args[1].(string),
+			"database":    cfg.DatabaseName,
+		})
+		return string(data), err
+	}).(pulumi.StringOutput)
+	_, err = lambda.NewInvocation(ctx, s.ResourceName("pgvector-extension"), &lambda.InvocationArgs{
+		FunctionName: function.Name,
+		Input:        input,
+	}, s.child(), pulumi.DependsOn([]pulumi.Resource{instance}))
+	if err != nil {
+		return err
+	}
+
+	err = s.attachRolePolicy(ctx, "vector-store-secret-policy", policyStatement{
+		Actions:   []string{"secretsmanager:GetSecretValue"},
+		Resources: pulumi.StringArray{s.VectorStoreSecretArn},
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, name := range s.selectedAgents(cfg.Agents) {
+		s.injectEnv(name, "VECTOR_STORE_ENGINE", pulumi.String(cfg.Engine))
+		s.injectEnv(name, "VECTOR_STORE_HOST", s.VectorStore.Endpoint)
+		s.injectEnv(name, "VECTOR_STORE_PORT", pulumi.String(strconv.Itoa(postgresPort)))
+		s.injectEnv(name, "VECTOR_STORE_DATABASE", pulumi.String(cfg.DatabaseName))
+		s.injectEnv(name, "VECTOR_STORE_SECRET_ARN", s.VectorStoreSecretArn)
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package agentcore
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/ec2"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// interfaceEndpointServices are the services agents reach through interface
+// endpoints, so they can run in private subnets without NAT egress.
+var interfaceEndpointServices = []string{
+	"bedrock",
+	"bedrock-runtime",
+	"secretsmanager",
+	"ecr.api",
+	"ecr.dkr",
+	"logs",
+	"sts",
+}
+
+// createVPCEndpoints creates interface endpoints in the private subnets and,
+// for a created VPC, an S3 gateway endpoint on the private route table.
+func (s *AgentCoreStack) createVPCEndpoints(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	stackName := s.Config.StackName
+
+	region, err := aws.GetRegion(ctx, nil, s.invoke())
+	if err != nil {
+		return err
+	}
+
+	// Agents reach the endpoints through the security group's self-referencing ingress
+	for _, service := range interfaceEndpointServices {
+		name := strings.ReplaceAll(service, ".", "-")
+		endpoint, err := ec2.NewVpcEndpoint(ctx, s.ResourceName(fmt.Sprintf("vpce-%s", name)), &ec2.VpcEndpointArgs{
+			VpcId:             s.vpcID(),
+			ServiceName:       pulumi.Sprintf("com.amazonaws.%s.%s", region.Name, service),
+			VpcEndpointType:   pulumi.String("Interface"),
+			SubnetIds:         s.privateSubnetIDs(),
+			SecurityGroupIds:  pulumi.StringArray{s.SecurityGroup.ID()},
+			PrivateDnsEnabled: pulumi.Bool(true),
+			Tags:              mergeTags(tags, pulumi.Sprintf("%s-vpce-%s", stackName, name)),
+		}, s.child())
+		if err != nil {
+			return err
+		}
+		s.VPCEndpoints[service] = endpoint
+	}
+
+	// Route tables of an existing VPC are not managed by the stack
+	if s.PrivateRouteTable == nil {
+		return nil
+	}
+
+	// ECR image layers are served from S3
+	endpoint, err := ec2.NewVpcEndpoint(ctx, s.ResourceName("vpce-s3"), &ec2.VpcEndpointArgs{
+		VpcId:           s.vpcID(),
+		ServiceName:     pulumi.Sprintf("com.amazonaws.%s.s3", region.Name),
+		VpcEndpointType: pulumi.String("Gateway"),
+		RouteTableIds:   pulumi.StringArray{s.PrivateRouteTable.ID()},
+		Tags:            mergeTags(tags, pulumi.Sprintf("%s-vpce-s3", stackName)),
+	}, s.child())
+	if err != nil {
+		return err
+	}
+	s.VPCEndpoints["s3"] = endpoint
+
+	return nil
+}
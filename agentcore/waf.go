@@ -0,0 +1,204 @@
+package agentcore
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/wafv2"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// WAF rules.
+const (
+	// WAFRuleRateLimit blocks client IPs exceeding WAFConfig.RateLimit.
+	WAFRuleRateLimit = "rate-limit"
+
+	// WAFRuleCommon is the AWS managed core rule set against common
+	// attacks such as cross-site scripting.
+	WAFRuleCommon = "common"
+
+	// WAFRuleKnownBadInputs is the AWS managed rule set against request
+	// patterns known to exploit vulnerabilities.
+	WAFRuleKnownBadInputs = "known-bad-inputs"
+
+	// WAFRuleIPReputation is the AWS managed list of IPs with a bad
+	// reputation.
+	WAFRuleIPReputation = "ip-reputation"
+
+	// WAFRuleBotControl is the AWS managed bot control rule set. It is
+	// charged per request in addition to the web ACL.
+	WAFRuleBotControl = "bot-control"
+)
+
+// wafManagedRuleGroups maps WAF rules to AWS managed rule groups.
+var wafManagedRuleGroups = map[string]string{
+	WAFRuleCommon:         "AWSManagedRulesCommonRuleSet",
+	WAFRuleKnownBadInputs: "AWSManagedRulesKnownBadInputsRuleSet",
+	WAFRuleIPReputation:   "AWSManagedRulesAmazonIpReputationList",
+	WAFRuleBotControl:     "AWSManagedRulesBotControlRuleSet",
+}
+
+// wafMetricNamePattern removes characters not allowed in WAF metric names.
+var wafMetricNamePattern = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// WAFConfig protects the stack's public entry points with a WAFv2 web ACL.
+//
+// The AppSync API is associated with a regional web ACL and the frontend
+// distribution with a CloudFront web ACL, which is created in us-east-1.
+// WAF cannot be associated with API Gateway HTTP APIs, so the approval API
+// and agent subdomains are not protected.
+type WAFConfig struct {
+	// Rules are the rules of the web ACL, evaluated in order.
+	// Supported: "rate-limit", "common", "known-bad-inputs", "ip-reputation", "bot-control"
+	// Default: ["rate-limit", "common", "known-bad-inputs"]
+	Rules []string `json:"rules,omitempty" yaml:"rules,omitempty"`
+
+	// RateLimit is the number of requests a client IP may make in a
+	// 5-minute window before the rate-limit rule blocks it.
+	// Range: 100-2000000000
+	// Default: 2000
+	RateLimit int `json:"rateLimit,omitempty" yaml:"rateLimit,omitempty"`
+
+	// CountOnly counts matching requests instead of blocking them, to
+	// evaluate the rules before enforcing them.
+	// Default: false
+	CountOnly bool `json:"countOnly,omitempty" yaml:"countOnly,omitempty"`
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *WAFConfig) ApplyDefaults() {
+	if len(c.Rules) == 0 {
+		c.Rules = []string{WAFRuleRateLimit, WAFRuleCommon, WAFRuleKnownBadInputs}
+	}
+	if c.RateLimit == 0 {
+		c.RateLimit = 2000
+	}
+}
+
+// Validate validates the WAFConfig.
+func (c *WAFConfig) Validate() error {
+	seen := make(map[string]bool)
+	for _, rule := range c.Rules {
+		if _, ok := wafManagedRuleGroups[rule]; !ok && rule != WAFRuleRateLimit {
+			return fmt.Errorf("waf.rules: '%s' must be one of [rate-limit common known-bad-inputs ip-reputation bot-control]", rule)
+		}
+		if seen[rule] {
+			return fmt.Errorf("waf.rules: duplicate rule '%s'", rule)
+		}
+		seen[rule] = true
+	}
+	if c.RateLimit < 100 || c.RateLimit > 2000000000 {
+		return fmt.Errorf("waf.rateLimit must be between 100 and 2000000000")
+	}
+	return nil
+}
+
+// wafRules builds the rules of a web ACL. metricPrefix names the rules'
+// CloudWatch metrics.
+func (c *WAFConfig) wafRules(metricPrefix string) wafv2.WebAclRuleArray {
+	rules := make(wafv2.WebAclRuleArray, 0, len(c.Rules))
+	for i, name := range c.Rules {
+		rule := &wafv2.WebAclRuleArgs{
+			Name:     pulumi.String(name),
+			Priority: pulumi.Int(i),
+			VisibilityConfig: &wafv2.WebAclRuleVisibilityConfigArgs{
+				CloudwatchMetricsEnabled: pulumi.Bool(true),
+				MetricName:               pulumi.String(metricPrefix + "-" + name),
+				SampledRequestsEnabled:   pulumi.Bool(true),
+			},
+		}
+		if name == WAFRuleRateLimit {
+			rule.Statement = &wafv2.WebAclRuleStatementArgs{
+				RateBasedStatement: &wafv2.WebAclRuleStatementRateBasedStatementArgs{
+					Limit:            pulumi.Int(c.RateLimit),
+					AggregateKeyType: pulumi.String("IP"),
+				},
+			}
+			if c.CountOnly {
+				rule.Action = &wafv2.WebAclRuleActionArgs{Count: &wafv2.WebAclRuleActionCountArgs{}}
+			} else {
+				rule.Action = &wafv2.WebAclRuleActionArgs{Block: &wafv2.WebAclRuleActionBlockArgs{}}
+			}
+		} else {
+			rule.Statement = &wafv2.WebAclRuleStatementArgs{
+				ManagedRuleGroupStatement: &wafv2.WebAclRuleStatementManagedRuleGroupStatementArgs{
+					Name:       pulumi.String(wafManagedRuleGroups[name]),
+					VendorName: pulumi.String("AWS"),
+				},
+			}
+			if c.CountOnly {
+				rule.OverrideAction = &wafv2.WebAclRuleOverrideActionArgs{Count: &wafv2.WebAclRuleOverrideActionCountArgs{}}
+			} else {
+				rule.OverrideAction = &wafv2.WebAclRuleOverrideActionArgs{None: &wafv2.WebAclRuleOverrideActionNoneArgs{}}
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// newWebACL creates a web ACL in the given scope.
+func (s *AgentCoreStack) newWebACL(ctx *pulumi.Context, name, scope string, tags pulumi.StringMap, opts ...pulumi.ResourceOption) (*wafv2.WebAcl, error) {
+	stackName := s.Config.StackName
+	metricName := wafMetricNamePattern.ReplaceAllString(fmt.Sprintf("%s-%s", stackName, name), "-")
+	return wafv2.NewWebAcl(ctx, s.ResourceName(name), &wafv2.WebAclArgs{
+		Name:        pulumi.Sprintf("%s-%s", stackName, name),
+		Description: pulumi.Sprintf("Protects the %s entry points", stackName),
+		Scope:       pulumi.String(scope),
+		DefaultAction: &wafv2.WebAclDefaultActionArgs{
+			Allow: &wafv2.WebAclDefaultActionAllowArgs{},
+		},
+		Rules: s.Options.WAF.wafRules(metricName),
+		VisibilityConfig: &wafv2.WebAclVisibilityConfigArgs{
+			CloudwatchMetricsEnabled: pulumi.Bool(true),
+			MetricName:               pulumi.String(metricName),
+			SampledRequestsEnabled:   pulumi.Bool(true),
+		},
+		Tags: mergeTags(tags, pulumi.Sprintf("%s-%s", stackName, name)),
+	}, append([]pulumi.ResourceOption{s.child()}, opts...)...)
+}
+
+// createWAF creates the web ACLs and associates the regional one with the
+// AppSync API. It runs after the AppSync API and before the frontend, whose
+// distribution references the CloudFront web ACL.
+func (s *AgentCoreStack) createWAF(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	var err error
+
+	if s.AppSyncAPI != nil {
+		s.WebACL, err = s.newWebACL(ctx, "waf", "REGIONAL", tags)
+		if err != nil {
+			return err
+		}
+		_, err = wafv2.NewWebAclAssociation(ctx, s.ResourceName("waf-appsync"), &wafv2.WebAclAssociationArgs{
+			ResourceArn: s.AppSyncAPI.Arn,
+			WebAclArn:   s.WebACL.Arn,
+		}, s.child())
+		if err != nil {
+			return err
+		}
+	}
+
+	if s.Options.Frontend != nil {
+		// CloudFront web ACLs must be created in us-east-1
+		provider, err := aws.NewProvider(ctx, s.ResourceName("waf-cloudfront-provider"), s.providerArgs(ctx, "us-east-1"), s.child())
+		if err != nil {
+			return err
+		}
+		s.CloudFrontWebACL, err = s.newWebACL(ctx, "waf-cloudfront", "CLOUDFRONT", tags, pulumi.Provider(provider))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cloudFrontWebACLArn returns the ARN of the CloudFront web ACL, or nil if
+// WAF is not enabled.
+func (s *AgentCoreStack) cloudFrontWebACLArn() pulumi.StringPtrInput {
+	if s.CloudFrontWebACL == nil {
+		return nil
+	}
+	return s.CloudFrontWebACL.Arn
+}
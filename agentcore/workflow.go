@@ -0,0 +1,422 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/sfn"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+var (
+	// workflowNamePattern matches workflow names, which are used in state
+	// machine names.
+	workflowNamePattern = regexp.MustCompile(`^[0-9a-zA-Z-_]{1,40}$`)
+
+	// workflowStepPattern matches step names, which are used in state names
+	// and JSONPath expressions.
+	workflowStepPattern = regexp.MustCompile(`^[a-zA-Z][0-9a-zA-Z_]{0,39}$`)
+)
+
+// workflowReservedStates are state names of every workflow definition.
+var workflowReservedStates = []string{"Start", "Succeeded", "Failed"}
+
+// WorkflowConfig orchestrates agents as a Step Functions state machine built
+// from a dependency graph of steps, such as research → synthesis →
+// verification.
+//
+// Create one with WorkflowBuilder. Steps run once all the steps they depend
+// on have succeeded; steps whose dependencies are satisfied together run in
+// parallel. Each step invokes its agent with the execution input and the
+// responses of the steps before it as {"input": ..., "results": {step: ...}}.
+// A step that still fails after its retries fails the execution.
+type WorkflowConfig struct {
+	// Name identifies the workflow within the stack.
+	Name string `json:"name" yaml:"name"`
+
+	// Description describes the workflow.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+
+	// Steps are the agent invocations of the workflow.
+	Steps []WorkflowStep `json:"steps" yaml:"steps"`
+
+	// MaxAttempts is how many times a failed step is retried.
+	// Range: 1-10
+	// Default: 3
+	MaxAttempts int `json:"maxAttempts,omitempty" yaml:"maxAttempts,omitempty"`
+
+	// RetryIntervalSeconds is the wait before the first retry.
+	// Default: 2
+	RetryIntervalSeconds int `json:"retryIntervalSeconds,omitempty" yaml:"retryIntervalSeconds,omitempty"`
+
+	// BackoffRate multiplies the retry interval after each attempt.
+	// Range: 1-10
+	// Default: 2
+	BackoffRate float64 `json:"backoffRate,omitempty" yaml:"backoffRate,omitempty"`
+
+	// TimeoutSeconds is how long an execution may run before it fails.
+	// Default: 3600
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty" yaml:"timeoutSeconds,omitempty"`
+}
+
+// WorkflowStep invokes an agent within a workflow.
+type WorkflowStep struct {
+	// Name identifies the step within the workflow.
+	Name string `json:"name" yaml:"name"`
+
+	// Agent is the name of the agent the step invokes.
+	Agent string `json:"agent" yaml:"agent"`
+
+	// DependsOn are the steps that must succeed before this one starts.
+	DependsOn []string `json:"dependsOn,omitempty" yaml:"dependsOn,omitempty"`
+
+	// TimeoutSeconds is how long an invocation may run before it is retried.
+	// Default: 900
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty" yaml:"timeoutSeconds,omitempty"`
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *WorkflowConfig) ApplyDefaults() {
+	if c.MaxAttempts == 0 {
+		c.MaxAttempts = 3
+	}
+	if c.RetryIntervalSeconds == 0 {
+		c.RetryIntervalSeconds = 2
+	}
+	if c.BackoffRate == 0 {
+		c.BackoffRate = 2
+	}
+	if c.TimeoutSeconds == 0 {
+		c.TimeoutSeconds = 3600
+	}
+	for i := range c.Steps {
+		if c.Steps[i].TimeoutSeconds == 0 {
+			c.Steps[i].TimeoutSeconds = 900
+		}
+	}
+}
+
+// Validate validates the WorkflowConfig against the stack configuration.
+func (c *WorkflowConfig) Validate(config iac.StackConfig) error {
+	if !workflowNamePattern.MatchString(c.Name) {
+		return fmt.Errorf("workflows: name %q must match %s", c.Name, workflowNamePattern)
+	}
+	field := fmt.Sprintf("workflows[%s]", c.Name)
+	if len(c.Steps) == 0 {
+		return fmt.Errorf("%s.steps must not be empty", field)
+	}
+	if c.MaxAttempts < 1 || c.MaxAttempts > 10 {
+		return fmt.Errorf("%s.maxAttempts must be between 1 and 10", field)
+	}
+	if c.RetryIntervalSeconds < 1 {
+		return fmt.Errorf("%s.retryIntervalSeconds must be at least 1", field)
+	}
+	if c.BackoffRate < 1 || c.BackoffRate > 10 {
+		return fmt.Errorf("%s.backoffRate must be between 1 and 10", field)
+	}
+	if c.TimeoutSeconds < 1 || c.TimeoutSeconds > 365*24*60*60 {
+		return fmt.Errorf("%s.timeoutSeconds must be between 1 and %d", field, 365*24*60*60)
+	}
+
+	steps := make(map[string]bool)
+	for _, step := range c.Steps {
+		if !workflowStepPattern.MatchString(step.Name) {
+			return fmt.Errorf("%s.steps: name %q must match %s", field, step.Name, workflowStepPattern)
+		}
+		if slices.Contains(workflowReservedStates, step.Name) {
+			return fmt.Errorf("%s.steps: name %q is reserved", field, step.Name)
+		}
+		if steps[step.Name] {
+			return fmt.Errorf("%s.steps: duplicate step '%s'", field, step.Name)
+		}
+		steps[step.Name] = true
+		if step.TimeoutSeconds < 1 {
+			return fmt.Errorf("%s.steps[%s].timeoutSeconds must be at least 1", field, step.Name)
+		}
+		if err := validateAgentNames(fmt.Sprintf("%s.steps[%s].agent", field, step.Name), []string{step.Agent}, config); err != nil {
+			return err
+		}
+	}
+	for _, step := range c.Steps {
+		for _, dep := range step.DependsOn {
+			if !steps[dep] {
+				return fmt.Errorf("%s.steps[%s].dependsOn: '%s' does not match any step", field, step.Name, dep)
+			}
+		}
+	}
+	if _, err := c.stages(); err != nil {
+		return fmt.Errorf("%s: %w", field, err)
+	}
+	return nil
+}
+
+// stages orders the steps into stages that run one after another. Each
+// stage holds the steps whose dependencies are all in earlier stages, sorted
+// by name.
+func (c *WorkflowConfig) stages() ([][]WorkflowStep, error) {
+	done := make(map[string]bool)
+	remaining := append([]WorkflowStep{}, c.Steps...)
+	var stages [][]WorkflowStep
+	for len(remaining) > 0 {
+		var stage, blocked []WorkflowStep
+		for _, step := range remaining {
+			ready := true
+			for _, dep := range step.DependsOn {
+				ready = ready && done[dep]
+			}
+			if ready {
+				stage = append(stage, step)
+			} else {
+				blocked = append(blocked, step)
+			}
+		}
+		if len(stage) == 0 {
+			names := make([]string, len(blocked))
+			for i, step := range blocked {
+				names[i] = step.Name
+			}
+			sort.Strings(names)
+			return nil, fmt.Errorf("steps %v have a dependency cycle", names)
+		}
+		sort.Slice(stage, func(i, j int) bool { return stage[i].Name < stage[j].Name })
+		for _, step := range stage {
+			done[step.Name] = true
+		}
+		stages = append(stages, stage)
+		remaining = blocked
+	}
+	return stages, nil
+}
+
+// workflowDefinition returns the Amazon States Language definition of a
+// workflow. runtimeArns maps step names to the runtime ARN they invoke.
+func workflowDefinition(cfg *WorkflowConfig, runtimeArns map[string]string) (string, error) {
+	stages, err := cfg.stages()
+	if err != nil {
+		return "", err
+	}
+
+	retry := []map[string]interface{}{{
+		"ErrorEquals":     []string{"States.ALL"},
+		"MaxAttempts":     cfg.MaxAttempts,
+		"IntervalSeconds": cfg.RetryIntervalSeconds,
+		"BackoffRate":     cfg.BackoffRate,
+	}}
+	catch := []map[string]interface{}{
+		{"ErrorEquals": []string{"States.ALL"}, "ResultPath": "$.error", "Next": "Failed"},
+	}
+	invoke := func(step WorkflowStep) map[string]interface{} {
+		return map[string]interface{}{
+			"Type":           "Task",
+			"Resource":       "arn:aws:states:::aws-sdk:bedrockagentcore:invokeAgentRuntime",
+			"TimeoutSeconds": step.TimeoutSeconds,
+			"Parameters": map[string]interface{}{
+				"AgentRuntimeArn":    runtimeArns[step.Name],
+				"Qualifier":          runtimeEndpointName,
+				"RuntimeSessionId.$": "States.UUID()",
+				"ContentType":        "application/json",
+				"Payload.$":          "States.JsonToString($)",
+			},
+			"ResultSelector": map[string]interface{}{"response.$": "$.Response"},
+			"ResultPath":     fmt.Sprintf("$.results.%s", step.Name),
+			"Retry":          retry,
+		}
+	}
+
+	states := map[string]interface{}{
+		"Start": map[string]interface{}{
+			"Type":       "Pass",
+			"Parameters": map[string]interface{}{"input.$": "$", "results": map[string]interface{}{}},
+			"Next":       stageName(stages, 0),
+		},
+		"Succeeded": map[string]interface{}{"Type": "Succeed", "OutputPath": "$.results"},
+		"Failed": map[string]interface{}{
+			"Type":      "Fail",
+			"ErrorPath": "$.error.Error",
+			"CausePath": "$.error.Cause",
+		},
+	}
+	for i, stage := range stages {
+		next := stageName(stages, i+1)
+		if len(stage) == 1 {
+			state := invoke(stage[0])
+			state["Next"] = next
+			state["Catch"] = catch
+			states[stageName(stages, i)] = state
+			continue
+		}
+
+		// Independent steps run as branches that return only their result,
+		// which are merged into the results afterwards
+		branches := make([]map[string]interface{}, len(stage))
+		selector := make(map[string]interface{}, len(stage))
+		for j, step := range stage {
+			state := invoke(step)
+			state["End"] = true
+			state["OutputPath"] = fmt.Sprintf("$.results.%s", step.Name)
+			branches[j] = map[string]interface{}{
+				"StartAt": step.Name,
+				"States":  map[string]interface{}{step.Name: state},
+			}
+			selector[step.Name+".$"] = fmt.Sprintf("$[%d]", j)
+		}
+		name := stageName(stages, i)
+		states[name] = map[string]interface{}{
+			"Type":           "Parallel",
+			"Branches":       branches,
+			"ResultSelector": selector,
+			"ResultPath":     "$.stage",
+			"Next":           name + "-merge",
+			"Catch":          catch,
+		}
+		states[name+"-merge"] = map[string]interface{}{
+			"Type": "Pass",
+			"Parameters": map[string]interface{}{
+				"input.$":   "$.input",
+				"results.$": "States.JsonMerge($.results, $.stage, false)",
+			},
+			"Next": next,
+		}
+	}
+
+	comment := cfg.Description
+	if comment == "" {
+		comment = fmt.Sprintf("Agent workflow %s", cfg.Name)
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"Comment":        comment,
+		"StartAt":        "Start",
+		"TimeoutSeconds": cfg.TimeoutSeconds,
+		"States":         states,
+	})
+	return string(data), err
+}
+
+// stageName returns the state name of the i-th stage, or "Succeeded" after
+// the last stage. Single-step stages are named after their step.
+func stageName(stages [][]WorkflowStep, i int) string {
+	switch {
+	case i >= len(stages):
+		return "Succeeded"
+	case len(stages[i]) == 1:
+		return stages[i][0].Name
+	default:
+		return fmt.Sprintf("stage-%d", i+1)
+	}
+}
+
+// createWorkflows creates the workflows' state machines and the roles Step
+// Functions assumes to invoke the agents. It runs after the runtimes are
+// created.
+func (s *AgentCoreStack) createWorkflows(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	stackName := s.Config.StackName
+
+	for _, cfg := range s.Options.Workflows {
+		// Steps of an agent with replicas are spread across the replicas
+		used := make(map[string]int)
+		stepArns := make(map[string]pulumi.StringOutput, len(cfg.Steps))
+		var runtimeArns pulumi.StringArray
+		for _, step := range cfg.Steps {
+			names := s.agentRuntimeNames(step.Agent)
+			arn := s.AgentRuntimes[names[used[step.Agent]%len(names)]].RuntimeArn
+			used[step.Agent]++
+			stepArns[step.Name] = arn
+			runtimeArns = append(runtimeArns, arn, pulumi.Sprintf("%s/*", arn))
+		}
+
+		roleName := fmt.Sprintf("workflow-%s-role", cfg.Name)
+		role, err := s.newServiceRole(ctx, roleName, "states.amazonaws.com", tags)
+		if err != nil {
+			return err
+		}
+		err = s.newRolePolicy(ctx, fmt.Sprintf("workflow-%s-policy", cfg.Name), role.Name, policyStatement{
+			Actions:   []string{"bedrock-agentcore:InvokeAgentRuntime"},
+			Resources: runtimeArns,
+		})
+		if err != nil {
+			return err
+		}
+
+		stepNames := make([]string, 0, len(stepArns))
+		arns := make([]interface{}, 0, len(stepArns))
+		for name, arn := range stepArns {
+			stepNames = append(stepNames, name)
+			arns = append(arns, arn)
+		}
+		definition := pulumi.All(arns...).ApplyT(func(args []interface{}) (string, error) {
+			runtimeArns := make(map[string]string, len(args))
+			for i, arn := range args {
+				runtimeArns[stepNames[i]] = arn.(string)
+			}
+			return workflowDefinition(cfg, runtimeArns)
+		}).(pulumi.StringOutput)
+
+		s.Workflows[cfg.Name], err = sfn.NewStateMachine(ctx, s.ResourceName(fmt.Sprintf("workflow-%s", cfg.Name)), &sfn.StateMachineArgs{
+			Name:       pulumi.Sprintf("%s-%s", stackName, cfg.Name),
+			Type:       pulumi.String("STANDARD"),
+			RoleArn:    role.Arn,
+			Definition: definition,
+			Tags:       mergeTags(tags, pulumi.Sprintf("%s-%s", stackName, cfg.Name)),
+		}, s.child())
+		if err != nil {
+			return err
+		}
+		s.WorkflowRoles[cfg.Name] = role.Arn
+	}
+
+	return nil
+}
+
+// WorkflowBuilder provides a fluent interface for building workflow configurations.
+type WorkflowBuilder struct {
+	config WorkflowConfig
+}
+
+// NewWorkflowBuilder creates a new workflow builder.
+func NewWorkflowBuilder(name string) *WorkflowBuilder {
+	return &WorkflowBuilder{config: WorkflowConfig{Name: name}}
+}
+
+// WithDescription sets the workflow description.
+func (b *WorkflowBuilder) WithDescription(description string) *WorkflowBuilder {
+	b.config.Description = description
+	return b
+}
+
+// Step adds a step that invokes agent once the steps it depends on have
+// succeeded.
+func (b *WorkflowBuilder) Step(name, agent string, dependsOn ...string) *WorkflowBuilder {
+	b.config.Steps = append(b.config.Steps, WorkflowStep{Name: name, Agent: agent, DependsOn: dependsOn})
+	return b
+}
+
+// WithStep adds a step.
+func (b *WorkflowBuilder) WithStep(step WorkflowStep) *WorkflowBuilder {
+	b.config.Steps = append(b.config.Steps, step)
+	return b
+}
+
+// WithRetry sets how failed steps are retried.
+func (b *WorkflowBuilder) WithRetry(maxAttempts, intervalSeconds int, backoffRate float64) *WorkflowBuilder {
+	b.config.MaxAttempts = maxAttempts
+	b.config.RetryIntervalSeconds = intervalSeconds
+	b.config.BackoffRate = backoffRate
+	return b
+}
+
+// WithTimeout sets how long an execution may run.
+func (b *WorkflowBuilder) WithTimeout(seconds int) *WorkflowBuilder {
+	b.config.TimeoutSeconds = seconds
+	return b
+}
+
+// Build returns the workflow configuration.
+func (b *WorkflowBuilder) Build() *WorkflowConfig {
+	config := b.config
+	config.Steps = append([]WorkflowStep{}, b.config.Steps...)
+	return &config
+}
@@ -0,0 +1,328 @@
+package agentcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/plexusone/agentkit/platforms/agentcore/iac"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/lambda"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/sqs"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// workQueueConsumerHandler invokes an agent with each queued message. The
+// agent is the queue's agent, or for the stack queue the message's "agent"
+// attribute or the default agent. Messages with a "sessionId" attribute
+// continue that session; others get a new one. Agents with replicas are
+// invoked on the replica chosen by the session's hash, as their router does.
+// Failed messages are retried and then moved to the dead-letter queue.
+const workQueueConsumerHandler = `import { createHash } from "node:crypto";
+import { BedrockAgentCoreClient, InvokeAgentRuntimeCommand } from "@aws-sdk/client-bedrock-agentcore";
+
+const client = new BedrockAgentCoreClient({});
+const queueAgents = JSON.parse(process.env.QUEUE_AGENTS);
+const runtimes = JSON.parse(process.env.AGENT_RUNTIMES);
+
+const invoke = async (record) => {
+  const attributes = record.messageAttributes ?? {};
+  const agent = queueAgents[record.eventSourceARN] ?? attributes.agent?.stringValue ?? process.env.DEFAULT_AGENT;
+  const arns = runtimes[agent];
+  if (!arns) throw new Error("unknown agent " + agent);
+
+  // Session IDs must be at least 33 characters
+  const sessionId = attributes.sessionId?.stringValue ?? record.messageId + record.messageId;
+  const replica = createHash("sha256").update(sessionId).digest().readUInt32BE(0) % arns.length;
+
+  const result = await client.send(new InvokeAgentRuntimeCommand({
+    agentRuntimeArn: arns[replica],
+    qualifier: process.env.QUALIFIER,
+    runtimeSessionId: sessionId,
+    contentType: attributes.contentType?.stringValue ?? "application/json",
+    accept: "application/json",
+    payload: Buffer.from(record.body, "utf8"),
+  }));
+  await result.response.transformToString();
+  if ((result.statusCode ?? 200) >= 400) throw new Error(agent + " returned " + result.statusCode);
+};
+
+export const handler = async (event) => {
+  const batchItemFailures = [];
+  await Promise.all(event.Records.map(async (record) => {
+    try {
+      await invoke(record);
+    } catch (err) {
+      console.error(JSON.stringify({ messageId: record.messageId, error: String(err) }));
+      batchItemFailures.push({ itemIdentifier: record.messageId });
+    }
+  }));
+  return { batchItemFailures };
+};
+`
+
+// WorkQueueConfig provisions SQS work queues so other systems can enqueue
+// agent tasks instead of invoking agents synchronously.
+//
+// A consumer function invokes the agent with each message body as the
+// payload. Messages may set the "sessionId" attribute to continue a
+// session and, on the stack queue, the "agent" attribute to pick the agent.
+// Messages that fail MaxReceiveCount times move to a dead-letter queue.
+type WorkQueueConfig struct {
+	// Mode selects a queue per agent or one queue for the stack.
+	// Supported: "agent", "stack"
+	// Default: "agent"
+	Mode string `json:"mode,omitempty" yaml:"mode,omitempty"`
+
+	// Agents is the list of agent names that get a queue, or that the stack
+	// queue may invoke. If empty, all agents in the stack are included.
+	Agents []string `json:"agents,omitempty" yaml:"agents,omitempty"`
+
+	// VisibilityTimeoutSeconds is the time the consumer has to invoke the
+	// agent before the message is retried. The consumer's timeout is the
+	// same, up to 900 seconds.
+	// Range: 30-43200
+	// Default: 900
+	VisibilityTimeoutSeconds int `json:"visibilityTimeoutSeconds,omitempty" yaml:"visibilityTimeoutSeconds,omitempty"`
+
+	// MaxReceiveCount is the number of attempts before a message moves to
+	// the dead-letter queue.
+	// Range: 1-1000
+	// Default: 3
+	MaxReceiveCount int `json:"maxReceiveCount,omitempty" yaml:"maxReceiveCount,omitempty"`
+
+	// BatchSize is the number of messages the consumer invokes agents for
+	// at once.
+	// Range: 1-10
+	// Default: 1
+	BatchSize int `json:"batchSize,omitempty" yaml:"batchSize,omitempty"`
+
+	// MaxConcurrency caps the concurrent consumers per queue, and so the
+	// concurrent agent invocations per queue times BatchSize. 0 means the
+	// Lambda default scaling.
+	// Range: 0 or 2-1000
+	MaxConcurrency int `json:"maxConcurrency,omitempty" yaml:"maxConcurrency,omitempty"`
+
+	// ProducerPrincipals are IAM role or account ARNs allowed to send
+	// messages to the queues through the queue policy.
+	ProducerPrincipals []string `json:"producerPrincipals,omitempty" yaml:"producerPrincipals,omitempty"`
+}
+
+// ApplyDefaults applies default values to unset fields.
+func (c *WorkQueueConfig) ApplyDefaults() {
+	if c.Mode == "" {
+		c.Mode = "agent"
+	}
+	if c.VisibilityTimeoutSeconds == 0 {
+		c.VisibilityTimeoutSeconds = 900
+	}
+	if c.MaxReceiveCount == 0 {
+		c.MaxReceiveCount = 3
+	}
+	if c.BatchSize == 0 {
+		c.BatchSize = 1
+	}
+}
+
+// Validate validates the WorkQueueConfig against the stack configuration.
+func (c *WorkQueueConfig) Validate(config iac.StackConfig) error {
+	if c.Mode != "agent" && c.Mode != "stack" {
+		return fmt.Errorf("workQueue.mode must be one of [agent stack]")
+	}
+	if c.VisibilityTimeoutSeconds < 30 || c.VisibilityTimeoutSeconds > 43200 {
+		return fmt.Errorf("workQueue.visibilityTimeoutSeconds must be between 30 and 43200")
+	}
+	if c.MaxReceiveCount < 1 || c.MaxReceiveCount > 1000 {
+		return fmt.Errorf("workQueue.maxReceiveCount must be between 1 and 1000")
+	}
+	if c.BatchSize < 1 || c.BatchSize > 10 {
+		return fmt.Errorf("workQueue.batchSize must be between 1 and 10")
+	}
+	if c.MaxConcurrency != 0 && (c.MaxConcurrency < 2 || c.MaxConcurrency > 1000) {
+		return fmt.Errorf("workQueue.maxConcurrency must be 0 or between 2 and 1000")
+	}
+	for _, principal := range c.ProducerPrincipals {
+		if !strings.HasPrefix(principal, "arn:") {
+			return fmt.Errorf("workQueue.producerPrincipals: '%s' is not an ARN", principal)
+		}
+	}
+	return validateAgentNames("workQueue.agents", c.Agents, config)
+}
+
+// defaultAgent returns the stack's default agent, or its first agent.
+func (s *AgentCoreStack) defaultAgent() string {
+	for _, agent := range s.Config.Agents {
+		if agent.IsDefault {
+			return agent.Name
+		}
+	}
+	return s.Config.Agents[0].Name
+}
+
+// createWorkQueues creates the work queues, their dead-letter queues and the
+// consumer that invokes the agents. It runs after the runtimes are created.
+func (s *AgentCoreStack) createWorkQueues(ctx *pulumi.Context, tags pulumi.StringMap) error {
+	stackName := s.Config.StackName
+	cfg := s.Options.WorkQueue
+	agents := s.runtimeAgents(cfg.Agents)
+
+	// Queues are keyed by agent name, or by the stack name in stack mode
+	queueNames := agents
+	if cfg.Mode == "stack" {
+		queueNames = []string{stackName}
+	}
+
+	var queueArns, runtimeArns pulumi.StringArray
+	queueAgents := make(map[string]pulumi.StringOutput)
+	queues := make(map[string]*sqs.Queue)
+	var resourceNames []string
+	for _, name := range queueNames {
+		resourceName := fmt.Sprintf("%s-work", name)
+		queueName := fmt.Sprintf("%s-%s-work", stackName, name)
+		if cfg.Mode == "stack" {
+			resourceName = "work"
+			queueName = fmt.Sprintf("%s-work", stackName)
+		}
+
+		dlq, err := sqs.NewQueue(ctx, s.ResourceName(resourceName+"-dlq"), &sqs.QueueArgs{
+			Name:                    pulumi.String(queueName + "-dlq"),
+			MessageRetentionSeconds: pulumi.Int(14 * 24 * 60 * 60),
+			SqsManagedSseEnabled:    pulumi.Bool(true),
+			Tags:                    mergeTags(tags, pulumi.String(queueName+"-dlq")),
+		}, s.child())
+		if err != nil {
+			return err
+		}
+		redrivePolicy := dlq.Arn.ApplyT(func(arn string) (string, error) {
+			data, err := json.Marshal(map[string]interface{}{
+				"deadLetterTargetArn": arn,
+				"maxReceiveCount":     cfg.MaxReceiveCount,
+			})
+			return string(data), err
+		}).(pulumi.StringOutput)
+
+		queueTags := tags
+		if cfg.Mode == "agent" {
+			queueTags = s.agentTags(name, tags)
+		}
+		queue, err := sqs.NewQueue(ctx, s.ResourceName(resourceName+"-queue"), &sqs.QueueArgs{
+			Name:                     pulumi.String(queueName),
+			VisibilityTimeoutSeconds: pulumi.Int(cfg.VisibilityTimeoutSeconds),
+			RedrivePolicy:            redrivePolicy,
+			SqsManagedSseEnabled:     pulumi.Bool(true),
+			Tags:                     mergeTags(queueTags, pulumi.String(queueName)),
+		}, s.child())
+		if err != nil {
+			return err
+		}
+		s.WorkQueues[name] = queue.Url
+		queueArns = append(queueArns, queue.Arn)
+		queues[resourceName] = queue
+		resourceNames = append(resourceNames, resourceName)
+		if cfg.Mode == "agent" {
+			queueAgents[name] = queue.Arn
+		}
+
+		if len(cfg.ProducerPrincipals) > 0 {
+			policy := queue.Arn.ApplyT(func(arn string) (string, error) {
+				return NewPolicyDocument(Statement{
+					Effect:    "Allow",
+					Principal: map[string][]string{"AWS": cfg.ProducerPrincipals},
+					Action:    []string{"sqs:SendMessage", "sqs:GetQueueUrl", "sqs:GetQueueAttributes"},
+					Resource:  []string{arn},
+				}).JSON()
+			}).(pulumi.StringOutput)
+			_, err = sqs.NewQueuePolicy(ctx, s.ResourceName(resourceName+"-queue-policy"), &sqs.QueuePolicyArgs{
+				QueueUrl: queue.Url,
+				Policy:   policy,
+			}, s.child())
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// Consumer configuration: queue ARNs to agents, and agents to runtimes
+	agentNames := make([]string, 0, len(queueAgents))
+	for name := range queueAgents {
+		agentNames = append(agentNames, name)
+	}
+	sort.Strings(agentNames)
+	queueAgentInputs := make([]interface{}, len(agentNames))
+	for i, name := range agentNames {
+		queueAgentInputs[i] = queueAgents[name]
+	}
+	queueAgentsJSON := pulumi.All(queueAgentInputs...).ApplyT(func(arns []interface{}) (string, error) {
+		mapping := make(map[string]string, len(arns))
+		for i, arn := range arns {
+			mapping[arn.(string)] = agentNames[i]
+		}
+		data, err := json.Marshal(mapping)
+		return string(data), err
+	}).(pulumi.StringOutput)
+
+	var runtimeInputs []interface{}
+	var runtimeAgents []string
+	for _, agentName := range agents {
+		for _, name := range s.agentRuntimeNames(agentName) {
+			arn := s.AgentRuntimes[name].RuntimeArn
+			runtimeInputs = append(runtimeInputs, arn)
+			runtimeAgents = append(runtimeAgents, agentName)
+			runtimeArns = append(runtimeArns, arn, pulumi.Sprintf("%s/*", arn))
+		}
+	}
+	runtimesJSON := pulumi.All(runtimeInputs...).ApplyT(func(arns []interface{}) (string, error) {
+		mapping := make(map[string][]string)
+		for i, arn := range arns {
+			mapping[runtimeAgents[i]] = append(mapping[runtimeAgents[i]], arn.(string))
+		}
+		data, err := json.Marshal(mapping)
+		return string(data), err
+	}).(pulumi.StringOutput)
+
+	defaultAgent := s.defaultAgent()
+	if !slices.Contains(agents, defaultAgent) {
+		defaultAgent = agents[0]
+	}
+	function, err := s.newInlineFunction(ctx, "work-queue-consumer", workQueueConsumerHandler,
+		min(cfg.VisibilityTimeoutSeconds, 900),
+		pulumi.StringMap{
+			"QUEUE_AGENTS":   queueAgentsJSON,
+			"AGENT_RUNTIMES": runtimesJSON,
+			"DEFAULT_AGENT":  pulumi.String(defaultAgent),
+			"QUALIFIER":      pulumi.String(runtimeEndpointName),
+		}, tags,
+		policyStatement{
+			Actions:   []string{"bedrock-agentcore:InvokeAgentRuntime"},
+			Resources: runtimeArns,
+		},
+		policyStatement{
+			Actions:   []string{"sqs:ReceiveMessage", "sqs:DeleteMessage", "sqs:GetQueueAttributes"},
+			Resources: queueArns,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, resourceName := range resourceNames {
+		args := &lambda.EventSourceMappingArgs{
+			EventSourceArn:        queues[resourceName].Arn,
+			FunctionName:          function.Arn,
+			BatchSize:             pulumi.Int(cfg.BatchSize),
+			FunctionResponseTypes: pulumi.StringArray{pulumi.String("ReportBatchItemFailures")},
+		}
+		if cfg.MaxConcurrency > 0 {
+			args.ScalingConfig = &lambda.EventSourceMappingScalingConfigArgs{
+				MaximumConcurrency: pulumi.Int(cfg.MaxConcurrency),
+			}
+		}
+		_, err := lambda.NewEventSourceMapping(ctx, s.ResourceName(resourceName+"-consumer"), args, s.child())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+// Command agentkit-pulumi generates deployment projects for agentkit agents.
+//
+// Usage:
+//
+//	agentkit-pulumi init [-dir DIR] [-force]
+//	agentkit-pulumi validate FILE...
+//	agentkit-pulumi schema
+//
+// init asks about the agents, runtime target, networking mode and
+// observability provider, and writes a ready-to-run Pulumi Go program with
+// its configuration files to DIR.
+//
+// validate checks JSON or YAML stack config files, reporting unknown
+// fields and mistyped values with their positions, and exits non-zero if
+// any file is invalid. schema prints the JSON Schema of the config files.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/plexusone/agentkit-aws-pulumi/agentcore"
+	"github.com/plexusone/agentkit-aws-pulumi/agentcore/scaffold"
+)
+
+// usage describes the commands.
+const usage = `usage:
+  agentkit-pulumi init [-dir DIR] [-force]
+  agentkit-pulumi validate FILE...
+  agentkit-pulumi schema`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	var err error
+	switch os.Args[1] {
+	case "init":
+		err = runInit(os.Args[2:])
+	case "validate":
+		err = runValidate(os.Args[2:])
+	case "schema":
+		_, err = os.Stdout.Write(agentcore.StackConfigSchema())
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "agentkit-pulumi:", err)
+		os.Exit(1)
+	}
+}
+
+// runValidate runs the validate command.
+func runValidate(paths []string) error {
+	if len(paths) == 0 {
+		return fmt.Errorf("validate needs at least one config file")
+	}
+	invalid := 0
+	for _, path := range paths {
+		if err := agentcore.ValidateConfigFile(path); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			invalid++
+			continue
+		}
+		fmt.Printf("%s: ok\n", path)
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d config files are invalid", invalid, len(paths))
+	}
+	return nil
+}
+
+// runInit runs the init command.
+func runInit(args []string) error {
+	flags := flag.NewFlagSet("init", flag.ExitOnError)
+	dir := flags.String("dir", "", "project directory (default: the project name)")
+	force := flags.Bool("force", false, "overwrite existing files")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	answers, err := scaffold.Ask(os.Stdin, os.Stdout)
+	if err != nil {
+		return err
+	}
+	files, err := scaffold.Generate(*answers)
+	if err != nil {
+		return err
+	}
+	if *dir == "" {
+		*dir = answers.Name
+	}
+	if err := scaffold.Write(*dir, files, *force); err != nil {
+		return err
+	}
+
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	fmt.Printf("\nCreated %s:\n", *dir)
+	for _, path := range paths {
+		fmt.Printf("  %s\n", filepath.Join(*dir, path))
+	}
+
+	fmt.Printf("\nNext steps:\n  cd %s\n  go mod tidy\n", *dir)
+	if answers.RuntimeTarget == scaffold.TargetLightsail {
+		fmt.Println("  go run .")
+	} else {
+		fmt.Printf("  pulumi stack init %s\n  pulumi up\n", answers.Name)
+	}
+	return nil
+}
@@ -0,0 +1,376 @@
+package pulumi
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/events"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+)
+
+// ApprovalOptions gates updates and destroys on an approval. Before the
+// operation, a preview's change summary and a hash of its steps are sent to
+// the Notifier, and the operation runs only once WaitForToken returns a
+// token signed for the request.
+//
+// Approval is requested before the stack's lock is acquired, so a pending
+// approval does not block other runs. Under the lock, the operation is
+// previewed again and runs only if its steps match the approved hash.
+type ApprovalOptions struct {
+	// Notifier posts approval requests, e.g. a WebhookNotifier,
+	// SNSNotifier or JiraNotifier.
+	Notifier Notifier
+
+	// WaitForToken blocks until an approver supplies a token for the
+	// request, e.g. ReadToken(os.Stdin).
+	WaitForToken func(ctx context.Context, req ApprovalRequest) (string, error)
+
+	// Secret signs approval tokens. Approvers compute a request's token
+	// with ApprovalToken.
+	Secret []byte
+
+	// Timeout is how long to wait for a token.
+	// Default: 24 hours
+	Timeout time.Duration
+
+	// SkipUnchanged runs operations without approval when the preview has
+	// no creates, updates or deletes.
+	SkipUnchanged bool
+}
+
+// ApprovalRequest describes a pending change to a stack.
+type ApprovalRequest struct {
+	// ID identifies the request. Tokens are signed for it.
+	ID string `json:"id"`
+
+	// Operation is "update" or "destroy".
+	Operation string `json:"operation"`
+
+	// ProjectName is the Pulumi project name.
+	ProjectName string `json:"projectName"`
+
+	// StackName is the fully qualified stack name.
+	StackName string `json:"stackName"`
+
+	// Summary is the previewed change summary.
+	Summary ResultSummary `json:"summary"`
+
+	// PlanHash is a SHA-256 hash of the previewed steps and the options
+	// that selected them. Tokens are signed for it, so a token only
+	// approves the changes that were previewed.
+	PlanHash string `json:"planHash"`
+
+	// RequestedAt is when approval was requested.
+	RequestedAt time.Time `json:"requestedAt"`
+}
+
+// Text renders the request as a human-readable message.
+func (r ApprovalRequest) Text() string {
+	return fmt.Sprintf("Approval requested for %s of %s/%s\n\nCreate: %d\nUpdate: %d\nDelete: %d\nUnchanged: %d\n\nRequest ID: %s\nPlan hash: %s\n",
+		r.Operation, r.ProjectName, r.StackName,
+		r.Summary.Create, r.Summary.Update, r.Summary.Delete, r.Summary.Same, r.ID, r.PlanHash)
+}
+
+// Notifier posts approval requests to approvers.
+type Notifier interface {
+	Notify(ctx context.Context, req ApprovalRequest) error
+}
+
+// ErrNotApproved is returned when the token supplied for a request is not
+// valid.
+var ErrNotApproved = errors.New("pulumi: change not approved")
+
+// ErrPlanChanged is returned when the changes previewed under the stack's
+// lock differ from the approved changes.
+var ErrPlanChanged = errors.New("pulumi: changes differ from the approved preview")
+
+// ApprovalToken returns the token that approves a request's previewed
+// changes.
+func ApprovalToken(secret []byte, requestID, planHash string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(requestID))
+	mac.Write([]byte{0})
+	mac.Write([]byte(planHash))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ReadToken returns a WaitForToken function that reads a token from a line
+// of r, such as an operator's terminal.
+func ReadToken(r io.Reader) func(ctx context.Context, req ApprovalRequest) (string, error) {
+	reader := bufio.NewReader(r)
+	return func(ctx context.Context, req ApprovalRequest) (string, error) {
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("pulumi: failed to read approval token: %w", err)
+		}
+		return strings.TrimSpace(line), nil
+	}
+}
+
+// changePreview is a previewed operation's change summary and a hash of
+// its steps.
+type changePreview struct {
+	Summary ResultSummary
+	Hash    string
+}
+
+// previewStep is the part of a previewed step that its hash covers.
+type previewStep struct {
+	Op     apitype.OpType         `json:"op"`
+	URN    string                 `json:"urn"`
+	Keys   []string               `json:"keys,omitempty"`
+	Diffs  []string               `json:"diffs,omitempty"`
+	Inputs map[string]interface{} `json:"inputs,omitempty"`
+}
+
+// hashSteps reads a preview's engine events until the channel is closed
+// and sends a hash of the steps that change resources and of the options
+// the preview ran with.
+func hashSteps(stream <-chan events.EngineEvent, options interface{}) <-chan string {
+	hash := make(chan string, 1)
+	go func() {
+		var steps []previewStep
+		for event := range stream {
+			if event.ResourcePreEvent == nil {
+				continue
+			}
+			meta := event.ResourcePreEvent.Metadata
+			if meta.Op == apitype.OpSame {
+				continue
+			}
+			step := previewStep{Op: meta.Op, URN: meta.URN, Keys: meta.Keys, Diffs: meta.Diffs}
+			if meta.New != nil {
+				step.Inputs = meta.New.Inputs
+			}
+			steps = append(steps, step)
+		}
+		// Steps are reported in the order the engine runs them, which
+		// varies with parallelism
+		sort.Slice(steps, func(i, j int) bool {
+			if steps[i].URN != steps[j].URN {
+				return steps[i].URN < steps[j].URN
+			}
+			return steps[i].Op < steps[j].Op
+		})
+		data, _ := json.Marshal(struct {
+			Options interface{}   `json:"options,omitempty"`
+			Steps   []previewStep `json:"steps"`
+		}{options, steps})
+		sum := sha256.Sum256(data)
+		hash <- hex.EncodeToString(sum[:])
+	}()
+	return hash
+}
+
+// requestApproval previews the operation, posts an approval request and
+// waits for its token. It returns the hash of the approved steps.
+func (s *Stack) requestApproval(ctx context.Context, operation string, preview func(context.Context) (changePreview, error)) (string, error) {
+	gate := s.opts.Approval
+	if gate.Notifier == nil || gate.WaitForToken == nil || len(gate.Secret) == 0 {
+		return "", fmt.Errorf("pulumi: approval requires a notifier, a token source and a secret")
+	}
+
+	changes, err := preview(ctx)
+	if err != nil {
+		return "", err
+	}
+	summary := changes.Summary
+	if gate.SkipUnchanged && summary.Create+summary.Update+summary.Delete == 0 {
+		return changes.Hash, nil
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("pulumi: failed to generate approval request ID: %w", err)
+	}
+	req := ApprovalRequest{
+		ID:          hex.EncodeToString(id),
+		Operation:   operation,
+		ProjectName: s.opts.ProjectName,
+		StackName:   s.opts.StackName,
+		Summary:     summary,
+		PlanHash:    changes.Hash,
+		RequestedAt: time.Now().UTC(),
+	}
+	if err := gate.Notifier.Notify(ctx, req); err != nil {
+		return "", fmt.Errorf("pulumi: failed to post approval request: %w", err)
+	}
+
+	timeout := gate.Timeout
+	if timeout == 0 {
+		timeout = 24 * time.Hour
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		token string
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		token, err := gate.WaitForToken(waitCtx, req)
+		done <- result{token, err}
+	}()
+
+	select {
+	case <-waitCtx.Done():
+		return "", fmt.Errorf("pulumi: waiting for approval of request %s: %w", req.ID, waitCtx.Err())
+	case r := <-done:
+		if r.err != nil {
+			return "", fmt.Errorf("pulumi: waiting for approval of request %s: %w", req.ID, r.err)
+		}
+		if !hmac.Equal([]byte(r.token), []byte(ApprovalToken(gate.Secret, req.ID, req.PlanHash))) {
+			return "", fmt.Errorf("%w: invalid token for request %s", ErrNotApproved, req.ID)
+		}
+	}
+	return req.PlanHash, nil
+}
+
+// verifyPlan previews the operation again and checks that its steps are
+// the approved ones. It runs under the stack's lock, after approval.
+func verifyPlan(ctx context.Context, approved string, preview func(context.Context) (changePreview, error)) error {
+	changes, err := preview(ctx)
+	if err != nil {
+		return err
+	}
+	if changes.Hash != approved {
+		return fmt.Errorf("%w: request approval again", ErrPlanChanged)
+	}
+	return nil
+}
+
+// WebhookNotifier posts approval requests as JSON to a URL.
+type WebhookNotifier struct {
+	// URL receives a POST of the ApprovalRequest.
+	URL string
+
+	// Headers are added to the request, e.g. Authorization.
+	Headers map[string]string
+
+	// Client sends the request.
+	// Default: http.DefaultClient
+	Client *http.Client
+}
+
+// Notify posts the request.
+func (n *WebhookNotifier) Notify(ctx context.Context, req ApprovalRequest) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, n.Client, n.URL, n.Headers, body)
+}
+
+// SNSNotifier publishes approval requests to an SNS topic as text, for
+// email and chat subscribers.
+type SNSNotifier struct {
+	// AWSConfig is the configuration of the SNS client.
+	AWSConfig aws.Config
+
+	// TopicARN is the topic approvers subscribe to.
+	TopicARN string
+}
+
+// Notify publishes the request.
+func (n *SNSNotifier) Notify(ctx context.Context, req ApprovalRequest) error {
+	_, err := sns.NewFromConfig(n.AWSConfig).Publish(ctx, &sns.PublishInput{
+		TopicArn: aws.String(n.TopicARN),
+		Subject:  aws.String(truncate(fmt.Sprintf("Approve %s of %s", req.Operation, req.StackName), 100)),
+		Message:  aws.String(req.Text()),
+	})
+	return err
+}
+
+// JiraNotifier opens a Jira issue for each approval request. The request
+// ID is in the issue description, so approvers can compute its token.
+type JiraNotifier struct {
+	// BaseURL is the Jira site, e.g. "https://example.atlassian.net".
+	BaseURL string
+
+	// ProjectKey is the project the issue is created in.
+	ProjectKey string
+
+	// IssueType is the issue type.
+	// Default: "Task"
+	IssueType string
+
+	// User and APIToken authenticate with basic authentication.
+	User     string
+	APIToken string
+
+	// Client sends the request.
+	// Default: http.DefaultClient
+	Client *http.Client
+}
+
+// Notify creates the issue.
+func (n *JiraNotifier) Notify(ctx context.Context, req ApprovalRequest) error {
+	issueType := n.IssueType
+	if issueType == "" {
+		issueType = "Task"
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"fields": map[string]interface{}{
+			"project":     map[string]string{"key": n.ProjectKey},
+			"issuetype":   map[string]string{"name": issueType},
+			"summary":     fmt.Sprintf("Approve %s of %s/%s", req.Operation, req.ProjectName, req.StackName),
+			"description": req.Text(),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(n.User+":"+n.APIToken))
+	return postJSON(ctx, n.Client, strings.TrimRight(n.BaseURL, "/")+"/rest/api/2/issue",
+		map[string]string{"Authorization": auth}, body)
+}
+
+// postJSON posts a JSON body and fails on a non-2xx response.
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body []byte) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		httpReq.Header.Set(k, v)
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s returned %s: %s", url, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// truncate shortens s to at most n bytes.
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,328 @@
+package pulumi
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+)
+
+// Attributes of a lock item. The table's partition key must be a string
+// attribute named LockKeyAttribute. Enabling DynamoDB TTL on
+// lockExpiresAttribute removes abandoned locks from the table; recovery
+// does not depend on it.
+const (
+	LockKeyAttribute      = "LockID"
+	lockOwnerAttribute    = "Owner"
+	lockExpiresAttribute  = "ExpiresAt"
+	lockAcquiredAttribute = "AcquiredAt"
+)
+
+// LockOptions configures a DynamoDB lock that prevents overlapping
+// operations against the same stack. Stack keys the lock on the fully
+// qualified organization/project/stack name.
+type LockOptions struct {
+	// AWSConfig is the configuration of the DynamoDB client.
+	AWSConfig aws.Config
+
+	// TableName is the DynamoDB table holding the locks.
+	TableName string
+
+	// Owner identifies the holder of the lock in error messages.
+	// Default: "<hostname>-<pid>-<random>"
+	Owner string
+
+	// TTL is how long a lock is held without a heartbeat. A lock that is
+	// not renewed within TTL, for example because its holder crashed, is
+	// stale and taken over by the next operation.
+	// Default: 5 minutes
+	TTL time.Duration
+
+	// Wait is how long to wait for a held lock to be released before
+	// failing. Zero fails immediately.
+	Wait time.Duration
+}
+
+// applyDefaults applies default values to unset fields.
+func (o *LockOptions) applyDefaults() {
+	if o.TTL == 0 {
+		o.TTL = 5 * time.Minute
+	}
+	if o.Owner == "" {
+		o.Owner = defaultLockOwner()
+	}
+}
+
+// LockedError is returned when another operation holds the stack's lock.
+type LockedError struct {
+	// StackName is the locked stack.
+	StackName string
+
+	// Owner is the holder of the lock.
+	Owner string
+
+	// ExpiresAt is when the lock becomes stale unless renewed.
+	ExpiresAt time.Time
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("pulumi: stack %q is locked by %s until %s",
+		e.StackName, e.Owner, e.ExpiresAt.Format(time.RFC3339))
+}
+
+// Lock is a held stack lock. It is renewed in the background until
+// released.
+type Lock struct {
+	client    *dynamodb.Client
+	table     string
+	stackName string
+	owner     string
+	ttl       time.Duration
+
+	stop chan struct{}
+	done chan struct{}
+
+	mu      sync.Mutex
+	lostErr error
+}
+
+// AcquireLock acquires the lock of a stack, taking over a stale lock. It
+// waits up to opts.Wait for a lock held by another owner. stackName should
+// be the fully qualified organization/project/stack name.
+func AcquireLock(ctx context.Context, stackName string, opts LockOptions) (*Lock, error) {
+	if opts.TableName == "" {
+		return nil, fmt.Errorf("pulumi: lock table name is required")
+	}
+	opts.applyDefaults()
+
+	l := &Lock{
+		client:    dynamodb.NewFromConfig(opts.AWSConfig),
+		table:     opts.TableName,
+		stackName: stackName,
+		owner:     opts.Owner,
+		ttl:       opts.TTL,
+	}
+
+	deadline := time.Now().Add(opts.Wait)
+	for {
+		err := l.put(ctx, false)
+		if err == nil {
+			break
+		}
+		var locked *LockedError
+		if !errors.As(err, &locked) || !time.Now().Before(deadline) {
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(min(time.Until(deadline), 5*time.Second)):
+		}
+	}
+
+	l.stop = make(chan struct{})
+	l.done = make(chan struct{})
+	go l.heartbeat()
+	return l, nil
+}
+
+// put writes the lock item. Unless renewing, it succeeds only if the lock
+// is free, stale or already held by this owner; when renewing it succeeds
+// only if this owner still holds the lock.
+func (l *Lock) put(ctx context.Context, renew bool) error {
+	now := time.Now()
+	condition := "attribute_not_exists(#key) OR #expires < :now OR #owner = :owner"
+	if renew {
+		condition = "#owner = :owner"
+	}
+	names := map[string]string{"#owner": lockOwnerAttribute}
+	values := map[string]types.AttributeValue{
+		":owner": &types.AttributeValueMemberS{Value: l.owner},
+	}
+	if !renew {
+		names["#key"] = LockKeyAttribute
+		names["#expires"] = lockExpiresAttribute
+		values[":now"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Unix(), 10)}
+	}
+
+	_, err := l.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(l.table),
+		Item: map[string]types.AttributeValue{
+			LockKeyAttribute:      &types.AttributeValueMemberS{Value: l.stackName},
+			lockOwnerAttribute:    &types.AttributeValueMemberS{Value: l.owner},
+			lockExpiresAttribute:  &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Add(l.ttl).Unix(), 10)},
+			lockAcquiredAttribute: &types.AttributeValueMemberS{Value: now.UTC().Format(time.RFC3339)},
+		},
+		ConditionExpression:                 aws.String(condition),
+		ExpressionAttributeNames:            names,
+		ExpressionAttributeValues:           values,
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	})
+	var failed *types.ConditionalCheckFailedException
+	if errors.As(err, &failed) {
+		return lockedError(l.stackName, failed.Item)
+	}
+	if err != nil {
+		return fmt.Errorf("pulumi: failed to write lock: %w", err)
+	}
+	return nil
+}
+
+// heartbeat renews the lock every third of its TTL until released.
+func (l *Lock) heartbeat() {
+	defer close(l.done)
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), l.ttl/3)
+			err := l.put(ctx, true)
+			cancel()
+			var locked *LockedError
+			if errors.As(err, &locked) {
+				l.mu.Lock()
+				l.lostErr = fmt.Errorf("pulumi: lost lock on stack %q: %w", l.stackName, err)
+				l.mu.Unlock()
+				return
+			}
+		}
+	}
+}
+
+// Err returns an error if the lock was taken over by another owner while
+// held, which happens when renewals fail for longer than the TTL.
+func (l *Lock) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lostErr
+}
+
+// Release stops renewing the lock and deletes it if this owner still
+// holds it.
+func (l *Lock) Release(ctx context.Context) error {
+	close(l.stop)
+	<-l.done
+
+	_, err := l.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(l.table),
+		Key: map[string]types.AttributeValue{
+			LockKeyAttribute: &types.AttributeValueMemberS{Value: l.stackName},
+		},
+		ConditionExpression:       aws.String("#owner = :owner"),
+		ExpressionAttributeNames:  map[string]string{"#owner": lockOwnerAttribute},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":owner": &types.AttributeValueMemberS{Value: l.owner}},
+	})
+	var failed *types.ConditionalCheckFailedException
+	if errors.As(err, &failed) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("pulumi: failed to release lock: %w", err)
+	}
+	return nil
+}
+
+// ForceUnlock deletes a stack's lock regardless of its owner. Use it only
+// when the holder is known to have stopped. stackName is the fully
+// qualified organization/project/stack name the lock is keyed on.
+func ForceUnlock(ctx context.Context, stackName string, opts LockOptions) error {
+	client := dynamodb.NewFromConfig(opts.AWSConfig)
+	_, err := client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(opts.TableName),
+		Key: map[string]types.AttributeValue{
+			LockKeyAttribute: &types.AttributeValueMemberS{Value: stackName},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("pulumi: failed to force unlock: %w", err)
+	}
+	return nil
+}
+
+// lockedError describes the lock item that failed a condition check.
+func lockedError(stackName string, item map[string]types.AttributeValue) error {
+	err := &LockedError{StackName: stackName, Owner: "another owner"}
+	if v, ok := item[lockOwnerAttribute].(*types.AttributeValueMemberS); ok {
+		err.Owner = v.Value
+	}
+	if v, ok := item[lockExpiresAttribute].(*types.AttributeValueMemberN); ok {
+		if secs, perr := strconv.ParseInt(v.Value, 10, 64); perr == nil {
+			err.ExpiresAt = time.Unix(secs, 0)
+		}
+	}
+	return err
+}
+
+// defaultLockOwner identifies this process.
+func defaultLockOwner() string {
+	host, _ := os.Hostname()
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(b))
+}
+
+// withLock runs fn while holding the stack's lock if one is configured.
+func (s *Stack) withLock(ctx context.Context, fn func() error) (err error) {
+	if s.opts.Lock == nil {
+		return fn()
+	}
+	key, err := s.lockKey(ctx)
+	if err != nil {
+		return err
+	}
+	lock, err := AcquireLock(ctx, key, *s.opts.Lock)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if rerr := lock.Release(context.WithoutCancel(ctx)); rerr != nil && err == nil {
+			err = rerr
+		}
+		if lerr := lock.Err(); lerr != nil && err == nil {
+			err = lerr
+		}
+	}()
+	return fn()
+}
+
+// lockKey returns the organization/project/stack name the stack's lock is
+// keyed on, so stacks of the same name in other projects or organizations
+// sharing a lock table do not block each other.
+func (s *Stack) lockKey(ctx context.Context) (string, error) {
+	name := s.stack.Name()
+	if strings.Count(name, "/") == 2 {
+		return name, nil
+	}
+	info, err := s.stack.Info(ctx)
+	if err != nil {
+		return "", fmt.Errorf("pulumi: failed to get stack info: %w", err)
+	}
+	// Pulumi Cloud reports the stack's console URL, which ends in
+	// organization/project/stack.
+	if u, perr := url.Parse(info.URL); perr == nil && strings.HasPrefix(u.Scheme, "http") {
+		if parts := strings.Split(strings.Trim(u.Path, "/"), "/"); len(parts) >= 3 {
+			return strings.Join(parts[len(parts)-3:], "/"), nil
+		}
+	}
+	// Self-managed backends use the fixed organization "organization".
+	org := "organization"
+	if i := strings.Index(name, "/"); i >= 0 {
+		org, name = name[:i], name[i+1:]
+	}
+	return auto.FullyQualifiedStackName(org, s.opts.ProjectName, name), nil
+}
@@ -0,0 +1,88 @@
+package pulumi
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// Export returns the stack's deployment state with secrets decrypted,
+// so it can be imported into a backend with a different secrets provider.
+func (s *Stack) Export(ctx context.Context) (apitype.UntypedDeployment, error) {
+	state, err := s.stack.Export(ctx)
+	if err != nil {
+		return apitype.UntypedDeployment{}, fmt.Errorf("pulumi export failed: %w", err)
+	}
+	return state, nil
+}
+
+// Import replaces the stack's deployment state. Secrets in plaintext are
+// encrypted with the stack's secrets provider.
+func (s *Stack) Import(ctx context.Context, state apitype.UntypedDeployment) error {
+	if err := s.withLock(ctx, func() error { return s.stack.Import(ctx, state) }); err != nil {
+		return fmt.Errorf("pulumi import failed: %w", err)
+	}
+	return nil
+}
+
+// Migrate copies a stack's state and configuration from one backend to
+// another without touching the deployed resources.
+//
+// The destination stack is created if it does not exist. If its project or
+// stack name differs from the source, resource URNs are rewritten so the
+// program's resources map onto the existing state instead of being
+// recreated. Run a refresh against the destination before the first update.
+func Migrate(ctx context.Context, source, destination StackOptions, program pulumi.RunFunc) (*Stack, error) {
+	src, err := NewStack(ctx, source, program)
+	if err != nil {
+		return nil, fmt.Errorf("pulumi: failed to open source stack: %w", err)
+	}
+	defer func() { _ = src.Close() }()
+
+	state, err := src.Export(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := src.stack.GetAllConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("pulumi: failed to read source config: %w", err)
+	}
+
+	dst, err := NewStack(ctx, destination, program)
+	if err != nil {
+		return nil, fmt.Errorf("pulumi: failed to open destination stack: %w", err)
+	}
+
+	if err := dst.stack.SetAllConfig(ctx, config); err != nil {
+		_ = dst.Close()
+		return nil, fmt.Errorf("pulumi: failed to copy config: %w", err)
+	}
+
+	oldPrefix := urnPrefix(source)
+	newPrefix := urnPrefix(destination)
+	if oldPrefix != newPrefix {
+		state.Deployment = bytes.ReplaceAll(state.Deployment, []byte(oldPrefix), []byte(newPrefix))
+	}
+
+	if err := dst.Import(ctx, state); err != nil {
+		_ = dst.Close()
+		return nil, err
+	}
+
+	return dst, nil
+}
+
+// urnPrefix returns the URN prefix of resources in a stack. URNs include
+// the project and the stack's short name, but not the organization.
+func urnPrefix(opts StackOptions) string {
+	stackName := opts.StackName
+	if i := strings.LastIndex(stackName, "/"); i >= 0 {
+		stackName = stackName[i+1:]
+	}
+	return fmt.Sprintf("urn:pulumi:%s::%s::", stackName, opts.ProjectName)
+}
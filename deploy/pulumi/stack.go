@@ -7,8 +7,10 @@ import (
 	"os"
 
 	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/events"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/optdestroy"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/optpreview"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optrefresh"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/optup"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
@@ -42,6 +44,14 @@ type StackOptions struct {
 
 	// EnvVars are additional environment variables for the Pulumi process.
 	EnvVars map[string]string
+
+	// Lock, if set, holds a DynamoDB lock on the stack during up, destroy,
+	// refresh and import so overlapping runs against the stack fail.
+	Lock *LockOptions
+
+	// Approval, if set, requires an approval of the previewed changes
+	// before up and destroy.
+	Approval *ApprovalOptions
 }
 
 // Stack wraps a Pulumi automation stack with helper methods.
@@ -89,11 +99,16 @@ func NewStack(ctx context.Context, opts StackOptions, program pulumi.RunFunc) (*
 		}
 	}
 
-	// Create or select stack
-	stack, err := auto.UpsertStackInlineSource(ctx, opts.StackName, opts.ProjectName, program,
+	workspaceOpts := []auto.LocalWorkspaceOption{
 		auto.Project(project),
 		auto.WorkDir(workDir),
-	)
+	}
+	if opts.SecretsProvider != "" {
+		workspaceOpts = append(workspaceOpts, auto.SecretsProvider(opts.SecretsProvider))
+	}
+
+	// Create or select stack
+	stack, err := auto.UpsertStackInlineSource(ctx, opts.StackName, opts.ProjectName, program, workspaceOpts...)
 	if err != nil {
 		if isTempWD {
 			_ = os.RemoveAll(workDir)
@@ -123,9 +138,35 @@ func NewStack(ctx context.Context, opts StackOptions, program pulumi.RunFunc) (*
 	}, nil
 }
 
-// Up runs a Pulumi up (deploy) operation.
+// Up runs a Pulumi up (deploy) operation. With Options.Approval, the
+// update is previewed with the options that select its steps, such as
+// optup.Target and optup.Replace; optup.Plan cannot be approved.
 func (s *Stack) Up(ctx context.Context, opts ...optup.Option) (*UpResult, error) {
-	result, err := s.stack.Up(ctx, opts...)
+	var approved string
+	var previewUpdate func(context.Context) (changePreview, error)
+	if s.opts.Approval != nil {
+		plan, err := newUpdatePlanOptions(opts)
+		if err != nil {
+			return nil, fmt.Errorf("pulumi up failed: %w", err)
+		}
+		previewUpdate = func(ctx context.Context) (changePreview, error) {
+			return s.previewUpdate(ctx, plan)
+		}
+		if approved, err = s.requestApproval(ctx, "update", previewUpdate); err != nil {
+			return nil, fmt.Errorf("pulumi up failed: %w", err)
+		}
+	}
+
+	var result auto.UpResult
+	err := s.withLock(ctx, func() (err error) {
+		if s.opts.Approval != nil {
+			if err := verifyPlan(ctx, approved, previewUpdate); err != nil {
+				return err
+			}
+		}
+		result, err = s.stack.Up(ctx, opts...)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("pulumi up failed: %w", err)
 	}
@@ -153,18 +194,126 @@ func (s *Stack) Preview(ctx context.Context, opts ...optpreview.Option) (*Previe
 	}
 
 	return &PreviewResult{
-		Summary: ResultSummary{
-			Create: result.ChangeSummary["create"],
-			Update: result.ChangeSummary["update"],
-			Delete: result.ChangeSummary["delete"],
-			Same:   result.ChangeSummary["same"],
-		},
+		Summary: changeSummary(result),
+	}, nil
+}
+
+// updatePlanOptions are the up options that select an update's steps. They
+// are applied to the approval preview and covered by its hash.
+type updatePlanOptions struct {
+	Replace           []string `json:"replace,omitempty"`
+	Target            []string `json:"target,omitempty"`
+	TargetDependents  bool     `json:"targetDependents,omitempty"`
+	Exclude           []string `json:"exclude,omitempty"`
+	ExcludeDependents bool     `json:"excludeDependents,omitempty"`
+	Refresh           bool     `json:"refresh,omitempty"`
+	PolicyPacks       []string `json:"policyPacks,omitempty"`
+	PolicyPackConfigs []string `json:"policyPackConfigs,omitempty"`
+	ConfigFile        string   `json:"configFile,omitempty"`
+	RunProgram        *bool    `json:"runProgram,omitempty"`
+}
+
+// newUpdatePlanOptions reads the plan options from up options. Other
+// options, such as output streams, do not change the steps. An update
+// plan file has no preview equivalent, so it is rejected.
+func newUpdatePlanOptions(opts []optup.Option) (updatePlanOptions, error) {
+	var up optup.Options
+	for _, opt := range opts {
+		opt.ApplyOption(&up)
+	}
+	if up.Plan != "" {
+		return updatePlanOptions{}, fmt.Errorf("pulumi: optup.Plan cannot be combined with approval")
+	}
+	return updatePlanOptions{
+		Replace:           up.Replace,
+		Target:            up.Target,
+		TargetDependents:  up.TargetDependents,
+		Exclude:           up.Exclude,
+		ExcludeDependents: up.ExcludeDependents,
+		Refresh:           up.Refresh,
+		PolicyPacks:       up.PolicyPacks,
+		PolicyPackConfigs: up.PolicyPackConfigs,
+		ConfigFile:        up.ConfigFile,
+		RunProgram:        up.RunProgram,
 	}, nil
 }
 
+// previewOptions returns the preview options equivalent to the plan options.
+func (o updatePlanOptions) previewOptions() []optpreview.Option {
+	var opts []optpreview.Option
+	if len(o.Replace) > 0 {
+		opts = append(opts, optpreview.Replace(o.Replace))
+	}
+	if len(o.Target) > 0 {
+		opts = append(opts, optpreview.Target(o.Target))
+	}
+	if o.TargetDependents {
+		opts = append(opts, optpreview.TargetDependents())
+	}
+	if len(o.Exclude) > 0 {
+		opts = append(opts, optpreview.Exclude(o.Exclude))
+	}
+	if o.ExcludeDependents {
+		opts = append(opts, optpreview.ExcludeDependents())
+	}
+	if o.Refresh {
+		opts = append(opts, optpreview.Refresh())
+	}
+	if len(o.PolicyPacks) > 0 {
+		opts = append(opts, optpreview.PolicyPacks(o.PolicyPacks...))
+	}
+	if len(o.PolicyPackConfigs) > 0 {
+		opts = append(opts, optpreview.PolicyPackConfigs(o.PolicyPackConfigs...))
+	}
+	if o.ConfigFile != "" {
+		opts = append(opts, optpreview.ConfigFile(o.ConfigFile))
+	}
+	if o.RunProgram != nil {
+		opts = append(opts, optpreview.RunProgram(*o.RunProgram))
+	}
+	return opts
+}
+
+// previewUpdate previews an update with the plan options for approval.
+func (s *Stack) previewUpdate(ctx context.Context, plan updatePlanOptions) (changePreview, error) {
+	stream := make(chan events.EngineEvent)
+	hash := hashSteps(stream, plan)
+	result, err := s.stack.Preview(ctx, append(plan.previewOptions(), optpreview.EventStreams(stream))...)
+	if err != nil {
+		return changePreview{}, fmt.Errorf("pulumi preview failed: %w", err)
+	}
+	return changePreview{Summary: changeSummary(result), Hash: <-hash}, nil
+}
+
 // Destroy runs a Pulumi destroy operation.
 func (s *Stack) Destroy(ctx context.Context, opts ...optdestroy.Option) error {
-	_, err := s.stack.Destroy(ctx, opts...)
+	previewDestroy := func(ctx context.Context) (changePreview, error) {
+		stream := make(chan events.EngineEvent)
+		hash := hashSteps(stream, nil)
+		result, err := s.stack.PreviewDestroy(ctx, append(opts, optdestroy.EventStreams(stream))...)
+		if err != nil {
+			return changePreview{}, fmt.Errorf("pulumi preview destroy failed: %w", err)
+		}
+		return changePreview{Summary: changeSummary(result), Hash: <-hash}, nil
+	}
+
+	var approved string
+	if s.opts.Approval != nil {
+		var err error
+		if approved, err = s.requestApproval(ctx, "destroy", previewDestroy); err != nil {
+			return fmt.Errorf("pulumi destroy failed: %w", err)
+		}
+	}
+
+	err := s.withLock(ctx, func() error {
+		if s.opts.Approval != nil {
+			if err := verifyPlan(ctx, approved, previewDestroy); err != nil {
+				return err
+			}
+		}
+		_, err := s.stack.Destroy(ctx, opts...)
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("pulumi destroy failed: %w", err)
 	}
@@ -172,8 +321,11 @@ func (s *Stack) Destroy(ctx context.Context, opts ...optdestroy.Option) error {
 }
 
 // Refresh refreshes the stack state.
-func (s *Stack) Refresh(ctx context.Context) error {
-	_, err := s.stack.Refresh(ctx)
+func (s *Stack) Refresh(ctx context.Context, opts ...optrefresh.Option) error {
+	err := s.withLock(ctx, func() error {
+		_, err := s.stack.Refresh(ctx, opts...)
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("pulumi refresh failed: %w", err)
 	}
@@ -226,6 +378,16 @@ func (s ResultSummary) Total() int {
 	return s.Create + s.Update + s.Delete + s.Same
 }
 
+// changeSummary converts a preview's change counts.
+func changeSummary(result auto.PreviewResult) ResultSummary {
+	return ResultSummary{
+		Create: result.ChangeSummary["create"],
+		Update: result.ChangeSummary["update"],
+		Delete: result.ChangeSummary["delete"],
+		Same:   result.ChangeSummary["same"],
+	}
+}
+
 // convertOutputs converts Pulumi outputs to a string map.
 func convertOutputs(outputs auto.OutputMap) map[string]string {
 	result := make(map[string]string, len(outputs))